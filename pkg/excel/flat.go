@@ -0,0 +1,352 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package excel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FlatFormat format of the file which stores one record per line
+type FlatFormat string
+
+const (
+	// CSV comma separated values, the first line is the header of column keys
+	CSV FlatFormat = "csv"
+	// JSONL json lines, every line is a json object keyed by column keys
+	JSONL FlatFormat = "jsonl"
+)
+
+// IsFlatFormat check if the format is a supported flat file format
+func IsFlatFormat(format string) bool {
+	switch FlatFormat(format) {
+	case CSV, JSONL:
+		return true
+	default:
+		return false
+	}
+}
+
+// utf8BOM byte order mark that is added by some spreadsheet software when saving csv file
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// FlatFile equivalent to a csv or json lines file, it is read and written in the way of io stream record by record,
+// so that the memory usage does not grow with the file size
+type FlatFile struct {
+	filePath string
+	format   FlatFormat
+}
+
+// NewFlatFile create a flat file
+func NewFlatFile(filePath string, format FlatFormat) (*FlatFile, error) {
+	if filePath == "" {
+		return nil, errors.New("flat file path can not be empty")
+	}
+
+	if !IsFlatFormat(string(format)) {
+		return nil, fmt.Errorf("flat file format %s is invalid", format)
+	}
+
+	dirPath := filepath.Dir(filePath)
+	if _, err := os.Stat(dirPath); err != nil {
+		if err := os.MkdirAll(dirPath, os.ModeDir|os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FlatFile{filePath: filePath, format: format}, nil
+}
+
+// GetFormat get flat file format
+func (f *FlatFile) GetFormat() FlatFormat {
+	return f.format
+}
+
+// NewWriter create the file and return a writer, the header is the column keys of the records, csv file writes it
+// as the first line, json lines file only writes these keys of each record
+func (f *FlatFile) NewWriter(header []string) (*FlatWriter, error) {
+	if len(header) == 0 {
+		return nil, errors.New("flat file header can not be empty")
+	}
+
+	file, err := os.Create(f.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &FlatWriter{file: file, buf: bufio.NewWriter(file), format: f.format, header: header}
+	switch f.format {
+	case CSV:
+		w.csv = csv.NewWriter(w.buf)
+		if err := w.csv.Write(header); err != nil {
+			file.Close()
+			return nil, err
+		}
+	case JSONL:
+		w.encoder = json.NewEncoder(w.buf)
+		w.encoder.SetEscapeHTML(false)
+	}
+
+	return w, nil
+}
+
+// NewReader return a reader which reads the file record by record
+func (f *FlatFile) NewReader() (*FlatReader, error) {
+	file, err := os.Open(f.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &FlatReader{file: file, format: f.format}
+	reader := bufio.NewReader(file)
+	if bom, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		if _, err := reader.Discard(len(utf8BOM)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	switch f.format {
+	case CSV:
+		r.csv = csv.NewReader(reader)
+		r.csv.FieldsPerRecord = -1
+		r.csv.LazyQuotes = true
+
+		header, err := r.csv.Read()
+		if err != nil && err != io.EOF {
+			file.Close()
+			return nil, err
+		}
+
+		r.header = make([]string, len(header))
+		for idx, key := range header {
+			r.header[idx] = strings.TrimSpace(key)
+		}
+	case JSONL:
+		r.buf = reader
+	}
+
+	return r, nil
+}
+
+// Clean delete the file
+func (f *FlatFile) Clean() error {
+	return os.Remove(f.filePath)
+}
+
+// FlatWriter write records to flat file in the way of io stream
+type FlatWriter struct {
+	file    *os.File
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	encoder *json.Encoder
+	format  FlatFormat
+	header  []string
+}
+
+// Write a record to the file, values of the keys which are not in the header are ignored. csv file stores the
+// string, number and bool values as they are, and stores the other values as json
+func (w *FlatWriter) Write(record map[string]interface{}) error {
+	if w.format == CSV {
+		row := make([]string, len(w.header))
+		for idx, key := range w.header {
+			cell, err := toCSVCell(record[key])
+			if err != nil {
+				return fmt.Errorf("convert value of %s to csv cell failed, err: %v", key, err)
+			}
+			row[idx] = cell
+		}
+
+		return w.csv.Write(row)
+	}
+
+	line := make(map[string]interface{}, len(w.header))
+	for _, key := range w.header {
+		val, ok := record[key]
+		if !ok || val == nil {
+			continue
+		}
+		line[key] = val
+	}
+
+	return w.encoder.Encode(line)
+}
+
+func toCSVCell(val interface{}) (string, error) {
+	switch value := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(value), nil
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// Close flush the buffered records and close the file
+func (w *FlatWriter) Close() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
+
+// FlatReader read records from flat file in the way of io stream
+type FlatReader struct {
+	file   *os.File
+	csv    *csv.Reader
+	buf    *bufio.Reader
+	format FlatFormat
+	header []string
+
+	record    map[string]interface{}
+	recordErr error
+	err       error
+	line      int
+	readLines int
+}
+
+// GetHeader get the column keys of csv file, json lines file has no header
+func (r *FlatReader) GetHeader() []string {
+	return r.header
+}
+
+// Next will return true if the next record is found, the reading stops when the file can not be read any more,
+// but a line that can not be parsed into a record is returned by CurRecord, so that the caller can skip it
+func (r *FlatReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	if r.format == CSV {
+		return r.nextCSV()
+	}
+
+	return r.nextJSONL()
+}
+
+func (r *FlatReader) nextCSV() bool {
+	row, err := r.csv.Read()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		r.err = err
+		return false
+	}
+
+	r.line, _ = r.csv.FieldPos(0)
+	r.record = make(map[string]interface{})
+	r.recordErr = nil
+	if len(row) > len(r.header) {
+		r.recordErr = fmt.Errorf("record has %d fields, but header only has %d", len(row), len(r.header))
+		return true
+	}
+
+	for idx, cell := range row {
+		if cell == "" {
+			continue
+		}
+		r.record[r.header[idx]] = cell
+	}
+
+	return true
+}
+
+func (r *FlatReader) nextJSONL() bool {
+	for {
+		data, err := r.buf.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			if err != io.EOF {
+				r.err = err
+			}
+			return false
+		}
+		r.readLines++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		r.line = r.readLines
+		r.record = make(map[string]interface{})
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		r.recordErr = decoder.Decode(&r.record)
+		if r.recordErr == nil && decoder.More() {
+			r.recordErr = errors.New("line has more than one json value")
+		}
+		if r.recordErr == nil && r.record == nil {
+			r.recordErr = errors.New("line is not a json object")
+		}
+
+		return true
+	}
+}
+
+// CurRecord return the current record, which is keyed by the column keys. csv values are strings, json lines
+// values are decoded json values, numbers are decoded as json.Number to keep their precision
+func (r *FlatReader) CurRecord() (map[string]interface{}, error) {
+	if r.recordErr != nil {
+		return nil, r.recordErr
+	}
+
+	return r.record, nil
+}
+
+// GetLine get the line number of the current record, starting from 1
+func (r *FlatReader) GetLine() int {
+	return r.line
+}
+
+// Err return the error that stops the reading
+func (r *FlatReader) Err() error {
+	return r.err
+}
+
+// Close reader
+func (r *FlatReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package excel
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatFileCSV(t *testing.T) {
+	file, err := NewFlatFile(filepath.Join(t.TempDir(), "inst.csv"), CSV)
+	require.NoError(t, err)
+
+	writer, err := file.NewWriter([]string{"bk_inst_id", "name", "table", "enabled"})
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(map[string]interface{}{"bk_inst_id": int64(1), "name": "a,\"b\"\nc",
+		"table": []map[string]interface{}{{"key": "val"}}, "enabled": true, "ignored": "x"}))
+	require.NoError(t, writer.Write(map[string]interface{}{"name": "d"}))
+	require.NoError(t, writer.Close())
+
+	reader, err := file.NewReader()
+	require.NoError(t, err)
+	require.Equal(t, []string{"bk_inst_id", "name", "table", "enabled"}, reader.GetHeader())
+
+	require.True(t, reader.Next())
+	record, err := reader.CurRecord()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"bk_inst_id": "1", "name": "a,\"b\"\nc", "table": `[{"key":"val"}]`,
+		"enabled": "true"}, record)
+	require.Equal(t, 2, reader.GetLine())
+
+	require.True(t, reader.Next())
+	record, err = reader.CurRecord()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "d"}, record)
+	require.Equal(t, 4, reader.GetLine())
+
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Err())
+	require.NoError(t, reader.Close())
+	require.NoError(t, file.Clean())
+}
+
+func TestFlatFileCSVWithBOM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inst.csv")
+	require.NoError(t, os.WriteFile(path, []byte("\xEF\xBB\xBFbk_inst_id, name\n1,a,extra\n2,b\n"), 0644))
+
+	file, err := NewFlatFile(path, CSV)
+	require.NoError(t, err)
+	reader, err := file.NewReader()
+	require.NoError(t, err)
+	require.Equal(t, []string{"bk_inst_id", "name"}, reader.GetHeader())
+
+	require.True(t, reader.Next())
+	_, err = reader.CurRecord()
+	require.Error(t, err)
+
+	require.True(t, reader.Next())
+	record, err := reader.CurRecord()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"bk_inst_id": "2", "name": "b"}, record)
+	require.Equal(t, 3, reader.GetLine())
+
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Close())
+}
+
+func TestFlatFileJSONL(t *testing.T) {
+	file, err := NewFlatFile(filepath.Join(t.TempDir(), "inst.jsonl"), JSONL)
+	require.NoError(t, err)
+
+	writer, err := file.NewWriter([]string{"bk_inst_id", "name", "table"})
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(map[string]interface{}{"bk_inst_id": int64(9007199254740993), "name": "<a>",
+		"table": []map[string]interface{}{{"key": "val"}}, "ignored": "x"}))
+	require.NoError(t, writer.Write(map[string]interface{}{"name": nil}))
+	require.NoError(t, writer.Close())
+
+	data, err := os.ReadFile(file.filePath)
+	require.NoError(t, err)
+	require.Equal(t, "{\"bk_inst_id\":9007199254740993,\"name\":\"<a>\",\"table\":[{\"key\":\"val\"}]}\n{}\n",
+		string(data))
+
+	// append an empty line, an invalid line, a null line and a line without line break to the end
+	data = append(data, []byte("\n{invalid\nnull\n{\"name\":\"b\"}")...)
+	require.NoError(t, os.WriteFile(file.filePath, data, 0644))
+
+	reader, err := file.NewReader()
+	require.NoError(t, err)
+	require.Nil(t, reader.GetHeader())
+
+	require.True(t, reader.Next())
+	record, err := reader.CurRecord()
+	require.NoError(t, err)
+	require.Equal(t, json.Number("9007199254740993"), record["bk_inst_id"])
+	require.Equal(t, []interface{}{map[string]interface{}{"key": "val"}}, record["table"])
+	require.Equal(t, 1, reader.GetLine())
+
+	require.True(t, reader.Next())
+	record, err = reader.CurRecord()
+	require.NoError(t, err)
+	require.Empty(t, record)
+
+	require.True(t, reader.Next())
+	_, err = reader.CurRecord()
+	require.Error(t, err)
+	require.Equal(t, 4, reader.GetLine())
+
+	require.True(t, reader.Next())
+	_, err = reader.CurRecord()
+	require.Error(t, err)
+	require.Equal(t, 5, reader.GetLine())
+
+	require.True(t, reader.Next())
+	record, err = reader.CurRecord()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "b"}, record)
+	require.Equal(t, 6, reader.GetLine())
+
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Err())
+	require.NoError(t, reader.Close())
+}
+
+func TestNewFlatFileInvalidFormat(t *testing.T) {
+	_, err := NewFlatFile(filepath.Join(t.TempDir(), "inst.xlsx"), FlatFormat("xlsx"))
+	require.Error(t, err)
+	require.True(t, IsFlatFormat("jsonl"))
+	require.False(t, IsFlatFormat("xls"))
+}
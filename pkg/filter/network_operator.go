@@ -0,0 +1,345 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/valid"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ipv6FullAddrRegex matches the ipv6 address in the standard format of ip field value, it is used to exclude the
+// ipv4 addresses when filtering ipv6 addresses by string order.
+const ipv6FullAddrRegex = "^[0-9a-f]{4}:"
+
+// IPInCIDROp is ip in cidr operator
+type IPInCIDROp OpType
+
+// Name is ip in cidr operator name
+func (o IPInCIDROp) Name() OpType {
+	return IPInCIDR
+}
+
+// ValidateValue validate ip in cidr operator's value
+func (o IPInCIDROp) ValidateValue(v interface{}, opt *ExprOption) error {
+	if _, err := parseCIDRValue(v); err != nil {
+		return fmt.Errorf("ip in cidr operator's value is invalid, err: %v", err)
+	}
+
+	return nil
+}
+
+// ToMgo convert the ip in cidr operator's field and value to a mongo query condition.
+func (o IPInCIDROp) ToMgo(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	ipNet, err := parseCIDRValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipRangeToMgo(field, ipNet.IP, lastIPOfCIDR(ipNet)), nil
+}
+
+// Match checks if the first data matches the second data by this operator
+func (o IPInCIDROp) Match(value1, value2 interface{}) (bool, error) {
+	if value1 == nil {
+		return false, nil
+	}
+
+	ip, err := parseIPValue(value1)
+	if err != nil {
+		return false, fmt.Errorf("parse input value(%+v) failed, err: %v", value1, err)
+	}
+
+	ipNet, err := parseCIDRValue(value2)
+	if err != nil {
+		return false, fmt.Errorf("parse rule value(%+v) failed, err: %v", value2, err)
+	}
+
+	return cidrContainsIP(ipNet, ip), nil
+}
+
+// IPRangeOp is ip range operator
+type IPRangeOp OpType
+
+// Name is ip range operator name
+func (o IPRangeOp) Name() OpType {
+	return IPRange
+}
+
+// ValidateValue validate ip range operator's value
+func (o IPRangeOp) ValidateValue(v interface{}, opt *ExprOption) error {
+	if _, _, err := parseIPRangeValue(v); err != nil {
+		return fmt.Errorf("ip range operator's value is invalid, err: %v", err)
+	}
+
+	return nil
+}
+
+// ToMgo convert the ip range operator's field and value to a mongo query condition.
+func (o IPRangeOp) ToMgo(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	start, end, err := parseIPRangeValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipRangeToMgo(field, start, end), nil
+}
+
+// Match checks if the first data matches the second data by this operator
+func (o IPRangeOp) Match(value1, value2 interface{}) (bool, error) {
+	if value1 == nil {
+		return false, nil
+	}
+
+	ip, err := parseIPValue(value1)
+	if err != nil {
+		return false, fmt.Errorf("parse input value(%+v) failed, err: %v", value1, err)
+	}
+
+	start, end, err := parseIPRangeValue(value2)
+	if err != nil {
+		return false, fmt.Errorf("parse rule value(%+v) failed, err: %v", value2, err)
+	}
+
+	if len(ip) != len(start) {
+		return false, nil
+	}
+	return bytes.Compare(ip, start) >= 0 && bytes.Compare(ip, end) <= 0, nil
+}
+
+// CIDRContainsOp is cidr contains operator
+type CIDRContainsOp OpType
+
+// Name is cidr contains operator name
+func (o CIDRContainsOp) Name() OpType {
+	return CIDRContains
+}
+
+// ValidateValue validate cidr contains operator's value
+func (o CIDRContainsOp) ValidateValue(v interface{}, opt *ExprOption) error {
+	if _, err := parseIPValue(v); err != nil {
+		return fmt.Errorf("cidr contains operator's value is invalid, err: %v", err)
+	}
+
+	return nil
+}
+
+// ToMgo convert the cidr contains operator's field and value to a mongo query condition.
+// the cidr field values are stored in the standard format, so the cidrs that contains the ip are exactly
+// the networks of the ip with all the prefix lengths, which can be matched by the $in operator using index.
+func (o CIDRContainsOp) ToMgo(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	ip, err := parseIPValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := len(ip) * 8
+	cidrs := make([]string, 0, bits+1)
+	for ones := bits; ones >= 0; ones-- {
+		mask := net.CIDRMask(ones, bits)
+		cidrs = append(cidrs, common.FormatCIDR(&net.IPNet{IP: ip.Mask(mask), Mask: mask}))
+	}
+
+	return mapstr.MapStr{
+		field: map[string]interface{}{common.BKDBIN: cidrs},
+	}, nil
+}
+
+// Match checks if the first data matches the second data by this operator
+func (o CIDRContainsOp) Match(value1, value2 interface{}) (bool, error) {
+	if value1 == nil {
+		return false, nil
+	}
+
+	ipNet, err := parseCIDRValue(value1)
+	if err != nil {
+		return false, fmt.Errorf("parse input value(%+v) failed, err: %v", value1, err)
+	}
+
+	ip, err := parseIPValue(value2)
+	if err != nil {
+		return false, fmt.Errorf("parse rule value(%+v) failed, err: %v", value2, err)
+	}
+
+	return cidrContainsIP(ipNet, ip), nil
+}
+
+func parseIPValue(v interface{}) (net.IP, error) {
+	if err := valid.ValidateNotEmptyStringType(v); err != nil {
+		return nil, err
+	}
+
+	return common.ParseIP(strings.TrimSpace(v.(string)))
+}
+
+func parseCIDRValue(v interface{}) (*net.IPNet, error) {
+	if err := valid.ValidateNotEmptyStringType(v); err != nil {
+		return nil, err
+	}
+
+	return common.ParseCIDR(strings.TrimSpace(v.(string)))
+}
+
+// parseIPRangeValue parse ip range value in the form of [start ip, end ip], the two ips must be of the same version
+func parseIPRangeValue(v interface{}) (net.IP, net.IP, error) {
+	if v == nil {
+		return nil, nil, errors.New("value is nil")
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Array && value.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("value(%+v) should be an array", v)
+	}
+
+	if value.Len() != 2 {
+		return nil, nil, fmt.Errorf("value(%+v) should be an array of start ip and end ip", v)
+	}
+
+	start, err := parseIPValue(value.Index(0).Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	end, err := parseIPValue(value.Index(1).Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(start) != len(end) {
+		return nil, nil, fmt.Errorf("start ip and end ip in value(%+v) are not of the same version", v)
+	}
+
+	if bytes.Compare(start, end) > 0 {
+		return nil, nil, fmt.Errorf("start ip is greater than end ip in value(%+v)", v)
+	}
+
+	return start, end, nil
+}
+
+// cidrContainsIP checks if the cidr contains the ip, unlike net.IPNet.Contains, the ipv4 embedded ipv6 address is
+// not treated as ipv4 address, so that it is consistent with the mongo condition of the ip field values.
+func cidrContainsIP(ipNet *net.IPNet, ip net.IP) bool {
+	if len(ip) != len(ipNet.IP) {
+		return false
+	}
+	return bytes.Equal(ip.Mask(ipNet.Mask), ipNet.IP)
+}
+
+func lastIPOfCIDR(ipNet *net.IPNet) net.IP {
+	last := make(net.IP, len(ipNet.IP))
+	for idx := range ipNet.IP {
+		last[idx] = ipNet.IP[idx] | ^ipNet.Mask[idx]
+	}
+	return last
+}
+
+// ipRangeToMgo generate the mongo condition that matches the ip field values between the start and end ip.
+// ipv6 field values are stored in fixed length format, so they can be compared directly by string order.
+// ipv4 field values are not, so the range is split into cidr blocks, and each block is matched by a prefix regex.
+func ipRangeToMgo(field string, start, end net.IP) map[string]interface{} {
+	if len(start) == net.IPv6len {
+		return mapstr.MapStr{
+			field: map[string]interface{}{
+				common.BKDBGTE:  common.FormatIP(start),
+				common.BKDBLTE:  common.FormatIP(end),
+				common.BKDBLIKE: ipv6FullAddrRegex,
+			},
+		}
+	}
+
+	from := uint64(binary.BigEndian.Uint32(start))
+	to := uint64(binary.BigEndian.Uint32(end))
+
+	values := make([]interface{}, 0)
+	for from <= to {
+		// find the largest block that begins with the from ip and does not exceed the end ip
+		size := uint(0)
+		for size < 32 && from&(1<<(size+1)-1) == 0 && from+1<<(size+1)-1 <= to {
+			size++
+		}
+
+		values = append(values, ipv4BlockToMgoValue(uint32(from), 32-int(size)))
+		from += 1 << size
+	}
+
+	return mapstr.MapStr{
+		field: map[string]interface{}{common.BKDBIN: values},
+	}
+}
+
+// ipv4BlockToMgoValue generate the $in element that matches the ipv4 addresses in the cidr block,
+// e.g. 10.0.0.1/32 => "10.0.0.1", 10.0.0.0/16 => /^10\.0\./, 10.0.0.128/25 => /^10\.0\.0\.(?:128|...|255)$/
+func ipv4BlockToMgoValue(ip uint32, prefix int) interface{} {
+	octets := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(octets, ip)
+	if prefix == 32 {
+		return octets.String()
+	}
+
+	full := prefix / 8
+	parts := make([]string, 0, full+1)
+	for idx := 0; idx < full; idx++ {
+		parts = append(parts, strconv.Itoa(int(octets[idx])))
+	}
+
+	rem := prefix % 8
+	if rem == 0 {
+		if full == 0 {
+			// matches all ipv4 addresses
+			return primitive.Regex{Pattern: `^[0-9]+\.`}
+		}
+		return primitive.Regex{Pattern: "^" + strings.Join(parts, `\.`) + `\.`}
+	}
+
+	low := int(octets[full])
+	high := low + 1<<(8-rem) - 1
+	alternatives := make([]string, 0, high-low+1)
+	for val := low; val <= high; val++ {
+		alternatives = append(alternatives, strconv.Itoa(val))
+	}
+	parts = append(parts, "(?:"+strings.Join(alternatives, "|")+")")
+
+	pattern := "^" + strings.Join(parts, `\.`)
+	if full == 3 {
+		return primitive.Regex{Pattern: pattern + "$"}
+	}
+	return primitive.Regex{Pattern: pattern + `\.`}
+}
@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package filter
+
+import (
+	"regexp"
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testIPs = []string{"0.0.0.0", "9.255.255.255", "10.0.0.0", "10.0.0.1", "10.0.0.127", "10.0.0.128", "10.0.1.5",
+	"10.0.255.255", "10.1.0.0", "10.200.3.4", "100.0.0.1", "192.168.1.1", "255.255.255.255",
+	"0000:0000:0000:0000:0000:0000:0000:0001", "2001:0db8:0000:0000:0000:0000:0000:0001",
+	"2001:0db8:ffff:ffff:ffff:ffff:ffff:ffff", "2001:0db9:0000:0000:0000:0000:0000:0000"}
+
+// matchMgoCond checks if the ip field value matches the mongo condition generated by the network operators
+func matchMgoCond(t *testing.T, cond map[string]interface{}, value string) bool {
+	fieldCond := cond["test"].(map[string]interface{})
+	if in, exists := fieldCond[common.BKDBIN]; exists {
+		switch values := in.(type) {
+		case []interface{}:
+			for _, item := range values {
+				switch v := item.(type) {
+				case string:
+					if v == value {
+						return true
+					}
+				case primitive.Regex:
+					if regexp.MustCompile(v.Pattern).MatchString(value) {
+						return true
+					}
+				default:
+					t.Fatalf("invalid $in element %+v", item)
+				}
+			}
+		case []string:
+			for _, v := range values {
+				if v == value {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return value >= fieldCond[common.BKDBGTE].(string) && value <= fieldCond[common.BKDBLTE].(string) &&
+		regexp.MustCompile(fieldCond[common.BKDBLIKE].(string)).MatchString(value)
+}
+
+func TestIPInCIDR(t *testing.T) {
+	op := IPInCIDR.Factory().Operator()
+
+	assert.NoError(t, op.ValidateValue("10.0.0.0/8", nil))
+	assert.NoError(t, op.ValidateValue("2001:db8::/32", nil))
+	assert.Error(t, op.ValidateValue("10.0.0.0", nil))
+	assert.Error(t, op.ValidateValue("10.0.0.0/33", nil))
+	assert.Error(t, op.ValidateValue(1, nil))
+
+	cidrs := map[string][]string{
+		"10.0.0.0/8": {"10.0.0.0", "10.0.0.1", "10.0.0.127", "10.0.0.128", "10.0.1.5", "10.0.255.255", "10.1.0.0",
+			"10.200.3.4"},
+		"10.0.0.0/25": {"10.0.0.0", "10.0.0.1", "10.0.0.127"},
+		"10.0.0.0/15": {"10.0.0.0", "10.0.0.1", "10.0.0.127", "10.0.0.128", "10.0.1.5", "10.0.255.255",
+			"10.1.0.0"},
+		"10.0.0.1/32":      {"10.0.0.1"},
+		"0.0.0.0/0":        testIPs[:13],
+		"2001:db8::/32":    {"2001:0db8:0000:0000:0000:0000:0000:0001", "2001:0db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+		"::/64":            {"0000:0000:0000:0000:0000:0000:0000:0001"},
+		"2001:db9::/128":   {"2001:0db9:0000:0000:0000:0000:0000:0000"},
+		"2001:db8:1::/48":  {},
+		"192.168.1.1/24":   {"192.168.1.1"},
+		"192.168.0.0/16":   {"192.168.1.1"},
+		"96.0.0.0/4":       {"100.0.0.1"},
+		"255.255.255.0/24": {"255.255.255.255"},
+	}
+
+	for cidr, expected := range cidrs {
+		cond, err := op.ToMgo("test", cidr)
+		assert.NoError(t, err)
+
+		for _, ip := range testIPs {
+			isExpected := false
+			for _, expectedIP := range expected {
+				if ip == expectedIP {
+					isExpected = true
+					break
+				}
+			}
+
+			matched, err := op.Match(ip, cidr)
+			assert.NoError(t, err)
+			assert.Equal(t, isExpected, matched, "%s in %s", ip, cidr)
+			assert.Equal(t, isExpected, matchMgoCond(t, cond, ip), "%s in %s mongo condition %+v", ip, cidr, cond)
+		}
+	}
+
+	matched, err := op.Match(nil, "10.0.0.0/8")
+	assert.NoError(t, err)
+	assert.Equal(t, false, matched)
+}
+
+func TestIPRange(t *testing.T) {
+	op := IPRange.Factory().Operator()
+
+	assert.NoError(t, op.ValidateValue([]interface{}{"10.0.0.1", "10.0.0.1"}, nil))
+	assert.NoError(t, op.ValidateValue([]string{"2001:db8::", "2001:db8::ffff"}, nil))
+	assert.Error(t, op.ValidateValue([]interface{}{"10.0.0.2", "10.0.0.1"}, nil))
+	assert.Error(t, op.ValidateValue([]interface{}{"10.0.0.1", "::1"}, nil))
+	assert.Error(t, op.ValidateValue([]interface{}{"10.0.0.1"}, nil))
+	assert.Error(t, op.ValidateValue("10.0.0.1", nil))
+
+	ranges := map[[2]string][]string{
+		{"10.0.0.1", "10.0.1.5"}:      {"10.0.0.1", "10.0.0.127", "10.0.0.128", "10.0.1.5"},
+		{"9.255.255.255", "10.0.0.0"}: {"9.255.255.255", "10.0.0.0"},
+		{"10.0.0.128", "100.0.0.1"}: {"10.0.0.128", "10.0.1.5", "10.0.255.255", "10.1.0.0", "10.200.3.4",
+			"100.0.0.1"},
+		{"0.0.0.0", "255.255.255.255"}: testIPs[:13],
+		{"::1", "2001:db8::ffff"}: {"0000:0000:0000:0000:0000:0000:0000:0001",
+			"2001:0db8:0000:0000:0000:0000:0000:0001"},
+		{"1000::", "3000::"}: {"2001:0db8:0000:0000:0000:0000:0000:0001", "2001:0db8:ffff:ffff:ffff:ffff:ffff:ffff",
+			"2001:0db9:0000:0000:0000:0000:0000:0000"},
+		{"10.0.0.127", "10.0.0.127"}:    {"10.0.0.127"},
+		{"192.168.1.2", "192.168.1.10"}: {},
+	}
+
+	for ipRange, expected := range ranges {
+		value := []interface{}{ipRange[0], ipRange[1]}
+		cond, err := op.ToMgo("test", value)
+		assert.NoError(t, err)
+
+		for _, ip := range testIPs {
+			isExpected := false
+			for _, expectedIP := range expected {
+				if ip == expectedIP {
+					isExpected = true
+					break
+				}
+			}
+
+			matched, err := op.Match(ip, value)
+			assert.NoError(t, err)
+			assert.Equal(t, isExpected, matched, "%s in %v", ip, ipRange)
+			assert.Equal(t, isExpected, matchMgoCond(t, cond, ip), "%s in %v mongo condition %+v", ip, ipRange, cond)
+		}
+	}
+}
+
+func TestCIDRContains(t *testing.T) {
+	op := CIDRContains.Factory().Operator()
+
+	assert.NoError(t, op.ValidateValue("10.0.0.1", nil))
+	assert.NoError(t, op.ValidateValue("2001:db8::1", nil))
+	assert.Error(t, op.ValidateValue("10.0.0.0/8", nil))
+	assert.Error(t, op.ValidateValue("", nil))
+
+	testCIDRs := []string{"10.0.0.0/8", "10.0.0.0/24", "10.0.1.0/24", "0.0.0.0/0", "10.0.0.1/32",
+		"2001:0db8:0000:0000:0000:0000:0000:0000/32", "0000:0000:0000:0000:0000:0000:0000:0000/0"}
+
+	ips := map[string][]string{
+		"10.0.0.1":        {"10.0.0.0/8", "10.0.0.0/24", "0.0.0.0/0", "10.0.0.1/32"},
+		"10.0.1.200":      {"10.0.0.0/8", "10.0.1.0/24", "0.0.0.0/0"},
+		"192.168.1.1":     {"0.0.0.0/0"},
+		"2001:db8::1":     {"2001:0db8:0000:0000:0000:0000:0000:0000/32", "0000:0000:0000:0000:0000:0000:0000:0000/0"},
+		"::ffff:10.0.0.1": {"0000:0000:0000:0000:0000:0000:0000:0000/0"},
+	}
+
+	for ip, expected := range ips {
+		cond, err := op.ToMgo("test", ip)
+		assert.NoError(t, err)
+
+		for _, cidr := range testCIDRs {
+			isExpected := false
+			for _, expectedCIDR := range expected {
+				if cidr == expectedCIDR {
+					isExpected = true
+					break
+				}
+			}
+
+			matched, err := op.Match(cidr, ip)
+			assert.NoError(t, err)
+			assert.Equal(t, isExpected, matched, "%s contains %s", cidr, ip)
+			assert.Equal(t, isExpected, matchMgoCond(t, cond, cidr), "%s contains %s mongo condition %+v", cidr, ip,
+				cond)
+		}
+	}
+}
@@ -102,6 +102,12 @@ func init() {
 	opFactory[OpFactory(obj.Name())] = &obj
 	filterArr := ArrayOp(Array)
 	opFactory[OpFactory(filterArr.Name())] = &filterArr
+	ipInCIDR := IPInCIDROp(IPInCIDR)
+	opFactory[OpFactory(ipInCIDR.Name())] = &ipInCIDR
+	ipRange := IPRangeOp(IPRange)
+	opFactory[OpFactory(ipRange.Name())] = &ipRange
+	cidrContains := CIDRContainsOp(CIDRContains)
+	opFactory[OpFactory(cidrContains.Name())] = &cidrContains
 }
 
 const (
@@ -242,6 +248,15 @@ const (
 	Object OpType = "filter_object"
 	// Array filter array elements operator
 	Array OpType = "filter_array"
+
+	// network operator that is used to filter ip and cidr fields
+
+	// IPInCIDR match ip field value that is in the cidr
+	IPInCIDR OpType = "ip_in_cidr"
+	// IPRange match ip field value that is in the ip range
+	IPRange OpType = "ip_range"
+	// CIDRContains match cidr field value that contains the ip
+	CIDRContains OpType = "cidr_contains"
 )
 
 // OpType defines the operators supported by cc.
@@ -254,7 +269,7 @@ func (op OpType) Validate() error {
 		DatetimeGreater, DatetimeGreaterOrEqual, BeginsWith, BeginsWithInsensitive, NotBeginsWith,
 		NotBeginsWithInsensitive, Contains, ContainsSensitive, NotContains, NotContainsInsensitive, EndsWith,
 		EndsWithInsensitive, NotEndsWith, NotEndsWithInsensitive, IsEmpty, IsNotEmpty, Size, IsNull,
-		IsNotNull, Exist, NotExist, Object, Array:
+		IsNotNull, Exist, NotExist, Object, Array, IPInCIDR, IPRange, CIDRContains:
 	default:
 		return fmt.Errorf("unsupported operator: %s", op)
 	}
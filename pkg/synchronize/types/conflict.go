@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package types
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// ConflictAction is the sync action that conflicts with the local change of the destination data
+type ConflictAction string
+
+const (
+	// ConflictActionUpdate means that the sync data is going to update the locally changed data
+	ConflictActionUpdate ConflictAction = "update"
+	// ConflictActionDelete means that the sync data is going to delete the locally changed data
+	ConflictActionDelete ConflictAction = "delete"
+)
+
+// ConflictStatus is the sync conflict status
+type ConflictStatus string
+
+const (
+	// ConflictPending means that the conflict is parked in the conflict queue and waits to be resolved
+	ConflictPending ConflictStatus = "pending"
+	// ConflictResolved means that the conflict is resolved
+	ConflictResolved ConflictStatus = "resolved"
+)
+
+// ConflictResolution is the resolution of the sync conflict
+type ConflictResolution string
+
+const (
+	// ResolutionSrc means that the source data is written to the destination
+	ResolutionSrc ConflictResolution = "src"
+	// ResolutionDest means that the local data of the destination is kept
+	ResolutionDest ConflictResolution = "dest"
+)
+
+// Validate conflict resolution
+func (r ConflictResolution) Validate() errors.RawErrorInfo {
+	switch r {
+	case ResolutionSrc, ResolutionDest:
+		return errors.RawErrorInfo{}
+	default:
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{"resolution"},
+		}
+	}
+}
+
+// SyncConflict is the conflict between the sync data and the local change of the destination data
+type SyncConflict struct {
+	ID      string         `json:"id" bson:"_id"`
+	ResType ResType        `json:"resource_type" bson:"resource_type"`
+	SubRes  string         `json:"sub_resource" bson:"sub_resource"`
+	DataID  int64          `json:"data_id" bson:"data_id"`
+	Action  ConflictAction `json:"action" bson:"action"`
+	Status  ConflictStatus `json:"status" bson:"status"`
+	// Resolution is the resolution of the resolved conflict
+	Resolution ConflictResolution `json:"resolution" bson:"resolution"`
+	// SrcData is the sync data from the source, it is empty for delete action
+	SrcData mapstr.MapStr `json:"src_data" bson:"src_data"`
+	// DestData is the locally changed data of the destination when the conflict is detected
+	DestData mapstr.MapStr `json:"dest_data" bson:"dest_data"`
+	// SrcHash and DestHash are the hash of the source data and the destination data, they are used to judge if the
+	// same conflict occurs again
+	SrcHash    string        `json:"-" bson:"src_hash"`
+	DestHash   string        `json:"-" bson:"dest_hash"`
+	CreateTime metadata.Time `json:"create_time" bson:"create_time"`
+	LastTime   metadata.Time `json:"last_time" bson:"last_time"`
+}
+
+// ListSyncConflictOption is the list sync conflict option
+type ListSyncConflictOption struct {
+	ResType ResType           `json:"resource_type"`
+	SubRes  string            `json:"sub_resource"`
+	Status  ConflictStatus    `json:"status"`
+	Page    metadata.BasePage `json:"page"`
+}
+
+// Validate list sync conflict option
+func (o *ListSyncConflictOption) Validate() errors.RawErrorInfo {
+	if o.ResType != "" {
+		if rawErr := o.ResType.Validate(o.SubRes); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+	}
+
+	switch o.Status {
+	case "", ConflictPending, ConflictResolved:
+	default:
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsIsInvalid,
+			Args:    []interface{}{"status"},
+		}
+	}
+
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListSyncConflictResult is the list sync conflict result
+type ListSyncConflictResult struct {
+	Count uint64         `json:"count"`
+	Info  []SyncConflict `json:"info"`
+}
+
+// ResolveSyncConflictOption is the resolve sync conflict option
+type ResolveSyncConflictOption struct {
+	IDs        []string           `json:"ids"`
+	Resolution ConflictResolution `json:"resolution"`
+}
+
+// Validate resolve sync conflict option
+func (o *ResolveSyncConflictOption) Validate() errors.RawErrorInfo {
+	if len(o.IDs) == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{"ids"},
+		}
+	}
+
+	if len(o.IDs) > common.BKMaxPageSize {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommXXExceedLimit,
+			Args:    []interface{}{"ids", common.BKMaxPageSize},
+		}
+	}
+
+	return o.Resolution.Validate()
+}
@@ -292,6 +292,8 @@ elasticsearch-replica-num = "$es_replica_num"
 es:
   #全文检索功能开关(取值：off/on)，默认是off，开启是on
   fullTextSearch: "$full_text_search"
+  #全文检索后端(取值：elasticsearch/mongodb)，默认是elasticsearch。未部署elasticsearch时可使用mongodb，由adminserver为模型和实例表创建文本索引，按完整的词进行匹配
+  backend: elasticsearch
   #elasticsearch服务监听url，默认是[http://127.0.0.1:9200](http://127.0.0.1:9200/)
   url: $es_url
   #用户
@@ -311,8 +313,70 @@ operationServer:
   # 禁用运营统计数据统计功能，默认false
   disableOperationStatistic: false
 
+# hostServer相关配置
+hostServer:
+  # 主机属性自动应用偏离检查配置，定时对比主机属性与所属模块的主机属性自动应用规则，生成偏离报告
+  hostApplyDrift:
+    # 是否开启定时检查，默认为false
+    enabled: false
+    # 检查的时间间隔，单位为分钟，默认为60分钟，最小为10分钟
+    intervalMinutes: 60
+    # 需要检查的业务ID列表，为空时检查所有业务
+    bizIDs: []
+    # 发现偏离后自动将主机属性修正为规则值的业务ID列表，规则存在冲突的属性不会被修正，修正操作会记录审计日志
+    autoRemediateBizIDs: []
+
+# coreService相关配置
+coreService:
+  # 审计日志相关配置
+  auditLog:
+    # 审计日志保留策略，定时将过期的审计日志归档为压缩的jsonl文件并从数据库中删除
+    retention:
+      # 是否开启定时归档，默认为false
+      enabled: false
+      # 归档的时间间隔，单位为分钟，默认为60分钟，最小为10分钟
+      intervalMinutes: 60
+      # 归档文件存放的本地目录，归档文件的索引记录在cc_AuditLogArchive表中，为空时过期的审计日志会被直接删除
+      archiveDir: /data/cmdb/audit_archive
+      # 按审计类型(audit_type)配置的保留策略，auditType为*时表示其他未单独配置的审计类型
+      # ttlDays为保留天数，maxCount为最多保留的条数，超过任一限制的审计日志即为过期，为0时表示不限制
+      policies:
+        - auditType: "*"
+          ttlDays: 180
+          maxCount: 0
+    # 审计日志导出配置，主coreService定时按ID顺序将写入的审计日志导出到以下的目标，用于对接SIEM等系统，为空时不导出
+    # format为导出格式，支持syslog(RFC5424格式，消息体为json格式的审计日志)和json(每行一条json格式的审计日志)
+    # network为导出方式，支持tcp和file，address为tcp的地址(ip:port)或者文件的路径
+    # name为导出目标的唯一名称，导出进度按名称记录，导出目标不可用时会从记录的进度重新导出，默认为network:address
+    # settleSeconds为只导出写入时间早于该时长的审计日志，以保证事务中的审计日志在事务提交后再导出，
+    # 需要大于事务超时时间，单位为秒，默认为180秒
+    export: []
+    #  - name: siem
+    #    format: syslog
+    #    network: tcp
+    #    address: 127.0.0.1:514
+    #    settleSeconds: 180
+    # 审计日志防篡改哈希链配置，定时将写入的审计日志按开发商账号依次链接到哈希链中，并定时对链头进行签名生成检查点
+    # 可以通过adminServer的/migrate/v3/find/auditlog/chain/verify接口或者tool_ctl audit-chain verify命令校验哈希链
+    chain:
+      # 是否开启，默认为false
+      enabled: false
+      # 链接审计日志的时间间隔，单位为秒，默认为60秒，最小为10秒
+      intervalSeconds: 60
+      # 只链接写入时间早于该时长的审计日志，以保证事务中的审计日志在事务结束后再链接，单位为秒，默认为300秒
+      settleSeconds: 300
+      # 生成签名检查点的时间间隔，单位为分钟，默认为60分钟
+      checkpointIntervalMinutes: 60
+      # 签名检查点使用的密钥，开启时必须设置，adminServer使用该配置校验检查点签名
+      signKey:
+
 #auth_server专属配置
 authServer:
+  #权限模式(取值：iam/rbac)，默认是iam，使用蓝鲸权限中心鉴权；rbac为使用cmdb内置的基于角色的权限控制，无需部署权限中心，修改后需重启各服务
+  mode: iam
+  rbac:
+    #rbac模式下的超级管理员，拥有所有权限，用于初始化角色和授权，可配置多个,用,(逗号)分割
+    admins: admin
   #蓝鲸权限中心地址,可配置多个,用,(逗号)分割
   address: $auth_address
   #cmdb项目在蓝鲸权限中心的应用编码
@@ -365,6 +429,17 @@ datacollection:
     rateLimiter:
       qps: 40
       burst: 100
+  # kubernetes集群采集配置，将已在cmdb中注册的集群的node、namespace、workload、pod同步到容器模型中，gamedeployment等自定义workload不采集
+  kube:
+    # 是否开启kubernetes集群采集，默认为false
+    enabled: false
+    # 全量对账周期，单位为秒，默认值为300，最小值为60
+    resyncSeconds: 300
+    # 需要采集的集群列表，集群需先在cmdb中创建，多个datacollection实例会自动分摊集群
+    clusters:
+    #  - bizID: 2
+    #    clusterUID: BCS-K8S-00001
+    #    kubeconfig: /data/cmdb/kube/bcs-k8s-00001.conf
 
 # 监控配置， monitor配置项必须存在
 monitor:
@@ -443,6 +518,16 @@ eventServer:
       fileOwner: "root"
       # 下发主机身份文件权限值
       filePrivilege: 644
+  # 事件订阅回调相关配置
+  webhook:
+    # 推送失败后的最大重试次数，超过后该批事件会被存入死信记录中
+    maxRetry: 5
+    # 每次推送的超时时间，单位为秒
+    timeoutSeconds: 10
+    # 第一次重试前的等待时间，之后每次重试等待时间翻倍，单位为秒
+    retryIntervalSeconds: 1
+    # 两次重试之间的最大等待时间，单位为秒
+    maxRetryIntervalSeconds: 60
 
 # apiServer相关配置
 apiServer:
@@ -534,9 +619,50 @@ transferService:
   role:
   # 全量同步周期，单位：小时，仅源环境需要配置
   syncIntervalHours:
-  # 传输介质地址
+  # 传输介质类型，http表示通过传输介质服务传输数据，file表示通过目录中的数据包文件传输数据，默认为http
+  transferMediumType: http
+  # 传输介质地址，仅传输介质类型为http时需要配置
   transferMediumAddress:
     - transfer.example.com
+  # 数据包文件传输介质配置，仅传输介质类型为file时需要配置
+  bundle:
+    # 源环境写入数据包、目标环境读取数据包的目录
+    dir:
+    # 数据包签名密钥，所有环境需要配置一致
+    secret:
+    # 每个数据包最多包含的同步数据条数，仅源环境需要配置，默认为1000
+    maxRecords: 1000
+    # 同步数据写入数据包的最长等待时间，单位：秒，仅源环境需要配置，默认为60
+    flushIntervalSeconds: 60
+  # 同步范围配置，仅源环境可以配置，不配置时同步全部数据
+  # scope:
+  #   # 需要同步的业务ID列表，不配置时同步全部业务
+  #   bizIDs: []
+  #   # 需要同步实例的模型ID列表，不配置时同步全部模型的实例
+  #   objIDs: []
+  #   # 资源属性过滤条件列表，只同步满足条件的数据
+  #   filters:
+  #     - resource: object_instance
+  #       objID:
+  #       filter:
+  # 同步数据与目标环境本地修改冲突时的处理策略，仅目标环境生效，srcWins/destWins/queue，默认为srcWins
+  conflictPolicy: srcWins
+
+# 钩子配置，每个钩子点可以绑定一个HTTP webhook，未配置或未开启时使用默认逻辑
+hooks:
+  webhook:
+    # 钩子点名称，如validateCreateBusiness、validateDeleteBusiness、validHostTransfer等
+    validateDeleteBusiness:
+      # 是否开启该钩子点的webhook, 默认为false
+      enabled: false
+      # webhook地址，以POST方式发送JSON请求
+      url:
+      # 调用超时时间，单位：秒，默认为5
+      timeout: 5
+      # 调用失败时的处理策略，failOpen表示使用默认逻辑，failClosed表示拒绝请求，默认为failOpen
+      failurePolicy: failOpen
+      # 调用webhook时以Bearer Token方式放入Authorization头中，可不配置
+      token:
     '''
 
     template = FileTemplate(common_file_template_str)
@@ -617,8 +743,102 @@ webServer:
     #权限模式，web页面使用，可选值: internal, iam
     authscheme: $auth_scheme
   login:
-    #登录模式
+    #登录模式，可选值: skip-login, blueking, oidc, ldap
     version: $loginVersion
+  # LDAP登录配置，login.version为ldap时生效，用户在cmdb登录页面输入LDAP用户名和密码登录
+  ldap:
+    # LDAP服务地址，如ldap://ldap.example.com:389或ldaps://ldap.example.com:636
+    url:
+    # 是否通过StartTLS将ldap连接升级为tls连接
+    startTLS: false
+    # 用于查询用户和用户组的服务账号，不填时匿名查询
+    bindDN:
+    bindPassword:
+    # 查询用户的base dn
+    userBaseDN:
+    # 查询用户的过滤条件，{username}会被替换为转义后的用户名
+    userFilter: (uid={username})
+    # 用户信息对应的LDAP属性
+    attributes:
+      # 用户名
+      username: uid
+      # 用户中文名
+      displayName: cn
+      # 邮箱
+      email: mail
+      # 手机号
+      phone: telephoneNumber
+      # 用户条目中记录所属用户组dn的属性，如memberOf，不填时不使用
+      memberOf:
+    # 查询用户组的base dn，不填时不查询用户组
+    groupBaseDN:
+    # 查询用户所属用户组的过滤条件，{dn}和{username}会被替换为转义后的用户dn和用户名
+    groupFilter: (|(member={dn})(uniqueMember={dn}))
+    # 用户组名称的属性
+    groupNameAttribute: cn
+    # LDAP用户组与开发商账号的映射，group必须为用户组的完整dn，admin为true时用户组成员是该开发商账号的管理员
+    groupMappings:
+    #  - group: cn=cmdb-admins,ou=groups,dc=example,dc=com
+    #    supplierAccount: 0
+    #    admin: true
+    # 不属于任何映射的用户组的用户所属的开发商账号
+    defaultSupplierAccount: 0
+    # 用户所属用户组的缓存时间，单位为秒
+    groupCacheTTL: 300
+    # LDAP操作的超时时间，单位为秒
+    timeout: 10
+    # 访问LDAP服务时使用的tls配置
+    tls:
+      # 客户端是否验证服务端证书，包含证书链和主机名，bool值, true为不校验, false为校验
+      insecureSkipVerify: false
+      # 服务使用的证书的路径,如:/data/cmdb/cert/server.crt
+      certFile:
+      # 服务使用的证书对应的密钥的路径,如:/data/cmdb/cert/server.key
+      keyFile:
+      # CA证书的路径，用于验证对方证书,如:/data/cmdb/cert/ca.crt
+      caFile:
+      # 用于解密根据RFC1423加密的证书密钥的PEM块
+      password:
+  # OpenID Connect登录配置，login.version为oidc时生效
+  oidc:
+    # 身份提供方的issuer地址，会通过该地址下的/.well-known/openid-configuration获取服务端点
+    issuer:
+    # 在身份提供方注册的客户端ID
+    clientId:
+    # 客户端密钥，公开客户端可以不填，仅使用PKCE
+    clientSecret:
+    # 申请的scope，以空格分隔，默认为openid profile email
+    scopes: openid profile email
+    # 登录回调地址，不填时默认为webServer.site.domainUrl加上/login/callback
+    redirectUrl:
+    # 退出登录后跳转的地址，不填时默认为webServer.site.domainUrl
+    postLogoutRedirectUrl:
+    # id token中的声明与cmdb用户信息的映射，支持以.分隔的嵌套声明
+    claims:
+      # 用户名，不存在时使用sub
+      username: preferred_username
+      # 用户中文名
+      displayName: name
+      # 邮箱
+      email: email
+      # 手机号
+      phone: phone_number
+      # 开发商账号，不填时默认为0
+      supplierAccount:
+      # 用户组
+      groups: groups
+    # 访问身份提供方时使用的tls配置
+    tls:
+      # 客户端是否验证服务端证书，包含证书链和主机名，bool值, true为不校验, false为校验
+      insecureSkipVerify: false
+      # 服务使用的证书的路径,如:/data/cmdb/cert/server.crt
+      certFile:
+      # 服务使用的证书对应的密钥的路径,如:/data/cmdb/cert/server.key
+      keyFile:
+      # CA证书的路径，用于验证对方证书,如:/data/cmdb/cert/ca.crt
+      caFile:
+      # 用于解密根据RFC1423加密的证书密钥的PEM块
+      password:
   #cmdb版本日志存放路径配置
   changelogPath:
     #中文版版本日志存放路径
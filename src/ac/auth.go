@@ -20,6 +20,8 @@ import (
 
 	"configcenter/src/ac/iam"
 	"configcenter/src/ac/meta"
+	"configcenter/src/ac/rbac"
+	"configcenter/src/apimachinery"
 	"configcenter/src/common/metadata"
 	"configcenter/src/scene_server/auth_server/sdk/types"
 	"configcenter/src/storage/dal/redis"
@@ -69,3 +71,68 @@ type AuthorizeInterface interface {
 	BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header, input metadata.IamInstancesWithCreator) (
 		[]metadata.IamCreatorActionPolicy, error)
 }
+
+// NewAuthorizer new authorizer that authorize with the built-in rbac or the iam by the configured auth mode
+func NewAuthorizer(clientSet apimachinery.ClientSetInterface) AuthorizeInterface {
+	return &authorizer{
+		iam:  iam.NewAuthorizer(clientSet),
+		rbac: rbac.NewAuthorizer(clientSet),
+	}
+}
+
+// authorizer dispatch the authorization to the iam or the built-in rbac by the configured auth mode, the services
+// must be restarted after the auth mode is changed, because auth server only registers the apis of the auth mode at
+// startup
+type authorizer struct {
+	iam  AuthorizeInterface
+	rbac AuthorizeInterface
+}
+
+func (a *authorizer) get() AuthorizeInterface {
+	if rbac.Enabled() {
+		return a.rbac
+	}
+	return a.iam
+}
+
+// AuthorizeBatch batch authorization will not pass if one of them does not have permission
+func (a *authorizer) AuthorizeBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.get().AuthorizeBatch(ctx, h, user, resources...)
+}
+
+// AuthorizeAnyBatch batch authorization will pass if one of them has permission
+func (a *authorizer) AuthorizeAnyBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.get().AuthorizeAnyBatch(ctx, h, user, resources...)
+}
+
+// ListAuthorizedResources list the ids of the resources that the user has the permission of
+func (a *authorizer) ListAuthorizedResources(ctx context.Context, h http.Header,
+	input meta.ListAuthorizedResourcesParam) (*types.AuthorizeList, error) {
+	return a.get().ListAuthorizedResources(ctx, h, input)
+}
+
+// GetNoAuthSkipUrl get no auth skip url
+func (a *authorizer) GetNoAuthSkipUrl(ctx context.Context, h http.Header, input *metadata.IamPermission) (string,
+	error) {
+	return a.get().GetNoAuthSkipUrl(ctx, h, input)
+}
+
+// GetPermissionToApply get permission to apply
+func (a *authorizer) GetPermissionToApply(ctx context.Context, h http.Header, input []meta.ResourceAttribute) (
+	*metadata.IamPermission, error) {
+	return a.get().GetPermissionToApply(ctx, h, input)
+}
+
+// RegisterResourceCreatorAction register resource creator actions
+func (a *authorizer) RegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstanceWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return a.get().RegisterResourceCreatorAction(ctx, h, input)
+}
+
+// BatchRegisterResourceCreatorAction batch register resource creator actions
+func (a *authorizer) BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstancesWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return a.get().BatchRegisterResourceCreatorAction(ctx, h, input)
+}
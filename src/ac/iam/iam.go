@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"configcenter/src/ac/meta"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/authserver"
 	"configcenter/src/apimachinery/flowctrl"
@@ -49,7 +50,7 @@ type IAM struct {
 // NewIAM new iam client
 func NewIAM(cfg AuthConfig, reg prometheus.Registerer) (*IAM, error) {
 	blog.V(5).Infof("new iam with parameters cfg: %+v", cfg)
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return new(IAM), nil
 	}
 
@@ -140,7 +141,7 @@ type RegisterIamOptions struct {
 
 // Register cc auth resources to iam
 func (i IAM) Register(ctx context.Context, redisCli redis.Client, opt *RegisterIamOptions, rid string) error {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return nil
 	}
 
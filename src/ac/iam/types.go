@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	"configcenter/src/ac/meta"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/common/auth"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/scene_server/auth_server/sdk/operator"
@@ -60,7 +61,7 @@ type AuthConfig struct {
 // ParseConfigFromKV TODO
 func ParseConfigFromKV(prefix string, configMap map[string]string) (AuthConfig, error) {
 	var cfg AuthConfig
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return AuthConfig{}, nil
 	}
 	address, err := cc.String(prefix + ".address")
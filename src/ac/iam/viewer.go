@@ -17,6 +17,7 @@ import (
 	"net/http"
 	"reflect"
 
+	"configcenter/src/ac/rbac"
 	"configcenter/src/apimachinery"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
@@ -43,7 +44,7 @@ func NewViewer(client apimachinery.ClientSetInterface, iam *IAM) *viewer {
 func (v *viewer) CreateView(ctx context.Context, header http.Header, objects []metadata.Object, redisCli redis.Client,
 	rid string) error {
 
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return nil
 	}
 
@@ -83,7 +84,7 @@ func (v *viewer) CreateView(ctx context.Context, header http.Header, objects []m
 func (v *viewer) DeleteView(ctx context.Context, header http.Header, objects []metadata.Object, redisCli redis.Client,
 	rid string) error {
 
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return nil
 	}
 
@@ -123,7 +124,7 @@ func (v *viewer) DeleteView(ctx context.Context, header http.Header, objects []m
 func (v *viewer) UpdateView(ctx context.Context, header http.Header, objects []metadata.Object, redisCli redis.Client,
 	rid string) error {
 
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return nil
 	}
 
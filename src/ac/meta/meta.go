@@ -112,6 +112,12 @@ type ListAuthorizedResourcesParam struct {
 	Action       Action       `json:"action"`
 }
 
+// AuthorizeResourcesParam is the param to authorize a user's operations on the resources with the built-in rbac
+type AuthorizeResourcesParam struct {
+	UserName  string              `json:"user_name"`
+	Resources []ResourceAttribute `json:"resources"`
+}
+
 // Action TODO
 type Action string
 
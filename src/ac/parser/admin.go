@@ -21,6 +21,16 @@ import (
 
 var findSystemConfigRegexp = regexp.MustCompile(`^/api/v3/admin/find/system_config/platform_setting/[^\s/]+/?$`)
 
+var (
+	updateRBACRoleRegexp        = regexp.MustCompile(`^/api/v3/update/rbac/role/[0-9]+/?$`)
+	deleteRBACRoleRegexp        = regexp.MustCompile(`^/api/v3/delete/rbac/role/[0-9]+/?$`)
+	updateRBACRoleBindingRegexp = regexp.MustCompile(`^/api/v3/update/rbac/role_binding/[0-9]+/?$`)
+	deleteRBACRoleBindingRegexp = regexp.MustCompile(`^/api/v3/delete/rbac/role_binding/[0-9]+/?$`)
+	updateRBACUserGroupRegexp   = regexp.MustCompile(`^/api/v3/update/rbac/user_group/[0-9]+/?$`)
+	deleteRBACUserGroupRegexp   = regexp.MustCompile(`^/api/v3/delete/rbac/user_group/[0-9]+/?$`)
+	deleteAPITokenRegexp        = regexp.MustCompile(`^/api/v3/delete/api_token/[0-9]+/?$`)
+)
+
 func (ps *parseStream) adminRelated() *parseStream {
 	if ps.shouldReturn() {
 		return ps
@@ -28,6 +38,8 @@ func (ps *parseStream) adminRelated() *parseStream {
 
 	ps.ConfigAdmin()
 	ps.PlatformSettingConfigAuth()
+	ps.RBACAuth()
+	ps.APITokenAuth()
 
 	return ps
 }
@@ -70,6 +82,129 @@ var PlatformSettingConfig = []AuthConfig{
 	},
 }
 
+// RBACConfigs the built-in rbac management apis, managing rbac is a kind of config admin operation
+var RBACConfigs = []AuthConfig{
+	{
+		Name:           "createRBACRole",
+		Description:    "创建角色",
+		Pattern:        "/api/v3/create/rbac/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateRBACRole",
+		Description:    "更新角色",
+		Regex:          updateRBACRoleRegexp,
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteRBACRole",
+		Description:    "删除角色",
+		Regex:          deleteRBACRoleRegexp,
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findManyRBACRole",
+		Description:    "查询角色",
+		Pattern:        "/api/v3/findmany/rbac/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "createRBACRoleBinding",
+		Description:    "创建角色绑定",
+		Pattern:        "/api/v3/create/rbac/role_binding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateRBACRoleBinding",
+		Description:    "更新角色绑定",
+		Regex:          updateRBACRoleBindingRegexp,
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteRBACRoleBinding",
+		Description:    "删除角色绑定",
+		Regex:          deleteRBACRoleBindingRegexp,
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findManyRBACRoleBinding",
+		Description:    "查询角色绑定",
+		Pattern:        "/api/v3/findmany/rbac/role_binding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "createRBACUserGroup",
+		Description:    "创建用户组",
+		Pattern:        "/api/v3/create/rbac/user_group",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateRBACUserGroup",
+		Description:    "更新用户组",
+		Regex:          updateRBACUserGroupRegexp,
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteRBACUserGroup",
+		Description:    "删除用户组",
+		Regex:          deleteRBACUserGroupRegexp,
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findManyRBACUserGroup",
+		Description:    "查询用户组",
+		Pattern:        "/api/v3/findmany/rbac/user_group",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "findRBACExplain",
+		Description:    "查询用户权限来源",
+		Pattern:        "/api/v3/find/rbac/explain",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	},
+}
+
+// APITokenConfigs the personal api token management apis, users can only manage their own tokens, so these apis
+// do not need to be authorized
+var APITokenConfigs = []AuthConfig{
+	{
+		Name:           "createAPIToken",
+		Description:    "创建个人API令牌",
+		Pattern:        "/api/v3/create/api_token",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "deleteAPIToken",
+		Description:    "删除个人API令牌",
+		Regex:          deleteAPITokenRegexp,
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "findManyAPIToken",
+		Description:    "查询个人API令牌",
+		Pattern:        "/api/v3/findmany/api_token",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
 // ConfigAdmin TODO
 func (ps *parseStream) ConfigAdmin() *parseStream {
 	return ParseStreamWithFramework(ps, ConfigAdminConfigs)
@@ -80,3 +215,13 @@ func (ps *parseStream) PlatformSettingConfigAuth() *parseStream {
 	return ParseStreamWithFramework(ps, PlatformSettingConfig)
 
 }
+
+// RBACAuth the built-in rbac management auth
+func (ps *parseStream) RBACAuth() *parseStream {
+	return ParseStreamWithFramework(ps, RBACConfigs)
+}
+
+// APITokenAuth the personal api token management auth
+func (ps *parseStream) APITokenAuth() *parseStream {
+	return ParseStreamWithFramework(ps, APITokenConfigs)
+}
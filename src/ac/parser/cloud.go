@@ -191,6 +191,44 @@ var cloudResourceTaskConfigs = []AuthConfig{
 			return []int64{taskID}, nil
 		},
 	},
+	{
+		Name:           "listCloudResourceTaskChangeSetPattern",
+		Description:    "查询云资源同步任务的变更集",
+		Pattern:        "/api/v3/findmany/cloud/sync/change_set",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.CloudResourceTask,
+		ResourceAction: meta.Find,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			val, err := request.getValueFromBody(common.BKCloudTaskID)
+			if err != nil {
+				return nil, err
+			}
+			taskID := val.Int()
+			if taskID <= 0 {
+				return nil, errors.New("invalid cloud sync task id")
+			}
+			return []int64{taskID}, nil
+		},
+	},
+	{
+		Name:           "applyCloudResourceTaskChangeSetRegex",
+		Description:    "审批并应用云资源同步任务的变更集",
+		Regex:          regexp.MustCompile(`^/api/v3/update/cloud/sync/change_set/[0-9]+/apply$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.CloudResourceTask,
+		ResourceAction: meta.Update,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			val, err := request.getValueFromBody(common.BKCloudTaskID)
+			if err != nil {
+				return nil, err
+			}
+			taskID := val.Int()
+			if taskID <= 0 {
+				return nil, errors.New("invalid cloud sync task id")
+			}
+			return []int64{taskID}, nil
+		},
+	},
 	{
 		Name:           "listCloudResourceRegionPattern",
 		Description:    "查询云资源同步地域信息",
@@ -13,13 +13,19 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 
 	"configcenter/src/ac/meta"
 	"configcenter/src/common"
+	httpheader "configcenter/src/common/http/header"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/common/watch"
 
 	"github.com/tidwall/gjson"
@@ -33,7 +39,8 @@ func (ps *parseStream) eventRelated() *parseStream {
 	ps.watch().
 		syncHostIdentifier().
 		pushHostIdentifier().
-		findHostIdentifierPushResult()
+		findHostIdentifierPushResult().
+		watchSubscription()
 	return ps
 }
 
@@ -54,24 +61,9 @@ func (ps *parseStream) watch() *parseStream {
 			return ps
 		}
 
-		if resource == string(watch.HostIdentifier) {
-			// redirect host identity resource to host resource in iam.
-			resource = string(watch.Host)
-		}
-
-		if resource == string(watch.BizSetRelation) {
-			// redirect biz set relation resource to biz set resource in iam.
-			resource = string(watch.BizSet)
-		}
-
-		authResource := meta.ResourceAttribute{
-			Basic: meta.Basic{
-				Type:   meta.EventWatch,
-				Action: meta.Action(resource),
-			},
-		}
+		authResource := WatchAuthAttribute(resource)
 
-		switch watch.CursorType(resource) {
+		switch watch.CursorType(authResource.Action) {
 		case watch.ObjectBase, watch.MainlineInstance, watch.InstAsst:
 			body, err := ps.RequestCtx.getRequestBody()
 			if err != nil {
@@ -112,6 +104,47 @@ func (ps *parseStream) watch() *parseStream {
 	return ps
 }
 
+// WatchAuthAttribute returns the resource attribute that is used to authorize the watch of the resource
+func WatchAuthAttribute(resource string) meta.ResourceAttribute {
+	// model metadata and templates can be viewed by anyone, so the watch of them is not authorized, either.
+	switch watch.CursorType(resource) {
+	case watch.Model, watch.ModelAttribute, watch.ModelAttributeGroup, watch.ModelUnique:
+		return meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.SkipAction}}
+	case watch.FieldTemplate:
+		return meta.ResourceAttribute{Basic: meta.Basic{Type: meta.FieldTemplate, Action: meta.SkipAction}}
+	case watch.ServiceTemplate:
+		return meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ProcessServiceTemplate, Action: meta.SkipAction}}
+	case watch.SetTemplate:
+		return meta.ResourceAttribute{Basic: meta.Basic{Type: meta.SetTemplate, Action: meta.SkipAction}}
+	}
+
+	return meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   meta.EventWatch,
+			Action: meta.Action(watchAuthResource(resource)),
+		},
+	}
+}
+
+// watchAuthResource returns the resource in iam that is used to authorize the watch of the resource
+func watchAuthResource(resource string) string {
+	switch watch.CursorType(resource) {
+	case watch.HostIdentifier:
+		// redirect host identity resource to host resource in iam.
+		return string(watch.Host)
+	case watch.BizSetRelation:
+		// redirect biz set relation resource to biz set resource in iam.
+		return string(watch.BizSet)
+	case watch.DynamicGroupMembership, watch.DynamicGroup:
+		// redirect dynamic group related resource to host resource in iam.
+		return string(watch.Host)
+	case watch.ServiceInstance:
+		// redirect service instance resource to process resource in iam.
+		return string(watch.Process)
+	}
+	return resource
+}
+
 const (
 	syncHostIdentifierPattern           = "/api/v3/event/sync/host_identifier"
 	pushHostIdentifierPattern           = "/api/v3/event/push/host_identifier"
@@ -174,3 +207,170 @@ func (ps *parseStream) findHostIdentifierPushResult() *parseStream {
 
 	return ps
 }
+
+const (
+	createWatchSubscriptionPattern         = "/api/v3/event/create/watch/subscription"
+	findWatchSubscriptionPattern           = "/api/v3/event/findmany/watch/subscription"
+	findWatchSubscriptionDeadLetterPattern = "/api/v3/event/findmany/watch/subscription/dead_letter"
+)
+
+var (
+	updateWatchSubscriptionRegexp = regexp.MustCompile(`^/api/v3/event/update/watch/subscription/[0-9]+/?$`)
+	deleteWatchSubscriptionRegexp = regexp.MustCompile(`^/api/v3/event/delete/watch/subscription/[0-9]+/?$`)
+	replayWatchSubscriptionRegexp = regexp.MustCompile(`^/api/v3/event/replay/watch/subscription/[0-9]+/?$`)
+)
+
+// watchSubscription parse the webhook subscription apis, creating or changing the subscribed resources requires
+// the watch authority of all the subscribed resources, operating an existing subscription and its dead letters
+// requires the watch authority of the resources it subscribes.
+func (ps *parseStream) watchSubscription() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createWatchSubscriptionPattern, http.MethodPost) {
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		resources := gjson.GetBytes(body, watch.SubscriptionResourcesField).Array()
+		if len(resources) == 0 {
+			ps.err = errors.New("create watch subscription, but bk_resources is not set")
+			return ps
+		}
+
+		for _, resource := range resources {
+			ps.Attribute.Resources = append(ps.Attribute.Resources, WatchAuthAttribute(resource.String()))
+		}
+		return ps
+	}
+
+	// update subscription, the watch authority of both the subscribed resources and the new resources is required
+	if ps.hitRegexp(updateWatchSubscriptionRegexp, http.MethodPut) {
+		subID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("update watch subscription, but got invalid subscription id %s",
+				ps.RequestCtx.Elements[6])
+			return ps
+		}
+
+		resources, err := ps.getWatchSubscriptionResources(subID)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		for _, resource := range gjson.GetBytes(body, watch.SubscriptionResourcesField).Array() {
+			resources = append(resources, resource.String())
+		}
+
+		ps.Attribute.Resources = watchSubscriptionAuthAttributes(resources)
+		return ps
+	}
+
+	// the subscriptions are filtered by the watch authority of the subscribed resources in event server
+	if ps.hitPattern(findWatchSubscriptionPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitPattern(findWatchSubscriptionDeadLetterPattern, http.MethodPost) {
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		subID := gjson.GetBytes(body, "bk_subscription_id").Int()
+		if subID <= 0 {
+			ps.err = errors.New("find watch subscription dead letter, but bk_subscription_id is invalid")
+			return ps
+		}
+
+		resources, err := ps.getWatchSubscriptionResources(subID)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = watchSubscriptionAuthAttributes(resources)
+		return ps
+	}
+
+	if ps.hitRegexp(deleteWatchSubscriptionRegexp, http.MethodDelete) ||
+		ps.hitRegexp(replayWatchSubscriptionRegexp, http.MethodPost) {
+
+		subID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("operate watch subscription, but got invalid subscription id %s",
+				ps.RequestCtx.Elements[6])
+			return ps
+		}
+
+		resources, err := ps.getWatchSubscriptionResources(subID)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = watchSubscriptionAuthAttributes(resources)
+		return ps
+	}
+
+	return ps
+}
+
+// getWatchSubscriptionResources get the resources that the watch subscription subscribes
+func (ps *parseStream) getWatchSubscriptionResources(subID int64) ([]string, error) {
+	cond := mapstr.MapStr{watch.SubscriptionIDField: subID}
+	cond = util.SetQueryOwner(cond, httpheader.GetSupplierAccount(ps.RequestCtx.Header))
+
+	opt := &metadata.DistinctFieldOption{
+		TableName: common.BKTableNameWatchSubscription,
+		Field:     watch.SubscriptionResourcesField,
+		Filter:    cond,
+	}
+	result, err := ps.engine.CoreAPI.CoreService().Common().GetDistinctField(context.Background(),
+		ps.RequestCtx.Header, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("watch subscription %d not found", subID)
+	}
+
+	resources := make([]string, 0, len(result))
+	for _, resource := range result {
+		resources = append(resources, util.GetStrByInterface(resource))
+	}
+	return resources, nil
+}
+
+// watchSubscriptionAuthAttributes returns the watch authority attributes of the distinct subscribed resources
+func watchSubscriptionAuthAttributes(resources []string) []meta.ResourceAttribute {
+	attributes := make([]meta.ResourceAttribute, 0, len(resources))
+	existing := make(map[string]struct{})
+	for _, resource := range resources {
+		if _, exists := existing[resource]; exists {
+			continue
+		}
+		existing[resource] = struct{}{}
+		attributes = append(attributes, WatchAuthAttribute(resource))
+	}
+	return attributes
+}
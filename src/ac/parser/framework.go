@@ -141,6 +141,6 @@ func ParseStreamWithFramework(ps *parseStream, authConfigs []AuthConfig) *parseS
 	if resources != nil {
 		ps.Attribute.Resources = resources
 	}
-	blog.V(7).Infof("ParseStreamWithFramework result: %s", resources)
+	blog.V(7).Infof("ParseStreamWithFramework result: %+v", resources)
 	return ps
 }
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -367,6 +368,10 @@ const (
 
 	listHostDetailAndTopologyPattern = "/api/v3/findmany/hosts/detail_topo"
 
+	listHostRecycleBinPattern        = "/api/v3/findmany/hosts/recycle_bin"
+	previewRestoreHostPattern        = "/api/v3/find/hosts/recycle_bin/restore_preview"
+	restoreHostFromRecycleBinPattern = "/api/v3/update/hosts/recycle_bin/restore"
+
 	findHostsServiceTemplatesPattern = "/api/v3/findmany/hosts/service_template"
 
 	// 特殊接口，给蓝鲸业务使用
@@ -780,6 +785,49 @@ func (ps *parseStream) host() *parseStream {
 		return ps
 	}
 
+	// list the deleted hosts in the recycle bin, the archived hosts can only be viewed by the user who can restore
+	// them to the resource pool, and to the business if the hosts are filtered by business
+	if ps.hitPattern(listHostRecycleBinPattern, http.MethodPost) {
+		bizID, err := ps.RequestCtx.getValueFromBody(common.BKAppIDField)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources, ps.err = ps.genRestoreHostAuthAttrs([]int64{bizID.Int()})
+		return ps
+	}
+
+	// preview or restore the deleted hosts in the recycle bin, the hosts are added to the resource pool and then
+	// transferred to the businesses of their archived host module relations that still exist
+	if ps.hitPattern(previewRestoreHostPattern, http.MethodPost) ||
+		ps.hitPattern(restoreHostFromRecycleBinPattern, http.MethodPost) {
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		opt := new(metadata.RestoreRecycleBinOption)
+		if err := json.Unmarshal(body, opt); err != nil {
+			ps.err = err
+			return ps
+		}
+
+		preview, err := ps.engine.CoreAPI.CoreService().Instance().PreviewRestoreRecycleBin(context.Background(),
+			ps.RequestCtx.Header, common.BKInnerObjIDHost, opt)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		bizIDs := make([]int64, len(preview.HostRelations))
+		for i, rel := range preview.HostRelations {
+			bizIDs[i] = rel.AppID
+		}
+		ps.Attribute.Resources, ps.err = ps.genRestoreHostAuthAttrs(bizIDs)
+		return ps
+	}
+
 	// list host's detail and it's topology info
 	if ps.hitPattern(listHostDetailAndTopologyPattern, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
@@ -1600,3 +1648,59 @@ func (ps *parseStream) cloudHost() *parseStream {
 	}
 	return ps
 }
+
+// genRestoreHostAuthAttrs generates the auth attributes of restoring the deleted hosts to the businesses, the hosts
+// are added to the resource pool first, and then transferred to the businesses other than the resource pool
+func (ps *parseStream) genRestoreHostAuthAttrs(bizIDs []int64) ([]meta.ResourceAttribute, error) {
+	dirID, err := ps.getResourcePoolDefaultDirID()
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory id value, %s", err.Error())
+	}
+
+	poolBizID, err := ps.getResourcePoolBusinessID()
+	if err != nil {
+		return nil, err
+	}
+
+	return genRestoreHostAuthAttrs(dirID, poolBizID, bizIDs), nil
+}
+
+func genRestoreHostAuthAttrs(dirID, poolBizID int64, bizIDs []int64) []meta.ResourceAttribute {
+	attrs := []meta.ResourceAttribute{
+		{
+			Basic: meta.Basic{
+				Type:   meta.HostInstance,
+				Action: meta.AddHostToResourcePool,
+			},
+			Layers: []meta.Item{
+				{
+					Type:       meta.ResourcePoolDirectory,
+					InstanceID: dirID,
+				},
+			},
+		},
+	}
+
+	bizIDMap := make(map[int64]struct{})
+	for _, bizID := range bizIDs {
+		if bizID <= 0 || bizID == poolBizID {
+			continue
+		}
+
+		if _, exists := bizIDMap[bizID]; exists {
+			continue
+		}
+		bizIDMap[bizID] = struct{}{}
+
+		attrs = append(attrs, meta.ResourceAttribute{
+			BusinessID: bizID,
+			Basic: meta.Basic{
+				Type:   meta.HostInstance,
+				Action: meta.MoveResPoolHostToBizIdleModule,
+			},
+			Layers: []meta.Item{{Type: meta.ModelModule, InstanceID: dirID}, {Type: meta.Business, InstanceID: bizID}},
+		})
+	}
+
+	return attrs
+}
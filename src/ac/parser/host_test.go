@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2019 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"testing"
+
+	"configcenter/src/ac/meta"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenRestoreHostAuthAttrs(t *testing.T) {
+	addToPool := meta.ResourceAttribute{
+		Basic:  meta.Basic{Type: meta.HostInstance, Action: meta.AddHostToResourcePool},
+		Layers: []meta.Item{{Type: meta.ResourcePoolDirectory, InstanceID: 3}},
+	}
+	transferToBiz := func(bizID int64) meta.ResourceAttribute {
+		return meta.ResourceAttribute{
+			BusinessID: bizID,
+			Basic:      meta.Basic{Type: meta.HostInstance, Action: meta.MoveResPoolHostToBizIdleModule},
+			Layers:     []meta.Item{{Type: meta.ModelModule, InstanceID: 3}, {Type: meta.Business, InstanceID: bizID}},
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		bizIDs []int64
+		attrs  []meta.ResourceAttribute
+	}{{
+		name:  "no business",
+		attrs: []meta.ResourceAttribute{addToPool},
+	}, {
+		name:   "restore to resource pool",
+		bizIDs: []int64{0, 1, 1},
+		attrs:  []meta.ResourceAttribute{addToPool},
+	}, {
+		name:   "restore to businesses",
+		bizIDs: []int64{2, 1, 5, 2},
+		attrs:  []meta.ResourceAttribute{addToPool, transferToBiz(2), transferToBiz(5)},
+	}}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.attrs, genRestoreHostAuthAttrs(3, 1, testCase.bizIDs), testCase.name)
+	}
+}
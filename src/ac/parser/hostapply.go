@@ -66,6 +66,15 @@ var HostApplyAuthConfigs = []AuthConfig{
 		BizIndex:       5,
 		ResourceType:   meta.HostApply,
 		ResourceAction: meta.DefaultHostApply,
+	}, {
+		Name:           "ListHostApplyDriftRegex",
+		Description:    "查询主机属性自动应用偏离报告",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/host_apply_drift/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		BizIndex:       5,
+		ResourceType:   meta.HostApply,
+		ResourceAction: meta.DefaultHostApply,
 	}, {
 		Name:           "FindmanyModuleHostApplyTaskStatus",
 		Description:    "查询模块场景下主机自动应用任务状态",
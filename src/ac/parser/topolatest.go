@@ -902,6 +902,13 @@ var (
 	countObjectInstancesRegexp  = regexp.MustCompile(`^/api/v3/count/instances/object/[^\s/]+/?$`)
 	// excel 导入主机专用接口
 	findObjectInstancesForExcelRegexp = regexp.MustCompile(`^/api/v3/find/instance/[^\s/]+/?$`)
+
+	listObjectInstanceRecycleBinRegexp = regexp.MustCompile(
+		`^/api/v3/findmany/recycle_bin/instance/object/[^\s/]+/?$`)
+	previewRestoreObjectInstanceRegexp = regexp.MustCompile(
+		`^/api/v3/find/recycle_bin/instance/object/[^\s/]+/restore_preview/?$`)
+	restoreObjectInstanceRegexp = regexp.MustCompile(
+		`^/api/v3/update/recycle_bin/instance/object/[^\s/]+/restore/?$`)
 )
 
 // NOCC:golint/fnsize(设计如此)
@@ -948,6 +955,38 @@ func (ps *parseStream) objectInstanceLatest() *parseStream {
 		return ps
 	}
 
+	// list, preview or restore the deleted instances in the recycle bin, restoring is the same as creating the
+	// instances, the archived instances can only be viewed by the user who can restore them
+	if ps.hitRegexp(listObjectInstanceRecycleBinRegexp, http.MethodPost) ||
+		ps.hitRegexp(previewRestoreObjectInstanceRegexp, http.MethodPost) ||
+		ps.hitRegexp(restoreObjectInstanceRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) < 7 {
+			ps.err = errors.New("restore instance, but got invalid url")
+			return ps
+		}
+
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[6]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		instanceType, err := ps.getInstanceTypeByObject(model.ObjectID, model.ID)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   instanceType,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
 	// create instance operation
 	if ps.hitRegexp(createObjectManyInstanceByImportLatestRegexp, http.MethodPost) {
 		if len(ps.RequestCtx.Elements) != 7 {
@@ -1325,10 +1325,14 @@ func (ps *parseStream) objectSet() *parseStream {
 }
 
 var (
-	searchAuditDict   = `/api/v3/find/audit_dict`
-	searchAuditList   = `/api/v3/findmany/audit_list`
-	searchAuditDetail = `/api/v3/find/audit`
-	searchInstAudit   = `/api/v3/find/inst_audit`
+	searchAuditDict    = `/api/v3/find/audit_dict`
+	searchAuditList    = `/api/v3/findmany/audit_list`
+	searchAuditArchive = `/api/v3/findmany/audit_archive`
+	searchAuditDetail  = `/api/v3/find/audit`
+	searchInstAudit    = `/api/v3/find/inst_audit`
+
+	previewRevertAudit = `/api/v3/find/audit/revert_preview`
+	revertAudit        = `/api/v3/update/audit/revert`
 )
 
 // NOCC:golint/fnsize(设计如此)
@@ -1349,7 +1353,7 @@ func (ps *parseStream) audit() *parseStream {
 		return ps
 	}
 
-	if ps.hitPattern(searchAuditList, http.MethodPost) {
+	if ps.hitPattern(searchAuditList, http.MethodPost) || ps.hitPattern(searchAuditArchive, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
 				Basic: meta.Basic{
@@ -1361,6 +1365,19 @@ func (ps *parseStream) audit() *parseStream {
 		return ps
 	}
 
+	// the inverse operations of the revert are authorized by the scene server, so only the audit log is authorized here
+	if ps.hitPattern(previewRevertAudit, http.MethodPost) || ps.hitPattern(revertAudit, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.AuditLog,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
 	if ps.hitPattern(searchAuditDetail, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
@@ -0,0 +1,190 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/authserver"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+)
+
+// SystemID is the system id of the permission to apply when authorizing with the built-in rbac
+const SystemID = "bk_cmdb"
+
+type authorizer struct {
+	authClientSet authserver.AuthServerClientInterface
+}
+
+// NewAuthorizer new authorizer that authorize with the built-in rbac by auth server
+func NewAuthorizer(clientSet apimachinery.ClientSetInterface) *authorizer {
+	return &authorizer{authClientSet: clientSet.AuthServer()}
+}
+
+// AuthorizeBatch batch authorization will not pass if one of them does not have permission
+func (a *authorizer) AuthorizeBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.authorizeBatch(ctx, h, true, user, resources...)
+}
+
+// AuthorizeAnyBatch batch authorization will pass if one of them has permission
+func (a *authorizer) AuthorizeAnyBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.authorizeBatch(ctx, h, false, user, resources...)
+}
+
+func (a *authorizer) authorizeBatch(ctx context.Context, h http.Header, exact bool, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+
+	rid := httpheader.GetRid(h)
+
+	decisions := make([]types.Decision, len(resources))
+	if !auth.EnableAuthorize() {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	// resources that need to be authorized, skipped resources' decisions are set as authorized
+	input := &meta.AuthorizeResourcesParam{UserName: user.UserName, Resources: make([]meta.ResourceAttribute, 0)}
+	for index, resource := range resources {
+		if resource.Action == meta.SkipAction {
+			decisions[index].Authorized = true
+			blog.V(5).Infof("skip authorization for resource: %+v, rid: %s", resource, rid)
+			continue
+		}
+		input.Resources = append(input.Resources, resource)
+	}
+
+	// all resources are skipped
+	if len(input.Resources) == 0 {
+		return decisions, nil
+	}
+
+	var authDecisions []types.Decision
+	var err error
+	if exact {
+		authDecisions, err = a.authClientSet.RBACAuthorizeBatch(ctx, h, input)
+	} else {
+		authDecisions, err = a.authClientSet.RBACAuthorizeAnyBatch(ctx, h, input)
+	}
+	if err != nil {
+		blog.ErrorJSON("rbac authorize batch failed, err: %s, exact: %s, input: %s, rid: %s", err, exact, input, rid)
+		return nil, err
+	}
+
+	index := 0
+	for _, decision := range authDecisions {
+		// skip resources' decisions are already set as authorized
+		for decisions[index].Authorized {
+			index++
+		}
+		decisions[index].Authorized = decision.Authorized
+		index++
+	}
+
+	return decisions, nil
+}
+
+// ListAuthorizedResources list the ids of the resources that the user has the permission of
+func (a *authorizer) ListAuthorizedResources(ctx context.Context, h http.Header,
+	input meta.ListAuthorizedResourcesParam) (*types.AuthorizeList, error) {
+	return a.authClientSet.RBACListAuthorizedResources(ctx, h, input)
+}
+
+// GetNoAuthSkipUrl there is no permission center to apply for permissions in rbac mode, returns an empty url
+func (a *authorizer) GetNoAuthSkipUrl(ctx context.Context, h http.Header,
+	input *metadata.IamPermission) (string, error) {
+	return "", nil
+}
+
+// GetPermissionToApply get the permissions that the user needs, they are presented by the cmdb resource types and
+// actions which are used in the rbac roles
+func (a *authorizer) GetPermissionToApply(ctx context.Context, h http.Header,
+	input []meta.ResourceAttribute) (*metadata.IamPermission, error) {
+	return GetPermissionToApply(input), nil
+}
+
+// GetPermissionToApply generate the permissions to apply of the resources with the cmdb resource types and actions
+func GetPermissionToApply(resources []meta.ResourceAttribute) *metadata.IamPermission {
+	permission := &metadata.IamPermission{SystemID: SystemID, SystemName: "CMDB",
+		Actions: make([]metadata.IamAction, 0)}
+
+	actionIndex := make(map[string]int)
+	for _, res := range resources {
+		if res.Action == meta.SkipAction {
+			continue
+		}
+
+		actionID := string(res.Type) + ":" + string(res.Action)
+		index, exists := actionIndex[actionID]
+		if !exists {
+			index = len(permission.Actions)
+			actionIndex[actionID] = index
+			permission.Actions = append(permission.Actions, metadata.IamAction{
+				ID:   actionID,
+				Name: actionID,
+				RelatedResourceTypes: []metadata.IamResourceType{{
+					SystemID: SystemID,
+					Type:     string(res.Type),
+					TypeName: string(res.Type),
+				}},
+			})
+		}
+
+		instance := make([]metadata.IamResourceInstance, 0)
+		for _, layer := range res.Layers {
+			instance = append(instance, newResourceInstance(layer.Type, layer.InstanceID, layer.InstanceIDEx))
+		}
+		if res.InstanceID > 0 || res.InstanceIDEx != "" {
+			instance = append(instance, newResourceInstance(res.Type, res.InstanceID, res.InstanceIDEx))
+		}
+		if len(instance) == 0 {
+			continue
+		}
+
+		resType := &permission.Actions[index].RelatedResourceTypes[0]
+		resType.Instances = append(resType.Instances, instance)
+	}
+
+	return permission
+}
+
+func newResourceInstance(resType meta.ResourceType, id int64, idEx string) metadata.IamResourceInstance {
+	if idEx == "" {
+		idEx = strconv.FormatInt(id, 10)
+	}
+	return metadata.IamResourceInstance{Type: string(resType), TypeName: string(resType), ID: idEx}
+}
+
+// RegisterResourceCreatorAction the creator is not granted any permission automatically in rbac mode, the
+// permissions are all granted by the role bindings
+func (a *authorizer) RegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstanceWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return make([]metadata.IamCreatorActionPolicy, 0), nil
+}
+
+// BatchRegisterResourceCreatorAction the creator is not granted any permission automatically in rbac mode
+func (a *authorizer) BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstancesWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return make([]metadata.IamCreatorActionPolicy, 0), nil
+}
@@ -0,0 +1,281 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// Grant is a role that is bound to the user by a role binding
+type Grant struct {
+	Role    metadata.RBACRole
+	Binding metadata.RBACRoleBinding
+	// Group is the user group through which the role is bound to the user, it is empty if the role is bound to the
+	// user directly
+	Group string
+}
+
+// Policy is all the rbac roles that are bound to a user
+type Policy struct {
+	User string
+	// Admin means that the user is a rbac administrator who has all the permissions
+	Admin  bool
+	Groups []string
+	Grants []Grant
+}
+
+// NewPolicy generate the rbac policy of the user by the groups that the user belongs to, and the role bindings of
+// the user and the groups with their roles, bindings whose role is not found are ignored
+func NewPolicy(user string, admin bool, groups []string, roles []metadata.RBACRole,
+	bindings []metadata.RBACRoleBinding) *Policy {
+
+	roleMap := make(map[int64]metadata.RBACRole)
+	for _, role := range roles {
+		roleMap[role.ID] = role
+	}
+
+	groupMap := make(map[string]struct{})
+	for _, group := range groups {
+		groupMap[group] = struct{}{}
+	}
+
+	policy := &Policy{User: user, Admin: admin, Groups: groups, Grants: make([]Grant, 0)}
+	for _, binding := range bindings {
+		role, exists := roleMap[binding.RoleID]
+		if !exists {
+			continue
+		}
+
+		grant := Grant{Role: role, Binding: binding}
+		if !util.InStrArr(binding.Users, user) {
+			bound := false
+			for _, group := range binding.Groups {
+				if _, exists := groupMap[group]; exists {
+					grant.Group, bound = group, true
+					break
+				}
+			}
+
+			if !bound {
+				continue
+			}
+		}
+
+		policy.Grants = append(policy.Grants, grant)
+	}
+
+	return policy
+}
+
+// Authorize check if the user has the permission to do the action with the resource
+func (p *Policy) Authorize(attr *meta.ResourceAttribute) bool {
+	if p.Admin || attr.Action == meta.SkipAction {
+		return true
+	}
+
+	for _, grant := range p.Grants {
+		if allows(grant.Role, attr.Type, attr.Action) && matchScope(grant.Binding.Scope, attr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeAny check if the user has the permission to do the action with any resource of the resource type
+func (p *Policy) AuthorizeAny(attr *meta.ResourceAttribute) bool {
+	if p.Admin || attr.Action == meta.SkipAction {
+		return true
+	}
+
+	for _, grant := range p.Grants {
+		if allows(grant.Role, attr.Type, attr.Action) {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain returns all the grants that authorize the user to do the action with the resource
+func (p *Policy) Explain(attr *meta.ResourceAttribute) []metadata.RBACGrant {
+	grants := make([]metadata.RBACGrant, 0)
+	for _, grant := range p.Grants {
+		if !allows(grant.Role, attr.Type, attr.Action) || !matchScope(grant.Binding.Scope, attr) {
+			continue
+		}
+
+		grants = append(grants, metadata.RBACGrant{
+			RoleID:    grant.Role.ID,
+			RoleName:  grant.Role.Name,
+			BindingID: grant.Binding.ID,
+			Scope:     grant.Binding.Scope,
+			Group:     grant.Group,
+		})
+	}
+	return grants
+}
+
+// AuthorizedList is the resources of a resource type that the user has the permission to do an action with
+type AuthorizedList struct {
+	// IsAny means that the user has the permission of all the resources
+	IsAny bool
+	// IDs are the ids of the authorized resources
+	IDs []string
+	// ClassificationIDs are the ids of the authorized model classifications, the user has the permission of all
+	// the models in these classifications, it is only set for model resource type
+	ClassificationIDs []int64
+}
+
+// ListAuthorized list the resources of the resource type that the user has the permission to do the action with,
+// if business id is set, the user has the permission of all the resources if it is authorized on the business
+func (p *Policy) ListAuthorized(resType meta.ResourceType, action meta.Action, bizID int64) *AuthorizedList {
+	if p.Admin {
+		return &AuthorizedList{IsAny: true}
+	}
+
+	ids := make([]string, 0)
+	classificationIDs := make([]int64, 0)
+	for _, grant := range p.Grants {
+		if !allows(grant.Role, resType, action) {
+			continue
+		}
+
+		scope := grant.Binding.Scope
+		switch scope.Type {
+		case metadata.RBACScopeGlobal:
+			return &AuthorizedList{IsAny: true}
+		case metadata.RBACScopeBiz:
+			if bizID > 0 && util.InStrArr(scope.IDs, strconv.FormatInt(bizID, 10)) {
+				return &AuthorizedList{IsAny: true}
+			}
+			if resType == meta.Business {
+				ids = append(ids, scope.IDs...)
+			}
+		case metadata.RBACScopeBizSet:
+			if resType == meta.BizSet {
+				ids = append(ids, scope.IDs...)
+			}
+		case metadata.RBACScopeClassification:
+			switch resType {
+			case meta.ModelClassification:
+				ids = append(ids, scope.IDs...)
+			case meta.Model:
+				for _, id := range scope.IDs {
+					classificationID, _ := strconv.ParseInt(id, 10, 64)
+					classificationIDs = append(classificationIDs, classificationID)
+				}
+			}
+		case metadata.RBACScopeInstance:
+			if scope.ResourceType == string(resType) {
+				ids = append(ids, scope.IDs...)
+			}
+		}
+	}
+
+	return &AuthorizedList{
+		IDs:               util.StrArrayUnique(ids),
+		ClassificationIDs: util.IntArrayUnique(classificationIDs),
+	}
+}
+
+// NeedClassification returns whether any grant of the policy is scoped on model classifications, the model
+// classifications of the resources need to be set in their layers for these grants to take effect
+func (p *Policy) NeedClassification() bool {
+	for _, grant := range p.Grants {
+		if grant.Binding.Scope.Type == metadata.RBACScopeClassification {
+			return true
+		}
+	}
+	return false
+}
+
+// allows check if the role has the permission of the action on the resource type
+func allows(role metadata.RBACRole, resType meta.ResourceType, action meta.Action) bool {
+	for _, permission := range role.Permissions {
+		if permission.ResourceType != metadata.RBACAny && permission.ResourceType != string(resType) {
+			continue
+		}
+
+		for _, act := range permission.Actions {
+			if act == metadata.RBACAny || act == string(action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchScope check if the resource is in the scope of the role binding
+func matchScope(scope metadata.RBACScope, attr *meta.ResourceAttribute) bool {
+	switch scope.Type {
+	case metadata.RBACScopeGlobal:
+		return true
+	case metadata.RBACScopeBiz:
+		if attr.BusinessID > 0 && util.InStrArr(scope.IDs, strconv.FormatInt(attr.BusinessID, 10)) {
+			return true
+		}
+		return matchResource(scope.IDs, meta.Business, attr)
+	case metadata.RBACScopeBizSet:
+		return matchResource(scope.IDs, meta.BizSet, attr)
+	case metadata.RBACScopeClassification:
+		return matchResource(scope.IDs, meta.ModelClassification, attr)
+	case metadata.RBACScopeInstance:
+		// the instance ids of different resource types may be the same, so the resource type must also match
+		return scope.ResourceType == string(attr.Type) && matchID(scope.IDs, attr.InstanceID, attr.InstanceIDEx)
+	}
+	return false
+}
+
+// matchResource check if the resource or one of its layers is of the resource type and its id is in the ids
+func matchResource(ids []string, resType meta.ResourceType, attr *meta.ResourceAttribute) bool {
+	if attr.Type == resType && matchID(ids, attr.InstanceID, attr.InstanceIDEx) {
+		return true
+	}
+
+	for _, layer := range attr.Layers {
+		if layer.Type == resType && matchID(ids, layer.InstanceID, layer.InstanceIDEx) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchID(ids []string, id int64, idEx string) bool {
+	if id > 0 && util.InStrArr(ids, strconv.FormatInt(id, 10)) {
+		return true
+	}
+	return idEx != "" && util.InStrArr(ids, idEx)
+}
+
+// ConvertAuthResource convert the auth resource of the api to the resource attribute that can be authorized
+func ConvertAuthResource(res metadata.AuthResource, supplierAccount string) meta.ResourceAttribute {
+	attr := meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:         meta.ResourceType(res.ResourceType),
+			Action:       meta.Action(res.Action),
+			InstanceID:   res.ResourceID,
+			InstanceIDEx: res.ResourceIDEx,
+		},
+		SupplierAccount: supplierAccount,
+		BusinessID:      res.BizID,
+	}
+
+	for _, item := range res.ParentLayers {
+		attr.Layers = append(attr.Layers, meta.Item{Type: meta.ResourceType(item.ResourceType),
+			InstanceID: item.ResourceID, InstanceIDEx: item.ResourceIDEx})
+	}
+	return attr
+}
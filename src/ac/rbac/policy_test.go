@@ -0,0 +1,204 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common/metadata"
+)
+
+func testPolicy(admin bool) *Policy {
+	roles := []metadata.RBACRole{
+		{ID: 1, Name: "host viewer", Permissions: []metadata.RBACPermission{
+			{ResourceType: string(meta.HostInstance), Actions: []string{string(meta.Find)}},
+		}},
+		{ID: 2, Name: "model admin", Permissions: []metadata.RBACPermission{
+			{ResourceType: string(meta.Model), Actions: []string{metadata.RBACAny}},
+		}},
+		{ID: 3, Name: "instance editor", Permissions: []metadata.RBACPermission{
+			{ResourceType: metadata.RBACAny, Actions: []string{string(meta.Update)}},
+		}},
+	}
+
+	bindings := []metadata.RBACRoleBinding{
+		{ID: 1, RoleID: 1, Users: []string{"alice"},
+			Scope: metadata.RBACScope{Type: metadata.RBACScopeBiz, IDs: []string{"2"}}},
+		{ID: 2, RoleID: 2, Groups: []string{"ops"},
+			Scope: metadata.RBACScope{Type: metadata.RBACScopeClassification, IDs: []string{"5"}}},
+		{ID: 3, RoleID: 3, Users: []string{"alice"},
+			Scope: metadata.RBACScope{Type: metadata.RBACScopeInstance, IDs: []string{"10", "host_abc"},
+				ResourceType: string(meta.MainlineInstance)}},
+		{ID: 6, RoleID: 3, Users: []string{"alice"},
+			Scope: metadata.RBACScope{Type: metadata.RBACScopeInstance, IDs: []string{"10"},
+				ResourceType: string(meta.Model)}},
+		// binding of other users or of a role that does not exist is ignored
+		{ID: 4, RoleID: 1, Users: []string{"bob"}, Scope: metadata.RBACScope{Type: metadata.RBACScopeGlobal}},
+		{ID: 5, RoleID: 100, Users: []string{"alice"}, Scope: metadata.RBACScope{Type: metadata.RBACScopeGlobal}},
+	}
+
+	return NewPolicy("alice", admin, []string{"ops"}, roles, bindings)
+}
+
+func TestPolicyAuthorize(t *testing.T) {
+	policy := testPolicy(false)
+
+	if len(policy.Grants) != 4 {
+		t.Fatalf("expect 4 grants, got %d", len(policy.Grants))
+	}
+
+	cases := []struct {
+		name string
+		attr meta.ResourceAttribute
+		pass bool
+	}{
+		{
+			name: "host in the business scope",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Find}, BusinessID: 2},
+			pass: true,
+		}, {
+			name: "host in other business",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Find}, BusinessID: 3},
+			pass: false,
+		}, {
+			name: "action not in the role",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Delete}, BusinessID: 2},
+			pass: false,
+		}, {
+			name: "model in the classification scope by layer",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Delete, InstanceID: 7},
+				Layers: []meta.Item{{Type: meta.ModelClassification, InstanceID: 5}}},
+			pass: true,
+		}, {
+			name: "model in other classification",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Delete, InstanceID: 7},
+				Layers: []meta.Item{{Type: meta.ModelClassification, InstanceID: 6}}},
+			pass: false,
+		}, {
+			name: "instance in the instance scope",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.MainlineInstance, Action: meta.Update,
+				InstanceID: 10}},
+			pass: true,
+		}, {
+			name: "instance in the instance scope by string id",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.MainlineInstance, Action: meta.Update,
+				InstanceIDEx: "host_abc"}},
+			pass: true,
+		}, {
+			name: "instance of other resource type with the same id",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.KubeCluster, Action: meta.Update,
+				InstanceID: 10}},
+			pass: false,
+		}, {
+			name: "instance of other resource type with the same string id",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.KubeCluster, Action: meta.Update,
+				InstanceIDEx: "host_abc"}},
+			pass: false,
+		}, {
+			name: "skipped action",
+			attr: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.SkipAction}},
+			pass: true,
+		},
+	}
+
+	for _, c := range cases {
+		if pass := policy.Authorize(&c.attr); pass != c.pass {
+			t.Errorf("%s: expect authorized %v, got %v", c.name, c.pass, pass)
+		}
+	}
+
+	attr := &meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Find}, BusinessID: 3}
+	if !policy.AuthorizeAny(attr) {
+		t.Errorf("expect any authorized with host find permission")
+	}
+
+	if !testPolicy(true).Authorize(&meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business,
+		Action: meta.Delete}}) {
+		t.Errorf("expect admin to be authorized")
+	}
+}
+
+func TestPolicyExplain(t *testing.T) {
+	policy := testPolicy(false)
+
+	attr := &meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Update, InstanceID: 10},
+		Layers: []meta.Item{{Type: meta.ModelClassification, InstanceID: 5}}}
+	grants := policy.Explain(attr)
+	if len(grants) != 2 {
+		t.Fatalf("expect 2 grants, got %+v", grants)
+	}
+
+	if grants[0].RoleID != 2 || grants[0].Group != "ops" {
+		t.Errorf("expect model admin role granted by ops group, got %+v", grants[0])
+	}
+
+	if grants[1].RoleID != 3 || grants[1].BindingID != 6 || grants[1].Group != "" {
+		t.Errorf("expect instance editor role granted to the user directly, got %+v", grants[1])
+	}
+}
+
+func TestPolicyListAuthorized(t *testing.T) {
+	policy := testPolicy(false)
+
+	list := policy.ListAuthorized(meta.HostInstance, meta.Find, 2)
+	if !list.IsAny {
+		t.Errorf("expect all hosts in the business authorized, got %+v", list)
+	}
+
+	list = policy.ListAuthorized(meta.Business, meta.Find, 0)
+	if list.IsAny || len(list.IDs) != 0 {
+		t.Errorf("expect no business authorized, got %+v", list)
+	}
+
+	list = policy.ListAuthorized(meta.Model, meta.Update, 0)
+	expect := &AuthorizedList{IDs: []string{"10"}, ClassificationIDs: []int64{5}}
+	if !reflect.DeepEqual(list, expect) {
+		t.Errorf("expect %+v, got %+v", expect, list)
+	}
+
+	list = policy.ListAuthorized(meta.MainlineInstance, meta.Update, 0)
+	expect = &AuthorizedList{IDs: []string{"10", "host_abc"}, ClassificationIDs: []int64{}}
+	if !reflect.DeepEqual(list, expect) {
+		t.Errorf("expect %+v, got %+v", expect, list)
+	}
+
+	list = policy.ListAuthorized(meta.KubeCluster, meta.Update, 0)
+	if list.IsAny || len(list.IDs) != 0 {
+		t.Errorf("expect no kube cluster authorized, got %+v", list)
+	}
+}
+
+func TestRBACScopeValidate(t *testing.T) {
+	cases := []struct {
+		scope metadata.RBACScope
+		valid bool
+	}{
+		{scope: metadata.RBACScope{Type: metadata.RBACScopeGlobal}, valid: true},
+		{scope: metadata.RBACScope{Type: metadata.RBACScopeBiz, IDs: []string{"2"}}, valid: true},
+		{scope: metadata.RBACScope{Type: metadata.RBACScopeBiz, IDs: []string{"2"},
+			ResourceType: string(meta.Business)}, valid: false},
+		{scope: metadata.RBACScope{Type: metadata.RBACScopeInstance, IDs: []string{"10"},
+			ResourceType: string(meta.MainlineInstance)}, valid: true},
+		{scope: metadata.RBACScope{Type: metadata.RBACScopeInstance, IDs: []string{"10"}}, valid: false},
+		{scope: metadata.RBACScope{Type: metadata.RBACScopeInstance, IDs: []string{"10"},
+			ResourceType: metadata.RBACAny}, valid: false},
+	}
+
+	for _, c := range cases {
+		if valid := c.scope.Validate().ErrCode == 0; valid != c.valid {
+			t.Errorf("scope %+v: expect valid %v, got %v", c.scope, c.valid, valid)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rbac is the built-in role based access control of cmdb, it can be used instead of the BlueKing IAM.
+// roles are bound to the auth resource types and actions, and are assigned to users and user groups on a scope of
+// resources, all of them are stored in cmdb itself.
+package rbac
+
+import (
+	"strings"
+
+	"configcenter/src/common/auth"
+	cc "configcenter/src/common/backbone/configcenter"
+)
+
+const (
+	// ModeIAM authorize with the BlueKing IAM, it is the default auth mode
+	ModeIAM = "iam"
+	// ModeRBAC authorize with the built-in rbac
+	ModeRBAC = "rbac"
+
+	modeConfigKey   = "authServer.mode"
+	adminsConfigKey = "authServer.rbac.admins"
+)
+
+// Enabled returns whether authorization is enabled and is done by the built-in rbac instead of iam
+func Enabled() bool {
+	if !auth.EnableAuthorize() {
+		return false
+	}
+
+	mode, _ := cc.String(modeConfigKey)
+	return strings.TrimSpace(mode) == ModeRBAC
+}
+
+// Admins returns the configured rbac administrators who have all the permissions, they are used to manage the
+// roles before any role is bound
+func Admins() []string {
+	adminStr, _ := cc.String(adminsConfigKey)
+
+	admins := make([]string, 0)
+	for _, admin := range strings.Split(adminStr, ",") {
+		if admin = strings.TrimSpace(admin); admin != "" {
+			admins = append(admins, admin)
+		}
+	}
+	return admins
+}
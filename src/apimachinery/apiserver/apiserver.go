@@ -126,6 +126,12 @@ type ApiServerClientInterface interface {
 	GroupRelResByIDs(ctx context.Context, h http.Header, kind metadata.GroupByResKind,
 		opt *metadata.GroupRelResByIDsOption) (map[int64][]interface{}, errors.CCErrorCoder)
 
+	CreateAPIToken(ctx context.Context, h http.Header, opt *metadata.CreateAPITokenOption) (
+		*metadata.CreateAPITokenResult, errors.CCErrorCoder)
+	DeleteAPIToken(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListAPITokens(ctx context.Context, h http.Header, opt *metadata.ListAPITokenOption) (
+		*metadata.ListAPITokenResult, errors.CCErrorCoder)
+
 	HealthCheck() (bool, error)
 	SearchProject(ctx context.Context, h http.Header, params *metadata.SearchProjectOption) (resp *metadata.InstResult,
 		err error)
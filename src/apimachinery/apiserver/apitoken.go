@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apiserver
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateAPIToken create personal api token for the current user
+func (a *apiServer) CreateAPIToken(ctx context.Context, h http.Header, opt *metadata.CreateAPITokenOption) (
+	*metadata.CreateAPITokenResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.CreateAPITokenResult `json:"data"`
+	})
+	subPath := "/create/api_token"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// DeleteAPIToken revoke personal api token of the current user
+func (a *apiServer) DeleteAPIToken(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+	subPath := "/delete/api_token/%d"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListAPITokens list personal api tokens of the current user
+func (a *apiServer) ListAPITokens(ctx context.Context, h http.Header, opt *metadata.ListAPITokenOption) (
+	*metadata.ListAPITokenResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ListAPITokenResult `json:"data"`
+	})
+	subPath := "/findmany/api_token"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
@@ -211,3 +211,80 @@ func (a *authServer) BatchRegisterResourceCreatorAction(ctx context.Context, h h
 
 	return response.Data, nil
 }
+
+// RBACAuthorizeBatch authorize the resources with the built-in rbac, it will not pass if one of them does not have
+// permission
+func (a *authServer) RBACAuthorizeBatch(ctx context.Context, h http.Header,
+	input *meta.AuthorizeResourcesParam) ([]types.Decision, error) {
+	subPath := "/rbac/authorize/batch"
+	response := new(authorizeBatchResp)
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(response)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if response.Code != 0 {
+		return nil, response.CCError()
+	}
+
+	return response.Data, nil
+}
+
+// RBACAuthorizeAnyBatch authorize the resources with the built-in rbac, it will pass if the user has the permission
+// of the resource type and action on any of the resources
+func (a *authServer) RBACAuthorizeAnyBatch(ctx context.Context, h http.Header,
+	input *meta.AuthorizeResourcesParam) ([]types.Decision, error) {
+	subPath := "/rbac/authorize/any/batch"
+	response := new(authorizeBatchResp)
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(response)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if response.Code != 0 {
+		return nil, response.CCError()
+	}
+
+	return response.Data, nil
+}
+
+// RBACListAuthorizedResources list the resources that the user has the permission with the built-in rbac
+func (a *authServer) RBACListAuthorizedResources(ctx context.Context, h http.Header,
+	input meta.ListAuthorizedResourcesParam) (*types.AuthorizeList, error) {
+	response := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *types.AuthorizeList `json:"data"`
+	})
+	subPath := "/rbac/findmany/authorized_resource"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(response)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if response.Code != 0 {
+		return nil, response.CCError()
+	}
+
+	return response.Data, nil
+}
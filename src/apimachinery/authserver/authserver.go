@@ -38,6 +38,13 @@ type AuthServerClientInterface interface {
 		[]metadata.IamCreatorActionPolicy, error)
 	BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header, input metadata.IamInstancesWithCreator) (
 		[]metadata.IamCreatorActionPolicy, error)
+
+	RBACAuthorizeBatch(ctx context.Context, h http.Header, input *meta.AuthorizeResourcesParam) ([]types.Decision,
+		error)
+	RBACAuthorizeAnyBatch(ctx context.Context, h http.Header, input *meta.AuthorizeResourcesParam) ([]types.Decision,
+		error)
+	RBACListAuthorizedResources(ctx context.Context, h http.Header, input meta.ListAuthorizedResourcesParam) (
+		*types.AuthorizeList, error)
 }
 
 // NewAuthServerClientInterface TODO
@@ -70,3 +70,32 @@ func (inst *auditlog) SearchAuditLog(ctx context.Context, h http.Header, param m
 
 	return resp.Data, nil
 }
+
+// SearchAuditLogArchive search the index of the archive files that the expired audit logs are moved into
+func (inst *auditlog) SearchAuditLogArchive(ctx context.Context, h http.Header,
+	opt *metadata.SearchAuditLogArchiveOption) (*metadata.SearchAuditLogArchiveResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.SearchAuditLogArchiveResult `json:"data"`
+	})
+	subPath := "/findmany/auditlog/archive"
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
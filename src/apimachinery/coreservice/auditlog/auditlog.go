@@ -27,6 +27,8 @@ type AuditClientInterface interface {
 	SaveAuditLog(ctx context.Context, h http.Header, logs ...metadata.AuditLog) errors.CCErrorCoder
 	SearchAuditLog(ctx context.Context, h http.Header, param metadata.QueryCondition) (*metadata.AuditQueryResult,
 		errors.CCErrorCoder)
+	SearchAuditLogArchive(ctx context.Context, h http.Header, opt *metadata.SearchAuditLogArchiveOption) (
+		*metadata.SearchAuditLogArchiveResult, errors.CCErrorCoder)
 }
 
 // NewAuditClientInterface TODO
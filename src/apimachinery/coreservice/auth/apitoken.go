@@ -0,0 +1,146 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateAPIToken create personal api token
+func (a *auth) CreateAPIToken(ctx context.Context, h http.Header, token *metadata.APIToken) (int64,
+	errors.CCErrorCoder) {
+
+	resp := new(metadata.CreateResult)
+	subPath := "/create/api_token"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(token).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return 0, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return 0, err
+	}
+
+	return resp.Data.ID, nil
+}
+
+// DeleteAPIToken delete personal api token
+func (a *auth) DeleteAPIToken(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+	subPath := "/delete/api_token/%d"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListAPITokens list personal api tokens of the user
+func (a *auth) ListAPITokens(ctx context.Context, h http.Header, opt *metadata.ListAPITokenOption) (
+	*metadata.ListAPITokenResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ListAPITokenResult `json:"data"`
+	})
+	subPath := "/findmany/api_token"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// FindAPITokenByHash find the valid api token by the hash of the token
+func (a *auth) FindAPITokenByHash(ctx context.Context, h http.Header, hash string) (*metadata.APIToken,
+	errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.APIToken `json:"data"`
+	})
+	subPath := "/find/api_token/by_hash"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(metadata.FindAPITokenOption{TokenHash: hash}).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// RecordAPITokenUse record the use of the api token
+func (a *auth) RecordAPITokenUse(ctx context.Context, h http.Header, id int64,
+	use *metadata.APITokenUse) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+	subPath := "/update/api_token/%d/use"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(use).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
@@ -18,6 +18,7 @@ import (
 	"net/http"
 
 	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
@@ -25,6 +26,33 @@ import (
 type AuthClientInterface interface {
 	SearchAuthResource(ctx context.Context, h http.Header,
 		param metadata.PullResourceParam) (metadata.PullResourceResponse, error)
+
+	CreateRBACRole(ctx context.Context, h http.Header, role *metadata.RBACRole) (int64, errors.CCErrorCoder)
+	UpdateRBACRole(ctx context.Context, h http.Header, id int64, role *metadata.RBACRole) errors.CCErrorCoder
+	DeleteRBACRole(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRBACRoles(ctx context.Context, h http.Header, opt *metadata.ListRBACRoleOption) (
+		*metadata.ListRBACRoleResult, errors.CCErrorCoder)
+	CreateRBACRoleBinding(ctx context.Context, h http.Header, binding *metadata.RBACRoleBinding) (int64,
+		errors.CCErrorCoder)
+	UpdateRBACRoleBinding(ctx context.Context, h http.Header, id int64,
+		binding *metadata.RBACRoleBinding) errors.CCErrorCoder
+	DeleteRBACRoleBinding(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRBACRoleBindings(ctx context.Context, h http.Header, opt *metadata.ListRBACRoleBindingOption) (
+		*metadata.ListRBACRoleBindingResult, errors.CCErrorCoder)
+	CreateRBACUserGroup(ctx context.Context, h http.Header, group *metadata.RBACUserGroup) (int64,
+		errors.CCErrorCoder)
+	UpdateRBACUserGroup(ctx context.Context, h http.Header, id int64,
+		group *metadata.RBACUserGroup) errors.CCErrorCoder
+	DeleteRBACUserGroup(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRBACUserGroups(ctx context.Context, h http.Header, opt *metadata.ListRBACUserGroupOption) (
+		*metadata.ListRBACUserGroupResult, errors.CCErrorCoder)
+
+	CreateAPIToken(ctx context.Context, h http.Header, token *metadata.APIToken) (int64, errors.CCErrorCoder)
+	DeleteAPIToken(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListAPITokens(ctx context.Context, h http.Header, opt *metadata.ListAPITokenOption) (
+		*metadata.ListAPITokenResult, errors.CCErrorCoder)
+	FindAPITokenByHash(ctx context.Context, h http.Header, hash string) (*metadata.APIToken, errors.CCErrorCoder)
+	RecordAPITokenUse(ctx context.Context, h http.Header, id int64, use *metadata.APITokenUse) errors.CCErrorCoder
 }
 
 // NewAuthClientInterface TODO
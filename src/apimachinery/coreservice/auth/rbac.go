@@ -0,0 +1,309 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// CreateRBACRole create rbac role
+func (a *auth) CreateRBACRole(ctx context.Context, h http.Header, role *metadata.RBACRole) (int64,
+	errors.CCErrorCoder) {
+
+	resp := new(metadata.CreateResult)
+	subPath := "/create/rbac/role"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(role).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return 0, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return 0, err
+	}
+
+	return resp.Data.ID, nil
+}
+
+// UpdateRBACRole update rbac role
+func (a *auth) UpdateRBACRole(ctx context.Context, h http.Header, id int64,
+	role *metadata.RBACRole) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+	subPath := "/update/rbac/role/%d"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(role).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// DeleteRBACRole delete rbac role
+func (a *auth) DeleteRBACRole(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+	subPath := "/delete/rbac/role/%d"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListRBACRoles list rbac roles
+func (a *auth) ListRBACRoles(ctx context.Context, h http.Header, opt *metadata.ListRBACRoleOption) (
+	*metadata.ListRBACRoleResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ListRBACRoleResult `json:"data"`
+	})
+	subPath := "/findmany/rbac/role"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateRBACRoleBinding create rbac role binding
+func (a *auth) CreateRBACRoleBinding(ctx context.Context, h http.Header, binding *metadata.RBACRoleBinding) (int64,
+	errors.CCErrorCoder) {
+
+	resp := new(metadata.CreateResult)
+	subPath := "/create/rbac/role_binding"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(binding).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return 0, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return 0, err
+	}
+
+	return resp.Data.ID, nil
+}
+
+// UpdateRBACRoleBinding update rbac role binding
+func (a *auth) UpdateRBACRoleBinding(ctx context.Context, h http.Header, id int64,
+	binding *metadata.RBACRoleBinding) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+	subPath := "/update/rbac/role_binding/%d"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(binding).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// DeleteRBACRoleBinding delete rbac role binding
+func (a *auth) DeleteRBACRoleBinding(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+	subPath := "/delete/rbac/role_binding/%d"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListRBACRoleBindings list rbac role bindings
+func (a *auth) ListRBACRoleBindings(ctx context.Context, h http.Header, opt *metadata.ListRBACRoleBindingOption) (
+	*metadata.ListRBACRoleBindingResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ListRBACRoleBindingResult `json:"data"`
+	})
+	subPath := "/findmany/rbac/role_binding"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// CreateRBACUserGroup create rbac user group
+func (a *auth) CreateRBACUserGroup(ctx context.Context, h http.Header, group *metadata.RBACUserGroup) (int64,
+	errors.CCErrorCoder) {
+
+	resp := new(metadata.CreateResult)
+	subPath := "/create/rbac/user_group"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(group).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return 0, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return 0, err
+	}
+
+	return resp.Data.ID, nil
+}
+
+// UpdateRBACUserGroup update rbac user group
+func (a *auth) UpdateRBACUserGroup(ctx context.Context, h http.Header, id int64,
+	group *metadata.RBACUserGroup) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+	subPath := "/update/rbac/user_group/%d"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(group).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// DeleteRBACUserGroup delete rbac user group
+func (a *auth) DeleteRBACUserGroup(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	resp := new(metadata.BaseResp)
+	subPath := "/delete/rbac/user_group/%d"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		SubResourcef(subPath, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+
+	return resp.CCError()
+}
+
+// ListRBACUserGroups list rbac user groups
+func (a *auth) ListRBACUserGroups(ctx context.Context, h http.Header, opt *metadata.ListRBACUserGroupOption) (
+	*metadata.ListRBACUserGroupResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ListRBACUserGroupResult `json:"data"`
+	})
+	subPath := "/findmany/rbac/user_group"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
@@ -303,3 +303,75 @@ func (c *cloud) DeleteDestroyedHostRelated(ctx context.Context, h http.Header,
 
 	return nil
 }
+
+// CreateSyncChangeSet create cloud sync change set
+func (c *cloud) CreateSyncChangeSet(ctx context.Context, h http.Header,
+	changeSet *metadata.CloudSyncChangeSet) (*metadata.CloudSyncChangeSet, errors.CCErrorCoder) {
+	ret := new(metadata.CreateSyncChangeSetResult)
+	subPath := "/create/cloud/sync/change_set"
+
+	err := c.client.Post().
+		WithContext(ctx).
+		Body(changeSet).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+// SearchSyncChangeSet search cloud sync change set
+func (c *cloud) SearchSyncChangeSet(ctx context.Context, h http.Header,
+	option *metadata.SearchSyncChangeSetOption) (*metadata.MultipleSyncChangeSet, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleSyncChangeSetResult)
+	subPath := "/findmany/cloud/sync/change_set"
+
+	err := c.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+// UpdateSyncChangeSet update cloud sync change set
+func (c *cloud) UpdateSyncChangeSet(ctx context.Context, h http.Header, changeSetID int64,
+	option map[string]interface{}) errors.CCErrorCoder {
+	ret := new(metadata.UpdatedOptionResult)
+	subPath := "/update/cloud/sync/change_set/%d"
+
+	err := c.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, changeSetID).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
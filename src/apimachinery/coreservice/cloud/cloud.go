@@ -48,6 +48,12 @@ type CloudInterface interface {
 		option *metadata.SearchSyncHistoryOption) (*metadata.MultipleSyncHistory, errors.CCErrorCoder)
 	DeleteDestroyedHostRelated(ctx context.Context, h http.Header,
 		option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder
+	CreateSyncChangeSet(ctx context.Context, h http.Header,
+		changeSet *metadata.CloudSyncChangeSet) (*metadata.CloudSyncChangeSet, errors.CCErrorCoder)
+	SearchSyncChangeSet(ctx context.Context, h http.Header,
+		option *metadata.SearchSyncChangeSetOption) (*metadata.MultipleSyncChangeSet, errors.CCErrorCoder)
+	UpdateSyncChangeSet(ctx context.Context, h http.Header, changeSetID int64,
+		option map[string]interface{}) errors.CCErrorCoder
 }
 
 // NewCloudInterfaceClient TODO
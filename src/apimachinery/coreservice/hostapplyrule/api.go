@@ -289,3 +289,78 @@ func (p *hostApplyRule) SearchRuleRelatedServiceTemplates(ctx context.Context, h
 
 	return resp.Data, nil
 }
+
+// SaveHostApplyDrift save a batch of the drifts detected by a host apply drift check run
+func (p *hostApplyRule) SaveHostApplyDrift(ctx context.Context, header http.Header, bizID int64,
+	option *metadata.SaveHostApplyDriftOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/createmany/host_apply_drift/bk_biz_id/%d", bizID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+	if resp.CCError() != nil {
+		return resp.CCError()
+	}
+
+	return nil
+}
+
+// FinishHostApplyDriftRun finish a host apply drift check run
+func (p *hostApplyRule) FinishHostApplyDriftRun(ctx context.Context, header http.Header, bizID int64,
+	option *metadata.FinishHostApplyDriftRunOption) errors.CCErrorCoder {
+
+	resp := new(metadata.BaseResp)
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/update/host_apply_drift_run/bk_biz_id/%d", bizID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return errors.CCHttpError
+	}
+	if resp.CCError() != nil {
+		return resp.CCError()
+	}
+
+	return nil
+}
+
+// ListHostApplyDrift list the host apply drift report of the business
+func (p *hostApplyRule) ListHostApplyDrift(ctx context.Context, header http.Header, bizID int64,
+	option *metadata.ListHostApplyDriftOption) (*metadata.MultipleHostApplyDriftResult, errors.CCErrorCoder) {
+
+	resp := struct {
+		metadata.BaseResp
+		Data *metadata.MultipleHostApplyDriftResult `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/host_apply_drift/bk_biz_id/%d", bizID).
+		WithHeaders(header).
+		Do().
+		Into(&resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if resp.CCError() != nil {
+		return nil, resp.CCError()
+	}
+
+	return resp.Data, nil
+}
@@ -46,6 +46,12 @@ type HostApplyRuleInterface interface {
 		option metadata.UpdateHostByHostApplyRuleOption) (metadata.MultipleHostApplyResult, errors.CCErrorCoder)
 	SearchRuleRelatedServiceTemplates(ctx context.Context, header http.Header,
 		option *metadata.RuleRelatedServiceTemplateOption) ([]metadata.SrvTemplate, errors.CCErrorCoder)
+	SaveHostApplyDrift(ctx context.Context, header http.Header, bizID int64,
+		option *metadata.SaveHostApplyDriftOption) errors.CCErrorCoder
+	FinishHostApplyDriftRun(ctx context.Context, header http.Header, bizID int64,
+		option *metadata.FinishHostApplyDriftRunOption) errors.CCErrorCoder
+	ListHostApplyDrift(ctx context.Context, header http.Header, bizID int64,
+		option *metadata.ListHostApplyDriftOption) (*metadata.MultipleHostApplyDriftResult, errors.CCErrorCoder)
 }
 
 // NewHostApplyRuleClient TODO
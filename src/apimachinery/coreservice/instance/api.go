@@ -211,7 +211,8 @@ func (inst *instance) DeleteInstanceCascade(ctx context.Context, h http.Header,
 }
 
 // ReadInstanceStruct TODO
-//  ReadInstanceStruct 按照结构体返回实例数据
+//
+//	ReadInstanceStruct 按照结构体返回实例数据
 func (inst *instance) ReadInstanceStruct(ctx context.Context, h http.Header, objID string,
 	input *metadata.QueryCondition, result interface{}) errors.CCErrorCoder {
 
@@ -286,3 +287,90 @@ func (inst *instance) GetInstanceObjectMapping(ctx context.Context, header http.
 
 	return resp.Data, nil
 }
+
+// ListRecycleBin list the archived deleted instances of the model
+func (inst *instance) ListRecycleBin(ctx context.Context, h http.Header, objID string,
+	opt *metadata.ListRecycleBinOption) (*metadata.ListRecycleBinResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ListRecycleBinResult `json:"data"`
+	})
+	subPath := "/findmany/recycle_bin/object/%s"
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// PreviewRestoreRecycleBin preview the result of restoring the archived deleted instances
+func (inst *instance) PreviewRestoreRecycleBin(ctx context.Context, h http.Header, objID string,
+	opt *metadata.RestoreRecycleBinOption) (*metadata.RestoreRecycleBinResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.RestoreRecycleBinResult `json:"data"`
+	})
+	subPath := "/find/recycle_bin/object/%s/restore_preview"
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// RestoreRecycleBin restore the archived deleted instances
+func (inst *instance) RestoreRecycleBin(ctx context.Context, h http.Header, objID string,
+	opt *metadata.RestoreRecycleBinOption) (*metadata.RestoreRecycleBinResult, errors.CCErrorCoder) {
+
+	resp := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.RestoreRecycleBinResult `json:"data"`
+	})
+	subPath := "/update/recycle_bin/object/%s/restore"
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
@@ -50,6 +50,16 @@ type InstanceClientInterface interface {
 		*metadata.CountResponseContent, error)
 	GetInstanceObjectMapping(ctx context.Context, h http.Header, ids []int64) ([]metadata.ObjectMapping,
 		errors.CCErrorCoder)
+
+	// ListRecycleBin list the archived deleted instances of the model
+	ListRecycleBin(ctx context.Context, h http.Header, objID string, opt *metadata.ListRecycleBinOption) (
+		*metadata.ListRecycleBinResult, errors.CCErrorCoder)
+	// PreviewRestoreRecycleBin preview the result of restoring the archived deleted instances
+	PreviewRestoreRecycleBin(ctx context.Context, h http.Header, objID string,
+		opt *metadata.RestoreRecycleBinOption) (*metadata.RestoreRecycleBinResult, errors.CCErrorCoder)
+	// RestoreRecycleBin restore the archived deleted instances
+	RestoreRecycleBin(ctx context.Context, h http.Header, objID string, opt *metadata.RestoreRecycleBinOption) (
+		*metadata.RestoreRecycleBinResult, errors.CCErrorCoder)
 }
 
 // NewInstanceClientInterface TODO
@@ -39,6 +39,10 @@ type TaskClientInterface interface {
 
 	TaskDetail(ctx context.Context, header http.Header, taskID string) (resp *metadata.TaskDetailData, err error)
 
+	// ListExecuteHistory list the latest execute records of the task
+	ListExecuteHistory(ctx context.Context, header http.Header, taskID string) ([]metadata.APITaskExecuteRecord,
+		errors.CCErrorCoder)
+
 	DeleteTask(ctx context.Context, header http.Header, taskCond *metadata.DeleteOption) error
 
 	ListLatestSyncStatus(ctx context.Context, header http.Header, option *metadata.ListLatestSyncStatusRequest) (
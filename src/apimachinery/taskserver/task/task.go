@@ -158,6 +158,29 @@ func (t *task) TaskDetail(ctx context.Context, header http.Header, taskID string
 	return &resp.Data, nil
 }
 
+// ListExecuteHistory list the latest execute records of the task
+func (t *task) ListExecuteHistory(ctx context.Context, header http.Header, taskID string) (
+	[]metadata.APITaskExecuteRecord, errors.CCErrorCoder) {
+
+	resp := new(metadata.ListTaskExecuteHistoryResponse)
+	subPath := "/task/findmany/execute_history/%s"
+
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, taskID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	if err != nil {
+		return nil, errors.CCHttpError
+	}
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
 // DeleteTask delete task
 func (t *task) DeleteTask(ctx context.Context, header http.Header, taskCond *metadata.DeleteOption) error {
 	resp := new(metadata.Response)
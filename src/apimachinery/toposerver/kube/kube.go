@@ -78,6 +78,32 @@ func (st *Kube) BatchCreatePod(ctx context.Context, header http.Header, data *ty
 	return ret.Data.IDs, nil
 }
 
+// DeletePods delete pods and their containers.
+func (st *Kube) DeletePods(ctx context.Context, header http.Header, option *types.DeletePodsOption) errors.CCErrorCoder {
+
+	ret := new(metadata.Response)
+	subPath := "/deletemany/kube/pod"
+
+	err := st.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("delete pods failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
 // SearchCluster search cluster.
 func (st *Kube) SearchCluster(ctx context.Context, header http.Header, input *types.QueryClusterOption) (
 	*metadata.Response, errors.CCErrorCoder) {
@@ -0,0 +1,400 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/ac/parser"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// apiTokenCacheTTL is the time that the api token is cached in api server, so a revoked token may still be
+	// used in this duration
+	apiTokenCacheTTL = 30 * time.Second
+	// apiTokenCacheMaxSize is the maximum number of the cached api tokens, the cache is reset when it is exceeded
+	apiTokenCacheMaxSize = 10000
+	// apiTokenRecordTimeout is the timeout of recording the use of the api token
+	apiTokenRecordTimeout = 10 * time.Second
+	// apiTokenRecordWorkers is the number of the workers that record the uses of the api tokens
+	apiTokenRecordWorkers = 10
+	// apiTokenRecordQueueSize is the maximum number of the api token uses that are waiting to be recorded
+	apiTokenRecordQueueSize = 10000
+	// apiTokenRecordQueueWait is the maximum time to wait for the queue when it is full, the use is recorded
+	// synchronously by the request after that
+	apiTokenRecordQueueWait = time.Second
+)
+
+var (
+	// readOnlyVerbs are the path segments of the query apis that use the post method
+	readOnlyVerbs = map[string]struct{}{"find": {}, "findmany": {}, "search": {}, "count": {}, "list": {}}
+	// writeVerbs are the path segments of the apis that changes the data
+	writeVerbs = map[string]struct{}{"create": {}, "createmany": {}, "update": {}, "updatemany": {}, "delete": {},
+		"deletemany": {}, "add": {}, "transfer": {}, "import": {}, "sync": {}, "bind": {}, "unbind": {}}
+)
+
+type apiTokenCacheItem struct {
+	// token is nil if the token is not exist
+	token    *metadata.APIToken
+	expireAt time.Time
+}
+
+// apiTokenCache caches the api tokens by the hash of the token, so that coreservice is not requested every time
+type apiTokenCache struct {
+	lock  sync.RWMutex
+	items map[string]apiTokenCacheItem
+}
+
+func newAPITokenCache() *apiTokenCache {
+	return &apiTokenCache{items: make(map[string]apiTokenCacheItem)}
+}
+
+func (c *apiTokenCache) get(hash string, now time.Time) (*metadata.APIToken, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	item, exists := c.items[hash]
+	if !exists || now.After(item.expireAt) {
+		return nil, false
+	}
+	return item.token, true
+}
+
+func (c *apiTokenCache) set(hash string, token *metadata.APIToken, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.items) >= apiTokenCacheMaxSize {
+		c.items = make(map[string]apiTokenCacheItem)
+	}
+	c.items[hash] = apiTokenCacheItem{token: token, expireAt: now.Add(apiTokenCacheTTL)}
+}
+
+// APITokenFilter authenticates the request with the personal api token in the authorization header, the user of the
+// token is injected into the request if the token is valid and the request is in the scope of the token, requests
+// without api token are not affected
+func (s *service) APITokenFilter() func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+		plainToken, exists := getBearerAPIToken(req.Request.Header)
+		if !exists {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		rid := httpheader.GetRid(req.Request.Header)
+		token, err := s.getAPIToken(req.Request.Header, metadata.HashAPIToken(plainToken))
+		if err != nil {
+			blog.Errorf("get api token failed, err: %v, rid: %s", err, rid)
+			s.RespError(req, resp, http.StatusInternalServerError, &metadata.RespError{
+				Msg:     err,
+				ErrCode: common.CCErrAPINoPassSourceCertification,
+			})
+			return
+		}
+
+		if token == nil || token.IsExpired() {
+			blog.Errorf("api token %s... is invalid or expired, rid: %s", plainToken[:len(metadata.APITokenPrefix)+2],
+				rid)
+			s.RespError(req, resp, http.StatusUnauthorized, &metadata.RespError{
+				Msg:     errors.New("api token is invalid or expired"),
+				ErrCode: common.CCErrAPINoPassSourceCertification,
+			})
+			return
+		}
+
+		if err = checkAPITokenScope(req, &token.Scope); err != nil {
+			blog.Errorf("request %s %s is out of the scope of api token %d, err: %v, rid: %s", req.Request.Method,
+				req.Request.URL.Path, token.ID, err, rid)
+			s.RespError(req, resp, http.StatusForbidden, &metadata.RespError{
+				Msg:     err,
+				ErrCode: common.CCErrCommAuthNotHavePermission,
+			})
+			return
+		}
+
+		setAPITokenHeader(req.Request.Header, token)
+
+		// the business scope is checked by the resources parsed with the user of the token, so that the businesses
+		// of the request are the same as the ones that are authorized
+		if len(token.Scope.BizIDs) > 0 {
+			attribute, err := parser.ParseAttribute(req, s.engine)
+			if err == nil {
+				err = checkAPITokenBizScope(attribute, token.Scope.BizIDs)
+			}
+			if err != nil {
+				blog.Errorf("request %s %s is out of the business scope of api token %d, err: %v, rid: %s",
+					req.Request.Method, req.Request.URL.Path, token.ID, err, rid)
+				s.RespError(req, resp, http.StatusForbidden, &metadata.RespError{
+					Msg:     err,
+					ErrCode: common.CCErrCommAuthNotHavePermission,
+				})
+				return
+			}
+		}
+
+		if err = s.recordAPITokenUse(req.Request, token); err != nil {
+			blog.Errorf("record api token %d use failed, err: %v, rid: %s", token.ID, err, rid)
+			s.RespError(req, resp, http.StatusServiceUnavailable, &metadata.RespError{
+				Msg:     err,
+				ErrCode: common.CCErrCommHTTPDoRequestFailed,
+			})
+			return
+		}
+
+		fchain.ProcessFilter(req, resp)
+	}
+}
+
+// getBearerAPIToken get the personal api token from the bearer authorization header
+func getBearerAPIToken(header http.Header) (string, bool) {
+	authorization := header.Get("Authorization")
+	if len(authorization) <= len("Bearer ") || !strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(authorization[len("Bearer "):])
+	if !metadata.IsAPIToken(token) {
+		return "", false
+	}
+	return token, true
+}
+
+// getAPIToken get api token by the hash of the token from cache or coreservice, returns nil if it is not exist
+func (s *service) getAPIToken(header http.Header, hash string) (*metadata.APIToken, error) {
+	now := time.Now()
+	if token, exists := s.apiTokens.get(hash, now); exists {
+		return token, nil
+	}
+
+	token, err := s.clientSet.CoreService().Auth().FindAPITokenByHash(context.Background(), header, hash)
+	if err != nil {
+		if err.GetCode() != common.CCErrCommNotFound {
+			return nil, err
+		}
+		token = nil
+	}
+
+	s.apiTokens.set(hash, token, now)
+	return token, nil
+}
+
+// setAPITokenHeader set the user of the api token to the request header, the headers that are only set by trusted
+// callers are removed, so that the request is treated as a normal api request of the user
+func setAPITokenHeader(header http.Header, token *metadata.APIToken) {
+	header.Del("Authorization")
+	header.Del(httpheader.ReqFromWebHeader)
+	header.Del(httpheader.IsInnerReqHeader)
+	header.Del(httpheader.BkJWTHeader)
+	httpheader.SetUser(header, token.User)
+	httpheader.SetSupplierAccount(header, token.OwnerID)
+	httpheader.SetAppCode(header, metadata.APITokenAppCode)
+}
+
+// recordAPITokenUse records the use of the api token, which updates the last used time of the token and saves the
+// audit log of the request, the request must be rejected if the use can not be recorded
+func (s *service) recordAPITokenUse(req *http.Request, token *metadata.APIToken) error {
+	clientIP := httpheader.GetReqRealIP(req.Header)
+	if clientIP == "" {
+		clientIP, _, _ = net.SplitHostPort(req.RemoteAddr)
+	}
+
+	record := apiTokenUseRecord{
+		header: req.Header.Clone(),
+		id:     token.ID,
+		use: &metadata.APITokenUse{
+			Method:   req.Method,
+			URL:      req.URL.Path,
+			ClientIP: clientIP,
+		},
+	}
+	return s.apiTokenRecorder.add(record)
+}
+
+// apiTokenUseRecord is a use of the api token that is waiting to be recorded
+type apiTokenUseRecord struct {
+	header http.Header
+	id     int64
+	use    *metadata.APITokenUse
+}
+
+// apiTokenRecorder records the uses of the api tokens by a fixed number of workers, so that the goroutines are bounded
+// when coreservice is slow, the requests wait for the queue and then record the uses by themselves if the queue is
+// still full, so that no use is lost
+type apiTokenRecorder struct {
+	queue  chan apiTokenUseRecord
+	wait   time.Duration
+	record func(ctx context.Context, header http.Header, id int64, use *metadata.APITokenUse) error
+}
+
+func newAPITokenRecorder(workers, queueSize int, record func(ctx context.Context, header http.Header, id int64,
+	use *metadata.APITokenUse) error) *apiTokenRecorder {
+
+	r := &apiTokenRecorder{
+		queue:  make(chan apiTokenUseRecord, queueSize),
+		wait:   apiTokenRecordQueueWait,
+		record: record,
+	}
+	for i := 0; i < workers; i++ {
+		go r.run()
+	}
+	return r
+}
+
+// add the api token use to the queue, if the queue is still full after waiting for a while, the use is recorded
+// synchronously and the error is returned if it failed
+func (r *apiTokenRecorder) add(record apiTokenUseRecord) error {
+	select {
+	case r.queue <- record:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(r.wait)
+	defer timer.Stop()
+
+	select {
+	case r.queue <- record:
+		return nil
+	case <-timer.C:
+	}
+
+	blog.Warnf("api token use record queue is full, record api token %d use synchronously, rid: %s", record.id,
+		httpheader.GetRid(record.header))
+	ctx, cancel := context.WithTimeout(context.Background(), apiTokenRecordTimeout)
+	defer cancel()
+	return r.record(ctx, record.header, record.id, record.use)
+}
+
+func (r *apiTokenRecorder) run() {
+	for record := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTokenRecordTimeout)
+		if err := r.record(ctx, record.header, record.id, record.use); err != nil {
+			blog.Errorf("record api token %d use failed, err: %v, use: %+v, rid: %s", record.id, err, record.use,
+				httpheader.GetRid(record.header))
+		}
+		cancel()
+	}
+}
+
+// checkAPITokenScope check if the request is in the scope of the api token
+func checkAPITokenScope(req *restful.Request, scope *metadata.APITokenScope) error {
+	// api token can not be used to manage api tokens, so that a leaked token can not be used to create new ones
+	if apiTokenUrlRegexp.MatchString(req.Request.URL.Path) {
+		return errors.New("api token can not be used to manage api tokens")
+	}
+
+	if scope.ReadOnly && !isReadOnlyRequest(req.Request.Method, req.Request.URL.Path) {
+		return errors.New("api token is read only")
+	}
+
+	if len(scope.URLGroups) > 0 {
+		group, err := getURLGroup(req)
+		if err != nil {
+			return err
+		}
+
+		if !util.InStrArr(scope.URLGroups, group) {
+			return fmt.Errorf("api token can not be used for %s apis", group)
+		}
+	}
+
+	return nil
+}
+
+// isReadOnlyRequest check if the request only queries data, post requests are read only only if the url contains a
+// query verb and does not contain a verb that changes the data
+func isReadOnlyRequest(method, path string) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return true
+	}
+
+	if method != http.MethodPost {
+		return false
+	}
+
+	isQuery := false
+	for _, segment := range strings.Split(strings.TrimPrefix(path, rootPath), "/") {
+		if _, exists := writeVerbs[segment]; exists {
+			return false
+		}
+		if _, exists := readOnlyVerbs[segment]; exists {
+			isQuery = true
+		}
+	}
+	return isQuery
+}
+
+// getURLGroup get the url group of the request, which is the type of the backend service the request is sent to
+func getURLGroup(req *restful.Request) (string, error) {
+	// the url is revised when the backend service is matched, so use a copy of the request
+	reqCopy := req.Request.Clone(req.Request.Context())
+	kind, err := URLPath(reqCopy.RequestURI).FilterChain(restful.NewRequest(reqCopy))
+	if err != nil {
+		return "", err
+	}
+	return string(kind), nil
+}
+
+// checkAPITokenBizScope check if all the resources of the request are in the businesses of the api token scope, the
+// request is rejected if any resource does not belong to a business
+func checkAPITokenBizScope(attribute *meta.AuthAttribute, bizIDs []int64) error {
+	if len(attribute.Resources) == 0 {
+		return errors.New("api token can only be used for business apis")
+	}
+
+	for _, resource := range attribute.Resources {
+		bizID := getResourceBizID(&resource)
+		if bizID <= 0 {
+			return fmt.Errorf("api token can not be used for %s resources that do not belong to a business",
+				resource.Type)
+		}
+
+		if !util.InArray(bizID, bizIDs) {
+			return fmt.Errorf("api token can not be used for business %d", bizID)
+		}
+	}
+	return nil
+}
+
+// getResourceBizID get the business that the resource belongs to, which is the business itself for business resource
+func getResourceBizID(resource *meta.ResourceAttribute) int64 {
+	if resource.BusinessID > 0 {
+		return resource.BusinessID
+	}
+
+	if resource.Type == meta.Business {
+		return resource.InstanceID
+	}
+
+	for _, layer := range resource.Layers {
+		if layer.Type == meta.Business && layer.InstanceID > 0 {
+			return layer.InstanceID
+		}
+	}
+	return 0
+}
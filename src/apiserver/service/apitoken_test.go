@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/ac/meta"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRequest(method, url, body string) *restful.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	return restful.NewRequest(httptest.NewRequest(method, url, reader))
+}
+
+func TestGetBearerAPIToken(t *testing.T) {
+	header := make(http.Header)
+	_, exists := getBearerAPIToken(header)
+	require.False(t, exists)
+
+	header.Set("Authorization", "Bearer other_token")
+	_, exists = getBearerAPIToken(header)
+	require.False(t, exists)
+
+	header.Set("Authorization", "bearer cmdb_abc")
+	token, exists := getBearerAPIToken(header)
+	require.True(t, exists)
+	require.Equal(t, "cmdb_abc", token)
+}
+
+func TestIsReadOnlyRequest(t *testing.T) {
+	cases := []struct {
+		method   string
+		path     string
+		readOnly bool
+	}{
+		{http.MethodGet, "/api/v3/find/system/config_admin", true},
+		{http.MethodPost, "/api/v3/findmany/object/instances", true},
+		{http.MethodPost, "/api/v3/hosts/search", true},
+		{http.MethodPost, "/api/v3/count/object/instances", true},
+		{http.MethodPost, "/api/v3/create/instance/object/host", false},
+		{http.MethodPost, "/api/v3/update/findmany/host", false},
+		{http.MethodPost, "/api/v3/hosts/transfer", false},
+		{http.MethodPut, "/api/v3/update/biz/0/2", false},
+		{http.MethodDelete, "/api/v3/delete/biz/0/2", false},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.readOnly, isReadOnlyRequest(c.method, c.path), "%s %s", c.method, c.path)
+	}
+}
+
+func TestCheckAPITokenScope(t *testing.T) {
+	// the api token management apis can not be accessed by api token
+	require.Error(t, checkAPITokenScope(newTestRequest(http.MethodPost, "/api/v3/create/api_token", "{}"),
+		&metadata.APITokenScope{}))
+	require.Error(t, checkAPITokenScope(newTestRequest(http.MethodDelete, "/api/v3/delete/api_token/1", ""),
+		&metadata.APITokenScope{}))
+
+	scope := &metadata.APITokenScope{ReadOnly: true}
+	require.NoError(t, checkAPITokenScope(newTestRequest(http.MethodPost, "/api/v3/findmany/cloud/account", "{}"),
+		scope))
+	require.Error(t, checkAPITokenScope(newTestRequest(http.MethodPost, "/api/v3/create/cloud/account", "{}"),
+		scope))
+
+	scope = &metadata.APITokenScope{URLGroups: []string{string(CloudType)}}
+	require.NoError(t, checkAPITokenScope(newTestRequest(http.MethodPost, "/api/v3/findmany/cloud/account", "{}"),
+		scope))
+	require.Error(t, checkAPITokenScope(newTestRequest(http.MethodPost, "/api/v3/findmany/rbac/role", "{}"),
+		scope))
+	require.Error(t, checkAPITokenScope(newTestRequest(http.MethodPost, "/api/v3/unknown/url", "{}"), scope))
+
+	// checking the url group does not revise the url of the request
+	req := newTestRequest(http.MethodPost, "/api/v3/findmany/cloud/account", "{}")
+	require.NoError(t, checkAPITokenScope(req, scope))
+	require.Equal(t, "/api/v3/findmany/cloud/account", req.Request.URL.Path)
+	require.Equal(t, "/api/v3/findmany/cloud/account", req.Request.RequestURI)
+}
+
+func TestCheckAPITokenBizScope(t *testing.T) {
+	bizIDs := []int64{2, 3}
+	newAttribute := func(resources ...meta.ResourceAttribute) *meta.AuthAttribute {
+		return &meta.AuthAttribute{Resources: resources}
+	}
+
+	require.NoError(t, checkAPITokenBizScope(newAttribute(
+		meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Find}, BusinessID: 2},
+		meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.Find, InstanceID: 3}},
+		meta.ResourceAttribute{Basic: meta.Basic{Type: meta.ModelModule, Action: meta.Find},
+			Layers: []meta.Item{{Type: meta.Business, InstanceID: 2}}},
+	), bizIDs))
+
+	// all the resources must be in the scope, no matter where the business id is set in the request
+	require.Error(t, checkAPITokenBizScope(newAttribute(
+		meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Find}, BusinessID: 2},
+		meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update}, BusinessID: 4},
+	), bizIDs))
+
+	// the resources that do not belong to a business are rejected
+	require.Error(t, checkAPITokenBizScope(newAttribute(
+		meta.ResourceAttribute{Basic: meta.Basic{Type: meta.CloudAccount, Action: meta.Find}},
+	), bizIDs))
+	require.Error(t, checkAPITokenBizScope(newAttribute(), bizIDs))
+}
+
+func TestAPITokenRecorder(t *testing.T) {
+	recorded := make(chan int64, 10)
+	block := make(chan struct{})
+	recorder := newAPITokenRecorder(1, 2, func(ctx context.Context, header http.Header, id int64,
+		use *metadata.APITokenUse) error {
+
+		if id == 5 {
+			return errors.New("record failed")
+		}
+		if id < 4 {
+			<-block
+		}
+		recorded <- id
+		return nil
+	})
+	recorder.wait = 10 * time.Millisecond
+
+	// the worker is blocked by the first use, the next two uses fill the queue, the others are recorded synchronously
+	require.NoError(t, recorder.add(apiTokenUseRecord{id: 1}))
+	require.Eventually(t, func() bool { return len(recorder.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, recorder.add(apiTokenUseRecord{id: 2}))
+	require.NoError(t, recorder.add(apiTokenUseRecord{id: 3}))
+	require.NoError(t, recorder.add(apiTokenUseRecord{id: 4}))
+	require.Equal(t, int64(4), <-recorded)
+	require.Error(t, recorder.add(apiTokenUseRecord{id: 5}))
+
+	close(block)
+	for _, id := range []int64{1, 2, 3} {
+		select {
+		case recordedID := <-recorded:
+			require.Equal(t, id, recordedID)
+		case <-time.After(time.Second):
+			t.Fatalf("api token use %d is not recorded", id)
+		}
+	}
+}
+
+func TestSetAPITokenHeader(t *testing.T) {
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer token")
+	header.Set(httpheader.ReqFromWebHeader, "true")
+	header.Set(httpheader.IsInnerReqHeader, "true")
+	header.Set(httpheader.BkJWTHeader, "jwt")
+
+	setAPITokenHeader(header, &metadata.APIToken{User: "user", OwnerID: "0"})
+	for _, key := range []string{"Authorization", httpheader.ReqFromWebHeader, httpheader.IsInnerReqHeader,
+		httpheader.BkJWTHeader} {
+		require.Empty(t, header.Get(key), key)
+	}
+	require.Equal(t, "user", httpheader.GetUser(header))
+	require.Equal(t, "0", httpheader.GetSupplierAccount(header))
+}
+
+func TestAPITokenCache(t *testing.T) {
+	cache := newAPITokenCache()
+	now := time.Now()
+
+	_, exists := cache.get("hash", now)
+	require.False(t, exists)
+
+	cache.set("hash", nil, now)
+	token, exists := cache.get("hash", now)
+	require.True(t, exists)
+	require.Nil(t, token)
+
+	cache.set("hash", &metadata.APIToken{ID: 1}, now)
+	token, exists = cache.get("hash", now.Add(apiTokenCacheTTL/2))
+	require.True(t, exists)
+	require.EqualValues(t, 1, token.ID)
+
+	_, exists = cache.get("hash", now.Add(2*apiTokenCacheTTL))
+	require.False(t, exists)
+}
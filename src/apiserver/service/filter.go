@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"configcenter/src/ac/parser"
 	"configcenter/src/apimachinery/discovery"
@@ -59,6 +60,8 @@ const (
 	CloudType RequestType = "cloud"
 	// CacheType TODO
 	CacheType RequestType = "cache"
+	// AuthType is the request type of the built-in rbac management api that is served by auth server
+	AuthType RequestType = "auth"
 )
 
 // URLFilterChan url filter chan
@@ -124,6 +127,9 @@ func (s *service) URLFilterChan(req *restful.Request, resp *restful.Response, ch
 	case CacheType:
 		servers, err = s.discovery.CacheService().GetServers()
 
+	case AuthType:
+		servers, err = s.discovery.AuthServer().GetServers()
+
 	default:
 		name := string(kind)
 		if name != "" {
@@ -327,33 +333,6 @@ func (s *service) verifyAuthorizeStatus(req *restful.Request, errFunc func() err
 	return nil, true
 }
 
-// KEYS[1] is the redis key to incr and expire
-// ARGV[1] is the ttl
-const setRequestCntTTLScript = `
-local cnt = redis.pcall('INCR', KEYS[1]);
-if type(cnt) ~= "number"
-then
-	return cnt
-end
-
-local rs = redis.pcall('TTL', KEYS[1]);
-if type(rs) ~= "number"
-then
-	return rs
-end
-
-if rs == -1
-then
-	rs = redis.pcall('EXPIRE', KEYS[1], ARGV[1]);
-	if type(rs) ~= "number"
-	then
-		return rs
-	end
-end
-
-return cnt
-`
-
 // LimiterFilter limit on a api request according to limiter rules
 func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
@@ -370,44 +349,67 @@ func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Respo
 		}
 
 		if rule.DenyAll {
-			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
-
-			s.RespError(req, resp, http.StatusOK, &metadata.RespError{
-				Msg:     fmt.Errorf("too many requests"),
-				ErrCode: common.CCErrTooManyRequestErr,
-			})
+			if s.limitRequest(req, resp, rule, nil) {
+				return
+			}
+			fchain.ProcessFilter(req, resp)
 			return
 		}
 
-		key := common.ApiCacheLimiterRulePrefix + rule.RuleName
-		result, err := s.cache.Eval(context.Background(), setRequestCntTTLScript, []string{key}, rule.TTL).Result()
+		partition := getLimiterPartition(req.Request.Header, rule)
+		result, err := takeLimiterQuota(context.Background(), s.cache, rule, partition, time.Now())
 		if err != nil {
-			blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
+			blog.Errorf("take limiter quota failed, partition: %s, rule: %#v, err: %v, rid: %s", partition, *rule,
+				err, rid)
 			fchain.ProcessFilter(req, resp)
 			return
 		}
-		cnt, ok := result.(int64)
-		if !ok {
-			blog.Errorf("execute setRequestCntTTLScript failed, key:%s, rule:%#v, err: %v, rid: %s",
-				key, *rule, result, rid)
+
+		if !result.allowed {
+			if s.limitRequest(req, resp, rule, result) {
+				return
+			}
 			fchain.ProcessFilter(req, resp)
 			return
 		}
 
-		if cnt > rule.Limit {
-			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
-			s.RespError(req, resp, http.StatusOK, &metadata.RespError{
-				Msg:     fmt.Errorf("too many requests"),
-				ErrCode: common.CCErrTooManyRequestErr,
-			})
-			return
+		s.limiterRequestTotal.With(prometheus.Labels{labelLimiterRule: rule.RuleName,
+			labelLimiterResult: limiterResultAllowed}).Inc()
+		if !rule.DryRun {
+			result.setHeader(resp.Header())
 		}
-
 		fchain.ProcessFilter(req, resp)
 		return
 	}
 }
 
+// limitRequest handles the request that exceeds the limit of the rule, returns true if the request is rejected,
+// returns false if the rule is in dry run mode, then the request should go on.
+func (s *service) limitRequest(req *restful.Request, resp *restful.Response, rule *metadata.LimiterRule,
+	result *limitResult) bool {
+
+	rid := httpheader.GetRid(req.Request.Header)
+	if rule.DryRun {
+		blog.Warnf("request would have been limited, matched dry run rule is %#v, rid: %s", *rule, rid)
+		s.limiterRequestTotal.With(prometheus.Labels{labelLimiterRule: rule.RuleName,
+			labelLimiterResult: limiterResultDryRunRejected}).Inc()
+		return false
+	}
+
+	blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
+	s.limiterRequestTotal.With(prometheus.Labels{labelLimiterRule: rule.RuleName,
+		labelLimiterResult: limiterResultRejected}).Inc()
+
+	if result != nil {
+		result.setHeader(resp.Header())
+	}
+	s.RespError(req, resp, http.StatusTooManyRequests, &metadata.RespError{
+		Msg:     fmt.Errorf("too many requests"),
+		ErrCode: common.CCErrTooManyRequestErr,
+	})
+	return true
+}
+
 // JwtFilter the filter that handles the source of the jwt request
 func (s *service) JwtFilter() func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/redis"
+)
+
+const (
+	// labelLimiterRule is the metric label of the matched limiter rule name
+	labelLimiterRule = "rule_name"
+	// labelLimiterResult is the metric label of the limiter result
+	labelLimiterResult = "result"
+
+	limiterResultAllowed        = "allowed"
+	limiterResultRejected       = "rejected"
+	limiterResultDryRunRejected = "dry_run_rejected"
+)
+
+// KEYS[1] is the redis key to incr and expire
+// ARGV[1] is the ttl
+// returns {count of requests in the window, ttl of the window in seconds}
+const fixedWindowScript = `
+local cnt = redis.pcall('INCR', KEYS[1]);
+if type(cnt) ~= "number"
+then
+	return cnt
+end
+
+local ttl = redis.pcall('TTL', KEYS[1]);
+if type(ttl) ~= "number"
+then
+	return ttl
+end
+
+if ttl == -1
+then
+	local rs = redis.pcall('EXPIRE', KEYS[1], ARGV[1]);
+	if type(rs) ~= "number"
+	then
+		return rs
+	end
+	ttl = tonumber(ARGV[1])
+end
+
+return {cnt, ttl}
+`
+
+// KEYS[1] is the redis sorted set key that logs the requests in the window
+// ARGV[1] is the window in milliseconds, ARGV[2] is the limit, ARGV[3] is the current unix milliseconds,
+// ARGV[4] is the unique member of this request
+// returns {1 if allowed else 0, remaining requests, milliseconds until the oldest request leaves the window}
+const slidingWindowScript = `
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local cnt = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if cnt < limit
+then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	cnt = cnt + 1
+	allowed = 1
+end
+
+local wait = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if #oldest == 2
+then
+	wait = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, limit - cnt, wait}
+`
+
+// KEYS[1] is the redis hash key that stores the tokens and the last refill time of the bucket
+// ARGV[1] is the bucket capacity, ARGV[2] is the refill rate in tokens per millisecond,
+// ARGV[3] is the current unix milliseconds
+// returns {1 if allowed else 0, remaining tokens, milliseconds until the next token if rejected,
+// or until the bucket is full if allowed}
+const tokenBucketScript = `
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil
+then
+	tokens = burst
+	ts = now
+end
+
+if now > ts
+then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+
+local allowed = 0
+local wait = 0
+if tokens >= 1
+then
+	tokens = tokens - 1
+	allowed = 1
+	wait = math.ceil((burst - tokens) / rate)
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+
+return {allowed, math.floor(tokens), wait}
+`
+
+// limitResult is the result of taking a request quota from a limiter rule
+type limitResult struct {
+	allowed bool
+	// limit is the max requests that can be made at once
+	limit int64
+	// remaining is the requests that can still be made
+	remaining int64
+	// reset is the duration until the quota is restored, the client should retry after it if the request is rejected
+	reset time.Duration
+}
+
+// setHeader sets the X-RateLimit-* headers, and the Retry-After header if the request is rejected
+func (r *limitResult) setHeader(header http.Header) {
+	resetSeconds := strconv.FormatInt(int64((r.reset+time.Second-1)/time.Second), 10)
+	header.Set("X-RateLimit-Limit", strconv.FormatInt(r.limit, 10))
+	header.Set("X-RateLimit-Remaining", strconv.FormatInt(r.remaining, 10))
+	header.Set("X-RateLimit-Reset", resetSeconds)
+	if !r.allowed {
+		header.Set("Retry-After", resetSeconds)
+	}
+}
+
+// getLimiterPartition get the partition of the request according to the rule's partitionby field
+func getLimiterPartition(header http.Header, rule *metadata.LimiterRule) string {
+	switch rule.PartitionBy {
+	case metadata.LimiterPartitionAppCode:
+		return httpheader.GetAppCode(header)
+	case metadata.LimiterPartitionUser:
+		return httpheader.GetUser(header)
+	case metadata.LimiterPartitionIP:
+		return httpheader.GetReqRealIP(header)
+	default:
+		return ""
+	}
+}
+
+// takeLimiterQuota takes a request quota of the partition from the limiter rule with the rule's algorithm
+func takeLimiterQuota(ctx context.Context, cache redis.Client, rule *metadata.LimiterRule, partition string,
+	now time.Time) (*limitResult, error) {
+
+	key := rule.CacheKey(partition)
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+
+	switch rule.GetAlgorithm() {
+	case metadata.FixedWindowLimiter:
+		values, err := evalLimiterScript(ctx, cache, fixedWindowScript, key, 2, rule.TTL)
+		if err != nil {
+			return nil, err
+		}
+		remaining := rule.Limit - values[0]
+		if remaining < 0 {
+			remaining = 0
+		}
+		return &limitResult{
+			allowed:   values[0] <= rule.Limit,
+			limit:     rule.Limit,
+			remaining: remaining,
+			reset:     time.Duration(values[1]) * time.Second,
+		}, nil
+
+	case metadata.SlidingWindowLimiter:
+		windowMs := rule.TTL * int64(time.Second/time.Millisecond)
+		values, err := evalLimiterScript(ctx, cache, slidingWindowScript, key, 3, windowMs, rule.Limit, nowMs,
+			slidingWindowMember(now))
+		if err != nil {
+			return nil, err
+		}
+		return &limitResult{
+			allowed:   values[0] == 1,
+			limit:     rule.Limit,
+			remaining: values[1],
+			reset:     time.Duration(values[2]) * time.Millisecond,
+		}, nil
+
+	case metadata.TokenBucketLimiter:
+		burst := rule.GetBurst()
+		rate := float64(rule.Limit) / float64(rule.TTL*int64(time.Second/time.Millisecond))
+		values, err := evalLimiterScript(ctx, cache, tokenBucketScript, key, 3, burst,
+			strconv.FormatFloat(rate, 'f', -1, 64), nowMs)
+		if err != nil {
+			return nil, err
+		}
+		return &limitResult{
+			allowed:   values[0] == 1,
+			limit:     burst,
+			remaining: values[1],
+			reset:     time.Duration(values[2]) * time.Millisecond,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("limiter algorithm %s is invalid", rule.Algorithm)
+	}
+}
+
+// slidingWindowMember generates a unique sorted set member of a request for the sliding window algorithm, the
+// request id can not be used since it may be empty or duplicated, which makes the requests undercounted.
+func slidingWindowMember(now time.Time) string {
+	return strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+}
+
+// evalLimiterScript executes the limiter script, and returns the integer array result with the expected length
+func evalLimiterScript(ctx context.Context, cache redis.Client, script string, key string, length int,
+	args ...interface{}) ([]int64, error) {
+
+	result, err := cache.Eval(ctx, script, []string{key}, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := result.([]interface{})
+	if !ok || len(items) != length {
+		return nil, fmt.Errorf("limiter script result %v is invalid", result)
+	}
+
+	values := make([]int64, length)
+	for idx, item := range items {
+		value, ok := item.(int64)
+		if !ok {
+			return nil, fmt.Errorf("limiter script result %v is invalid", result)
+		}
+		values[idx] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/alicebob/miniredis"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) (redis.Client, func()) {
+	redisMock, err := miniredis.Run()
+	require.NoError(t, err)
+
+	cache, err := redis.NewFromConfig(redis.Config{Address: redisMock.Addr(), Database: "0", MaxOpenConns: 1})
+	require.NoError(t, err)
+	return cache, redisMock.Close
+}
+
+func TestFixedWindowLimiter(t *testing.T) {
+	cache, closeFunc := newTestRedis(t)
+	defer closeFunc()
+
+	ctx := context.Background()
+	rule := &metadata.LimiterRule{RuleName: "fixed", Url: "/", Limit: 2, TTL: 60}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		result, err := takeLimiterQuota(ctx, cache, rule, "", now)
+		require.NoError(t, err)
+		require.True(t, result.allowed)
+		require.EqualValues(t, 1-i, result.remaining)
+	}
+
+	result, err := takeLimiterQuota(ctx, cache, rule, "", now)
+	require.NoError(t, err)
+	require.False(t, result.allowed)
+	require.EqualValues(t, 0, result.remaining)
+	require.Equal(t, 60*time.Second, result.reset)
+
+	header := make(http.Header)
+	result.setHeader(header)
+	require.Equal(t, "60", header.Get("Retry-After"))
+	require.Equal(t, "2", header.Get("X-RateLimit-Limit"))
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	cache, closeFunc := newTestRedis(t)
+	defer closeFunc()
+
+	ctx := context.Background()
+	rule := &metadata.LimiterRule{RuleName: "sliding", Url: "/", Limit: 2, TTL: 10,
+		Algorithm: metadata.SlidingWindowLimiter, PartitionBy: metadata.LimiterPartitionUser}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		result, err := takeLimiterQuota(ctx, cache, rule, "admin", now.Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+		require.True(t, result.allowed)
+	}
+
+	result, err := takeLimiterQuota(ctx, cache, rule, "admin", now.Add(5*time.Second))
+	require.NoError(t, err)
+	require.False(t, result.allowed)
+	require.Equal(t, 5*time.Second, result.reset)
+
+	// other partitions are limited separately
+	result, err = takeLimiterQuota(ctx, cache, rule, "other", now.Add(5*time.Second))
+	require.NoError(t, err)
+	require.True(t, result.allowed)
+
+	// the first request leaves the window
+	result, err = takeLimiterQuota(ctx, cache, rule, "admin", now.Add(10*time.Second))
+	require.NoError(t, err)
+	require.True(t, result.allowed)
+	require.EqualValues(t, 0, result.remaining)
+
+	// requests at the same time are all counted
+	rule.RuleName = "sliding_same_time"
+	for i := 0; i < 2; i++ {
+		result, err = takeLimiterQuota(ctx, cache, rule, "admin", now)
+		require.NoError(t, err)
+		require.True(t, result.allowed)
+	}
+	result, err = takeLimiterQuota(ctx, cache, rule, "admin", now)
+	require.NoError(t, err)
+	require.False(t, result.allowed)
+}
+
+func TestLimiterAlgorithmSwitch(t *testing.T) {
+	cache, closeFunc := newTestRedis(t)
+	defer closeFunc()
+
+	ctx := context.Background()
+	rule := &metadata.LimiterRule{RuleName: "switch", Url: "/", Limit: 1, TTL: 10}
+	now := time.Now()
+
+	result, err := takeLimiterQuota(ctx, cache, rule, "", now)
+	require.NoError(t, err)
+	require.True(t, result.allowed)
+
+	// the counter of the previous algorithm is stored in a different key, so it does not cause WRONGTYPE error
+	for _, algorithm := range []metadata.LimiterAlgorithm{metadata.SlidingWindowLimiter,
+		metadata.TokenBucketLimiter} {
+		rule.Algorithm = algorithm
+		result, err = takeLimiterQuota(ctx, cache, rule, "", now)
+		require.NoError(t, err)
+		require.True(t, result.allowed)
+	}
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	cache, closeFunc := newTestRedis(t)
+	defer closeFunc()
+
+	ctx := context.Background()
+	// refills 1 token per second, and allows 3 requests at once
+	rule := &metadata.LimiterRule{RuleName: "bucket", Url: "/", Limit: 10, TTL: 10,
+		Algorithm: metadata.TokenBucketLimiter, Burst: 3}
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		result, err := takeLimiterQuota(ctx, cache, rule, "", now)
+		require.NoError(t, err)
+		require.True(t, result.allowed)
+		require.EqualValues(t, 3, result.limit)
+	}
+
+	result, err := takeLimiterQuota(ctx, cache, rule, "", now)
+	require.NoError(t, err)
+	require.False(t, result.allowed)
+	require.Equal(t, time.Second, result.reset)
+
+	result, err = takeLimiterQuota(ctx, cache, rule, "", now.Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, result.allowed)
+	require.EqualValues(t, 0, result.remaining)
+}
@@ -14,8 +14,10 @@
 package service
 
 import (
+	"context"
+	"net/http"
+
 	"configcenter/src/ac"
-	"configcenter/src/ac/iam"
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/common/auth"
@@ -53,10 +55,15 @@ type service struct {
 	authorizer ac.AuthorizeInterface
 	cache      redis.Client
 	limiter    *Limiter
+	apiTokens  *apiTokenCache
+	// apiTokenRecorder records the uses of the api tokens asynchronously
+	apiTokenRecorder *apiTokenRecorder
 	// noPermissionRequestTotal is the total number of request without permission
 	noPermissionRequestTotal *prometheus.CounterVec
 	// errorRequestTotal is the total number of request with error response
 	errorRequestTotal *prometheus.CounterVec
+	// limiterRequestTotal is the total number of request that matched the limiter rules
+	limiterRequestTotal *prometheus.CounterVec
 }
 
 // SetConfig set config
@@ -68,7 +75,12 @@ func (s *service) SetConfig(engine *backbone.Engine, httpClient HTTPClient, disc
 	s.clientSet = clientSet
 	s.cache = cache
 	s.limiter = limiter
-	s.authorizer = iam.NewAuthorizer(clientSet)
+	s.apiTokens = newAPITokenCache()
+	s.apiTokenRecorder = newAPITokenRecorder(apiTokenRecordWorkers, apiTokenRecordQueueSize,
+		func(ctx context.Context, header http.Header, id int64, use *metadata.APITokenUse) error {
+			return clientSet.CoreService().Auth().RecordAPITokenUse(ctx, header, id, use)
+		})
+	s.authorizer = ac.NewAuthorizer(clientSet)
 }
 
 // WebServices TODO
@@ -86,9 +98,19 @@ func (s *service) WebServices() []*restful.WebService {
 	)
 	s.engine.Metric().Registry().MustRegister(s.errorRequestTotal)
 
+	s.limiterRequestTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cmdb_api_limiter_request_total",
+			Help: "total number of request that matched the api limiter rules.",
+		},
+		[]string{labelLimiterRule, labelLimiterResult},
+	)
+	s.engine.Metric().Registry().MustRegister(s.limiterRequestTotal)
+
 	ws := &restful.WebService{}
 	ws.Path(rootPath)
 	ws.Filter(s.JwtFilter())
+	ws.Filter(s.APITokenFilter())
 	ws.Filter(s.engine.Metric().RestfulMiddleWare)
 	ws.Filter(rdapi.AllGlobalFilter(getErrFun))
 	ws.Filter(rdapi.RequestLogFilter())
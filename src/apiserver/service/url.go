@@ -52,6 +52,8 @@ func (u URLPath) FilterChain(req *restful.Request) (RequestType, error) {
 		return AdminType, nil
 	case u.WithCloud(req):
 		return CloudType, nil
+	case u.WithAuth(req):
+		return AuthType, nil
 	default:
 		if server, isHit := match.FilterMatch(req); isHit {
 			return RequestType(server), nil
@@ -78,7 +80,7 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 
 	topoPrefixes := []string{"/search/instances", "/count/instances", "/search/instance_associations",
 		"/count/instance_associations", "/topo/", "/identifier/", "/inst/", "/module/", "/object/", "/set/",
-		"/find/audit", "/find/inst_audit"}
+		"/find/audit", "/find/inst_audit", "/update/audit/"}
 
 	for _, prefix := range topoPrefixes {
 		if strings.HasPrefix(string(*u), rootPath+prefix) {
@@ -92,7 +94,7 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 		"/objectattgroup", "/objectattgroupproperty", "/objectattgroupasst", "/objecttopo", "/topomodelmainline",
 		"/topoinst", "/topopath", "/instassttopo", "/objecttopology", "/topoassociationtype", "/objectassociation",
 		"/instassociation", "/insttopo", "/instance", "/instassociationdetail", "/associationtype", "/find/full_text",
-		"/find/audit_dict", "/findmany/audit_list"}
+		"/find/audit_dict", "/findmany/audit_list", "/findmany/audit_archive"}
 
 	for _, component := range topoURLComponents {
 		if strings.Contains(string(*u), component) {
@@ -126,7 +128,7 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 // hostCloudAreaURLRegexp host server operator cloud area api regex
 var hostCloudAreaURLRegexp = regexp.MustCompile(fmt.Sprintf("^/api/v3/(%s)/(cloudarea|cloudarea/.*)$", verbs))
 var hostURLRegexp = regexp.MustCompile(fmt.Sprintf(
-	"^/api/v3/(%s)/(host|hosts|host_apply_rule|host_apply_plan)/.*$", verbs))
+	"^/api/v3/(%s)/(host|hosts|host_apply_rule|host_apply_plan|host_apply_drift)/.*$", verbs))
 
 // WithHost transform the host's url
 func (u *URLPath) WithHost(req *restful.Request) (isHit bool) {
@@ -329,6 +331,30 @@ func (u *URLPath) WithCloud(req *restful.Request) (isHit bool) {
 	return false
 }
 
+var rbacUrlRegexp = regexp.MustCompile(fmt.Sprintf("^/api/v3/(%s)/rbac/.*$", verbs))
+var apiTokenUrlRegexp = regexp.MustCompile(fmt.Sprintf("^/api/v3/(%s)/api_token(/.*)?$", verbs))
+
+// WithAuth transform the built-in rbac and personal api token management url to auth server
+func (u *URLPath) WithAuth(req *restful.Request) (isHit bool) {
+	authRoot := "/ac/v3"
+	from, to := rootPath, authRoot
+
+	switch {
+	case rbacUrlRegexp.MatchString(string(*u)):
+		from, to, isHit = rootPath, authRoot, true
+	case apiTokenUrlRegexp.MatchString(string(*u)):
+		from, to, isHit = rootPath, authRoot, true
+	default:
+		isHit = false
+	}
+
+	if isHit {
+		u.revise(req, from, to)
+		return true
+	}
+	return false
+}
+
 func (u URLPath) revise(req *restful.Request, from, to string) {
 	req.Request.RequestURI = to + req.Request.RequestURI[len(from):]
 	req.Request.URL.Path = to + req.Request.URL.Path[len(from):]
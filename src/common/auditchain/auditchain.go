@@ -0,0 +1,178 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package auditchain links the audit logs of each supplier account into a tamper-evident hash chain, and verifies the
+// hash chain with the signed checkpoints.
+//
+// the audit logs are written in transactions by all the core services concurrently, so they are not linked when they
+// are written, instead, the master core service links the audit logs that are written before the settle time in the
+// order of their ids periodically. each chained audit log records its sequence in the chain, the hash of the previous
+// audit log and its own hash that covers the previous hash, the sequence and all of its fields, so that modifying or
+// deleting an audit log breaks the chain. the head of the chain is signed as a checkpoint periodically, so that the
+// chain can not be rewritten or truncated without the sign key.
+package auditchain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"configcenter/src/common/metadata"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+const (
+	// ChainSeqField is the sequence of the audit log in the hash chain
+	ChainSeqField = "chain_seq"
+	// PrevHashField is the hash of the previous audit log in the hash chain
+	PrevHashField = "prev_hash"
+	// HashField is the hash of the audit log in the hash chain
+	HashField = "hash"
+)
+
+// hashSkipFields is the fields of the audit log that are not covered by the hash
+var hashSkipFields = map[string]struct{}{
+	"_id":         {},
+	ChainSeqField: {},
+	PrevHashField: {},
+	HashField:     {},
+}
+
+// Hash calculates the hash of the audit log document in the hash chain, which covers the previous hash, the sequence
+// and all the fields of the document except the chain fields and the mongodb object id. the document is hashed in a
+// canonical form in which the fields are sorted by name, so that the hash does not depend on the field order.
+func Hash(prevHash string, seq int64, doc bson.Raw) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte{0})
+
+	seqBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(seqBytes, uint64(seq))
+	h.Write(seqBytes)
+
+	if err := writeCanonicalDocument(h, doc, hashSkipFields); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeCanonicalDocument(h hash.Hash, doc bson.Raw, skipFields map[string]struct{}) error {
+	elements, err := doc.Elements()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(elements, func(i, j int) bool {
+		return elements[i].Key() < elements[j].Key()
+	})
+
+	h.Write([]byte{byte(bsontype.EmbeddedDocument)})
+	for _, element := range elements {
+		key := element.Key()
+		if _, exists := skipFields[key]; exists {
+			continue
+		}
+
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		if err := writeCanonicalValue(h, element.Value()); err != nil {
+			return err
+		}
+	}
+	h.Write([]byte{0})
+	return nil
+}
+
+func writeCanonicalValue(h hash.Hash, value bson.RawValue) error {
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		return writeCanonicalDocument(h, value.Document(), nil)
+	case bsontype.Array:
+		values, err := value.Array().Values()
+		if err != nil {
+			return err
+		}
+
+		h.Write([]byte{byte(bsontype.Array)})
+		for _, val := range values {
+			if err := writeCanonicalValue(h, val); err != nil {
+				return err
+			}
+		}
+		h.Write([]byte{0})
+		return nil
+	default:
+		// the scalar values are self delimited, strings and binaries are prefixed with their length
+		h.Write([]byte{byte(value.Type)})
+		h.Write(value.Value)
+		return nil
+	}
+}
+
+// Sign signs the checkpoint by HMAC-SHA256 with the sign key
+func Sign(signKey string, checkpoint *metadata.AuditChainCheckpoint) string {
+	mac := hmac.New(sha256.New, []byte(signKey))
+	fmt.Fprintf(mac, "%s|%d|%s|%d", checkpoint.SupplierAccount, checkpoint.ChainSeq, checkpoint.Hash,
+		checkpoint.CreateTime.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks if the signature of the checkpoint is signed by the sign key
+func VerifySignature(signKey string, checkpoint *metadata.AuditChainCheckpoint) bool {
+	return hmac.Equal([]byte(Sign(signKey, checkpoint)), []byte(checkpoint.Signature))
+}
+
+// SignRange signs the archived hash chain range by HMAC-SHA256 with the sign key, the signature covers the boundary
+// hashes of the range, so that the archived range can not be forged to hide the deleted audit logs
+func SignRange(signKey string, r *metadata.AuditChainRange) string {
+	mac := hmac.New(sha256.New, []byte(signKey))
+	fmt.Fprintf(mac, "%s|%d|%d|%s|%s", r.SupplierAccount, r.Start, r.End, r.PrevHash, r.EndHash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRangeSignature checks if the signature of the archived hash chain range is signed by the sign key
+func VerifyRangeSignature(signKey string, r *metadata.AuditChainRange) bool {
+	return hmac.Equal([]byte(SignRange(signKey, r)), []byte(r.Signature))
+}
+
+// lookupInt64 returns the integer field value of the document, 0 is returned if it does not exist
+func lookupInt64(doc bson.Raw, field string) int64 {
+	value, err := doc.LookupErr(field)
+	if err != nil {
+		return 0
+	}
+
+	if val, ok := value.Int64OK(); ok {
+		return val
+	}
+	if val, ok := value.Int32OK(); ok {
+		return int64(val)
+	}
+	return 0
+}
+
+// lookupString returns the string field value of the document, empty string is returned if it does not exist
+func lookupString(doc bson.Raw, field string) string {
+	value, err := doc.LookupErr(field)
+	if err != nil {
+		return ""
+	}
+
+	val, _ := value.StringValueOK()
+	return val
+}
@@ -0,0 +1,252 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditchain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testSignKey = "test-sign-key"
+
+func newTestDB(t *testing.T, count int) *memory.DB {
+	db := memory.NewDB()
+	logs := make([]metadata.AuditLog, count)
+	for idx := range logs {
+		logs[idx] = metadata.AuditLog{
+			ID:              int64(idx + 1),
+			AuditType:       metadata.HostType,
+			SupplierAccount: common.BKDefaultOwnerID,
+			User:            "admin",
+			ResourceType:    metadata.HostRes,
+			Action:          metadata.AuditUpdate,
+			OperationDetail: &metadata.InstanceOpDetail{
+				BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{
+					PreData:      map[string]interface{}{"bk_host_name": "a"},
+					UpdateFields: map[string]interface{}{"bk_host_name": "b"},
+				}},
+				ModelID: common.BKInnerObjIDHost,
+			},
+			OperationTime: metadata.Time{Time: time.Now().Add(-time.Hour)},
+			ResourceID:    int64(idx + 1),
+		}
+	}
+	require.NoError(t, db.Table(common.BKTableNameAuditLog).Insert(context.Background(), logs))
+	return db
+}
+
+func sealTestDB(t *testing.T, db *memory.DB) {
+	ctx := context.Background()
+	_, err := Seal(ctx, db, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, Checkpoint(ctx, db, testSignKey))
+}
+
+func verifyTestDB(t *testing.T, db *memory.DB) *metadata.VerifyAuditChainResult {
+	result, err := Verify(context.Background(), db, testSignKey,
+		&metadata.VerifyAuditChainOption{SupplierAccount: common.BKDefaultOwnerID})
+	require.NoError(t, err)
+	return result
+}
+
+func TestHashIgnoresFieldOrder(t *testing.T) {
+	doc1, err := bson.Marshal(bson.D{
+		{Key: "a", Value: 1},
+		{Key: "b", Value: bson.D{{Key: "c", Value: "x"}, {Key: "d", Value: []int{1, 2}}}},
+	})
+	require.NoError(t, err)
+	doc2, err := bson.Marshal(bson.D{
+		{Key: "b", Value: bson.D{{Key: "d", Value: []int{1, 2}}, {Key: "c", Value: "x"}}},
+		{Key: "a", Value: 1},
+		{Key: HashField, Value: "h"},
+	})
+	require.NoError(t, err)
+	doc3, err := bson.Marshal(bson.D{
+		{Key: "a", Value: 1},
+		{Key: "b", Value: bson.D{{Key: "c", Value: "y"}, {Key: "d", Value: []int{1, 2}}}},
+	})
+	require.NoError(t, err)
+
+	hash1, err := Hash("prev", 1, doc1)
+	require.NoError(t, err)
+	hash2, err := Hash("prev", 1, doc2)
+	require.NoError(t, err)
+	hash3, err := Hash("prev", 1, doc3)
+	require.NoError(t, err)
+	hash4, err := Hash("prev", 2, doc1)
+	require.NoError(t, err)
+
+	require.Equal(t, hash1, hash2)
+	require.NotEqual(t, hash1, hash3)
+	require.NotEqual(t, hash1, hash4)
+}
+
+func TestSealAndVerify(t *testing.T) {
+	db := newTestDB(t, 5)
+	sealTestDB(t, db)
+
+	result := verifyTestDB(t, db)
+	require.True(t, result.Intact)
+	require.EqualValues(t, 5, result.CheckedCount)
+	require.EqualValues(t, 5, result.LastSeq)
+	require.EqualValues(t, 1, result.CheckpointCount)
+
+	// sealing again does not change the chain
+	sealTestDB(t, db)
+	result = verifyTestDB(t, db)
+	require.True(t, result.Intact)
+	require.EqualValues(t, 1, result.CheckpointCount)
+}
+
+func TestVerifyModified(t *testing.T) {
+	db := newTestDB(t, 5)
+	sealTestDB(t, db)
+
+	err := db.Table(common.BKTableNameAuditLog).Update(context.Background(), mapstr.MapStr{common.BKFieldID: 3},
+		mapstr.MapStr{"user": "hacker"})
+	require.NoError(t, err)
+
+	result := verifyTestDB(t, db)
+	require.False(t, result.Intact)
+	require.NotNil(t, result.BrokenLink)
+	require.Equal(t, metadata.AuditChainHashMismatch, result.BrokenLink.Reason)
+	require.EqualValues(t, 3, result.BrokenLink.AuditID)
+}
+
+// archiveTestLogs deletes the audit logs by ids and records them as archived with the signed ranges
+func archiveTestLogs(t *testing.T, db *memory.DB, signKey string, ids []int64) []metadata.AuditChainRange {
+	ctx := context.Background()
+	cond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}}
+
+	logs := make([]metadata.AuditLog, 0)
+	require.NoError(t, db.Table(common.BKTableNameAuditLog).Find(cond).All(ctx, &logs))
+	require.NoError(t, db.Table(common.BKTableNameAuditLog).Delete(ctx, cond))
+
+	archive := metadata.AuditLogArchive{ID: ids[0], ChainRanges: GenArchivedRanges(signKey, logs)}
+	require.NoError(t, db.Table(common.BKTableNameAuditLogArchive).Insert(ctx, archive))
+	return archive.ChainRanges
+}
+
+func TestVerifyDeleted(t *testing.T) {
+	db := newTestDB(t, 5)
+	sealTestDB(t, db)
+
+	err := db.Table(common.BKTableNameAuditLog).Delete(context.Background(),
+		mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: []int64{2, 3}}})
+	require.NoError(t, err)
+
+	result := verifyTestDB(t, db)
+	require.False(t, result.Intact)
+	require.Equal(t, &metadata.AuditChainRange{SupplierAccount: common.BKDefaultOwnerID, Start: 2, End: 3},
+		result.MissingRange)
+}
+
+func TestVerifyArchived(t *testing.T) {
+	db := newTestDB(t, 6)
+	sealTestDB(t, db)
+
+	// the archived audit logs are skipped but reported as unverified
+	archiveTestLogs(t, db, testSignKey, []int64{2, 3})
+	archiveTestLogs(t, db, testSignKey, []int64{4})
+
+	result := verifyTestDB(t, db)
+	require.False(t, result.Intact)
+	require.Nil(t, result.BrokenLink)
+	require.Nil(t, result.MissingRange)
+	require.Equal(t, []metadata.AuditChainRange{{SupplierAccount: common.BKDefaultOwnerID, Start: 2, End: 4}},
+		result.UnverifiedRanges)
+	require.EqualValues(t, 3, result.CheckedCount)
+	require.EqualValues(t, 6, result.LastSeq)
+}
+
+func TestVerifyForgedArchivedRange(t *testing.T) {
+	db := newTestDB(t, 5)
+	sealTestDB(t, db)
+
+	// the archived range that is not signed by the sign key is ignored
+	archiveTestLogs(t, db, "forged-sign-key", []int64{2, 3})
+
+	result := verifyTestDB(t, db)
+	require.False(t, result.Intact)
+	require.Equal(t, &metadata.AuditChainRange{SupplierAccount: common.BKDefaultOwnerID, Start: 2, End: 3},
+		result.MissingRange)
+	require.Empty(t, result.UnverifiedRanges)
+}
+
+func TestVerifyArchivedRangeBoundary(t *testing.T) {
+	db := newTestDB(t, 5)
+	sealTestDB(t, db)
+
+	// the boundary hashes of the archived range must link with the audit logs around it
+	ctx := context.Background()
+	err := db.Table(common.BKTableNameAuditLog).Update(ctx, mapstr.MapStr{common.BKFieldID: 4},
+		mapstr.MapStr{PrevHashField: "forged"})
+	require.NoError(t, err)
+	ranges := archiveTestLogs(t, db, testSignKey, []int64{4})
+	require.Equal(t, "forged", ranges[0].PrevHash)
+
+	result := verifyTestDB(t, db)
+	require.False(t, result.Intact)
+	require.NotNil(t, result.BrokenLink)
+	require.Equal(t, metadata.AuditChainPrevHashMismatch, result.BrokenLink.Reason)
+	require.EqualValues(t, 4, result.BrokenLink.ChainSeq)
+
+	// the audit log after the archived range must link with its end hash
+	db = newTestDB(t, 5)
+	sealTestDB(t, db)
+	ranges = archiveTestLogs(t, db, testSignKey, []int64{3})
+	err = db.Table(common.BKTableNameAuditLog).Update(ctx, mapstr.MapStr{common.BKFieldID: 4},
+		mapstr.MapStr{PrevHashField: "forged"})
+	require.NoError(t, err)
+
+	result = verifyTestDB(t, db)
+	require.False(t, result.Intact)
+	require.Equal(t, &metadata.AuditChainBrokenLink{ChainSeq: 4, AuditID: 4, Reason: metadata.AuditChainPrevHashMismatch,
+		Expected: ranges[0].EndHash, Actual: "forged"}, result.BrokenLink)
+}
+
+func TestGenArchivedRanges(t *testing.T) {
+	logs := []metadata.AuditLog{
+		{SupplierAccount: "0", ChainSeq: 2, PrevHash: "h1", Hash: "h2"},
+		{SupplierAccount: "0", ChainSeq: 1, PrevHash: "", Hash: "h1"},
+		{SupplierAccount: "1", ChainSeq: 1, PrevHash: "", Hash: "g1"},
+		{SupplierAccount: "0", ChainSeq: 5, PrevHash: "h4", Hash: "h5"},
+		{SupplierAccount: "0"},
+	}
+
+	ranges := GenArchivedRanges(testSignKey, logs)
+	for idx := range ranges {
+		require.True(t, VerifyRangeSignature(testSignKey, &ranges[idx]))
+		require.False(t, VerifyRangeSignature("forged-sign-key", &ranges[idx]))
+		ranges[idx].Signature = ""
+	}
+	require.Equal(t, []metadata.AuditChainRange{
+		{SupplierAccount: "0", Start: 1, End: 2, PrevHash: "", EndHash: "h2"},
+		{SupplierAccount: "0", Start: 5, End: 5, PrevHash: "h4", EndHash: "h5"},
+		{SupplierAccount: "1", Start: 1, End: 1, PrevHash: "", EndHash: "g1"},
+	}, ranges)
+
+	// the signature covers the boundary hashes
+	r := GenArchivedRanges(testSignKey, logs[:1])[0]
+	r.EndHash = "forged"
+	require.False(t, VerifyRangeSignature(testSignKey, &r))
+}
@@ -0,0 +1,189 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditchain
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// sealBatchSize is the number of the audit logs that are read at a time when linking them into the hash chain
+const sealBatchSize = 500
+
+// chainHead is the last audit log in the hash chain
+type chainHead struct {
+	ChainSeq int64  `bson:"chain_seq"`
+	Hash     string `bson:"hash"`
+}
+
+// Seal links the audit logs that are written before the settle time and not chained yet into the hash chains of
+// their supplier accounts in the order of their ids, returns the number of the chained audit logs. it must not be
+// called concurrently, otherwise the same sequence may be assigned to different audit logs.
+func Seal(ctx context.Context, db dal.DB, settleTime time.Time) (int64, error) {
+	cond := mapstr.MapStr{
+		ChainSeqField:               mapstr.MapStr{common.BKDBExists: false},
+		common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: settleTime},
+	}
+	suppliers, err := db.Table(common.BKTableNameAuditLog).Distinct(ctx, common.BKOwnerIDField, cond)
+	if err != nil {
+		blog.Errorf("get supplier accounts of the audit logs to be chained failed, err: %v", err)
+		return 0, err
+	}
+
+	var total int64
+	for _, supplier := range suppliers {
+		count, err := sealSupplier(ctx, db, util.GetStrByInterface(supplier), settleTime)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func sealSupplier(ctx context.Context, db dal.DB, supplier string, settleTime time.Time) (int64, error) {
+	head, err := getChainHead(ctx, db, supplier)
+	if err != nil {
+		return 0, err
+	}
+
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField:       supplier,
+		ChainSeqField:               mapstr.MapStr{common.BKDBExists: false},
+		common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: settleTime},
+	}
+
+	var count int64
+	for {
+		docs := make([]bson.Raw, 0)
+		err := db.Table(common.BKTableNameAuditLog).Find(cond).Sort(common.BKFieldID).Limit(sealBatchSize).
+			All(ctx, &docs)
+		if err != nil {
+			blog.Errorf("get audit logs to be chained failed, err: %v, cond: %+v", err, cond)
+			return count, err
+		}
+
+		for _, doc := range docs {
+			seq := head.ChainSeq + 1
+			hash, err := Hash(head.Hash, seq, doc)
+			if err != nil {
+				blog.Errorf("calculate audit log hash failed, err: %v, doc: %s", err, doc)
+				return count, err
+			}
+
+			id := lookupInt64(doc, common.BKFieldID)
+			updateCond := mapstr.MapStr{
+				common.BKFieldID:      id,
+				common.BKOwnerIDField: supplier,
+				ChainSeqField:         mapstr.MapStr{common.BKDBExists: false},
+			}
+			data := mapstr.MapStr{
+				ChainSeqField: seq,
+				PrevHashField: head.Hash,
+				HashField:     hash,
+			}
+			if err := db.Table(common.BKTableNameAuditLog).Update(ctx, updateCond, data); err != nil {
+				blog.Errorf("chain audit log %d failed, err: %v", id, err)
+				return count, err
+			}
+
+			head = &chainHead{ChainSeq: seq, Hash: hash}
+			count++
+		}
+
+		if len(docs) < sealBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// getChainHead get the last audit log in the hash chain of the supplier account, the sequence of the empty chain is 0
+func getChainHead(ctx context.Context, db dal.DB, supplier string) (*chainHead, error) {
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: supplier,
+		ChainSeqField:         mapstr.MapStr{common.BKDBExists: true},
+	}
+
+	heads := make([]chainHead, 0)
+	err := db.Table(common.BKTableNameAuditLog).Find(cond).Sort("-"+ChainSeqField).Limit(1).
+		Fields(ChainSeqField, HashField).All(ctx, &heads)
+	if err != nil {
+		blog.Errorf("get audit log hash chain head failed, err: %v, supplier account: %s", err, supplier)
+		return nil, err
+	}
+
+	if len(heads) == 0 {
+		return new(chainHead), nil
+	}
+	return &heads[0], nil
+}
+
+// Checkpoint signs the heads of the audit log hash chains that have changed since their last checkpoints
+func Checkpoint(ctx context.Context, db dal.DB, signKey string) error {
+	cond := mapstr.MapStr{ChainSeqField: mapstr.MapStr{common.BKDBExists: true}}
+	suppliers, err := db.Table(common.BKTableNameAuditLog).Distinct(ctx, common.BKOwnerIDField, cond)
+	if err != nil {
+		blog.Errorf("get supplier accounts of the chained audit logs failed, err: %v", err)
+		return err
+	}
+
+	for _, supplierVal := range suppliers {
+		supplier := util.GetStrByInterface(supplierVal)
+		head, err := getChainHead(ctx, db, supplier)
+		if err != nil {
+			return err
+		}
+
+		lastCheckpoints := make([]metadata.AuditChainCheckpoint, 0)
+		err = db.Table(common.BKTableNameAuditLogCheckpoint).Find(mapstr.MapStr{common.BKOwnerIDField: supplier}).
+			Sort("-"+ChainSeqField).Limit(1).All(ctx, &lastCheckpoints)
+		if err != nil {
+			blog.Errorf("get the last audit log checkpoint failed, err: %v, supplier account: %s", err, supplier)
+			return err
+		}
+
+		if len(lastCheckpoints) > 0 && lastCheckpoints[0].ChainSeq >= head.ChainSeq {
+			continue
+		}
+
+		id, err := db.NextSequence(ctx, common.BKTableNameAuditLogCheckpoint)
+		if err != nil {
+			blog.Errorf("get next audit log checkpoint id failed, err: %v", err)
+			return err
+		}
+
+		checkpoint := &metadata.AuditChainCheckpoint{
+			ID:              int64(id),
+			SupplierAccount: supplier,
+			ChainSeq:        head.ChainSeq,
+			Hash:            head.Hash,
+			CreateTime:      metadata.Now(),
+		}
+		checkpoint.Signature = Sign(signKey, checkpoint)
+
+		if err := db.Table(common.BKTableNameAuditLogCheckpoint).Insert(ctx, checkpoint); err != nil {
+			blog.Errorf("save audit log checkpoint %+v failed, err: %v", checkpoint, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditchain
+
+import (
+	"context"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// verifyBatchSize is the number of the audit logs that are read at a time when verifying the hash chain
+const verifyBatchSize = 1000
+
+// Verify walks the audit log hash chain of the supplier account from the start sequence, and reports the first broken
+// link or missing range. the missing ranges whose audit logs are moved into the archive files are skipped and reported
+// as unverified, the chain with unverified ranges is not intact. the signatures of the checkpoints and the archived
+// ranges are not verified if the sign key is empty.
+func Verify(ctx context.Context, db dal.DB, signKey string, opt *metadata.VerifyAuditChainOption) (
+	*metadata.VerifyAuditChainResult, error) {
+
+	result := &metadata.VerifyAuditChainResult{
+		SupplierAccount:   opt.SupplierAccount,
+		SignatureVerified: signKey != "",
+		UnverifiedRanges:  make([]metadata.AuditChainRange, 0),
+	}
+
+	checkpoints, err := getCheckpoints(ctx, db, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	archivedRanges, err := getArchivedRanges(ctx, db, signKey, opt.SupplierAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpointHashes := make(map[int64]string)
+	for idx := range checkpoints {
+		if signKey != "" && !VerifySignature(signKey, &checkpoints[idx]) {
+			result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: checkpoints[idx].ChainSeq,
+				Reason: metadata.AuditChainCheckpointInvalid}
+			return result, nil
+		}
+		checkpointHashes[checkpoints[idx].ChainSeq] = checkpoints[idx].Hash
+	}
+	result.CheckpointCount = int64(len(checkpoints))
+
+	v := &verifier{
+		result:           result,
+		checkpointHashes: checkpointHashes,
+		archivedRanges:   archivedRanges,
+		expectedSeq:      opt.StartSeq,
+	}
+	if v.expectedSeq == 0 {
+		v.expectedSeq = 1
+	}
+
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: opt.SupplierAccount,
+		ChainSeqField:         mapstr.MapStr{common.BKDBGTE: v.expectedSeq},
+	}
+	for {
+		docs := make([]bson.Raw, 0)
+		err := db.Table(common.BKTableNameAuditLog).Find(cond).Sort(ChainSeqField).Limit(verifyBatchSize).
+			All(ctx, &docs)
+		if err != nil {
+			blog.Errorf("get chained audit logs failed, err: %v, cond: %+v", err, cond)
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			if !v.verify(doc) {
+				return result, nil
+			}
+		}
+
+		if len(docs) < verifyBatchSize {
+			break
+		}
+		cond[ChainSeqField] = mapstr.MapStr{common.BKDBGT: v.result.LastSeq}
+	}
+
+	// the audit logs after the last one are missing if the last checkpoint is after it
+	if len(checkpoints) > 0 {
+		lastCheckpoint := checkpoints[len(checkpoints)-1]
+		if lastCheckpoint.ChainSeq >= v.expectedSeq && !v.skipMissingRange(lastCheckpoint.ChainSeq) {
+			return result, nil
+		}
+	}
+
+	// the archived audit logs are not verified, so the chain is not known to be intact
+	result.Intact = len(result.UnverifiedRanges) == 0
+	return result, nil
+}
+
+type verifier struct {
+	result *metadata.VerifyAuditChainResult
+	// checkpointHashes is the map of the checkpoint sequence to the signed hash
+	checkpointHashes map[int64]string
+	archivedRanges   []metadata.AuditChainRange
+	// expectedSeq is the sequence of the next audit log
+	expectedSeq int64
+	// prevHash is the hash of the previous audit log, empty if the previous audit log is not verified
+	prevHash string
+	// verified defines if the previous audit log is verified
+	verified bool
+}
+
+// verify checks if the audit log document is correctly linked to the previous one, returns false if the chain is
+// broken or missing audit logs before it
+func (v *verifier) verify(doc bson.Raw) bool {
+	seq := lookupInt64(doc, ChainSeqField)
+	id := lookupInt64(doc, common.BKFieldID)
+
+	if seq < v.expectedSeq {
+		v.result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: seq, AuditID: id,
+			Reason: metadata.AuditChainDuplicateSeq}
+		return false
+	}
+
+	if seq > v.expectedSeq && !v.skipMissingRange(seq-1) {
+		return false
+	}
+
+	prevHash := lookupString(doc, PrevHashField)
+	// the previous hash of the first audit log in the chain is empty, and the previous hash of the first verified
+	// audit log can not be checked if the verification does not start from the beginning
+	if (v.verified || seq == 1) && prevHash != v.prevHash {
+		v.result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: seq, AuditID: id,
+			Reason: metadata.AuditChainPrevHashMismatch, Expected: v.prevHash, Actual: prevHash}
+		return false
+	}
+
+	hash, err := Hash(prevHash, seq, doc)
+	if err != nil {
+		blog.Errorf("calculate audit log %d hash failed, err: %v", id, err)
+		hash = ""
+	}
+
+	actualHash := lookupString(doc, HashField)
+	if hash != actualHash {
+		v.result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: seq, AuditID: id,
+			Reason: metadata.AuditChainHashMismatch, Expected: hash, Actual: actualHash}
+		return false
+	}
+
+	if checkpointHash, exists := v.checkpointHashes[seq]; exists && checkpointHash != actualHash {
+		v.result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: seq, AuditID: id,
+			Reason: metadata.AuditChainCheckpointMismatch, Expected: checkpointHash, Actual: actualHash}
+		return false
+	}
+
+	v.prevHash = actualHash
+	v.verified = true
+	v.expectedSeq = seq + 1
+	v.result.LastSeq = seq
+	v.result.CheckedCount++
+	return true
+}
+
+// skipMissingRange checks if the audit logs from the expected sequence to the end sequence are archived, they are
+// skipped and reported as unverified if archived, otherwise the missing range is reported. the boundary hashes of the
+// adjacent archived ranges must link with each other and with the verified audit logs around them.
+func (v *verifier) skipMissingRange(end int64) bool {
+	missing := metadata.AuditChainRange{
+		SupplierAccount: v.result.SupplierAccount,
+		Start:           v.expectedSeq,
+		End:             end,
+	}
+
+	next, prevHash, linked := missing.Start, v.prevHash, v.verified || missing.Start == 1
+	for _, r := range v.archivedRanges {
+		if r.End < next {
+			continue
+		}
+		if r.Start > next {
+			break
+		}
+
+		// only the range starts right after the previous one can be linked by the boundary hashes
+		if r.Start == next {
+			if linked && r.PrevHash != prevHash {
+				v.result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: r.Start,
+					Reason: metadata.AuditChainPrevHashMismatch, Expected: prevHash, Actual: r.PrevHash}
+				return false
+			}
+		} else {
+			linked = false
+		}
+
+		if checkpointHash, exists := v.checkpointHashes[r.End]; exists && checkpointHash != r.EndHash {
+			v.result.BrokenLink = &metadata.AuditChainBrokenLink{ChainSeq: r.End,
+				Reason: metadata.AuditChainCheckpointMismatch, Expected: checkpointHash, Actual: r.EndHash}
+			return false
+		}
+		prevHash, next = r.EndHash, r.End+1
+
+		if next > end {
+			break
+		}
+	}
+
+	if next <= end {
+		v.result.MissingRange = &missing
+		return false
+	}
+
+	v.result.UnverifiedRanges = append(v.result.UnverifiedRanges, missing)
+	// the previous hash of the audit log after the archived ones is checked by the end hash of the last range if the
+	// range ends right before it
+	v.prevHash = prevHash
+	v.verified = linked && next == end+1
+	v.expectedSeq = end + 1
+	return true
+}
+
+func getCheckpoints(ctx context.Context, db dal.DB, opt *metadata.VerifyAuditChainOption) (
+	[]metadata.AuditChainCheckpoint, error) {
+
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: opt.SupplierAccount,
+		ChainSeqField:         mapstr.MapStr{common.BKDBGTE: opt.StartSeq},
+	}
+
+	checkpoints := make([]metadata.AuditChainCheckpoint, 0)
+	err := db.Table(common.BKTableNameAuditLogCheckpoint).Find(cond).Sort(ChainSeqField).All(ctx, &checkpoints)
+	if err != nil {
+		blog.Errorf("get audit log checkpoints failed, err: %v, cond: %+v", err, cond)
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// getArchivedRanges get the sorted hash chain ranges of the supplier account that are moved into the archive files,
+// the ranges that are not signed by the sign key are ignored so that the forged ranges can not hide the missing logs
+func getArchivedRanges(ctx context.Context, db dal.DB, signKey, supplier string) ([]metadata.AuditChainRange,
+	error) {
+
+	cond := mapstr.MapStr{"chain_ranges." + common.BKOwnerIDField: supplier}
+
+	archives := make([]metadata.AuditLogArchive, 0)
+	err := db.Table(common.BKTableNameAuditLogArchive).Find(cond).Fields("chain_ranges").All(ctx, &archives)
+	if err != nil {
+		blog.Errorf("get archived audit log chain ranges failed, err: %v, cond: %+v", err, cond)
+		return nil, err
+	}
+
+	ranges := make([]metadata.AuditChainRange, 0)
+	for _, archive := range archives {
+		for _, r := range archive.ChainRanges {
+			if r.SupplierAccount != supplier {
+				continue
+			}
+			if signKey != "" && !VerifyRangeSignature(signKey, &r) {
+				blog.Errorf("archived audit log chain range %+v signature is invalid, skip it", r)
+				continue
+			}
+			ranges = append(ranges, r)
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	return ranges, nil
+}
+
+// GenArchivedRanges merges the chained audit logs into continuous hash chain ranges of their supplier accounts, and
+// signs the ranges with their boundary hashes by the sign key
+func GenArchivedRanges(signKey string, logs []metadata.AuditLog) []metadata.AuditChainRange {
+	supplierLogs := make(map[string][]metadata.AuditLog)
+	suppliers := make([]string, 0)
+	for _, log := range logs {
+		if log.ChainSeq <= 0 {
+			continue
+		}
+		if _, exists := supplierLogs[log.SupplierAccount]; !exists {
+			suppliers = append(suppliers, log.SupplierAccount)
+		}
+		supplierLogs[log.SupplierAccount] = append(supplierLogs[log.SupplierAccount], log)
+	}
+
+	ranges := make([]metadata.AuditChainRange, 0)
+	for _, supplier := range suppliers {
+		chainLogs := supplierLogs[supplier]
+		sort.Slice(chainLogs, func(i, j int) bool {
+			return chainLogs[i].ChainSeq < chainLogs[j].ChainSeq
+		})
+
+		start := len(ranges)
+		for _, log := range chainLogs {
+			last := len(ranges) - 1
+			if last >= start && ranges[last].End+1 == log.ChainSeq {
+				ranges[last].End = log.ChainSeq
+				ranges[last].EndHash = log.Hash
+				continue
+			}
+			ranges = append(ranges, metadata.AuditChainRange{SupplierAccount: supplier, Start: log.ChainSeq,
+				End: log.ChainSeq, PrevHash: log.PrevHash, EndHash: log.Hash})
+		}
+	}
+
+	for idx := range ranges {
+		ranges[idx].Signature = SignRange(signKey, &ranges[idx])
+	}
+	return ranges
+}
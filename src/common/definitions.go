@@ -182,6 +182,12 @@ const (
 	// detail to see https://docs.mongodb.com/manual/reference/operator/query/regex/#op._S_options
 	BKDBOPTIONS = "$options"
 
+	// BKDBText the db operator that searches the text index
+	BKDBText = "$text"
+
+	// BKDBSearch the db operator,used with $text
+	BKDBSearch = "$search"
+
 	// BKDBEQ the db operator
 	BKDBEQ = "$eq"
 
@@ -990,7 +996,7 @@ const (
 
 var FieldTypes = []string{FieldTypeSingleChar, FieldTypeLongChar, FieldTypeInt, FieldTypeFloat, FieldTypeEnum,
 	FieldTypeEnumMulti, FieldTypeDate, FieldTypeTime, FieldTypeUser, FieldTypeOrganization, FieldTypeTimeZone,
-	FieldTypeBool, FieldTypeList, FieldTypeTable, FieldTypeInnerTable, FieldTypeEnumQuote}
+	FieldTypeBool, FieldTypeList, FieldTypeTable, FieldTypeInnerTable, FieldTypeEnumQuote, FieldTypeIP, FieldTypeCIDR}
 
 const (
 	// FieldTypeSingleChar the single char filed type
@@ -1050,6 +1056,12 @@ const (
 	// FieldTypeIDRule the id rule field type
 	FieldTypeIDRule string = "id_rule"
 
+	// FieldTypeIP the ip field type, supports ipv4 and ipv6 address
+	FieldTypeIP string = "ip"
+
+	// FieldTypeCIDR the cidr field type, supports ipv4 and ipv6 cidr
+	FieldTypeCIDR string = "cidr"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -1400,6 +1412,10 @@ const (
 	BKOpenSourceLoginPluginVersion = "opensource"
 	// BKSkipLoginPluginVersion TODO
 	BKSkipLoginPluginVersion = "skip-login"
+	// BKOIDCLoginPluginVersion login with an OpenID Connect provider, like the corporate sso system
+	BKOIDCLoginPluginVersion = "oidc"
+	// BKLDAPLoginPluginVersion login with the user name and password stored in the ldap directory
+	BKLDAPLoginPluginVersion = "ldap"
 
 	// BKNoopMonitorPlugin TODO
 	// monitor plugin type
@@ -1672,6 +1688,11 @@ const (
 	BKVpcName                    = "bk_vpc_name"
 	BKRegion                     = "bk_region"
 	BKCloudSyncVpcs              = "bk_sync_vpcs"
+	BKCloudEndpoint              = "bk_endpoint"
+	BKCloudFieldMapping          = "bk_field_mapping"
+	BKCloudChangeSetID           = "bk_change_set_id"
+	BKCloudSyncDryRun            = "bk_dry_run"
+	BKCloudSyncPaused            = "bk_paused"
 
 	// 是否为被销毁的云主机
 	IsDestroyedCloudHost = "is_destroyed_cloud_host"
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConditions(t *testing.T) {
+	timeCond := &metadata.TimeCondition{Operator: "and",
+		Rules: []metadata.TimeConditionItem{{Field: common.CreateTimeField}}}
+	conds := []metadata.DynamicGroupInfoCondition{
+		{
+			ObjID: common.BKInnerObjIDHost,
+			Condition: []metadata.DynamicGroupCondition{
+				{Field: common.BKHostNameField, Operator: common.BKDBEQ, Value: "a"},
+			},
+			TimeCondition: timeCond,
+		},
+		{
+			ObjID: common.BKInnerObjIDHost,
+			Condition: []metadata.DynamicGroupCondition{
+				{Field: common.BKOSTypeField, Operator: common.BKDBIN, Value: []interface{}{"1"}},
+			},
+			TimeCondition: &metadata.TimeCondition{Operator: "and",
+				Rules: []metadata.TimeConditionItem{{Field: common.LastTimeField}}},
+		},
+		{ObjID: common.BKInnerObjIDSet},
+	}
+
+	result := ParseConditions(conds)
+	require.Len(t, result, 2)
+	for _, cond := range result {
+		if cond.ObjectID != common.BKInnerObjIDHost {
+			require.Equal(t, common.BKInnerObjIDSet, cond.ObjectID)
+			require.Empty(t, cond.Condition)
+			continue
+		}
+
+		require.Equal(t, []metadata.ConditionItem{
+			{Field: common.BKHostNameField, Operator: common.BKDBEQ, Value: "a"},
+			{Field: common.BKOSTypeField, Operator: common.BKDBIN, Value: []interface{}{"1"}},
+		}, cond.Condition)
+		require.Len(t, cond.TimeCondition.Rules, 2)
+	}
+
+	// the time condition of the dynamic group is not changed
+	require.Len(t, timeCond.Rules, 1)
+}
+
+// fakeHostSearcher searches the hosts in the module host relations and hosts of one biz
+type fakeHostSearcher struct {
+	HostSearcher
+	relations []int64
+	query     *metadata.QueryInput
+}
+
+func (f *fakeHostSearcher) GetAppIDByCond(kit *rest.Kit, cond metadata.ConditionWithTime) ([]int64, error) {
+	return []int64{2}, nil
+}
+
+func (f *fakeHostSearcher) GetDistinctHostIDByTopology(kit *rest.Kit,
+	input *metadata.DistinctHostIDByTopoRelationRequest) ([]int64, error) {
+
+	if input.HostIDArr == nil {
+		return f.relations, nil
+	}
+
+	hostIDs := make([]int64, 0)
+	for _, hostID := range f.relations {
+		for _, id := range input.HostIDArr {
+			if hostID == id {
+				hostIDs = append(hostIDs, hostID)
+			}
+		}
+	}
+	return hostIDs, nil
+}
+
+func (f *fakeHostSearcher) GetHosts(kit *rest.Kit, input *metadata.QueryInput) (*metadata.HostInfo, error) {
+	f.query = input
+	return &metadata.HostInfo{Count: 1, Info: []mapstr.MapStr{{common.BKHostIDField: 2}}}, nil
+}
+
+func TestHostDynamicGroupExecutorLimitHostIDs(t *testing.T) {
+	newParams := func() *metadata.HostCommonSearch {
+		return &metadata.HostCommonSearch{AppID: 2, Condition: []metadata.SearchCondition{{
+			ObjectID: common.BKInnerObjIDHost,
+			Condition: []metadata.ConditionItem{
+				{Field: common.BKHostIDField, Operator: common.BKDBNE, Value: 1},
+			},
+		}}}
+	}
+
+	// the given hosts are ANDed with the host id condition of the dynamic group
+	searcher := &fakeHostSearcher{relations: []int64{1, 2, 3}}
+	hosts, _, err := NewHostDynamicGroupExecutor(&rest.Kit{}, searcher, newParams(), nil, true).
+		LimitHostIDs([]int64{2}).Execute()
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	require.Equal(t, map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBNE: 1,
+		common.BKDBIN: []int64{2}}}, searcher.query.Condition)
+
+	// no host is matched if the dynamic group is limited to no host
+	searcher = &fakeHostSearcher{relations: []int64{1, 2, 3}}
+	hosts, _, err = NewHostDynamicGroupExecutor(&rest.Kit{}, searcher, newParams(), nil, true).
+		LimitHostIDs([]int64{}).Execute()
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+	require.Nil(t, searcher.query)
+}
@@ -239,6 +239,12 @@ const (
 	// 该状态码只提供给支持可多选字段校验报错时使用，目前用户类型，枚举多选，枚举引用，组织类型校验可多选报错时可以使用
 	CCErrCommParamsNeedSingleChoice = 1199092
 
+	// CCErrCommHookRejected the hook [%s] rejected the request, reason: %s
+	CCErrCommHookRejected = 1199093
+
+	// CCErrCommHookCallFailed call the hook [%s] failed, err: %s
+	CCErrCommHookCallFailed = 1199094
+
 	// too many requests
 	CCErrTooManyRequestErr = 1199997
 
@@ -516,6 +522,7 @@ const (
 	CCErrTopoFieldTemplateUniqueConflict               = 1101126
 	CCErrTopoBizFieldConflict                          = 1101127
 	CCErrTopoArchiveBusinessHasKube                    = 1101128
+	CCErrTopoAuditRevertConflict                       = 1101129
 
 	// object controller 1102XXX
 
@@ -944,6 +951,8 @@ const (
 	CCErrGetCloudAccountConfBatchFailed       = 1118021
 	CCErrDeleteDestroyedHostRelatedFailed     = 1118022
 	CCErrCloudAccountDeletedFailedForSyncTask = 1118023
+	CCErrCloudSyncChangeSetNotPending         = 1118024
+	CCErrCloudSyncTaskIsSyncing               = 1118025
 
 	/** TODO: 以下错误码需要改造 **/
 
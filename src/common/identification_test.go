@@ -33,11 +33,16 @@ func TestSetIdentification(t *testing.T) {
 }
 
 func TestGetIdentification(t *testing.T) {
+	// the identification can only be set once, reset it so that the test does not depend on the other tests
+	identification = "unknown"
+	SetIdentification("id")
+	SetIdentification("other")
+
 	tests := []struct {
 		name string
 		want string
 	}{
-		{"", "unkonw"},
+		{"", "id"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -59,6 +59,11 @@ func IndexEqual(toDBIndex, dbIndex types.Index) bool {
 		return false
 	}
 
+	// text index keys in db are {_fts: "text", _ftsx: 1} instead of the defined fields, compare the language instead
+	if toDBIndex.IsTextIndex() || dbIndex.IsTextIndex() {
+		return toDBIndex.IsTextIndex() && dbIndex.IsTextIndex() && toDBIndex.DefaultLanguage == dbIndex.DefaultLanguage
+	}
+
 	toDBIdxMap := toDBIndex.Keys.Map()
 
 	dbIdxMap := dbIndex.Keys.Map()
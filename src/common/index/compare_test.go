@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package index
+
+import (
+	"testing"
+
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTextIndexEqual(t *testing.T) {
+	logicIndex := FullTextSearchIndex()
+	require.True(t, logicIndex.IsTextIndex())
+
+	// text index keys in db are different from the defined keys
+	dbIndex := types.Index{
+		Name:            logicIndex.Name,
+		Keys:            bson.D{{"_fts", types.TextIndexType}, {"_ftsx", int32(1)}},
+		Background:      true,
+		DefaultLanguage: "none",
+	}
+	require.True(t, IndexEqual(logicIndex, dbIndex))
+	require.True(t, IndexEqual(dbIndex, logicIndex))
+
+	dbIndex.DefaultLanguage = "english"
+	require.False(t, IndexEqual(logicIndex, dbIndex))
+
+	normalIndex := types.Index{Name: logicIndex.Name, Keys: bson.D{{"_fts", 1}}, Background: true}
+	require.False(t, normalIndex.IsTextIndex())
+	require.False(t, IndexEqual(logicIndex, normalIndex))
+}
@@ -41,10 +41,29 @@ func InstanceAssociationIndexes() []types.Index {
 	return associationDefaultIndexes
 }
 
+// FullTextSearchIndex returns the text index of all string fields that the mongodb fulltext search backend uses,
+// the language is none so that the words are matched as they are without stemming.
+func FullTextSearchIndex() types.Index {
+	return types.Index{
+		Name:            common.CCLogicIndexNamePrefix + "fullTextSearch",
+		Keys:            bson.D{{"$**", types.TextIndexType}},
+		Background:      true,
+		DefaultLanguage: "none",
+	}
+}
+
+// FullTextSearchTables returns the tables of the models and inner object instances that need the full text search
+// index, the common object instance tables need it too.
+func FullTextSearchTables() []string {
+	return []string{common.BKTableNameObjDes, common.BKTableNameBaseApp, common.BKTableNameBaseSet,
+		common.BKTableNameBaseModule, common.BKTableNameBaseHost}
+}
+
 // CCFieldTypeToDBType TODO
 func CCFieldTypeToDBType(typ string) string {
 	switch typ {
-	case common.FieldTypeSingleChar, common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeList:
+	case common.FieldTypeSingleChar, common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeList,
+		common.FieldTypeIP, common.FieldTypeCIDR:
 		return "string"
 	case common.FieldTypeInt, common.FieldTypeFloat:
 		return "number"
@@ -159,7 +178,8 @@ func ToDBUniqueIndex(objID string, id uint64, keys []metadata.UniqueKey,
 func ValidateCCFieldType(propertyType string, keyLen int) bool {
 	if keyLen == 1 {
 		switch propertyType {
-		case common.FieldTypeSingleChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeList:
+		case common.FieldTypeSingleChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeList,
+			common.FieldTypeIP, common.FieldTypeCIDR:
 			return true
 		default:
 			return false
@@ -168,7 +188,7 @@ func ValidateCCFieldType(propertyType string, keyLen int) bool {
 
 	switch propertyType {
 	case common.FieldTypeSingleChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
-		common.FieldTypeDate, common.FieldTypeList:
+		common.FieldTypeDate, common.FieldTypeList, common.FieldTypeIP, common.FieldTypeCIDR:
 		return true
 	default:
 		return false
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseIP parse an ipv4 or ipv6 address, returns 4 bytes ip for ipv4 address and 16 bytes ip for ipv6 address.
+// address that contains ":" is treated as ipv6 address, including the ipv4 embedded ones like ::ffff:127.0.0.1
+func ParseIP(address string) (net.IP, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("address %s is invalid", address)
+	}
+
+	if strings.Contains(address, ":") {
+		return ip.To16(), nil
+	}
+	return ip.To4(), nil
+}
+
+// ParseCIDR parse an ipv4 or ipv6 cidr, returns the network of the cidr,
+// its ip is 4 bytes for ipv4 cidr and 16 bytes for ipv6 cidr.
+func ParseCIDR(cidr string) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("cidr %s is invalid, err: %v", cidr, err)
+	}
+
+	ones, _ := ipNet.Mask.Size()
+	if strings.Contains(cidr, ":") {
+		return &net.IPNet{IP: ipNet.IP.To16(), Mask: net.CIDRMask(ones, 8*net.IPv6len)}, nil
+	}
+	return &net.IPNet{IP: ipNet.IP.To4(), Mask: net.CIDRMask(ones, 8*net.IPv4len)}, nil
+}
+
+// FormatIP format the ip parsed by ParseIP to the standard format that ip field value is stored in db.
+// 127.0.0.1 => 127.0.0.1
+// ::1 => 0000:0000:0000:0000:0000:0000:0000:0001
+// ipv6 address is formatted to fixed length so that it can be compared by string order.
+func FormatIP(ip net.IP) string {
+	if len(ip) == net.IPv4len {
+		return ip.String()
+	}
+
+	part := make([]string, 8)
+	for i := 0; i < 8; i++ {
+		part[i] = hex.EncodeToString(ip[2*i : 2*i+2])
+	}
+	return strings.Join(part, ":")
+}
+
+// FormatCIDR format the network parsed by ParseCIDR to the standard format that cidr field value is stored in db.
+// 192.168.1.1/24 => 192.168.1.0/24
+// 2001:db8::1/64 => 2001:0db8:0000:0000:0000:0000:0000:0000/64
+func FormatCIDR(ipNet *net.IPNet) string {
+	ones, _ := ipNet.Mask.Size()
+	return FormatIP(ipNet.IP) + "/" + strconv.Itoa(ones)
+}
+
+// ConvertIPToStandardFormat convert ipv4 or ipv6 address to the standard format of ip field value,
+// the leading and trailing spaces are trimmed, so the validation and the conversion accept the same values
+func ConvertIPToStandardFormat(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !strings.Contains(address, ":") {
+		ip, err := ParseIP(address)
+		if err != nil {
+			return "", err
+		}
+		return FormatIP(ip), nil
+	}
+
+	return convertIPv6ToFullAddr(address)
+}
+
+// ConvertCIDRToStandardFormat convert ipv4 or ipv6 cidr to the standard format of cidr field value,
+// the leading and trailing spaces are trimmed the same as ip
+func ConvertCIDRToStandardFormat(cidr string) (string, error) {
+	ipNet, err := ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil {
+		return "", err
+	}
+	return FormatCIDR(ipNet), nil
+}
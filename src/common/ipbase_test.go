@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import "testing"
+
+func TestConvertNetworkToStandardFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		convert func(string) (string, error)
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"ipv4", ConvertIPToStandardFormat, "127.0.0.1", "127.0.0.1", false},
+		{"ipv4 with spaces", ConvertIPToStandardFormat, " 127.0.0.1 ", "127.0.0.1", false},
+		{"ipv6 with spaces", ConvertIPToStandardFormat, " ::1\t", "0000:0000:0000:0000:0000:0000:0000:0001", false},
+		{"invalid ip", ConvertIPToStandardFormat, "127.0.0", "", true},
+		{"cidr with spaces", ConvertCIDRToStandardFormat, " 10.0.0.1/8 ", "10.0.0.0/8", false},
+		{"invalid cidr", ConvertCIDRToStandardFormat, "10.0.0.1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.convert(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convert %q error = %v, wantErr %v", tt.value, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("convert %q = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
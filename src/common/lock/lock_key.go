@@ -26,6 +26,9 @@ const (
 
 	// CheckSetTemplateSyncFormat  检测集群模板同步的状态
 	CheckSetTemplateSyncFormat = "topo:settemplate:sync:status:check:%d"
+
+	// CloudSyncTaskFormat 云同步任务的同步锁，同一任务的同步和变更集应用不能同时进行
+	CloudSyncTaskFormat = "cloudserver:sync:task:%d"
 )
 
 // StrFormat  build  lock key format
@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+const (
+	// APITokenPrefix is the prefix of the personal api tokens, it is used to tell the api tokens from other bearer
+	// tokens in the authorization header
+	APITokenPrefix = "cmdb_"
+	// APITokenAppCode is the app code of the requests that are authenticated by api token
+	APITokenAppCode = "api_token"
+	// APITokenDefaultExpireDays is the default valid days of an api token
+	APITokenDefaultExpireDays = 90
+	// APITokenMaxExpireDays is the maximum valid days of an api token
+	APITokenMaxExpireDays = 365
+	// APITokenUserLimit is the maximum number of api tokens that a user can have
+	APITokenUserLimit = 20
+)
+
+// GenerateAPIToken generate a random personal api token
+func GenerateAPIToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return APITokenPrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// HashAPIToken returns the hash of the api token that is stored instead of the token itself, the token is
+// generated randomly with enough entropy, so a fast hash is sufficient
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken check if the token is a personal api token
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix) && len(token) > len(APITokenPrefix)
+}
+
+// APITokenScope restricts the requests that an api token can be used for, the token has the same permissions as
+// its user if no restriction is set
+type APITokenScope struct {
+	// ReadOnly the token can only be used for the query requests
+	ReadOnly bool `json:"read_only" bson:"read_only"`
+	// BizIDs the token can only be used for the requests of these businesses
+	BizIDs []int64 `json:"bk_biz_ids" bson:"bk_biz_ids"`
+	// URLGroups the token can only be used for the requests that are routed to these groups of url, the group
+	// is the name of the backend service that api server routes the request to, like topo, host
+	URLGroups []string `json:"url_groups" bson:"url_groups"`
+}
+
+// Validate api token scope
+func (s *APITokenScope) Validate() errors.RawErrorInfo {
+	for _, bizID := range s.BizIDs {
+		if bizID <= 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.bk_biz_ids"}}
+		}
+	}
+
+	for _, group := range s.URLGroups {
+		if len(group) == 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.url_groups"}}
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// APIToken is a personal api token that users use to access the api server without the browser
+type APIToken struct {
+	ID          int64  `json:"id" bson:"id"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	// TokenHash is the hash of the token, the token itself is only returned once when it is created
+	TokenHash string `json:"token_hash,omitempty" bson:"token_hash"`
+	// TokenPrefix is the beginning of the token, so that users can recognize the token
+	TokenPrefix  string        `json:"token_prefix" bson:"token_prefix"`
+	User         string        `json:"user" bson:"user"`
+	Scope        APITokenScope `json:"scope" bson:"scope"`
+	ExpireTime   *Time         `json:"expire_time" bson:"expire_time"`
+	LastUsedTime *Time         `json:"last_used_time" bson:"last_used_time"`
+	OwnerID      string        `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime   *Time         `json:"create_time" bson:"create_time"`
+}
+
+// IsExpired check if the api token is expired
+func (t *APIToken) IsExpired() bool {
+	return t.ExpireTime == nil || !Now().Time.Before(t.ExpireTime.Time)
+}
+
+// CreateAPITokenOption create api token option
+type CreateAPITokenOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// ExpireDays is the valid days of the token, default is APITokenDefaultExpireDays
+	ExpireDays int           `json:"expire_days"`
+	Scope      APITokenScope `json:"scope"`
+}
+
+// Validate create api token option
+func (o *CreateAPITokenOption) Validate() errors.RawErrorInfo {
+	if len(o.Name) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	if o.ExpireDays == 0 {
+		o.ExpireDays = APITokenDefaultExpireDays
+	}
+
+	if o.ExpireDays < 0 || o.ExpireDays > APITokenMaxExpireDays {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"expire_days"}}
+	}
+
+	return o.Scope.Validate()
+}
+
+// CreateAPITokenResult create api token result, the token is only returned here
+type CreateAPITokenResult struct {
+	ID    int64  `json:"id"`
+	Token string `json:"token"`
+}
+
+// ListAPITokenOption list the api tokens of the current user option
+type ListAPITokenOption struct {
+	IDs  []int64  `json:"ids"`
+	Page BasePage `json:"page"`
+}
+
+// Validate list api tokens option
+func (o *ListAPITokenOption) Validate() errors.RawErrorInfo {
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListAPITokenResult list api tokens result
+type ListAPITokenResult struct {
+	Count uint64     `json:"count"`
+	Info  []APIToken `json:"info"`
+}
+
+// FindAPITokenOption find the api token by the hash of the token option
+type FindAPITokenOption struct {
+	TokenHash string `json:"token_hash"`
+}
+
+// APITokenUse is the request that an api token is used for
+type APITokenUse struct {
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	ClientIP string `json:"client_ip"`
+}
@@ -16,9 +16,37 @@ import (
 	"fmt"
 	"regexp"
 
+	"configcenter/src/common"
 	"configcenter/src/common/util"
 )
 
+// LimiterAlgorithm is the algorithm that the api limiter uses to count the requests of a rule
+type LimiterAlgorithm string
+
+const (
+	// FixedWindowLimiter counts the requests in a fixed window of ttl seconds, this is the default algorithm
+	FixedWindowLimiter LimiterAlgorithm = "fixed_window"
+	// SlidingWindowLimiter logs the requests in the last ttl seconds, and allows at most limit requests in it
+	SlidingWindowLimiter LimiterAlgorithm = "sliding_window"
+	// TokenBucketLimiter refills limit tokens every ttl seconds, and allows at most burst requests at once
+	TokenBucketLimiter LimiterAlgorithm = "token_bucket"
+)
+
+// LimiterPartition defines how the requests that matched a rule are partitioned,
+// each partition is limited separately
+type LimiterPartition string
+
+const (
+	// LimiterPartitionNone all the requests that matched the rule share one counter, this is the default partition
+	LimiterPartitionNone LimiterPartition = ""
+	// LimiterPartitionAppCode requests are limited by their app code
+	LimiterPartitionAppCode LimiterPartition = "appcode"
+	// LimiterPartitionUser requests are limited by their user
+	LimiterPartitionUser LimiterPartition = "user"
+	// LimiterPartitionIP requests are limited by their source ip
+	LimiterPartitionIP LimiterPartition = "ip"
+)
+
 // LimiterRule is a rule for api limiter
 type LimiterRule struct {
 	RuleName string `json:"rulename"`
@@ -30,6 +58,41 @@ type LimiterRule struct {
 	Limit    int64  `json:"limit"`
 	TTL      int64  `json:"ttl"`
 	DenyAll  bool   `json:"denyall"`
+	// Algorithm is the limiter algorithm, default is fixed_window
+	Algorithm LimiterAlgorithm `json:"algorithm,omitempty"`
+	// Burst is the bucket capacity of token_bucket algorithm, default is the same as limit
+	Burst int64 `json:"burst,omitempty"`
+	// PartitionBy limits the requests separately by appcode, user or ip, default is no partition
+	PartitionBy LimiterPartition `json:"partitionby,omitempty"`
+	// DryRun only logs and records the metrics of the requests that would have been limited, but not rejects them
+	DryRun bool `json:"dryrun,omitempty"`
+}
+
+// GetAlgorithm returns the limiter algorithm of the rule
+func (r LimiterRule) GetAlgorithm() LimiterAlgorithm {
+	if r.Algorithm == "" {
+		return FixedWindowLimiter
+	}
+	return r.Algorithm
+}
+
+// GetBurst returns the bucket capacity of token_bucket algorithm
+func (r LimiterRule) GetBurst() int64 {
+	if r.Burst <= 0 {
+		return r.Limit
+	}
+	return r.Burst
+}
+
+// CacheKey returns the redis key of the rule's counter for the partition, partition is ignored if the rule is not
+// partitioned. The key is namespaced by the algorithm, because each algorithm stores a different redis data type,
+// so that switching the algorithm of a rule starts with a new counter instead of a WRONGTYPE error.
+func (r LimiterRule) CacheKey(partition string) string {
+	if r.PartitionBy == LimiterPartitionNone {
+		return fmt.Sprintf("%s%s:%s", common.ApiCacheLimiterRulePrefix, r.RuleName, r.GetAlgorithm())
+	}
+	return fmt.Sprintf("%s%s:%s:%s:%s", common.ApiCacheLimiterRulePrefix, r.RuleName, r.GetAlgorithm(),
+		r.PartitionBy, partition)
 }
 
 // Verify to check the fields of LimiterRule
@@ -55,5 +118,23 @@ func (r LimiterRule) Verify() error {
 			return fmt.Errorf("both limit and ttl must be set and bigger than 0 when denyall is false")
 		}
 	}
+	switch r.Algorithm {
+	case "", FixedWindowLimiter, SlidingWindowLimiter, TokenBucketLimiter:
+	default:
+		return fmt.Errorf("algorithm must be one of %s,%s,%s", FixedWindowLimiter, SlidingWindowLimiter,
+			TokenBucketLimiter)
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("burst can not be negative")
+	}
+	if r.Burst > 0 && r.GetAlgorithm() != TokenBucketLimiter {
+		return fmt.Errorf("burst can only be set when algorithm is %s", TokenBucketLimiter)
+	}
+	switch r.PartitionBy {
+	case LimiterPartitionNone, LimiterPartitionAppCode, LimiterPartitionUser, LimiterPartitionIP:
+	default:
+		return fmt.Errorf("partitionby must be one of %s,%s,%s", LimiterPartitionAppCode, LimiterPartitionUser,
+			LimiterPartitionIP)
+	}
 	return nil
 }
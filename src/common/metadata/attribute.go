@@ -188,6 +188,8 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		common.FieldTypeOrganization: attribute.validOrganization,
 		common.FieldTypeInnerTable:   attribute.validInnerTable,
 		common.FieldTypeIDRule:       attribute.validIDRule,
+		common.FieldTypeIP:           attribute.validIP,
+		common.FieldTypeCIDR:         attribute.validCIDR,
 	}
 
 	rawError := errors.RawErrorInfo{}
@@ -500,6 +502,52 @@ func (attribute *Attribute) validTimeZone(ctx context.Context, val interface{},
 	return errors.RawErrorInfo{}
 }
 
+// validIP valid object attribute that is ip type
+func (attribute *Attribute) validIP(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	return attribute.validNetwork(ctx, val, key, common.ConvertIPToStandardFormat)
+}
+
+// validCIDR valid object attribute that is cidr type
+func (attribute *Attribute) validCIDR(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
+	return attribute.validNetwork(ctx, val, key, common.ConvertCIDRToStandardFormat)
+}
+
+// validNetwork valid object attribute that is ip or cidr type, the value is validated by its convert function
+func (attribute *Attribute) validNetwork(ctx context.Context, val interface{}, key string,
+	convert func(string) (string, error)) errors.RawErrorInfo {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if val == nil || val == "" {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	value, ok := val.(string)
+	if !ok {
+		blog.Errorf("params should be string, rid: %s", rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedString,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if _, err := convert(value); err != nil {
+		blog.Errorf("params %s is invalid, err: %v, rid: %s", value, err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
 // validInt valid object attribute that is int type
 func (attribute *Attribute) validInt(ctx context.Context, val interface{}, key string) errors.RawErrorInfo {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -1562,7 +1610,7 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 
 	fieldType := attribute.PropertyType
 	switch fieldType {
-	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeIP, common.FieldTypeCIDR:
 		value, ok := val.(string)
 		if ok == false {
 			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
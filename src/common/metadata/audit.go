@@ -205,6 +205,12 @@ type AuditLog struct {
 	RequestID string `json:"rid,omitempty" bson:"rid,omitempty"`
 	// todo ExtendResourceName for the temporary solution of ipv6
 	ExtendResourceName string `json:"extend_resource_name" bson:"extend_resource_name"`
+	// ChainSeq is the sequence of the audit log in the hash chain of its supplier account, 0 means it is not chained
+	ChainSeq int64 `json:"chain_seq,omitempty" bson:"chain_seq,omitempty"`
+	// PrevHash is the hash of the previous audit log in the hash chain
+	PrevHash string `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	// Hash is the hash of the audit log in the hash chain, which covers the audit log and the previous hash
+	Hash string `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 type bsonAuditLog struct {
@@ -223,6 +229,9 @@ type bsonAuditLog struct {
 	AppCode            string          `json:"code" bson:"code"`
 	RequestID          string          `json:"rid" bson:"rid"`
 	ExtendResourceName string          `json:"extend_resource_name" bson:"extend_resource_name"`
+	ChainSeq           int64           `json:"chain_seq,omitempty" bson:"chain_seq,omitempty"`
+	PrevHash           string          `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	Hash               string          `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 type jsonAuditLog struct {
@@ -241,6 +250,9 @@ type jsonAuditLog struct {
 	AppCode            string          `json:"code" bson:"code"`
 	RequestID          string          `json:"rid" bson:"rid"`
 	ExtendResourceName string          `json:"extend_resource_name" bson:"extend_resource_name"`
+	ChainSeq           int64           `json:"chain_seq,omitempty" bson:"chain_seq,omitempty"`
+	PrevHash           string          `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	Hash               string          `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 // DetailFactory TODO
@@ -289,6 +301,9 @@ func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 	auditLog.AppCode = audit.AppCode
 	auditLog.RequestID = audit.RequestID
 	auditLog.ExtendResourceName = audit.ExtendResourceName
+	auditLog.ChainSeq = audit.ChainSeq
+	auditLog.PrevHash = audit.PrevHash
+	auditLog.Hash = audit.Hash
 
 	if audit.OperationDetail == nil {
 		return nil
@@ -304,7 +319,7 @@ func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 	}
 
 	switch audit.AuditType {
-	case KubeType, FieldTemplateType:
+	case KubeType, FieldTemplateType, APITokenType:
 		operationDetail := new(GenericOpDetail)
 		if err := json.Unmarshal(audit.OperationDetail, &operationDetail); err != nil {
 			return err
@@ -353,6 +368,9 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 	auditLog.AppCode = audit.AppCode
 	auditLog.RequestID = audit.RequestID
 	auditLog.ExtendResourceName = audit.ExtendResourceName
+	auditLog.ChainSeq = audit.ChainSeq
+	auditLog.PrevHash = audit.PrevHash
+	auditLog.Hash = audit.Hash
 
 	if audit.OperationDetail == nil {
 		return nil
@@ -368,7 +386,7 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 	}
 
 	switch audit.AuditType {
-	case KubeType, FieldTemplateType:
+	case KubeType, FieldTemplateType, APITokenType:
 		operationDetail := new(GenericOpDetail)
 		if err := bson.Unmarshal(audit.OperationDetail, &operationDetail); err != nil {
 			return err
@@ -412,6 +430,9 @@ func (auditLog AuditLog) MarshalBSON() ([]byte, error) {
 	audit.AppCode = auditLog.AppCode
 	audit.RequestID = auditLog.RequestID
 	audit.ExtendResourceName = auditLog.ExtendResourceName
+	audit.ChainSeq = auditLog.ChainSeq
+	audit.PrevHash = auditLog.PrevHash
+	audit.Hash = auditLog.Hash
 	var err error
 	switch val := auditLog.OperationDetail.(type) {
 	default:
@@ -655,6 +676,9 @@ const (
 	// FieldTemplateType is field template audit type
 	FieldTemplateType AuditType = "field_template"
 
+	// APITokenType is personal api token audit type
+	APITokenType AuditType = "api_token"
+
 	// ObjTemplateIDs In the context of audit logging, the tags of the
 	// binding field templates that correspond to the models
 	ObjTemplateIDs AuditType = "bk_template_ids"
@@ -767,6 +791,9 @@ const (
 
 	// PlatformSettingRes is platform setting audit resource type
 	PlatformSettingRes ResourceType = "platform_setting"
+
+	// APITokenRes is personal api token audit resource type
+	APITokenRes ResourceType = "api_token"
 )
 
 // OperateFromType TODO
@@ -819,6 +846,8 @@ const (
 	// AuditResume TODO
 	// resume using an object
 	AuditResume ActionType = "resume"
+	// AuditUse use a resource, like using an api token to access the api
+	AuditUse ActionType = "use"
 )
 
 // GetAuditTypeByObjID TODO
@@ -890,7 +919,7 @@ func GetAuditTypesByCategory(category string) []AuditType {
 		return []AuditType{HostType}
 	case "other":
 		return []AuditType{ModelType, AssociationKindType, EventPushType, DynamicGroupType, PlatFormSettingType,
-			FieldTemplateType}
+			FieldTemplateType, APITokenType}
 	}
 	return []AuditType{}
 }
@@ -1120,6 +1149,15 @@ var auditDict = []resourceTypeInfo{
 			actionInfoMap[AuditDelete],
 		},
 	},
+	{
+		ID:   APITokenRes,
+		Name: "个人API令牌",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditDelete],
+			actionInfoMap[AuditUse],
+		},
+	},
 }
 
 // 注意：记得在actionInfoEnMap中添加对应的英文
@@ -1134,6 +1172,7 @@ var actionInfoMap = map[ActionType]actionTypeInfo{
 	AuditRecover:            {ID: AuditRecover, Name: "恢复"},
 	AuditPause:              {ID: AuditPause, Name: "停用"},
 	AuditResume:             {ID: AuditResume, Name: "启用"},
+	AuditUse:                {ID: AuditUse, Name: "使用"},
 }
 
 type resourceTypeInfo struct {
@@ -1383,6 +1422,15 @@ var auditEnDict = []resourceTypeInfo{
 			actionInfoEnMap[AuditDelete],
 		},
 	},
+	{
+		ID:   APITokenRes,
+		Name: "Personal API Token",
+		Operations: []actionTypeInfo{
+			actionInfoEnMap[AuditCreate],
+			actionInfoEnMap[AuditDelete],
+			actionInfoEnMap[AuditUse],
+		},
+	},
 }
 
 var actionInfoEnMap = map[ActionType]actionTypeInfo{
@@ -1396,4 +1444,5 @@ var actionInfoEnMap = map[ActionType]actionTypeInfo{
 	AuditRecover:            {ID: AuditRecover, Name: "Recover"},
 	AuditPause:              {ID: AuditPause, Name: "Pause"},
 	AuditResume:             {ID: AuditResume, Name: "Resume"},
+	AuditUse:                {ID: AuditUse, Name: "Use"},
 }
@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// AuditLogArchive is the index of an archive file that contains the expired audit logs, the audit logs are saved in
+// the file as gzip compressed json lines sorted by id
+type AuditLogArchive struct {
+	ID int64 `json:"id" bson:"id"`
+	// FilePath is the path of the archive file on the local disk of the core service that archived it
+	FilePath string `json:"file_path" bson:"file_path"`
+	// Host is the host of the core service that archived the audit logs
+	Host string `json:"host" bson:"host"`
+	// AuditTypes is the audit types of the archived audit logs
+	AuditTypes []AuditType `json:"audit_types" bson:"audit_types"`
+	// StartID and EndID is the id range of the archived audit logs
+	StartID int64 `json:"start_id" bson:"start_id"`
+	EndID   int64 `json:"end_id" bson:"end_id"`
+	// StartTime and EndTime is the operation time range of the archived audit logs
+	StartTime Time `json:"start_time" bson:"start_time"`
+	EndTime   Time `json:"end_time" bson:"end_time"`
+	// Count is the number of the archived audit logs
+	Count int64 `json:"count" bson:"count"`
+	// Size is the size of the archive file, unit: byte
+	Size int64 `json:"size" bson:"size"`
+	// ChainRanges is the hash chain sequence ranges of the archived audit logs
+	ChainRanges []AuditChainRange `json:"chain_ranges" bson:"chain_ranges"`
+	CreateTime  Time              `json:"create_time" bson:"create_time"`
+}
+
+// SearchAuditLogArchiveOption search the index of the audit log archive files option
+type SearchAuditLogArchiveOption struct {
+	// AuditType filters the archive files that contains the audit logs of the audit type
+	AuditType AuditType `json:"audit_type"`
+	// AuditID filters the archive file that contains the audit log with the id
+	AuditID int64 `json:"audit_id"`
+	// Time filters the archive files that contains the audit logs operated between the start and end time
+	Time OperationTimeCondition `json:"time"`
+	Page BasePage               `json:"page"`
+}
+
+// Validate search audit log archive option
+func (o *SearchAuditLogArchiveOption) Validate() errors.RawErrorInfo {
+	if o.AuditID < 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"audit_id"}}
+	}
+
+	if _, _, err := o.Time.ParseTime(); err != nil {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"time"}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// SearchAuditLogArchiveResult search the index of the audit log archive files result
+type SearchAuditLogArchiveResult struct {
+	Count int64             `json:"count"`
+	Info  []AuditLogArchive `json:"info"`
+}
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// AuditChainCheckpoint is a signed checkpoint of the audit log hash chain of a supplier account, it records the hash
+// of the audit log at the sequence, so that the audit logs before it can not be rewritten or truncated unnoticed
+type AuditChainCheckpoint struct {
+	ID              int64  `json:"id" bson:"id"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	ChainSeq        int64  `json:"chain_seq" bson:"chain_seq"`
+	Hash            string `json:"hash" bson:"hash"`
+	CreateTime      Time   `json:"create_time" bson:"create_time"`
+	// Signature is the HMAC-SHA256 signature of the checkpoint signed by the configured sign key
+	Signature string `json:"signature" bson:"signature"`
+}
+
+// AuditChainRange is a hash chain sequence range of a supplier account, both start and end are included
+type AuditChainRange struct {
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Start           int64  `json:"start" bson:"start"`
+	End             int64  `json:"end" bson:"end"`
+	// PrevHash is the previous hash of the first audit log in the archived range, EndHash is the hash of the last
+	// audit log in the archived range, they link the archived range with the audit logs around it
+	PrevHash string `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	EndHash  string `json:"end_hash,omitempty" bson:"end_hash,omitempty"`
+	// Signature is the HMAC-SHA256 signature of the archived range signed by the checkpoint sign key
+	Signature string `json:"signature,omitempty" bson:"signature,omitempty"`
+}
+
+// VerifyAuditChainOption verify the audit log hash chain of a supplier account option
+type VerifyAuditChainOption struct {
+	SupplierAccount string `json:"bk_supplier_account"`
+	// StartSeq is the sequence that the verification starts from, the verification starts from the beginning if it is
+	// not set
+	StartSeq int64 `json:"start_seq"`
+}
+
+// Validate verify audit chain option
+func (o *VerifyAuditChainOption) Validate() errors.RawErrorInfo {
+	if o.SupplierAccount == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKOwnerIDField}}
+	}
+
+	if o.StartSeq < 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"start_seq"}}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// AuditChainBrokenReason is the reason why the hash chain is broken at an audit log
+type AuditChainBrokenReason string
+
+const (
+	// AuditChainHashMismatch means that the audit log is modified after it is chained
+	AuditChainHashMismatch AuditChainBrokenReason = "hash_mismatch"
+	// AuditChainPrevHashMismatch means that the previous hash of the audit log does not match the previous audit log
+	AuditChainPrevHashMismatch AuditChainBrokenReason = "prev_hash_mismatch"
+	// AuditChainDuplicateSeq means that more than one audit log has the same sequence
+	AuditChainDuplicateSeq AuditChainBrokenReason = "duplicate_seq"
+	// AuditChainCheckpointMismatch means that the hash of the audit log does not match the signed checkpoint
+	AuditChainCheckpointMismatch AuditChainBrokenReason = "checkpoint_mismatch"
+	// AuditChainCheckpointInvalid means that the signature of the checkpoint is invalid
+	AuditChainCheckpointInvalid AuditChainBrokenReason = "checkpoint_signature_invalid"
+)
+
+// AuditChainBrokenLink is the first link where the hash chain is broken
+type AuditChainBrokenLink struct {
+	ChainSeq int64                  `json:"chain_seq"`
+	AuditID  int64                  `json:"audit_id,omitempty"`
+	Reason   AuditChainBrokenReason `json:"reason"`
+	Expected string                 `json:"expected,omitempty"`
+	Actual   string                 `json:"actual,omitempty"`
+}
+
+// VerifyAuditChainResult is the result of verifying the audit log hash chain of a supplier account, the verification
+// stops at the first broken link or missing range
+type VerifyAuditChainResult struct {
+	SupplierAccount string `json:"bk_supplier_account"`
+	// Intact defines if the hash chain is neither broken nor missing any audit log, and all of its audit logs are
+	// verified
+	Intact bool `json:"intact"`
+	// CheckedCount is the number of the verified audit logs
+	CheckedCount int64 `json:"checked_count"`
+	// LastSeq is the sequence of the last verified audit log
+	LastSeq int64 `json:"last_seq"`
+	// CheckpointCount is the number of the verified checkpoints
+	CheckpointCount int64 `json:"checkpoint_count"`
+	// SignatureVerified defines if the signatures of the checkpoints are verified, they are not verified if the sign
+	// key is not configured
+	SignatureVerified bool `json:"signature_verified"`
+	// UnverifiedRanges is the missing ranges of the chain whose audit logs are moved into the archive files, they are
+	// skipped since only their signed boundaries are verified, the chain is not intact if there is any of them
+	UnverifiedRanges []AuditChainRange     `json:"unverified_ranges"`
+	BrokenLink       *AuditChainBrokenLink `json:"broken_link,omitempty"`
+	MissingRange     *AuditChainRange      `json:"missing_range,omitempty"`
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+// AuditRevertLimit is the maximum number of audit logs that can be reverted at a time
+const AuditRevertLimit = 100
+
+// RevertAuditOption revert the operation recorded by the audit log option, either the id of the audit log or the
+// request id that covers several audit logs must be set
+type RevertAuditOption struct {
+	ID        int64  `json:"id"`
+	RequestID string `json:"rid"`
+}
+
+// Validate revert audit option
+func (o *RevertAuditOption) Validate() errors.RawErrorInfo {
+	if o.ID == 0 && o.RequestID == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"id or rid"}}
+	}
+
+	if o.ID != 0 && o.RequestID != "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"id and rid"}}
+	}
+
+	if o.ID < 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{common.BKFieldID}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// AuditRevertReason is the reason why the operation recorded by the audit log can not be reverted
+type AuditRevertReason string
+
+const (
+	// AuditRevertUnsupported means that the resource type or the action of the audit log is not supported
+	AuditRevertUnsupported AuditRevertReason = "unsupported"
+	// AuditRevertChanged means that the resource has been changed since the operation
+	AuditRevertChanged AuditRevertReason = "changed_since"
+	// AuditRevertNotExist means that the resource to be reverted does not exist
+	AuditRevertNotExist AuditRevertReason = "not_exist"
+	// AuditRevertAlreadyExist means that the deleted resource to be reverted already exists
+	AuditRevertAlreadyExist AuditRevertReason = "already_exist"
+)
+
+// AuditRevertItem is the inverse operation of an audit log, with the diff between the current state of the resource
+// and the state after reverting
+type AuditRevertItem struct {
+	AuditID      int64        `json:"audit_id"`
+	ResourceType ResourceType `json:"resource_type"`
+	ResourceID   interface{}  `json:"resource_id"`
+	ObjID        string       `json:"bk_obj_id,omitempty"`
+	// Action is the action recorded by the audit log
+	Action ActionType `json:"action"`
+	// RevertAction is the inverse action that is used to revert the operation
+	RevertAction ActionType `json:"revert_action,omitempty"`
+	// Current is the current data of the reverted fields, nil means the resource does not exist
+	Current map[string]interface{} `json:"current"`
+	// Target is the data of the reverted fields after reverting, nil means the resource will be deleted
+	Target map[string]interface{} `json:"target"`
+	// Reason is the reason why the operation can not be reverted, empty means it can be reverted
+	Reason AuditRevertReason `json:"reason,omitempty"`
+}
+
+// RevertAuditResult is the preview or the result of reverting the operations recorded by the audit logs, the
+// operations are reverted only when all of them can be reverted
+type RevertAuditResult struct {
+	Items []AuditRevertItem `json:"items"`
+}
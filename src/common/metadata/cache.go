@@ -46,10 +46,12 @@ type ListWithIDOption struct {
 
 // DeleteArchive TODO
 type DeleteArchive struct {
-	Oid    string      `json:"oid" bson:"oid"`
-	Coll   string      `json:"coll" bson:"coll"`
-	Time   time.Time   `json:"time" bson:"time"`
-	Detail interface{} `json:"detail" bson:"detail"`
+	Oid  string    `json:"oid" bson:"oid"`
+	Coll string    `json:"coll" bson:"coll"`
+	Time time.Time `json:"time" bson:"time"`
+	// Operator is the user who deleted the data
+	Operator string      `json:"operator" bson:"operator"`
+	Detail   interface{} `json:"detail" bson:"detail"`
 }
 
 // ListHostWithPage TODO
@@ -13,6 +13,7 @@
 package metadata
 
 import (
+	"net/url"
 	"time"
 
 	"configcenter/src/common"
@@ -34,6 +35,11 @@ type CloudAccount struct {
 	LastEditor  string    `json:"bk_last_editor" bson:"bk_last_editor"`
 	CreateTime  time.Time `json:"create_time" bson:"create_time"`
 	LastTime    time.Time `json:"last_time" bson:"last_time"`
+
+	// Endpoint 云厂商接口地址，仅HTTP主机清单类型的云厂商需要设置
+	Endpoint string `json:"bk_endpoint,omitempty" bson:"bk_endpoint,omitempty"`
+	// FieldMapping 主机属性到主机清单实例字段的映射，仅HTTP主机清单类型的云厂商使用，未设置的属性使用默认字段
+	FieldMapping map[string]string `json:"bk_field_mapping,omitempty" bson:"bk_field_mapping,omitempty"`
 }
 
 // Validate TODO
@@ -72,6 +78,47 @@ func (c *CloudAccount) Validate() (rawError errors.RawErrorInfo) {
 		}
 	}
 
+	if c.CloudVendor == HTTPInventory {
+		if rawErr := ValidateInventoryEndpoint(c.Endpoint); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+		return ValidateInventoryFieldMapping(c.FieldMapping)
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ValidateInventoryEndpoint validate the endpoint of the http inventory cloud vendor
+func ValidateInventoryEndpoint(endpoint string) errors.RawErrorInfo {
+	if endpoint == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKCloudEndpoint},
+		}
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{common.BKCloudEndpoint},
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ValidateInventoryFieldMapping validate the field mapping of the http inventory cloud vendor
+func ValidateInventoryFieldMapping(fieldMapping map[string]string) errors.RawErrorInfo {
+	for field, key := range fieldMapping {
+		if !util.InStrArr(InventoryMappingFields, field) || key == "" {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{common.BKCloudFieldMapping},
+			}
+		}
+	}
+
 	return errors.RawErrorInfo{}
 }
 
@@ -87,17 +134,25 @@ type CloudAccountWithExtraInfo struct {
 const (
 	AWS          string = "1"
 	TencentCloud string = "2"
+	// HTTPInventory 企业私有云，通过HTTP主机清单接口获取私有云的地域、vpc和主机实例
+	HTTPInventory string = "5"
 )
 
 // SupportedCloudVendors 支持的云厂商
 // 实现了相应的云厂商插件
-var SupportedCloudVendors = []string{AWS, TencentCloud}
+var SupportedCloudVendors = []string{AWS, TencentCloud, HTTPInventory}
+
+// InventoryMappingFields HTTP主机清单实例中可以通过bk_field_mapping配置映射的主机属性
+var InventoryMappingFields = []string{common.BKCloudInstIDField, common.BKHostInnerIPField,
+	common.BKHostOuterIPField, common.BKCloudHostStatusField, common.BKVpcID}
 
 // 云同步任务同步状态
 const (
 	CloudSyncSuccess    string = "cloud_sync_success"
 	CloudSyncFail       string = "cloud_sync_fail"
 	CloudSyncInProgress string = "cloud_sync_in_progress"
+	// CloudSyncPendingApproval 同步的变更已生成为待审批的变更集，需要审批后才会应用
+	CloudSyncPendingApproval string = "cloud_sync_pending_approval"
 )
 
 // CloudAccountConf 云厂商账户配置
@@ -106,6 +161,9 @@ type CloudAccountConf struct {
 	VendorName string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
 	SecretID   string `json:"bk_secret_id" bson:"bk_secret_id"`
 	SecretKey  string `json:"bk_secret_key" bson:"bk_secret_key"`
+	// Endpoint 和 FieldMapping 仅HTTP主机清单类型的云厂商使用
+	Endpoint     string            `json:"bk_endpoint,omitempty" bson:"bk_endpoint,omitempty"`
+	FieldMapping map[string]string `json:"bk_field_mapping,omitempty" bson:"bk_field_mapping,omitempty"`
 }
 
 // SearchCloudOption TODO
@@ -180,9 +238,11 @@ type MultipleCloudAccountConf struct {
 
 // CloudAccountVerify TODO
 type CloudAccountVerify struct {
-	SecretID    string `json:"bk_secret_id"`
-	SecretKey   string `json:"bk_secret_key"`
-	CloudVendor string `json:"bk_cloud_vendor"`
+	SecretID     string            `json:"bk_secret_id"`
+	SecretKey    string            `json:"bk_secret_key"`
+	CloudVendor  string            `json:"bk_cloud_vendor"`
+	Endpoint     string            `json:"bk_endpoint"`
+	FieldMapping map[string]string `json:"bk_field_mapping"`
 }
 
 // SearchAccountValidityOption TODO
@@ -243,6 +303,8 @@ type CloudSyncTask struct {
 	SyncAll           bool           `json:"bk_sync_all" bson:"bk_sync_all"`
 	SyncAllDir        int64          `json:"bk_sync_all_dir" bson:"bk_sync_all_dir"`
 	SyncVpcs          []VpcSyncInfo  `json:"bk_sync_vpcs" bson:"bk_sync_vpcs"`
+	DryRun            bool           `json:"bk_dry_run" bson:"bk_dry_run"`
+	Paused            bool           `json:"bk_paused" bson:"bk_paused"`
 	Creator           string         `json:"bk_creator" bson:"bk_creator"`
 	LastEditor        string         `json:"bk_last_editor" bson:"bk_last_editor"`
 	CreateTime        time.Time      `json:"create_time" bson:"create_time"`
@@ -414,6 +476,87 @@ type SyncResult struct {
 	StatusDescription SyncStatusDesc  `json:"bk_status_description" bson:"bk_status_description"`
 }
 
+// 云同步变更集状态
+const (
+	// ChangeSetPending 待审批
+	ChangeSetPending string = "pending"
+	// ChangeSetApplied 已审批并应用
+	ChangeSetApplied string = "applied"
+	// ChangeSetSuperseded 被同一任务更新的变更集替代，不能再应用
+	ChangeSetSuperseded string = "superseded"
+)
+
+// 云同步变更集生成原因
+const (
+	// ChangeSetReasonDryRun 同步任务开启了试运行
+	ChangeSetReasonDryRun string = "dry_run"
+	// ChangeSetReasonDeleteThreshold 要删除的主机比例超过了阈值，任务被自动暂停
+	ChangeSetReasonDeleteThreshold string = "delete_threshold"
+)
+
+// CloudSyncChangeSet 云同步任务待审批的变更集，包含一次同步要新增、更新、删除的主机和要标记为已销毁的云区域
+type CloudSyncChangeSet struct {
+	ChangeSetID int64  `json:"bk_change_set_id" bson:"bk_change_set_id"`
+	TaskID      int64  `json:"bk_task_id" bson:"bk_task_id"`
+	Status      string `json:"bk_status" bson:"bk_status"`
+	Reason      string `json:"bk_reason" bson:"bk_reason"`
+	// DeleteRatio 要删除的主机占任务下已同步主机的百分比
+	DeleteRatio   float64           `json:"delete_ratio" bson:"delete_ratio"`
+	Add           []CloudHost       `json:"add" bson:"add"`
+	Update        []CloudHostUpdate `json:"update" bson:"update"`
+	Delete        []CloudHost       `json:"delete" bson:"delete"`
+	DestroyedVpcs []VpcSyncInfo     `json:"destroyed_vpcs" bson:"destroyed_vpcs"`
+	OwnerID       string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Operator      string            `json:"operator,omitempty" bson:"operator,omitempty"`
+	CreateTime    time.Time         `json:"create_time" bson:"create_time"`
+	LastTime      time.Time         `json:"last_time" bson:"last_time"`
+}
+
+// CloudHostUpdate 变更集中要更新的云主机，包含更新前后有差异的字段值
+type CloudHostUpdate struct {
+	CloudHost `json:",inline" bson:",inline"`
+	Before    map[string]interface{} `json:"before" bson:"before"`
+	After     map[string]interface{} `json:"after" bson:"after"`
+}
+
+// SearchSyncChangeSetOption 查询云同步变更集的条件
+type SearchSyncChangeSetOption struct {
+	TaskID      int64    `json:"bk_task_id"`
+	ChangeSetID int64    `json:"bk_change_set_id"`
+	Status      string   `json:"bk_status"`
+	Fields      []string `json:"fields"`
+	Page        BasePage `json:"page"`
+}
+
+// Validate validate the search sync change set option
+func (s *SearchSyncChangeSetOption) Validate() (rawError errors.RawErrorInfo) {
+	if s.TaskID <= 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedSet,
+			Args:    []interface{}{common.BKCloudTaskID},
+		}
+	}
+
+	if s.Page.IsIllegal() {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommPageLimitIsExceeded,
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// MultipleSyncChangeSet TODO
+type MultipleSyncChangeSet struct {
+	Count int64                `json:"count"`
+	Info  []CloudSyncChangeSet `json:"info"`
+}
+
+// ApplySyncChangeSetOption 审批并应用云同步变更集的参数
+type ApplySyncChangeSetOption struct {
+	TaskID int64 `json:"bk_task_id"`
+}
+
 // SecretKeyResult TODO
 type SecretKeyResult struct {
 	Code    int           `json:"code"`
@@ -713,6 +713,18 @@ type MultipleSyncHistoryResult struct {
 	Data     MultipleSyncHistory `json:"data"`
 }
 
+// CreateSyncChangeSetResult TODO
+type CreateSyncChangeSetResult struct {
+	BaseResp `json:",inline"`
+	Data     CloudSyncChangeSet `json:"data"`
+}
+
+// MultipleSyncChangeSetResult TODO
+type MultipleSyncChangeSetResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleSyncChangeSet `json:"data"`
+}
+
 // MultipleSyncRegionResult TODO
 type MultipleSyncRegionResult struct {
 	BaseResp `json:",inline"`
@@ -223,7 +223,7 @@ const (
 func getAttributeType(attributeType string) (string, error) {
 	switch attributeType {
 	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeEnumMulti,
-		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList:
+		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList, common.FieldTypeIP, common.FieldTypeCIDR:
 		return stringType, nil
 	case common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeOrganization, common.FieldTypeEnumQuote:
 		return numericType, nil
@@ -437,6 +437,22 @@ func (g *DynamicGroup) Validate(validatefunc Validatefunc) error {
 	return g.Info.Validate(g.ObjID, validatefunc)
 }
 
+// DynamicGroupMember is a host that matches the conditions of a host dynamic group, the members are maintained by
+// the dynamic group membership event watch to generate the events of hosts joining or leaving dynamic groups.
+type DynamicGroupMember struct {
+	// ID is the dynamic group unique id.
+	ID string `json:"id" bson:"id"`
+
+	// AppID is application id which dynamic group belongs to.
+	AppID int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+
+	// HostID is the member host id.
+	HostID int64 `json:"bk_host_id" bson:"bk_host_id"`
+
+	// SupplierAccount supplier account.
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
 // DynamicGroupBatch is batch result struct of dynamic group.
 type DynamicGroupBatch struct {
 	// Count batch count.
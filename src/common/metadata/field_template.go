@@ -181,7 +181,8 @@ func (f *FieldTemplateAttr) validateType() ccErr.RawErrorInfo {
 	switch f.PropertyType {
 	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
 		common.FieldTypeEnumMulti, common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser,
-		common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList:
+		common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList,
+		common.FieldTypeIP, common.FieldTypeCIDR:
 
 	default:
 		return ccErr.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid,
@@ -565,3 +565,114 @@ type CheckHostApplyEnabledOption struct {
 type CheckHostApplyEnabledRes struct {
 	HostApplyEnabledIDs []int64 `json:"host_apply_enabled_ids"`
 }
+
+// HostApplyDrift a host attribute whose value drifts away from the host apply rules of the host's modules,
+// it is generated by the scheduled host apply drift check of the host server.
+type HostApplyDrift struct {
+	BizID       int64   `json:"bk_biz_id" bson:"bk_biz_id"`
+	HostID      int64   `json:"bk_host_id" bson:"bk_host_id"`
+	ModuleIDs   []int64 `json:"bk_module_ids" bson:"bk_module_ids"`
+	AttributeID int64   `json:"bk_attribute_id" bson:"bk_attribute_id"`
+	PropertyID  string  `json:"bk_property_id" bson:"bk_property_id"`
+	// ExpectValue is the value that the host apply rules expect, it is nil if the rules are in conflict
+	ExpectValue interface{} `json:"expect_value" bson:"expect_value"`
+	ActualValue interface{} `json:"actual_value" bson:"actual_value"`
+	RuleIDs     []int64     `json:"host_apply_rule_ids" bson:"host_apply_rule_ids"`
+	// Conflict represents whether the host apply rules of the host's modules have different values
+	Conflict bool `json:"conflict" bson:"conflict"`
+	// Remediated represents whether the drift has been fixed by the auto remediation
+	Remediated bool      `json:"remediated" bson:"remediated"`
+	DetectTime time.Time `json:"detect_time" bson:"detect_time"`
+	// RunID is the id of the drift check run that detects the drift, only the drifts of the active run are reported
+	RunID           string `json:"run_id" bson:"run_id"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// HostApplyDriftRun the drift check run status of a business. the drifts of a run are saved in batches, and the run
+// becomes the active one that is reported only after all of its drifts are saved.
+type HostApplyDriftRun struct {
+	BizID int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+	// RunID is the id of the active run, it is the last run that succeeded
+	RunID string `json:"run_id" bson:"run_id"`
+	// FinishTime is the finish time of the active run
+	FinishTime time.Time `json:"finish_time" bson:"finish_time"`
+	// LastRunTime is the finish time of the last run, no matter whether it succeeded or not
+	LastRunTime time.Time `json:"last_run_time" bson:"last_run_time"`
+	// LastError is the error of the last run, it is empty if the last run succeeded
+	LastError       string `json:"last_error" bson:"last_error"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// SaveHostApplyDriftOption save a batch of the drifts detected by a drift check run
+type SaveHostApplyDriftOption struct {
+	RunID  string           `json:"run_id"`
+	Drifts []HostApplyDrift `json:"drifts"`
+}
+
+// Validate save host apply drift option
+func (o *SaveHostApplyDriftOption) Validate() errors.RawErrorInfo {
+	if len(o.RunID) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"run_id"}}
+	}
+
+	if len(o.Drifts) > common.BKMaxLimitSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit, Args: []interface{}{"drifts",
+			common.BKMaxLimitSize}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// FinishHostApplyDriftRunOption finish a drift check run option. if the run succeeded, it becomes the active run and
+// the drifts of the other runs are removed, otherwise the error is recorded and the drifts of the run are removed.
+type FinishHostApplyDriftRunOption struct {
+	RunID string `json:"run_id"`
+	Error string `json:"error"`
+}
+
+// Validate finish host apply drift run option
+func (o *FinishHostApplyDriftRunOption) Validate() errors.RawErrorInfo {
+	if len(o.RunID) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"run_id"}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ListHostApplyDriftOption list host apply drift report option
+type ListHostApplyDriftOption struct {
+	HostIDs      []int64  `json:"bk_host_ids"`
+	ModuleIDs    []int64  `json:"bk_module_ids"`
+	AttributeIDs []int64  `json:"bk_attribute_ids"`
+	Conflict     *bool    `json:"conflict"`
+	Remediated   *bool    `json:"remediated"`
+	Page         BasePage `json:"page"`
+}
+
+// Validate list host apply drift report option
+func (o *ListHostApplyDriftOption) Validate() errors.RawErrorInfo {
+	if len(o.HostIDs) > common.BKMaxLimitSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit, Args: []interface{}{"bk_host_ids",
+			common.BKMaxLimitSize}}
+	}
+
+	if len(o.ModuleIDs) > common.BKMaxLimitSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit, Args: []interface{}{"bk_module_ids",
+			common.BKMaxLimitSize}}
+	}
+
+	if len(o.AttributeIDs) > common.BKMaxLimitSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit, Args: []interface{}{"bk_attribute_ids",
+			common.BKMaxLimitSize}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// MultipleHostApplyDriftResult host apply drift report result
+type MultipleHostApplyDriftResult struct {
+	Count int64            `json:"count"`
+	Info  []HostApplyDrift `json:"info"`
+	// Run is the drift check run status of the business, it is nil if the check has never run
+	Run *HostApplyDriftRun `json:"run"`
+}
@@ -0,0 +1,302 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+)
+
+const (
+	// RBACAny matches any resource type or any action in the permission of a rbac role
+	RBACAny = "*"
+	// RBACExplainLimit is the maximum number of resources that can be explained at a time
+	RBACExplainLimit = 100
+)
+
+// RBACScopeType is the type of the scope that a rbac role binding takes effect on
+type RBACScopeType string
+
+const (
+	// RBACScopeGlobal the role binding takes effect on all the resources
+	RBACScopeGlobal RBACScopeType = "global"
+	// RBACScopeBiz the role binding takes effect on the businesses and the resources belong to them
+	RBACScopeBiz RBACScopeType = "biz"
+	// RBACScopeBizSet the role binding takes effect on the business sets
+	RBACScopeBizSet RBACScopeType = "biz_set"
+	// RBACScopeClassification the role binding takes effect on the model classifications and the models and
+	// instances belong to them
+	RBACScopeClassification RBACScopeType = "classification"
+	// RBACScopeInstance the role binding takes effect on the specified resource instances
+	RBACScopeInstance RBACScopeType = "instance"
+)
+
+// RBACPermission is the actions that a rbac role is allowed to do with a type of resource
+type RBACPermission struct {
+	// ResourceType is the auth resource type, like "hostInstance", "*" means any resource type
+	ResourceType string `json:"resource_type" bson:"resource_type"`
+	// Actions are the auth actions, like "find", "update", "*" means any action
+	Actions []string `json:"actions" bson:"actions"`
+}
+
+// RBACRole is a named set of permissions of the built-in rbac authorization
+type RBACRole struct {
+	ID          int64            `json:"id" bson:"id"`
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description" bson:"description"`
+	Permissions []RBACPermission `json:"permissions" bson:"permissions"`
+	OwnerID     string           `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string           `json:"creator" bson:"creator"`
+	Modifier    string           `json:"modifier" bson:"modifier"`
+	CreateTime  *Time            `json:"create_time" bson:"create_time"`
+	LastTime    *Time            `json:"last_time" bson:"last_time"`
+}
+
+// Validate rbac role
+func (r *RBACRole) Validate() errors.RawErrorInfo {
+	if len(r.Name) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	if len(r.Permissions) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"permissions"}}
+	}
+
+	for _, permission := range r.Permissions {
+		if len(permission.ResourceType) == 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+				Args: []interface{}{"permissions.resource_type"}}
+		}
+
+		if len(permission.Actions) == 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"permissions.actions"}}
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// RBACScope is the scope of the resources that a rbac role binding takes effect on
+type RBACScope struct {
+	Type RBACScopeType `json:"type" bson:"type"`
+	// IDs are the ids of the businesses, business sets, model classifications or resource instances that the role
+	// binding takes effect on, it must be empty for global scope
+	IDs []string `json:"ids" bson:"ids"`
+	// ResourceType is the resource type of the instances for instance scope, it must be empty for other scopes, so
+	// that the instance ids only take effect on the instances of this resource type
+	ResourceType string `json:"resource_type,omitempty" bson:"resource_type,omitempty"`
+}
+
+// Validate rbac scope
+func (s RBACScope) Validate() errors.RawErrorInfo {
+	if s.Type == RBACScopeInstance {
+		if len(s.ResourceType) == 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet,
+				Args: []interface{}{"scope.resource_type"}}
+		}
+		if s.ResourceType == RBACAny {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid,
+				Args: []interface{}{"scope.resource_type"}}
+		}
+	} else if len(s.ResourceType) != 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.resource_type"}}
+	}
+
+	switch s.Type {
+	case RBACScopeGlobal:
+		if len(s.IDs) != 0 {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.ids"}}
+		}
+		return errors.RawErrorInfo{}
+	case RBACScopeBiz, RBACScopeBizSet, RBACScopeClassification:
+		for _, id := range s.IDs {
+			if intID, err := strconv.ParseInt(id, 10, 64); err != nil || intID <= 0 {
+				return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.ids"}}
+			}
+		}
+	case RBACScopeInstance:
+	default:
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"scope.type"}}
+	}
+
+	if len(s.IDs) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"scope.ids"}}
+	}
+
+	if len(s.IDs) > common.BKMaxPageSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"scope.ids", common.BKMaxPageSize}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// RBACRoleBinding assigns a rbac role to users and user groups on a scope of resources
+type RBACRoleBinding struct {
+	ID         int64     `json:"id" bson:"id"`
+	RoleID     int64     `json:"role_id" bson:"role_id"`
+	Users      []string  `json:"users" bson:"users"`
+	Groups     []string  `json:"groups" bson:"groups"`
+	Scope      RBACScope `json:"scope" bson:"scope"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string    `json:"creator" bson:"creator"`
+	Modifier   string    `json:"modifier" bson:"modifier"`
+	CreateTime *Time     `json:"create_time" bson:"create_time"`
+	LastTime   *Time     `json:"last_time" bson:"last_time"`
+}
+
+// Validate rbac role binding
+func (b *RBACRoleBinding) Validate() errors.RawErrorInfo {
+	if b.RoleID <= 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"role_id"}}
+	}
+
+	if len(b.Users) == 0 && len(b.Groups) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"users or groups"}}
+	}
+
+	return b.Scope.Validate()
+}
+
+// RBACUserGroup is a group of users that rbac roles can be assigned to
+type RBACUserGroup struct {
+	ID          int64    `json:"id" bson:"id"`
+	Name        string   `json:"name" bson:"name"`
+	Description string   `json:"description" bson:"description"`
+	Members     []string `json:"members" bson:"members"`
+	OwnerID     string   `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string   `json:"creator" bson:"creator"`
+	Modifier    string   `json:"modifier" bson:"modifier"`
+	CreateTime  *Time    `json:"create_time" bson:"create_time"`
+	LastTime    *Time    `json:"last_time" bson:"last_time"`
+}
+
+// Validate rbac user group
+func (g *RBACUserGroup) Validate() errors.RawErrorInfo {
+	if len(g.Name) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{common.BKFieldName}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// ListRBACRoleOption list rbac roles option
+type ListRBACRoleOption struct {
+	IDs   []int64  `json:"ids"`
+	Names []string `json:"names"`
+	Page  BasePage `json:"page"`
+}
+
+// Validate list rbac roles option
+func (o *ListRBACRoleOption) Validate() errors.RawErrorInfo {
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListRBACRoleResult list rbac roles result
+type ListRBACRoleResult struct {
+	Count uint64     `json:"count"`
+	Info  []RBACRole `json:"info"`
+}
+
+// ListRBACRoleBindingOption list rbac role bindings option, bindings that match any of the users or groups are
+// returned if users or groups are set
+type ListRBACRoleBindingOption struct {
+	IDs     []int64  `json:"ids"`
+	RoleIDs []int64  `json:"role_ids"`
+	Users   []string `json:"users"`
+	Groups  []string `json:"groups"`
+	Page    BasePage `json:"page"`
+}
+
+// Validate list rbac role bindings option
+func (o *ListRBACRoleBindingOption) Validate() errors.RawErrorInfo {
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListRBACRoleBindingResult list rbac role bindings result
+type ListRBACRoleBindingResult struct {
+	Count uint64            `json:"count"`
+	Info  []RBACRoleBinding `json:"info"`
+}
+
+// ListRBACUserGroupOption list rbac user groups option
+type ListRBACUserGroupOption struct {
+	IDs     []int64  `json:"ids"`
+	Names   []string `json:"names"`
+	Members []string `json:"members"`
+	Page    BasePage `json:"page"`
+}
+
+// Validate list rbac user groups option
+func (o *ListRBACUserGroupOption) Validate() errors.RawErrorInfo {
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ListRBACUserGroupResult list rbac user groups result
+type ListRBACUserGroupResult struct {
+	Count uint64          `json:"count"`
+	Info  []RBACUserGroup `json:"info"`
+}
+
+// RBACExplainOption explain the rbac permissions of a user on the resources option
+type RBACExplainOption struct {
+	User      string         `json:"user"`
+	Resources []AuthResource `json:"resources"`
+}
+
+// Validate rbac explain option
+func (o *RBACExplainOption) Validate() errors.RawErrorInfo {
+	if len(o.User) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"user"}}
+	}
+
+	if len(o.Resources) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"resources"}}
+	}
+
+	if len(o.Resources) > RBACExplainLimit {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"resources", RBACExplainLimit}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// RBACGrant is a rbac role binding that grants the permission of a resource to the user
+type RBACGrant struct {
+	RoleID    int64     `json:"role_id"`
+	RoleName  string    `json:"role_name"`
+	BindingID int64     `json:"binding_id"`
+	Scope     RBACScope `json:"scope"`
+	// Group is the user group through which the role is bound to the user, it is empty if the role is bound directly
+	Group string `json:"group,omitempty"`
+}
+
+// RBACExplainResource is the explanation of the rbac permission of a resource
+type RBACExplainResource struct {
+	AuthResource `json:",inline"`
+	Passed       bool `json:"is_pass"`
+	// Grants are all the role bindings that grant the permission of the resource to the user
+	Grants []RBACGrant `json:"grants"`
+}
+
+// RBACExplainResult is the explanation of the rbac permissions of a user on the resources
+type RBACExplainResult struct {
+	User string `json:"user"`
+	// Admin means that the user is a rbac administrator who has all the permissions
+	Admin     bool                  `json:"admin"`
+	Groups    []string              `json:"groups"`
+	Resources []RBACExplainResource `json:"resources"`
+}
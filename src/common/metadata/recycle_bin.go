@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+)
+
+// RecycleBinRestoreLimit is the maximum number of archived records that can be restored at a time
+const RecycleBinRestoreLimit = 100
+
+// ListRecycleBinOption list the archived deleted instances of a model option
+type ListRecycleBinOption struct {
+	// BizID filters the archived instances that belong to the business, hosts are filtered by the business of their
+	// archived host module relations
+	BizID int64 `json:"bk_biz_id"`
+	// Operator filters the archived instances that are deleted by the user
+	Operator string `json:"operator"`
+	// Time filters the archived instances that are deleted between the start and end time
+	Time OperationTimeCondition `json:"time"`
+	// InstIDs filters the archived instances by their instance ids
+	InstIDs []int64  `json:"inst_ids"`
+	Page    BasePage `json:"page"`
+}
+
+// Validate list recycle bin option
+func (o *ListRecycleBinOption) Validate() errors.RawErrorInfo {
+	if o.BizID < 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{common.BKAppIDField}}
+	}
+
+	if len(o.InstIDs) > common.BKMaxPageSize {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"inst_ids", common.BKMaxPageSize}}
+	}
+
+	if _, _, err := o.Time.ParseTime(); err != nil {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsIsInvalid, Args: []interface{}{"time"}}
+	}
+
+	return o.Page.ValidateWithEnableCount(false)
+}
+
+// ParseTime parse the start and end time of the condition, zero time is returned if it is not set
+func (o OperationTimeCondition) ParseTime() (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if len(o.Start) != 0 {
+		start, err = time.ParseInLocation(common.TimeTransferModel, o.Start, time.Local)
+		if err != nil {
+			return start, end, err
+		}
+	}
+
+	if len(o.End) != 0 {
+		end, err = time.ParseInLocation(common.TimeTransferModel, o.End, time.Local)
+		if err != nil {
+			return start, end, err
+		}
+	}
+
+	return start, end, nil
+}
+
+// RecycleBinRecord is an archived deleted instance in the recycle bin
+type RecycleBinRecord struct {
+	// ID is the id of the archived record, it is used to restore the instance
+	ID       string        `json:"id"`
+	ObjID    string        `json:"bk_obj_id"`
+	InstID   int64         `json:"bk_inst_id"`
+	BizID    int64         `json:"bk_biz_id"`
+	Operator string        `json:"operator"`
+	Time     time.Time     `json:"time"`
+	Detail   mapstr.MapStr `json:"detail"`
+}
+
+// ListRecycleBinResult list recycle bin result
+type ListRecycleBinResult struct {
+	Count uint64             `json:"count"`
+	Info  []RecycleBinRecord `json:"info"`
+}
+
+// RestoreRecycleBinOption restore the archived deleted instances option
+type RestoreRecycleBinOption struct {
+	// IDs are the ids of the archived records to restore
+	IDs []string `json:"ids"`
+}
+
+// Validate restore recycle bin option
+func (o *RestoreRecycleBinOption) Validate() errors.RawErrorInfo {
+	if len(o.IDs) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"ids"}}
+	}
+
+	if len(o.IDs) > RecycleBinRestoreLimit {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommXXExceedLimit,
+			Args: []interface{}{"ids", RecycleBinRestoreLimit}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// RestoreConflictReason is the reason why an archived instance can not be restored
+type RestoreConflictReason string
+
+const (
+	// RestoreConflictIDExists means that an instance with the same id exists
+	RestoreConflictIDExists RestoreConflictReason = "id_exists"
+	// RestoreConflictDuplicateID means that the instance is selected more than once
+	RestoreConflictDuplicateID RestoreConflictReason = "duplicate_id"
+	// RestoreConflictUnique means that the instance violates the unique rule of the model
+	RestoreConflictUnique RestoreConflictReason = "unique_rule"
+)
+
+// RestoreConflict is the conflict that prevents the archived instance from being restored
+type RestoreConflict struct {
+	ID     string                `json:"id"`
+	InstID int64                 `json:"bk_inst_id"`
+	Reason RestoreConflictReason `json:"reason"`
+	// UniqueID is the id of the violated unique rule
+	UniqueID uint64 `json:"unique_id,omitempty"`
+}
+
+// RestoreRecycleBinResult is the preview or the result of restoring the archived deleted instances, the instances
+// are restored only when there is no conflict
+type RestoreRecycleBinResult struct {
+	Instances []mapstr.MapStr `json:"instances"`
+	// Associations are the archived instance associations whose both sides and model association still exist
+	Associations []InstAsst `json:"associations"`
+	// HostRelations are the archived host module relations whose module still exists, hosts that have no such
+	// relation are restored to the idle module of the resource pool
+	HostRelations []ModuleHost      `json:"host_relations"`
+	Conflicts     []RestoreConflict `json:"conflicts"`
+}
@@ -63,6 +63,35 @@ type APITaskDetail struct {
 	CreateTime time.Time `json:"create_time,omitempty" bson:"create_time"`
 	// LastTime 任务最后更新时间
 	LastTime time.Time `json:"last_time,omitempty" bson:"last_time"`
+	// ExecuteHistory 任务最近的执行记录，最多保留APITaskMaxExecuteHistory条
+	ExecuteHistory []APITaskExecuteRecord `json:"execute_history,omitempty" bson:"execute_history,omitempty"`
+	// ExecuteCount 任务的执行次数，不受执行记录保留条数的限制
+	ExecuteCount int64 `json:"execute_count,omitempty" bson:"execute_count,omitempty"`
+}
+
+// APITaskExecuteRecord one execution record of the api task
+type APITaskExecuteRecord struct {
+	// Attempt 第几次执行该任务，从1开始
+	Attempt int64 `json:"attempt" bson:"attempt"`
+	// StartTime 本次执行的开始时间
+	StartTime time.Time `json:"start_time" bson:"start_time"`
+	// EndTime 本次执行的结束时间
+	EndTime time.Time `json:"end_time" bson:"end_time"`
+	// Status 本次执行结束后的任务状态，执行被中断时为executing
+	Status APITaskStatus `json:"status" bson:"status"`
+	// Error 本次执行失败或被中断的原因
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+	// SubTasks 本次执行的子任务结果
+	SubTasks []APISubTaskExecuteRecord `json:"sub_tasks" bson:"sub_tasks"`
+}
+
+// APISubTaskExecuteRecord execution result of the sub task in one execution of the api task
+type APISubTaskExecuteRecord struct {
+	SubTaskID string        `json:"sub_task_id" bson:"sub_task_id"`
+	Status    APITaskStatus `json:"status" bson:"status"`
+	// Retry 请求执行子任务的重试次数
+	Retry int64  `json:"retry" bson:"retry"`
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
 }
 
 // APISubTaskDetail task data and execute detail
@@ -153,8 +182,20 @@ const (
 	// APITaskFieldTemplateMaxNum the possible task status scenarios are: one is executing,
 	// one is waiting or new, but there will be no more than two tasks.
 	APITaskFieldTemplateMaxNum = 2
+	// APITaskExecuteHistoryField the field that stores the latest execute records of the task
+	APITaskExecuteHistoryField = "execute_history"
+	// APITaskMaxExecuteHistory the maximum number of execute records kept for each task
+	APITaskMaxExecuteHistory = 20
+	// APITaskExecuteCountField the field that stores the execute count of the task
+	APITaskExecuteCountField = "execute_count"
 )
 
+// APITaskListFields the fields of the task returned by the list apis, the execute history is excluded since it is
+// large and is listed by the execute history api
+var APITaskListFields = []string{common.BKTaskIDField, "task_type", common.BKInstIDField, APITaskExtraField, "user",
+	"header", common.BKStatusField, "detail", common.BkSupplierAccount, common.CreateTimeField, common.LastTimeField,
+	APITaskExecuteCountField}
+
 // ListAPITaskRequest TODO
 type ListAPITaskRequest struct {
 	Condition mapstr.MapStr `json:"condition"`
@@ -209,6 +250,12 @@ type TaskDetailData struct {
 	Info APITaskDetail `json:"info"`
 }
 
+// ListTaskExecuteHistoryResponse list api task execute history response
+type ListTaskExecuteHistoryResponse struct {
+	BaseResp
+	Data []APITaskExecuteRecord `json:"data"`
+}
+
 // ListAPITaskDetail list api task detail condition
 type ListAPITaskDetail struct {
 	InstID []int64  `json:"bk_inst_id"`
@@ -71,6 +71,23 @@ type LoginUserPluginInerface interface {
 	GetUserList(c *gin.Context, config map[string]string) ([]*LoginSystemUserInfo, *errors.RawErrorInfo)
 }
 
+// LoginCallbackPluginInterface is implemented by the login plugins that redirect the user to an external login system,
+// it handles the callback request from the login system after the user logs in, and returns the url to redirect to
+type LoginCallbackPluginInterface interface {
+	HandleLoginCallback(c *gin.Context, config map[string]string) (redirectURL string, err error)
+}
+
+// LogoutPluginInterface is implemented by the login plugins that need to log the user out of the login system too
+type LogoutPluginInterface interface {
+	GetLogoutUrl(c *gin.Context, config map[string]string, input *LogoutRequestParams) string
+}
+
+// PasswordLoginPluginInterface is implemented by the login plugins that authenticate the user name and password
+// submitted to the login page of web server, the plugin saves the identity in the session when authenticated
+type PasswordLoginPluginInterface interface {
+	AuthenticateUser(c *gin.Context, config map[string]string, userName, password string) *errors.RawErrorInfo
+}
+
 // LoginSystemUserInfo TODO
 type LoginSystemUserInfo struct {
 	CnName string `json:"chinese_name"`
@@ -101,14 +101,55 @@ const (
 	// BKTableNameHostApplyRule rule for host property auto apply
 	BKTableNameHostApplyRule = "cc_HostApplyRule"
 
+	// BKTableNameHostApplyDrift host apply drift report generated by the scheduled host apply drift check
+	BKTableNameHostApplyDrift = "cc_HostApplyDrift"
+
+	// BKTableNameHostApplyDriftRun host apply drift check run status of each business
+	BKTableNameHostApplyDriftRun = "cc_HostApplyDriftRun"
+
+	// BKTableNameAuditLogArchive index of the archive files that the expired audit logs are moved into
+	BKTableNameAuditLogArchive = "cc_AuditLogArchive"
+
+	// BKTableNameAuditLogCheckpoint signed checkpoints of the audit log hash chains
+	BKTableNameAuditLogCheckpoint = "cc_AuditLogCheckpoint"
+
+	// BKTableNameAuditLogExportProgress the last exported audit log id of the audit log export sinks
+	BKTableNameAuditLogExportProgress = "cc_AuditLogExportProgress"
+
+	// BKTableNameRBACRole roles of the built-in rbac authorization
+	BKTableNameRBACRole = "cc_RBACRole"
+	// BKTableNameRBACRoleBinding bindings that assign the rbac roles to users and user groups
+	BKTableNameRBACRoleBinding = "cc_RBACRoleBinding"
+	// BKTableNameRBACUserGroup user groups of the built-in rbac authorization
+	BKTableNameRBACUserGroup = "cc_RBACUserGroup"
+
+	// BKTableNameAPIToken personal api tokens that users use to access the api server
+	BKTableNameAPIToken = "cc_APIToken"
+
+	// BKTableNameDynamicGroupMember host dynamic group members that the dynamic group membership events are based on
+	BKTableNameDynamicGroupMember = "cc_DynamicGroupMember"
+
 	// cloud sync tables
 	BKTableNameCloudSyncTask    = "cc_CloudSyncTask"
 	BKTableNameCloudAccount     = "cc_CloudAccount"
 	BKTableNameCloudSyncHistory = "cc_CloudSyncHistory"
+	// BKTableNameCloudSyncChangeSet pending change sets generated by dry-run or paused cloud sync tasks
+	BKTableNameCloudSyncChangeSet = "cc_CloudSyncChangeSet"
+
+	// BKTableNameSyncBundleProgress the last sealed bundle sequence of the source environment and the last imported
+	// bundle sequence of the destination environment that uses the file transfer medium
+	BKTableNameSyncBundleProgress = "cc_SyncBundleProgress"
+	// BKTableNameSyncBundleData the imported sync data of the file transfer medium that is waiting to be pulled
+	BKTableNameSyncBundleData = "cc_SyncBundleData"
 
 	// BKTableNameWatchToken the table to store the latest watch token for collections
 	BKTableNameWatchToken = "cc_WatchToken"
 
+	// BKTableNameWatchSubscription the table to store the webhook subscriptions of the watch events
+	BKTableNameWatchSubscription = "cc_WatchSubscription"
+	// BKTableNameWatchDeadLetter the table to store the watch event batches that failed to be delivered to webhooks
+	BKTableNameWatchDeadLetter = "cc_WatchDeadLetter"
+
 	// BKTableNameMainlineInstance is a virtual collection name which represent for mainline instance events
 	BKTableNameMainlineInstance = "cc_MainlineInstance"
 
@@ -29,11 +29,11 @@ func TestGetInstTableName(t *testing.T) {
 		{"", args{BKInnerObjIDHost}, BKTableNameBaseHost},
 		{"", args{BKInnerObjIDProc}, BKTableNameBaseProcess},
 		{"", args{BKInnerObjIDPlat}, BKTableNameBasePlat},
-		{"", args{"object"}, "cc_ObjectBase_pub_object"},
+		{"", args{"object"}, "cc_ObjectBase_0_pub_object"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GetInstTableName(tt.args.objID); got != tt.want {
+			if got := GetInstTableName(tt.args.objID, BKDefaultOwnerID); got != tt.want {
 				t.Errorf("GetInstTableName() = %v, want %v", got, tt.want)
 			}
 		})
@@ -36,6 +36,13 @@ var delArchiveCollMap = map[string]string{
 	common.BKTableNameBaseBizSet:              common.BKTableNameDelArchive,
 	common.BKTableNameBasePlat:                common.BKTableNameDelArchive,
 	common.BKTableNameBaseProject:             common.BKTableNameDelArchive,
+	common.BKTableNameObjDes:                  common.BKTableNameDelArchive,
+	common.BKTableNameObjAttDes:               common.BKTableNameDelArchive,
+	common.BKTableNamePropertyGroup:           common.BKTableNameDelArchive,
+	common.BKTableNameObjUnique:               common.BKTableNameDelArchive,
+	common.BKTableNameServiceTemplate:         common.BKTableNameDelArchive,
+	common.BKTableNameFieldTemplate:           common.BKTableNameDelArchive,
+	common.BKTableNameDynamicGroup:            common.BKTableNameDelArchive,
 	fullsynccond.BKTableNameFullSyncCond:      common.BKTableNameDelArchive,
 
 	common.BKTableNameBaseInst:         common.BKTableNameDelArchive,
@@ -80,7 +87,9 @@ func GetDelArchiveTable(table string) (string, bool) {
 func GetDelArchiveFields(table string) []string {
 	switch table {
 	case common.BKTableNameServiceInstance:
-		return []string{common.BKFieldID}
+		// only archive the identity fields of service instance, which are used as its delete event detail
+		return []string{common.BKFieldID, common.BKFieldName, common.BKAppIDField, common.BKModuleIDField,
+			common.BKHostIDField, common.BKServiceTemplateIDField, common.BkSupplierAccount}
 	}
 
 	return make([]string, 0)
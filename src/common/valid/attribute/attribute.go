@@ -20,6 +20,7 @@ package attrvalid
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"unicode/utf8"
 
 	"configcenter/src/common"
@@ -305,6 +306,41 @@ var validTableFieldType = map[string]struct{}{
 	common.FieldTypeBool:       {},
 }
 
+// ValidFieldTypeNetwork validate ip or cidr field type's default value
+func ValidFieldTypeNetwork(kit *rest.Kit, propertyType string, defaultVal interface{}) error {
+	if defaultVal == nil {
+		return nil
+	}
+
+	value, ok := defaultVal.(string)
+	if !ok {
+		blog.Errorf("%s default value %+v type %T is invalid, rid: %s", propertyType, defaultVal, defaultVal, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsNeedString, metadata.AttributeFieldDefault)
+	}
+
+	// validate by the same convert functions as the instance values, so that they accept the same values
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil
+	}
+
+	var err error
+	switch propertyType {
+	case common.FieldTypeIP:
+		_, err = common.ConvertIPToStandardFormat(value)
+	case common.FieldTypeCIDR:
+		_, err = common.ConvertCIDRToStandardFormat(value)
+	default:
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldPropertyType)
+	}
+
+	if err != nil {
+		blog.Errorf("%s default value %s is invalid, err: %v, rid: %s", propertyType, value, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldDefault)
+	}
+	return nil
+}
+
 // ValidTableFieldOption judging the legitimacy of the basic type of the form field
 func ValidTableFieldOption(kit *rest.Kit, propertyType string, option, defaultValue interface{},
 	isMultiple *bool, objID string) error {
@@ -327,7 +363,7 @@ func ValidPropertyTypeIsMultiple(kit *rest.Kit, propertyType string, isMultiple
 	switch propertyType {
 	case common.FieldTypeSingleChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
 		common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeLongChar, common.FieldTypeTimeZone,
-		common.FieldTypeBool, common.FieldTypeList:
+		common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIP, common.FieldTypeCIDR:
 		if isMultiple != nil && *isMultiple {
 			return kit.CCError.Errorf(common.CCErrCommFieldTypeNotSupportMultiple, propertyType)
 		}
@@ -107,9 +107,9 @@ func TestValidPropertyOption(t *testing.T) {
 			Type:      "text",
 			IsDefault: true,
 		}}, true, []string{"c", "b"}}, false},
-		{"int", args{common.FieldTypeInt, metadata.PrevIntOption{
-			Min: 1,
-			Max: 100,
+		{"int", args{common.FieldTypeInt, map[string]interface{}{
+			"min": 1,
+			"max": 100,
 		}, false, 1}, false},
 		{"int_default", args{common.FieldTypeInt, "{}", false, 1}, false},
 		{"float", args{common.FieldTypeFloat, map[string]interface{}{
@@ -130,9 +130,9 @@ func TestValidPropertyOption(t *testing.T) {
 			Type:      "aaa",
 			IsDefault: false,
 		}}, false, nil}, true},
-		{"int", args{common.FieldTypeInt, metadata.PrevIntOption{
-			Min: 101,
-			Max: 100,
+		{"int", args{common.FieldTypeInt, map[string]interface{}{
+			"min": 101,
+			"max": 100,
 		}, false, 100}, true},
 		{"int_default", args{common.FieldTypeInt, `{"min":1}`, false, -1}, true},
 		{"float", args{common.FieldTypeFloat, map[string]interface{}{
@@ -147,8 +147,12 @@ func TestValidPropertyOption(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidPropertyOption(kit, tt.args.propertyType, tt.args.option, tt.args.isMultiple,
-				tt.args.defaultVal)
+			// the extra option of enum is whether it is multiple, and is the default value of the other types
+			var extraOpt interface{} = tt.args.defaultVal
+			if tt.args.propertyType == common.FieldTypeEnum || tt.args.propertyType == common.FieldTypeEnumMulti {
+				extraOpt = &tt.args.isMultiple
+			}
+			err := ValidPropertyOption(kit, tt.args.propertyType, tt.args.option, extraOpt)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidPropertyOption() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -176,3 +180,35 @@ func TestIsStrProperty(t *testing.T) {
 		})
 	}
 }
+
+func TestValidFieldTypeNetwork(t *testing.T) {
+	kit := rest.NewKitFromHeader(http.Header{}, errif{})
+
+	tests := []struct {
+		name         string
+		propertyType string
+		defaultVal   interface{}
+		wantErr      bool
+	}{
+		{"nil", common.FieldTypeIP, nil, false},
+		{"empty", common.FieldTypeIP, " ", false},
+		{"ipv4", common.FieldTypeIP, "10.0.0.1", false},
+		{"ipv4_with_spaces", common.FieldTypeIP, " 10.0.0.1 ", false},
+		{"ipv6", common.FieldTypeIP, "2001:db8::1", false},
+		{"cidr", common.FieldTypeCIDR, "10.0.0.0/8", false},
+		{"cidr_with_spaces", common.FieldTypeCIDR, " 2001:db8::/32 ", false},
+		// invalid test
+		{"ip", common.FieldTypeIP, "10.0.0.256", true},
+		{"ip_type", common.FieldTypeIP, 1, true},
+		{"cidr", common.FieldTypeCIDR, "10.0.0.1", true},
+		{"property_type", common.FieldTypeSingleChar, "10.0.0.1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidFieldTypeNetwork(kit, tt.propertyType, tt.defaultVal)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidFieldTypeNetwork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -79,6 +79,16 @@ var (
 		KubeWorkload:            20,
 		KubePod:                 21,
 		Project:                 22,
+		DynamicGroupMembership:  23,
+		Model:                   24,
+		ModelAttribute:          25,
+		ModelAttributeGroup:     26,
+		ModelUnique:             27,
+		ServiceTemplate:         28,
+		SetTemplate:             29,
+		ServiceInstance:         30,
+		FieldTemplate:           31,
+		DynamicGroup:            32,
 	}
 
 	intCursorTypeMap = make(map[int]CursorType)
@@ -124,6 +134,29 @@ const (
 	Plat CursorType = "plat"
 	// Project project event cursor type
 	Project CursorType = "project"
+	// DynamicGroupMembership a mixed event type containing host, host relation, set, module & dynamic group events,
+	// which are converted to the events of hosts joining or leaving host dynamic groups
+	DynamicGroupMembership CursorType = "dynamic_group_membership"
+	// model metadata related cursor types
+	// Model model definition event cursor type
+	Model CursorType = "model"
+	// ModelAttribute model attribute event cursor type
+	ModelAttribute CursorType = "model_attribute"
+	// ModelAttributeGroup model attribute group event cursor type
+	ModelAttributeGroup CursorType = "model_attribute_group"
+	// ModelUnique model unique rule event cursor type
+	ModelUnique CursorType = "model_unique"
+	// FieldTemplate field template event cursor type
+	FieldTemplate CursorType = "field_template"
+	// template and service instance related cursor types
+	// ServiceTemplate service template event cursor type
+	ServiceTemplate CursorType = "service_template"
+	// SetTemplate set template event cursor type
+	SetTemplate CursorType = "set_template"
+	// ServiceInstance service instance event cursor type
+	ServiceInstance CursorType = "service_instance"
+	// DynamicGroup dynamic group event cursor type
+	DynamicGroup CursorType = "dynamic_group"
 	// kube related cursor types
 	// KubeCluster cursor type
 	KubeCluster CursorType = "kube_cluster"
@@ -162,7 +195,8 @@ func (ct *CursorType) ParseInt(typ int) {
 func ListCursorTypes() []CursorType {
 	return []CursorType{Host, ModuleHostRelation, Biz, Set, Module, ObjectBase, Process, ProcessInstanceRelation,
 		HostIdentifier, MainlineInstance, InstAsst, BizSet, BizSetRelation, Plat, KubeCluster, KubeNode, KubeNamespace,
-		KubeWorkload, KubePod, Project}
+		KubeWorkload, KubePod, Project, DynamicGroupMembership, Model, ModelAttribute, ModelAttributeGroup, ModelUnique,
+		ServiceTemplate, SetTemplate, ServiceInstance, FieldTemplate, DynamicGroup}
 }
 
 // Cursor is a self-defined token which is corresponding to the mongodb's resume token.
@@ -345,6 +379,15 @@ var collEventCursorTypeMap = map[string]CursorType{
 	kubetypes.BKTableNameBaseWorkload:         KubeWorkload,
 	kubetypes.BKTableNameBasePod:              KubePod,
 	common.BKTableNameBaseProject:             Project,
+	common.BKTableNameObjDes:                  Model,
+	common.BKTableNameObjAttDes:               ModelAttribute,
+	common.BKTableNamePropertyGroup:           ModelAttributeGroup,
+	common.BKTableNameObjUnique:               ModelUnique,
+	common.BKTableNameServiceTemplate:         ServiceTemplate,
+	common.BKTableNameSetTemplate:             SetTemplate,
+	common.BKTableNameServiceInstance:         ServiceInstance,
+	common.BKTableNameFieldTemplate:           FieldTemplate,
+	common.BKTableNameDynamicGroup:            DynamicGroup,
 }
 
 // GetEventCursor get event cursor.
@@ -0,0 +1,319 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// SubscriptionIDField is the id field of the webhook subscription
+	SubscriptionIDField = "bk_subscription_id"
+	// SubscriptionNameField is the name field of the webhook subscription
+	SubscriptionNameField = "bk_subscription_name"
+	// SubscriptionResourcesField is the subscribed resources field of the webhook subscription
+	SubscriptionResourcesField = "bk_resources"
+	// SubscriptionEnabledField is the enabled field of the webhook subscription
+	SubscriptionEnabledField = "bk_enabled"
+	// SubscriptionRevisionField is the revision field of the webhook subscription, it is increased when the
+	// subscription is updated or replayed, so that the running delivery worker can be restarted.
+	SubscriptionRevisionField = "bk_revision"
+	// SubscriptionCursorsField is the field that stores the delivered cursor of each resource of the subscription
+	SubscriptionCursorsField = "bk_cursors"
+	// DeadLetterIDField is the id field of the webhook dead letter
+	DeadLetterIDField = "bk_dead_letter_id"
+
+	// SignatureHeader is the http header of the hmac-sha256 signature of the delivered batch
+	SignatureHeader = "X-Bkcmdb-Signature"
+	// TimestampHeader is the http header of the unix timestamp when the batch is delivered
+	TimestampHeader = "X-Bkcmdb-Timestamp"
+	// SubscriptionHeader is the http header of the subscription id of the delivered batch
+	SubscriptionHeader = "X-Bkcmdb-Subscription"
+
+	// DefaultSubscriptionBatchSize is the default max number of events delivered in one batch
+	DefaultSubscriptionBatchSize = 50
+	// MaxSubscriptionBatchSize is the max number of events that can be delivered in one batch
+	MaxSubscriptionBatchSize = 200
+)
+
+// Subscription is a server side subscription of the watch events, event server watches the events of the
+// subscribed resources and delivers them to the callback url in batches.
+type Subscription struct {
+	ID          int64        `json:"bk_subscription_id" bson:"bk_subscription_id"`
+	Name        string       `json:"bk_subscription_name" bson:"bk_subscription_name"`
+	CallbackURL string       `json:"bk_callback_url" bson:"bk_callback_url"`
+	Secret      string       `json:"bk_secret,omitempty" bson:"bk_secret"`
+	Resources   []CursorType `json:"bk_resources" bson:"bk_resources"`
+	// EventTypes event types to be delivered, empty means all.
+	EventTypes []EventType `json:"bk_event_types" bson:"bk_event_types"`
+	// Filter is an optional expression that is matched against the event detail,
+	// only the matched events are delivered.
+	Filter    *filter.Expression `json:"bk_filter,omitempty" bson:"bk_filter,omitempty"`
+	BatchSize int                `json:"bk_batch_size" bson:"bk_batch_size"`
+	Enabled   bool               `json:"bk_enabled" bson:"bk_enabled"`
+	Revision  int64              `json:"bk_revision" bson:"bk_revision"`
+	// Cursors is the cursor of the last delivered event of each resource.
+	Cursors    map[CursorType]string `json:"bk_cursors" bson:"bk_cursors"`
+	OwnerID    string                `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator    string                `json:"creator" bson:"creator"`
+	Modifier   string                `json:"modifier" bson:"modifier"`
+	CreateTime time.Time             `json:"create_time" bson:"create_time"`
+	LastTime   time.Time             `json:"last_time" bson:"last_time"`
+}
+
+// Validate validates the subscription to be created.
+func (s *Subscription) Validate() error {
+	if len(s.Name) == 0 {
+		return errors.New("bk_subscription_name is not set")
+	}
+
+	if err := validateCallbackURL(s.CallbackURL); err != nil {
+		return err
+	}
+
+	if err := validateSubscribedResources(s.Resources); err != nil {
+		return err
+	}
+
+	for _, eventType := range s.EventTypes {
+		if err := eventType.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateSubscriptionFilter(s.Filter); err != nil {
+		return err
+	}
+
+	if s.BatchSize < 0 || s.BatchSize > MaxSubscriptionBatchSize {
+		return fmt.Errorf("bk_batch_size must be in range [0, %d]", MaxSubscriptionBatchSize)
+	}
+
+	return nil
+}
+
+// UpdateSubscriptionOption is the option to update a subscription, only the set fields are updated.
+type UpdateSubscriptionOption struct {
+	Name        *string            `json:"bk_subscription_name"`
+	CallbackURL *string            `json:"bk_callback_url"`
+	Secret      *string            `json:"bk_secret"`
+	Resources   []CursorType       `json:"bk_resources"`
+	EventTypes  []EventType        `json:"bk_event_types"`
+	Filter      *filter.Expression `json:"bk_filter"`
+	BatchSize   *int               `json:"bk_batch_size"`
+	Enabled     *bool              `json:"bk_enabled"`
+}
+
+// Validate validates the update subscription option.
+func (u *UpdateSubscriptionOption) Validate() error {
+	if u.Name != nil && len(*u.Name) == 0 {
+		return errors.New("bk_subscription_name can not be empty")
+	}
+
+	if u.CallbackURL != nil {
+		if err := validateCallbackURL(*u.CallbackURL); err != nil {
+			return err
+		}
+	}
+
+	if u.Resources != nil {
+		if err := validateSubscribedResources(u.Resources); err != nil {
+			return err
+		}
+	}
+
+	for _, eventType := range u.EventTypes {
+		if err := eventType.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateSubscriptionFilter(u.Filter); err != nil {
+		return err
+	}
+
+	if u.BatchSize != nil && (*u.BatchSize < 0 || *u.BatchSize > MaxSubscriptionBatchSize) {
+		return fmt.Errorf("bk_batch_size must be in range [0, %d]", MaxSubscriptionBatchSize)
+	}
+
+	return nil
+}
+
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("bk_callback_url is invalid, err: %v", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.New("bk_callback_url must be an absolute http or https url")
+	}
+	return nil
+}
+
+func validateSubscribedResources(resources []CursorType) error {
+	if len(resources) == 0 {
+		return errors.New("bk_resources is not set")
+	}
+
+	supported := make(map[CursorType]struct{})
+	for _, typ := range ListCursorTypes() {
+		supported[typ] = struct{}{}
+	}
+
+	for _, resource := range resources {
+		if _, exists := supported[resource]; !exists {
+			return fmt.Errorf("unsupported resource %s", resource)
+		}
+	}
+	return nil
+}
+
+func validateSubscriptionFilter(expr *filter.Expression) error {
+	if expr == nil {
+		return nil
+	}
+
+	opt := filter.NewDefaultExprOpt(nil)
+	opt.IgnoreRuleFields = true
+	if err := expr.Validate(opt); err != nil {
+		return fmt.Errorf("bk_filter is invalid, err: %v", err)
+	}
+	return nil
+}
+
+// ListSubscriptionOption is the option to list subscriptions.
+type ListSubscriptionOption struct {
+	IDs  []int64           `json:"bk_subscription_ids"`
+	Page metadata.BasePage `json:"page"`
+}
+
+// Validate validates the list subscription option.
+func (l *ListSubscriptionOption) Validate() error {
+	if _, err := l.Page.Validate(false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListSubscriptionResult is the result of list subscriptions.
+type ListSubscriptionResult struct {
+	Count int64           `json:"count"`
+	Info  []*Subscription `json:"info"`
+}
+
+// DeadLetter is a batch of events that failed to be delivered after all the retries.
+type DeadLetter struct {
+	ID             int64      `json:"bk_dead_letter_id" bson:"bk_dead_letter_id"`
+	SubscriptionID int64      `json:"bk_subscription_id" bson:"bk_subscription_id"`
+	Resource       CursorType `json:"bk_resource" bson:"bk_resource"`
+	// StartCursor is the cursor that the events are watched from, replay from it to deliver these events again.
+	StartCursor string `json:"bk_start_cursor" bson:"bk_start_cursor"`
+	// Cursor is the cursor of the last event in this batch.
+	Cursor string `json:"bk_cursor" bson:"bk_cursor"`
+	// Payload is the request body of the failed delivery.
+	Payload    string    `json:"bk_payload" bson:"bk_payload"`
+	Error      string    `json:"bk_error" bson:"bk_error"`
+	Attempts   int       `json:"bk_attempts" bson:"bk_attempts"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+}
+
+// ListDeadLetterOption is the option to list dead letters of a subscription.
+type ListDeadLetterOption struct {
+	SubscriptionID int64             `json:"bk_subscription_id"`
+	Resource       CursorType        `json:"bk_resource"`
+	Page           metadata.BasePage `json:"page"`
+}
+
+// Validate validates the list dead letter option.
+func (l *ListDeadLetterOption) Validate() error {
+	if l.SubscriptionID <= 0 {
+		return errors.New("bk_subscription_id is invalid")
+	}
+
+	if _, err := l.Page.Validate(false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListDeadLetterResult is the result of list dead letters.
+type ListDeadLetterResult struct {
+	Count int64         `json:"count"`
+	Info  []*DeadLetter `json:"info"`
+}
+
+// ReplaySubscriptionOption is the option to replay the events of a subscription from a cursor,
+// use either the cursor of the resource or the dead letter whose events needs to be delivered again.
+type ReplaySubscriptionOption struct {
+	Resource     CursorType `json:"bk_resource"`
+	Cursor       string     `json:"bk_cursor"`
+	DeadLetterID int64      `json:"bk_dead_letter_id"`
+}
+
+// Validate validates the replay subscription option.
+func (r *ReplaySubscriptionOption) Validate() error {
+	if r.DeadLetterID != 0 {
+		if len(r.Resource) != 0 || len(r.Cursor) != 0 {
+			return errors.New("bk_dead_letter_id and bk_resource/bk_cursor can not use at the same time")
+		}
+		return nil
+	}
+
+	if len(r.Resource) == 0 || len(r.Cursor) == 0 {
+		return errors.New("bk_resource and bk_cursor must be set when bk_dead_letter_id is not set")
+	}
+
+	cursor := new(Cursor)
+	if err := cursor.Decode(r.Cursor); err != nil {
+		return fmt.Errorf("bk_cursor is invalid, err: %v", err)
+	}
+
+	if cursor.Type != r.Resource {
+		return fmt.Errorf("bk_cursor is not a cursor of resource %s", r.Resource)
+	}
+	return nil
+}
+
+// DeliveryBatch is the request body of a delivery to the subscription's callback url.
+type DeliveryBatch struct {
+	SubscriptionID int64               `json:"bk_subscription_id"`
+	Resource       CursorType          `json:"bk_resource"`
+	Events         []*WatchEventDetail `json:"bk_events"`
+}
+
+// SignPayload returns the hex encoded hmac-sha256 signature of the delivered payload, the signed content is
+// the timestamp header value and the request body joined with a dot.
+func SignPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyPayload checks if the signature of the delivered payload is valid.
+func VerifyPayload(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(SignPayload(secret, timestamp, payload)), []byte(signature))
+}
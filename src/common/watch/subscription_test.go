@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPayload(t *testing.T) {
+	payload := []byte(`{"bk_subscription_id":1,"bk_resource":"host","bk_events":[]}`)
+	signature := SignPayload("secret", 1588853652, payload)
+
+	require.Equal(t, "sha256=", signature[:7])
+	require.True(t, VerifyPayload("secret", 1588853652, payload, signature))
+	require.False(t, VerifyPayload("wrong", 1588853652, payload, signature))
+	require.False(t, VerifyPayload("secret", 1588853653, payload, signature))
+	require.False(t, VerifyPayload("secret", 1588853652, append(payload, ' '), signature))
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	sub := new(Subscription)
+	err := json.Unmarshal([]byte(`{"bk_subscription_name":"test","bk_callback_url":"http://127.0.0.1/echo",
+		"bk_resources":["host","biz"],"bk_event_types":["create"],"bk_filter":{"condition":"AND","rules":[
+		{"field":"bk_host_innerip","operator":"equal","value":"127.0.0.1"}]}}`), sub)
+	require.NoError(t, err)
+	require.NoError(t, sub.Validate())
+
+	sub.CallbackURL = "/echo"
+	require.Error(t, sub.Validate())
+
+	sub.CallbackURL = "https://127.0.0.1/echo"
+	sub.Resources = []CursorType{NoEvent}
+	require.Error(t, sub.Validate())
+
+	sub.Resources = []CursorType{Host}
+	sub.EventTypes = []EventType{Unknown}
+	require.Error(t, sub.Validate())
+
+	sub.EventTypes = nil
+	sub.BatchSize = MaxSubscriptionBatchSize + 1
+	require.Error(t, sub.Validate())
+}
+
+func TestReplaySubscriptionOptionValidate(t *testing.T) {
+	require.NoError(t, (&ReplaySubscriptionOption{DeadLetterID: 1}).Validate())
+	require.NoError(t, (&ReplaySubscriptionOption{Resource: Host, Cursor: cursorSample}).Validate())
+
+	require.Error(t, (&ReplaySubscriptionOption{DeadLetterID: 1, Resource: Host}).Validate())
+	require.Error(t, (&ReplaySubscriptionOption{Resource: Host}).Validate())
+	require.Error(t, (&ReplaySubscriptionOption{Resource: Biz, Cursor: cursorSample}).Validate())
+	require.Error(t, (&ReplaySubscriptionOption{Resource: Host, Cursor: "invalid"}).Validate())
+}
@@ -66,6 +66,10 @@ type Config struct {
 	SyncIAMPeriodMinutes int
 	// 通过何种方式调用gse接口注册dataid
 	DataIdMigrateWay MigrateWay
+	// AuditChainSignKey is the key that the audit log hash chain checkpoints are signed with by the core service
+	AuditChainSignKey string
+	// FullTextSearchIndex is whether the fulltext search uses the mongodb backend that needs the text indexes
+	FullTextSearchIndex bool
 }
 
 // MigrateWay 通过何种方式调用gse接口注册dataid
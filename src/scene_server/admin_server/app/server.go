@@ -33,6 +33,7 @@ import (
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/thirdparty/elasticsearch"
 	"configcenter/src/thirdparty/monitor"
 )
 
@@ -162,6 +163,11 @@ func parseSeverConfig(ctx context.Context, op *options.ServerOption) (*MigrateSe
 	}
 
 	process.Config.SnapReportMode, _ = cc.String("datacollection.hostsnap.reportMode")
+	process.Config.AuditChainSignKey, _ = cc.String("coreService.auditLog.chain.signKey")
+	fullTextSearch, _ := cc.String("es.fullTextSearch")
+	fullTextBackend, _ := cc.String("es.backend")
+	process.Config.FullTextSearchIndex = elasticsearch.EsConfig{FullTextSearch: fullTextSearch,
+		Backend: fullTextBackend}.UseMongoDB()
 	process.Config.SnapKafka, _ = cc.Kafka("kafka.snap")
 
 	if err := monitor.InitMonitor(); err != nil {
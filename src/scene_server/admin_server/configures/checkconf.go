@@ -155,7 +155,13 @@ func (cc *ConfCenter) isEsConfigOK(v *viper.Viper, fileName string) error {
 			blog.Errorf("The configuration file is %s, the es.fullTextSearch should be on or off !", fileName)
 			return fmt.Errorf("The configuration file is %s, the es.fullTextSearch should be on or off !", fileName)
 		}
-		if fullTextSearch == "on" {
+		backend := v.GetString("es.backend")
+		if backend != "" && backend != "elasticsearch" && backend != "mongodb" {
+			blog.Errorf("The configuration file is %s, the es.backend should be elasticsearch or mongodb !", fileName)
+			return fmt.Errorf("The configuration file is %s, the es.backend should be elasticsearch or mongodb !",
+				fileName)
+		}
+		if fullTextSearch == "on" && backend != "mongodb" {
 			if err := cc.isConfigEmpty("es.url", fileName, v); err != nil {
 				return err
 			}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	iamcli "configcenter/src/ac/iam"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
@@ -96,7 +97,7 @@ func newKit() *rest.Kit {
 
 // SyncIAM sync the system instances resource between CMDB and IAM
 func (s *syncor) SyncIAM(iamCli *iamcli.IAM, redisCli redis.Client, lgc *logics.Logics) {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return
 	}
 	time.Sleep(time.Minute)
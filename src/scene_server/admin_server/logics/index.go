@@ -43,6 +43,7 @@ import (
 func DBSync(e *backbone.Engine, db dal.RDB, options options.Config) {
 	f := func() {
 		defaultDBTable = db
+		defaultOptions = options
 		fmt.Println(defaultDBTable)
 	}
 	once.Do(f)
@@ -57,6 +58,7 @@ var (
 	once sync.Once
 
 	defaultDBTable dal.RDB
+	defaultOptions options.Config
 )
 
 type dbTable struct {
@@ -156,7 +158,7 @@ func RunSyncDBIndex(ctx context.Context, e *backbone.Engine) error {
 
 	}
 
-	dt := &dbTable{db: defaultDBTable, rid: rid}
+	dt := &dbTable{db: defaultDBTable, rid: rid, options: defaultOptions}
 	blog.Infof("start table common index rid: %s", rid)
 	if err := dt.syncIndexes(ctx); err != nil {
 		blog.Errorf("model table sync error. err: %s, rid: %s", err.Error(), dt.rid)
@@ -187,6 +189,12 @@ func (dt *dbTable) syncDBTableIndexes(ctx context.Context) error {
 		return err
 	}
 
+	if dt.options.FullTextSearchIndex {
+		for _, tableName := range index.FullTextSearchTables() {
+			dtIndexesMap[tableName] = append(dtIndexesMap[tableName], index.FullTextSearchIndex())
+		}
+	}
+
 	for tableName, indexes := range tableIndexes {
 		blog.Infof("start sync table(%s) index, rid: %s", tableName, dt.rid)
 		deprecatedTableIndexNames := deprecatedIndexNames[tableName]
@@ -334,6 +342,9 @@ func (dt *dbTable) findSyncIndexesLogicUnique(ctx context.Context) (map[string][
 			if count > 0 {
 				tbIndexes[instTable] = append(index.TableInstanceIndexes(), uniques...)
 			}
+			if dt.options.FullTextSearchIndex {
+				tbIndexes[instTable] = append(tbIndexes[instTable], index.FullTextSearchIndex())
+			}
 
 		} else {
 			tb := ""
@@ -446,6 +457,9 @@ func (dt *dbTable) syncModelShardingTable(ctx context.Context) error {
 		if count > 0 {
 			objIndexes = append(index.TableInstanceIndexes(), uniques...)
 		}
+		if dt.options.FullTextSearchIndex {
+			objIndexes = append(objIndexes, index.FullTextSearchIndex())
+		}
 
 		dt.createTable(ctx, obj, modelDBTableNameMap, instTable, objIndexes)
 
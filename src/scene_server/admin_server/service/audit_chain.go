@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditchain"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// VerifyAuditChain walks the audit log hash chain of the supplier account, and reports the first broken link or
+// missing range, the supplier account in the header is used if it is not specified
+func (s *Service) VerifyAuditChain(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := httpheader.GetRid(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(httpheader.GetLanguage(rHeader))
+
+	opt := new(metadata.VerifyAuditChainOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(opt); err != nil {
+		blog.Errorf("decode verify audit chain option failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest,
+			&metadata.RespError{Msg: defErr.CCError(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if opt.SupplierAccount == "" {
+		opt.SupplierAccount = httpheader.GetSupplierAccount(rHeader)
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: rawErr.ToCCError(defErr)})
+		return
+	}
+
+	ctx := context.WithValue(s.ctx, common.ContextRequestIDField, rid)
+	result, err := auditchain.Verify(ctx, s.db, s.Config.AuditChainSignKey, opt)
+	if err != nil {
+		blog.Errorf("verify audit log hash chain failed, err: %v, opt: %+v, rid: %s", err, opt, rid)
+		_ = resp.WriteError(http.StatusInternalServerError,
+			&metadata.RespError{Msg: defErr.CCError(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	if !result.Intact {
+		blog.Warnf("audit log hash chain is not intact, result: %+v, rid: %s", result, rid)
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -17,6 +17,7 @@ import (
 	"net/http"
 
 	aciam "configcenter/src/ac/iam"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
@@ -29,7 +30,7 @@ import (
 
 // InitAuthCenter init auth resources on IAM
 func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
 	}
@@ -95,7 +96,7 @@ func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
 */
 // RegisterAuthAccount register auth account to iam
 func (s *Service) RegisterAuthAccount(req *restful.Request, resp *restful.Response) {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
 		return
 	}
@@ -146,7 +146,8 @@ func (s *Service) createWatchIndexes(cursorType watch.CursorType, key event.Key,
 			ExpireAfterSeconds: dbChainTTLTime},
 	}
 
-	if cursorType == watch.ObjectBase || cursorType == watch.MainlineInstance || cursorType == watch.InstAsst {
+	if cursorType == watch.ObjectBase || cursorType == watch.MainlineInstance || cursorType == watch.InstAsst ||
+		cursorType == watch.DynamicGroupMembership {
 		subResourceIndex := daltypes.Index{
 			Name: "index_sub_resource", Keys: bson.D{{common.BKSubResourceField, 1}}, Background: true,
 		}
@@ -225,6 +226,25 @@ func (s *Service) createWatchToken(key event.Key) error {
 		return nil
 	}
 
+	if key.Collection() == event.DynamicGroupMembershipKey.Collection() {
+		// dynamic group membership's watch token is generated in the same way with the biz set relation's watch token
+		data := mapstr.MapStr{
+			"_id":                              key.Collection(),
+			common.BKTableNameBaseHost:         watch.LastChainNodeData{Coll: common.BKTableNameBaseHost},
+			common.BKTableNameModuleHostConfig: watch.LastChainNodeData{Coll: common.BKTableNameModuleHostConfig},
+			common.BKTableNameBaseSet:          watch.LastChainNodeData{Coll: common.BKTableNameBaseSet},
+			common.BKTableNameBaseModule:       watch.LastChainNodeData{Coll: common.BKTableNameBaseModule},
+			common.BKTableNameDynamicGroup:     watch.LastChainNodeData{Coll: common.BKTableNameDynamicGroup},
+			common.BKFieldID:                   0,
+			common.BKTokenField:                "",
+		}
+		if err = s.watchDB.Table(common.BKTableNameWatchToken).Insert(s.ctx, data); err != nil {
+			blog.Errorf("init last dynamic group membership watch token failed, err: %v, data: %+v", err, data)
+			return err
+		}
+		return nil
+	}
+
 	data := watch.LastChainNodeData{
 		Coll:  key.Collection(),
 		Token: "",
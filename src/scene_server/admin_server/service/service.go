@@ -116,6 +116,7 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/migrate/dataid").To(s.migrateDataID))
 	api.Route(api.POST("/migrate/old/dataid").To(s.migrateOldDataID))
 	api.Route(api.POST("/delete/auditlog").To(s.DeleteAuditLog))
+	api.Route(api.POST("/find/auditlog/chain/verify").To(s.VerifyAuditChain))
 	api.Route(api.POST("/migrate/sync/db/index").To(s.RunSyncDBIndex))
 	api.Route(api.GET("/healthz").To(s.Healthz))
 	api.Route(api.GET("/monitor_healthz").To(s.MonitorHealth))
@@ -19,6 +19,7 @@ import (
 	"strconv"
 
 	iamtype "configcenter/src/ac/iam"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
@@ -35,7 +36,7 @@ import (
 // migrateIAMSysInstances migrate iam system instances
 func migrateIAMSysInstances(ctx context.Context, db dal.RDB, cache redis.Client, iam *iamtype.IAM,
 	conf *upgrader.Config) error {
-	if !auth.EnableAuthorize() {
+	if !auth.EnableAuthorize() || rbac.Enabled() {
 		return nil
 	}
 
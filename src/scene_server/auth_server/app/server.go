@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"configcenter/src/ac/iam"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
@@ -61,6 +62,15 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 			continue
 		}
 
+		lgc := logics.NewLogics(engine.CoreAPI)
+
+		// authorize with the built-in rbac, iam client and authorizer are not needed
+		if rbac.Enabled() {
+			blog.Infof("auth mode is rbac, authorize with the built-in rbac")
+			authServer.Service = service.NewAuthService(engine, nil, lgc, nil)
+			break
+		}
+
 		authConf := authServer.Config.Auth
 		iamConf := sdktypes.IamConfig{
 			Address:   authConf.Address,
@@ -79,7 +89,6 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 			return err
 		}
 
-		lgc := logics.NewLogics(engine.CoreAPI)
 		authConfig := sdktypes.Config{
 			Iam:     iamConf,
 			Options: opt,
@@ -0,0 +1,254 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/ac/rbac"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+)
+
+// GetRBACPolicy get the rbac policy of the user, including all the roles that are bound to the user directly or by
+// the user groups that the user belongs to
+func (lgc *Logics) GetRBACPolicy(kit *rest.Kit, user string) (*rbac.Policy, error) {
+	groups := make([]string, 0)
+	groupOpt := &metadata.ListRBACUserGroupOption{
+		Members: []string{user},
+		Page:    metadata.BasePage{Limit: common.BKMaxPageSize},
+	}
+	for {
+		res, err := lgc.CoreAPI.CoreService().Auth().ListRBACUserGroups(kit.Ctx, kit.Header, groupOpt)
+		if err != nil {
+			blog.Errorf("list rbac user groups failed, err: %v, user: %s, rid: %s", err, user, kit.Rid)
+			return nil, err
+		}
+
+		for _, group := range res.Info {
+			groups = append(groups, group.Name)
+		}
+
+		if len(res.Info) < common.BKMaxPageSize {
+			break
+		}
+		groupOpt.Page.Start += common.BKMaxPageSize
+	}
+
+	bindings := make([]metadata.RBACRoleBinding, 0)
+	roleIDs := make([]int64, 0)
+	bindingOpt := &metadata.ListRBACRoleBindingOption{
+		Users:  []string{user},
+		Groups: groups,
+		Page:   metadata.BasePage{Limit: common.BKMaxPageSize},
+	}
+	for {
+		res, err := lgc.CoreAPI.CoreService().Auth().ListRBACRoleBindings(kit.Ctx, kit.Header, bindingOpt)
+		if err != nil {
+			blog.Errorf("list rbac role bindings failed, err: %v, user: %s, rid: %s", err, user, kit.Rid)
+			return nil, err
+		}
+
+		for _, binding := range res.Info {
+			bindings = append(bindings, binding)
+			roleIDs = append(roleIDs, binding.RoleID)
+		}
+
+		if len(res.Info) < common.BKMaxPageSize {
+			break
+		}
+		bindingOpt.Page.Start += common.BKMaxPageSize
+	}
+
+	roles := make([]metadata.RBACRole, 0)
+	roleIDs = util.IntArrayUnique(roleIDs)
+	for start := 0; start < len(roleIDs); start += common.BKMaxPageSize {
+		end := start + common.BKMaxPageSize
+		if end > len(roleIDs) {
+			end = len(roleIDs)
+		}
+
+		roleOpt := &metadata.ListRBACRoleOption{
+			IDs:  roleIDs[start:end],
+			Page: metadata.BasePage{Limit: common.BKMaxPageSize},
+		}
+		res, err := lgc.CoreAPI.CoreService().Auth().ListRBACRoles(kit.Ctx, kit.Header, roleOpt)
+		if err != nil {
+			blog.Errorf("list rbac roles failed, err: %v, ids: %v, rid: %s", err, roleOpt.IDs, kit.Rid)
+			return nil, err
+		}
+		roles = append(roles, res.Info...)
+	}
+
+	admin := util.InStrArr(rbac.Admins(), user)
+	return rbac.NewPolicy(user, admin, groups, roles, bindings), nil
+}
+
+// SetRBACClassificationLayers set the model classification layer of the models and the model related resources, so
+// that the role bindings that are scoped on the model classifications can take effect on them
+func (lgc *Logics) SetRBACClassificationLayers(kit *rest.Kit, policy *rbac.Policy,
+	attrs []meta.ResourceAttribute) error {
+
+	if !policy.NeedClassification() {
+		return nil
+	}
+
+	modelIDs := make([]int64, 0)
+	for _, attr := range attrs {
+		if attr.Type == meta.Model && attr.InstanceID > 0 {
+			modelIDs = append(modelIDs, attr.InstanceID)
+		}
+		for _, layer := range attr.Layers {
+			if layer.Type == meta.Model && layer.InstanceID > 0 {
+				modelIDs = append(modelIDs, layer.InstanceID)
+			}
+		}
+	}
+
+	if len(modelIDs) == 0 {
+		return nil
+	}
+
+	modelCond := &metadata.QueryCondition{
+		Fields:    []string{common.BKFieldID, common.BKClassificationIDField},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		Condition: map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: modelIDs}},
+	}
+	models, err := lgc.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, modelCond)
+	if err != nil {
+		blog.Errorf("get models failed, err: %v, ids: %v, rid: %s", err, modelIDs, kit.Rid)
+		return err
+	}
+
+	classificationIDs := make([]string, 0)
+	for _, model := range models.Info {
+		classificationIDs = append(classificationIDs, model.ObjCls)
+	}
+
+	classificationCond := &metadata.QueryCondition{
+		Fields: []string{common.BKFieldID, common.BKClassificationIDField},
+		Page:   metadata.BasePage{Limit: common.BKNoLimit},
+		Condition: map[string]interface{}{
+			common.BKClassificationIDField: map[string]interface{}{common.BKDBIN: classificationIDs},
+		},
+	}
+	classifications, err := lgc.CoreAPI.CoreService().Model().ReadModelClassification(kit.Ctx, kit.Header,
+		classificationCond)
+	if err != nil {
+		blog.Errorf("get model classifications failed, err: %v, ids: %v, rid: %s", err, classificationIDs, kit.Rid)
+		return err
+	}
+
+	classificationMap := make(map[string]int64)
+	for _, classification := range classifications.Info {
+		classificationMap[classification.ClassificationID] = classification.ID
+	}
+
+	modelClassificationMap := make(map[int64]int64)
+	for _, model := range models.Info {
+		modelClassificationMap[model.ID] = classificationMap[model.ObjCls]
+	}
+
+	for idx := range attrs {
+		attr := &attrs[idx]
+		modelID := int64(0)
+		if attr.Type == meta.Model {
+			modelID = attr.InstanceID
+		}
+		for _, layer := range attr.Layers {
+			if layer.Type == meta.Model {
+				modelID = layer.InstanceID
+			}
+		}
+
+		if classificationID := modelClassificationMap[modelID]; classificationID > 0 {
+			attr.Layers = append(attr.Layers, meta.Item{Type: meta.ModelClassification, InstanceID: classificationID})
+		}
+	}
+
+	return nil
+}
+
+// RBACListAuthorizedResources list the resources that the user has the permission with the built-in rbac
+func (lgc *Logics) RBACListAuthorizedResources(kit *rest.Kit, input *meta.ListAuthorizedResourcesParam) (
+	*types.AuthorizeList, error) {
+
+	policy, err := lgc.GetRBACPolicy(kit, input.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	authorized := policy.ListAuthorized(input.ResourceType, input.Action, input.BizID)
+	if authorized.IsAny {
+		return &types.AuthorizeList{IsAny: true, Ids: make([]string, 0)}, nil
+	}
+
+	ids := authorized.IDs
+	if len(authorized.ClassificationIDs) > 0 {
+		modelIDs, err := lgc.getClassificationModelIDs(kit, authorized.ClassificationIDs)
+		if err != nil {
+			return nil, err
+		}
+		ids = util.StrArrayUnique(append(ids, modelIDs...))
+	}
+
+	return &types.AuthorizeList{Ids: ids}, nil
+}
+
+// getClassificationModelIDs get the ids of the models in the model classifications
+func (lgc *Logics) getClassificationModelIDs(kit *rest.Kit, ids []int64) ([]string, error) {
+	classificationCond := &metadata.QueryCondition{
+		Fields:    []string{common.BKClassificationIDField},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		Condition: map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: ids}},
+	}
+	classifications, err := lgc.CoreAPI.CoreService().Model().ReadModelClassification(kit.Ctx, kit.Header,
+		classificationCond)
+	if err != nil {
+		blog.Errorf("get model classifications failed, err: %v, ids: %v, rid: %s", err, ids, kit.Rid)
+		return nil, err
+	}
+
+	classificationIDs := make([]string, 0)
+	for _, classification := range classifications.Info {
+		classificationIDs = append(classificationIDs, classification.ClassificationID)
+	}
+
+	if len(classificationIDs) == 0 {
+		return make([]string, 0), nil
+	}
+
+	modelCond := &metadata.QueryCondition{
+		Fields: []string{common.BKFieldID},
+		Page:   metadata.BasePage{Limit: common.BKNoLimit},
+		Condition: map[string]interface{}{
+			common.BKClassificationIDField: map[string]interface{}{common.BKDBIN: classificationIDs},
+		},
+	}
+	models, err := lgc.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, modelCond)
+	if err != nil {
+		blog.Errorf("get models failed, err: %v, classifications: %v, rid: %s", err, classificationIDs, kit.Rid)
+		return nil, err
+	}
+
+	modelIDs := make([]string, 0)
+	for _, model := range models.Info {
+		modelIDs = append(modelIDs, strconv.FormatInt(model.ID, 10))
+	}
+	return modelIDs, nil
+}
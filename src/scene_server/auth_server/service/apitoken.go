@@ -0,0 +1,145 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// apiTokenPrefixLength is the length of the beginning of the token that is saved for users to recognize the token
+const apiTokenPrefixLength = 12
+
+// CreateAPIToken create personal api token for the current user, the token is only returned in the response
+func (s *AuthService) CreateAPIToken(ctx *rest.Contexts) {
+	opt := new(metadata.CreateAPITokenOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	plainToken, err := metadata.GenerateAPIToken()
+	if err != nil {
+		blog.Errorf("generate api token failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommInternalServerError, err.Error()))
+		return
+	}
+
+	expireTime := metadata.Time{Time: time.Now().AddDate(0, 0, opt.ExpireDays)}
+	token := &metadata.APIToken{
+		Name:        opt.Name,
+		Description: opt.Description,
+		TokenHash:   metadata.HashAPIToken(plainToken),
+		TokenPrefix: plainToken[:apiTokenPrefixLength],
+		Scope:       opt.Scope,
+		ExpireTime:  &expireTime,
+	}
+
+	id, ccErr := s.engine.CoreAPI.CoreService().Auth().CreateAPIToken(ctx.Kit.Ctx, ctx.Kit.Header, token)
+	if ccErr != nil {
+		ctx.RespAutoError(ccErr)
+		return
+	}
+
+	token.ID = id
+	token.TokenHash = ""
+	if ccErr = s.saveAPITokenAudit(ctx.Kit, metadata.AuditCreate, token); ccErr != nil {
+		ctx.RespAutoError(ccErr)
+		return
+	}
+
+	ctx.RespEntity(metadata.CreateAPITokenResult{ID: id, Token: plainToken})
+}
+
+// DeleteAPIToken revoke personal api token of the current user
+func (s *AuthService) DeleteAPIToken(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	opt := &metadata.ListAPITokenOption{IDs: []int64{id}, Page: metadata.BasePage{Limit: 1}}
+	tokens, err := s.engine.CoreAPI.CoreService().Auth().ListAPITokens(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(tokens.Info) == 0 {
+		blog.Errorf("api token %d of user %s is not exist, rid: %s", id, ctx.Kit.User, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommNotFound))
+		return
+	}
+
+	if err = s.engine.CoreAPI.CoreService().Auth().DeleteAPIToken(ctx.Kit.Ctx, ctx.Kit.Header, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err = s.saveAPITokenAudit(ctx.Kit, metadata.AuditDelete, &tokens.Info[0]); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListAPITokens list personal api tokens of the current user
+func (s *AuthService) ListAPITokens(ctx *rest.Contexts) {
+	opt := new(metadata.ListAPITokenOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.engine.CoreAPI.CoreService().Auth().ListAPITokens(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *AuthService) saveAPITokenAudit(kit *rest.Kit, action metadata.ActionType,
+	token *metadata.APIToken) errors.CCErrorCoder {
+
+	audit := metadata.AuditLog{
+		AuditType:       metadata.APITokenType,
+		ResourceType:    metadata.APITokenRes,
+		Action:          action,
+		ResourceID:      token.ID,
+		ResourceName:    token.Name,
+		OperationDetail: &metadata.GenericOpDetail{Data: token},
+	}
+
+	if err := s.engine.CoreAPI.CoreService().Audit().SaveAuditLog(kit.Ctx, kit.Header, audit); err != nil {
+		blog.Errorf("save api token %d audit log failed, err: %v, action: %s, rid: %s", token.ID, err, action,
+			kit.Rid)
+		return err
+	}
+	return nil
+}
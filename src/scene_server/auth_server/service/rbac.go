@@ -0,0 +1,352 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/ac/rbac"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+)
+
+// RBACAuthorizeBatch check if a user has the authority to operate resources with the built-in rbac.
+func (s *AuthService) RBACAuthorizeBatch(ctx *rest.Contexts) {
+	s.rbacAuthorizeBatch(ctx, true)
+}
+
+// RBACAuthorizeAnyBatch check if a user has any authority of the resource types and actions with the built-in rbac.
+func (s *AuthService) RBACAuthorizeAnyBatch(ctx *rest.Contexts) {
+	s.rbacAuthorizeBatch(ctx, false)
+}
+
+func (s *AuthService) rbacAuthorizeBatch(ctx *rest.Contexts, exact bool) {
+	input := new(meta.AuthorizeResourcesParam)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	policy, err := s.lgc.GetRBACPolicy(ctx.Kit, input.UserName)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	decisions := make([]types.Decision, len(input.Resources))
+	if !exact {
+		for idx := range input.Resources {
+			decisions[idx].Authorized = policy.AuthorizeAny(&input.Resources[idx])
+		}
+		ctx.RespEntity(decisions)
+		return
+	}
+
+	if err := s.lgc.SetRBACClassificationLayers(ctx.Kit, policy, input.Resources); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	for idx := range input.Resources {
+		decisions[idx].Authorized = policy.Authorize(&input.Resources[idx])
+	}
+	ctx.RespEntity(decisions)
+}
+
+// RBACListAuthorizedResources returns the resources the user has the authority to operate with the built-in rbac.
+func (s *AuthService) RBACListAuthorizedResources(ctx *rest.Contexts) {
+	input := new(meta.ListAuthorizedResourcesParam)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	authorizeList, err := s.lgc.RBACListAuthorizedResources(ctx.Kit, input)
+	if err != nil {
+		blog.ErrorJSON("list rbac authorized resources failed, err: %s, input: %s, rid: %s", err, input, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(authorizeList)
+}
+
+// RBACGetPermissionToApply get the permissions to apply in the form of rbac resource types and actions
+func (s *AuthService) RBACGetPermissionToApply(ctx *rest.Contexts) {
+	input := make([]meta.ResourceAttribute, 0)
+	if err := ctx.DecodeInto(&input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(rbac.GetPermissionToApply(input))
+}
+
+// RBACExplain explain why a user has or does not have the permission of the resources, returns the roles and bindings
+// that grant the permissions
+func (s *AuthService) RBACExplain(ctx *rest.Contexts) {
+	input := new(metadata.RBACExplainOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	policy, err := s.lgc.GetRBACPolicy(ctx.Kit, input.User)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	attrs := make([]meta.ResourceAttribute, len(input.Resources))
+	for idx, res := range input.Resources {
+		attrs[idx] = rbac.ConvertAuthResource(res, ctx.Kit.SupplierAccount)
+	}
+
+	if err := s.lgc.SetRBACClassificationLayers(ctx.Kit, policy, attrs); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result := &metadata.RBACExplainResult{
+		User:      input.User,
+		Admin:     policy.Admin,
+		Groups:    policy.Groups,
+		Resources: make([]metadata.RBACExplainResource, len(input.Resources)),
+	}
+	for idx, res := range input.Resources {
+		result.Resources[idx] = metadata.RBACExplainResource{
+			AuthResource: res,
+			Passed:       policy.Authorize(&attrs[idx]),
+			Grants:       policy.Explain(&attrs[idx]),
+		}
+	}
+	ctx.RespEntity(result)
+}
+
+func parseRBACID(ctx *rest.Contexts) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateRBACRole create rbac role
+func (s *AuthService) CreateRBACRole(ctx *rest.Contexts) {
+	role := new(metadata.RBACRole)
+	if err := ctx.DecodeInto(role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.engine.CoreAPI.CoreService().Auth().CreateRBACRole(ctx.Kit.Ctx, ctx.Kit.Header, role)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// UpdateRBACRole update rbac role
+func (s *AuthService) UpdateRBACRole(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	role := new(metadata.RBACRole)
+	if err := ctx.DecodeInto(role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	err := s.engine.CoreAPI.CoreService().Auth().UpdateRBACRole(ctx.Kit.Ctx, ctx.Kit.Header, id, role)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DeleteRBACRole delete rbac role
+func (s *AuthService) DeleteRBACRole(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.engine.CoreAPI.CoreService().Auth().DeleteRBACRole(ctx.Kit.Ctx, ctx.Kit.Header, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListRBACRoles list rbac roles
+func (s *AuthService) ListRBACRoles(ctx *rest.Contexts) {
+	opt := new(metadata.ListRBACRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.engine.CoreAPI.CoreService().Auth().ListRBACRoles(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// CreateRBACRoleBinding create rbac role binding
+func (s *AuthService) CreateRBACRoleBinding(ctx *rest.Contexts) {
+	binding := new(metadata.RBACRoleBinding)
+	if err := ctx.DecodeInto(binding); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.engine.CoreAPI.CoreService().Auth().CreateRBACRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, binding)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// UpdateRBACRoleBinding update rbac role binding
+func (s *AuthService) UpdateRBACRoleBinding(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	binding := new(metadata.RBACRoleBinding)
+	if err := ctx.DecodeInto(binding); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	err := s.engine.CoreAPI.CoreService().Auth().UpdateRBACRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, id, binding)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DeleteRBACRoleBinding delete rbac role binding
+func (s *AuthService) DeleteRBACRoleBinding(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.engine.CoreAPI.CoreService().Auth().DeleteRBACRoleBinding(ctx.Kit.Ctx, ctx.Kit.Header, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListRBACRoleBindings list rbac role bindings
+func (s *AuthService) ListRBACRoleBindings(ctx *rest.Contexts) {
+	opt := new(metadata.ListRBACRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.engine.CoreAPI.CoreService().Auth().ListRBACRoleBindings(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// CreateRBACUserGroup create rbac user group
+func (s *AuthService) CreateRBACUserGroup(ctx *rest.Contexts) {
+	group := new(metadata.RBACUserGroup)
+	if err := ctx.DecodeInto(group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.engine.CoreAPI.CoreService().Auth().CreateRBACUserGroup(ctx.Kit.Ctx, ctx.Kit.Header, group)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// UpdateRBACUserGroup update rbac user group
+func (s *AuthService) UpdateRBACUserGroup(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	group := new(metadata.RBACUserGroup)
+	if err := ctx.DecodeInto(group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	err := s.engine.CoreAPI.CoreService().Auth().UpdateRBACUserGroup(ctx.Kit.Ctx, ctx.Kit.Header, id, group)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DeleteRBACUserGroup delete rbac user group
+func (s *AuthService) DeleteRBACUserGroup(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.engine.CoreAPI.CoreService().Auth().DeleteRBACUserGroup(ctx.Kit.Ctx, ctx.Kit.Header, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListRBACUserGroups list rbac user groups
+func (s *AuthService) ListRBACUserGroups(ctx *rest.Contexts) {
+	opt := new(metadata.ListRBACUserGroupOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.engine.CoreAPI.CoreService().Auth().ListRBACUserGroups(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"configcenter/src/ac/iam"
+	"configcenter/src/ac/rbac"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
@@ -156,25 +157,33 @@ func setSupplierID(req *http.Request) {
 
 // WebService TODO
 func (s *AuthService) WebService() *restful.Container {
-	api := new(restful.WebService)
-	api.Path("/auth/v3")
-	api.Filter(s.engine.Metric().RestfulMiddleWare)
-	// only allows iam to pull resource using these api
-	api.Filter(s.checkRequestFromIamFilter())
-	api.Produces(restful.MIME_JSON)
-
-	s.initResourcePull(api)
-
 	container := restful.NewContainer()
 
 	opentelemetry.AddOtlpFilter(container)
 
-	container.Add(api)
-
 	authAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	authAPI.Path("/ac/v3")
-	s.initAuth(authAPI)
-	container.Add(authAPI)
+
+	// authorize with the built-in rbac, iam is not used, so the resource pull api for iam is not needed
+	if rbac.Enabled() {
+		s.initRBAC(authAPI)
+		s.initAPIToken(authAPI)
+		container.Add(authAPI)
+	} else {
+		api := new(restful.WebService)
+		api.Path("/auth/v3")
+		api.Filter(s.engine.Metric().RestfulMiddleWare)
+		// only allows iam to pull resource using these api
+		api.Filter(s.checkRequestFromIamFilter())
+		api.Produces(restful.MIME_JSON)
+
+		s.initResourcePull(api)
+		container.Add(api)
+
+		s.initAuth(authAPI)
+		s.initAPIToken(authAPI)
+		container.Add(authAPI)
+	}
 
 	// common api
 	commonAPI := new(restful.WebService).Produces(restful.MIME_JSON)
@@ -185,6 +194,20 @@ func (s *AuthService) WebService() *restful.Container {
 	return container
 }
 
+// initAPIToken personal api token management apis, users can only manage their own tokens
+func (s *AuthService) initAPIToken(api *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.engine.CCErr,
+		Language: s.engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/api_token", Handler: s.CreateAPIToken})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/api_token/{id}", Handler: s.DeleteAPIToken})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/api_token", Handler: s.ListAPITokens})
+
+	utility.AddToRestfulWebService(api)
+}
+
 func (s *AuthService) initResourcePull(api *restful.WebService) {
 	utility := rest.NewRestUtility(rest.Config{
 		ErrorIf:  s.engine.CCErr,
@@ -216,3 +239,45 @@ func (s *AuthService) initAuth(api *restful.WebService) {
 
 	utility.AddToRestfulWebService(api)
 }
+
+func (s *AuthService) initRBAC(api *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.engine.CCErr,
+		Language: s.engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/rbac/authorize/batch",
+		Handler: s.RBACAuthorizeBatch})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/rbac/authorize/any/batch",
+		Handler: s.RBACAuthorizeAnyBatch})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/rbac/findmany/authorized_resource",
+		Handler: s.RBACListAuthorizedResources})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/permission_to_apply",
+		Handler: s.RBACGetPermissionToApply})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/rbac/explain", Handler: s.RBACExplain})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/rbac/role", Handler: s.CreateRBACRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/rbac/role/{id}", Handler: s.UpdateRBACRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/rbac/role/{id}", Handler: s.DeleteRBACRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/rbac/role", Handler: s.ListRBACRoles})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/rbac/role_binding",
+		Handler: s.CreateRBACRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/rbac/role_binding/{id}",
+		Handler: s.UpdateRBACRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/rbac/role_binding/{id}",
+		Handler: s.DeleteRBACRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/rbac/role_binding",
+		Handler: s.ListRBACRoleBindings})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/rbac/user_group",
+		Handler: s.CreateRBACUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/rbac/user_group/{id}",
+		Handler: s.UpdateRBACUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/rbac/user_group/{id}",
+		Handler: s.DeleteRBACUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/rbac/user_group",
+		Handler: s.ListRBACUserGroups})
+
+	utility.AddToRestfulWebService(api)
+}
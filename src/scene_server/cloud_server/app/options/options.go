@@ -51,4 +51,6 @@ type Config struct {
 	SecretsEnv     string
 	// sync period of cloud sync task, unit is second
 	SyncPeriodMinutes int
+	// percent of hosts to be deleted in one sync which makes the cloud sync task paused, 0 means no limit
+	DeleteThresholdPercent int
 }
@@ -18,7 +18,7 @@ import (
 	"fmt"
 	"time"
 
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
@@ -31,6 +31,7 @@ import (
 	"configcenter/src/scene_server/cloud_server/cloudsync"
 	"configcenter/src/scene_server/cloud_server/logics"
 	svc "configcenter/src/scene_server/cloud_server/service"
+	"configcenter/src/storage/dal/redis"
 	"configcenter/src/thirdparty/secrets"
 )
 
@@ -79,6 +80,17 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return err
 	}
 
+	redisConf, err := engine.WithRedis()
+	if err != nil {
+		blog.Errorf("get redis conf failed, err: %v", err)
+		return err
+	}
+	cacheDB, err := redis.NewFromConfig(redisConf)
+	if err != nil {
+		blog.Errorf("new redis client failed, err: %v", err)
+		return fmt.Errorf("new redis client failed, err: %v", err)
+	}
+
 	blog.Infof("enable auth center: %v", auth.EnableAuthorize())
 
 	accountCryptor, err := getCrypto(op, process)
@@ -87,12 +99,12 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	}
 	process.Service.SetEncryptor(accountCryptor)
 
-	authorizer := iam.NewAuthorizer(engine.CoreAPI)
+	authorizer := ac.NewAuthorizer(engine.CoreAPI)
 	service.SetAuthorizer(authorizer)
 
 	mongoConf := mongoConfig.GetMongoConf()
 
-	process.Service.Logics = logics.NewLogics(service.Engine, accountCryptor, authorizer)
+	process.Service.Logics = logics.NewLogics(service.Engine, accountCryptor, authorizer, cacheDB)
 
 	process.setSyncPeriod()
 	syncConf := cloudsync.SyncConf{
@@ -168,6 +180,7 @@ func (c *CloudServer) onCloudConfigUpdate(previous, current cc.ProcessConfig) {
 	c.Config.SecretsProject, _ = cc.String("cloudServer.cryptor.secretsProject")
 	c.Config.SecretsEnv, _ = cc.String("cloudServer.cryptor.secretsEnv")
 	c.Config.SyncPeriodMinutes, _ = cc.Int("cloudServer.syncTask.syncPeriodMinutes")
+	c.Config.DeleteThresholdPercent, _ = cc.Int("cloudServer.syncTask.deleteThresholdPercent")
 }
 
 // getSecretKey get the secret key from bk-secrets service
@@ -217,4 +230,10 @@ func (c *CloudServer) setSyncPeriod() {
 		cloudsync.SyncPeriodMinutes = cloudsync.SyncPeriodMinutesMin
 	}
 	blog.Infof("sync period is %d minutes", cloudsync.SyncPeriodMinutes)
+
+	cloudsync.DeleteThresholdPercent = c.Config.DeleteThresholdPercent
+	if cloudsync.DeleteThresholdPercent < 0 || cloudsync.DeleteThresholdPercent > 100 {
+		cloudsync.DeleteThresholdPercent = 0
+	}
+	blog.Infof("sync delete threshold is %d percent", cloudsync.DeleteThresholdPercent)
 }
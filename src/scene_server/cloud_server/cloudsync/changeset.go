@@ -0,0 +1,295 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+)
+
+// buildChangeSet 在任务开启了试运行，或者要删除的主机比例超过阈值时，根据有差异的主机生成待审批的变更集，否则返回nil
+func (h *HostSyncor) buildChangeSet(task *metadata.CloudSyncTask, hostResource *metadata.CloudHostResource,
+	diffHosts map[string][]*metadata.CloudHost, localHosts map[string]*metadata.CloudHost) (
+	*metadata.CloudSyncChangeSet, error) {
+
+	if !task.DryRun && DeleteThresholdPercent <= 0 {
+		return nil, nil
+	}
+
+	changeSet := &metadata.CloudSyncChangeSet{
+		TaskID:        task.TaskID,
+		Add:           make([]metadata.CloudHost, 0),
+		Update:        make([]metadata.CloudHostUpdate, 0),
+		Delete:        make([]metadata.CloudHost, 0),
+		DestroyedVpcs: make([]metadata.VpcSyncInfo, 0),
+	}
+
+	for _, host := range diffHosts["add"] {
+		changeSet.Add = append(changeSet.Add, *host)
+	}
+	for _, host := range diffHosts["update"] {
+		changeSet.Update = append(changeSet.Update, newCloudHostUpdate(localHosts[host.InstanceId], host))
+	}
+	for _, host := range diffHosts["delete"] {
+		changeSet.Delete = append(changeSet.Delete, *host)
+	}
+
+	// 被销毁vpc下的主机也会被删除
+	destroyedCloudIDs := make([]int64, 0)
+	for _, vpc := range hostResource.DestroyedVpcs {
+		changeSet.DestroyedVpcs = append(changeSet.DestroyedVpcs, *vpc)
+		destroyedCloudIDs = append(destroyedCloudIDs, vpc.CloudID)
+	}
+	syncedCnt := 0
+	if len(destroyedCloudIDs) > 0 {
+		hosts, err := h.getLocalHosts(destroyedCloudIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			if host.InstanceState == common.BKCloudHostStatusDestroyed {
+				continue
+			}
+			syncedCnt++
+			changeSet.Delete = append(changeSet.Delete, *host)
+		}
+	}
+
+	for _, host := range localHosts {
+		if host.InstanceState != common.BKCloudHostStatusDestroyed {
+			syncedCnt++
+		}
+	}
+	if syncedCnt > 0 {
+		changeSet.DeleteRatio = float64(len(changeSet.Delete)) * 100 / float64(syncedCnt)
+	}
+
+	switch {
+	case task.DryRun:
+		changeSet.Reason = metadata.ChangeSetReasonDryRun
+	case DeleteThresholdPercent > 0 && changeSet.DeleteRatio > float64(DeleteThresholdPercent):
+		blog.Infof("task %d delete ratio %.2f%% exceeds threshold %d%%, pause it, rid: %s", task.TaskID,
+			changeSet.DeleteRatio, DeleteThresholdPercent, h.readKit.Rid)
+		changeSet.Reason = metadata.ChangeSetReasonDeleteThreshold
+	default:
+		return nil, nil
+	}
+
+	// 试运行时没有任何变更，则不需要审批
+	if len(changeSet.Add) == 0 && len(changeSet.Update) == 0 && len(changeSet.Delete) == 0 &&
+		len(changeSet.DestroyedVpcs) == 0 {
+		return nil, nil
+	}
+
+	return changeSet, nil
+}
+
+// newCloudHostUpdate 生成要更新的云主机，记录有差异的字段更新前后的值
+func newCloudHostUpdate(local, remote *metadata.CloudHost) metadata.CloudHostUpdate {
+	update := metadata.CloudHostUpdate{
+		CloudHost: *remote,
+		Before:    make(map[string]interface{}),
+		After:     make(map[string]interface{}),
+	}
+	if local == nil {
+		return update
+	}
+	update.HostID = local.HostID
+
+	if local.CloudID != remote.CloudID {
+		update.Before[common.BKCloudIDField] = local.CloudID
+		update.After[common.BKCloudIDField] = remote.CloudID
+	}
+	if local.PrivateIp != remote.PrivateIp {
+		update.Before[common.BKHostInnerIPField] = local.PrivateIp
+		update.After[common.BKHostInnerIPField] = remote.PrivateIp
+	}
+	if local.PublicIp != remote.PublicIp {
+		update.Before[common.BKHostOuterIPField] = local.PublicIp
+		update.After[common.BKHostOuterIPField] = remote.PublicIp
+	}
+	if local.InstanceState != remote.InstanceState {
+		update.Before[common.BKCloudHostStatusField] = local.InstanceState
+		update.After[common.BKCloudHostStatusField] = remote.InstanceState
+	}
+	return update
+}
+
+// saveChangeSet 保存待审批的变更集，并更新任务状态为待审批，超过删除阈值的任务会被暂停
+func (h *HostSyncor) saveChangeSet(changeSet *metadata.CloudSyncChangeSet) error {
+	if _, err := h.logics.CreateSyncChangeSet(h.writeKit, changeSet); err != nil {
+		return err
+	}
+
+	option := mapstr.MapStr{common.BKCloudSyncStatus: metadata.CloudSyncPendingApproval}
+	if changeSet.Reason == metadata.ChangeSetReasonDeleteThreshold {
+		option.Set(common.BKCloudSyncPaused, true)
+	}
+	if err := h.logics.UpdateSyncTask(h.writeKit, changeSet.TaskID, option); err != nil {
+		blog.Errorf("update task %d to pending approval failed, err: %v, rid: %s", changeSet.TaskID, err,
+			h.readKit.Rid)
+		return err
+	}
+
+	blog.Infof("task %d generate change set, reason: %s, add: %d, update: %d, delete: %d, rid: %s",
+		changeSet.TaskID, changeSet.Reason, len(changeSet.Add), len(changeSet.Update), len(changeSet.Delete),
+		h.readKit.Rid)
+	return nil
+}
+
+// supersedePendingChangeSet 将任务下待审批的变更集标记为已替代
+func (h *HostSyncor) supersedePendingChangeSet(taskID int64) error {
+	option := &metadata.SearchSyncChangeSetOption{
+		TaskID: taskID,
+		Status: metadata.ChangeSetPending,
+		Fields: []string{common.BKCloudChangeSetID},
+	}
+	result, err := h.logics.SearchSyncChangeSet(h.readKit, option)
+	if err != nil {
+		return err
+	}
+
+	for _, changeSet := range result.Info {
+		option := mapstr.MapStr{common.BKStatus: metadata.ChangeSetSuperseded}
+		if err := h.logics.UpdateSyncChangeSet(h.writeKit, changeSet.ChangeSetID, option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyChangeSet 审批并应用待审批的变更集，应用后任务恢复同步
+func (h *HostSyncor) ApplyChangeSet(task *metadata.CloudSyncTask, changeSet *metadata.CloudSyncChangeSet,
+	operator string) error {
+
+	h.readKit = ccom.NewKit()
+	h.writeKit = ccom.NewWriteKit(task.OwnerID)
+	httpheader.SetRid(h.writeKit.Header, httpheader.GetRid(h.readKit.Header))
+
+	// 任务正在同步时不能应用变更集，避免和同步并发修改主机
+	locker, err := h.lockTask(task.TaskID)
+	if err != nil {
+		return err
+	}
+	if locker == nil {
+		blog.Errorf("task %d is being synced, can not apply change set %d, rid: %s", task.TaskID,
+			changeSet.ChangeSetID, h.readKit.Rid)
+		return h.readKit.CCError.CCErrorf(common.CCErrCloudSyncTaskIsSyncing, task.TaskID)
+	}
+	defer locker.Unlock()
+
+	startTime := time.Now()
+	blog.Infof("start apply change set %d of task %d, operator: %s, rid: %s", changeSet.ChangeSetID, task.TaskID,
+		operator, h.readKit.Rid)
+
+	syncResult := new(metadata.SyncResult)
+	syncResult.FailInfo.IPError = make(map[string]string)
+
+	txnErr := h.logics.CoreAPI.CoreService().Txn().AutoRunTxn(h.readKit.Ctx, h.readKit.Header, func() error {
+		return h.applyChangeSet(syncResult, task, changeSet, operator, startTime)
+	})
+
+	ccom.DelHeaderTxnInfo(h.readKit.Header)
+	ccom.DelHeaderTxnInfo(h.writeKit.Header)
+
+	if txnErr != nil {
+		blog.Errorf("apply change set %d failed, taskID: %d, err: %v, rid: %s", changeSet.ChangeSetID, task.TaskID,
+			txnErr, h.readKit.Rid)
+		return txnErr
+	}
+
+	blog.Infof("apply change set %d of task %d success, costTime: %ds, detail: %#v, rid: %s",
+		changeSet.ChangeSetID, task.TaskID, time.Since(startTime)/time.Second, syncResult.Detail, h.readKit.Rid)
+	return nil
+}
+
+func (h *HostSyncor) applyChangeSet(syncResult *metadata.SyncResult, task *metadata.CloudSyncTask,
+	changeSet *metadata.CloudSyncChangeSet, operator string, startTime time.Time) error {
+
+	ccom.CopyHeaderTxnInfo(h.readKit.Header, h.writeKit.Header)
+
+	// 先更新变更集状态，变更集已被应用或者替代时直接返回
+	option := mapstr.MapStr{
+		common.BKStatus:        metadata.ChangeSetApplied,
+		common.BKOperatorField: operator,
+	}
+	if err := h.logics.UpdateSyncChangeSet(h.writeKit, changeSet.ChangeSetID, option); err != nil {
+		return err
+	}
+
+	// 被销毁vpc下的主机在变更集的删除主机中，这里只更新云区域和任务中的vpc状态
+	destroyedCloudIDs := make([]int64, 0)
+	destroyedVpcs := make(map[string]bool)
+	for _, vpc := range changeSet.DestroyedVpcs {
+		destroyedCloudIDs = append(destroyedCloudIDs, vpc.CloudID)
+		destroyedVpcs[vpc.VpcID] = true
+	}
+	if len(destroyedCloudIDs) > 0 {
+		if err := h.updateDestroyedCloudArea(destroyedCloudIDs); err != nil {
+			blog.Errorf("updateDestroyedCloudArea fail, cloudIDs: %v, err: %v, rid: %s", destroyedCloudIDs, err,
+				h.readKit.Rid)
+			return err
+		}
+		if err := h.updateDestroyedTaskVpc(task.TaskID, destroyedVpcs); err != nil {
+			blog.Errorf("updateDestroyedTaskVpc fail, taskID: %d, err: %v, rid: %s", task.TaskID, err,
+				h.readKit.Rid)
+			return err
+		}
+	}
+
+	diffHosts := make(map[string][]*metadata.CloudHost)
+	for i := range changeSet.Add {
+		diffHosts["add"] = append(diffHosts["add"], &changeSet.Add[i])
+	}
+	for i := range changeSet.Update {
+		diffHosts["update"] = append(diffHosts["update"], &changeSet.Update[i].CloudHost)
+	}
+	for i := range changeSet.Delete {
+		diffHosts["delete"] = append(diffHosts["delete"], &changeSet.Delete[i])
+	}
+
+	if err := h.syncDiffHosts(diffHosts, syncResult); err != nil {
+		blog.Errorf("syncDiffHosts fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return err
+	}
+
+	if err := h.SetSyncResultStatus(syncResult, startTime); err != nil {
+		return err
+	}
+
+	if _, err := h.addSyncHistory(syncResult, task.TaskID); err != nil {
+		blog.Errorf("addSyncHistory fail, taskID: %d, err: %v, rid: %s", task.TaskID, err, h.readKit.Rid)
+		return err
+	}
+
+	ts := time.Now()
+	taskOption := mapstr.MapStr{
+		common.BKCloudSyncStatus:            syncResult.SyncStatus,
+		common.BKCloudSyncStatusDescription: syncResult.StatusDescription,
+		common.BKCloudLastSyncTime:          &ts,
+		common.BKCloudSyncPaused:            false,
+	}
+	if err := h.logics.UpdateSyncTask(h.writeKit, task.TaskID, taskOption); err != nil {
+		blog.Errorf("update task %d after apply change set failed, err: %v, rid: %s", task.TaskID, err,
+			h.readKit.Rid)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCloudHost(instID string, hostID int64, privateIP, state string) *metadata.CloudHost {
+	return &metadata.CloudHost{
+		Instance: metadata.Instance{InstanceId: instID, PrivateIp: privateIP, InstanceState: state},
+		CloudID:  1,
+		HostID:   hostID,
+	}
+}
+
+func TestBuildChangeSet(t *testing.T) {
+	defer func(percent int) { DeleteThresholdPercent = percent }(DeleteThresholdPercent)
+
+	h := &HostSyncor{readKit: ccom.NewKit()}
+	hostResource := &metadata.CloudHostResource{TaskID: 1}
+
+	running := common.BKCloudHostStatusRunning
+	localHosts := map[string]*metadata.CloudHost{
+		"i-1": newTestCloudHost("i-1", 1, "10.0.0.1", running),
+		"i-2": newTestCloudHost("i-2", 2, "10.0.0.2", running),
+		"i-3": newTestCloudHost("i-3", 3, "10.0.0.3", running),
+		"i-4": newTestCloudHost("i-4", 4, "", common.BKCloudHostStatusDestroyed),
+	}
+	diffHosts := map[string][]*metadata.CloudHost{
+		"add":    {newTestCloudHost("i-5", 0, "10.0.0.5", running)},
+		"update": {newTestCloudHost("i-2", 0, "10.0.1.2", running)},
+		"delete": {localHosts["i-3"]},
+	}
+
+	// 没有开启试运行和删除阈值时直接同步
+	DeleteThresholdPercent = 0
+	task := &metadata.CloudSyncTask{TaskID: 1}
+	changeSet, err := h.buildChangeSet(task, hostResource, diffHosts, localHosts)
+	require.NoError(t, err)
+	require.Nil(t, changeSet)
+
+	// 试运行时生成变更集，已销毁的主机不计入已同步主机
+	task.DryRun = true
+	changeSet, err = h.buildChangeSet(task, hostResource, diffHosts, localHosts)
+	require.NoError(t, err)
+	require.NotNil(t, changeSet)
+	require.Equal(t, metadata.ChangeSetReasonDryRun, changeSet.Reason)
+	require.Equal(t, int64(1), changeSet.TaskID)
+	require.Len(t, changeSet.Add, 1)
+	require.Len(t, changeSet.Delete, 1)
+	require.Len(t, changeSet.Update, 1)
+	require.Equal(t, int64(2), changeSet.Update[0].HostID)
+	require.Equal(t, map[string]interface{}{common.BKHostInnerIPField: "10.0.0.2"}, changeSet.Update[0].Before)
+	require.Equal(t, map[string]interface{}{common.BKHostInnerIPField: "10.0.1.2"}, changeSet.Update[0].After)
+	require.InDelta(t, 100.0/3, changeSet.DeleteRatio, 0.001)
+
+	// 试运行时没有任何变更，不需要审批
+	changeSet, err = h.buildChangeSet(task, hostResource, map[string][]*metadata.CloudHost{}, localHosts)
+	require.NoError(t, err)
+	require.Nil(t, changeSet)
+
+	// 删除比例未超过阈值时直接同步
+	task.DryRun = false
+	DeleteThresholdPercent = 50
+	changeSet, err = h.buildChangeSet(task, hostResource, diffHosts, localHosts)
+	require.NoError(t, err)
+	require.Nil(t, changeSet)
+
+	// 删除比例超过阈值时生成变更集
+	DeleteThresholdPercent = 30
+	changeSet, err = h.buildChangeSet(task, hostResource, diffHosts, localHosts)
+	require.NoError(t, err)
+	require.NotNil(t, changeSet)
+	require.Equal(t, metadata.ChangeSetReasonDeleteThreshold, changeSet.Reason)
+}
+
+func TestNewCloudHostUpdate(t *testing.T) {
+	local := newTestCloudHost("i-1", 1, "10.0.0.1", common.BKCloudHostStatusRunning)
+	remote := newTestCloudHost("i-1", 0, "10.0.0.1", common.BKCloudHostStatusStopped)
+	remote.PublicIp = "1.1.1.1"
+	remote.CloudID = 2
+
+	update := newCloudHostUpdate(local, remote)
+	require.Equal(t, int64(1), update.HostID)
+	require.Equal(t, map[string]interface{}{
+		common.BKCloudIDField:         int64(1),
+		common.BKHostOuterIPField:     "",
+		common.BKCloudHostStatusField: common.BKCloudHostStatusRunning,
+	}, update.Before)
+	require.Equal(t, map[string]interface{}{
+		common.BKCloudIDField:         int64(2),
+		common.BKHostOuterIPField:     "1.1.1.1",
+		common.BKCloudHostStatusField: common.BKCloudHostStatusStopped,
+	}, update.After)
+
+	// 本地没有的主机没有变更前的值
+	update = newCloudHostUpdate(nil, remote)
+	require.Zero(t, update.HostID)
+	require.Empty(t, update.Before)
+	require.Empty(t, update.After)
+}
@@ -11,6 +11,7 @@ import (
 	"configcenter/src/common/blog"
 	httpheader "configcenter/src/common/http/header"
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/lock"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
@@ -18,6 +19,9 @@ import (
 	"configcenter/src/scene_server/cloud_server/logics"
 )
 
+// syncTaskLockExpire 任务同步锁的过期时间，需要大于一次同步或者应用变更集的耗时
+const syncTaskLockExpire = 30 * time.Minute
+
 // HostSyncor 云主机同步器
 type HostSyncor struct {
 	logics *logics.Logics
@@ -35,31 +39,53 @@ func NewHostSyncor(logics *logics.Logics) *HostSyncor {
 }
 
 func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource *metadata.CloudHostResource,
-	taskID int64, accountConf *metadata.CloudAccountConf, startTime time.Time) error {
+	task *metadata.CloudSyncTask, accountConf *metadata.CloudAccountConf, startTime time.Time) (bool, error) {
 
+	taskID := task.TaskID
 	// 让writeKit的header含有同样的事务信息，以保证同一个事务里写操作后的数据能够被读到
 	ccom.CopyHeaderTxnInfo(h.readKit.Header, h.writeKit.Header)
-	if len(hostResource.DestroyedVpcs) > 0 {
-		// 同步被销毁的VPC相关资源
-		err := h.syncDestroyedVpcs(hostResource, syncResult)
-		if err != nil {
-			blog.Errorf("syncDestroyedVpcs fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
-			return err
-		}
-	}
 
 	// 查询vpc对应的云区域并更新云主机资源信息里的云区域id
 	err := h.addCLoudId(accountConf, hostResource)
 	if err != nil {
 		blog.Errorf("addCLoudId fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
 	}
 
 	// 根据主机实例id获取mongo中的主机信息,并获取有差异的主机
-	diffHosts, err := h.getDiffHosts(hostResource)
+	diffHosts, localHosts, err := h.getDiffHosts(hostResource)
 	if err != nil {
 		blog.Errorf("getDiffHosts fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
+	}
+
+	// 试运行或者要删除的主机比例超过阈值时，生成待审批的变更集，不直接同步
+	changeSet, err := h.buildChangeSet(task, hostResource, diffHosts, localHosts)
+	if err != nil {
+		blog.Errorf("buildChangeSet fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+		return false, err
+	}
+	if changeSet != nil {
+		if err := h.saveChangeSet(changeSet); err != nil {
+			blog.Errorf("saveChangeSet fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+			return false, err
+		}
+		return true, nil
+	}
+
+	// 直接同步时，之前生成的待审批变更集已经过期，不能再应用
+	if err := h.supersedePendingChangeSet(taskID); err != nil {
+		blog.Errorf("supersedePendingChangeSet fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+		return false, err
+	}
+
+	if len(hostResource.DestroyedVpcs) > 0 {
+		// 同步被销毁的VPC相关资源
+		err := h.syncDestroyedVpcs(hostResource, syncResult)
+		if err != nil {
+			blog.Errorf("syncDestroyedVpcs fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+			return false, err
+		}
 	}
 
 	// 没差异则结束
@@ -67,7 +93,7 @@ func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource
 		blog.Infof("no diff hosts for taskid:%d, rid:%s", taskID, h.readKit.Rid)
 		if syncResult.SuccessInfo.Count == 0 {
 			blog.Infof("no any hosts need sync for taskID: %d, rid: %s", taskID, h.readKit.Rid)
-			return nil
+			return false, nil
 		}
 	}
 
@@ -75,40 +101,40 @@ func (h *HostSyncor) syncCloudHost(syncResult *metadata.SyncResult, hostResource
 	err = h.updateTaskState(h.writeKit, taskID, metadata.CloudSyncInProgress, nil)
 	if err != nil {
 		blog.Errorf("updateTaskState fail, taskID: %d, err: %v, rid:%s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
 	}
 
 	// 同步有差异的主机数据
 	err = h.syncDiffHosts(diffHosts, syncResult)
 	if err != nil {
 		blog.Errorf("syncDiffHosts fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
 	}
 
 	// 设置SyncResult的状态信息
 	err = h.SetSyncResultStatus(syncResult, startTime)
 	if err != nil {
 		blog.Errorf("SetSyncResultStatus fail, taskID: %d, err: %v, rid: %s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
 	}
 
 	// 增加任务同步历史记录
 	_, err = h.addSyncHistory(syncResult, taskID)
 	if err != nil {
 		blog.Errorf("addSyncHistory fail, taskID: %d, err: %v, rid:%s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
 	}
 
 	// 完成后更新任务同步状态
 	err = h.updateTaskState(h.writeKit, taskID, syncResult.SyncStatus, &syncResult.StatusDescription)
 	if err != nil {
 		blog.Errorf("updateTaskState fail, taskid: %d, err: %v, rid:%s", taskID, err, h.readKit.Rid)
-		return err
+		return false, err
 	}
 
 	blog.Infof("sync success, finish sync taskid: %v, costTime: %ds, detail: %#v, failInfo: %#v, rid: %s",
 		taskID, time.Since(startTime)/time.Second, syncResult.Detail, syncResult.FailInfo, h.readKit.Rid)
-	return nil
+	return false, nil
 }
 
 // Sync 同步云主机
@@ -119,6 +145,12 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 		}
 	}()
 
+	// 被自动暂停的任务需要审批变更集后才能继续同步
+	if task.Paused {
+		blog.Infof("task %d is paused, wait for its change set to be applied", task.TaskID)
+		return nil
+	}
+
 	// 每次同步生成新的kit
 	h.readKit = ccom.NewKit()
 	// 将云同步任务的开发商ID作为写kit的开发商ID
@@ -126,6 +158,17 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	// 让读写kit的requestID保持一致，以追踪同一个task的日志
 	httpheader.SetRid(h.writeKit.Header, httpheader.GetRid(h.readKit.Header))
 
+	// 同一任务的变更集正在应用或者上一轮同步还未结束时，跳过本轮同步
+	locker, err := h.lockTask(task.TaskID)
+	if err != nil {
+		return err
+	}
+	if locker == nil {
+		blog.Infof("task %d is being synced, skip this round, rid: %s", task.TaskID, h.readKit.Rid)
+		return nil
+	}
+	defer locker.Unlock()
+
 	startTime := time.Now()
 	blog.Infof("start sync taskid:%d, rid:%s", task.TaskID, h.readKit.Rid)
 
@@ -153,8 +196,12 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	syncResult := new(metadata.SyncResult)
 	syncResult.FailInfo.IPError = make(map[string]string)
 
+	// 是否生成了待审批的变更集
+	pending := false
 	txnErr := h.logics.CoreAPI.CoreService().Txn().AutoRunTxn(h.readKit.Ctx, h.readKit.Header, func() error {
-		return h.syncCloudHost(syncResult, hostResource, task.TaskID, accountConf, startTime)
+		var err error
+		pending, err = h.syncCloudHost(syncResult, hostResource, task, accountConf, startTime)
+		return err
 	})
 
 	// 事务结束，去掉readKit、writeKit中header的事务信息
@@ -185,7 +232,9 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 			return fmt.Errorf("taskID %d is not found", task.TaskID)
 		}
 
-		if ret.Info[0].SyncStatus == metadata.CloudSyncFail {
+		// 没有生成变更集时，待审批状态也要恢复为正常
+		status := ret.Info[0].SyncStatus
+		if status == metadata.CloudSyncFail || (status == metadata.CloudSyncPendingApproval && !pending) {
 			costTime, _ := strconv.ParseFloat(fmt.Sprintf("%.1f",
 				float64(time.Since(startTime)/time.Millisecond)/1000.0), 64)
 			err := h.updateTaskState(h.writeKit, task.TaskID, metadata.CloudSyncSuccess,
@@ -194,7 +243,7 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 				blog.Errorf("updateTaskState fail, taskid:%d, err:%v, rid:%s", task.TaskID, err,
 					h.readKit.Rid)
 			}
-			blog.Infof("update taskid:%d status from fail to success, rid:%s", task.TaskID, h.readKit.Rid)
+			blog.Infof("update taskid:%d status from %s to success, rid:%s", task.TaskID, status, h.readKit.Rid)
 		}
 		blog.Errorf("sync success, taskid:%d, rid:%s", task.TaskID, h.readKit.Rid)
 	}
@@ -205,6 +254,20 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 	return nil
 }
 
+// lockTask 加任务的同步锁，保证同一任务的同步和变更集应用不会同时进行，锁已被占用时返回nil
+func (h *HostSyncor) lockTask(taskID int64) (lock.Locker, error) {
+	locker := h.logics.NewLocker()
+	locked, err := locker.Lock(lock.GetLockKey(lock.CloudSyncTaskFormat, taskID), syncTaskLockExpire)
+	if err != nil {
+		blog.Errorf("lock sync task %d failed, err: %v, rid: %s", taskID, err, h.readKit.Rid)
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+	return locker, nil
+}
+
 // getCloudHostResource 根据任务详情和账号信息获取要同步的云主机资源
 func (h *HostSyncor) getCloudHostResource(task *metadata.CloudSyncTask,
 	accountConf *metadata.CloudAccountConf) (*metadata.CloudHostResource, error) {
@@ -302,7 +365,9 @@ func (h *HostSyncor) addCLoudId(accountConf *metadata.CloudAccountConf,
 }
 
 // getDiffHosts 根据主机实例id获取mongo中的主机信息,并获取有差异的主机
-func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map[string][]*metadata.CloudHost, error) {
+// 同时返回本地已有的云主机，key为主机实例id
+func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map[string][]*metadata.CloudHost,
+	map[string]*metadata.CloudHost, error) {
 	// 云端的主机
 	remoteHostsMap := make(map[string]*metadata.CloudHost)
 	for _, hostRes := range hostResource.HostResource {
@@ -325,7 +390,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 	// 本地已有的云主机
 	localHosts, err := h.getLocalHosts(cloudIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	blog.V(4).Infof("taskid:%d, len(localHosts):%d, rid:%s", hostResource.TaskID, len(localHosts), h.readKit.Rid)
 	localIdHostsMap := make(map[string]*metadata.CloudHost)
@@ -364,7 +429,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 		}
 	}
 
-	return diffHosts, nil
+	return diffHosts, localIdHostsMap, nil
 }
 
 // syncDiffHosts 同步有差异的主机数据
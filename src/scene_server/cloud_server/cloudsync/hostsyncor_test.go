@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/lock"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+	"configcenter/src/scene_server/cloud_server/logics"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/alicebob/miniredis"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHostSyncor(t *testing.T) (*HostSyncor, redis.Client, func()) {
+	redisMock, err := miniredis.Run()
+	require.NoError(t, err)
+
+	cache, err := redis.NewFromConfig(redis.Config{Address: redisMock.Addr(), Database: "0", MaxOpenConns: 1})
+	require.NoError(t, err)
+
+	h := NewHostSyncor(logics.NewLogics(nil, nil, nil, cache))
+	h.readKit = ccom.NewKit()
+	return h, cache, redisMock.Close
+}
+
+func TestLockTask(t *testing.T) {
+	h, _, closeFunc := newTestHostSyncor(t)
+	defer closeFunc()
+
+	locker, err := h.lockTask(1)
+	require.NoError(t, err)
+	require.NotNil(t, locker)
+
+	// 同一任务不能重复加锁，不同任务互不影响
+	another, err := h.lockTask(1)
+	require.NoError(t, err)
+	require.Nil(t, another)
+
+	another, err = h.lockTask(2)
+	require.NoError(t, err)
+	require.NotNil(t, another)
+	require.NoError(t, another.Unlock())
+
+	require.NoError(t, locker.Unlock())
+	locker, err = h.lockTask(1)
+	require.NoError(t, err)
+	require.NotNil(t, locker)
+	require.NoError(t, locker.Unlock())
+}
+
+func TestApplyChangeSetWhenSyncing(t *testing.T) {
+	errors.SetGlobalCCError(errors.NewFromCtx(errors.EmptyErrorsSetting))
+	defer errors.SetGlobalCCError(nil)
+
+	h, cache, closeFunc := newTestHostSyncor(t)
+	defer closeFunc()
+
+	// 任务正在同步时，应用变更集直接失败
+	syncing := lock.NewLocker(cache)
+	locked, err := syncing.Lock(lock.GetLockKey(lock.CloudSyncTaskFormat, 1), syncTaskLockExpire)
+	require.NoError(t, err)
+	require.True(t, locked)
+	defer syncing.Unlock()
+
+	task := &metadata.CloudSyncTask{TaskID: 1}
+	changeSet := &metadata.CloudSyncChangeSet{ChangeSetID: 2, TaskID: 1}
+	err = h.ApplyChangeSet(task, changeSet, "admin")
+	require.Error(t, err)
+	ccErr, ok := err.(errors.CCErrorCoder)
+	require.True(t, ok)
+	require.Equal(t, common.CCErrCloudSyncTaskIsSyncing, ccErr.GetCode())
+}
+
+func TestGetHostIDAndIP(t *testing.T) {
+	hostID, innerIP, err := getHostIDAndIP(map[string]interface{}{
+		common.BKHostIDField:      float64(3),
+		common.BKHostInnerIPField: "10.0.0.3",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), hostID)
+	require.Equal(t, "10.0.0.3", innerIP)
+
+	_, _, err = getHostIDAndIP(map[string]interface{}{common.BKHostInnerIPField: "10.0.0.3"})
+	require.Error(t, err)
+}
@@ -27,6 +27,9 @@ const (
 // SyncPeriodMinutes 同步周期，单位为分钟
 var SyncPeriodMinutes int
 
+// DeleteThresholdPercent 一次同步要删除的主机占已同步主机的百分比超过该值时，自动暂停任务，为0时不检查
+var DeleteThresholdPercent int
+
 // 任务处理器
 type taskProcessor struct {
 	scheduler *taskScheduler
@@ -61,11 +61,11 @@ var regionIdNameMap = map[string]string{
 }
 
 // NewVendorClient 创建云厂商客户端
-func (c *awsClient) NewVendorClient(secretID, secretKey string) VendorClient {
+func (c *awsClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
 	return &awsClient{
 		vendorName: metadata.AWS,
-		secretID:   secretID,
-		secretKey:  secretKey,
+		secretID:   conf.SecretID,
+		secretKey:  conf.SecretKey,
 	}
 }
 
@@ -0,0 +1,328 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudvendor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	"github.com/tidwall/gjson"
+)
+
+func init() {
+	Register(metadata.HTTPInventory, &inventoryClient{})
+}
+
+// inventoryClient 通用的HTTP主机清单客户端，从符合约定格式的HTTP接口中获取私有云的地域、vpc和主机实例
+// 接口格式详见 docs/wiki/http-inventory-cloud-vendor.md
+type inventoryClient struct {
+	endpoint     string
+	secretID     string
+	secretKey    string
+	fieldMapping map[string]string
+	httpCli      *http.Client
+}
+
+const (
+	inventoryMinPageSize int64 = 1
+	inventoryMaxPageSize int64 = 500
+
+	inventoryRequestTimeout = 30 * time.Second
+	// inventoryMaxBodySize 主机清单接口单次返回数据的最大字节数
+	inventoryMaxBodySize = 20 * 1024 * 1024
+)
+
+// defaultInventoryFields 主机属性默认对应的主机清单实例字段
+var defaultInventoryFields = map[string]string{
+	common.BKCloudInstIDField:     "instance_id",
+	common.BKHostInnerIPField:     "private_ip",
+	common.BKHostOuterIPField:     "public_ip",
+	common.BKCloudHostStatusField: "state",
+	common.BKVpcID:                "vpc_id",
+}
+
+// NewVendorClient 创建云厂商客户端
+func (c *inventoryClient) NewVendorClient(conf metadata.CloudAccountConf) VendorClient {
+	fieldMapping := make(map[string]string)
+	for field, key := range defaultInventoryFields {
+		fieldMapping[field] = key
+	}
+	for field, key := range conf.FieldMapping {
+		if _, exists := defaultInventoryFields[field]; exists && key != "" {
+			fieldMapping[field] = key
+		}
+	}
+
+	return &inventoryClient{
+		endpoint:     strings.TrimSuffix(conf.Endpoint, "/"),
+		secretID:     conf.SecretID,
+		secretKey:    conf.SecretKey,
+		fieldMapping: fieldMapping,
+		httpCli:      &http.Client{Timeout: inventoryRequestTimeout},
+	}
+}
+
+// inventoryRegions 主机清单地域接口返回数据
+type inventoryRegions struct {
+	Regions []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		State string `json:"state"`
+	} `json:"regions"`
+}
+
+// inventoryVpcs 主机清单vpc接口返回数据
+type inventoryVpcs struct {
+	Count int64 `json:"count"`
+	Vpcs  []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"vpcs"`
+}
+
+// GetRegions 获取地域列表
+// 接口：GET {endpoint}/regions
+func (c *inventoryClient) GetRegions() ([]*metadata.Region, error) {
+	body, err := c.get("/regions", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(inventoryRegions)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("decode inventory regions failed, err: %v", err)
+	}
+
+	regionSet := make([]*metadata.Region, 0)
+	for _, region := range resp.Regions {
+		if region.ID == "" {
+			continue
+		}
+
+		name := region.Name
+		if name == "" {
+			name = region.ID
+		}
+		regionSet = append(regionSet, &metadata.Region{
+			RegionId:    region.ID,
+			RegionName:  name,
+			RegionState: region.State,
+		})
+	}
+
+	return regionSet, nil
+}
+
+// GetVpcs 获取vpc列表
+// 接口：GET {endpoint}/vpcs?region={region}&offset={offset}&limit={limit}
+func (c *inventoryClient) GetVpcs(region string, opt *ccom.VpcOpt) (*metadata.VpcsInfo, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultVpcOpt()
+	}
+
+	query := c.newQuery(region, opt.Filters)
+	vpcsInfo := &metadata.VpcsInfo{VpcSet: make([]*metadata.Vpc, 0)}
+	// 在limit小于全部数据量的情况下，获取limit数量的数据，否则获取全部数据
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("get inventory vpcs loopCnt: %d, bigger than MaxLoopCnt, count: %d", loopCnt, vpcsInfo.Count)
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		c.setPage(query, int64(len(vpcsInfo.VpcSet)), opt.Limit)
+		body, err := c.get("/vpcs", query)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := new(inventoryVpcs)
+		if err := json.Unmarshal(body, resp); err != nil {
+			return nil, fmt.Errorf("decode inventory vpcs failed, err: %v", err)
+		}
+
+		for _, vpc := range resp.Vpcs {
+			name := vpc.Name
+			if name == "" {
+				name = vpc.ID
+			}
+			vpcsInfo.VpcSet = append(vpcsInfo.VpcSet, &metadata.Vpc{VpcId: vpc.ID, VpcName: name})
+		}
+		vpcsInfo.Count = resp.Count
+
+		// 在获取到limit数量或者全部数据，或者接口不再返回数据的情况下，退出循环
+		if len(resp.Vpcs) == 0 || int64(len(vpcsInfo.VpcSet)) >= opt.Limit ||
+			int64(len(vpcsInfo.VpcSet)) >= resp.Count {
+			break
+		}
+	}
+
+	return vpcsInfo, nil
+}
+
+// GetInstances 获取实例列表
+// 接口：GET {endpoint}/instances?region={region}&vpc-id={vpc_id}&offset={offset}&limit={limit}
+func (c *inventoryClient) GetInstances(region string, opt *ccom.InstanceOpt) (*metadata.InstancesInfo, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+
+	query := c.newQuery(region, opt.Filters)
+	instancesInfo := &metadata.InstancesInfo{InstanceSet: make([]*metadata.Instance, 0)}
+	// 没有实例id的实例会被跳过，所以分页的offset按接口返回的原始数据个数计算
+	offset := int64(0)
+	// 在limit小于全部数据量的情况下，获取limit数量的数据，否则获取全部数据
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("get inventory instances loopCnt: %d, bigger than MaxLoopCnt, count: %d", loopCnt,
+				instancesInfo.Count)
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		c.setPage(query, offset, opt.Limit)
+		body, err := c.get("/instances", query)
+		if err != nil {
+			return nil, err
+		}
+
+		if !gjson.ValidBytes(body) {
+			return nil, fmt.Errorf("decode inventory instances failed, response is not a valid json")
+		}
+
+		insts := gjson.GetBytes(body, "instances").Array()
+		offset += int64(len(insts))
+		for _, inst := range insts {
+			instance := c.convertInstance(inst)
+			if instance.InstanceId == "" {
+				blog.Errorf("inventory instance has no %s field, skip it, instance: %s",
+					c.fieldMapping[common.BKCloudInstIDField], inst.Raw)
+				continue
+			}
+			instancesInfo.InstanceSet = append(instancesInfo.InstanceSet, instance)
+		}
+		instancesInfo.Count = gjson.GetBytes(body, "count").Int()
+
+		// 在获取到limit数量或者全部数据，或者接口不再返回数据的情况下，退出循环
+		if len(insts) == 0 || int64(len(instancesInfo.InstanceSet)) >= opt.Limit || offset >= instancesInfo.Count {
+			break
+		}
+	}
+
+	return instancesInfo, nil
+}
+
+// GetInstancesTotalCnt 获取实例总个数
+func (c *inventoryClient) GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error) {
+	if opt == nil {
+		opt = ccom.GetDefaultInstanceOpt()
+	}
+	// 直接将limit设为最小值，能最快地获取到实例总个数
+	opt.Limit = inventoryMinPageSize
+	instsInfo, err := c.GetInstances(region, opt)
+	if err != nil {
+		return 0, err
+	}
+	return instsInfo.Count, nil
+}
+
+// convertInstance 根据字段映射将主机清单实例转换为云主机实例
+func (c *inventoryClient) convertInstance(inst gjson.Result) *metadata.Instance {
+	return &metadata.Instance{
+		InstanceId:    c.getField(inst, common.BKCloudInstIDField),
+		PrivateIp:     c.getField(inst, common.BKHostInnerIPField),
+		PublicIp:      c.getField(inst, common.BKHostOuterIPField),
+		InstanceState: ccom.CovertInstState(c.getField(inst, common.BKCloudHostStatusField)),
+		VpcId:         c.getField(inst, common.BKVpcID),
+	}
+}
+
+// getField 获取主机属性对应的实例字段值，字段支持以"."分隔的嵌套路径，字段值为数组时取第一个元素
+func (c *inventoryClient) getField(inst gjson.Result, field string) string {
+	value := inst.Get(c.fieldMapping[field])
+	if value.IsArray() {
+		values := value.Array()
+		if len(values) == 0 {
+			return ""
+		}
+		value = values[0]
+	}
+	return value.String()
+}
+
+// newQuery 生成请求参数，过滤条件作为同名的请求参数传递
+func (c *inventoryClient) newQuery(region string, filters []*ccom.Filter) url.Values {
+	query := url.Values{}
+	query.Set("region", region)
+	for _, filter := range filters {
+		if filter == nil || filter.Name == nil {
+			continue
+		}
+		for _, value := range filter.Values {
+			if value != nil {
+				query.Add(*filter.Name, *value)
+			}
+		}
+	}
+	return query
+}
+
+// setPage 设置分页请求参数，单次请求返回结果条数不在取值范围内的设为最大值
+func (c *inventoryClient) setPage(query url.Values, offset, limit int64) {
+	if limit < inventoryMinPageSize || limit > inventoryMaxPageSize {
+		limit = inventoryMaxPageSize
+	}
+	query.Set("offset", strconv.FormatInt(offset, 10))
+	query.Set("limit", strconv.FormatInt(limit, 10))
+}
+
+// get 请求主机清单接口，云账户的密钥id和密钥以basic auth的方式传递
+func (c *inventoryClient) get(path string, query url.Values) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, c.endpoint+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.secretID, c.secretKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, inventoryMaxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("read inventory response of %s failed, err: %v", path, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		// 与云厂商sdk的鉴权错误保持一致，以便上层识别为账户密钥错误
+		return nil, fmt.Errorf("AuthFailure: request inventory %s failed, status: %d", path, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("request inventory %s failed, status: %d, body: %s", path, resp.StatusCode,
+			string(body))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,146 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudvendor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newInventoryServer() *httptest.Server {
+	instances := []string{
+		`{"uuid":"i-1","addr":{"private":["10.0.0.1"]},"status":"ACTIVE","network":"vpc-1"}`,
+		`{"uuid":"i-2","addr":{"private":["10.0.0.2"],"public":"1.1.1.2"},"status":"SHUTOFF","network":"vpc-1"}`,
+		`{"uuid":"i-3","addr":{"private":["10.0.1.3"]},"status":"BUILD","network":"vpc-2"}`,
+		`{"addr":{"private":["10.0.2.4"]},"status":"ACTIVE","network":"vpc-3"}`,
+		`{"uuid":"i-5","addr":{"private":["10.0.2.5"]},"status":"ACTIVE","network":"vpc-3"}`,
+		`{"uuid":"i-6","addr":{"private":["10.0.2.6"]},"status":"ACTIVE","network":"vpc-3"}`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pwd, ok := r.BasicAuth()
+		if !ok || user != "id" || pwd != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/regions":
+			fmt.Fprint(w, `{"regions":[{"id":"r1","name":"region one","state":"available"},{"id":"r2"}]}`)
+		case "/api/vpcs":
+			fmt.Fprint(w, `{"count":2,"vpcs":[{"id":"vpc-1","name":"vpc one"},{"id":"vpc-2"}]}`)
+		case "/api/instances":
+			if r.URL.Query().Get("region") != "r1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			matched := make([]string, 0)
+			for _, inst := range instances {
+				vpcID := r.URL.Query().Get("vpc-id")
+				if vpcID == "" || strings.Contains(inst, `"network":"`+vpcID+`"`) {
+					matched = append(matched, inst)
+				}
+			}
+
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			// the server returns at most 2 instances per page
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit > 2 {
+				limit = 2
+			}
+			end := offset + limit
+			if end > len(matched) {
+				end = len(matched)
+			}
+			fmt.Fprintf(w, `{"count":%d,"instances":[%s]}`, len(matched), strings.Join(matched[offset:end], ","))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestInventoryClient(t *testing.T) {
+	server := newInventoryServer()
+	defer server.Close()
+
+	conf := metadata.CloudAccountConf{
+		VendorName: metadata.HTTPInventory,
+		SecretID:   "id",
+		SecretKey:  "key",
+		Endpoint:   server.URL + "/api/",
+		FieldMapping: map[string]string{
+			common.BKCloudInstIDField:     "uuid",
+			common.BKHostInnerIPField:     "addr.private",
+			common.BKHostOuterIPField:     "addr.public",
+			common.BKCloudHostStatusField: "status",
+			common.BKVpcID:                "network",
+		},
+	}
+	client, err := GetVendorClient(conf)
+	require.NoError(t, err)
+
+	regions, err := client.GetRegions()
+	require.NoError(t, err)
+	require.Len(t, regions, 2)
+	require.Equal(t, "region one", regions[0].RegionName)
+	require.Equal(t, "r2", regions[1].RegionName)
+
+	vpcs, err := client.GetVpcs("r1", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), vpcs.Count)
+	require.Equal(t, "vpc-2", vpcs.VpcSet[1].VpcName)
+
+	insts, err := client.GetInstances("r1", &ccom.InstanceOpt{BaseOpt: ccom.BaseOpt{
+		Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-1"})}},
+		Limit:   ccom.MaxLimit,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), insts.Count)
+	require.Equal(t, &metadata.Instance{InstanceId: "i-1", PrivateIp: "10.0.0.1",
+		InstanceState: common.BKCloudHostStatusRunning, VpcId: "vpc-1"}, insts.InstanceSet[0])
+	require.Equal(t, &metadata.Instance{InstanceId: "i-2", PrivateIp: "10.0.0.2", PublicIp: "1.1.1.2",
+		InstanceState: common.BKCloudHostStatusStopped, VpcId: "vpc-1"}, insts.InstanceSet[1])
+
+	// the instance without id is skipped, the following pages are not shifted by it
+	insts, err = client.GetInstances("r1", &ccom.InstanceOpt{BaseOpt: ccom.BaseOpt{
+		Filters: []*ccom.Filter{{Name: ccom.StringPtr("vpc-id"), Values: ccom.StringPtrs([]string{"vpc-3"})}},
+		Limit:   ccom.MaxLimit,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), insts.Count)
+	require.Len(t, insts.InstanceSet, 2)
+	require.Equal(t, "i-5", insts.InstanceSet[0].InstanceId)
+	require.Equal(t, "i-6", insts.InstanceSet[1].InstanceId)
+
+	count, err := client.GetInstancesTotalCnt("r1", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), count)
+
+	conf.SecretKey = "wrong"
+	client, err = GetVendorClient(conf)
+	require.NoError(t, err)
+	_, err = client.GetRegions()
+	require.Error(t, err)
+	require.Contains(t, strings.ToLower(err.Error()), "authfailure")
+}
@@ -24,7 +24,7 @@ var vendorClients = make(map[string]VendorClient, 0)
 // VendorClient TODO
 type VendorClient interface {
 	// NewVendorClient 创建云厂商客户端
-	NewVendorClient(secretID, secretKey string) VendorClient
+	NewVendorClient(conf metadata.CloudAccountConf) VendorClient
 	// GetRegions 获取地域列表
 	GetRegions() ([]*metadata.Region, error)
 	// GetVpcs 获取vpc列表
@@ -47,6 +47,6 @@ func GetVendorClient(conf metadata.CloudAccountConf) (VendorClient, error) {
 	if client, ok = vendorClients[conf.VendorName]; !ok {
 		return nil, fmt.Errorf("vendor %s is not supported", conf.VendorName)
 	}
-	cli := client.NewVendorClient(conf.SecretID, conf.SecretKey)
+	cli := client.NewVendorClient(conf)
 	return cli, nil
 }
@@ -17,6 +17,8 @@ import (
 	"configcenter/src/ac"
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/cryptor"
+	"configcenter/src/common/lock"
+	"configcenter/src/storage/dal/redis"
 )
 
 // Logics framwork need
@@ -24,13 +26,21 @@ type Logics struct {
 	*backbone.Engine
 	cryptor    cryptor.Cryptor
 	authorizer ac.AuthorizeInterface
+	cacheDB    redis.Client
 }
 
 // NewLogics TODO
-func NewLogics(engine *backbone.Engine, cryptor cryptor.Cryptor, authorizer ac.AuthorizeInterface) *Logics {
+func NewLogics(engine *backbone.Engine, cryptor cryptor.Cryptor, authorizer ac.AuthorizeInterface,
+	cacheDB redis.Client) *Logics {
 	return &Logics{
 		Engine:     engine,
 		cryptor:    cryptor,
 		authorizer: authorizer,
+		cacheDB:    cacheDB,
 	}
 }
+
+// NewLocker 创建基于redis的锁
+func (lgc *Logics) NewLocker() lock.Locker {
+	return lock.NewLocker(lgc.cacheDB)
+}
@@ -371,6 +371,51 @@ func (lgc *Logics) SearchSyncHistory(kit *rest.Kit,
 	return result, nil
 }
 
+// CreateSyncChangeSet 创建云同步任务待审批的变更集
+func (lgc *Logics) CreateSyncChangeSet(kit *rest.Kit, changeSet *metadata.CloudSyncChangeSet) (
+	*metadata.CloudSyncChangeSet, error) {
+	result, err := lgc.CoreAPI.CoreService().Cloud().CreateSyncChangeSet(kit.Ctx, kit.Header, changeSet)
+	if err != nil {
+		blog.Errorf("CreateSyncChangeSet failed, rid:%s, taskID:%d, err:%+v", kit.Rid, changeSet.TaskID, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SearchSyncChangeSet 查询云同步任务的变更集
+func (lgc *Logics) SearchSyncChangeSet(kit *rest.Kit,
+	option *metadata.SearchSyncChangeSetOption) (*metadata.MultipleSyncChangeSet, error) {
+	// set default limit
+	if option.Page.Limit == 0 {
+		option.Page.Limit = common.BKDefaultLimit
+	}
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("SearchSyncChangeSet failed, option is invalid, rid:%s, option:%+v", kit.Rid, option)
+		return nil, rawErr.ToCCError(kit.CCError)
+	}
+
+	result, err := lgc.CoreAPI.CoreService().Cloud().SearchSyncChangeSet(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("SearchSyncChangeSet failed, rid:%s, option:%+v, err:%+v", kit.Rid, option, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateSyncChangeSet 更新云同步任务变更集的状态
+func (lgc *Logics) UpdateSyncChangeSet(kit *rest.Kit, changeSetID int64, option map[string]interface{}) error {
+	err := lgc.CoreAPI.CoreService().Cloud().UpdateSyncChangeSet(kit.Ctx, kit.Header, changeSetID, option)
+	if err != nil {
+		blog.Errorf("UpdateSyncChangeSet failed, rid:%s, changeSetID:%d, option:%+v, err:%+v", kit.Rid,
+			changeSetID, option, err)
+		return err
+	}
+
+	return nil
+}
+
 // SearchSyncRegion TODO
 func (lgc *Logics) SearchSyncRegion(kit *rest.Kit, option *metadata.SearchSyncRegionOption) ([]metadata.SyncRegion,
 	error) {
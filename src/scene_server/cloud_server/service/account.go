@@ -37,8 +37,15 @@ func (s *Service) VerifyConnectivity(ctx *rest.Contexts) {
 		return
 	}
 
+	if account.CloudVendor == metadata.HTTPInventory {
+		if rawErr := metadata.ValidateInventoryEndpoint(account.Endpoint); rawErr.ErrCode != 0 {
+			ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+			return
+		}
+	}
+
 	conf := metadata.CloudAccountConf{VendorName: account.CloudVendor, SecretID: account.SecretID,
-		SecretKey: account.SecretKey}
+		SecretKey: account.SecretKey, Endpoint: account.Endpoint, FieldMapping: account.FieldMapping}
 	err := s.Logics.AccountVerify(ctx.Kit, conf)
 	if err != nil {
 		blog.ErrorJSON("cloud account verify failed, cloudvendor:%s, err :%v, rid: %s", account.CloudVendor, err,
@@ -115,6 +115,10 @@ func (s *Service) initRoute(api *restful.WebService) {
 		Handler: s.SearchSyncHistory})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/region",
 		Handler: s.SearchSyncRegion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/change_set",
+		Handler: s.SearchSyncChangeSet})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/sync/change_set/{bk_change_set_id}/apply",
+		Handler: s.ApplySyncChangeSet})
 
 	utility.AddToRestfulWebService(api)
 }
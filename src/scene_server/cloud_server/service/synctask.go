@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/cloud_server/cloudsync"
 )
 
 // SearchVpc TODO
@@ -198,3 +199,77 @@ func (s *Service) SearchSyncRegion(ctx *rest.Contexts) {
 
 	ctx.RespEntity(result)
 }
+
+// SearchSyncChangeSet 查询云同步任务的变更集
+func (s *Service) SearchSyncChangeSet(ctx *rest.Contexts) {
+	option := metadata.SearchSyncChangeSetOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.Logics.SearchSyncChangeSet(ctx.Kit, &option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// ApplySyncChangeSet 审批并应用云同步任务待审批的变更集，被自动暂停的任务在应用后恢复同步
+func (s *Service) ApplySyncChangeSet(ctx *rest.Contexts) {
+	changeSetIDStr := ctx.Request.PathParameter(common.BKCloudChangeSetID)
+	changeSetID, err := strconv.ParseInt(changeSetIDStr, 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudChangeSetID))
+		return
+	}
+
+	option := metadata.ApplySyncChangeSetOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if option.TaskID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKCloudSyncTaskID))
+		return
+	}
+
+	taskOpt := &metadata.SearchCloudOption{Condition: map[string]interface{}{common.BKCloudSyncTaskID: option.TaskID}}
+	tasks, err := s.Engine.CoreAPI.CoreService().Cloud().SearchSyncTask(ctx.Kit.Ctx, ctx.Kit.Header, taskOpt)
+	if err != nil {
+		blog.Errorf("search sync task failed, taskID: %d, err: %v, rid: %s", option.TaskID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(tasks.Info) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudSyncTaskID))
+		return
+	}
+
+	// 变更集必须属于该任务并且是待审批状态
+	changeSetOpt := &metadata.SearchSyncChangeSetOption{
+		TaskID:      option.TaskID,
+		ChangeSetID: changeSetID,
+		Status:      metadata.ChangeSetPending,
+		Page:        metadata.BasePage{Limit: 1},
+	}
+	changeSets, err := s.Logics.SearchSyncChangeSet(ctx.Kit, changeSetOpt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(changeSets.Info) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCloudSyncChangeSetNotPending, changeSetID))
+		return
+	}
+
+	err = cloudsync.NewHostSyncor(s.Logics).ApplyChangeSet(&tasks.Info[0], &changeSets.Info[0], ctx.Kit.User)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
@@ -36,6 +36,7 @@ import (
 	"configcenter/src/scene_server/datacollection/app/options"
 	"configcenter/src/scene_server/datacollection/collections"
 	"configcenter/src/scene_server/datacollection/collections/hostsnap"
+	"configcenter/src/scene_server/datacollection/collections/kube"
 	"configcenter/src/scene_server/datacollection/collections/middleware"
 	"configcenter/src/scene_server/datacollection/collections/netcollect"
 	svc "configcenter/src/scene_server/datacollection/service"
@@ -72,6 +73,9 @@ const (
 
 	// defaultAppInitWaitDuration is default wait duration for app db init.
 	defaultAppInitWaitDuration = 10 * time.Second
+
+	// kubeConfigKey is the configs key of kubernetes collector.
+	kubeConfigKey = "datacollection.kube"
 )
 
 // DataCollectionConfig is configs for DataCollection app.
@@ -105,6 +109,9 @@ type DataCollectionConfig struct {
 
 	// SnapReportMode hostsnap report mode
 	SnapReportMode string
+
+	// Kube kubernetes collector configs.
+	Kube kube.Config
 }
 
 // DataCollection is data collection server.
@@ -293,6 +300,16 @@ func (c *DataCollection) initConfigs() error {
 		blog.Warnf("parse auth center config failed: %v", err)
 	}
 
+	if cc.IsExist(kubeConfigKey) {
+		if err := cc.UnmarshalKey(kubeConfigKey, &c.config.Kube); err != nil {
+			return fmt.Errorf("parse kube collector configs, %+v", err)
+		}
+
+		if err := c.config.Kube.Validate(); err != nil {
+			return fmt.Errorf("kube collector configs are invalid, %+v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -552,6 +569,13 @@ func (c *DataCollection) Run() error {
 
 	blog.Info("run collect porters success!")
 
+	// run kubernetes collector, the clusters are collected by the datacollection nodes that they are hashed to.
+	if c.config.Kube.Enabled {
+		collector := kube.NewCollector(c.config.Kube, kube.NewCmdbClient(c.engine.CoreAPI), c.hash.IsMatch)
+		go collector.Run(c.ctx)
+		blog.Info("run kube collector success!")
+	}
+
 	return nil
 }
 
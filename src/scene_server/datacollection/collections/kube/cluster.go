@@ -0,0 +1,280 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/kube/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// reconcileInterval is the minimum interval between two reconciliations that are triggered by events, so that
+// the events that happen in a short time are reconciled together.
+const reconcileInterval = 30 * time.Second
+
+// clusterCollector watches the kubernetes resources of a cluster with informers, and reconciles them into cmdb.
+type clusterCollector struct {
+	conf   ClusterConfig
+	cmdb   CmdbClient
+	resync time.Duration
+
+	factory      informers.SharedInformerFactory
+	nodes        corelisters.NodeLister
+	namespaces   corelisters.NamespaceLister
+	pods         corelisters.PodLister
+	deployments  appslisters.DeploymentLister
+	replicaSets  appslisters.ReplicaSetLister
+	statefulSets appslisters.StatefulSetLister
+	daemonSets   appslisters.DaemonSetLister
+	jobs         batchlisters.JobLister
+	cronJobs     batchlisters.CronJobLister
+	synced       []cache.InformerSynced
+
+	// notify is signaled when any of the collected resources changes.
+	notify chan struct{}
+}
+
+func newClusterCollector(conf ClusterConfig, clientSet kubernetes.Interface, cmdb CmdbClient,
+	resync time.Duration) *clusterCollector {
+
+	factory := informers.NewSharedInformerFactory(clientSet, 0)
+	c := &clusterCollector{
+		conf:         conf,
+		cmdb:         cmdb,
+		resync:       resync,
+		factory:      factory,
+		nodes:        factory.Core().V1().Nodes().Lister(),
+		namespaces:   factory.Core().V1().Namespaces().Lister(),
+		pods:         factory.Core().V1().Pods().Lister(),
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		replicaSets:  factory.Apps().V1().ReplicaSets().Lister(),
+		statefulSets: factory.Apps().V1().StatefulSets().Lister(),
+		daemonSets:   factory.Apps().V1().DaemonSets().Lister(),
+		jobs:         factory.Batch().V1().Jobs().Lister(),
+		cronJobs:     factory.Batch().V1().CronJobs().Lister(),
+		notify:       make(chan struct{}, 1),
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.trigger() },
+		UpdateFunc: func(interface{}, interface{}) { c.trigger() },
+		DeleteFunc: func(interface{}) { c.trigger() },
+	}
+
+	informerList := []cache.SharedIndexInformer{
+		factory.Core().V1().Nodes().Informer(),
+		factory.Core().V1().Namespaces().Informer(),
+		factory.Core().V1().Pods().Informer(),
+		factory.Apps().V1().Deployments().Informer(),
+		factory.Apps().V1().ReplicaSets().Informer(),
+		factory.Apps().V1().StatefulSets().Informer(),
+		factory.Apps().V1().DaemonSets().Informer(),
+		factory.Batch().V1().Jobs().Informer(),
+		factory.Batch().V1().CronJobs().Informer(),
+	}
+	for _, informer := range informerList {
+		informer.AddEventHandler(handler)
+		c.synced = append(c.synced, informer.HasSynced)
+	}
+
+	return c
+}
+
+// trigger notifies the collector to reconcile the cluster.
+func (c *clusterCollector) trigger() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts the informers and reconciles the cluster when its resources change or the resync interval is
+// reached, until the context is done.
+func (c *clusterCollector) Run(ctx context.Context) error {
+	c.factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), c.synced...) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("wait for informer caches of cluster %s to sync failed", c.conf.ClusterUID)
+	}
+
+	ticker := time.NewTicker(c.resync)
+	defer ticker.Stop()
+
+	for {
+		kit := newKit(ctx)
+		if err := c.reconcile(kit); err != nil {
+			blog.Errorf("kube collector| reconcile cluster %s failed, err: %v, rid: %s", c.conf.ClusterUID, err,
+				kit.Rid)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-c.notify:
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(reconcileInterval):
+			}
+		}
+	}
+}
+
+func newKit(ctx context.Context) *rest.Kit {
+	header := headerutil.BuildHeader(common.CCSystemCollectorUserName, common.BKDefaultOwnerID)
+	return &rest.Kit{
+		Rid:             httpheader.GetRid(header),
+		Header:          header,
+		Ctx:             ctx,
+		User:            common.CCSystemCollectorUserName,
+		SupplierAccount: common.BKDefaultOwnerID,
+	}
+}
+
+// reconcile makes the kube resources of the cluster in cmdb consistent with the kubernetes resources. The
+// resources are created and updated from the top to the bottom of the kube topology, and deleted from the
+// bottom to the top, so that the parent of a resource always exists when the resource is written.
+func (c *clusterCollector) reconcile(kit *rest.Kit) error {
+	cluster, err := c.cmdb.GetCluster(kit, c.conf.BizID, c.conf.ClusterUID)
+	if err != nil {
+		return err
+	}
+
+	if cluster == nil {
+		return fmt.Errorf("cluster %s is not registered in biz %d", c.conf.ClusterUID, c.conf.BizID)
+	}
+
+	r := &reconciler{collector: c, kit: kit, bizID: c.conf.BizID, cluster: cluster}
+
+	nodes, staleNodeIDs, err := r.syncNodes()
+	if err != nil {
+		return err
+	}
+
+	namespaceIDs, staleNamespaceIDs, err := r.syncNamespaces()
+	if err != nil {
+		return err
+	}
+
+	workloadIDs, staleWorkloadIDs, err := r.syncWorkloads(namespaceIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := r.syncPods(nodes, namespaceIDs, workloadIDs); err != nil {
+		return err
+	}
+
+	for _, kind := range workloadKinds {
+		if len(staleWorkloadIDs[kind]) == 0 {
+			continue
+		}
+		if err := c.cmdb.DeleteWorkloads(kit, r.bizID, kind, staleWorkloadIDs[kind]); err != nil {
+			return err
+		}
+	}
+
+	if len(staleNamespaceIDs) > 0 {
+		if err := c.cmdb.DeleteNamespaces(kit, r.bizID, staleNamespaceIDs); err != nil {
+			return err
+		}
+	}
+
+	if len(staleNodeIDs) > 0 {
+		if err := c.cmdb.DeleteNodes(kit, r.bizID, staleNodeIDs); err != nil {
+			return err
+		}
+	}
+
+	blog.V(4).Infof("kube collector| reconcile cluster %s success, rid: %s", c.conf.ClusterUID, kit.Rid)
+	return nil
+}
+
+// podOwner returns the kind and name of the workload that the pod belongs to, returns false if the pod is
+// controlled by a controller that is not collected.
+func (c *clusterCollector) podOwner(pod *corev1.Pod) (types.WorkloadType, string, bool) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return types.KubePodWorkload, podsWorkloadName, true
+	}
+
+	switch owner.Kind {
+	case "ReplicaSet":
+		rs, err := c.replicaSets.ReplicaSets(pod.Namespace).Get(owner.Name)
+		if err != nil {
+			return "", "", false
+		}
+
+		rsOwner := metav1.GetControllerOf(rs)
+		if rsOwner == nil || rsOwner.Kind != "Deployment" {
+			return "", "", false
+		}
+		return types.KubeDeployment, rsOwner.Name, true
+
+	case "StatefulSet":
+		return types.KubeStatefulSet, owner.Name, true
+
+	case "DaemonSet":
+		return types.KubeDaemonSet, owner.Name, true
+
+	case "Job":
+		job, err := c.jobs.Jobs(pod.Namespace).Get(owner.Name)
+		if err != nil {
+			return "", "", false
+		}
+
+		if jobOwner := metav1.GetControllerOf(job); jobOwner != nil && jobOwner.Kind == "CronJob" {
+			return types.KubeCronJob, jobOwner.Name, true
+		}
+		return types.KubeJob, owner.Name, true
+
+	default:
+		return "", "", false
+	}
+}
+
+// listPods lists the pods that are scheduled to nodes.
+func (c *clusterCollector) listPods() ([]*corev1.Pod, error) {
+	pods, err := c.pods.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			scheduled = append(scheduled, pod)
+		}
+	}
+	return scheduled, nil
+}
@@ -0,0 +1,499 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"configcenter/pkg/filter"
+	filtertools "configcenter/pkg/tools/filter"
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/util"
+	"configcenter/src/kube/types"
+)
+
+const (
+	// listPageSize is the page size to list kube resources from cmdb.
+	listPageSize = 500
+
+	// writeBatchSize is the max number of kube resources to create or delete in one request.
+	writeBatchSize = 100
+)
+
+// CmdbClient is the cmdb operations that the kubernetes collector depends on.
+type CmdbClient interface {
+	// GetCluster get the registered cluster by uid, returns nil if the cluster does not exist.
+	GetCluster(kit *rest.Kit, bizID int64, uid string) (*types.Cluster, error)
+
+	ListNodes(kit *rest.Kit, bizID, clusterID int64) ([]types.Node, error)
+	CreateNodes(kit *rest.Kit, bizID int64, nodes []types.OneNodeCreateOption) error
+	UpdateNode(kit *rest.Kit, bizID, id int64, node *types.Node) error
+	DeleteNodes(kit *rest.Kit, bizID int64, ids []int64) error
+
+	ListNamespaces(kit *rest.Kit, bizID, clusterID int64) ([]types.Namespace, error)
+	CreateNamespaces(kit *rest.Kit, bizID int64, namespaces []types.Namespace) error
+	UpdateNamespace(kit *rest.Kit, bizID, id int64, namespace *types.Namespace) error
+	DeleteNamespaces(kit *rest.Kit, bizID int64, ids []int64) error
+
+	ListWorkloads(kit *rest.Kit, bizID, clusterID int64, kind types.WorkloadType) ([]types.WorkloadInterface, error)
+	CreateWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType, workloads []types.WorkloadInterface) error
+	UpdateWorkload(kit *rest.Kit, bizID int64, kind types.WorkloadType, id int64,
+		workload types.WorkloadInterface) error
+	DeleteWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType, ids []int64) error
+
+	ListPods(kit *rest.Kit, bizID, clusterID int64) ([]types.Pod, error)
+	CreatePods(kit *rest.Kit, bizID int64, pods []types.PodsInfo) error
+	DeletePods(kit *rest.Kit, bizID int64, ids []int64) error
+
+	// GetHostIDsByIP get the ids of the hosts in the biz by their inner ips, returns a map of ip to host id.
+	GetHostIDsByIP(kit *rest.Kit, bizID int64, ips []string) (map[string]int64, error)
+}
+
+// NewCmdbClient creates a cmdb client that operates kube resources through the kube apis of topo server.
+func NewCmdbClient(clientSet apimachinery.ClientSetInterface) CmdbClient {
+	return &cmdbClient{clientSet: clientSet}
+}
+
+type cmdbClient struct {
+	clientSet apimachinery.ClientSetInterface
+}
+
+func clusterFilter(clusterID int64) *filter.Expression {
+	return filtertools.GenAtomFilter(types.BKClusterIDFiled, filter.Equal, clusterID)
+}
+
+// decodeInfo decodes the info of kube resources returned by topo server into the result.
+func decodeInfo(info interface{}, result interface{}) error {
+	js, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, result)
+}
+
+// splitIDs splits ids into batches that can be written in one request.
+func splitIDs(ids []int64) [][]int64 {
+	batches := make([][]int64, 0)
+	for start := 0; start < len(ids); start += writeBatchSize {
+		end := start + writeBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[start:end])
+	}
+	return batches
+}
+
+// GetCluster get the registered cluster by uid.
+func (c *cmdbClient) GetCluster(kit *rest.Kit, bizID int64, uid string) (*types.Cluster, error) {
+	opt := &types.QueryClusterOption{
+		BizID:  bizID,
+		Filter: filtertools.GenAtomFilter(types.UidField, filter.Equal, uid),
+		Page:   metadata.BasePage{Limit: 1},
+	}
+
+	resp, err := c.clientSet.TopoServer().Kube().SearchCluster(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search cluster %s failed, err: %v, rid: %s", uid, err, kit.Rid)
+		return nil, err
+	}
+
+	result := new(struct {
+		Info []types.Cluster `json:"info"`
+	})
+	if err := decodeInfo(resp.Data, result); err != nil {
+		blog.Errorf("decode cluster %s failed, err: %v, rid: %s", uid, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(result.Info) == 0 {
+		return nil, nil
+	}
+	return &result.Info[0], nil
+}
+
+// ListNodes list all nodes of the cluster.
+func (c *cmdbClient) ListNodes(kit *rest.Kit, bizID, clusterID int64) ([]types.Node, error) {
+	nodes := make([]types.Node, 0)
+	for start := 0; ; start += listPageSize {
+		opt := &types.QueryNodeOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: listPageSize, Sort: types.BKIDField},
+		}
+
+		resp, err := c.clientSet.TopoServer().Kube().SearchNode(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("search nodes of cluster %d failed, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		result := new(struct {
+			Info []types.Node `json:"info"`
+		})
+		if err := decodeInfo(resp.Data, result); err != nil {
+			blog.Errorf("decode nodes of cluster %d failed, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		nodes = append(nodes, result.Info...)
+		if len(result.Info) < listPageSize {
+			return nodes, nil
+		}
+	}
+}
+
+// CreateNodes create nodes.
+func (c *cmdbClient) CreateNodes(kit *rest.Kit, bizID int64, nodes []types.OneNodeCreateOption) error {
+	for start := 0; start < len(nodes); start += writeBatchSize {
+		end := start + writeBatchSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+
+		opt := &types.CreateNodesOption{BizID: bizID, Nodes: nodes[start:end]}
+		if _, err := c.clientSet.TopoServer().Kube().BatchCreateNode(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("create nodes failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateNode update the node.
+func (c *cmdbClient) UpdateNode(kit *rest.Kit, bizID, id int64, node *types.Node) error {
+	opt := &types.UpdateNodeOption{
+		BizID:                 bizID,
+		UpdateNodeByIDsOption: types.UpdateNodeByIDsOption{IDs: []int64{id}, Data: *node},
+	}
+
+	if err := c.clientSet.TopoServer().Kube().UpdateNodeFields(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("update node %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteNodes delete nodes.
+func (c *cmdbClient) DeleteNodes(kit *rest.Kit, bizID int64, ids []int64) error {
+	for _, batch := range splitIDs(ids) {
+		opt := &types.BatchDeleteNodeOption{
+			BizID:                      bizID,
+			BatchDeleteNodeByIDsOption: types.BatchDeleteNodeByIDsOption{IDs: batch},
+		}
+
+		if err := c.clientSet.TopoServer().Kube().BatchDeleteNode(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("delete nodes %v failed, err: %v, rid: %s", batch, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListNamespaces list all namespaces of the cluster.
+func (c *cmdbClient) ListNamespaces(kit *rest.Kit, bizID, clusterID int64) ([]types.Namespace, error) {
+	namespaces := make([]types.Namespace, 0)
+	for start := 0; ; start += listPageSize {
+		opt := &types.NsQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: listPageSize, Sort: types.BKIDField},
+		}
+
+		resp, err := c.clientSet.TopoServer().Kube().ListNamespace(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("list namespaces of cluster %d failed, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		page := make([]types.Namespace, 0)
+		if err := decodeInfo(resp.Info, &page); err != nil {
+			blog.Errorf("decode namespaces of cluster %d failed, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		namespaces = append(namespaces, page...)
+		if len(page) < listPageSize {
+			return namespaces, nil
+		}
+	}
+}
+
+// CreateNamespaces create namespaces.
+func (c *cmdbClient) CreateNamespaces(kit *rest.Kit, bizID int64, namespaces []types.Namespace) error {
+	for start := 0; start < len(namespaces); start += writeBatchSize {
+		end := start + writeBatchSize
+		if end > len(namespaces) {
+			end = len(namespaces)
+		}
+
+		opt := &types.NsCreateOption{BizID: bizID, Data: namespaces[start:end]}
+		if _, err := c.clientSet.TopoServer().Kube().CreateNamespace(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("create namespaces failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateNamespace update the namespace.
+func (c *cmdbClient) UpdateNamespace(kit *rest.Kit, bizID, id int64, namespace *types.Namespace) error {
+	opt := &types.NsUpdateOption{
+		BizID:               bizID,
+		NsUpdateByIDsOption: types.NsUpdateByIDsOption{IDs: []int64{id}, Data: namespace},
+	}
+
+	if err := c.clientSet.TopoServer().Kube().UpdateNamespace(kit.Ctx, kit.Header, opt); err != nil {
+		blog.Errorf("update namespace %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteNamespaces delete namespaces.
+func (c *cmdbClient) DeleteNamespaces(kit *rest.Kit, bizID int64, ids []int64) error {
+	for _, batch := range splitIDs(ids) {
+		opt := &types.NsDeleteOption{BizID: bizID, NsDeleteByIDsOption: types.NsDeleteByIDsOption{IDs: batch}}
+		if err := c.clientSet.TopoServer().Kube().DeleteNamespace(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("delete namespaces %v failed, err: %v, rid: %s", batch, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListWorkloads list all workloads of the kind in the cluster.
+func (c *cmdbClient) ListWorkloads(kit *rest.Kit, bizID, clusterID int64, kind types.WorkloadType) (
+	[]types.WorkloadInterface, error) {
+
+	workloads := make([]types.WorkloadInterface, 0)
+	for start := 0; ; start += listPageSize {
+		opt := &types.WlQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: listPageSize, Sort: types.BKIDField},
+		}
+
+		resp, err := c.clientSet.TopoServer().Kube().ListWorkload(kit.Ctx, kit.Header, kind, opt)
+		if err != nil {
+			blog.Errorf("list %s workloads of cluster %d failed, err: %v, rid: %s", kind, clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		js, jsErr := json.Marshal(resp.Info)
+		if jsErr != nil {
+			return nil, jsErr
+		}
+
+		page, jsErr := types.WlArrayUnmarshalJSON(kind, js)
+		if jsErr != nil {
+			blog.Errorf("decode %s workloads of cluster %d failed, err: %v, rid: %s", kind, clusterID, jsErr,
+				kit.Rid)
+			return nil, jsErr
+		}
+
+		workloads = append(workloads, page...)
+		if len(page) < listPageSize {
+			return workloads, nil
+		}
+	}
+}
+
+// CreateWorkloads create workloads of the kind.
+func (c *cmdbClient) CreateWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType,
+	workloads []types.WorkloadInterface) error {
+
+	for start := 0; start < len(workloads); start += writeBatchSize {
+		end := start + writeBatchSize
+		if end > len(workloads) {
+			end = len(workloads)
+		}
+
+		opt := &types.WlCreateOption{BizID: bizID, Kind: kind, Data: workloads[start:end]}
+		if _, err := c.clientSet.TopoServer().Kube().CreateWorkload(kit.Ctx, kit.Header, kind, opt); err != nil {
+			blog.Errorf("create %s workloads failed, err: %v, rid: %s", kind, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateWorkload update the workload of the kind.
+func (c *cmdbClient) UpdateWorkload(kit *rest.Kit, bizID int64, kind types.WorkloadType, id int64,
+	workload types.WorkloadInterface) error {
+
+	opt := &types.WlUpdateOption{
+		BizID:               bizID,
+		WlUpdateByIDsOption: types.WlUpdateByIDsOption{Kind: kind, IDs: []int64{id}, Data: workload},
+	}
+
+	if err := c.clientSet.TopoServer().Kube().UpdateWorkload(kit.Ctx, kit.Header, kind, opt); err != nil {
+		blog.Errorf("update %s workload %d failed, err: %v, rid: %s", kind, id, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// DeleteWorkloads delete workloads of the kind.
+func (c *cmdbClient) DeleteWorkloads(kit *rest.Kit, bizID int64, kind types.WorkloadType, ids []int64) error {
+	for _, batch := range splitIDs(ids) {
+		opt := &types.WlDeleteOption{BizID: bizID, WlDeleteByIDsOption: types.WlDeleteByIDsOption{IDs: batch}}
+		if err := c.clientSet.TopoServer().Kube().DeleteWorkload(kit.Ctx, kit.Header, kind, opt); err != nil {
+			blog.Errorf("delete %s workloads %v failed, err: %v, rid: %s", kind, batch, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPods list all pods of the cluster.
+func (c *cmdbClient) ListPods(kit *rest.Kit, bizID, clusterID int64) ([]types.Pod, error) {
+	pods := make([]types.Pod, 0)
+	for start := 0; ; start += listPageSize {
+		opt := &types.PodQueryOption{
+			BizID:  bizID,
+			Filter: clusterFilter(clusterID),
+			Page:   metadata.BasePage{Start: start, Limit: listPageSize, Sort: types.BKIDField},
+		}
+
+		resp, err := c.clientSet.TopoServer().Kube().ListPod(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("list pods of cluster %d failed, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		page := make([]types.Pod, 0)
+		if err := decodeInfo(resp.Info, &page); err != nil {
+			blog.Errorf("decode pods of cluster %d failed, err: %v, rid: %s", clusterID, err, kit.Rid)
+			return nil, err
+		}
+
+		pods = append(pods, page...)
+		if len(page) < listPageSize {
+			return pods, nil
+		}
+	}
+}
+
+// CreatePods create pods with their containers.
+func (c *cmdbClient) CreatePods(kit *rest.Kit, bizID int64, pods []types.PodsInfo) error {
+	for start := 0; start < len(pods); start += writeBatchSize {
+		end := start + writeBatchSize
+		if end > len(pods) {
+			end = len(pods)
+		}
+
+		opt := &types.CreatePodsOption{Data: []types.PodsInfoArray{{BizID: bizID, Pods: pods[start:end]}}}
+		if _, err := c.clientSet.TopoServer().Kube().BatchCreatePod(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("create pods failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePods delete pods with their containers.
+func (c *cmdbClient) DeletePods(kit *rest.Kit, bizID int64, ids []int64) error {
+	for _, batch := range splitIDs(ids) {
+		opt := &types.DeletePodsOption{Data: []types.DeletePodData{{BizID: bizID, PodIDs: batch}}}
+		if err := c.clientSet.TopoServer().Kube().DeletePods(kit.Ctx, kit.Header, opt); err != nil {
+			blog.Errorf("delete pods %v failed, err: %v, rid: %s", batch, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// GetHostIDsByIP get the ids of the hosts in the biz by their inner ips.
+func (c *cmdbClient) GetHostIDsByIP(kit *rest.Kit, bizID int64, ips []string) (map[string]int64, error) {
+	hostIDs := make(map[string]int64)
+	if len(ips) == 0 {
+		return hostIDs, nil
+	}
+
+	ipMap := make(map[string]struct{})
+	for _, ip := range ips {
+		ipMap[ip] = struct{}{}
+	}
+
+	for start := 0; start < len(ips); start += common.BKMaxInstanceLimit {
+		end := start + common.BKMaxInstanceLimit
+		if end > len(ips) {
+			end = len(ips)
+		}
+
+		opt := &metadata.ListHosts{
+			BizID:  bizID,
+			Fields: []string{common.BKHostIDField, common.BKHostInnerIPField},
+			HostPropertyFilter: &querybuilder.QueryFilter{
+				Rule: querybuilder.CombinedRule{
+					Condition: querybuilder.ConditionAnd,
+					Rules: []querybuilder.Rule{
+						querybuilder.AtomRule{
+							Field:    common.BKHostInnerIPField,
+							Operator: querybuilder.OperatorIn,
+							Value:    ips[start:end],
+						},
+					},
+				},
+			},
+			Page: metadata.BasePage{Limit: common.BKMaxInstanceLimit},
+		}
+
+		hosts, err := c.clientSet.CoreService().Host().ListHosts(kit.Ctx, kit.Header, opt)
+		if err != nil {
+			blog.Errorf("list hosts by ips failed, biz: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+			return nil, err
+		}
+
+		for _, host := range hosts.Info {
+			hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+			if err != nil {
+				return nil, fmt.Errorf("parse host id %v failed, err: %v", host[common.BKHostIDField], err)
+			}
+
+			for _, ip := range getHostInnerIPs(host[common.BKHostInnerIPField]) {
+				if _, exists := ipMap[ip]; exists {
+					hostIDs[ip] = hostID
+				}
+			}
+		}
+	}
+
+	return hostIDs, nil
+}
+
+// getHostInnerIPs get the inner ips of the host, inner ip can be stored as an array or a comma separated string.
+func getHostInnerIPs(value interface{}) []string {
+	switch ips := value.(type) {
+	case string:
+		return strings.Split(ips, ",")
+	case []interface{}:
+		result := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			result = append(result, util.GetStrByInterface(ip))
+		}
+		return result
+	case []string:
+		return ips
+	default:
+		return make([]string, 0)
+	}
+}
@@ -0,0 +1,379 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/criteria/enumor"
+	"configcenter/src/kube/types"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// nodeRoleLabelPrefix is the prefix of the labels that mark the roles of a node.
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+	// podsWorkloadName is the name of the pods workload that holds the pods without a supported controller
+	// in a namespace.
+	podsWorkloadName = "pods"
+)
+
+// workloadKinds are the workload kinds that the collector collects, game workloads are custom resources that
+// are not supported by the kubernetes client set, so they are not included.
+var workloadKinds = []types.WorkloadType{types.KubeDeployment, types.KubeStatefulSet, types.KubeDaemonSet,
+	types.KubeJob, types.KubeCronJob, types.KubePodWorkload}
+
+// isChanged returns if any field of the update data differs from the existing resource, the fields are compared
+// in json format so that the existing resource returned by cmdb can be compared with the update data directly.
+func isChanged(updateData interface{}, existing interface{}) (bool, error) {
+	updateMap, err := toJSONMap(updateData)
+	if err != nil {
+		return false, err
+	}
+
+	existingMap, err := toJSONMap(existing)
+	if err != nil {
+		return false, err
+	}
+
+	for field, value := range updateMap {
+		if !reflect.DeepEqual(value, existingMap[field]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func toJSONMap(data interface{}) (map[string]interface{}, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(js, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func copyLabels(labels map[string]string) *map[string]string {
+	result := make(map[string]string, len(labels))
+	for key, value := range labels {
+		result[key] = value
+	}
+	return &result
+}
+
+// convertNode converts the kubernetes node to the cmdb node data.
+func convertNode(node *corev1.Node) *types.Node {
+	roles := make([]string, 0)
+	for label := range node.Labels {
+		if strings.HasPrefix(label, nodeRoleLabelPrefix) {
+			roles = append(roles, strings.TrimPrefix(label, nodeRoleLabelPrefix))
+		}
+	}
+	sort.Strings(roles)
+
+	taints := make(enumor.MapStringType)
+	for _, taint := range node.Spec.Taints {
+		taints[taint.Key] = fmt.Sprintf("%s:%s", taint.Value, taint.Effect)
+	}
+
+	internalIP, externalIP := make([]string, 0), make([]string, 0)
+	var hostname string
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeInternalIP:
+			internalIP = append(internalIP, address.Address)
+		case corev1.NodeExternalIP:
+			externalIP = append(externalIP, address.Address)
+		case corev1.NodeHostName:
+			hostname = address.Address
+		}
+	}
+
+	name := node.Name
+	rolesStr := strings.Join(roles, ",")
+	labels := enumor.MapStringType(*copyLabels(node.Labels))
+	unschedulable := node.Spec.Unschedulable
+	runtime := node.Status.NodeInfo.ContainerRuntimeVersion
+	podCidr := node.Spec.PodCIDR
+
+	return &types.Node{
+		Name:             &name,
+		Roles:            &rolesStr,
+		Labels:           &labels,
+		Taints:           &taints,
+		Unschedulable:    &unschedulable,
+		InternalIP:       &internalIP,
+		ExternalIP:       &externalIP,
+		HostName:         &hostname,
+		RuntimeComponent: &runtime,
+		PodCidr:          &podCidr,
+	}
+}
+
+// nodeUpdateData returns the editable fields of the node data.
+func nodeUpdateData(node *types.Node) *types.Node {
+	data := *node
+	data.Name = nil
+	return &data
+}
+
+// convertNamespace converts the kubernetes namespace to the cmdb namespace data.
+func convertNamespace(namespace *corev1.Namespace) *types.Namespace {
+	return &types.Namespace{
+		Name:   namespace.Name,
+		Labels: copyLabels(namespace.Labels),
+	}
+}
+
+// namespaceUpdateData returns the editable fields of the namespace data.
+func namespaceUpdateData(namespace *types.Namespace) *types.Namespace {
+	return &types.Namespace{Labels: namespace.Labels}
+}
+
+func convertLabelSelector(selector *metav1.LabelSelector) *types.LabelSelector {
+	if selector == nil {
+		return nil
+	}
+
+	result := &types.LabelSelector{
+		MatchLabels:      *copyLabels(selector.MatchLabels),
+		MatchExpressions: make([]types.LabelSelectorRequirement, len(selector.MatchExpressions)),
+	}
+	for i, expr := range selector.MatchExpressions {
+		result.MatchExpressions[i] = types.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: types.LabelSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		}
+	}
+	return result
+}
+
+func convertIntOrString(value *intstr.IntOrString) *types.IntOrString {
+	if value == nil {
+		return nil
+	}
+
+	return &types.IntOrString{
+		Type:   types.Type(value.Type),
+		IntVal: value.IntVal,
+		StrVal: value.StrVal,
+	}
+}
+
+func int32PtrToInt64Ptr(value *int32) *int64 {
+	if value == nil {
+		return nil
+	}
+	result := int64(*value)
+	return &result
+}
+
+func int32ToInt64Ptr(value int32) *int64 {
+	result := int64(value)
+	return &result
+}
+
+// workloadBase returns the workload base data of the kubernetes workload.
+func workloadBase(meta metav1.ObjectMeta) types.WorkloadBase {
+	return types.WorkloadBase{
+		NamespaceSpec: types.NamespaceSpec{Namespace: meta.Namespace},
+		Name:          meta.Name,
+	}
+}
+
+func convertDeployment(deploy *appsv1.Deployment) types.WorkloadInterface {
+	strategyType := types.DeploymentStrategyType(deploy.Spec.Strategy.Type)
+	result := &types.Deployment{
+		WorkloadBase:    workloadBase(deploy.ObjectMeta),
+		Labels:          copyLabels(deploy.Labels),
+		Selector:        convertLabelSelector(deploy.Spec.Selector),
+		Replicas:        int32PtrToInt64Ptr(deploy.Spec.Replicas),
+		MinReadySeconds: int32ToInt64Ptr(deploy.Spec.MinReadySeconds),
+		StrategyType:    &strategyType,
+	}
+
+	if rolling := deploy.Spec.Strategy.RollingUpdate; rolling != nil {
+		result.RollingUpdateStrategy = &types.RollingUpdateDeployment{
+			MaxUnavailable: convertIntOrString(rolling.MaxUnavailable),
+			MaxSurge:       convertIntOrString(rolling.MaxSurge),
+		}
+	}
+	return result
+}
+
+func convertStatefulSet(sts *appsv1.StatefulSet) types.WorkloadInterface {
+	strategyType := types.StatefulSetUpdateStrategyType(sts.Spec.UpdateStrategy.Type)
+	result := &types.StatefulSet{
+		WorkloadBase:    workloadBase(sts.ObjectMeta),
+		Labels:          copyLabels(sts.Labels),
+		Selector:        convertLabelSelector(sts.Spec.Selector),
+		Replicas:        int32PtrToInt64Ptr(sts.Spec.Replicas),
+		MinReadySeconds: int32ToInt64Ptr(sts.Spec.MinReadySeconds),
+		StrategyType:    &strategyType,
+	}
+
+	if rolling := sts.Spec.UpdateStrategy.RollingUpdate; rolling != nil {
+		result.RollingUpdateStrategy = &types.RollingUpdateStatefulSetStrategy{
+			Partition:      rolling.Partition,
+			MaxUnavailable: convertIntOrString(rolling.MaxUnavailable),
+		}
+	}
+	return result
+}
+
+func convertDaemonSet(ds *appsv1.DaemonSet) types.WorkloadInterface {
+	strategyType := types.DaemonSetUpdateStrategyType(ds.Spec.UpdateStrategy.Type)
+	result := &types.DaemonSet{
+		WorkloadBase:    workloadBase(ds.ObjectMeta),
+		Labels:          copyLabels(ds.Labels),
+		Selector:        convertLabelSelector(ds.Spec.Selector),
+		Replicas:        int32ToInt64Ptr(ds.Status.DesiredNumberScheduled),
+		MinReadySeconds: int32ToInt64Ptr(ds.Spec.MinReadySeconds),
+		StrategyType:    &strategyType,
+	}
+
+	if rolling := ds.Spec.UpdateStrategy.RollingUpdate; rolling != nil {
+		result.RollingUpdateStrategy = &types.RollingUpdateDaemonSet{
+			MaxUnavailable: convertIntOrString(rolling.MaxUnavailable),
+			MaxSurge:       convertIntOrString(rolling.MaxSurge),
+		}
+	}
+	return result
+}
+
+func convertJob(job *batchv1.Job) types.WorkloadInterface {
+	return &types.Job{
+		WorkloadBase: workloadBase(job.ObjectMeta),
+		Labels:       copyLabels(job.Labels),
+		Selector:     convertLabelSelector(job.Spec.Selector),
+		Replicas:     int32PtrToInt64Ptr(job.Spec.Parallelism),
+	}
+}
+
+func convertCronJob(cronJob *batchv1.CronJob) types.WorkloadInterface {
+	return &types.CronJob{
+		WorkloadBase: workloadBase(cronJob.ObjectMeta),
+		Labels:       copyLabels(cronJob.Labels),
+		Selector:     convertLabelSelector(cronJob.Spec.JobTemplate.Spec.Selector),
+		Replicas:     int32PtrToInt64Ptr(cronJob.Spec.JobTemplate.Spec.Parallelism),
+	}
+}
+
+// newPodsWorkload returns the pods workload data of the namespace.
+func newPodsWorkload(namespace string) types.WorkloadInterface {
+	return &types.PodsWorkload{
+		WorkloadBase: types.WorkloadBase{
+			NamespaceSpec: types.NamespaceSpec{Namespace: namespace},
+			Name:          podsWorkloadName,
+		},
+		Labels: copyLabels(nil),
+	}
+}
+
+// workloadUpdateData returns the editable fields of the workload data.
+func workloadUpdateData(kind types.WorkloadType, workload types.WorkloadInterface) (types.WorkloadInterface,
+	error) {
+
+	data, err := kind.NewInst()
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := json.Marshal(workload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(js, data); err != nil {
+		return nil, err
+	}
+
+	data.SetWorkloadBase(types.WorkloadBase{})
+	return data, nil
+}
+
+// convertPod converts the kubernetes pod to the cmdb pod data, returns false if the pod is not ready to be
+// collected because some of its containers are not created yet.
+func convertPod(pod *corev1.Pod) (*types.PodsInfo, bool) {
+	containerIDs := make(map[string]string)
+	for _, status := range pod.Status.ContainerStatuses {
+		containerIDs[status.Name] = status.ContainerID
+	}
+
+	containers := make([]types.Container, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		containerID := containerIDs[container.Name]
+		if containerID == "" {
+			return nil, false
+		}
+
+		name, image := container.Name, container.Image
+		ports := make([]types.ContainerPort, len(container.Ports))
+		for i, port := range container.Ports {
+			ports[i] = types.ContainerPort{
+				Name:          port.Name,
+				HostPort:      port.HostPort,
+				ContainerPort: port.ContainerPort,
+				Protocol:      types.Protocol(port.Protocol),
+				HostIP:        port.HostIP,
+			}
+		}
+		args := append(make([]string, 0), container.Args...)
+
+		containers = append(containers, types.Container{
+			Name:        &name,
+			ContainerID: &containerID,
+			Image:       &image,
+			Ports:       &ports,
+			Args:        &args,
+		})
+	}
+
+	name, ip := pod.Name, pod.Status.PodIP
+	podIPs := make([]types.PodIP, len(pod.Status.PodIPs))
+	for i, podIP := range pod.Status.PodIPs {
+		podIPs[i] = types.PodIP{IP: podIP.IP}
+	}
+	qosClass := types.PodQOSClass(pod.Status.QOSClass)
+	operator := []string{common.CCSystemCollectorUserName}
+
+	return &types.PodsInfo{
+		Pod: types.Pod{
+			Name:          &name,
+			Priority:      pod.Spec.Priority,
+			Labels:        copyLabels(pod.Labels),
+			IP:            &ip,
+			IPs:           &podIPs,
+			QOSClass:      &qosClass,
+			NodeSelectors: copyLabels(pod.Spec.NodeSelector),
+			Operator:      &operator,
+		},
+		Containers: containers,
+	}, true
+}
@@ -0,0 +1,213 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kube collects kubernetes resources of the registered clusters with informers, and reconciles nodes,
+// namespaces, workloads and pods of them into cmdb through the kube apis of topo server.
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// defaultResyncSeconds is the default interval to reconcile a cluster even if no event happens.
+	defaultResyncSeconds = 300
+
+	// minResyncSeconds is the minimum interval to reconcile a cluster even if no event happens.
+	minResyncSeconds = 60
+
+	// checkClusterInterval is the interval to check which clusters are collected by the local datacollection node.
+	checkClusterInterval = 30 * time.Second
+)
+
+// Config is the kubernetes collector configs.
+type Config struct {
+	// Enabled marks if the kubernetes collector is enabled.
+	Enabled bool `mapstructure:"enabled"`
+
+	// ResyncSeconds is the interval to reconcile a cluster even if no event happens.
+	ResyncSeconds int `mapstructure:"resyncSeconds"`
+
+	// Clusters are the collected clusters, they must have been registered in cmdb.
+	Clusters []ClusterConfig `mapstructure:"clusters"`
+}
+
+// ClusterConfig is the configs of a collected cluster.
+type ClusterConfig struct {
+	// BizID is the id of the biz that the cluster belongs to.
+	BizID int64 `mapstructure:"bizID"`
+
+	// ClusterUID is the uid of the registered cluster in cmdb.
+	ClusterUID string `mapstructure:"clusterUID"`
+
+	// KubeConfig is the path of the kubeconfig file to access the cluster.
+	KubeConfig string `mapstructure:"kubeconfig"`
+}
+
+// Validate validates the kubernetes collector configs and sets the default values.
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.ResyncSeconds == 0 {
+		c.ResyncSeconds = defaultResyncSeconds
+	}
+
+	if c.ResyncSeconds < minResyncSeconds {
+		return fmt.Errorf("kube resyncSeconds %d is less than %d", c.ResyncSeconds, minResyncSeconds)
+	}
+
+	uidMap := make(map[string]struct{})
+	for _, cluster := range c.Clusters {
+		if cluster.BizID <= 0 {
+			return fmt.Errorf("kube cluster %s bizID is invalid", cluster.ClusterUID)
+		}
+
+		if cluster.ClusterUID == "" {
+			return errors.New("kube cluster clusterUID is not set")
+		}
+
+		if _, exists := uidMap[cluster.ClusterUID]; exists {
+			return fmt.Errorf("kube cluster %s is duplicated", cluster.ClusterUID)
+		}
+		uidMap[cluster.ClusterUID] = struct{}{}
+
+		if cluster.KubeConfig == "" {
+			return fmt.Errorf("kube cluster %s kubeconfig is not set", cluster.ClusterUID)
+		}
+	}
+
+	return nil
+}
+
+// Collector runs the collectors of the configured clusters that are hashed to the local datacollection node.
+type Collector struct {
+	conf Config
+	cmdb CmdbClient
+
+	// isMatch returns if the cluster with the uid is collected by the local datacollection node.
+	isMatch func(clusterUID string) bool
+
+	// newClientSet creates the kubernetes client set of the cluster.
+	newClientSet func(conf ClusterConfig) (kubernetes.Interface, error)
+
+	lock sync.Mutex
+	// running is the running cluster collectors, key is cluster uid.
+	running map[string]*runningCollector
+}
+
+type runningCollector struct {
+	cancel context.CancelFunc
+}
+
+// NewCollector creates a new kubernetes collector.
+func NewCollector(conf Config, cmdb CmdbClient, isMatch func(clusterUID string) bool) *Collector {
+	return &Collector{
+		conf:         conf,
+		cmdb:         cmdb,
+		isMatch:      isMatch,
+		newClientSet: newClientSetFromKubeConfig,
+		running:      make(map[string]*runningCollector),
+	}
+}
+
+// newClientSetFromKubeConfig creates the kubernetes client set by the kubeconfig file of the cluster.
+func newClientSetFromKubeConfig(conf ClusterConfig) (kubernetes.Interface, error) {
+	restConf, err := clientcmd.BuildConfigFromFlags("", conf.KubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig %s failed, err: %v", conf.KubeConfig, err)
+	}
+
+	return kubernetes.NewForConfig(restConf)
+}
+
+// Run keeps the collectors of the clusters that are hashed to the local node running until the context is done.
+func (c *Collector) Run(ctx context.Context) {
+	blog.Infof("kube collector| start collecting %d clusters", len(c.conf.Clusters))
+
+	ticker := time.NewTicker(checkClusterInterval)
+	defer ticker.Stop()
+
+	for {
+		c.checkClusters(ctx)
+
+		select {
+		case <-ctx.Done():
+			blog.Infof("kube collector| stop collecting clusters")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkClusters starts the collectors of the clusters that are newly hashed to the local node, and stops the
+// collectors of the clusters that are hashed to other nodes.
+func (c *Collector) checkClusters(ctx context.Context) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, conf := range c.conf.Clusters {
+		running, isRunning := c.running[conf.ClusterUID]
+		isMatch := c.isMatch(conf.ClusterUID)
+
+		if isRunning && !isMatch {
+			blog.Infof("kube collector| cluster %s is moved to another node, stop collecting it", conf.ClusterUID)
+			running.cancel()
+			delete(c.running, conf.ClusterUID)
+			continue
+		}
+
+		if isRunning || !isMatch {
+			continue
+		}
+
+		clientSet, err := c.newClientSet(conf)
+		if err != nil {
+			blog.Errorf("kube collector| create client set for cluster %s failed, err: %v", conf.ClusterUID, err)
+			continue
+		}
+
+		clusterCtx, cancel := context.WithCancel(ctx)
+		running = &runningCollector{cancel: cancel}
+		c.running[conf.ClusterUID] = running
+
+		collector := newClusterCollector(conf, clientSet, c.cmdb, time.Duration(c.conf.ResyncSeconds)*time.Second)
+		go c.runCluster(clusterCtx, collector, running)
+	}
+}
+
+func (c *Collector) runCluster(ctx context.Context, collector *clusterCollector, running *runningCollector) {
+	uid := collector.conf.ClusterUID
+	blog.Infof("kube collector| start collecting cluster %s", uid)
+
+	if err := collector.Run(ctx); err != nil {
+		blog.Errorf("kube collector| collect cluster %s failed, err: %v", uid, err)
+	}
+
+	// remove the stopped collector so that it can be restarted by the next check
+	c.lock.Lock()
+	if c.running[uid] == running {
+		running.cancel()
+		delete(c.running, uid)
+	}
+	c.lock.Unlock()
+}
@@ -0,0 +1,374 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/kube/types"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	testBizID     = int64(2)
+	testClusterID = int64(1)
+	testUID       = "BCS-K8S-00001"
+	nodeTable     = "node"
+	nsTable       = "namespace"
+	podTable      = "pod"
+)
+
+// fakeCmdb is an in-memory cmdb that stores kube resources of one cluster in json format like topo server does.
+type fakeCmdb struct {
+	hosts  map[string]int64
+	nextID int64
+	tables map[string]map[int64]map[string]interface{}
+	writes int
+}
+
+func newFakeCmdb(hosts map[string]int64) *fakeCmdb {
+	return &fakeCmdb{hosts: hosts, tables: make(map[string]map[int64]map[string]interface{})}
+}
+
+func (f *fakeCmdb) insert(table string, data interface{}) int64 {
+	doc, _ := toJSONMap(data)
+	f.nextID++
+	doc["id"] = f.nextID
+	if f.tables[table] == nil {
+		f.tables[table] = make(map[int64]map[string]interface{})
+	}
+	f.tables[table][f.nextID] = doc
+	f.writes++
+	return f.nextID
+}
+
+func (f *fakeCmdb) update(table string, id int64, data interface{}) {
+	doc, _ := toJSONMap(data)
+	for key, value := range doc {
+		f.tables[table][id][key] = value
+	}
+	f.writes++
+}
+
+func (f *fakeCmdb) remove(table string, ids []int64) {
+	for _, id := range ids {
+		delete(f.tables[table], id)
+	}
+	f.writes++
+}
+
+func (f *fakeCmdb) list(table string) []map[string]interface{} {
+	ids := make([]int64, 0)
+	for id := range f.tables[table] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	docs := make([]map[string]interface{}, len(ids))
+	for i, id := range ids {
+		docs[i] = f.tables[table][id]
+	}
+	return docs
+}
+
+func (f *fakeCmdb) GetCluster(_ *rest.Kit, bizID int64, uid string) (*types.Cluster, error) {
+	if bizID != testBizID || uid != testUID {
+		return nil, nil
+	}
+	clusterUID := testUID
+	return &types.Cluster{ID: testClusterID, BizID: testBizID, Uid: &clusterUID}, nil
+}
+
+func (f *fakeCmdb) ListNodes(*rest.Kit, int64, int64) ([]types.Node, error) {
+	nodes := make([]types.Node, 0)
+	return nodes, decodeInfo(f.list(nodeTable), &nodes)
+}
+
+func (f *fakeCmdb) CreateNodes(_ *rest.Kit, _ int64, nodes []types.OneNodeCreateOption) error {
+	for _, node := range nodes {
+		f.insert(nodeTable, node)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) UpdateNode(_ *rest.Kit, _, id int64, node *types.Node) error {
+	f.update(nodeTable, id, node)
+	return nil
+}
+
+func (f *fakeCmdb) DeleteNodes(_ *rest.Kit, _ int64, ids []int64) error {
+	f.remove(nodeTable, ids)
+	return nil
+}
+
+func (f *fakeCmdb) ListNamespaces(*rest.Kit, int64, int64) ([]types.Namespace, error) {
+	namespaces := make([]types.Namespace, 0)
+	return namespaces, decodeInfo(f.list(nsTable), &namespaces)
+}
+
+func (f *fakeCmdb) CreateNamespaces(_ *rest.Kit, _ int64, namespaces []types.Namespace) error {
+	for _, namespace := range namespaces {
+		f.insert(nsTable, namespace)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) UpdateNamespace(_ *rest.Kit, _, id int64, namespace *types.Namespace) error {
+	f.update(nsTable, id, namespace)
+	return nil
+}
+
+func (f *fakeCmdb) DeleteNamespaces(_ *rest.Kit, _ int64, ids []int64) error {
+	f.remove(nsTable, ids)
+	return nil
+}
+
+func (f *fakeCmdb) ListWorkloads(_ *rest.Kit, _, _ int64, kind types.WorkloadType) ([]types.WorkloadInterface,
+	error) {
+
+	js, err := json.Marshal(f.list(string(kind)))
+	if err != nil {
+		return nil, err
+	}
+	return types.WlArrayUnmarshalJSON(kind, js)
+}
+
+func (f *fakeCmdb) CreateWorkloads(_ *rest.Kit, _ int64, kind types.WorkloadType,
+	workloads []types.WorkloadInterface) error {
+
+	for _, workload := range workloads {
+		f.insert(string(kind), workload)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) UpdateWorkload(_ *rest.Kit, _ int64, kind types.WorkloadType, id int64,
+	workload types.WorkloadInterface) error {
+
+	f.update(string(kind), id, workload)
+	return nil
+}
+
+func (f *fakeCmdb) DeleteWorkloads(_ *rest.Kit, _ int64, kind types.WorkloadType, ids []int64) error {
+	f.remove(string(kind), ids)
+	return nil
+}
+
+func (f *fakeCmdb) ListPods(*rest.Kit, int64, int64) ([]types.Pod, error) {
+	pods := make([]types.Pod, 0)
+	return pods, decodeInfo(f.list(podTable), &pods)
+}
+
+func (f *fakeCmdb) CreatePods(_ *rest.Kit, _ int64, pods []types.PodsInfo) error {
+	for _, info := range pods {
+		pod := info.Pod
+		ref := info.Spec.Ref
+		pod.Ref = &ref
+		pod.ClusterID = info.Spec.ClusterID
+		pod.NamespaceID = info.Spec.NamespaceID
+		pod.Namespace = f.tables[nsTable][info.Spec.NamespaceID]["name"].(string)
+		pod.NodeID = info.Spec.NodeID
+		pod.HostID = info.HostID
+		f.insert(podTable, pod)
+	}
+	return nil
+}
+
+func (f *fakeCmdb) DeletePods(_ *rest.Kit, _ int64, ids []int64) error {
+	f.remove(podTable, ids)
+	return nil
+}
+
+func (f *fakeCmdb) GetHostIDsByIP(_ *rest.Kit, _ int64, ips []string) (map[string]int64, error) {
+	hostIDs := make(map[string]int64)
+	for _, ip := range ips {
+		if hostID, exists := f.hosts[ip]; exists {
+			hostIDs[ip] = hostID
+		}
+	}
+	return hostIDs, nil
+}
+
+func (f *fakeCmdb) names(table string) []string {
+	names := make([]string, 0)
+	for _, doc := range f.list(table) {
+		names = append(names, doc["name"].(string))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newNode(name, ip string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{nodeRoleLabelPrefix + "worker": ""}},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+		},
+	}
+}
+
+func newPod(name, node string, owner *metav1.OwnerReference) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:   node,
+			Containers: []corev1.Container{{Name: "app", Image: "nginx"}},
+		},
+		Status: corev1.PodStatus{
+			PodIP:             "172.16.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", ContainerID: "containerd://" + name}},
+		},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func controllerRef(kind, name string) *metav1.OwnerReference {
+	isController := true
+	return &metav1.OwnerReference{Kind: kind, Name: name, Controller: &isController}
+}
+
+func startCollector(t *testing.T, ctx context.Context, clientSet *fake.Clientset,
+	cmdb *fakeCmdb) *clusterCollector {
+
+	conf := ClusterConfig{BizID: testBizID, ClusterUID: testUID}
+	collector := newClusterCollector(conf, clientSet, cmdb, time.Minute)
+	collector.factory.Start(ctx.Done())
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), collector.synced...))
+	return collector
+}
+
+func TestReconcile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replicas := int32(2)
+	clientSet := fake.NewSimpleClientset(
+		newNode("node1", "10.0.0.1"),
+		newNode("node2", "10.0.0.2"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{*controllerRef("Deployment", "web")}},
+		},
+		newPod("web-abc-1", "node1", controllerRef("ReplicaSet", "web-abc")),
+		newPod("static", "node1", nil),
+		newPod("web-abc-2", "node2", controllerRef("ReplicaSet", "web-abc")),
+	)
+	cmdb := newFakeCmdb(map[string]int64{"10.0.0.1": 100})
+	collector := startCollector(t, ctx, clientSet, cmdb)
+
+	require.NoError(t, collector.reconcile(newKit(ctx)))
+
+	require.Equal(t, []string{"node1", "node2"}, cmdb.names(nodeTable))
+	nodes, err := cmdb.ListNodes(nil, testBizID, testClusterID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), nodes[0].HostID)
+	require.Equal(t, int64(0), nodes[1].HostID)
+	require.Equal(t, "worker", *nodes[0].Roles)
+
+	require.Equal(t, []string{"default"}, cmdb.names(nsTable))
+	require.Equal(t, []string{"web"}, cmdb.names(string(types.KubeDeployment)))
+	require.Equal(t, []string{podsWorkloadName}, cmdb.names(string(types.KubePodWorkload)))
+
+	// pod on the node that has no host is not collected
+	require.Equal(t, []string{"static", "web-abc-1"}, cmdb.names(podTable))
+	pods, err := cmdb.ListPods(nil, testBizID, testClusterID)
+	require.NoError(t, err)
+	for _, pod := range pods {
+		require.Equal(t, int64(100), pod.HostID)
+		require.Equal(t, "default", pod.Namespace)
+		if *pod.Name == "web-abc-1" {
+			require.Equal(t, types.KubeDeployment, pod.Ref.Kind)
+			require.Equal(t, "web", pod.Ref.Name)
+		} else {
+			require.Equal(t, types.KubePodWorkload, pod.Ref.Kind)
+		}
+	}
+
+	// reconcile without changes writes nothing
+	writes := cmdb.writes
+	require.NoError(t, collector.reconcile(newKit(ctx)))
+	require.Equal(t, writes, cmdb.writes)
+
+	// changed workload is updated, vanished resources are deleted
+	replicas = 3
+	_, err = clientSet.AppsV1().Deployments("default").Update(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, clientSet.CoreV1().Pods("default").Delete(ctx, "static", metav1.DeleteOptions{}))
+	require.NoError(t, clientSet.CoreV1().Pods("default").Delete(ctx, "web-abc-2", metav1.DeleteOptions{}))
+	require.NoError(t, clientSet.CoreV1().Nodes().Delete(ctx, "node2", metav1.DeleteOptions{}))
+
+	require.Eventually(t, func() bool {
+		kubeNodes, _ := collector.nodes.List(labels.Everything())
+		kubePods, _ := collector.pods.List(labels.Everything())
+		deploy, _ := collector.deployments.Deployments("default").Get("web")
+		return len(kubeNodes) == 1 && len(kubePods) == 1 && deploy != nil && *deploy.Spec.Replicas == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, collector.reconcile(newKit(ctx)))
+
+	require.Equal(t, []string{"node1"}, cmdb.names(nodeTable))
+	require.Equal(t, []string{"web-abc-1"}, cmdb.names(podTable))
+	require.Empty(t, cmdb.names(string(types.KubePodWorkload)))
+
+	workloads, err := cmdb.ListWorkloads(nil, testBizID, testClusterID, types.KubeDeployment)
+	require.NoError(t, err)
+	require.Len(t, workloads, 1)
+	require.Equal(t, int64(3), *workloads[0].(*types.Deployment).Replicas)
+}
+
+func TestReconcileUnregisteredCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmdb := newFakeCmdb(nil)
+	collector := newClusterCollector(ClusterConfig{BizID: testBizID, ClusterUID: "unknown"},
+		fake.NewSimpleClientset(), cmdb, time.Minute)
+
+	require.Error(t, collector.reconcile(newKit(ctx)))
+	require.Zero(t, cmdb.writes)
+}
+
+func TestConfigValidate(t *testing.T) {
+	conf := Config{Enabled: true, Clusters: []ClusterConfig{{BizID: 2, ClusterUID: testUID, KubeConfig: "a"}}}
+	require.NoError(t, conf.Validate())
+	require.Equal(t, defaultResyncSeconds, conf.ResyncSeconds)
+
+	conf.Clusters = append(conf.Clusters, ClusterConfig{BizID: 2, ClusterUID: testUID, KubeConfig: "b"})
+	require.Error(t, conf.Validate())
+
+	conf = Config{Enabled: true, Clusters: []ClusterConfig{{BizID: 2, ClusterUID: testUID}}}
+	require.Error(t, conf.Validate())
+}
@@ -0,0 +1,511 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kube
+
+import (
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/kube/types"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// reconciler reconciles the kube resources of a cluster in one round.
+type reconciler struct {
+	collector *clusterCollector
+	kit       *rest.Kit
+	bizID     int64
+	cluster   *types.Cluster
+}
+
+func resourceKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func (r *reconciler) clusterSpec() types.ClusterSpec {
+	spec := types.ClusterSpec{BizID: r.bizID, ClusterID: r.cluster.ID}
+	if r.cluster.Uid != nil {
+		spec.ClusterUID = *r.cluster.Uid
+	}
+	return spec
+}
+
+// syncNodes creates and updates the nodes of the cluster in cmdb, returns the cmdb nodes by name and the ids of
+// the cmdb nodes that no longer exist in the cluster.
+func (r *reconciler) syncNodes() (map[string]types.Node, []int64, error) {
+	kubeNodes, err := r.collector.nodes.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := r.collector.cmdb.ListNodes(r.kit, r.bizID, r.cluster.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existingMap := make(map[string]types.Node)
+	for _, node := range existing {
+		if node.Name != nil {
+			existingMap[*node.Name] = node
+		}
+	}
+
+	createNodes := make([]types.OneNodeCreateOption, 0)
+	for _, kubeNode := range kubeNodes {
+		data := convertNode(kubeNode)
+		node, exists := existingMap[kubeNode.Name]
+		if !exists {
+			createNodes = append(createNodes, types.OneNodeCreateOption{
+				BizID:     r.bizID,
+				ClusterID: r.cluster.ID,
+				Node:      *data,
+			})
+			continue
+		}
+
+		updateData := nodeUpdateData(data)
+		changed, err := isChanged(updateData, node)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if changed {
+			if err := r.collector.cmdb.UpdateNode(r.kit, r.bizID, node.ID, updateData); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if len(createNodes) > 0 {
+		if err := r.matchNodeHosts(createNodes); err != nil {
+			return nil, nil, err
+		}
+
+		if err := r.collector.cmdb.CreateNodes(r.kit, r.bizID, createNodes); err != nil {
+			return nil, nil, err
+		}
+
+		if existing, err = r.collector.cmdb.ListNodes(r.kit, r.bizID, r.cluster.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	kubeNodeMap := make(map[string]struct{})
+	for _, kubeNode := range kubeNodes {
+		kubeNodeMap[kubeNode.Name] = struct{}{}
+	}
+
+	nodes := make(map[string]types.Node)
+	staleIDs := make([]int64, 0)
+	for _, node := range existing {
+		if node.Name == nil {
+			continue
+		}
+
+		if _, exists := kubeNodeMap[*node.Name]; !exists {
+			staleIDs = append(staleIDs, node.ID)
+			continue
+		}
+		nodes[*node.Name] = node
+	}
+
+	return nodes, staleIDs, nil
+}
+
+// matchNodeHosts sets the ids of the hosts in the biz whose inner ips match the internal ips of the nodes.
+func (r *reconciler) matchNodeHosts(nodes []types.OneNodeCreateOption) error {
+	ips := make([]string, 0)
+	for _, node := range nodes {
+		if node.InternalIP != nil {
+			ips = append(ips, *node.InternalIP...)
+		}
+	}
+
+	hostIDs, err := r.collector.cmdb.GetHostIDsByIP(r.kit, r.bizID, ips)
+	if err != nil {
+		return err
+	}
+
+	for i, node := range nodes {
+		if node.InternalIP == nil {
+			continue
+		}
+
+		for _, ip := range *node.InternalIP {
+			if hostID, exists := hostIDs[ip]; exists {
+				nodes[i].HostID = hostID
+				break
+			}
+		}
+
+		if nodes[i].HostID == 0 {
+			blog.Warnf("kube collector| no host in biz %d matches node %s with ips %v, rid: %s", r.bizID,
+				*node.Name, *node.InternalIP, r.kit.Rid)
+		}
+	}
+
+	return nil
+}
+
+// syncNamespaces creates and updates the namespaces of the cluster in cmdb, returns the cmdb namespace ids by name
+// and the ids of the cmdb namespaces that no longer exist in the cluster.
+func (r *reconciler) syncNamespaces() (map[string]int64, []int64, error) {
+	kubeNamespaces, err := r.collector.namespaces.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := r.collector.cmdb.ListNamespaces(r.kit, r.bizID, r.cluster.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existingMap := make(map[string]types.Namespace)
+	for _, namespace := range existing {
+		existingMap[namespace.Name] = namespace
+	}
+
+	createNamespaces := make([]types.Namespace, 0)
+	kubeNamespaceMap := make(map[string]struct{})
+	for _, kubeNamespace := range kubeNamespaces {
+		kubeNamespaceMap[kubeNamespace.Name] = struct{}{}
+
+		data := convertNamespace(kubeNamespace)
+		namespace, exists := existingMap[kubeNamespace.Name]
+		if !exists {
+			data.ClusterSpec = r.clusterSpec()
+			createNamespaces = append(createNamespaces, *data)
+			continue
+		}
+
+		updateData := namespaceUpdateData(data)
+		changed, err := isChanged(updateData, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if changed {
+			if err := r.collector.cmdb.UpdateNamespace(r.kit, r.bizID, namespace.ID, updateData); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if len(createNamespaces) > 0 {
+		if err := r.collector.cmdb.CreateNamespaces(r.kit, r.bizID, createNamespaces); err != nil {
+			return nil, nil, err
+		}
+
+		if existing, err = r.collector.cmdb.ListNamespaces(r.kit, r.bizID, r.cluster.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	namespaceIDs := make(map[string]int64)
+	staleIDs := make([]int64, 0)
+	for _, namespace := range existing {
+		if _, exists := kubeNamespaceMap[namespace.Name]; !exists {
+			staleIDs = append(staleIDs, namespace.ID)
+			continue
+		}
+		namespaceIDs[namespace.Name] = namespace.ID
+	}
+
+	return namespaceIDs, staleIDs, nil
+}
+
+// listWorkloads lists the kubernetes workloads of the kind and converts them to the cmdb workload data.
+func (r *reconciler) listWorkloads(kind types.WorkloadType) ([]types.WorkloadInterface, error) {
+	c := r.collector
+	workloads := make([]types.WorkloadInterface, 0)
+
+	switch kind {
+	case types.KubeDeployment:
+		list, err := c.deployments.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			workloads = append(workloads, convertDeployment(item))
+		}
+
+	case types.KubeStatefulSet:
+		list, err := c.statefulSets.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			workloads = append(workloads, convertStatefulSet(item))
+		}
+
+	case types.KubeDaemonSet:
+		list, err := c.daemonSets.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			workloads = append(workloads, convertDaemonSet(item))
+		}
+
+	case types.KubeJob:
+		list, err := c.jobs.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			workloads = append(workloads, convertJob(item))
+		}
+
+	case types.KubeCronJob:
+		list, err := c.cronJobs.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			workloads = append(workloads, convertCronJob(item))
+		}
+
+	case types.KubePodWorkload:
+		// pods that are not controlled by any controller are collected into the pods workload of their namespace
+		pods, err := c.listPods()
+		if err != nil {
+			return nil, err
+		}
+
+		namespaceMap := make(map[string]struct{})
+		for _, pod := range pods {
+			if ownerKind, _, ok := c.podOwner(pod); ok && ownerKind == types.KubePodWorkload {
+				namespaceMap[pod.Namespace] = struct{}{}
+			}
+		}
+		for namespace := range namespaceMap {
+			workloads = append(workloads, newPodsWorkload(namespace))
+		}
+	}
+
+	return workloads, nil
+}
+
+// syncWorkloads creates and updates the workloads of the cluster in cmdb, returns the cmdb workload ids by kind and
+// resource key, and the ids of the cmdb workloads that no longer exist in the cluster by kind.
+func (r *reconciler) syncWorkloads(namespaceIDs map[string]int64) (map[types.WorkloadType]map[string]int64,
+	map[types.WorkloadType][]int64, error) {
+
+	workloadIDs := make(map[types.WorkloadType]map[string]int64)
+	staleIDs := make(map[types.WorkloadType][]int64)
+
+	for _, kind := range workloadKinds {
+		ids, stale, err := r.syncKindWorkloads(kind, namespaceIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		workloadIDs[kind] = ids
+		staleIDs[kind] = stale
+	}
+
+	return workloadIDs, staleIDs, nil
+}
+
+func (r *reconciler) syncKindWorkloads(kind types.WorkloadType, namespaceIDs map[string]int64) (map[string]int64,
+	[]int64, error) {
+
+	kubeWorkloads, err := r.listWorkloads(kind)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := r.collector.cmdb.ListWorkloads(r.kit, r.bizID, r.cluster.ID, kind)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existingMap := make(map[string]types.WorkloadInterface)
+	for _, workload := range existing {
+		base := workload.GetWorkloadBase()
+		existingMap[resourceKey(base.Namespace, base.Name)] = workload
+	}
+
+	createWorkloads := make([]types.WorkloadInterface, 0)
+	kubeWorkloadMap := make(map[string]struct{})
+	for _, kubeWorkload := range kubeWorkloads {
+		base := kubeWorkload.GetWorkloadBase()
+		namespaceID, exists := namespaceIDs[base.Namespace]
+		if !exists {
+			continue
+		}
+
+		key := resourceKey(base.Namespace, base.Name)
+		kubeWorkloadMap[key] = struct{}{}
+
+		workload, exists := existingMap[key]
+		if !exists {
+			base.NamespaceSpec = types.NamespaceSpec{
+				ClusterSpec: r.clusterSpec(),
+				NamespaceID: namespaceID,
+				Namespace:   base.Namespace,
+			}
+			kubeWorkload.SetWorkloadBase(base)
+			createWorkloads = append(createWorkloads, kubeWorkload)
+			continue
+		}
+
+		updateData, err := workloadUpdateData(kind, kubeWorkload)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		changed, err := isChanged(updateData, workload)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if changed {
+			err := r.collector.cmdb.UpdateWorkload(r.kit, r.bizID, kind, workload.GetWorkloadBase().ID, updateData)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if len(createWorkloads) > 0 {
+		if err := r.collector.cmdb.CreateWorkloads(r.kit, r.bizID, kind, createWorkloads); err != nil {
+			return nil, nil, err
+		}
+
+		if existing, err = r.collector.cmdb.ListWorkloads(r.kit, r.bizID, r.cluster.ID, kind); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	workloadIDs := make(map[string]int64)
+	staleIDs := make([]int64, 0)
+	for _, workload := range existing {
+		base := workload.GetWorkloadBase()
+		key := resourceKey(base.Namespace, base.Name)
+		if _, exists := kubeWorkloadMap[key]; !exists {
+			staleIDs = append(staleIDs, base.ID)
+			continue
+		}
+		workloadIDs[key] = base.ID
+	}
+
+	return workloadIDs, staleIDs, nil
+}
+
+// syncPods deletes the cmdb pods that no longer exist in the cluster or whose placement changed, and creates the
+// pods that are not in cmdb yet. Pods can not be updated in cmdb, so a changed pod is deleted and created again.
+func (r *reconciler) syncPods(nodes map[string]types.Node, namespaceIDs map[string]int64,
+	workloadIDs map[types.WorkloadType]map[string]int64) error {
+
+	kubePods, err := r.collector.listPods()
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]*types.PodsInfo)
+	for _, pod := range kubePods {
+		kind, name, ok := r.collector.podOwner(pod)
+		if !ok {
+			continue
+		}
+
+		node, exists := nodes[pod.Spec.NodeName]
+		if !exists || node.HostID == 0 {
+			blog.V(4).Infof("kube collector| node %s of pod %s/%s has no host, skip it, rid: %s",
+				pod.Spec.NodeName, pod.Namespace, pod.Name, r.kit.Rid)
+			continue
+		}
+
+		namespaceID, exists := namespaceIDs[pod.Namespace]
+		if !exists {
+			continue
+		}
+
+		workloadID, exists := workloadIDs[kind][resourceKey(pod.Namespace, name)]
+		if !exists {
+			continue
+		}
+
+		info, ready := convertPod(pod)
+		if !ready {
+			continue
+		}
+
+		info.Spec = types.SpecSimpleInfo{
+			ClusterID:   r.cluster.ID,
+			NamespaceID: namespaceID,
+			Ref:         types.Reference{Kind: kind, Name: name, ID: workloadID},
+			NodeID:      node.ID,
+		}
+		info.HostID = node.HostID
+		desired[resourceKey(pod.Namespace, pod.Name)] = info
+	}
+
+	existing, err := r.collector.cmdb.ListPods(r.kit, r.bizID, r.cluster.ID)
+	if err != nil {
+		return err
+	}
+
+	staleIDs := make([]int64, 0)
+	existingMap := make(map[string]types.Pod)
+	for _, pod := range existing {
+		if pod.Name == nil {
+			continue
+		}
+
+		key := resourceKey(pod.Namespace, *pod.Name)
+		info, exists := desired[key]
+		if _, duplicated := existingMap[key]; duplicated || !exists || isPodChanged(pod, info) {
+			staleIDs = append(staleIDs, pod.ID)
+			continue
+		}
+		existingMap[key] = pod
+	}
+
+	createPods := make([]types.PodsInfo, 0)
+	for key, info := range desired {
+		if _, exists := existingMap[key]; !exists {
+			createPods = append(createPods, *info)
+		}
+	}
+
+	if len(staleIDs) > 0 {
+		if err := r.collector.cmdb.DeletePods(r.kit, r.bizID, staleIDs); err != nil {
+			return err
+		}
+	}
+
+	if len(createPods) > 0 {
+		if err := r.collector.cmdb.CreatePods(r.kit, r.bizID, createPods); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isPodChanged returns if the cmdb pod is placed differently with the kubernetes pod.
+func isPodChanged(pod types.Pod, info *types.PodsInfo) bool {
+	if pod.Ref == nil || pod.Ref.Kind != info.Spec.Ref.Kind || pod.Ref.ID != info.Spec.Ref.ID {
+		return true
+	}
+
+	if pod.NodeID != info.Spec.NodeID || pod.HostID != info.HostID {
+		return true
+	}
+
+	if pod.IP == nil || info.IP == nil {
+		return pod.IP != info.IP
+	}
+	return *pod.IP != *info.IP
+}
@@ -17,6 +17,7 @@ import (
 	"configcenter/src/ac/iam"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/scene_server/event_server/subscription"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -63,4 +64,7 @@ type Config struct {
 
 	// ApiConf gse apiServer connection config
 	ApiConf *client.GseConnConfig
+
+	// WebhookConf webhook subscription delivery config
+	WebhookConf *subscription.Conf
 }
@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"configcenter/src/ac"
 	"configcenter/src/ac/extensions"
 	"configcenter/src/ac/iam"
 	"configcenter/src/common/auth"
@@ -30,6 +31,7 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/scene_server/event_server/app/options"
 	svc "configcenter/src/scene_server/event_server/service"
+	"configcenter/src/scene_server/event_server/subscription"
 	"configcenter/src/scene_server/event_server/sync/hostidentifier"
 	eventtype "configcenter/src/scene_server/event_server/types"
 	"configcenter/src/storage/dal"
@@ -184,6 +186,12 @@ func (es *EventServer) initConfigs() error {
 		return err
 	}
 
+	es.config.WebhookConf, err = subscription.ParseConf()
+	if err != nil {
+		blog.Errorf("parse eventServer webhook config error, err: %v", err)
+		return err
+	}
+
 	identifierConf, err := hostidentifier.ParseIdentifierConf()
 	if err != nil {
 		blog.Errorf("parse eventServer host identifier config error, err: %v", err)
@@ -240,7 +248,7 @@ func (es *EventServer) initModules() error {
 	blog.Infof("init modules, connected to cc redis, %+v", es.config.Redis)
 
 	// initialize auth authorizer
-	es.service.SetAuthorizer(iam.NewAuthorizer(es.engine.CoreAPI))
+	es.service.SetAuthorizer(ac.NewAuthorizer(es.engine.CoreAPI))
 
 	iamCli := new(iam.IAM)
 	if auth.EnableAuthorize() {
@@ -271,6 +279,9 @@ func (es *EventServer) Run() error {
 	}
 	blog.Info("init modules success!")
 
+	// deliver the watch events to the webhook subscriptions
+	go subscription.NewManager(es.engine, es.db, es.config.WebhookConf).Run(es.ctx)
+
 	if err := es.runSyncData(); err != nil {
 		return err
 	}
@@ -112,6 +112,19 @@ func (s *Service) initService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host_identifier_push_result",
 		Handler: s.GetHostIdentifierPushResult})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/watch/subscription",
+		Handler: s.CreateSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/watch/subscription/{bk_subscription_id}",
+		Handler: s.UpdateSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/watch/subscription/{bk_subscription_id}",
+		Handler: s.DeleteSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/watch/subscription",
+		Handler: s.ListSubscription})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/watch/subscription/dead_letter",
+		Handler: s.ListDeadLetter})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/replay/watch/subscription/{bk_subscription_id}",
+		Handler: s.ReplaySubscription})
+
 	utility.AddToRestfulWebService(web)
 
 }
@@ -0,0 +1,428 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/ac/parser"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/dal/types"
+)
+
+// CreateSubscription create a webhook subscription of the watch events
+func (s *Service) CreateSubscription(ctx *rest.Contexts) {
+	sub := new(watch.Subscription)
+	if err := ctx.DecodeInto(sub); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := sub.Validate(); err != nil {
+		blog.Errorf("create subscription option is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	if err := s.checkSubscriptionNameDuplicate(ctx.Kit, sub.Name, 0); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	id, err := s.db.NextSequence(ctx.Kit.Ctx, common.BKTableNameWatchSubscription)
+	if err != nil {
+		blog.Errorf("generate subscription id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed))
+		return
+	}
+
+	now := time.Now()
+	sub.ID = int64(id)
+	if sub.BatchSize == 0 {
+		sub.BatchSize = watch.DefaultSubscriptionBatchSize
+	}
+	sub.Revision = 1
+	sub.Cursors = make(map[watch.CursorType]string)
+	sub.OwnerID = ctx.Kit.SupplierAccount
+	sub.Creator = ctx.Kit.User
+	sub.Modifier = ctx.Kit.User
+	sub.CreateTime = now
+	sub.LastTime = now
+
+	if err := s.db.Table(common.BKTableNameWatchSubscription).Insert(ctx.Kit.Ctx, sub); err != nil {
+		blog.Errorf("create subscription failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBInsertFailed))
+		return
+	}
+
+	sub.Secret = ""
+	ctx.RespEntity(sub)
+}
+
+// UpdateSubscription update a webhook subscription, the delivery worker is restarted with the updated subscription
+func (s *Service) UpdateSubscription(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(watch.SubscriptionIDField), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, watch.SubscriptionIDField))
+		return
+	}
+
+	opt := new(watch.UpdateSubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("update subscription option is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	if _, err := s.getSubscription(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	data := mapstr.MapStr{
+		common.ModifierField: ctx.Kit.User,
+		common.LastTimeField: time.Now(),
+	}
+	if opt.Name != nil {
+		if err := s.checkSubscriptionNameDuplicate(ctx.Kit, *opt.Name, id); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		data.Set(watch.SubscriptionNameField, *opt.Name)
+	}
+	if opt.CallbackURL != nil {
+		data.Set("bk_callback_url", *opt.CallbackURL)
+	}
+	if opt.Secret != nil {
+		data.Set("bk_secret", *opt.Secret)
+	}
+	if opt.Resources != nil {
+		data.Set(watch.SubscriptionResourcesField, opt.Resources)
+	}
+	if opt.EventTypes != nil {
+		data.Set("bk_event_types", opt.EventTypes)
+	}
+	if opt.Filter != nil {
+		data.Set("bk_filter", opt.Filter)
+	}
+	if opt.BatchSize != nil {
+		batchSize := *opt.BatchSize
+		if batchSize == 0 {
+			batchSize = watch.DefaultSubscriptionBatchSize
+		}
+		data.Set("bk_batch_size", batchSize)
+	}
+	if opt.Enabled != nil {
+		data.Set(watch.SubscriptionEnabledField, *opt.Enabled)
+	}
+
+	if err := s.updateSubscription(ctx.Kit, id, data); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// DeleteSubscription delete a webhook subscription and its dead letters
+func (s *Service) DeleteSubscription(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(watch.SubscriptionIDField), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, watch.SubscriptionIDField))
+		return
+	}
+
+	cond := util.SetModOwner(mapstr.MapStr{watch.SubscriptionIDField: id}, ctx.Kit.SupplierAccount)
+	if err := s.db.Table(common.BKTableNameWatchSubscription).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete subscription %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	if err := s.db.Table(common.BKTableNameWatchDeadLetter).Delete(ctx.Kit.Ctx, cond); err != nil {
+		blog.Errorf("delete subscription %d dead letters failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+// ListSubscription list webhook subscriptions, the secret of the subscription is not returned, only the subscriptions
+// that are created by the user or whose resources are all authorized to be watched by the user are returned
+func (s *Service) ListSubscription(ctx *rest.Contexts) {
+	opt := new(watch.ListSubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("list subscription option is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	cond := mapstr.MapStr{}
+	if len(opt.IDs) > 0 {
+		cond.Set(watch.SubscriptionIDField, mapstr.MapStr{common.BKDBIN: opt.IDs})
+	}
+
+	if auth.EnableAuthorize() {
+		unauthorized, err := s.getUnauthorizedWatchResources(ctx.Kit)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+
+		if len(unauthorized) > 0 {
+			cond.Set(common.BKDBOR, []mapstr.MapStr{
+				{common.CreatorField: ctx.Kit.User},
+				{watch.SubscriptionResourcesField: mapstr.MapStr{common.BKDBNIN: unauthorized}},
+			})
+		}
+	}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	count, err := s.db.Table(common.BKTableNameWatchSubscription).Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count subscriptions failed, cond: %v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = watch.SubscriptionIDField
+	}
+
+	subs := make([]*watch.Subscription, 0)
+	err = s.db.Table(common.BKTableNameWatchSubscription).Find(cond).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).Sort(sort).All(ctx.Kit.Ctx, &subs)
+	if err != nil {
+		blog.Errorf("list subscriptions failed, cond: %v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+
+	ctx.RespEntity(&watch.ListSubscriptionResult{Count: int64(count), Info: subs})
+}
+
+// getUnauthorizedWatchResources get the resources that the user is not authorized to watch
+func (s *Service) getUnauthorizedWatchResources(kit *rest.Kit) ([]watch.CursorType, error) {
+	resources := watch.ListCursorTypes()
+	attributes := make([]meta.ResourceAttribute, len(resources))
+	for i, resource := range resources {
+		attributes[i] = parser.WatchAuthAttribute(string(resource))
+	}
+
+	user := meta.UserInfo{UserName: kit.User, SupplierAccount: kit.SupplierAccount}
+	decisions, err := s.authorizer.AuthorizeBatch(kit.Ctx, kit.Header, user, attributes...)
+	if err != nil {
+		blog.Errorf("authorize watch resources failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommCheckAuthorizeFailed)
+	}
+
+	unauthorized := make([]watch.CursorType, 0)
+	for i, decision := range decisions {
+		if !decision.Authorized {
+			unauthorized = append(unauthorized, resources[i])
+		}
+	}
+	return unauthorized, nil
+}
+
+// ListDeadLetter list the event batches of a subscription that failed to be delivered
+func (s *Service) ListDeadLetter(ctx *rest.Contexts) {
+	opt := new(watch.ListDeadLetterOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("list dead letter option is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	cond := mapstr.MapStr{watch.SubscriptionIDField: opt.SubscriptionID}
+	if len(opt.Resource) > 0 {
+		cond.Set("bk_resource", opt.Resource)
+	}
+	cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+
+	count, err := s.db.Table(common.BKTableNameWatchDeadLetter).Find(cond).Count(ctx.Kit.Ctx)
+	if err != nil {
+		blog.Errorf("count dead letters failed, cond: %v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = "-" + watch.DeadLetterIDField
+	}
+
+	deadLetters := make([]*watch.DeadLetter, 0)
+	err = s.db.Table(common.BKTableNameWatchDeadLetter).Find(cond).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).Sort(sort).All(ctx.Kit.Ctx, &deadLetters)
+	if err != nil {
+		blog.Errorf("list dead letters failed, cond: %v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+		return
+	}
+
+	ctx.RespEntity(&watch.ListDeadLetterResult{Count: int64(count), Info: deadLetters})
+}
+
+// ReplaySubscription reset the delivered cursor of a subscribed resource, the events after the cursor are
+// delivered again. the cursor can be specified directly or by a dead letter.
+func (s *Service) ReplaySubscription(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(watch.SubscriptionIDField), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, watch.SubscriptionIDField))
+		return
+	}
+
+	opt := new(watch.ReplaySubscriptionOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		blog.Errorf("replay subscription option is invalid, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, err.Error()))
+		return
+	}
+
+	sub, ccErr := s.getSubscription(ctx.Kit, id)
+	if ccErr != nil {
+		ctx.RespAutoError(ccErr)
+		return
+	}
+
+	resource, cursor := opt.Resource, opt.Cursor
+	if opt.DeadLetterID != 0 {
+		cond := mapstr.MapStr{watch.DeadLetterIDField: opt.DeadLetterID, watch.SubscriptionIDField: id}
+		cond = util.SetQueryOwner(cond, ctx.Kit.SupplierAccount)
+		deadLetter := new(watch.DeadLetter)
+		if err := s.db.Table(common.BKTableNameWatchDeadLetter).Find(cond).One(ctx.Kit.Ctx, deadLetter); err != nil {
+			if s.db.IsNotFoundError(err) {
+				ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, watch.DeadLetterIDField))
+				return
+			}
+			blog.Errorf("get dead letter failed, cond: %v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+
+		// the events are watched from now on when the subscription starts, they can not be replayed by cursor
+		if len(deadLetter.StartCursor) == 0 {
+			blog.Errorf("dead letter %d has no start cursor to replay from, rid: %s", deadLetter.ID, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, watch.DeadLetterIDField))
+			return
+		}
+		resource, cursor = deadLetter.Resource, deadLetter.StartCursor
+	}
+
+	subscribed := false
+	for _, res := range sub.Resources {
+		if res == resource {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		blog.Errorf("subscription %d does not subscribe resource %s, rid: %s", id, resource, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_resource"))
+		return
+	}
+
+	data := mapstr.MapStr{
+		watch.SubscriptionCursorsField + "." + string(resource): cursor,
+		common.ModifierField: ctx.Kit.User,
+		common.LastTimeField: time.Now(),
+	}
+	if err := s.updateSubscription(ctx.Kit, id, data); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) getSubscription(kit *rest.Kit, id int64) (*watch.Subscription, error) {
+	cond := util.SetQueryOwner(mapstr.MapStr{watch.SubscriptionIDField: id}, kit.SupplierAccount)
+	sub := new(watch.Subscription)
+	if err := s.db.Table(common.BKTableNameWatchSubscription).Find(cond).One(kit.Ctx, sub); err != nil {
+		if s.db.IsNotFoundError(err) {
+			blog.Errorf("subscription %d is not exist, rid: %s", id, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get subscription %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return sub, nil
+}
+
+// updateSubscription update the subscription and increase its revision to restart the delivery worker
+func (s *Service) updateSubscription(kit *rest.Kit, id int64, data mapstr.MapStr) error {
+	cond := util.SetModOwner(mapstr.MapStr{watch.SubscriptionIDField: id}, kit.SupplierAccount)
+	err := s.db.Table(common.BKTableNameWatchSubscription).UpdateMultiModel(kit.Ctx, cond,
+		types.ModeUpdate{Op: "set", Doc: data},
+		types.ModeUpdate{Op: "inc", Doc: mapstr.MapStr{watch.SubscriptionRevisionField: 1}})
+	if err != nil {
+		blog.Errorf("update subscription %d failed, data: %v, err: %v, rid: %s", id, data, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+func (s *Service) checkSubscriptionNameDuplicate(kit *rest.Kit, name string, excludeID int64) error {
+	cond := mapstr.MapStr{watch.SubscriptionNameField: name}
+	if excludeID != 0 {
+		cond.Set(watch.SubscriptionIDField, mapstr.MapStr{common.BKDBNE: excludeID})
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	count, err := s.db.Table(common.BKTableNameWatchSubscription).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count subscription failed, cond: %v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if count > 0 {
+		blog.Errorf("subscription name %s is duplicated, rid: %s", name, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, watch.SubscriptionNameField)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package subscription delivers the watch events to the webhook subscriptions
+package subscription
+
+import (
+	"errors"
+	"time"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+)
+
+const (
+	defaultMaxRetry         = 5
+	defaultTimeout          = 10 * time.Second
+	defaultRetryInterval    = time.Second
+	defaultMaxRetryInterval = time.Minute
+)
+
+// Conf webhook subscription delivery config
+type Conf struct {
+	// MaxRetry is the max retry times of a failed delivery, the batch is put into the dead letter store after that
+	MaxRetry int
+	// Timeout is the http timeout of each delivery
+	Timeout time.Duration
+	// RetryInterval is the interval before the first retry, it is doubled after each retry
+	RetryInterval time.Duration
+	// MaxRetryInterval is the max interval between two retries
+	MaxRetryInterval time.Duration
+}
+
+// ParseConf parse webhook subscription delivery config, default value is used if the config is not set
+func ParseConf() (*Conf, error) {
+	conf := &Conf{
+		MaxRetry:         defaultMaxRetry,
+		Timeout:          defaultTimeout,
+		RetryInterval:    defaultRetryInterval,
+		MaxRetryInterval: defaultMaxRetryInterval,
+	}
+
+	if cc.IsExist("eventServer.webhook.maxRetry") {
+		maxRetry, err := cc.Int("eventServer.webhook.maxRetry")
+		if err != nil {
+			blog.Errorf("get eventServer.webhook.maxRetry error, err: %v", err)
+			return nil, err
+		}
+		if maxRetry < 0 {
+			return nil, errors.New("eventServer.webhook.maxRetry can not be negative")
+		}
+		conf.MaxRetry = maxRetry
+	}
+
+	durations := map[string]*time.Duration{
+		"eventServer.webhook.timeoutSeconds":          &conf.Timeout,
+		"eventServer.webhook.retryIntervalSeconds":    &conf.RetryInterval,
+		"eventServer.webhook.maxRetryIntervalSeconds": &conf.MaxRetryInterval,
+	}
+	for key, duration := range durations {
+		if !cc.IsExist(key) {
+			continue
+		}
+
+		seconds, err := cc.Int(key)
+		if err != nil {
+			blog.Errorf("get %s error, err: %v", key, err)
+			return nil, err
+		}
+		if seconds <= 0 {
+			return nil, errors.New(key + " must be positive")
+		}
+		*duration = time.Duration(seconds) * time.Second
+	}
+
+	return conf, nil
+}
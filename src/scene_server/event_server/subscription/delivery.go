@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common/watch"
+)
+
+// deliverer delivers the event batches to the callback url of the subscriptions
+type deliverer struct {
+	conf   *Conf
+	client *http.Client
+	metric *metric
+}
+
+func newDeliverer(conf *Conf) *deliverer {
+	return &deliverer{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+		metric: initMetric(),
+	}
+}
+
+// deliver posts the events to the callback url of the subscription, failed delivery is retried with exponential
+// backoff. returns the request body, the number of attempts and the error of the last attempt.
+func (d *deliverer) deliver(ctx context.Context, sub *watch.Subscription, resource watch.CursorType,
+	events []*watch.WatchEventDetail) ([]byte, int, error) {
+
+	payload, err := json.Marshal(&watch.DeliveryBatch{
+		SubscriptionID: sub.ID,
+		Resource:       resource,
+		Events:         events,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("marshal delivery batch failed, err: %v", err)
+	}
+
+	interval := d.conf.RetryInterval
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, sub, payload)
+		if err == nil {
+			d.metric.collectDelivered(sub.ID, resource, len(events))
+			return payload, attempt, nil
+		}
+		d.metric.collectFailedDelivery(sub.ID, resource)
+
+		if attempt > d.conf.MaxRetry {
+			return payload, attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return payload, attempt, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > d.conf.MaxRetryInterval {
+			interval = d.conf.MaxRetryInterval
+		}
+	}
+}
+
+func (d *deliverer) post(ctx context.Context, sub *watch.Subscription, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(watch.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(watch.SubscriptionHeader, strconv.FormatInt(sub.ID, 10))
+	if len(sub.Secret) != 0 {
+		req.Header.Set(watch.SignatureHeader, watch.SignPayload(sub.Secret, timestamp, payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("callback responds with status code %d, body: %s", resp.StatusCode, body)
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"configcenter/src/common/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliver(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(watch.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		require.True(t, watch.VerifyPayload("secret", timestamp, body, r.Header.Get(watch.SignatureHeader)))
+		require.Equal(t, "1", r.Header.Get(watch.SubscriptionHeader))
+
+		batch := new(watch.DeliveryBatch)
+		require.NoError(t, json.Unmarshal(body, batch))
+		require.Equal(t, watch.Host, batch.Resource)
+		require.Len(t, batch.Events, 1)
+
+		// fail the first two attempts
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newDeliverer(&Conf{
+		MaxRetry:         3,
+		Timeout:          time.Second,
+		RetryInterval:    time.Millisecond,
+		MaxRetryInterval: 2 * time.Millisecond,
+	})
+	sub := &watch.Subscription{ID: 1, CallbackURL: server.URL, Secret: "secret"}
+	events := []*watch.WatchEventDetail{{Cursor: "cursor", Resource: watch.Host, EventType: watch.Create,
+		Detail: watch.JsonString(`{"bk_host_id":1}`)}}
+
+	_, attempts, err := d.deliver(context.Background(), sub, watch.Host, events)
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	// all the attempts fail
+	atomic.StoreInt32(&calls, -10)
+	payload, attempts, err := d.deliver(context.Background(), sub, watch.Host, events)
+	require.Error(t, err)
+	require.Equal(t, 4, attempts)
+	require.Contains(t, string(payload), `"bk_host_id":1`)
+}
+
+func TestFilterEvents(t *testing.T) {
+	sub := new(watch.Subscription)
+	err := json.Unmarshal([]byte(`{"bk_filter":{"condition":"AND","rules":[
+		{"field":"bk_host_innerip","operator":"equal","value":"127.0.0.1"}]}}`), sub)
+	require.NoError(t, err)
+
+	events := []*watch.WatchEventDetail{
+		{Cursor: "1", Detail: watch.JsonString(`{"bk_host_id":1,"bk_host_innerip":"127.0.0.1"}`)},
+		{Cursor: "2", Detail: watch.JsonString(`{"bk_host_id":2,"bk_host_innerip":"127.0.0.2"}`)},
+	}
+
+	w := &worker{sub: sub}
+	matched := w.filterEvents(events, "")
+	require.Len(t, matched, 1)
+	require.Equal(t, "1", matched[0].Cursor)
+
+	w.sub = new(watch.Subscription)
+	require.Len(t, w.filterEvents(events, ""), 2)
+}
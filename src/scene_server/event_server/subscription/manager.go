@@ -0,0 +1,127 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+	"configcenter/src/storage/dal"
+)
+
+// reconcileInterval is the interval to check the subscriptions and start or stop the delivery workers
+const reconcileInterval = 10 * time.Second
+
+// Manager runs a delivery worker for each resource of the enabled subscriptions on the master event server
+type Manager struct {
+	engine    *backbone.Engine
+	db        dal.RDB
+	deliverer *deliverer
+	workers   map[string]*worker
+}
+
+// NewManager creates a new webhook subscription manager
+func NewManager(engine *backbone.Engine, db dal.RDB, conf *Conf) *Manager {
+	return &Manager{
+		engine:    engine,
+		db:        db,
+		deliverer: newDeliverer(conf),
+		workers:   make(map[string]*worker),
+	}
+}
+
+// Run reconciles the delivery workers with the enabled subscriptions periodically until the context is done
+func (m *Manager) Run(ctx context.Context) {
+	for {
+		if m.engine.Discovery().IsMaster() {
+			if err := m.reconcile(ctx); err != nil {
+				blog.Errorf("reconcile webhook subscription workers failed, err: %v", err)
+			}
+		} else {
+			blog.V(4).Infof("loop webhook subscription delivery, but not master, skip.")
+			m.stopAll()
+		}
+
+		select {
+		case <-ctx.Done():
+			m.stopAll()
+			return
+		case <-time.After(reconcileInterval):
+		}
+	}
+}
+
+func workerKey(subID int64, resource watch.CursorType) string {
+	return fmt.Sprintf("%d:%s", subID, resource)
+}
+
+func (m *Manager) reconcile(ctx context.Context) error {
+	rid := util.GenerateRID()
+	cond := mapstr.MapStr{watch.SubscriptionEnabledField: true}
+	subs := make([]*watch.Subscription, 0)
+	if err := m.db.Table(common.BKTableNameWatchSubscription).Find(cond).All(ctx, &subs); err != nil {
+		blog.Errorf("get enabled webhook subscriptions failed, err: %v, rid: %s", err, rid)
+		return err
+	}
+
+	expected := make(map[string]*watch.Subscription)
+	for _, sub := range subs {
+		for _, resource := range sub.Resources {
+			expected[workerKey(sub.ID, resource)] = sub
+		}
+	}
+
+	// stop the workers whose subscription is removed, disabled or changed, or the worker exits by itself.
+	for key, w := range m.workers {
+		sub, exists := expected[key]
+		if exists && sub.Revision == w.sub.Revision && !w.exited() {
+			continue
+		}
+
+		w.stop()
+		delete(m.workers, key)
+		m.deliverer.metric.deleteLag(w.sub.ID, w.resource)
+	}
+
+	for _, sub := range subs {
+		for _, resource := range sub.Resources {
+			key := workerKey(sub.ID, resource)
+			if _, exists := m.workers[key]; exists {
+				continue
+			}
+
+			w := newWorker(ctx, m, sub, resource)
+			m.workers[key] = w
+			w.start()
+			blog.Infof("start webhook subscription %d worker of resource %s, revision: %d, rid: %s", sub.ID, resource,
+				sub.Revision, rid)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) stopAll() {
+	for key, w := range m.workers {
+		w.stop()
+		delete(m.workers, key)
+		m.deliverer.metric.deleteLag(w.sub.ID, w.resource)
+	}
+}
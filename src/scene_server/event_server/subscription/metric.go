@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common/metrics"
+	"configcenter/src/common/watch"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const subscriptionMetricSubsystem = "webhook"
+
+var (
+	subMtc     *metric
+	subMtcOnce = sync.Once{}
+)
+
+// initMetric register the webhook subscription metrics, the metrics are labeled by the subscription and resource
+func initMetric() *metric {
+	subMtcOnce.Do(func() {
+		labels := []string{"subscription_id", "resource"}
+		m := new(metric)
+
+		m.lag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subscriptionMetricSubsystem,
+			Name:      "lag_seconds",
+			Help:      "the duration(seconds) between the last delivered event occurs and now",
+		}, labels)
+		metrics.Register().MustRegister(m.lag)
+
+		m.deliveredEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subscriptionMetricSubsystem,
+			Name:      "total_delivered_event_count",
+			Help:      "the total count of the events that are delivered successfully",
+		}, labels)
+		metrics.Register().MustRegister(m.deliveredEvents)
+
+		m.failedDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subscriptionMetricSubsystem,
+			Name:      "total_failed_delivery_count",
+			Help:      "the total count of the failed delivery attempts, including the retries",
+		}, labels)
+		metrics.Register().MustRegister(m.failedDeliveries)
+
+		m.deadLetters = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subscriptionMetricSubsystem,
+			Name:      "total_dead_letter_count",
+			Help:      "the total count of the event batches that are put into the dead letter store",
+		}, labels)
+		metrics.Register().MustRegister(m.deadLetters)
+
+		subMtc = m
+	})
+
+	return subMtc
+}
+
+type metric struct {
+	// record the lag of each subscribed resource
+	lag *prometheus.GaugeVec
+	// record the count of the delivered events
+	deliveredEvents *prometheus.CounterVec
+	// record the count of the failed delivery attempts
+	failedDeliveries *prometheus.CounterVec
+	// record the count of the event batches put into the dead letter store
+	deadLetters *prometheus.CounterVec
+}
+
+func labelsOf(subID int64, resource watch.CursorType) prometheus.Labels {
+	return prometheus.Labels{"subscription_id": strconv.FormatInt(subID, 10), "resource": string(resource)}
+}
+
+// collectLag collect the lag by the cluster time of the last delivered event, zero time means no lag
+func (m *metric) collectLag(subID int64, resource watch.CursorType, eventTime time.Time) {
+	lag := float64(0)
+	if !eventTime.IsZero() {
+		lag = time.Since(eventTime).Seconds()
+	}
+	m.lag.With(labelsOf(subID, resource)).Set(lag)
+}
+
+func (m *metric) deleteLag(subID int64, resource watch.CursorType) {
+	m.lag.Delete(labelsOf(subID, resource))
+}
+
+func (m *metric) collectDelivered(subID int64, resource watch.CursorType, count int) {
+	m.deliveredEvents.With(labelsOf(subID, resource)).Add(float64(count))
+}
+
+func (m *metric) collectFailedDelivery(subID int64, resource watch.CursorType) {
+	m.failedDeliveries.With(labelsOf(subID, resource)).Inc()
+}
+
+func (m *metric) collectDeadLetter(subID int64, resource watch.CursorType) {
+	m.deadLetters.With(labelsOf(subID, resource)).Inc()
+}
@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"configcenter/pkg/filter"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+)
+
+// worker watches the events of one resource of a subscription and delivers them to the callback url
+type worker struct {
+	manager  *Manager
+	sub      *watch.Subscription
+	resource watch.CursorType
+	cursor   string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func newWorker(ctx context.Context, m *Manager, sub *watch.Subscription, resource watch.CursorType) *worker {
+	w := &worker{
+		manager:  m,
+		sub:      sub,
+		resource: resource,
+		cursor:   sub.Cursors[resource],
+		done:     make(chan struct{}),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+// start starts the worker in a new goroutine
+func (w *worker) start() {
+	go w.run(w.ctx)
+}
+
+func (w *worker) run(ctx context.Context) {
+	defer close(w.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		rid := util.GenerateRID()
+		header := headerutil.GenCommonHeader(common.CCSystemOperatorUserName, w.sub.OwnerID, rid)
+		if !w.watchAndDeliver(ctx, header, rid) {
+			return
+		}
+	}
+}
+
+// stop stops the worker and waits for it to exit
+func (w *worker) stop() {
+	w.cancel()
+	<-w.done
+}
+
+func (w *worker) exited() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// watchAndDeliver watch one batch of events and deliver the matched ones, returns if the worker should continue
+func (w *worker) watchAndDeliver(ctx context.Context, header http.Header, rid string) bool {
+	opts := &watch.WatchEventOptions{
+		EventTypes: w.sub.EventTypes,
+		Resource:   w.resource,
+		Cursor:     w.cursor,
+	}
+	if len(w.cursor) == 0 {
+		opts.StartFrom = time.Now().Unix()
+	}
+
+	resp, err := w.manager.engine.CoreAPI.CacheService().Cache().Event().InnerWatchEvent(ctx, header, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		if err.GetCode() == common.CCErrEventChainNodeNotExist {
+			// the cursor is expired, watch from now on, the skipped events can be found by the lag metrics.
+			blog.Errorf("subscription %d resource %s cursor %s is expired, reset to watch from now, rid: %s",
+				w.sub.ID, w.resource, w.cursor, rid)
+			w.cursor = ""
+		} else {
+			blog.Errorf("subscription %d watch resource %s failed, err: %v, rid: %s", w.sub.ID, w.resource, err, rid)
+		}
+		time.Sleep(time.Second)
+		return true
+	}
+
+	if resp == nil || len(resp.Events) == 0 {
+		time.Sleep(time.Second)
+		return true
+	}
+
+	if !resp.Watched {
+		w.manager.deliverer.metric.collectLag(w.sub.ID, w.resource, time.Time{})
+		return w.saveCursor(ctx, resp.Events[0].Cursor, rid)
+	}
+
+	startCursor := w.cursor
+	matched := w.filterEvents(resp.Events, rid)
+	batchSize := w.sub.BatchSize
+	if batchSize <= 0 {
+		batchSize = watch.DefaultSubscriptionBatchSize
+	}
+
+	for start := 0; start < len(matched); start += batchSize {
+		end := start + batchSize
+		if end > len(matched) {
+			end = len(matched)
+		}
+		batch := matched[start:end]
+
+		payload, attempts, err := w.manager.deliverer.deliver(ctx, w.sub, w.resource, batch)
+		if ctx.Err() != nil {
+			return false
+		}
+
+		if err != nil {
+			blog.Errorf("deliver subscription %d resource %s events failed after %d attempts, err: %v, rid: %s",
+				w.sub.ID, w.resource, attempts, err, rid)
+			w.saveDeadLetter(ctx, startCursor, batch[len(batch)-1].Cursor, payload, attempts, err, rid)
+		}
+		startCursor = batch[len(batch)-1].Cursor
+	}
+
+	lastCursor := resp.Events[len(resp.Events)-1].Cursor
+	w.manager.deliverer.metric.collectLag(w.sub.ID, w.resource, cursorTime(lastCursor))
+	return w.saveCursor(ctx, lastCursor, rid)
+}
+
+// filterEvents returns the events whose detail matches the filter of the subscription
+func (w *worker) filterEvents(events []*watch.WatchEventDetail, rid string) []*watch.WatchEventDetail {
+	if w.sub.Filter == nil || w.sub.Filter.RuleFactory == nil {
+		return events
+	}
+
+	matched := make([]*watch.WatchEventDetail, 0)
+	for _, event := range events {
+		detail, ok := event.Detail.(watch.JsonString)
+		if !ok {
+			js, err := json.Marshal(event.Detail)
+			if err != nil {
+				blog.Errorf("marshal event detail failed, cursor: %s, err: %v, rid: %s", event.Cursor, err, rid)
+				continue
+			}
+			detail = watch.JsonString(js)
+		}
+
+		match, err := w.sub.Filter.Match(filter.JsonString(detail))
+		if err != nil {
+			blog.Errorf("match subscription %d filter failed, cursor: %s, err: %v, rid: %s", w.sub.ID, event.Cursor,
+				err, rid)
+			continue
+		}
+
+		if match {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// saveCursor saves the delivered cursor of the resource, returns false if the subscription is changed, in which
+// case the worker exits and a new one is started with the changed subscription.
+func (w *worker) saveCursor(ctx context.Context, cursor, rid string) bool {
+	if cursor == w.cursor {
+		return true
+	}
+
+	cond := mapstr.MapStr{
+		watch.SubscriptionIDField:       w.sub.ID,
+		watch.SubscriptionRevisionField: w.sub.Revision,
+	}
+	data := mapstr.MapStr{watch.SubscriptionCursorsField + "." + string(w.resource): cursor}
+
+	cnt, err := w.manager.db.Table(common.BKTableNameWatchSubscription).UpdateMany(ctx, cond, data)
+	if err != nil {
+		blog.Errorf("save subscription %d resource %s cursor failed, err: %v, rid: %s", w.sub.ID, w.resource, err,
+			rid)
+		// the events may be delivered again after restart, keep the cursor in memory to continue delivering
+		w.cursor = cursor
+		return true
+	}
+
+	if cnt == 0 {
+		blog.Infof("subscription %d is changed or removed, stop the worker of resource %s, rid: %s", w.sub.ID,
+			w.resource, rid)
+		return false
+	}
+
+	w.cursor = cursor
+	return true
+}
+
+// saveDeadLetter saves the event batch that failed to be delivered after all the retries
+func (w *worker) saveDeadLetter(ctx context.Context, startCursor, cursor string, payload []byte, attempts int,
+	deliverErr error, rid string) {
+
+	w.manager.deliverer.metric.collectDeadLetter(w.sub.ID, w.resource)
+
+	id, err := w.manager.db.NextSequence(ctx, common.BKTableNameWatchDeadLetter)
+	if err != nil {
+		blog.Errorf("generate dead letter id failed, err: %v, rid: %s", err, rid)
+		return
+	}
+
+	deadLetter := &watch.DeadLetter{
+		ID:             int64(id),
+		SubscriptionID: w.sub.ID,
+		Resource:       w.resource,
+		StartCursor:    startCursor,
+		Cursor:         cursor,
+		Payload:        string(payload),
+		Error:          deliverErr.Error(),
+		Attempts:       attempts,
+		OwnerID:        w.sub.OwnerID,
+		CreateTime:     time.Now(),
+	}
+	if err := w.manager.db.Table(common.BKTableNameWatchDeadLetter).Insert(ctx, deadLetter); err != nil {
+		blog.Errorf("save subscription %d dead letter failed, cursor: %s, err: %v, rid: %s", w.sub.ID, cursor, err,
+			rid)
+	}
+}
+
+// cursorTime returns the cluster time of the event that the cursor represents
+func cursorTime(cursor string) time.Time {
+	c := new(watch.Cursor)
+	if err := c.Decode(cursor); err != nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(c.ClusterTime.Sec), int64(c.ClusterTime.Nano))
+}
@@ -14,6 +14,9 @@
 package options
 
 import (
+	"fmt"
+	"sync"
+
 	"configcenter/src/ac/iam"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
@@ -47,4 +50,66 @@ type Config struct {
 	Redis redis.Config
 	// Auth is auth config
 	Auth iam.AuthConfig
+	// hostApplyDrift is the scheduled host apply drift check config, it is updated when the config is reloaded
+	hostApplyDrift     HostApplyDriftConfig
+	hostApplyDriftLock sync.RWMutex
+}
+
+// GetHostApplyDrift returns the scheduled host apply drift check config
+func (c *Config) GetHostApplyDrift() HostApplyDriftConfig {
+	c.hostApplyDriftLock.RLock()
+	defer c.hostApplyDriftLock.RUnlock()
+	return c.hostApplyDrift
+}
+
+// SetHostApplyDrift sets the scheduled host apply drift check config when the config is reloaded
+func (c *Config) SetHostApplyDrift(conf HostApplyDriftConfig) {
+	c.hostApplyDriftLock.Lock()
+	defer c.hostApplyDriftLock.Unlock()
+	c.hostApplyDrift = conf
+}
+
+// HostApplyDriftConfig is the config of the scheduled host apply drift check, the check compares the host attributes
+// with the host apply rules of their modules periodically, and saves the differences as the drift report.
+type HostApplyDriftConfig struct {
+	// Enabled defines if the scheduled host apply drift check is enabled
+	Enabled bool `mapstructure:"enabled"`
+	// IntervalMinutes is the check interval, unit: minute
+	IntervalMinutes int `mapstructure:"intervalMinutes"`
+	// BizIDs is the ids of the businesses to be checked, all businesses are checked if it is empty
+	BizIDs []int64 `mapstructure:"bizIDs"`
+	// AutoRemediateBizIDs is the ids of the businesses whose drifted host attributes are updated to the rule values
+	AutoRemediateBizIDs []int64 `mapstructure:"autoRemediateBizIDs"`
+}
+
+const (
+	// HostApplyDriftIntervalMinutesDefault is the default host apply drift check interval
+	HostApplyDriftIntervalMinutesDefault = 60
+	// HostApplyDriftIntervalMinutesMin is the minimum host apply drift check interval
+	HostApplyDriftIntervalMinutesMin = 10
+)
+
+// Validate HostApplyDriftConfig and set the default values
+func (c *HostApplyDriftConfig) Validate() error {
+	if c.IntervalMinutes == 0 {
+		c.IntervalMinutes = HostApplyDriftIntervalMinutesDefault
+	}
+
+	if c.IntervalMinutes < HostApplyDriftIntervalMinutesMin {
+		return fmt.Errorf("host apply drift check interval minutes %d is less than %d", c.IntervalMinutes,
+			HostApplyDriftIntervalMinutesMin)
+	}
+
+	for _, bizID := range c.BizIDs {
+		if bizID <= 0 {
+			return fmt.Errorf("host apply drift check biz id %d is invalid", bizID)
+		}
+	}
+
+	for _, bizID := range c.AutoRemediateBizIDs {
+		if bizID <= 0 {
+			return fmt.Errorf("host apply drift auto remediate biz id %d is invalid", bizID)
+		}
+	}
+	return nil
 }
@@ -31,3 +31,43 @@ func TestNewServerOption(t *testing.T) {
 func TestServerOption_AddFlags(t *testing.T) {
 	svrOpt.AddFlags(pflag.CommandLine)
 }
+
+func TestHostApplyDriftConfig_Validate(t *testing.T) {
+	conf := HostApplyDriftConfig{Enabled: true}
+	if err := conf.Validate(); err != nil {
+		t.Errorf("validate default host apply drift config failed, err: %v", err)
+	}
+	if conf.IntervalMinutes != HostApplyDriftIntervalMinutesDefault {
+		t.Errorf("host apply drift interval minutes %d is not set to default", conf.IntervalMinutes)
+	}
+
+	invalidConfigs := []HostApplyDriftConfig{
+		{Enabled: true, IntervalMinutes: HostApplyDriftIntervalMinutesMin - 1},
+		{Enabled: true, IntervalMinutes: 30, BizIDs: []int64{2, 0}},
+		{Enabled: true, IntervalMinutes: 30, AutoRemediateBizIDs: []int64{-1}},
+	}
+	for _, invalidConf := range invalidConfigs {
+		if err := invalidConf.Validate(); err == nil {
+			t.Errorf("invalid host apply drift config %+v passed the validation", invalidConf)
+		}
+	}
+}
+
+func TestConfig_HostApplyDrift(t *testing.T) {
+	conf := new(Config)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 100; i++ {
+			conf.SetHostApplyDrift(HostApplyDriftConfig{Enabled: true, IntervalMinutes: i})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = conf.GetHostApplyDrift()
+	}
+	<-done
+
+	if drift := conf.GetHostApplyDrift(); !drift.Enabled || drift.IntervalMinutes != 100 {
+		t.Errorf("host apply drift config %+v is not the last set one", drift)
+	}
+}
@@ -113,12 +113,16 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return err
 	}
 
+	go service.RunHostApplyDriftCheck(ctx)
+
 	select {
 	case <-ctx.Done():
 	}
 	return nil
 }
 
+const hostApplyDriftConfigKey = "hostServer.hostApplyDrift"
+
 // HostServer TODO
 type HostServer struct {
 	Core    *backbone.Engine
@@ -135,4 +139,28 @@ func (h *HostServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 	if h.Config == nil {
 		h.Config = new(options.Config)
 	}
+
+	h.Config.SetHostApplyDrift(parseHostApplyDriftConfig())
+}
+
+// parseHostApplyDriftConfig parse the scheduled host apply drift check config, the check is disabled if the config
+// is not set or invalid
+func parseHostApplyDriftConfig() options.HostApplyDriftConfig {
+	conf := options.HostApplyDriftConfig{}
+	if !cc.IsExist(hostApplyDriftConfigKey) {
+		return conf
+	}
+
+	if err := cc.UnmarshalKey(hostApplyDriftConfigKey, &conf); err != nil {
+		blog.Errorf("parse host apply drift config failed, err: %v", err)
+		return options.HostApplyDriftConfig{}
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("host apply drift config is invalid, err: %v", err)
+		return options.HostApplyDriftConfig{}
+	}
+
+	blog.Infof("host apply drift check config: %+v", conf)
+	return conf
 }
@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/auditlog"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/dynamicgroup"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/json"
 	meta "configcenter/src/common/metadata"
@@ -581,7 +582,7 @@ func (s *Service) checkAndBuildParam(kit *rest.Kit, input *meta.ExecuteOption, b
 	cond = append(cond, info.Condition...)
 	cond = append(cond, info.VariableCondition...)
 
-	return result, parseCond(cond), nil
+	return result, dynamicgroup.ParseConditions(cond), nil
 }
 
 func buildFinalCond(kit *rest.Kit, reqCondArr, originCondArr []meta.DynamicGroupInfoCondition) (
@@ -679,40 +680,6 @@ func validateCond(kit *rest.Kit, reqCondArr, originCondArr []meta.DynamicGroupIn
 	return nil
 }
 
-func parseCond(conditions []meta.DynamicGroupInfoCondition) []meta.SearchCondition {
-	conditionMap := make(map[string]*meta.SearchCondition)
-
-	for _, cond := range conditions {
-		if conditionMap[cond.ObjID] == nil {
-			conditionMap[cond.ObjID] = &meta.SearchCondition{ObjectID: cond.ObjID}
-		}
-
-		for _, item := range cond.Condition {
-			condItem := meta.ConditionItem{Field: item.Field, Operator: item.Operator, Value: item.Value}
-			conditionMap[cond.ObjID].Condition = append(conditionMap[cond.ObjID].Condition, condItem)
-		}
-
-		if cond.TimeCondition == nil {
-			continue
-		}
-
-		if conditionMap[cond.ObjID].TimeCondition == nil {
-			conditionMap[cond.ObjID].TimeCondition = cond.TimeCondition
-			continue
-		}
-
-		conditionMap[cond.ObjID].TimeCondition.Rules = append(conditionMap[cond.ObjID].TimeCondition.Rules,
-			cond.TimeCondition.Rules...)
-	}
-
-	result := make([]meta.SearchCondition, 0)
-	for _, condition := range conditionMap {
-		result = append(result, *condition)
-	}
-
-	return result
-}
-
 // changeTimeToMatchLocalZone TODO
 // change the time in UTC format to the time in the local time zone
 func changeTimeToMatchLocalZone(conditions []meta.DynamicGroupInfoCondition) {
@@ -0,0 +1,360 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/app/options"
+
+	"github.com/rs/xid"
+)
+
+// ListHostApplyDrift list the host apply drift report of the business generated by the scheduled drift check
+func (s *Service) ListHostApplyDrift(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil || bizID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := new(metadata.ListHostApplyDriftOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, ccErr := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyDrift(ctx.Kit.Ctx, ctx.Kit.Header, bizID,
+		option)
+	if ccErr != nil {
+		blog.Errorf("list host apply drift failed, biz: %d, option: %+v, err: %v, rid: %s", bizID, option, ccErr,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// hostApplyDriftBatchSize is the number of hosts that the apply plan is generated for at a time, and the number of
+// drifts that are saved at a time
+const hostApplyDriftBatchSize = 500
+
+// RunHostApplyDriftCheck checks if the host attributes drift away from the host apply rules periodically,
+// only the master host server runs the check, so that the check is not executed repeatedly.
+func (s *Service) RunHostApplyDriftCheck(ctx context.Context) {
+	for {
+		interval := s.GetHostApplyDrift().IntervalMinutes
+		if interval <= 0 {
+			interval = options.HostApplyDriftIntervalMinutesDefault
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(interval) * time.Minute):
+		}
+
+		conf := s.GetHostApplyDrift()
+		if !conf.Enabled {
+			continue
+		}
+
+		if !s.Engine.ServiceManageInterface.IsMaster() {
+			blog.V(4).Infof("it is not master, skip host apply drift check")
+			continue
+		}
+
+		header := headerutil.BuildHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID)
+		kit := rest.NewKitFromHeader(header, s.CCErr)
+		kit.Ctx = ctx
+
+		blog.Infof("start host apply drift check, rid: %s", kit.Rid)
+		s.checkHostApplyDrift(kit, conf)
+		blog.Infof("finish host apply drift check, rid: %s", kit.Rid)
+	}
+}
+
+func (s *Service) checkHostApplyDrift(kit *rest.Kit, conf options.HostApplyDriftConfig) {
+	bizIDs := conf.BizIDs
+	if len(bizIDs) == 0 {
+		var err error
+		bizIDs, err = s.Logic.GetAppIDByCond(kit, metadata.ConditionWithTime{})
+		if err != nil {
+			blog.Errorf("get all biz ids failed, err: %v, rid: %s", err, kit.Rid)
+			return
+		}
+	}
+
+	for _, bizID := range bizIDs {
+		autoRemediate := util.InArray(bizID, conf.AutoRemediateBizIDs)
+		if err := s.checkBizHostApplyDrift(kit, bizID, autoRemediate); err != nil {
+			blog.Errorf("check biz %d host apply drift failed, err: %v, rid: %s", bizID, err, kit.Rid)
+			continue
+		}
+	}
+}
+
+// checkBizHostApplyDrift run a drift check of the business. the drifts are saved in batches under a new run id, and
+// the run becomes the reported one only after all batches are saved. if the check fails, the error is recorded and
+// the drifts of the last succeeded run are still reported.
+func (s *Service) checkBizHostApplyDrift(kit *rest.Kit, bizID int64, autoRemediate bool) error {
+	runID := xid.New().String()
+	count, err := s.detectBizHostApplyDrift(kit, bizID, runID, autoRemediate)
+
+	option := &metadata.FinishHostApplyDriftRunOption{RunID: runID}
+	if err != nil {
+		option.Error = err.Error()
+	}
+
+	ccErr := s.CoreAPI.CoreService().HostApplyRule().FinishHostApplyDriftRun(kit.Ctx, kit.Header, bizID, option)
+	if ccErr != nil {
+		blog.Errorf("finish biz %d host apply drift run failed, option: %+v, err: %v, rid: %s", bizID, option,
+			ccErr, kit.Rid)
+		if err == nil {
+			return ccErr
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	blog.Infof("biz %d host apply drift check done, run: %s, drift count: %d, rid: %s", bizID, runID, count,
+		kit.Rid)
+	return nil
+}
+
+// detectBizHostApplyDrift generate the apply plan of all hosts in the business by the rules of their modules, the
+// differences are saved as the drifts of the run, and fixed if auto remediation is enabled.
+func (s *Service) detectBizHostApplyDrift(kit *rest.Kit, bizID int64, runID string, autoRemediate bool) (int,
+	error) {
+
+	rules, err := s.getRulesPriorityFromTemplate(kit, nil, bizID)
+	if err != nil {
+		blog.Errorf("get biz %d host apply rules failed, err: %v, rid: %s", bizID, err, kit.Rid)
+		return 0, err
+	}
+
+	drifts := make([]metadata.HostApplyDrift, 0)
+	plans := make([]metadata.OneHostApplyPlan, 0)
+	if len(rules) > 0 {
+		plans, err = s.generateBizHostApplyPlans(kit, bizID, rules)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	for _, plan := range plans {
+		drifts = append(drifts, convertPlanToHostApplyDrifts(plan, now)...)
+	}
+
+	if autoRemediate {
+		s.remediateHostApplyDrifts(kit, plans, drifts)
+	}
+
+	for start := 0; start < len(drifts); start += hostApplyDriftBatchSize {
+		end := start + hostApplyDriftBatchSize
+		if end > len(drifts) {
+			end = len(drifts)
+		}
+
+		option := &metadata.SaveHostApplyDriftOption{RunID: runID, Drifts: drifts[start:end]}
+		ccErr := s.CoreAPI.CoreService().HostApplyRule().SaveHostApplyDrift(kit.Ctx, kit.Header, bizID, option)
+		if ccErr != nil {
+			blog.Errorf("save biz %d host apply drift failed, run: %s, count: %d, err: %v, rid: %s", bizID, runID,
+				end-start, ccErr, kit.Rid)
+			return 0, ccErr
+		}
+	}
+
+	return len(drifts), nil
+}
+
+// generateBizHostApplyPlans generate the apply plans of the hosts that have drifted attributes in the business
+func (s *Service) generateBizHostApplyPlans(kit *rest.Kit, bizID int64, rules []metadata.HostApplyRule) (
+	[]metadata.OneHostApplyPlan, error) {
+
+	moduleIDs := make([]int64, 0)
+	for _, rule := range rules {
+		moduleIDs = append(moduleIDs, rule.ModuleID)
+	}
+
+	relationReq := &metadata.HostModuleRelationRequest{
+		ApplicationID: bizID,
+		ModuleIDArr:   util.IntArrayUnique(moduleIDs),
+		Page:          metadata.BasePage{Limit: common.BKNoLimit},
+		Fields:        []string{common.BKModuleIDField, common.BKHostIDField},
+	}
+	relations, err := s.CoreAPI.CoreService().Host().GetHostModuleRelation(kit.Ctx, kit.Header, relationReq)
+	if err != nil {
+		blog.Errorf("get host module relation failed, biz: %d, err: %v, rid: %s", bizID, err, kit.Rid)
+		return nil, err
+	}
+
+	hostModuleMap := make(map[int64][]int64)
+	hostIDs := make([]int64, 0)
+	for _, relation := range relations.Info {
+		if _, exists := hostModuleMap[relation.HostID]; !exists {
+			hostIDs = append(hostIDs, relation.HostID)
+		}
+		hostModuleMap[relation.HostID] = append(hostModuleMap[relation.HostID], relation.ModuleID)
+	}
+
+	plans := make([]metadata.OneHostApplyPlan, 0)
+	for start := 0; start < len(hostIDs); start += hostApplyDriftBatchSize {
+		end := start + hostApplyDriftBatchSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+
+		hostModules := make([]metadata.Host2Modules, 0)
+		for _, hostID := range hostIDs[start:end] {
+			hostModules = append(hostModules, metadata.Host2Modules{
+				HostID:    hostID,
+				ModuleIDs: hostModuleMap[hostID],
+			})
+		}
+
+		planOption := metadata.HostApplyPlanOption{
+			Rules:       rules,
+			HostModules: hostModules,
+		}
+		planResult, ccErr := s.CoreAPI.CoreService().HostApplyRule().GenerateApplyPlan(kit.Ctx, kit.Header, bizID,
+			planOption)
+		if ccErr != nil {
+			blog.Errorf("generate apply plan failed, biz: %d, err: %v, rid: %s", bizID, ccErr, kit.Rid)
+			return nil, ccErr
+		}
+		plans = append(plans, planResult.Plans...)
+	}
+
+	return plans, nil
+}
+
+// convertPlanToHostApplyDrifts convert the conflict fields of the host apply plan to the drifts, since no conflict
+// resolver is specified, every attribute whose value differs from the rules is in the conflict fields of the plan.
+func convertPlanToHostApplyDrifts(plan metadata.OneHostApplyPlan, detectTime time.Time) []metadata.HostApplyDrift {
+	updateValues := make(map[string]interface{})
+	for _, field := range plan.UpdateFields {
+		updateValues[field.PropertyID] = field.PropertyValue
+	}
+
+	drifts := make([]metadata.HostApplyDrift, 0)
+	for _, field := range plan.ConflictFields {
+		if len(field.Rules) == 0 {
+			continue
+		}
+
+		drift := metadata.HostApplyDrift{
+			HostID:      plan.HostID,
+			ModuleIDs:   plan.ModuleIDs,
+			AttributeID: field.AttributeID,
+			PropertyID:  field.PropertyID,
+			ActualValue: field.PropertyValue,
+			RuleIDs:     make([]int64, 0),
+			DetectTime:  detectTime,
+		}
+
+		for _, rule := range field.Rules {
+			drift.RuleIDs = append(drift.RuleIDs, rule.ID)
+			if !reflect.DeepEqual(rule.PropertyValue, field.Rules[0].PropertyValue) {
+				drift.Conflict = true
+			}
+		}
+
+		if !drift.Conflict {
+			drift.ExpectValue = field.Rules[0].PropertyValue
+			if value, exists := updateValues[field.PropertyID]; exists {
+				drift.ExpectValue = value
+			}
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts
+}
+
+// remediateHostApplyDrifts update the drifted host attributes to the rule values, the attributes whose rules are in
+// conflict are skipped. hosts with the same update data are updated together, and audit logs are saved for them.
+func (s *Service) remediateHostApplyDrifts(kit *rest.Kit, plans []metadata.OneHostApplyPlan,
+	drifts []metadata.HostApplyDrift) {
+
+	invalidHosts := make(map[int64]bool)
+	for _, plan := range plans {
+		if plan.ErrCode != 0 {
+			invalidHosts[plan.HostID] = true
+		}
+	}
+
+	hostData := make(map[int64]map[string]interface{})
+	for _, drift := range drifts {
+		if drift.Conflict || invalidHosts[drift.HostID] {
+			continue
+		}
+		if _, exists := hostData[drift.HostID]; !exists {
+			hostData[drift.HostID] = make(map[string]interface{})
+		}
+		hostData[drift.HostID][drift.PropertyID] = drift.ExpectValue
+	}
+
+	// group hosts with the same update data together, key is the json format of the update data
+	updateHosts := make(map[string]*metadata.UpdateHost)
+	for hostID, data := range hostData {
+		dataStr, err := json.MarshalToString(data)
+		if err != nil {
+			blog.Errorf("marshal host %d update data failed, data: %+v, err: %v, rid: %s", hostID, data, err, kit.Rid)
+			continue
+		}
+		if _, exists := updateHosts[dataStr]; !exists {
+			updateHosts[dataStr] = &metadata.UpdateHost{HostIDs: make([]int64, 0), Properties: data}
+		}
+		updateHosts[dataStr].HostIDs = append(updateHosts[dataStr].HostIDs, hostID)
+	}
+
+	remediatedHosts := make(map[int64]bool)
+	for _, update := range updateHosts {
+		// each update runs in its own transaction, use a new kit so that the transaction header is not shared
+		option := &metadata.UpdateHostOpt{Update: []metadata.UpdateHost{*update}}
+		if err := s.updateHost(kit.NewKit(), update.HostIDs, option, false); err != nil {
+			blog.Errorf("remediate host apply drift failed, hosts: %v, data: %+v, err: %v, rid: %s", update.HostIDs,
+				update.Properties, err, kit.Rid)
+			continue
+		}
+
+		for _, hostID := range update.HostIDs {
+			remediatedHosts[hostID] = true
+		}
+	}
+
+	for index, drift := range drifts {
+		if !drift.Conflict && remediatedHosts[drift.HostID] {
+			drifts[index].Remediated = true
+		}
+	}
+}
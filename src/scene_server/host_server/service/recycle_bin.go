@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// ListHostRecycleBin list the archived deleted hosts
+func (s *Service) ListHostRecycleBin(ctx *rest.Contexts) {
+	opt := new(metadata.ListRecycleBinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Instance().ListRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.BKInnerObjIDHost, opt)
+	if err != nil {
+		blog.Errorf("list host recycle bin failed, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// PreviewRestoreHostRecycleBin preview the hosts, host module relations, instance associations and the conflicts of
+// restoring the archived deleted hosts
+func (s *Service) PreviewRestoreHostRecycleBin(ctx *rest.Contexts) {
+	opt := new(metadata.RestoreRecycleBinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Instance().PreviewRestoreRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.BKInnerObjIDHost, opt)
+	if err != nil {
+		blog.Errorf("preview restore hosts failed, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// RestoreHostRecycleBin restore the archived deleted hosts with their host module relations and instance
+// associations, hosts whose modules are all deleted are restored to the idle module of the resource pool
+func (s *Service) RestoreHostRecycleBin(ctx *rest.Contexts) {
+	opt := new(metadata.RestoreRecycleBinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	var result *metadata.RestoreRecycleBinResult
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		result, err = s.CoreAPI.CoreService().Instance().RestoreRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header,
+			common.BKInnerObjIDHost, opt)
+		if err != nil {
+			blog.Errorf("restore hosts failed, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+			return err
+		}
+
+		return s.saveRestoreHostAuditLog(ctx.Kit, result)
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// saveRestoreHostAuditLog save audit log of the restored hosts, grouped by the business they are restored to
+func (s *Service) saveRestoreHostAuditLog(kit *rest.Kit, result *metadata.RestoreRecycleBinResult) error {
+	hostBizMap := make(map[int64]int64)
+	for _, rel := range result.HostRelations {
+		hostBizMap[rel.HostID] = rel.AppID
+	}
+
+	bizHostsMap := make(map[int64][]mapstr.MapStr)
+	for _, host := range result.Instances {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if err != nil {
+			blog.Errorf("parse host id failed, err: %v, host: %+v, rid: %s", err, host, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+		}
+		bizID := hostBizMap[hostID]
+		bizHostsMap[bizID] = append(bizHostsMap[bizID], host)
+	}
+
+	audit := auditlog.NewHostAudit(s.CoreAPI.CoreService())
+	genParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditCreate)
+	auditLogs := make([]metadata.AuditLog, 0)
+	for bizID, hosts := range bizHostsMap {
+		logs, err := audit.GenerateAuditLog(genParam, bizID, hosts)
+		if err != nil {
+			blog.Errorf("generate restore host audit log failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+		auditLogs = append(auditLogs, logs...)
+	}
+
+	for i := range auditLogs {
+		auditLogs[i].Action = metadata.AuditRecover
+	}
+
+	if err := audit.SaveAuditLog(kit, auditLogs...); err != nil {
+		blog.Errorf("save restore host audit log failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+	}
+	return nil
+}
@@ -160,6 +160,12 @@ func (s *Service) initHost(web *restful.WebService) {
 		Handler: s.SearchHostWithKube})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/updatemany/hosts/all/property",
 		Handler: s.UpdateHostAllProperty})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/hosts/recycle_bin",
+		Handler: s.ListHostRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/hosts/recycle_bin/restore_preview",
+		Handler: s.PreviewRestoreHostRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/hosts/recycle_bin/restore",
+		Handler: s.RestoreHostRecycleBin})
 	utility.AddToRestfulWebService(web)
 
 }
@@ -225,6 +231,8 @@ func (s *Service) initHostapplyrule(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/check/objectattr/host_apply_enabled",
 		Handler: s.CheckAttrHostApplyEnabled})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_apply_drift/bk_biz_id/{bk_biz_id}",
+		Handler: s.ListHostApplyDrift})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -335,8 +335,8 @@ func (lgc *Logics) List(kit *rest.Kit, name string, input *metadata.ListAPITaskR
 	}
 
 	rows := make([]metadata.APITaskDetail, 0)
-	err = lgc.db.Table(common.BKTableNameAPITask).Find(input.Condition).Start(uint64(input.Page.Start)).
-		Limit(uint64(input.Page.Limit)).Sort(input.Page.Sort).All(kit.Ctx, &rows)
+	err = lgc.db.Table(common.BKTableNameAPITask).Find(input.Condition).Fields(metadata.APITaskListFields...).
+		Start(uint64(input.Page.Start)).Limit(uint64(input.Page.Limit)).Sort(input.Page.Sort).All(kit.Ctx, &rows)
 
 	return rows, cnt, nil
 }
@@ -374,11 +374,17 @@ func (lgc *Logics) ListLatestTask(kit *rest.Kit, name string,
 		aggregateCond = append([]map[string]interface{}{{common.BKDBMatch: input.Condition}}, aggregateCond...)
 	}
 
+	// the execute history is listed by the execute history api, it is not returned even if it is specified
+	cond := map[string]int64{metadata.APITaskExecuteHistoryField: 0}
 	if len(input.Fields) != 0 {
-		cond := map[string]int64{}
+		cond = map[string]int64{}
 		for _, field := range input.Fields {
-			cond[field] = 1
+			if field != metadata.APITaskExecuteHistoryField {
+				cond[field] = 1
+			}
 		}
+	}
+	if len(cond) != 0 {
 		aggregateCond = append(aggregateCond, map[string]interface{}{
 			common.BKDBProject: cond,
 		})
@@ -411,6 +417,28 @@ func (lgc *Logics) Detail(kit *rest.Kit, taskID string) (*metadata.APITaskDetail
 	return &rows[0], nil
 }
 
+// ListExecuteHistory list the latest execute records of the task
+func (lgc *Logics) ListExecuteHistory(kit *rest.Kit, taskID string) ([]metadata.APITaskExecuteRecord, error) {
+	condition := mapstr.MapStr{common.BKTaskIDField: taskID}
+
+	task := new(metadata.APITaskDetail)
+	err := lgc.db.Table(common.BKTableNameAPITask).Find(condition).Fields(metadata.APITaskExecuteHistoryField).
+		One(kit.Ctx, task)
+	if err != nil {
+		if lgc.db.IsNotFoundError(err) {
+			blog.Errorf("task %s is not exist, rid: %s", taskID, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get task execute history failed, cond: %#v, err: %v, rid: %s", condition, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	if task.ExecuteHistory == nil {
+		return make([]metadata.APITaskExecuteRecord, 0), nil
+	}
+	return task.ExecuteHistory, nil
+}
+
 // DeleteTask delete task
 func (lgc *Logics) DeleteTask(kit *rest.Kit, taskCond *metadata.DeleteOption) error {
 	if len(taskCond.Condition) == 0 {
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogics(t *testing.T) (*Logics, *rest.Kit) {
+	db := memory.NewDB()
+	history := make([]metadata.APITaskExecuteRecord, 0)
+	for attempt := int64(1); attempt <= 3; attempt++ {
+		history = append(history, metadata.APITaskExecuteRecord{Attempt: attempt, Status: metadata.APITAskStatusFail})
+	}
+
+	now := time.Now()
+	tasks := []metadata.APITaskDetail{
+		{TaskID: "t1", TaskType: "sync", InstID: 1, Status: metadata.APITAskStatusFail, CreateTime: now,
+			ExecuteHistory: history, ExecuteCount: 3},
+		{TaskID: "t2", TaskType: "sync", InstID: 1, Status: metadata.APITaskStatusNew, CreateTime: now.Add(time.Second)},
+	}
+	require.NoError(t, db.Table(common.BKTableNameAPITask).Insert(context.Background(), tasks))
+	// the task list api filters the tasks by the name of the task queue
+	require.NoError(t, db.Table(common.BKTableNameAPITask).Update(context.Background(), map[string]interface{}{},
+		map[string]interface{}{"name": "sync"}))
+
+	kit := rest.NewKitFromHeader(http.Header{}, errors.NewFromCtx(errors.EmptyErrorsSetting))
+	return NewLogics(nil, db), kit
+}
+
+func TestListTaskWithoutExecuteHistory(t *testing.T) {
+	lgc, kit := newTestLogics(t)
+
+	tasks, cnt, err := lgc.List(kit, "sync", &metadata.ListAPITaskRequest{
+		Condition: map[string]interface{}{common.BKTaskIDField: "t1"},
+		Page:      metadata.BasePage{Limit: 10},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, cnt)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "t1", tasks[0].TaskID)
+	require.EqualValues(t, 3, tasks[0].ExecuteCount)
+	require.Nil(t, tasks[0].ExecuteHistory)
+
+	// the latest task of each instance is returned without execute history, even if it is specified in fields
+	tasks, err = lgc.ListLatestTask(kit, "", &metadata.ListAPITaskLatestRequest{
+		Condition: map[string]interface{}{common.BKInstIDField: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "t2", tasks[0].TaskID)
+
+	tasks, err = lgc.ListLatestTask(kit, "", &metadata.ListAPITaskLatestRequest{
+		Condition: map[string]interface{}{common.BKTaskIDField: "t1"},
+		Fields:    []string{common.BKTaskIDField, metadata.APITaskExecuteHistoryField},
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "t1", tasks[0].TaskID)
+	require.Nil(t, tasks[0].ExecuteHistory)
+	require.Empty(t, tasks[0].Status)
+
+	tasks, err = lgc.ListLatestTask(kit, "", &metadata.ListAPITaskLatestRequest{
+		Condition: map[string]interface{}{common.BKTaskIDField: "t1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Nil(t, tasks[0].ExecuteHistory)
+	require.Equal(t, metadata.APITAskStatusFail, tasks[0].Status)
+}
+
+func TestListExecuteHistory(t *testing.T) {
+	lgc, kit := newTestLogics(t)
+
+	history, err := lgc.ListExecuteHistory(kit, "t1")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.EqualValues(t, 3, history[2].Attempt)
+
+	history, err = lgc.ListExecuteHistory(kit, "t2")
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"sync"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const taskMetricSubsystem = "task_queue"
+
+var (
+	queueMtc     *queueMetric
+	queueMtcOnce = sync.Once{}
+)
+
+// initQueueMetric register the task queue metrics, the metrics are labeled by the task type(task queue name)
+func initQueueMetric() *queueMetric {
+	queueMtcOnce.Do(func() {
+		m := new(queueMetric)
+
+		m.queueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: taskMetricSubsystem,
+			Name:      "length",
+			Help:      "the number of tasks waiting to be executed in the task queue",
+		}, []string{"task_type"})
+		metrics.Register().MustRegister(m.queueLength)
+
+		m.waitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: taskMetricSubsystem,
+			Name:      "wait_duration_seconds",
+			Help:      "the duration(seconds) between the task is created and it starts to be executed",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+		}, []string{"task_type"})
+		metrics.Register().MustRegister(m.waitDuration)
+
+		m.executeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: taskMetricSubsystem,
+			Name:      "execute_duration_seconds",
+			Help:      "the duration(seconds) of each execution of the task",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1200},
+		}, []string{"task_type", "status"})
+		metrics.Register().MustRegister(m.executeDuration)
+
+		m.retryCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: taskMetricSubsystem,
+			Name:      "total_retry_count",
+			Help:      "the total retry count of the sub task requests",
+		}, []string{"task_type"})
+		metrics.Register().MustRegister(m.retryCount)
+
+		m.failureCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: taskMetricSubsystem,
+			Name:      "total_failure_count",
+			Help:      "the total count of the task executions that are failed or interrupted",
+		}, []string{"task_type", "status"})
+		metrics.Register().MustRegister(m.failureCount)
+
+		queueMtc = m
+	})
+
+	return queueMtc
+}
+
+type queueMetric struct {
+	// record the number of tasks waiting to be executed
+	queueLength *prometheus.GaugeVec
+	// record the duration between the task is created and it starts to be executed
+	waitDuration *prometheus.HistogramVec
+	// record the duration of each execution of the task, labeled with the status after the execution
+	executeDuration *prometheus.HistogramVec
+	// record the retry count of the sub task requests
+	retryCount *prometheus.CounterVec
+	// record the count of failed or interrupted task executions
+	failureCount *prometheus.CounterVec
+}
+
+func (m *queueMetric) collectQueueLength(taskType string, length uint64) {
+	m.queueLength.With(prometheus.Labels{"task_type": taskType}).Set(float64(length))
+}
+
+func (m *queueMetric) collectWaitDuration(taskType string, createTime time.Time) {
+	if createTime.IsZero() {
+		return
+	}
+	m.waitDuration.With(prometheus.Labels{"task_type": taskType}).Observe(time.Since(createTime).Seconds())
+}
+
+func (m *queueMetric) collectExecuteResult(taskType string, record *metadata.APITaskExecuteRecord) {
+	labels := prometheus.Labels{"task_type": taskType, "status": string(record.Status)}
+	m.executeDuration.With(labels).Observe(record.EndTime.Sub(record.StartTime).Seconds())
+
+	if record.Status != metadata.APITaskStatusSuccess {
+		m.failureCount.With(labels).Inc()
+	}
+}
+
+func (m *queueMetric) collectRetry(taskType string, retry int64) {
+	if retry <= 0 {
+		return
+	}
+	m.retryCount.With(prometheus.Labels{"task_type": taskType}).Add(float64(retry))
+}
@@ -29,6 +29,7 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/task_server/logics"
 	"configcenter/src/scene_server/task_server/taskconfig"
+	dbtypes "configcenter/src/storage/dal/types"
 )
 
 var (
@@ -50,6 +51,7 @@ type TaskQueue struct {
 	close bool
 	sync.WaitGroup
 	service *Service
+	metric  *queueMetric
 }
 
 // NewQueue TODO
@@ -71,6 +73,7 @@ func (s *Service) NewQueue(taskMap map[string]TaskInfo) *TaskQueue {
 	return &TaskQueue{
 		task:    taskArr,
 		service: s,
+		metric:  initQueueMetric(),
 	}
 }
 
@@ -131,6 +134,8 @@ func (tq *TaskQueue) execute(ctx context.Context, task TaskInfo) {
 			continue
 		}
 
+		tq.collectQueueLength(ctx, task.Name)
+
 		if len(taskQueueInfoArr) == 0 {
 			// no task, sleep 5s
 			time.Sleep(time.Second * 5)
@@ -200,6 +205,13 @@ func (tq *TaskQueue) executeTaskQueueItem(ctx context.Context, taskInfo TaskInfo
 		return false
 	}
 
+	// the task that is put back to the queue after compensation waits from its last update time
+	if taskQueueInfo.ExecuteCount == 0 {
+		tq.metric.collectWaitDuration(taskInfo.Name, taskQueueInfo.CreateTime)
+	} else {
+		tq.metric.collectWaitDuration(taskInfo.Name, taskQueueInfo.LastTime)
+	}
+
 	tq.executePush(ctx, taskInfo, &taskQueueInfo)
 	return true
 }
@@ -211,11 +223,20 @@ func (tq *TaskQueue) executePush(ctx context.Context, taskInfo TaskInfo, taskQue
 
 	blog.InfoJSON("start execute task, id: %s, rid: %s", taskQueue.TaskID, kit.Rid)
 
+	record := &metadata.APITaskExecuteRecord{
+		Attempt:   taskQueue.ExecuteCount + 1,
+		StartTime: time.Now(),
+		Status:    metadata.APITaskStatusExecute,
+		SubTasks:  make([]metadata.APISubTaskExecuteRecord, 0),
+	}
+	defer tq.saveExecuteRecord(kit, taskInfo.Name, taskQueue.TaskID, record)
+
 	allSucc := true
 
 	for _, subTask := range taskQueue.Detail {
-		success, needReturn := tq.executeSubTask(kit, taskInfo, taskQueue.TaskID, &subTask)
+		success, needReturn := tq.executeSubTask(kit, taskInfo, taskQueue.TaskID, &subTask, record)
 		if needReturn {
+			record.Error = "task execution is interrupted"
 			return
 		}
 
@@ -245,30 +266,44 @@ func (tq *TaskQueue) executePush(ctx context.Context, taskInfo TaskInfo, taskQue
 	})
 
 	if needReturn {
+		record.Error = "task execution is interrupted"
 		return
 	}
+	record.Status = updateStatus
 
 	blog.Infof("successfully updated task %s status to %s, rid: %s", taskQueue.TaskID, updateStatus, kit.Rid)
 }
 
-// executeSubTask execute subtask, returns if it is successful and if the task needs return
+// executeSubTask execute subtask and add its result to the execute record,
+// returns if it is successful and if the task needs return
 func (tq *TaskQueue) executeSubTask(kit *rest.Kit, taskInfo TaskInfo, taskID string,
-	subTask *metadata.APISubTaskDetail) (bool, bool) {
+	subTask *metadata.APISubTaskDetail, record *metadata.APITaskExecuteRecord) (bool, bool) {
 
 	blog.Infof("start execute task(id: %s) subtask(id: %s)", taskID, subTask.SubTaskID)
 
+	subRecord := metadata.APISubTaskExecuteRecord{SubTaskID: subTask.SubTaskID, Status: subTask.Status}
+	defer func() {
+		record.SubTasks = append(record.SubTasks, subRecord)
+		if subRecord.Error != "" && record.Error == "" {
+			record.Error = subRecord.Error
+		}
+	}()
+
 	if subTask.Status == metadata.APITaskStatusSuccess {
 		return true, false
 	}
 
 	if subTask.Status != metadata.APITaskStatusNew && subTask.Status != metadata.APITaskStatusWaitExecute {
 		blog.Errorf("task(id: %s) subtask(id: %s) status is wrong", taskID, subTask.SubTaskID)
+		subRecord.Error = fmt.Sprintf("subtask status %s is wrong", subTask.Status)
 		return false, false
 	}
 
 	var resp *metadata.Response
 	var err error
+	attempts := int64(0)
 	needReturn := retryWrapper(kit, int(taskInfo.Retry), func() error {
+		attempts++
 		if resp, err = tq.service.CoreAPI.TaskServer().Queue(taskInfo.Name).Post(kit.Ctx, kit.Header, taskInfo.Path,
 			subTask.Data); err != nil {
 			time.Sleep(time.Millisecond * 100)
@@ -279,11 +314,20 @@ func (tq *TaskQueue) executeSubTask(kit *rest.Kit, taskInfo TaskInfo, taskID str
 		return nil
 	})
 
+	if attempts > 1 {
+		subRecord.Retry = attempts - 1
+		tq.metric.collectRetry(taskInfo.Name, subRecord.Retry)
+	}
+
 	if needReturn {
+		subRecord.Status = metadata.APITaskStatusExecute
 		return false, true
 	}
 
 	if err != nil {
+		if resp == nil {
+			resp = new(metadata.Response)
+		}
 		resp.Result = false
 		resp.Code = common.CCErrCommHTTPDoRequestFailed
 		resp.ErrMsg = kit.CCError.CCErrorf(common.CCErrCommHTTPDoRequestFailed).Error()
@@ -295,8 +339,11 @@ func (tq *TaskQueue) executeSubTask(kit *rest.Kit, taskInfo TaskInfo, taskID str
 	if err != nil || !resp.Result {
 		updateData.Set("detail.$.status", metadata.APITAskStatusFail)
 		updateData.Set("status", metadata.APITAskStatusFail)
+		subRecord.Status = metadata.APITAskStatusFail
+		subRecord.Error = resp.ErrMsg
 	} else {
 		updateData.Set("detail.$.status", metadata.APITaskStatusSuccess)
+		subRecord.Status = metadata.APITaskStatusSuccess
 	}
 	updateData.Set("detail.$.response", resp)
 	updateData.Set(common.LastTimeField, time.Now())
@@ -325,6 +372,41 @@ func (tq *TaskQueue) executeSubTask(kit *rest.Kit, taskInfo TaskInfo, taskID str
 	return true, false
 }
 
+// saveExecuteRecord collect the metrics of the task execution and save the execute record to the task,
+// only the latest APITaskMaxExecuteHistory records are kept, the execute count is increased at the same time.
+func (tq *TaskQueue) saveExecuteRecord(kit *rest.Kit, taskType, taskID string, record *metadata.APITaskExecuteRecord) {
+	record.EndTime = time.Now()
+	tq.metric.collectExecuteResult(taskType, record)
+
+	// the task context may be canceled when the execution is interrupted, use a new context to save the record
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cond := mapstr.MapStr{common.BKTaskIDField: taskID}
+	pushDoc := mapstr.MapStr{
+		metadata.APITaskExecuteHistoryField: mapstr.MapStr{
+			"$each":  []*metadata.APITaskExecuteRecord{record},
+			"$slice": -metadata.APITaskMaxExecuteHistory,
+		},
+	}
+	incDoc := mapstr.MapStr{metadata.APITaskExecuteCountField: 1}
+	err := tq.service.DB.Table(common.BKTableNameAPITask).UpdateMultiModel(ctx, cond,
+		dbtypes.ModeUpdate{Op: "push", Doc: pushDoc}, dbtypes.ModeUpdate{Op: "inc", Doc: incDoc})
+	if err != nil {
+		blog.Errorf("save task %s execute record failed, err: %v, record: %#v, rid: %s", taskID, err, record, kit.Rid)
+	}
+}
+
+// collectQueueLength collect the number of tasks waiting to be executed in the task queue
+func (tq *TaskQueue) collectQueueLength(ctx context.Context, name string) {
+	cnt, err := tq.service.DB.Table(common.BKTableNameAPITask).Find(waitExecuteCond(name)).Count(ctx)
+	if err != nil {
+		blog.Errorf("count wait execute task failed, err: %v, task type: %s", err, name)
+		return
+	}
+	tq.metric.collectQueueLength(name, cnt)
+}
+
 // retryWrapper retry task execute step wrapper, returns if task is terminated.
 func retryWrapper(kit *rest.Kit, maxRetry int, handler func() error) bool {
 	for retry := 0; retry < maxRetry; retry++ {
@@ -380,16 +462,21 @@ func (tq *TaskQueue) isTaskLocked(ctx context.Context, taskID string) (bool, err
 	return result == 1, nil
 }
 
-func (tq *TaskQueue) getWaitExecute(ctx context.Context, name string) ([]metadata.APITaskDetail, error) {
-	cond := mapstr.MapStr{
+func waitExecuteCond(name string) mapstr.MapStr {
+	return mapstr.MapStr{
 		common.BKTaskTypeField: name,
 		common.BKStatusField: mapstr.MapStr{
 			common.BKDBIN: []metadata.APITaskStatus{metadata.APITaskStatusNew, metadata.APITaskStatusWaitExecute},
 		},
 	}
+}
+
+func (tq *TaskQueue) getWaitExecute(ctx context.Context, name string) ([]metadata.APITaskDetail, error) {
+	cond := waitExecuteCond(name)
 
 	rows := make([]metadata.APITaskDetail, 0)
-	err := tq.service.DB.Table(common.BKTableNameAPITask).Find(cond).Sort("create_time").Limit(20).All(ctx, &rows)
+	err := tq.service.DB.Table(common.BKTableNameAPITask).Find(cond).Fields(metadata.APITaskListFields...).
+		Sort("create_time").Limit(20).All(ctx, &rows)
 	if err != nil {
 		blog.ErrorJSON("query wait execute failed, err: %v, task type: %s, cond: %#v", err, name, cond)
 		return nil, tq.service.CCErr.Error("zh-cn", common.CCErrCommDBSelectFailed)
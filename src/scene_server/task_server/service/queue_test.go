@@ -0,0 +1,181 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/apimachinery/flowctrl"
+	taskUtil "configcenter/src/apimachinery/taskserver/util"
+	apiutil "configcenter/src/apimachinery/util"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/task_server/logics"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTaskQueue returns a task queue whose sub task requests of the task type are handled by the handler
+func newTestTaskQueue(t *testing.T, taskType string, handler http.HandlerFunc) (*TaskQueue, dal.DB) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	taskUtil.UpdateTaskServerConfigServ(taskType, func() ([]string, error) {
+		return []string{server.URL}, nil
+	})
+	require.Eventually(t, func() bool { return taskServerReady(taskType) }, time.Second, 10*time.Millisecond)
+
+	client, err := apiutil.NewClient(nil)
+	require.NoError(t, err)
+	coreAPI := apimachinery.NewClientSet(client, discovery.NewMockDiscoveryInterface(),
+		flowctrl.NewRateLimiter(1000, 1000))
+
+	db := memory.NewDB()
+	s := &Service{
+		Engine: &backbone.Engine{CoreAPI: coreAPI, CCErr: errors.NewFromCtx(errors.EmptyErrorsSetting)},
+		DB:     db,
+		Logics: logics.NewLogics(coreAPI, db),
+	}
+	return &TaskQueue{service: s, metric: initQueueMetric()}, db
+}
+
+func taskServerReady(taskType string) (ready bool) {
+	defer func() {
+		if recover() != nil {
+			ready = false
+		}
+	}()
+	addrs, err := taskUtil.NewSyncrhonizeConfig(taskType).GetServers()
+	return err == nil && len(addrs) > 0
+}
+
+func insertTestTask(t *testing.T, db dal.DB, taskID string, subTaskIDs ...string) {
+	task := metadata.APITaskDetail{
+		TaskID:     taskID,
+		Status:     metadata.APITaskStatusExecute,
+		CreateTime: time.Now(),
+		Detail:     make([]metadata.APISubTaskDetail, 0),
+	}
+	for _, subTaskID := range subTaskIDs {
+		task.Detail = append(task.Detail, metadata.APISubTaskDetail{SubTaskID: subTaskID,
+			Data: map[string]interface{}{"id": subTaskID}, Status: metadata.APITaskStatusNew})
+	}
+	require.NoError(t, db.Table(common.BKTableNameAPITask).Insert(context.Background(), task))
+}
+
+func getTestTask(t *testing.T, db dal.DB, taskID string) *metadata.APITaskDetail {
+	task := new(metadata.APITaskDetail)
+	err := db.Table(common.BKTableNameAPITask).Find(map[string]interface{}{common.BKTaskIDField: taskID}).
+		One(context.Background(), task)
+	require.NoError(t, err)
+	return task
+}
+
+func TestExecutePushRetry(t *testing.T) {
+	// the first 2 requests fail, the sub task succeeds on its second retry
+	var requests int32
+	tq, db := newTestTaskQueue(t, "test_retry", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"result":true,"bk_error_code":0,"bk_error_msg":"success"}`)
+	})
+	insertTestTask(t, db, "t1", "s1", "s2")
+
+	taskInfo := TaskInfo{Name: "test_retry", Path: "/sync", Retry: 3}
+	task := getTestTask(t, db, "t1")
+	tq.executePush(context.Background(), taskInfo, task)
+
+	task = getTestTask(t, db, "t1")
+	require.Equal(t, metadata.APITaskStatusSuccess, task.Status)
+	require.EqualValues(t, 1, task.ExecuteCount)
+	require.Len(t, task.ExecuteHistory, 1)
+
+	record := task.ExecuteHistory[0]
+	require.EqualValues(t, 1, record.Attempt)
+	require.Equal(t, metadata.APITaskStatusSuccess, record.Status)
+	require.Empty(t, record.Error)
+	require.Len(t, record.SubTasks, 2)
+	require.EqualValues(t, 2, record.SubTasks[0].Retry)
+	require.Equal(t, metadata.APITaskStatusSuccess, record.SubTasks[0].Status)
+	require.Zero(t, record.SubTasks[1].Retry)
+	require.EqualValues(t, 4, atomic.LoadInt32(&requests))
+}
+
+func TestExecutePushRetryExhausted(t *testing.T) {
+	var requests int32
+	tq, db := newTestTaskQueue(t, "test_retry_exhausted", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	insertTestTask(t, db, "t1", "s1", "s2")
+
+	taskInfo := TaskInfo{Name: "test_retry_exhausted", Path: "/sync", Retry: 3}
+	tq.executePush(context.Background(), taskInfo, getTestTask(t, db, "t1"))
+
+	// the failed sub task ends the task, the following sub task is not executed
+	task := getTestTask(t, db, "t1")
+	require.Equal(t, metadata.APITAskStatusFail, task.Status)
+	require.Equal(t, metadata.APITAskStatusFail, task.Detail[0].Status)
+	require.Equal(t, metadata.APITaskStatusNew, task.Detail[1].Status)
+	require.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+	record := task.ExecuteHistory[0]
+	require.Equal(t, metadata.APITAskStatusFail, record.Status)
+	require.NotEmpty(t, record.Error)
+	require.Len(t, record.SubTasks, 1)
+	require.EqualValues(t, 2, record.SubTasks[0].Retry)
+}
+
+func TestSaveExecuteRecord(t *testing.T) {
+	tq, db := newTestTaskQueue(t, "test_history", func(w http.ResponseWriter, r *http.Request) {})
+	insertTestTask(t, db, "t1")
+	kit := rest.NewKitFromHeader(http.Header{}, tq.service.CCErr)
+
+	// the attempt keeps increasing after the execute history reaches its limit
+	total := metadata.APITaskMaxExecuteHistory + 5
+	for i := 0; i < total; i++ {
+		task := new(metadata.APITaskDetail)
+		err := db.Table(common.BKTableNameAPITask).Find(map[string]interface{}{common.BKTaskIDField: "t1"}).
+			Fields(metadata.APITaskListFields...).One(context.Background(), task)
+		require.NoError(t, err)
+		require.Nil(t, task.ExecuteHistory)
+
+		record := &metadata.APITaskExecuteRecord{
+			Attempt:   task.ExecuteCount + 1,
+			StartTime: time.Now(),
+			Status:    metadata.APITAskStatusFail,
+		}
+		tq.saveExecuteRecord(kit, "test_history", "t1", record)
+	}
+
+	task := getTestTask(t, db, "t1")
+	require.EqualValues(t, total, task.ExecuteCount)
+	require.Len(t, task.ExecuteHistory, metadata.APITaskMaxExecuteHistory)
+	require.EqualValues(t, total-metadata.APITaskMaxExecuteHistory+1, task.ExecuteHistory[0].Attempt)
+	require.EqualValues(t, total, task.ExecuteHistory[metadata.APITaskMaxExecuteHistory-1].Attempt)
+}
@@ -87,6 +87,8 @@ func (s *Service) addAPIService(web *restful.WebService) {
 		Handler: s.ListLatestTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/findone/detail/{task_id}",
 		Handler: s.DetailTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/findmany/execute_history/{task_id}",
+		Handler: s.ListTaskExecuteHistory})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/deletemany", Handler: s.DeleteTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/latest/sync_status",
 		Handler: s.ListLatestSyncStatus})
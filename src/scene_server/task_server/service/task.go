@@ -120,6 +120,17 @@ func (s *Service) DetailTask(ctx *rest.Contexts) {
 	ctx.RespEntity(map[string]interface{}{"info": taskInfo})
 }
 
+// ListTaskExecuteHistory list the latest execute records of a task
+func (s *Service) ListTaskExecuteHistory(ctx *rest.Contexts) {
+	history, err := s.Logics.ListExecuteHistory(ctx.Kit, ctx.Request.PathParameter("task_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(history)
+}
+
 // DeleteTask delete task by condition
 func (s *Service) DeleteTask(ctx *rest.Contexts) {
 
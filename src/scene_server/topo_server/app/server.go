@@ -95,7 +95,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	}
 
 	essrv := new(elasticsearch.EsSrv)
-	if server.Config.Es.FullTextSearch == "on" {
+	if server.Config.Es.FullTextSearch == "on" && !server.Config.Es.UseMongoDB() {
 		esClient, err := elasticsearch.NewEsClient(server.Config.Es)
 		if err != nil {
 			blog.Errorf("failed to create elastic search client, err:%s", err.Error())
@@ -0,0 +1,649 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// revertIgnoredFields are the fields that are maintained by the system, they are not reverted
+var revertIgnoredFields = map[string]struct{}{
+	common.LastTimeField:   {},
+	common.CreateTimeField: {},
+	common.BKUpdatedAt:     {},
+	common.BKUpdatedBy:     {},
+	common.BKCreatedAt:     {},
+	common.BKCreatedBy:     {},
+}
+
+// PreviewRevertAudit preview the inverse operations of the operations recorded by the audit logs, and the diff
+// between the current state of the resources and the state after reverting
+func (s *Service) PreviewRevertAudit(ctx *rest.Contexts) {
+	opt := new(metadata.RevertAuditOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	items, err := s.genAuditRevertItems(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(&metadata.RevertAuditResult{Items: items})
+}
+
+// RevertAudit revert the operations recorded by the audit logs by their inverse operations, the operations are
+// reverted in the reverse order of their audit logs only when all of them can be reverted
+func (s *Service) RevertAudit(ctx *rest.Contexts) {
+	opt := new(metadata.RevertAuditOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	items, err := s.genAuditRevertItems(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	for _, item := range items {
+		if item.Reason != "" {
+			blog.Errorf("audit log %d can not be reverted, reason: %s, rid: %s", item.AuditID, item.Reason,
+				ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrTopoAuditRevertConflict, item.AuditID,
+				item.Reason))
+			return
+		}
+	}
+
+	authResp, authorized, err := s.authorizeAuditRevert(ctx.Kit, items)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if !authorized {
+		ctx.RespNoAuth(authResp)
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		for _, item := range items {
+			if err := s.revertAuditItem(ctx.Kit, item); err != nil {
+				blog.Errorf("revert audit log %d failed, err: %v, item: %+v, rid: %s", item.AuditID, err, item,
+					ctx.Kit.Rid)
+				return err
+			}
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(&metadata.RevertAuditResult{Items: items})
+}
+
+// getRevertAuditLogs get the audit logs to revert, sorted by id in descending order so that the latest operation is
+// reverted first
+func (s *Service) getRevertAuditLogs(kit *rest.Kit, opt *metadata.RevertAuditOption) ([]metadata.AuditLog, error) {
+	cond := mapstr.MapStr{}
+	if opt.ID != 0 {
+		cond[common.BKFieldID] = opt.ID
+	} else {
+		cond["rid"] = opt.RequestID
+	}
+
+	query := metadata.QueryCondition{
+		Condition: cond,
+		Page:      metadata.BasePage{Limit: metadata.AuditRevertLimit + 1, Sort: "-" + common.BKFieldID},
+	}
+	rsp, err := s.Engine.CoreAPI.CoreService().Audit().SearchAuditLog(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("search audit logs failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	if len(rsp.Info) == 0 {
+		blog.Errorf("no audit log is found, cond: %+v, rid: %s", cond, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommNotFound, "audit log")
+	}
+
+	if len(rsp.Info) > metadata.AuditRevertLimit {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommXXExceedLimit, "audit log", metadata.AuditRevertLimit)
+	}
+
+	return rsp.Info, nil
+}
+
+// genAuditRevertItems generate the inverse operations of the audit logs
+func (s *Service) genAuditRevertItems(kit *rest.Kit, opt *metadata.RevertAuditOption) ([]metadata.AuditRevertItem,
+	error) {
+
+	auditLogs, err := s.getRevertAuditLogs(kit, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	auditIDs := make([]int64, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		auditIDs[i] = auditLog.ID
+	}
+
+	items := make([]metadata.AuditRevertItem, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		items[i] = metadata.AuditRevertItem{
+			AuditID:      auditLog.ID,
+			ResourceType: auditLog.ResourceType,
+			ResourceID:   auditLog.ResourceID,
+			Action:       auditLog.Action,
+		}
+
+		switch detail := auditLog.OperationDetail.(type) {
+		case *metadata.InstanceOpDetail:
+			if auditLog.ResourceType != metadata.ModelInstanceRes && auditLog.ResourceType != metadata.HostRes {
+				items[i].Reason = metadata.AuditRevertUnsupported
+				continue
+			}
+			err = s.genInstRevertItem(kit, &items[i], detail)
+		case *metadata.ModelAttrOpDetail:
+			if auditLog.ResourceType != metadata.ModelAttributeRes {
+				items[i].Reason = metadata.AuditRevertUnsupported
+				continue
+			}
+			err = s.genAttrRevertItem(kit, &items[i], detail)
+		case *metadata.InstanceAssociationOpDetail:
+			err = s.genInstAsstRevertItem(kit, &items[i], detail)
+		default:
+			items[i].Reason = metadata.AuditRevertUnsupported
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if items[i].Reason != "" {
+			continue
+		}
+
+		changed, err := s.isResourceChangedSince(kit, genAuditChangeCond(auditLog, auditIDs))
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			items[i].Reason = metadata.AuditRevertChanged
+		}
+	}
+
+	return items, nil
+}
+
+// genAuditChangeCond generate the condition of the audit logs that record the changes of the resource after the
+// operation of the audit log, the audit logs that are reverted together are not regarded as changes of each other
+func genAuditChangeCond(auditLog metadata.AuditLog, auditIDs []int64) mapstr.MapStr {
+	cond := mapstr.MapStr{
+		common.BKFieldID: mapstr.MapStr{
+			common.BKDBGT:  auditLog.ID,
+			common.BKDBNIN: auditIDs,
+		},
+		common.BKResourceTypeField: auditLog.ResourceType,
+		common.BKResourceIDField:   auditLog.ResourceID,
+	}
+
+	// the instance association audit logs use the source instance id as the resource id
+	if detail, ok := auditLog.OperationDetail.(*metadata.InstanceAssociationOpDetail); ok {
+		cond["operation_detail.asst_id"] = detail.AssociationID
+		cond["operation_detail.dest_inst_id"] = detail.TargetInstanceID
+	}
+	return cond
+}
+
+// isResourceChangedSince check if the resource has been changed after the operation of the audit log, cond is
+// generated by genAuditChangeCond
+func (s *Service) isResourceChangedSince(kit *rest.Kit, cond mapstr.MapStr) (bool, error) {
+	query := metadata.QueryCondition{
+		Condition:      cond,
+		Fields:         []string{common.BKFieldID},
+		Page:           metadata.BasePage{Limit: 1},
+		DisableCounter: true,
+	}
+	rsp, err := s.Engine.CoreAPI.CoreService().Audit().SearchAuditLog(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("search later audit logs failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return false, err
+	}
+
+	return len(rsp.Info) > 0, nil
+}
+
+// genInstRevertItem generate the inverse operation of the model instance or host operation
+func (s *Service) genInstRevertItem(kit *rest.Kit, item *metadata.AuditRevertItem,
+	detail *metadata.InstanceOpDetail) error {
+
+	item.ObjID = detail.ModelID
+	if detail.Details == nil {
+		item.Reason = metadata.AuditRevertUnsupported
+		return nil
+	}
+
+	instID, err := util.GetInt64ByInterface(item.ResourceID)
+	if err != nil {
+		blog.Errorf("parse audit log %d resource id failed, err: %v, rid: %s", item.AuditID, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKResourceIDField)
+	}
+
+	cond := &metadata.QueryCondition{
+		Condition:      mapstr.MapStr{metadata.GetInstIDFieldByObjID(item.ObjID): instID},
+		DisableCounter: true,
+	}
+	insts, err := s.Logics.InstOperation().FindInst(kit, item.ObjID, cond)
+	if err != nil {
+		return err
+	}
+
+	var current mapstr.MapStr
+	if len(insts.Info) > 0 {
+		current = insts.Info[0]
+	}
+
+	switch item.Action {
+	case metadata.AuditCreate:
+		// deleting a host has side effects on its relations, so hosts can not be reverted by deleting
+		if item.ObjID == common.BKInnerObjIDHost {
+			item.Reason = metadata.AuditRevertUnsupported
+			return nil
+		}
+
+		item.RevertAction = metadata.AuditDelete
+		item.Current = current
+		if current == nil {
+			item.Reason = metadata.AuditRevertNotExist
+		}
+
+	case metadata.AuditUpdate:
+		item.RevertAction = metadata.AuditUpdate
+		item.Current, item.Target = genRevertUpdateDiff(current, detail.Details)
+		if current == nil {
+			item.Reason = metadata.AuditRevertNotExist
+		}
+
+	case metadata.AuditDelete:
+		// deleted hosts are restored by the host recycle bin, which handles their host module relations
+		if item.ObjID == common.BKInnerObjIDHost {
+			item.Reason = metadata.AuditRevertUnsupported
+			return nil
+		}
+
+		item.RevertAction = metadata.AuditRecover
+		item.Target = detail.Details.PreData
+		if current != nil {
+			item.Current = current
+			item.Reason = metadata.AuditRevertAlreadyExist
+			return nil
+		}
+
+		recordID, err := s.getInstRecycleBinRecord(kit, item.ObjID, instID)
+		if err != nil {
+			return err
+		}
+
+		if recordID == "" {
+			item.Reason = metadata.AuditRevertNotExist
+		}
+
+	default:
+		item.Reason = metadata.AuditRevertUnsupported
+	}
+
+	return nil
+}
+
+// genRevertUpdateDiff generate the current and target data of the updated fields
+func genRevertUpdateDiff(current mapstr.MapStr, details *metadata.BasicContent) (mapstr.MapStr, mapstr.MapStr) {
+	currentData, targetData := make(mapstr.MapStr), make(mapstr.MapStr)
+	for field := range details.UpdateFields {
+		if _, exists := revertIgnoredFields[field]; exists {
+			continue
+		}
+
+		currentData[field] = current[field]
+		targetData[field] = details.PreData[field]
+	}
+	return currentData, targetData
+}
+
+// getInstRecycleBinRecord get the id of the latest archived record of the deleted instance in the recycle bin
+func (s *Service) getInstRecycleBinRecord(kit *rest.Kit, objID string, instID int64) (string, error) {
+	opt := &metadata.ListRecycleBinOption{
+		InstIDs: []int64{instID},
+		Page:    metadata.BasePage{Limit: 1},
+	}
+	rsp, err := s.Engine.CoreAPI.CoreService().Instance().ListRecycleBin(kit.Ctx, kit.Header, objID, opt)
+	if err != nil {
+		blog.Errorf("list %s recycle bin failed, err: %v, inst id: %d, rid: %s", objID, err, instID, kit.Rid)
+		return "", err
+	}
+
+	if len(rsp.Info) == 0 {
+		return "", nil
+	}
+	return rsp.Info[0].ID, nil
+}
+
+// genAttrRevertItem generate the inverse operation of the model attribute operation, only update can be reverted
+func (s *Service) genAttrRevertItem(kit *rest.Kit, item *metadata.AuditRevertItem,
+	detail *metadata.ModelAttrOpDetail) error {
+
+	item.ObjID = detail.BkObjID
+	if item.Action != metadata.AuditUpdate || detail.Details == nil {
+		item.Reason = metadata.AuditRevertUnsupported
+		return nil
+	}
+
+	attr, err := s.getRevertAttribute(kit, item.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	var current mapstr.MapStr
+	if attr != nil {
+		current = attr.ToMapStr()
+	}
+
+	item.RevertAction = metadata.AuditUpdate
+	item.Current, item.Target = genRevertUpdateDiff(current, detail.Details)
+	item.Target = removeImmutableFields(item.Target)
+	if current == nil {
+		item.Reason = metadata.AuditRevertNotExist
+	}
+	return nil
+}
+
+func (s *Service) getRevertAttribute(kit *rest.Kit, resourceID interface{}) (*metadata.Attribute, error) {
+	attrID, err := util.GetInt64ByInterface(resourceID)
+	if err != nil {
+		blog.Errorf("parse attribute id %v failed, err: %v, rid: %s", resourceID, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKResourceIDField)
+	}
+
+	cond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKFieldID: attrID}}
+	rsp, err := s.Engine.CoreAPI.CoreService().Model().ReadModelAttrByCondition(kit.Ctx, kit.Header, cond)
+	if err != nil {
+		blog.Errorf("get attribute %d failed, err: %v, rid: %s", attrID, err, kit.Rid)
+		return nil, err
+	}
+
+	if len(rsp.Info) == 0 {
+		return nil, nil
+	}
+	return &rsp.Info[0], nil
+}
+
+// genInstAsstRevertItem generate the inverse operation of the instance association operation
+func (s *Service) genInstAsstRevertItem(kit *rest.Kit, item *metadata.AuditRevertItem,
+	detail *metadata.InstanceAssociationOpDetail) error {
+
+	item.ObjID = detail.SourceModelID
+	instID, err := util.GetInt64ByInterface(item.ResourceID)
+	if err != nil {
+		blog.Errorf("parse audit log %d resource id failed, err: %v, rid: %s", item.AuditID, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKResourceIDField)
+	}
+
+	asstData := mapstr.MapStr{
+		common.AssociationObjAsstIDField: detail.AssociationID,
+		common.BKObjIDField:              detail.SourceModelID,
+		common.BKInstIDField:             instID,
+		common.BKAsstObjIDField:          detail.TargetModelID,
+		common.BKAsstInstIDField:         detail.TargetInstanceID,
+	}
+
+	asst, err := s.getRevertInstAsst(kit, detail.SourceModelID, asstData)
+	if err != nil {
+		return err
+	}
+
+	var current mapstr.MapStr
+	if asst != nil {
+		current = asstData.Clone()
+		current[common.BKFieldID] = asst.ID
+	}
+
+	switch item.Action {
+	case metadata.AuditCreate:
+		item.RevertAction = metadata.AuditDelete
+		item.Current = current
+		if current == nil {
+			item.Reason = metadata.AuditRevertNotExist
+		}
+	case metadata.AuditDelete:
+		item.RevertAction = metadata.AuditCreate
+		item.Current = current
+		item.Target = asstData
+		if current != nil {
+			item.Reason = metadata.AuditRevertAlreadyExist
+		}
+	default:
+		item.Reason = metadata.AuditRevertUnsupported
+	}
+
+	return nil
+}
+
+func (s *Service) getRevertInstAsst(kit *rest.Kit, objID string, asstData mapstr.MapStr) (*metadata.InstAsst,
+	error) {
+
+	cond := &metadata.InstAsstQueryCondition{
+		Cond: metadata.QueryCondition{
+			Condition: mapstr.MapStr{
+				common.AssociationObjAsstIDField: asstData[common.AssociationObjAsstIDField],
+				common.BKInstIDField:             asstData[common.BKInstIDField],
+				common.BKAsstInstIDField:         asstData[common.BKAsstInstIDField],
+			},
+			DisableCounter: true,
+		},
+		ObjID: objID,
+	}
+	rsp, err := s.Engine.CoreAPI.CoreService().Association().ReadInstAssociation(kit.Ctx, kit.Header, cond)
+	if err != nil {
+		blog.Errorf("get instance association failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	if len(rsp.Info) == 0 {
+		return nil, nil
+	}
+	return &rsp.Info[0], nil
+}
+
+// auditRevertAuthType is the way to authorize the inverse operation
+type auditRevertAuthType int
+
+const (
+	// auditRevertAuthSkip means that the inverse operation is authorized by the host server
+	auditRevertAuthSkip auditRevertAuthType = iota
+	// auditRevertAuthByModel means that the inverse operation is authorized by the instance operation of the model
+	auditRevertAuthByModel
+	// auditRevertAuthByInstance means that the inverse operation is authorized by the instance of the model
+	auditRevertAuthByInstance
+	// auditRevertAuthByObject means that the inverse operation is authorized by the model itself
+	auditRevertAuthByObject
+)
+
+// getAuditRevertAuth get the way and action to authorize the inverse operation as if it is requested by the user
+// directly
+func getAuditRevertAuth(item metadata.AuditRevertItem) (auditRevertAuthType, meta.Action) {
+	if item.ObjID == common.BKInnerObjIDHost {
+		return auditRevertAuthSkip, ""
+	}
+
+	switch item.ResourceType {
+	case metadata.ModelInstanceRes:
+		switch item.RevertAction {
+		case metadata.AuditRecover:
+			return auditRevertAuthByModel, meta.Create
+		case metadata.AuditDelete:
+			return auditRevertAuthByInstance, meta.Delete
+		default:
+			return auditRevertAuthByInstance, meta.Update
+		}
+	case metadata.ModelAttributeRes:
+		return auditRevertAuthByObject, meta.Update
+	case metadata.InstanceAssociationRes:
+		return auditRevertAuthByInstance, meta.Update
+	}
+	return auditRevertAuthSkip, ""
+}
+
+// authorizeAuditRevert authorize the inverse operations as if they are requested by the user directly, the host
+// operations are authorized by the host server
+func (s *Service) authorizeAuditRevert(kit *rest.Kit, items []metadata.AuditRevertItem) (*metadata.BaseResp, bool,
+	error) {
+
+	for _, item := range items {
+		authType, action := getAuditRevertAuth(item)
+		var err error
+		switch authType {
+		case auditRevertAuthByModel:
+			authResp, authorized, err := s.AuthManager.HasInstOpAuth(kit, []string{item.ObjID}, action)
+			if err != nil || !authorized {
+				return authResp, authorized, err
+			}
+			continue
+		case auditRevertAuthByInstance:
+			resourceID, parseErr := util.GetInt64ByInterface(item.ResourceID)
+			if parseErr != nil {
+				return nil, false, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKResourceIDField)
+			}
+			err = s.AuthManager.AuthorizeByInstanceID(kit.Ctx, kit.Header, action, item.ObjID, resourceID)
+		case auditRevertAuthByObject:
+			err = s.AuthManager.AuthorizeByObjectIDs(kit.Ctx, kit.Header, action, 0, item.ObjID)
+		}
+
+		if err != nil {
+			blog.Errorf("authorize revert audit log %d failed, err: %v, rid: %s", item.AuditID, err, kit.Rid)
+			return nil, false, err
+		}
+	}
+
+	return nil, true, nil
+}
+
+// revertAuditItem execute the inverse operation through the same logics as the normal apis, so that the data is
+// validated and a new audit log is saved
+func (s *Service) revertAuditItem(kit *rest.Kit, item metadata.AuditRevertItem) error {
+	switch item.ResourceType {
+	case metadata.HostRes:
+		hostID, err := util.GetInt64ByInterface(item.ResourceID)
+		if err != nil {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKResourceIDField)
+		}
+
+		data := map[string]interface{}{
+			"update": []metadata.UpdateHostProperty{{HostID: hostID, Properties: item.Target}},
+		}
+		return s.Engine.CoreAPI.HostServer().UpdateHostPropertyBatch(kit.Ctx, kit.Header, data)
+
+	case metadata.ModelInstanceRes:
+		return s.revertInstance(kit, item)
+
+	case metadata.ModelAttributeRes:
+		attr, err := s.getRevertAttribute(kit, item.ResourceID)
+		if err != nil {
+			return err
+		}
+
+		if attr == nil {
+			return kit.CCError.CCErrorf(common.CCErrTopoAuditRevertConflict, item.AuditID,
+				metadata.AuditRevertNotExist)
+		}
+		return s.Logics.AttributeOperation().UpdateObjectAttribute(kit, item.Target, attr.ID, attr.BizID, false)
+
+	case metadata.InstanceAssociationRes:
+		if item.RevertAction == metadata.AuditDelete {
+			asstID, err := util.GetInt64ByInterface(item.Current[common.BKFieldID])
+			if err != nil {
+				return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKFieldID)
+			}
+			_, err = s.Logics.InstAssociationOperation().DeleteInstAssociation(kit, item.ObjID, []int64{asstID})
+			return err
+		}
+
+		instID, _ := util.GetInt64ByInterface(item.Target[common.BKInstIDField])
+		asstInstID, _ := util.GetInt64ByInterface(item.Target[common.BKAsstInstIDField])
+		_, err := s.Logics.InstAssociationOperation().CreateInstanceAssociation(kit,
+			&metadata.CreateAssociationInstRequest{
+				ObjectAsstID: util.GetStrByInterface(item.Target[common.AssociationObjAsstIDField]),
+				InstID:       instID,
+				AsstInstID:   asstInstID,
+			})
+		return err
+	}
+
+	return kit.CCError.CCErrorf(common.CCErrTopoAuditRevertConflict, item.AuditID, metadata.AuditRevertUnsupported)
+}
+
+func (s *Service) revertInstance(kit *rest.Kit, item metadata.AuditRevertItem) error {
+	instID, err := util.GetInt64ByInterface(item.ResourceID)
+	if err != nil {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKResourceIDField)
+	}
+
+	switch item.RevertAction {
+	case metadata.AuditDelete:
+		return s.Logics.InstOperation().DeleteInstByInstID(kit, item.ObjID, []int64{instID}, true)
+
+	case metadata.AuditUpdate:
+		cond := mapstr.MapStr{metadata.GetInstIDFieldByObjID(item.ObjID): instID}
+		return s.Logics.InstOperation().UpdateInst(kit, cond, item.Target, item.ObjID)
+
+	case metadata.AuditRecover:
+		recordID, err := s.getInstRecycleBinRecord(kit, item.ObjID, instID)
+		if err != nil {
+			return err
+		}
+
+		if recordID == "" {
+			return kit.CCError.CCErrorf(common.CCErrTopoAuditRevertConflict, item.AuditID,
+				metadata.AuditRevertNotExist)
+		}
+
+		_, err = s.restoreInstances(kit, item.ObjID, &metadata.RestoreRecycleBinOption{IDs: []string{recordID}})
+		return err
+	}
+
+	return kit.CCError.CCErrorf(common.CCErrTopoAuditRevertConflict, item.AuditID, metadata.AuditRevertUnsupported)
+}
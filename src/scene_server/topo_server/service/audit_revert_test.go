@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenRevertUpdateDiff(t *testing.T) {
+	details := &metadata.BasicContent{
+		PreData: mapstr.MapStr{"name": "old", "port": 80, "remark": "a", common.LastTimeField: "2024-01-01",
+			common.BKUpdatedBy: "admin"},
+		UpdateFields: mapstr.MapStr{"name": "new", "port": nil, common.LastTimeField: "2024-02-01",
+			common.BKUpdatedBy: "user"},
+	}
+
+	current := mapstr.MapStr{"name": "latest", "remark": "b", common.LastTimeField: "2024-03-01"}
+	currentData, targetData := genRevertUpdateDiff(current, details)
+	require.Equal(t, mapstr.MapStr{"name": "latest", "port": nil}, currentData)
+	require.Equal(t, mapstr.MapStr{"name": "old", "port": 80}, targetData)
+
+	// the resource does not exist
+	currentData, targetData = genRevertUpdateDiff(nil, details)
+	require.Equal(t, mapstr.MapStr{"name": nil, "port": nil}, currentData)
+	require.Equal(t, mapstr.MapStr{"name": "old", "port": 80}, targetData)
+}
+
+func TestIsResourceChangedSince(t *testing.T) {
+	db := memory.NewDB()
+	ctx := context.Background()
+	logs := []mapstr.MapStr{
+		{common.BKFieldID: 1, common.BKResourceTypeField: metadata.ModelInstanceRes, common.BKResourceIDField: 10},
+		{common.BKFieldID: 2, common.BKResourceTypeField: metadata.ModelInstanceRes, common.BKResourceIDField: 11},
+		{common.BKFieldID: 3, common.BKResourceTypeField: metadata.ModelInstanceRes, common.BKResourceIDField: 10},
+		{common.BKFieldID: 4, common.BKResourceTypeField: metadata.InstanceAssociationRes,
+			common.BKResourceIDField: 10,
+			"operation_detail":       mapstr.MapStr{"asst_id": "a_connect_b", "dest_inst_id": 20}},
+		{common.BKFieldID: 5, common.BKResourceTypeField: metadata.InstanceAssociationRes,
+			common.BKResourceIDField: 10,
+			"operation_detail":       mapstr.MapStr{"asst_id": "a_connect_b", "dest_inst_id": 21}},
+		{common.BKFieldID: 6, common.BKResourceTypeField: metadata.ModelAttributeRes, common.BKResourceIDField: 10},
+	}
+	require.NoError(t, db.Table(common.BKTableNameAuditLog).Insert(ctx, logs))
+
+	asstLog := func(id int64, destInstID int64) metadata.AuditLog {
+		return metadata.AuditLog{ID: id, ResourceType: metadata.InstanceAssociationRes, ResourceID: 10,
+			OperationDetail: &metadata.InstanceAssociationOpDetail{
+				AssociationOpDetail: metadata.AssociationOpDetail{AssociationID: "a_connect_b"},
+				TargetInstanceID:    destInstID,
+			}}
+	}
+
+	testCases := []struct {
+		name     string
+		auditLog metadata.AuditLog
+		auditIDs []int64
+		changed  bool
+	}{{
+		name:     "instance is changed later",
+		auditLog: metadata.AuditLog{ID: 1, ResourceType: metadata.ModelInstanceRes, ResourceID: 10},
+		auditIDs: []int64{1},
+		changed:  true,
+	}, {
+		name:     "instance is changed by the audit logs reverted together",
+		auditLog: metadata.AuditLog{ID: 1, ResourceType: metadata.ModelInstanceRes, ResourceID: 10},
+		auditIDs: []int64{1, 3},
+		changed:  false,
+	}, {
+		name:     "instance is the latest changed",
+		auditLog: metadata.AuditLog{ID: 3, ResourceType: metadata.ModelInstanceRes, ResourceID: 10},
+		auditIDs: []int64{3},
+		changed:  false,
+	}, {
+		name:     "other instances are changed later",
+		auditLog: metadata.AuditLog{ID: 2, ResourceType: metadata.ModelInstanceRes, ResourceID: 11},
+		auditIDs: []int64{2},
+		changed:  false,
+	}, {
+		name:     "association of the same source instance to another instance is changed later",
+		auditLog: asstLog(4, 20),
+		auditIDs: []int64{4},
+		changed:  false,
+	}, {
+		name:     "association is changed later",
+		auditLog: asstLog(4, 21),
+		auditIDs: []int64{4},
+		changed:  true,
+	}}
+
+	for _, testCase := range testCases {
+		cond := genAuditChangeCond(testCase.auditLog, testCase.auditIDs)
+		cnt, err := db.Table(common.BKTableNameAuditLog).Find(cond).Count(ctx)
+		require.NoError(t, err, testCase.name)
+		require.Equal(t, testCase.changed, cnt > 0, testCase.name)
+	}
+}
+
+func TestGetAuditRevertAuth(t *testing.T) {
+	testCases := []struct {
+		item     metadata.AuditRevertItem
+		authType auditRevertAuthType
+		action   meta.Action
+	}{{
+		item:     metadata.AuditRevertItem{ResourceType: metadata.HostRes, ObjID: common.BKInnerObjIDHost},
+		authType: auditRevertAuthSkip,
+	}, {
+		item: metadata.AuditRevertItem{ResourceType: metadata.ModelInstanceRes, ObjID: "switch",
+			RevertAction: metadata.AuditRecover},
+		authType: auditRevertAuthByModel,
+		action:   meta.Create,
+	}, {
+		item: metadata.AuditRevertItem{ResourceType: metadata.ModelInstanceRes, ObjID: "switch",
+			RevertAction: metadata.AuditDelete},
+		authType: auditRevertAuthByInstance,
+		action:   meta.Delete,
+	}, {
+		item: metadata.AuditRevertItem{ResourceType: metadata.ModelInstanceRes, ObjID: "switch",
+			RevertAction: metadata.AuditUpdate},
+		authType: auditRevertAuthByInstance,
+		action:   meta.Update,
+	}, {
+		item: metadata.AuditRevertItem{ResourceType: metadata.ModelAttributeRes, ObjID: "switch",
+			RevertAction: metadata.AuditUpdate},
+		authType: auditRevertAuthByObject,
+		action:   meta.Update,
+	}, {
+		item: metadata.AuditRevertItem{ResourceType: metadata.InstanceAssociationRes, ObjID: "switch",
+			RevertAction: metadata.AuditCreate},
+		authType: auditRevertAuthByInstance,
+		action:   meta.Update,
+	}, {
+		item: metadata.AuditRevertItem{ResourceType: metadata.InstanceAssociationRes, ObjID: "switch",
+			RevertAction: metadata.AuditDelete},
+		authType: auditRevertAuthByInstance,
+		action:   meta.Update,
+	}}
+
+	for _, testCase := range testCases {
+		authType, action := getAuditRevertAuth(testCase.item)
+		require.Equal(t, testCase.authType, authType, testCase.item)
+		require.Equal(t, testCase.action, action, testCase.item)
+	}
+}
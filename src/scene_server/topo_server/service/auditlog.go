@@ -270,6 +270,29 @@ func parseAuditTypeCondition(kit *rest.Kit, condition metadata.AuditQueryConditi
 	return nil, false
 }
 
+// SearchAuditArchive search the index of the archive files that the expired audit logs are moved into
+func (s *Service) SearchAuditArchive(ctx *rest.Contexts) {
+	opt := new(metadata.SearchAuditLogArchiveOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Audit().SearchAuditLogArchive(ctx.Kit.Ctx, ctx.Kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search audit log archive failed, err: %v, opt: %+v, rid: %s", err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
 // SearchInstAudit search instance audit, online allow front-end to use
 // 前端在资源池内查看实例的变更记录需要针对当前用户的实例查询权限进行鉴权，原有审计查询接口鉴权条件为操作审计权限，需要进行区别
 func (s *Service) SearchInstAudit(ctx *rest.Contexts) {
@@ -244,6 +244,9 @@ type FullTextSearchReq struct {
 
 	// Page search page settings.
 	Page *Page `json:"page"`
+
+	// keyword is the raw search keyword without escaping.
+	keyword string
 }
 
 // Validate validate the fulltext search request.
@@ -259,6 +262,7 @@ func (r *FullTextSearchReq) Validate() error {
 
 	// escape special characters.
 	rawString := strings.Trim(r.QueryString, "*")
+	r.keyword = rawString
 	r.QueryString = "*" + esSpecialCharactersRegex.ReplaceAllString(rawString, `\$1`) + "*"
 
 	// check query_string length in UTF-8 encoding.
@@ -555,7 +559,7 @@ func (s *Service) fullTextMetadata(ctx *rest.Contexts, hits []*elastic.SearchHit
 		}
 	}
 
-	blog.V(5).Infof("fulltext metadata query models: %s, instances: %s, rid: %s",
+	blog.V(5).Infof("fulltext metadata query models: %v, instances: %v, rid: %s",
 		objectIDs, instMetadataConditions, ctx.Kit.Rid)
 	// set read preference.
 	ctx.SetReadPreference(common.SecondaryPreferredMode)
@@ -735,7 +739,7 @@ func (s *Service) fullTextSearchForObject(ctx *rest.Contexts, objectIDs []string
 // FullTextSearch fulltext search service.
 func (s *Service) FullTextSearch(ctx *rest.Contexts) {
 	// check elastic client.
-	if s.Es.Client == nil {
+	if s.Es.Client == nil && !s.Config.Es.UseMongoDB() {
 		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrorTopoFullTextClientNotInitialized))
 		return
 	}
@@ -758,6 +762,17 @@ func (s *Service) FullTextSearch(ctx *rest.Contexts) {
 		return
 	}
 
+	if s.Config.Es.UseMongoDB() {
+		response, err := s.mongoFullTextSearch(ctx, &request)
+		if err != nil {
+			blog.Errorf("fulltext search with mongodb failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrorTopoFullTextFindErr))
+			return
+		}
+		ctx.RespEntity(response)
+		return
+	}
+
 	// generate elastic query.
 	esQuery, indexes, subCountQueries := request.GenerateESQuery()
 
@@ -0,0 +1,455 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// mongoSearchFieldTypes are the attribute types whose values are highlighted by the mongodb fulltext search backend.
+var mongoSearchFieldTypes = []string{common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeUser,
+	common.FieldTypeList, common.FieldTypeIDRule}
+
+// mongoSearchModelFields are the model fields that are highlighted by the mongodb fulltext search backend.
+var mongoSearchModelFields = []string{common.BKObjIDField, common.BKObjNameField}
+
+// fullTextSearchTarget is a model or the instances of a model that the mongodb fulltext search backend searches.
+type fullTextSearchTarget struct {
+	// Kind data kind model or instance.
+	Kind string
+
+	// Key data model key biz/set/module/host/{common object id}.
+	Key string
+}
+
+// generateMongoTargets returns the search targets of the models/instances filter.
+func (f *FullTextSearchFilter) generateMongoTargets() []fullTextSearchTarget {
+	targets := make([]fullTextSearchTarget, 0)
+	for _, model := range f.Models {
+		targets = append(targets, fullTextSearchTarget{Kind: metadata.DataKindModel, Key: model})
+	}
+	for _, instance := range f.Instances {
+		targets = append(targets, fullTextSearchTarget{Kind: metadata.DataKindInstance, Key: instance})
+	}
+	return targets
+}
+
+// mongoFullTextSearcher does fulltext search with the text index of the models and instances tables, which is
+// created by the admin server when the mongodb backend is enabled. it is used when elasticsearch is not deployed.
+// NOTE: the text index matches whole words, so unlike the elastic wildcard query_string, part of a word is not
+// matched, e.g. "web" matches "web server" but not "webserver".
+type mongoFullTextSearcher struct {
+	service *Service
+	kit     *rest.Kit
+	request *FullTextSearchReq
+	// textCond is the text index search condition of the keyword
+	textCond mapstr.MapStr
+	// fields is the string attributes of each object that are used to highlight the matched values
+	fields map[string][]string
+	// conditions is the search conditions of each target, no condition means no data matches
+	conditions map[fullTextSearchTarget][]mapstr.MapStr
+	// counts is the matched data count of each search condition of each target
+	counts map[fullTextSearchTarget][]int64
+}
+
+// mongoFullTextSearch fulltext search with the mongodb backend.
+func (s *Service) mongoFullTextSearch(ctx *rest.Contexts, request *FullTextSearchReq) (*FullTextSearchResp, error) {
+	searcher := newMongoFullTextSearcher(s, ctx.Kit, request)
+
+	// set read preference.
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+
+	// main search uses sub resource firstly, data is ordered by the targets.
+	targets := request.Filter.generateMongoTargets()
+	mainTargets := targets
+	if request.SubResource != nil {
+		mainTargets = request.SubResource.generateMongoTargets()
+	}
+
+	if err := searcher.getSearchFields(append(targets, mainTargets...)); err != nil {
+		return nil, err
+	}
+
+	// aggregation search.
+	response := &FullTextSearchResp{Aggregations: make([]Aggregation, 0), Hits: make([]SearchResult, 0)}
+	for _, target := range targets {
+		count, err := searcher.count(target)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			continue
+		}
+		response.Aggregations = append(response.Aggregations, Aggregation{Kind: target.Kind, Key: target.Key,
+			Count: count})
+		response.Total += count
+	}
+
+	start, limit := int64(request.Page.Start), int64(request.Page.Limit)
+	for _, target := range mainTargets {
+		if limit <= 0 {
+			break
+		}
+
+		count, err := searcher.count(target)
+		if err != nil {
+			return nil, err
+		}
+		if start >= count {
+			start -= count
+			continue
+		}
+
+		hits, err := searcher.search(target, start, limit)
+		if err != nil {
+			return nil, err
+		}
+		response.Hits = append(response.Hits, hits...)
+		start = 0
+		limit -= int64(len(hits))
+	}
+
+	if len(response.Hits) == 0 {
+		return response, nil
+	}
+
+	attrs, err := s.getObjAttrs(ctx.Kit, response.Hits)
+	if err != nil {
+		blog.Errorf("get object attributes failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		return nil, err
+	}
+	response.Attrs = attrs
+	return response, nil
+}
+
+func newMongoFullTextSearcher(s *Service, kit *rest.Kit, request *FullTextSearchReq) *mongoFullTextSearcher {
+	return &mongoFullTextSearcher{
+		service:    s,
+		kit:        kit,
+		request:    request,
+		textCond:   mongoTextCondition(request.keyword),
+		fields:     make(map[string][]string),
+		conditions: make(map[fullTextSearchTarget][]mapstr.MapStr),
+		counts:     make(map[fullTextSearchTarget][]int64),
+	}
+}
+
+// mongoTextCondition returns the text index search condition of the keyword, the keyword is searched as a phrase
+// so that all of its words are matched in order, the quotes in it are removed since they can not be escaped.
+func mongoTextCondition(keyword string) mapstr.MapStr {
+	phrase := strings.TrimSpace(strings.ReplaceAll(keyword, `"`, " "))
+	return mapstr.MapStr{common.BKDBText: mapstr.MapStr{common.BKDBSearch: `"` + phrase + `"`}}
+}
+
+// getSearchFields gets the string attributes of all the searched objects in one request.
+func (m *mongoFullTextSearcher) getSearchFields(targets []fullTextSearchTarget) error {
+	objIDs := make([]string, 0)
+	for _, target := range targets {
+		if target.Kind == metadata.DataKindInstance {
+			objIDs = append(objIDs, target.Key)
+		}
+	}
+	if len(objIDs) == 0 {
+		return nil
+	}
+
+	attrCond := &metadata.QueryCondition{
+		Fields: []string{common.BKObjIDField, common.BKPropertyIDField, common.BKPropertyTypeField},
+		Condition: mapstr.MapStr{
+			common.BKObjIDField:        mapstr.MapStr{common.BKDBIN: util.StrArrayUnique(objIDs)},
+			common.BKPropertyTypeField: mapstr.MapStr{common.BKDBIN: mongoSearchFieldTypes},
+		},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	attrs, err := m.service.Engine.CoreAPI.CoreService().Model().ReadModelAttrByCondition(m.kit.Ctx, m.kit.Header,
+		attrCond)
+	if err != nil {
+		blog.Errorf("get objects %v attributes failed, err: %v, rid: %s", objIDs, err, m.kit.Rid)
+		return err
+	}
+
+	m.fields = groupSearchFields(attrs.Info)
+	return nil
+}
+
+// groupSearchFields groups the searched string attribute fields by object id.
+func groupSearchFields(attrs []metadata.Attribute) map[string][]string {
+	fields := make(map[string][]string)
+	for _, attr := range attrs {
+		if !util.InStrArr(mongoSearchFieldTypes, attr.PropertyType) {
+			continue
+		}
+		fields[attr.ObjectID] = append(fields[attr.ObjectID], attr.PropertyID)
+	}
+	return fields
+}
+
+// condition returns the search conditions of the target, the matched data of the target is the matched data of
+// these conditions in order, no condition means no data matches.
+func (m *mongoFullTextSearcher) condition(target fullTextSearchTarget) ([]mapstr.MapStr, error) {
+	if conds, exists := m.conditions[target]; exists {
+		return conds, nil
+	}
+
+	conds, err := m.generateCondition(target)
+	if err != nil {
+		return nil, err
+	}
+	m.conditions[target] = conds
+	return conds, nil
+}
+
+func (m *mongoFullTextSearcher) generateCondition(target fullTextSearchTarget) ([]mapstr.MapStr, error) {
+	cond := mapstr.MapStr{common.BKObjIDField: target.Key}
+	if target.Kind == metadata.DataKindInstance {
+		// coreservice sets the bk_obj_id condition for the common object instances itself
+		cond = mapstr.MapStr{}
+	}
+	for key, value := range m.textCond {
+		cond[key] = value
+	}
+
+	if len(m.request.BizID) == 0 {
+		return []mapstr.MapStr{cond}, nil
+	}
+
+	// the same as elastic backend, models are not matched when searching in a business
+	if target.Kind == metadata.DataKindModel {
+		return nil, nil
+	}
+
+	bizID, err := strconv.ParseInt(m.request.BizID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bk_biz_id %s, err: %v", m.request.BizID, err)
+	}
+
+	if target.Key != common.BKInnerObjIDHost {
+		cond[common.BKAppIDField] = bizID
+		return []mapstr.MapStr{cond}, nil
+	}
+
+	hostIDs, err := m.service.Engine.CoreAPI.CoreService().Host().GetDistinctHostIDByTopology(m.kit.Ctx,
+		m.kit.Header, &metadata.DistinctHostIDByTopoRelationRequest{ApplicationIDArr: []int64{bizID}})
+	if err != nil {
+		blog.Errorf("get biz %d host ids failed, err: %v, rid: %s", bizID, err, m.kit.Rid)
+		return nil, err
+	}
+	return splitHostIDCondition(cond, hostIDs), nil
+}
+
+// mongoHostIDBatchSize is the max number of host ids in one host search condition of a business
+const mongoHostIDBatchSize = 500
+
+// splitHostIDCondition splits the host ids of a business into batches, and returns the search condition of each
+// batch, so that the size of the condition is bounded no matter how many hosts the business has.
+func splitHostIDCondition(cond mapstr.MapStr, hostIDs []int64) []mapstr.MapStr {
+	conds := make([]mapstr.MapStr, 0)
+	for start := 0; start < len(hostIDs); start += mongoHostIDBatchSize {
+		end := start + mongoHostIDBatchSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+
+		batchCond := cond.Clone()
+		batchCond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: hostIDs[start:end]}
+		conds = append(conds, batchCond)
+	}
+	return conds
+}
+
+// count returns the matched data count of the target.
+func (m *mongoFullTextSearcher) count(target fullTextSearchTarget) (int64, error) {
+	counts, err := m.conditionCounts(target)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, condCount := range counts {
+		count += condCount
+	}
+	return count, nil
+}
+
+// conditionCounts returns the matched data count of each search condition of the target.
+func (m *mongoFullTextSearcher) conditionCounts(target fullTextSearchTarget) ([]int64, error) {
+	if counts, exists := m.counts[target]; exists {
+		return counts, nil
+	}
+
+	conds, err := m.condition(target)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(conds))
+	for idx, cond := range conds {
+		counts[idx], err = m.countByCond(target, cond)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.counts[target] = counts
+	return counts, nil
+}
+
+func (m *mongoFullTextSearcher) countByCond(target fullTextSearchTarget, cond mapstr.MapStr) (int64, error) {
+	if target.Kind == metadata.DataKindModel {
+		result, err := m.service.Engine.CoreAPI.CoreService().Model().ReadModel(m.kit.Ctx, m.kit.Header,
+			&metadata.QueryCondition{Condition: cond, Page: metadata.BasePage{Limit: 1}})
+		if err != nil {
+			blog.Errorf("fulltext count model %s failed, err: %v, rid: %s", target.Key, err, m.kit.Rid)
+			return 0, err
+		}
+		return result.Count, nil
+	}
+
+	result, err := m.service.Engine.CoreAPI.CoreService().Instance().CountInstances(m.kit.Ctx, m.kit.Header,
+		target.Key, &metadata.Condition{Condition: cond})
+	if err != nil {
+		// the text index of a new model's instance table may not be created yet, skip it instead of failing
+		// the whole search, its instances are matched after the admin server syncs the indexes
+		if isTextIndexNotFoundError(err) {
+			blog.Warnf("object %s instance table has no text index, skip it, rid: %s", target.Key, m.kit.Rid)
+			return 0, nil
+		}
+		blog.Errorf("fulltext count object %s instances failed, err: %v, rid: %s", target.Key, err, m.kit.Rid)
+		return 0, err
+	}
+	return int64(result.Count), nil
+}
+
+// isTextIndexNotFoundError returns if the error is returned by mongodb because the table has no text index
+func isTextIndexNotFoundError(err error) bool {
+	return strings.Contains(err.Error(), "text index required")
+}
+
+// search returns the matched data of the target with paging.
+func (m *mongoFullTextSearcher) search(target fullTextSearchTarget, start, limit int64) ([]SearchResult, error) {
+	counts, err := m.conditionCounts(target)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0)
+	for idx, cond := range m.conditions[target] {
+		if limit <= 0 {
+			break
+		}
+		if start >= counts[idx] {
+			start -= counts[idx]
+			continue
+		}
+
+		hits, err := m.searchByCond(target, cond, start, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, hits...)
+		start = 0
+		limit -= int64(len(hits))
+	}
+	return results, nil
+}
+
+func (m *mongoFullTextSearcher) searchByCond(target fullTextSearchTarget, cond mapstr.MapStr, start,
+	limit int64) ([]SearchResult, error) {
+
+	results := make([]SearchResult, 0)
+	if target.Kind == metadata.DataKindModel {
+		input := &metadata.QueryCondition{
+			Fields:         m.request.Fields,
+			Condition:      cond,
+			Page:           metadata.BasePage{Start: int(start), Limit: int(limit)},
+			DisableCounter: true,
+		}
+		objects, err := m.service.Engine.CoreAPI.CoreService().Model().ReadModel(m.kit.Ctx, m.kit.Header, input)
+		if err != nil {
+			blog.Errorf("fulltext search model %s failed, err: %v, rid: %s", target.Key, err, m.kit.Rid)
+			return nil, err
+		}
+
+		for _, object := range objects.Info {
+			source := mapstr.MapStr{common.BKObjIDField: object.ObjectID, common.BKObjNameField: object.ObjectName}
+			results = append(results, SearchResult{
+				Kind:      metadata.DataKindModel,
+				Key:       object.ObjectID,
+				Source:    object,
+				Highlight: mongoHighlight(source, mongoSearchModelFields, m.request.keyword),
+			})
+		}
+		return results, nil
+	}
+
+	input := &metadata.QueryCondition{
+		Condition:      cond,
+		Page:           metadata.BasePage{Start: int(start), Limit: int(limit), Sort: common.GetInstIDField(target.Key)},
+		DisableCounter: true,
+	}
+	instances, err := m.service.Engine.CoreAPI.CoreService().Instance().ReadInstance(m.kit.Ctx, m.kit.Header,
+		target.Key, input)
+	if err != nil {
+		blog.Errorf("fulltext search object %s instances failed, err: %v, rid: %s", target.Key, err, m.kit.Rid)
+		return nil, err
+	}
+
+	for _, instance := range instances.Info {
+		results = append(results, SearchResult{
+			Kind:      metadata.DataKindInstance,
+			Key:       target.Key,
+			Source:    instance,
+			Highlight: mongoHighlight(instance, m.fields[target.Key], m.request.keyword),
+		})
+	}
+	return results, nil
+}
+
+// mongoHighlight returns the highlight of the matched values in the same format as elastic keywords highlight.
+func mongoHighlight(source mapstr.MapStr, fields []string, keyword string) map[string][]string {
+	lowerKeyword := strings.ToLower(keyword)
+	keywords := make([]string, 0)
+
+	for _, field := range fields {
+		values := make([]interface{}, 0)
+		switch value := source[field].(type) {
+		case []interface{}:
+			values = append(values, value...)
+		case nil:
+			continue
+		default:
+			values = append(values, value)
+		}
+
+		for _, value := range values {
+			str := util.GetStrByInterface(value)
+			if strings.Contains(strings.ToLower(str), lowerKeyword) {
+				keywords = append(keywords, "<em>"+str+"</em>")
+			}
+		}
+	}
+
+	return map[string][]string{
+		metadata.IndexPropertyKeywords: util.StrArrayUnique(keywords),
+		metadata.TablePropertyName:     make([]string, 0),
+	}
+}
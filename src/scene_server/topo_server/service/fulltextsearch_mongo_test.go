@@ -0,0 +1,122 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoTextCondition(t *testing.T) {
+	require.Equal(t, mapstr.MapStr{common.BKDBText: mapstr.MapStr{common.BKDBSearch: `"web server"`}},
+		mongoTextCondition("web server"))
+
+	// quotes can not be escaped in the phrase, they are removed
+	require.Equal(t, mapstr.MapStr{common.BKDBText: mapstr.MapStr{common.BKDBSearch: `"a  b"`}},
+		mongoTextCondition(`"a" b`))
+}
+
+func TestGenerateMongoCondition(t *testing.T) {
+	request := &FullTextSearchReq{QueryString: "web", Filter: &FullTextSearchFilter{Models: []string{"biz"},
+		Instances: []string{"set", "switch"}}, Page: &Page{Limit: 10}}
+	require.NoError(t, request.Validate())
+	textCond := mongoTextCondition("web")
+
+	searcher := newMongoFullTextSearcher(nil, &rest.Kit{}, request)
+	targets := request.Filter.generateMongoTargets()
+	require.Equal(t, []fullTextSearchTarget{{Kind: metadata.DataKindModel, Key: "biz"},
+		{Kind: metadata.DataKindInstance, Key: "set"}, {Kind: metadata.DataKindInstance, Key: "switch"}}, targets)
+
+	cond, err := searcher.condition(targets[0])
+	require.NoError(t, err)
+	require.Equal(t, []mapstr.MapStr{{common.BKObjIDField: "biz", common.BKDBText: textCond[common.BKDBText]}},
+		cond)
+
+	// coreservice sets the bk_obj_id condition of instances itself
+	cond, err = searcher.condition(targets[2])
+	require.NoError(t, err)
+	require.Equal(t, []mapstr.MapStr{textCond}, cond)
+
+	// models are not matched in a business, and instances are searched in the business
+	request.BizID = "2"
+	searcher = newMongoFullTextSearcher(nil, &rest.Kit{}, request)
+	cond, err = searcher.condition(targets[0])
+	require.NoError(t, err)
+	require.Empty(t, cond)
+
+	cond, err = searcher.condition(targets[1])
+	require.NoError(t, err)
+	require.Equal(t, []mapstr.MapStr{{common.BKAppIDField: int64(2), common.BKDBText: textCond[common.BKDBText]}},
+		cond)
+
+	request.BizID = "x"
+	searcher = newMongoFullTextSearcher(nil, &rest.Kit{}, request)
+	_, err = searcher.condition(targets[1])
+	require.Error(t, err)
+}
+
+func TestSplitHostIDCondition(t *testing.T) {
+	textCond := mongoTextCondition("web")
+	require.Empty(t, splitHostIDCondition(textCond, nil))
+
+	hostIDs := make([]int64, mongoHostIDBatchSize+1)
+	for idx := range hostIDs {
+		hostIDs[idx] = int64(idx + 1)
+	}
+
+	conds := splitHostIDCondition(textCond, hostIDs)
+	require.Len(t, conds, 2)
+	require.Equal(t, mapstr.MapStr{common.BKDBText: textCond[common.BKDBText],
+		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs[:mongoHostIDBatchSize]}}, conds[0])
+	require.Equal(t, mapstr.MapStr{common.BKDBText: textCond[common.BKDBText],
+		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs[mongoHostIDBatchSize:]}}, conds[1])
+
+	// the origin condition is not changed
+	require.NotContains(t, textCond, common.BKHostIDField)
+}
+
+func TestIsTextIndexNotFoundError(t *testing.T) {
+	require.True(t, isTextIndexNotFoundError(errors.New("(IndexNotFound) text index required for $text query")))
+	require.False(t, isTextIndexNotFoundError(errors.New("connection refused")))
+}
+
+func TestGroupSearchFields(t *testing.T) {
+	attrs := []metadata.Attribute{
+		{ObjectID: "switch", PropertyID: "name", PropertyType: common.FieldTypeSingleChar},
+		{ObjectID: "switch", PropertyID: "port", PropertyType: common.FieldTypeInt},
+		{ObjectID: "switch", PropertyID: "desc", PropertyType: common.FieldTypeLongChar},
+		{ObjectID: "set", PropertyID: "bk_set_name", PropertyType: common.FieldTypeSingleChar},
+	}
+	require.Equal(t, map[string][]string{"switch": {"name", "desc"}, "set": {"bk_set_name"}},
+		groupSearchFields(attrs))
+}
+
+func TestMongoHighlight(t *testing.T) {
+	source := mapstr.MapStr{
+		"name": "Web Server",
+		"tags": []interface{}{"web", "db"},
+		"desc": "database",
+		"port": 80,
+	}
+	highlight := mongoHighlight(source, []string{"name", "tags", "desc", "other"}, "web")
+	require.ElementsMatch(t, []string{"<em>Web Server</em>", "<em>web</em>"},
+		highlight[metadata.IndexPropertyKeywords])
+	require.Empty(t, highlight[metadata.TablePropertyName])
+}
@@ -0,0 +1,144 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// ListInstRecycleBin list the archived deleted instances of the model
+func (s *Service) ListInstRecycleBin(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if objID == common.BKInnerObjIDHost {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField))
+		return
+	}
+
+	opt := new(metadata.ListRecycleBinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Instance().ListRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header, objID, opt)
+	if err != nil {
+		blog.Errorf("list %s recycle bin failed, err: %v, opt: %+v, rid: %s", objID, err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// PreviewRestoreInstRecycleBin preview the instances, instance associations and the conflicts of restoring the
+// archived deleted instances
+func (s *Service) PreviewRestoreInstRecycleBin(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if objID == common.BKInnerObjIDHost {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField))
+		return
+	}
+
+	opt := new(metadata.RestoreRecycleBinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Instance().PreviewRestoreRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header,
+		objID, opt)
+	if err != nil {
+		blog.Errorf("preview restore %s failed, err: %v, opt: %+v, rid: %s", objID, err, opt, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// RestoreInstRecycleBin restore the archived deleted instances with their instance associations
+func (s *Service) RestoreInstRecycleBin(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	if objID == common.BKInnerObjIDHost {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField))
+		return
+	}
+
+	opt := new(metadata.RestoreRecycleBinOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	var result *metadata.RestoreRecycleBinResult
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		var err error
+		result, err = s.restoreInstances(ctx.Kit, objID, opt)
+		return err
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// restoreInstances restore the archived deleted instances and save the audit log of the restored instances
+func (s *Service) restoreInstances(kit *rest.Kit, objID string, opt *metadata.RestoreRecycleBinOption) (
+	*metadata.RestoreRecycleBinResult, error) {
+
+	result, ccErr := s.Engine.CoreAPI.CoreService().Instance().RestoreRecycleBin(kit.Ctx, kit.Header, objID, opt)
+	if ccErr != nil {
+		blog.Errorf("restore %s failed, err: %v, opt: %+v, rid: %s", objID, ccErr, opt, kit.Rid)
+		return nil, ccErr
+	}
+
+	audit := auditlog.NewInstanceAudit(s.Engine.CoreAPI.CoreService())
+	genParam := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditCreate)
+	auditLogs, err := audit.GenerateAuditLog(genParam, objID, result.Instances)
+	if err != nil {
+		blog.Errorf("generate restore %s audit log failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	for i := range auditLogs {
+		auditLogs[i].Action = metadata.AuditRecover
+	}
+
+	if err = audit.SaveAuditLog(kit, auditLogs...); err != nil {
+		blog.Errorf("save restore %s audit log failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+	}
+	return result, nil
+}
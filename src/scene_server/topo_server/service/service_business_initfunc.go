@@ -268,6 +268,12 @@ func (s *Service) initBusinessInst(web *restful.WebService) {
 		Handler: s.SearchObjectInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/count/instances/object/{bk_obj_id}",
 		Handler: s.CountObjectInstances})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/recycle_bin/instance/object/{bk_obj_id}",
+		Handler: s.ListInstRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/find/recycle_bin/instance/object/{bk_obj_id}/restore_preview", Handler: s.PreviewRestoreInstRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost,
+		Path: "/update/recycle_bin/instance/object/{bk_obj_id}/restore", Handler: s.RestoreInstRecycleBin})
 
 	utility.AddToRestfulWebService(web)
 }
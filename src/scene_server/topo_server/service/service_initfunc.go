@@ -52,8 +52,13 @@ func (s *Service) initAuditLog(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/find/audit_dict", Handler: s.SearchAuditDict})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/audit_list", Handler: s.SearchAuditList})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/audit_archive",
+		Handler: s.SearchAuditArchive})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/audit", Handler: s.SearchAuditDetail})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/inst_audit", Handler: s.SearchInstAudit})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/audit/revert_preview",
+		Handler: s.PreviewRevertAudit})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/audit/revert", Handler: s.RevertAudit})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -0,0 +1,528 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+import (
+	"context"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+)
+
+// rearrangeHostEvents rearrange host events into membership events of the changed hosts, the deleted host's
+// membership events are generated by its host relation delete events, so host delete events are skipped.
+func (m *membership) rearrangeHostEvents(es []*types.Event, rid string) ([]*types.Event, error) {
+	hostEvents := make(map[int64]*types.Event)
+	for _, e := range es {
+		if e.OperationType == types.Delete {
+			continue
+		}
+
+		hostID := gjson.GetBytes(e.DocBytes, common.BKHostIDField).Int()
+		if hostID <= 0 {
+			blog.Errorf("dynamic group membership event, get host id from host: %s failed, skip, rid: %s",
+				e.DocBytes, rid)
+			continue
+		}
+
+		// the same host's events are aggregated to the last one
+		hostEvents[hostID] = e
+	}
+
+	return m.genHostMembershipEvents(hostEvents, rid)
+}
+
+// hostRelationArchive is the archived host relation data of the deleted host relation
+type hostRelationArchive struct {
+	Oid    string              `bson:"oid"`
+	Detail metadata.ModuleHost `bson:"detail"`
+}
+
+// rearrangeHostRelationEvents rearrange host relation events into membership events of the related hosts
+func (m *membership) rearrangeHostRelationEvents(es []*types.Event, rid string) ([]*types.Event, error) {
+	hostEvents := make(map[int64]*types.Event)
+	deleteEventMap := make(map[string]*types.Event)
+	deleteOids := make([]string, 0)
+	for _, e := range es {
+		if e.OperationType == types.Delete {
+			deleteEventMap[e.Oid] = e
+			deleteOids = append(deleteOids, e.Oid)
+			continue
+		}
+
+		hostID := gjson.GetBytes(e.DocBytes, common.BKHostIDField).Int()
+		if hostID <= 0 {
+			blog.Errorf("dynamic group membership event, get host id from relation: %s failed, skip, rid: %s",
+				e.DocBytes, rid)
+			continue
+		}
+		hostEvents[hostID] = e
+	}
+
+	if len(deleteOids) > 0 {
+		// get the deleted host relations' host ids from del archive table
+		filter := map[string]interface{}{
+			"oid":  map[string]interface{}{common.BKDBIN: deleteOids},
+			"coll": common.BKTableNameModuleHostConfig,
+		}
+
+		archives := make([]hostRelationArchive, 0)
+		if err := m.ccDB.Table(common.BKTableNameDelArchive).Find(filter).All(context.Background(),
+			&archives); err != nil {
+			m.metrics.CollectMongoError()
+			blog.Errorf("get archived host relations failed, oids: %+v, err: %v, rid: %s", deleteOids, err, rid)
+			return nil, err
+		}
+
+		for _, archive := range archives {
+			e, exists := deleteEventMap[archive.Oid]
+			if !exists || archive.Detail.HostID <= 0 {
+				blog.Errorf("get archived host relation %+v event failed, skip, rid: %s", archive, rid)
+				continue
+			}
+
+			if _, exists := hostEvents[archive.Detail.HostID]; !exists {
+				hostEvents[archive.Detail.HostID] = e
+			}
+		}
+	}
+
+	return m.genHostMembershipEvents(hostEvents, rid)
+}
+
+// genHostMembershipEvents evaluate the host dynamic groups of the hosts' biz on the hosts, then compare the result
+// with the stored members of the hosts to generate membership events
+func (m *membership) genHostMembershipEvents(hostEvents map[int64]*types.Event, rid string) ([]*types.Event,
+	error) {
+
+	if len(hostEvents) == 0 {
+		return make([]*types.Event, 0), nil
+	}
+
+	hostIDs := make([]int64, 0, len(hostEvents))
+	for hostID := range hostEvents {
+		hostIDs = append(hostIDs, hostID)
+	}
+
+	// get the current biz of the hosts
+	relCond := map[string]interface{}{
+		common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs},
+	}
+	relations := make([]metadata.ModuleHost, 0)
+	err := m.ccDB.Table(common.BKTableNameModuleHostConfig).Find(relCond).
+		Fields(common.BKAppIDField, common.BKHostIDField).All(context.Background(), &relations)
+	if err != nil {
+		m.metrics.CollectMongoError()
+		blog.Errorf("get host relations failed, host ids: %+v, err: %v, rid: %s", hostIDs, err, rid)
+		return nil, err
+	}
+
+	bizIDs := make([]int64, 0)
+	bizHostIDs := make(map[int64][]int64)
+	bizHostExists := make(map[int64]map[int64]struct{})
+	for _, rel := range relations {
+		if _, exists := bizHostExists[rel.AppID]; !exists {
+			bizIDs = append(bizIDs, rel.AppID)
+			bizHostExists[rel.AppID] = make(map[int64]struct{})
+		}
+
+		if _, exists := bizHostExists[rel.AppID][rel.HostID]; exists {
+			continue
+		}
+		bizHostExists[rel.AppID][rel.HostID] = struct{}{}
+		bizHostIDs[rel.AppID] = append(bizHostIDs[rel.AppID], rel.HostID)
+	}
+
+	groups, err := m.getHostDynamicGroups(map[string]interface{}{
+		common.BKAppIDField: map[string]interface{}{common.BKDBIN: bizIDs},
+	}, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := m.getGroupMembers(map[string]interface{}{
+		common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs},
+	}, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceFunc := func(member metadata.DynamicGroupMember) *types.Event {
+		return hostEvents[member.HostID]
+	}
+
+	events := make([]*types.Event, 0)
+	for _, group := range groups {
+		if len(bizHostIDs[group.AppID]) == 0 {
+			continue
+		}
+
+		matched, err := m.matchGroupHosts(group, bizHostIDs[group.AppID], rid)
+		if err != nil {
+			return nil, err
+		}
+
+		joined, left := diffGroupMembers(group, matched, previous[group.ID])
+		delete(previous, group.ID)
+
+		events, err = appendMembershipEvents(events, joined, left, sourceFunc)
+		if err != nil {
+			blog.Errorf("generate dynamic group %s membership events failed, err: %v, rid: %s", group.ID, err, rid)
+			return nil, err
+		}
+	}
+
+	// the hosts leave the dynamic groups that is not in their biz or is deleted
+	events, err = appendLeftGroupsEvents(events, previous, sourceFunc)
+	if err != nil {
+		blog.Errorf("generate host membership events of left groups failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// rearrangeDynamicGroupEvents rearrange dynamic group events into membership events of the changed dynamic groups
+func (m *membership) rearrangeDynamicGroupEvents(es []*types.Event, rid string) ([]*types.Event, error) {
+	groupIDs := make([]string, 0)
+	groupEvents := make(map[string]*types.Event)
+	var deleteEvent *types.Event
+	for _, e := range es {
+		// deleted dynamic group is not archived, so all the groups that are deleted is checked using its last event
+		if e.OperationType == types.Delete {
+			deleteEvent = e
+			continue
+		}
+
+		groupID := gjson.GetBytes(e.DocBytes, common.BKFieldID).String()
+		if len(groupID) == 0 {
+			blog.Errorf("dynamic group membership event, get id from dynamic group: %s failed, skip, rid: %s",
+				e.DocBytes, rid)
+			continue
+		}
+
+		if _, exists := groupEvents[groupID]; !exists {
+			groupIDs = append(groupIDs, groupID)
+		}
+		groupEvents[groupID] = e
+	}
+
+	events, err := m.genChangedGroupsEvents(groupIDs, groupEvents, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleteEvent == nil {
+		return events, nil
+	}
+
+	deletedEvents, err := m.genDeletedGroupsEvents(deleteEvent, groupEvents, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(events, deletedEvents...), nil
+}
+
+// rearrangeTopoEvents rearrange set or module events into membership events of the dynamic groups in their biz that
+// have conditions of the set or module, since the membership depends on the set and module attributes. the inserted
+// and deleted set or module has no host, the hosts' membership changes are generated by their host relation events.
+func (m *membership) rearrangeTopoEvents(objID string, es []*types.Event, rid string) ([]*types.Event, error) {
+	bizIDs := make([]int64, 0)
+	bizEvents := make(map[int64]*types.Event)
+	for _, e := range es {
+		if e.OperationType != types.Update && e.OperationType != types.Replace {
+			continue
+		}
+
+		bizID := gjson.GetBytes(e.DocBytes, common.BKAppIDField).Int()
+		if bizID <= 0 {
+			blog.Errorf("dynamic group membership event, get biz id from %s: %s failed, skip, rid: %s", objID,
+				e.DocBytes, rid)
+			continue
+		}
+
+		if _, exists := bizEvents[bizID]; !exists {
+			bizIDs = append(bizIDs, bizID)
+		}
+		bizEvents[bizID] = e
+	}
+
+	if len(bizIDs) == 0 {
+		return make([]*types.Event, 0), nil
+	}
+
+	groups, err := m.getHostDynamicGroups(map[string]interface{}{
+		common.BKAppIDField: map[string]interface{}{common.BKDBIN: bizIDs},
+	}, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]string, 0)
+	groupEvents := make(map[string]*types.Event)
+	for _, group := range groups {
+		if !hasObjCond(group.Info, objID) {
+			continue
+		}
+		groupIDs = append(groupIDs, group.ID)
+		groupEvents[group.ID] = bizEvents[group.AppID]
+	}
+
+	return m.genChangedGroupsEvents(groupIDs, groupEvents, rid)
+}
+
+// genChangedGroupsEvents evaluate the created or updated dynamic groups on all hosts in their biz, then compare the
+// result with the stored members of the dynamic groups to generate membership events
+func (m *membership) genChangedGroupsEvents(groupIDs []string, groupEvents map[string]*types.Event, rid string) (
+	[]*types.Event, error) {
+
+	if len(groupIDs) == 0 {
+		return make([]*types.Event, 0), nil
+	}
+
+	groups, err := m.getHostDynamicGroups(map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBIN: groupIDs},
+	}, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := m.getGroupMembers(map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBIN: groupIDs},
+	}, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceFunc := func(member metadata.DynamicGroupMember) *types.Event {
+		return groupEvents[member.ID]
+	}
+
+	events := make([]*types.Event, 0)
+	for _, group := range groups {
+		matched, err := m.matchGroupHosts(group, nil, rid)
+		if err != nil {
+			return nil, err
+		}
+
+		joined, left := diffGroupMembers(group, matched, previous[group.ID])
+		delete(previous, group.ID)
+
+		events, err = appendMembershipEvents(events, joined, left, sourceFunc)
+		if err != nil {
+			blog.Errorf("generate dynamic group %s membership events failed, err: %v, rid: %s", group.ID, err, rid)
+			return nil, err
+		}
+	}
+
+	// the remaining dynamic groups are deleted after the events occur
+	events, err = appendLeftGroupsEvents(events, previous, sourceFunc)
+	if err != nil {
+		blog.Errorf("generate membership events of deleted dynamic groups failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// genDeletedGroupsEvents generate leaving events for all the members of the deleted dynamic groups, skip the dynamic
+// groups whose membership events are already generated by their other events
+func (m *membership) genDeletedGroupsEvents(source *types.Event, groupEvents map[string]*types.Event, rid string) (
+	[]*types.Event, error) {
+
+	memberGroupIDs, err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Distinct(context.Background(),
+		common.BKFieldID, map[string]interface{}{})
+	if err != nil {
+		m.metrics.CollectMongoError()
+		blog.Errorf("get distinct dynamic group ids from members failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	if len(memberGroupIDs) == 0 {
+		return make([]*types.Event, 0), nil
+	}
+
+	existGroupIDs, err := m.ccDB.Table(common.BKTableNameDynamicGroup).Distinct(context.Background(),
+		common.BKFieldID, map[string]interface{}{
+			common.BKFieldID:    map[string]interface{}{common.BKDBIN: memberGroupIDs},
+			common.BKObjIDField: common.BKInnerObjIDHost,
+		})
+	if err != nil {
+		m.metrics.CollectMongoError()
+		blog.Errorf("get existing dynamic group ids failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	existGroupIDMap := make(map[interface{}]struct{})
+	for _, groupID := range existGroupIDs {
+		existGroupIDMap[groupID] = struct{}{}
+	}
+
+	deletedGroupIDs := make([]interface{}, 0)
+	for _, groupID := range memberGroupIDs {
+		if _, exists := existGroupIDMap[groupID]; exists {
+			continue
+		}
+
+		if id, ok := groupID.(string); ok {
+			if _, exists := groupEvents[id]; exists {
+				continue
+			}
+		}
+		deletedGroupIDs = append(deletedGroupIDs, groupID)
+	}
+
+	if len(deletedGroupIDs) == 0 {
+		return make([]*types.Event, 0), nil
+	}
+
+	previous, err := m.getGroupMembers(map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBIN: deletedGroupIDs},
+	}, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := appendLeftGroupsEvents(make([]*types.Event, 0), previous,
+		func(metadata.DynamicGroupMember) *types.Event { return source })
+	if err != nil {
+		blog.Errorf("generate membership events of deleted dynamic groups failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// getHostDynamicGroups get host dynamic groups by condition, sorted by dynamic group id
+func (m *membership) getHostDynamicGroups(cond map[string]interface{}, rid string) ([]metadata.DynamicGroup, error) {
+	cond[common.BKObjIDField] = common.BKInnerObjIDHost
+
+	groups := make([]metadata.DynamicGroup, 0)
+	err := m.ccDB.Table(common.BKTableNameDynamicGroup).Find(cond).Sort(common.BKFieldID).
+		All(context.Background(), &groups)
+	if err != nil {
+		m.metrics.CollectMongoError()
+		blog.Errorf("get host dynamic groups failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// getGroupMembers get stored dynamic group members by condition, returns dynamic group id -> host id -> member map
+func (m *membership) getGroupMembers(cond map[string]interface{}, rid string) (
+	map[string]map[int64]metadata.DynamicGroupMember, error) {
+
+	members := make([]metadata.DynamicGroupMember, 0)
+	err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Find(cond).All(context.Background(), &members)
+	if err != nil {
+		m.metrics.CollectMongoError()
+		blog.Errorf("get dynamic group members failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+		return nil, err
+	}
+
+	memberMap := make(map[string]map[int64]metadata.DynamicGroupMember)
+	for _, member := range members {
+		if _, exists := memberMap[member.ID]; !exists {
+			memberMap[member.ID] = make(map[int64]metadata.DynamicGroupMember)
+		}
+		memberMap[member.ID][member.HostID] = member
+	}
+
+	return memberMap, nil
+}
+
+// diffGroupMembers compare the matched hosts of the dynamic group with its previous members, returns the members
+// that joined the dynamic group and the members that left the dynamic group, both sorted by host id.
+func diffGroupMembers(group metadata.DynamicGroup, matched map[int64]string,
+	previous map[int64]metadata.DynamicGroupMember) ([]metadata.DynamicGroupMember, []metadata.DynamicGroupMember) {
+
+	joined := make([]metadata.DynamicGroupMember, 0)
+	for hostID, supplierAccount := range matched {
+		if _, exists := previous[hostID]; exists {
+			continue
+		}
+
+		joined = append(joined, metadata.DynamicGroupMember{
+			ID:              group.ID,
+			AppID:           group.AppID,
+			HostID:          hostID,
+			SupplierAccount: supplierAccount,
+		})
+	}
+
+	left := make([]metadata.DynamicGroupMember, 0)
+	for hostID, member := range previous {
+		if _, exists := matched[hostID]; !exists {
+			left = append(left, member)
+		}
+	}
+
+	sortMembers(joined)
+	sortMembers(left)
+	return joined, left
+}
+
+func sortMembers(members []metadata.DynamicGroupMember) {
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].ID != members[j].ID {
+			return members[i].ID < members[j].ID
+		}
+		return members[i].HostID < members[j].HostID
+	})
+}
+
+// appendMembershipEvents generate the joining and leaving membership events and append them to events, the source
+// event of each membership event is returned by sourceFunc
+func appendMembershipEvents(events []*types.Event, joined, left []metadata.DynamicGroupMember,
+	sourceFunc func(metadata.DynamicGroupMember) *types.Event) ([]*types.Event, error) {
+
+	for _, member := range joined {
+		e, err := newMembershipEvent(sourceFunc(member), member, types.Insert)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	for _, member := range left {
+		e, err := newMembershipEvent(sourceFunc(member), member, types.Delete)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// appendLeftGroupsEvents generate leaving membership events for all members in the group members map
+func appendLeftGroupsEvents(events []*types.Event, groupMembers map[string]map[int64]metadata.DynamicGroupMember,
+	sourceFunc func(metadata.DynamicGroupMember) *types.Event) ([]*types.Event, error) {
+
+	left := make([]metadata.DynamicGroupMember, 0)
+	for _, members := range groupMembers {
+		for _, member := range members {
+			left = append(left, member)
+		}
+	}
+	sortMembers(left)
+
+	return appendMembershipEvents(events, nil, left, sourceFunc)
+}
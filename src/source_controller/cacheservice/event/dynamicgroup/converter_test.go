@@ -0,0 +1,146 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// membershipChanges formats membership events as "+group/host" for joining and "-group/host" for leaving
+func membershipChanges(t *testing.T, events []*types.Event) []string {
+	changes := make([]string, 0)
+	for _, e := range events {
+		member := new(metadata.DynamicGroupMember)
+		require.NoError(t, json.Unmarshal(e.DocBytes, member))
+
+		oper := "+"
+		if e.OperationType == types.Delete {
+			oper = "-"
+		}
+		changes = append(changes, fmt.Sprintf("%s%s/%d", oper, member.ID, member.HostID))
+	}
+	return changes
+}
+
+func insertTestMembers(t *testing.T, m *membership, members ...metadata.DynamicGroupMember) {
+	require.NoError(t, m.ccDB.Table(common.BKTableNameDynamicGroupMember).Insert(context.Background(), members))
+}
+
+func newTestMember(groupID string, hostID int64) metadata.DynamicGroupMember {
+	return metadata.DynamicGroupMember{ID: groupID, AppID: 2, HostID: hostID, SupplierAccount: "0"}
+}
+
+func TestDiffGroupMembers(t *testing.T) {
+	group := metadata.DynamicGroup{AppID: 2, ID: "g"}
+	matched := map[int64]string{3: "0", 1: "0", 2: "0"}
+	previous := map[int64]metadata.DynamicGroupMember{
+		2: newTestMember("g", 2),
+		5: newTestMember("g", 5),
+		4: newTestMember("g", 4),
+	}
+
+	joined, left := diffGroupMembers(group, matched, previous)
+	require.Equal(t, []metadata.DynamicGroupMember{newTestMember("g", 1), newTestMember("g", 3)}, joined)
+	require.Equal(t, []metadata.DynamicGroupMember{newTestMember("g", 4), newTestMember("g", 5)}, left)
+
+	joined, left = diffGroupMembers(group, nil, nil)
+	require.Empty(t, joined)
+	require.Empty(t, left)
+}
+
+func TestRearrangeTopoEvents(t *testing.T) {
+	m := newTestMembership(t, event.SetKey)
+	insertTestMembers(t, m, newTestMember("set", 1), newTestMember("os", 1), newTestMember("os", 2))
+
+	// set 11 is renamed to prod, host 2 joins the group with set condition
+	require.NoError(t, m.ccDB.Table(common.BKTableNameBaseSet).Update(context.Background(),
+		mapstr.MapStr{common.BKSetIDField: 11}, mapstr.MapStr{common.BKSetNameField: "prod"}))
+
+	es := []*types.Event{
+		{Oid: "1", OperationType: types.Insert, DocBytes: []byte(`{"bk_set_id":12,"bk_biz_id":3}`)},
+		{Oid: "2", OperationType: types.Update, DocBytes: []byte(`{"bk_set_id":11,"bk_biz_id":2}`)},
+		{Oid: "3", OperationType: types.Delete, DocBytes: []byte(`{"bk_set_id":13,"bk_biz_id":4}`)},
+	}
+	events, err := m.rearrangeTopoEvents(common.BKInnerObjIDSet, es, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"+set/2"}, membershipChanges(t, events))
+	require.Equal(t, "2", events[0].Oid)
+
+	// module 100 is renamed, host 1 leaves the group with module condition, other groups are not evaluated
+	insertTestMembers(t, m, newTestMember("module", 1))
+	require.NoError(t, m.ccDB.Table(common.BKTableNameBaseModule).Update(context.Background(),
+		mapstr.MapStr{common.BKModuleIDField: 100}, mapstr.MapStr{common.BKModuleNameField: "app"}))
+
+	es = []*types.Event{{Oid: "4", OperationType: types.Update, DocBytes: []byte(`{"bk_module_id":100,"bk_biz_id":2}`)}}
+	events, err = m.rearrangeTopoEvents(common.BKInnerObjIDModule, es, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"-module/1"}, membershipChanges(t, events))
+
+	// inserted and deleted topo nodes and events without biz id generate no membership events
+	es = []*types.Event{
+		{Oid: "5", OperationType: types.Insert, DocBytes: []byte(`{"bk_module_id":102,"bk_biz_id":2}`)},
+		{Oid: "6", OperationType: types.Update, DocBytes: []byte(`{"bk_module_id":102}`)},
+	}
+	events, err = m.rearrangeTopoEvents(common.BKInnerObjIDModule, es, "")
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestRearrangeHostEvents(t *testing.T) {
+	m := newTestMembership(t, event.HostKey)
+	insertTestMembers(t, m, newTestMember("os", 1), newTestMember("os", 2))
+
+	// host 2 os type changes, it leaves the os group, host 1 joins the set and module groups
+	require.NoError(t, m.ccDB.Table(common.BKTableNameBaseHost).Update(context.Background(),
+		mapstr.MapStr{common.BKHostIDField: 2}, mapstr.MapStr{common.BKOSTypeField: "2"}))
+
+	es := []*types.Event{
+		{Oid: "1", OperationType: types.Update, DocBytes: []byte(`{"bk_host_id":1}`)},
+		{Oid: "2", OperationType: types.Update, DocBytes: []byte(`{"bk_host_id":2}`)},
+		{Oid: "3", OperationType: types.Delete, DocBytes: []byte(`{"bk_host_id":3}`)},
+	}
+	events, err := m.rearrangeHostEvents(es, "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"+module/1", "+set/1", "-os/2"}, membershipChanges(t, events))
+
+	// the stored members are updated by the membership events, so the same events generate nothing afterwards
+	require.NoError(t, m.saveMembers(events, ""))
+	events, err = m.rearrangeHostEvents(es, "")
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestRearrangeDynamicGroupEvents(t *testing.T) {
+	m := newTestMembership(t, event.DynamicGroupKey)
+	insertTestMembers(t, m, newTestMember("os", 1), newTestMember("deleted", 2))
+
+	es := []*types.Event{
+		{Oid: "1", OperationType: types.Insert, DocBytes: []byte(`{"id":"set","bk_biz_id":2}`)},
+		{Oid: "2", OperationType: types.Update, DocBytes: []byte(`{"id":"os","bk_biz_id":2}`)},
+		{Oid: "3", OperationType: types.Delete, DocBytes: []byte(`{"id":"deleted","bk_biz_id":2}`)},
+	}
+	events, err := m.rearrangeDynamicGroupEvents(es, "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"+set/1", "+os/2", "-deleted/2"}, membershipChanges(t, events))
+}
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+/*
+  Dynamic group membership event is a mix event of cc_HostBase, cc_ModuleHostConfig, cc_SetBase, cc_ModuleBase and
+  cc_DynamicGroup events. It has these features as follows:
+  1. It's a virtual event of hosts joining or leaving host dynamic groups, generated from the events mentioned upper.
+     Dynamic groups of set type are not supported.
+  2. The current members of the host dynamic groups are stored in cc_DynamicGroupMember table. When a host or its
+     relation changes, the host dynamic groups of the host's biz are evaluated on the host, when a dynamic group is
+     created or updated, it is evaluated on all hosts of its biz. When a set or module is updated, the dynamic groups
+     of its biz that have set or module conditions are evaluated on all hosts of the biz. The dynamic groups are
+     evaluated by the host dynamic group executor that is shared with host server, the evaluated hosts are ANDed with
+     the conditions of the dynamic groups. The evaluation result is compared with the stored members, and the
+     differences are converted to the membership events, then the stored members are updated after the events are
+     inserted. When a dynamic group is deleted, all of its stored members are converted to leaving events.
+  3. Host joining a dynamic group is a create event, and host leaving a dynamic group is a delete event. The chain node
+     uses the host id as its instance id and the dynamic group id as its sub resource, so that the consumer can watch
+     the membership events of one dynamic group by setting bk_filter.bk_sub_resource to the dynamic group id.
+  4. Dynamic group membership event has a event detail in the form of {"id": "xxx", "bk_host_id": 1}, the detail is
+     generated by the chain node directly, and is not stored in redis.
+  5. The membership is evaluated concurrently for the different kinds of source events, so the same membership change
+     may be emitted more than once in rare cases, the consumer should handle the events idempotently. The stored
+     members are corrected in the next evaluation if they are out of date.
+  6. Dynamic group membership's auth resource is redirect to host resource, and it's event is authorized by host event.
+*/
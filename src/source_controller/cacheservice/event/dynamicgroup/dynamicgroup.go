@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dynamicgroup defines the dynamic group membership event watch logics
+package dynamicgroup
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/source_controller/cacheservice/event"
+	mixevent "configcenter/src/source_controller/cacheservice/event/mix-event"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo/local"
+	"configcenter/src/storage/stream"
+)
+
+const (
+	membershipLockKey = common.BKCacheKeyV3Prefix + "dynamic_group_membership:event_lock"
+	membershipLockTTL = 1 * time.Minute
+)
+
+// NewDynamicGroupMembership init and run dynamic group membership event watch
+func NewDynamicGroupMembership(watch stream.LoopInterface, watchDB *local.Mongo, ccDB dal.DB) error {
+	base := mixevent.MixEventFlowOptions{
+		MixKey:       event.DynamicGroupMembershipKey,
+		Watch:        watch,
+		WatchDB:      watchDB,
+		CcDB:         ccDB,
+		EventLockKey: membershipLockKey,
+		EventLockTTL: membershipLockTTL,
+	}
+
+	// watch host event, all host fields may be used in dynamic group conditions
+	host := base
+	host.Key = event.HostKey
+	if err := newMembership(context.Background(), host); err != nil {
+		blog.Errorf("watch host event for dynamic group membership failed, err: %v", err)
+		return err
+	}
+	blog.Info("watch dynamic group membership events, watch host success")
+
+	// watch host relation event
+	relation := base
+	relation.Key = event.ModuleHostRelationKey
+	relation.WatchFields = []string{common.BKHostIDField}
+	if err := newMembership(context.Background(), relation); err != nil {
+		blog.Errorf("watch host relation event for dynamic group membership failed, err: %v", err)
+		return err
+	}
+	blog.Info("watch dynamic group membership events, watch host relation success")
+
+	// watch set and module event, their attributes may be used in dynamic group conditions
+	set := base
+	set.Key = event.SetKey
+	set.WatchFields = []string{common.BKAppIDField}
+	if err := newMembership(context.Background(), set); err != nil {
+		blog.Errorf("watch set event for dynamic group membership failed, err: %v", err)
+		return err
+	}
+	blog.Info("watch dynamic group membership events, watch set success")
+
+	module := base
+	module.Key = event.ModuleKey
+	module.WatchFields = []string{common.BKAppIDField}
+	if err := newMembership(context.Background(), module); err != nil {
+		blog.Errorf("watch module event for dynamic group membership failed, err: %v", err)
+		return err
+	}
+	blog.Info("watch dynamic group membership events, watch module success")
+
+	// watch dynamic group event
+	group := base
+	group.Key = event.DynamicGroupKey
+	group.WatchFields = []string{common.BKFieldID}
+	if err := newMembership(context.Background(), group); err != nil {
+		blog.Errorf("watch dynamic group event for dynamic group membership failed, err: %v", err)
+		return err
+	}
+	blog.Info("watch dynamic group membership events, watch dynamic group success")
+
+	return nil
+}
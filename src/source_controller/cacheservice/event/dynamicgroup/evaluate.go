@@ -0,0 +1,219 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	dyngroup "configcenter/src/common/dynamicgroup"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	params "configcenter/src/common/paraparse"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/dal"
+)
+
+// matchGroupHosts evaluate the host dynamic group on the hosts in its biz by the dynamic group executor that is shared
+// with host server, nil host ids means all hosts in its biz, returns the matched host id to supplier account map
+func (m *membership) matchGroupHosts(group metadata.DynamicGroup, hostIDs []int64, rid string) (map[int64]string,
+	error) {
+
+	kit := &rest.Kit{
+		Rid:             rid,
+		Header:          make(http.Header),
+		Ctx:             context.Background(),
+		CCError:         errors.NewFromCtx(errors.EmptyErrorsSetting).CreateDefaultCCErrorIf("zh-cn"),
+		User:            common.CCSystemOperatorUserName,
+		SupplierAccount: common.BKSuperOwnerID,
+	}
+
+	conds := make([]metadata.DynamicGroupInfoCondition, 0)
+	conds = append(conds, group.Info.Condition...)
+	conds = append(conds, group.Info.VariableCondition...)
+	searchCond := &metadata.HostCommonSearch{AppID: group.AppID, Condition: dyngroup.ParseConditions(conds)}
+
+	searcher := &hostSearcher{db: m.ccDB, metrics: m.metrics}
+	executor := dyngroup.NewHostDynamicGroupExecutor(kit, searcher, searchCond,
+		[]string{common.BKHostIDField, common.BkSupplierAccount}, true)
+	if hostIDs != nil {
+		executor.LimitHostIDs(hostIDs)
+	}
+
+	hosts, _, err := executor.Execute()
+	if err != nil {
+		blog.Errorf("execute dynamic group %s failed, host ids: %v, err: %v, rid: %s", group.ID, hostIDs, err, rid)
+		return nil, err
+	}
+
+	matched := make(map[int64]string)
+	for _, host := range hosts {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("parse host id %v failed, err: %v, rid: %s", host[common.BKHostIDField], err, rid)
+			return nil, err
+		}
+		matched[hostID] = util.GetStrByInterface(host[common.BkSupplierAccount])
+	}
+
+	return matched, nil
+}
+
+// hasObjCond returns if the dynamic group has the condition of the object
+func hasObjCond(info metadata.DynamicGroupInfo, objID string) bool {
+	conds := make([]metadata.DynamicGroupInfoCondition, 0)
+	conds = append(conds, info.Condition...)
+	conds = append(conds, info.VariableCondition...)
+	for _, cond := range conds {
+		if cond.ObjID == objID && (len(cond.Condition) > 0 || cond.TimeCondition != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostSearcher searches the instances for the host dynamic group executor from db, only the host, set and module
+// conditions are supported by the host dynamic groups, so the mainline and other object conditions are not supported
+type hostSearcher struct {
+	db      dal.DB
+	metrics *event.EventMetrics
+}
+
+// GetAppIDByCond get the ids of the businesses that match the condition
+func (h *hostSearcher) GetAppIDByCond(kit *rest.Kit, cond metadata.ConditionWithTime) ([]int64, error) {
+	return h.getInstIDs(kit, common.BKTableNameBaseApp, common.BKAppIDField, cond, mapstr.MapStr{
+		common.BKDataStatusField: mapstr.MapStr{common.BKDBNE: common.DataStatusDisabled},
+	})
+}
+
+// GetSetIDByObjectCond is not supported, because host dynamic group has no mainline object condition
+func (h *hostSearcher) GetSetIDByObjectCond(kit *rest.Kit, _ int64, _ []metadata.ConditionItem) ([]int64, error) {
+	return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKInnerObjIDObject)
+}
+
+// GetSetIDByCond get the ids of the sets that match the condition
+func (h *hostSearcher) GetSetIDByCond(kit *rest.Kit, cond metadata.ConditionWithTime) ([]int64, error) {
+	return h.getInstIDs(kit, common.BKTableNameBaseSet, common.BKSetIDField, cond, nil)
+}
+
+// GetModuleIDByCond get the ids of the modules that match the condition
+func (h *hostSearcher) GetModuleIDByCond(kit *rest.Kit, cond metadata.ConditionWithTime) ([]int64, error) {
+	return h.getInstIDs(kit, common.BKTableNameBaseModule, common.BKModuleIDField, cond, nil)
+}
+
+// GetObjectInstByCond is not supported, because host dynamic group has no other object condition
+func (h *hostSearcher) GetObjectInstByCond(kit *rest.Kit, objID string, _ []metadata.ConditionItem) ([]int64,
+	error) {
+	return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, objID)
+}
+
+// GetDistinctHostIDByTopology get the distinct ids of the hosts in the topology
+func (h *hostSearcher) GetDistinctHostIDByTopology(kit *rest.Kit,
+	input *metadata.DistinctHostIDByTopoRelationRequest) ([]int64, error) {
+
+	cond := make(map[string]interface{})
+	for field, ids := range map[string][]int64{
+		common.BKAppIDField:    input.ApplicationIDArr,
+		common.BKSetIDField:    input.SetIDArr,
+		common.BKModuleIDField: input.ModuleIDArr,
+		common.BKHostIDField:   input.HostIDArr,
+	} {
+		if len(ids) > 0 {
+			cond[field] = map[string]interface{}{common.BKDBIN: ids}
+		}
+	}
+
+	ids, err := h.db.Table(common.BKTableNameModuleHostConfig).Distinct(kit.Ctx, common.BKHostIDField, cond)
+	if err != nil {
+		h.metrics.CollectMongoError()
+		blog.Errorf("get distinct host ids failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, err
+	}
+
+	return util.SliceInterfaceToInt64(ids)
+}
+
+// GetHosts get the hosts that match the query
+func (h *hostSearcher) GetHosts(kit *rest.Kit, input *metadata.QueryInput) (*metadata.HostInfo, error) {
+	cond, err := mergeTimeCond(input.Condition, input.TimeCondition)
+	if err != nil {
+		blog.Errorf("merge host time condition failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	query := h.db.Table(common.BKTableNameBaseHost).Find(cond).Fields(util.SplitStrField(input.Fields, ",")...)
+	if input.Limit > 0 {
+		query = query.Start(uint64(input.Start)).Limit(uint64(input.Limit)).Sort(input.Sort)
+	}
+
+	hosts := make([]mapstr.MapStr, 0)
+	if err = query.All(kit.Ctx, &hosts); err != nil {
+		h.metrics.CollectMongoError()
+		blog.Errorf("get hosts failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, err
+	}
+
+	return &metadata.HostInfo{Count: len(hosts), Info: hosts}, nil
+}
+
+// getInstIDs get the ids of the instances that match the condition
+func (h *hostSearcher) getInstIDs(kit *rest.Kit, table, idField string, cond metadata.ConditionWithTime,
+	extraCond mapstr.MapStr) ([]int64, error) {
+
+	dbCond := make(map[string]interface{})
+	if err := params.ParseCommonParams(cond.Condition, dbCond); err != nil {
+		blog.Errorf("parse %s condition failed, err: %v, rid: %s", table, err, kit.Rid)
+		return nil, err
+	}
+	for key, value := range extraCond {
+		dbCond[key] = value
+	}
+
+	dbCond, err := mergeTimeCond(dbCond, cond.TimeCondition)
+	if err != nil {
+		blog.Errorf("merge %s time condition failed, err: %v, rid: %s", table, err, kit.Rid)
+		return nil, err
+	}
+
+	insts := make([]mapstr.MapStr, 0)
+	if err := h.db.Table(table).Find(dbCond).Fields(idField).All(kit.Ctx, &insts); err != nil {
+		h.metrics.CollectMongoError()
+		blog.Errorf("get %s failed, cond: %+v, err: %v, rid: %s", table, dbCond, err, kit.Rid)
+		return nil, err
+	}
+
+	instIDs := make([]int64, 0, len(insts))
+	for _, inst := range insts {
+		instID, err := inst.Int64(idField)
+		if err != nil {
+			blog.Errorf("parse %s %v failed, err: %v, rid: %s", idField, inst[idField], err, kit.Rid)
+			return nil, err
+		}
+		instIDs = append(instIDs, instID)
+	}
+
+	return instIDs, nil
+}
+
+// mergeTimeCond merge the time condition into the db condition if it is set
+func mergeTimeCond(cond map[string]interface{}, timeCond *metadata.TimeCondition) (map[string]interface{}, error) {
+	if timeCond == nil {
+		return cond, nil
+	}
+	return timeCond.MergeTimeCondition(cond)
+}
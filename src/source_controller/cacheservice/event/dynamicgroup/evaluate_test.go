@@ -0,0 +1,188 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/cacheservice/event"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testMetrics = event.InitialMetrics("dynamic_group_test", "dynamic_group_membership_test")
+
+// newTestMembership returns a membership with an in-memory db that has 2 hosts in biz 2:
+// host 1 in set 10(prod)/module 100(web), host 2 in set 11(test)/module 101(db)
+func newTestMembership(t *testing.T, key event.Key) *membership {
+	db := memory.NewDB()
+	ctx := context.Background()
+
+	biz := mapstr.MapStr{common.BKAppIDField: 2, common.BKAppNameField: "biz"}
+	require.NoError(t, db.Table(common.BKTableNameBaseApp).Insert(ctx, []mapstr.MapStr{biz}))
+
+	sets := []mapstr.MapStr{
+		{common.BKSetIDField: 10, common.BKAppIDField: 2, common.BKSetNameField: "prod"},
+		{common.BKSetIDField: 11, common.BKAppIDField: 2, common.BKSetNameField: "test"},
+	}
+	require.NoError(t, db.Table(common.BKTableNameBaseSet).Insert(ctx, sets))
+
+	modules := []mapstr.MapStr{
+		{common.BKModuleIDField: 100, common.BKSetIDField: 10, common.BKAppIDField: 2,
+			common.BKModuleNameField: "web"},
+		{common.BKModuleIDField: 101, common.BKSetIDField: 11, common.BKAppIDField: 2,
+			common.BKModuleNameField: "db"},
+	}
+	require.NoError(t, db.Table(common.BKTableNameBaseModule).Insert(ctx, modules))
+
+	relations := []mapstr.MapStr{
+		{common.BKAppIDField: 2, common.BKSetIDField: 10, common.BKModuleIDField: 100, common.BKHostIDField: 1,
+			common.BkSupplierAccount: "0"},
+		{common.BKAppIDField: 2, common.BKSetIDField: 11, common.BKModuleIDField: 101, common.BKHostIDField: 2,
+			common.BkSupplierAccount: "0"},
+	}
+	require.NoError(t, db.Table(common.BKTableNameModuleHostConfig).Insert(ctx, relations))
+
+	hosts := []mapstr.MapStr{
+		{common.BKHostIDField: 1, common.BKHostNameField: "a", common.BKOSTypeField: "1",
+			common.BkSupplierAccount: "0"},
+		{common.BKHostIDField: 2, common.BKHostNameField: "b", common.BKOSTypeField: "1",
+			common.BkSupplierAccount: "0"},
+	}
+	require.NoError(t, db.Table(common.BKTableNameBaseHost).Insert(ctx, hosts))
+
+	groups := []metadata.DynamicGroup{
+		newTestGroup("set", common.BKInnerObjIDSet, common.BKSetNameField, "prod"),
+		newTestGroup("os", common.BKInnerObjIDHost, common.BKOSTypeField, "1"),
+		newTestGroup("module", common.BKInnerObjIDModule, common.BKModuleNameField, "web"),
+	}
+	require.NoError(t, db.Table(common.BKTableNameDynamicGroup).Insert(ctx, groups))
+
+	return &membership{key: key, ccDB: db, metrics: testMetrics}
+}
+
+func newTestGroup(id, objID, field string, value interface{}) metadata.DynamicGroup {
+	return metadata.DynamicGroup{
+		AppID: 2,
+		ID:    id,
+		Name:  id,
+		ObjID: common.BKInnerObjIDHost,
+		Info: metadata.DynamicGroupInfo{
+			Condition: []metadata.DynamicGroupInfoCondition{{
+				ObjID:     objID,
+				Condition: []metadata.DynamicGroupCondition{{Field: field, Operator: common.BKDBEQ, Value: value}},
+			}},
+		},
+	}
+}
+
+func TestHasObjCond(t *testing.T) {
+	info := metadata.DynamicGroupInfo{
+		Condition: []metadata.DynamicGroupInfoCondition{
+			{
+				ObjID: common.BKInnerObjIDHost,
+				Condition: []metadata.DynamicGroupCondition{
+					{Field: common.BKHostNameField, Operator: common.BKDBEQ, Value: "a"},
+				},
+			},
+			{ObjID: common.BKInnerObjIDModule},
+		},
+		VariableCondition: []metadata.DynamicGroupInfoCondition{{
+			ObjID: common.BKInnerObjIDSet,
+			TimeCondition: &metadata.TimeCondition{Operator: "and",
+				Rules: []metadata.TimeConditionItem{{Field: common.CreateTimeField}}},
+		}},
+	}
+
+	require.True(t, hasObjCond(info, common.BKInnerObjIDHost))
+	require.True(t, hasObjCond(info, common.BKInnerObjIDSet))
+	require.False(t, hasObjCond(info, common.BKInnerObjIDModule))
+}
+
+func TestMatchGroupHosts(t *testing.T) {
+	m := newTestMembership(t, event.HostKey)
+
+	testCases := []struct {
+		group   metadata.DynamicGroup
+		hostIDs []int64
+		matched map[int64]string
+	}{
+		{
+			group:   newTestGroup("set", common.BKInnerObjIDSet, common.BKSetNameField, "prod"),
+			matched: map[int64]string{1: "0"},
+		},
+		{
+			group:   newTestGroup("module", common.BKInnerObjIDModule, common.BKModuleNameField, "db"),
+			matched: map[int64]string{2: "0"},
+		},
+		{
+			group:   newTestGroup("os", common.BKInnerObjIDHost, common.BKOSTypeField, "1"),
+			matched: map[int64]string{1: "0", 2: "0"},
+		},
+		{
+			// only the given hosts are evaluated
+			group:   newTestGroup("os", common.BKInnerObjIDHost, common.BKOSTypeField, "1"),
+			hostIDs: []int64{2},
+			matched: map[int64]string{2: "0"},
+		},
+		{
+			// the host id condition of the group is ANDed with the given hosts
+			group:   newTestGroup("host_id", common.BKInnerObjIDHost, common.BKHostIDField, 1),
+			hostIDs: []int64{2},
+			matched: map[int64]string{},
+		},
+		{
+			group: metadata.DynamicGroup{AppID: 2, ID: "host_ids", ObjID: common.BKInnerObjIDHost,
+				Info: metadata.DynamicGroupInfo{Condition: []metadata.DynamicGroupInfoCondition{{
+					ObjID: common.BKInnerObjIDHost,
+					Condition: []metadata.DynamicGroupCondition{{Field: common.BKHostIDField,
+						Operator: common.BKDBIN, Value: []interface{}{1, 2}}},
+				}}},
+			},
+			hostIDs: []int64{2},
+			matched: map[int64]string{2: "0"},
+		},
+		{
+			group:   newTestGroup("none", common.BKInnerObjIDSet, common.BKSetNameField, "none"),
+			matched: map[int64]string{},
+		},
+		{
+			// group without conditions matches all hosts in its biz
+			group:   metadata.DynamicGroup{AppID: 2, ID: "all", ObjID: common.BKInnerObjIDHost},
+			matched: map[int64]string{1: "0", 2: "0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		matched, err := m.matchGroupHosts(testCase.group, testCase.hostIDs, "")
+		require.NoError(t, err)
+		require.Equal(t, testCase.matched, matched, "group: %s", testCase.group.ID)
+	}
+
+	// the set and host conditions are both matched
+	group := newTestGroup("set_host", common.BKInnerObjIDSet, common.BKSetNameField, "test")
+	group.Info.VariableCondition = []metadata.DynamicGroupInfoCondition{{
+		ObjID: common.BKInnerObjIDHost,
+		Condition: []metadata.DynamicGroupCondition{
+			{Field: common.BKHostNameField, Operator: common.BKDBEQ, Value: "a"},
+		},
+	}}
+	matched, err := m.matchGroupHosts(group, nil, "")
+	require.NoError(t, err)
+	require.Empty(t, matched)
+}
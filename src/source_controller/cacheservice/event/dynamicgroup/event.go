@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicgroup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/event"
+	mixevent "configcenter/src/source_controller/cacheservice/event/mix-event"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/stream/types"
+)
+
+// newMembership init and run dynamic group membership event watch with sub event key
+func newMembership(ctx context.Context, opts mixevent.MixEventFlowOptions) error {
+	m := membership{
+		key:     opts.Key,
+		ccDB:    opts.CcDB,
+		metrics: event.InitialMetrics(opts.Key.Collection(), "dynamic_group_membership"),
+	}
+	opts.AfterInsertEvents = m.saveMembers
+
+	flow, err := mixevent.NewMixEventFlow(opts, m.rearrangeEvents, m.parseEvent)
+	if err != nil {
+		return err
+	}
+
+	return flow.RunFlow(ctx)
+}
+
+// membership dynamic group membership event watch logic struct
+type membership struct {
+	key     event.Key
+	ccDB    dal.DB
+	metrics *event.EventMetrics
+}
+
+// rearrangeEvents rearrange host, host relation, set, module and dynamic group events into dynamic group membership
+// events
+func (m *membership) rearrangeEvents(rid string, es []*types.Event) ([]*types.Event, error) {
+	switch m.key.Collection() {
+	case event.HostKey.Collection():
+		return m.rearrangeHostEvents(es, rid)
+	case event.ModuleHostRelationKey.Collection():
+		return m.rearrangeHostRelationEvents(es, rid)
+	case event.DynamicGroupKey.Collection():
+		return m.rearrangeDynamicGroupEvents(es, rid)
+	case event.SetKey.Collection():
+		return m.rearrangeTopoEvents(common.BKInnerObjIDSet, es, rid)
+	case event.ModuleKey.Collection():
+		return m.rearrangeTopoEvents(common.BKInnerObjIDModule, es, rid)
+	default:
+		blog.Errorf("received unsupported dynamic group membership event, skip, es: %+v, rid: %s", es, rid)
+		return es[:0], nil
+	}
+}
+
+// parseEvent parse membership event into chain node, the detail is generated by chain node when watched
+func (m *membership) parseEvent(e *types.Event, id uint64, rid string) (*watch.ChainNode, []byte, bool, error) {
+	switch e.OperationType {
+	case types.Insert, types.Delete:
+	default:
+		blog.Errorf("dynamic group membership event, received unsupported event operation type: %s, doc: %s, rid: %s",
+			e.OperationType, e.DocBytes, rid)
+		return nil, nil, false, nil
+	}
+
+	member := new(metadata.DynamicGroupMember)
+	if err := json.Unmarshal(e.DocBytes, member); err != nil {
+		blog.Errorf("unmarshal dynamic group member(%s) failed, err: %v, rid: %s", e.DocBytes, err, rid)
+		return nil, nil, false, err
+	}
+
+	cursor, err := genMembershipCursor(e, member, rid)
+	if err != nil {
+		blog.Errorf("get dynamic group membership event cursor failed, member: %+v, err: %v, oid: %s, rid: %s",
+			member, err, e.ID(), rid)
+		return nil, nil, false, err
+	}
+
+	chainNode := &watch.ChainNode{
+		ID:              id,
+		ClusterTime:     e.ClusterTime,
+		Oid:             e.Oid,
+		EventType:       watch.ConvertOperateType(e.OperationType),
+		Token:           e.Token.Data,
+		Cursor:          cursor,
+		InstanceID:      member.HostID,
+		SubResource:     []string{member.ID},
+		SupplierAccount: member.SupplierAccount,
+	}
+
+	return chainNode, nil, false, nil
+}
+
+// genMembershipCursor generate membership event cursor, one source event can be converted to multiple membership
+// events, so the dynamic group id and host id is used as the unique key to distinguish them.
+func genMembershipCursor(e *types.Event, member *metadata.DynamicGroupMember, rid string) (string, error) {
+	cursor := &watch.Cursor{
+		Type:        watch.DynamicGroupMembership,
+		ClusterTime: e.ClusterTime,
+		Oid:         e.Oid,
+		Oper:        e.OperationType,
+		UniqKey:     member.ID + ":" + strconv.FormatInt(member.HostID, 10),
+	}
+
+	cursorEncode, err := cursor.Encode()
+	if err != nil {
+		blog.Errorf("encode dynamic group membership cursor failed, cursor: %+v, err: %v, rid: %s", cursor, err, rid)
+		return "", err
+	}
+
+	return cursorEncode, nil
+}
+
+// newMembershipEvent generate a membership event from the source event, insert event for the host joining the
+// dynamic group, delete event for the host leaving the dynamic group.
+func newMembershipEvent(source *types.Event, member metadata.DynamicGroupMember, oper types.OperType) (*types.Event,
+	error) {
+
+	doc, err := json.Marshal(member)
+	if err != nil {
+		return nil, fmt.Errorf("marshal dynamic group member %+v failed, err: %v", member, err)
+	}
+
+	return &types.Event{
+		Oid:           source.Oid,
+		OperationType: oper,
+		Document:      &member,
+		DocBytes:      doc,
+		Collection:    source.Collection,
+		ClusterTime:   source.ClusterTime,
+		Token:         source.Token,
+		ChangeDesc:    new(types.ChangeDescription),
+	}, nil
+}
+
+// saveMembers update the stored dynamic group members by the inserted membership events
+func (m *membership) saveMembers(events []*types.Event, rid string) error {
+	groupIDs := make([]string, 0)
+	groupHostIDs := make(map[string][]int64)
+	groupJoined := make(map[string][]metadata.DynamicGroupMember)
+	for _, e := range events {
+		member := new(metadata.DynamicGroupMember)
+		if err := json.Unmarshal(e.DocBytes, member); err != nil {
+			blog.Errorf("unmarshal dynamic group member(%s) failed, err: %v, rid: %s", e.DocBytes, err, rid)
+			return err
+		}
+
+		if _, exists := groupHostIDs[member.ID]; !exists {
+			groupIDs = append(groupIDs, member.ID)
+		}
+		groupHostIDs[member.ID] = append(groupHostIDs[member.ID], member.HostID)
+
+		if e.OperationType == types.Insert {
+			groupJoined[member.ID] = append(groupJoined[member.ID], *member)
+		}
+	}
+
+	for _, groupID := range groupIDs {
+		cond := map[string]interface{}{
+			common.BKFieldID:     groupID,
+			common.BKHostIDField: map[string]interface{}{common.BKDBIN: groupHostIDs[groupID]},
+		}
+
+		if err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Delete(context.Background(), cond); err != nil {
+			m.metrics.CollectMongoError()
+			blog.Errorf("delete dynamic group members failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+			return err
+		}
+
+		joined := groupJoined[groupID]
+		if len(joined) == 0 {
+			continue
+		}
+
+		if err := m.ccDB.Table(common.BKTableNameDynamicGroupMember).Insert(context.Background(),
+			joined); err != nil {
+			m.metrics.CollectMongoError()
+			blog.Errorf("insert dynamic group members failed, group: %s, err: %v, rid: %s", groupID, err, rid)
+			return err
+		}
+	}
+
+	return nil
+}
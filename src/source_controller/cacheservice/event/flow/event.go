@@ -99,6 +99,14 @@ func NewEvent(watch stream.LoopInterface, isMaster discovery.ServiceManageInterf
 		blog.Errorf("run project event flow failed, err: %v", err)
 	}
 
+	if err := e.runModelMetadata(context.Background()); err != nil {
+		blog.Errorf("run model metadata event flow failed, err: %v", err)
+	}
+
+	if err := e.runTemplate(context.Background()); err != nil {
+		blog.Errorf("run template event flow failed, err: %v", err)
+	}
+
 	return nil
 }
 
@@ -265,3 +273,41 @@ func (e *Event) runProject(ctx context.Context) error {
 
 	return newFlow(ctx, opts, getDeleteEventDetails, parseEvent)
 }
+
+// runModelMetadata run the event flows of model definitions, attributes, attribute groups and unique rules
+func (e *Event) runModelMetadata(ctx context.Context) error {
+	keys := []event.Key{event.ModelKey, event.ModelAttributeKey, event.ModelAttributeGroupKey, event.ModelUniqueKey}
+	return e.runGeneralFlows(ctx, keys)
+}
+
+// runTemplate run the event flows of service templates, set templates, service instances, field templates and
+// dynamic groups
+func (e *Event) runTemplate(ctx context.Context) error {
+	keys := []event.Key{event.ServiceTemplateKey, event.SetTemplateKey, event.ServiceInstanceKey,
+		event.FieldTemplateKey, event.DynamicGroupKey}
+	return e.runGeneralFlows(ctx, keys)
+}
+
+// runGeneralFlows run the event flows of the resources whose event detail is the db document itself,
+// the failure of one resource's flow does not stop the others from running.
+func (e *Event) runGeneralFlows(ctx context.Context, keys []event.Key) error {
+	var firstErr error
+	for _, key := range keys {
+		opts := flowOptions{
+			key:         key,
+			watch:       e.watch,
+			watchDB:     e.watchDB,
+			ccDB:        e.ccDB,
+			isMaster:    e.isMaster,
+			EventStruct: new(map[string]interface{}),
+		}
+
+		if err := newFlow(ctx, opts, getDeleteEventDetails, parseEvent); err != nil {
+			blog.Errorf("run %s event flow failed, err: %v", key.Collection(), err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
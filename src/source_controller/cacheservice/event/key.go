@@ -339,6 +339,67 @@ func GenBizSetRelationDetail(bizSetID int64, bizIDsStr string) string {
 	return fmt.Sprintf(`{"bk_biz_set_id":%d,"bk_biz_ids":[%s]}`, bizSetID, bizIDsStr)
 }
 
+var dynamicGroupFields = []string{common.BKFieldID, common.BKFieldName, common.BKAppIDField}
+
+// DynamicGroupKey dynamic group event watch key, it is also used as a source of dynamic group membership events
+// NOTE: dynamic group id is a string, so its instance id is not set
+var DynamicGroupKey = Key{
+	namespace:  watchCacheNamespace + "dynamic_group",
+	collection: common.BKTableNameDynamicGroup,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, dynamicGroupFields...)
+		for idx := range dynamicGroupFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", dynamicGroupFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+}
+
+// dynamicGroupMembershipWatchCollName a virtual collection name for the events of hosts joining or leaving dynamic
+// groups, which are converted from host, host relation and dynamic group events
+const dynamicGroupMembershipWatchCollName = "cc_DynamicGroupMembershipMixed"
+
+// DynamicGroupMembershipKey dynamic group membership event watch key
+var DynamicGroupMembershipKey = Key{
+	namespace:  watchCacheNamespace + "dynamic_group_membership",
+	collection: dynamicGroupMembershipWatchCollName,
+	// unused ttl seconds, details is generated directly from chain node.
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, common.BKFieldID, common.BKHostIDField)
+		if !fields[0].Exists() {
+			return fmt.Errorf("field %s not exist", common.BKFieldID)
+		}
+		if !fields[1].Exists() {
+			return fmt.Errorf("field %s not exist", common.BKHostIDField)
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, common.BKFieldID, common.BKHostIDField)
+		return fmt.Sprintf("dynamic group id: %s, host id: %s", fields[0].String(), fields[1].String())
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKHostIDField).Int()
+	},
+}
+
+// GenDynamicGroupMembershipDetail generate dynamic group membership event detail json form by chain node, the chain
+// node's instance id is the host id, and its sub resource is the dynamic group id
+func GenDynamicGroupMembershipDetail(node *watch.ChainNode) string {
+	groupID := ""
+	if len(node.SubResource) > 0 {
+		groupID = node.SubResource[0]
+	}
+	return fmt.Sprintf(`{"id":%q,"bk_host_id":%d}`, groupID, node.InstanceID)
+}
+
 var platFields = []string{common.BKCloudIDField, common.BKCloudNameField}
 
 // PlatKey cloud area event watch key
@@ -511,6 +572,181 @@ var ProjectKey = Key{
 	},
 }
 
+var modelFields = []string{common.BKFieldID, common.BKObjIDField, common.BKObjNameField}
+
+// ModelKey model definition event watch key
+var ModelKey = Key{
+	namespace:  watchCacheNamespace + string(watch.Model),
+	collection: common.BKTableNameObjDes,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelFields...)
+		for idx := range modelFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKObjNameField).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelAttrFields = []string{common.BKFieldID, common.BKObjIDField, common.BKPropertyIDField}
+
+// ModelAttributeKey model attribute event watch key
+var ModelAttributeKey = Key{
+	namespace:  watchCacheNamespace + string(watch.ModelAttribute),
+	collection: common.BKTableNameObjAttDes,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelAttrFields...)
+		for idx := range modelAttrFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelAttrFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, common.BKObjIDField, common.BKPropertyIDField)
+		return fields[0].String() + ":" + fields[1].String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelAttrGroupFields = []string{common.BKFieldID, common.BKObjIDField, common.BKPropertyGroupIDField}
+
+// ModelAttributeGroupKey model attribute group event watch key
+var ModelAttributeGroupKey = Key{
+	namespace:  watchCacheNamespace + string(watch.ModelAttributeGroup),
+	collection: common.BKTableNamePropertyGroup,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelAttrGroupFields...)
+		for idx := range modelAttrGroupFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelAttrGroupFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, common.BKObjIDField, common.BKPropertyGroupNameField)
+		return fields[0].String() + ":" + fields[1].String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var modelUniqueFields = []string{common.BKFieldID, common.BKObjIDField}
+
+// ModelUniqueKey model unique rule event watch key
+var ModelUniqueKey = Key{
+	namespace:  watchCacheNamespace + string(watch.ModelUnique),
+	collection: common.BKTableNameObjUnique,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, modelUniqueFields...)
+		for idx := range modelUniqueFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", modelUniqueFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, common.BKObjIDField, common.BKFieldID)
+		return fmt.Sprintf("object: %s, unique id: %s", fields[0].String(), fields[1].String())
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+var templateFields = []string{common.BKFieldID, common.BKFieldName}
+
+// validateTemplate validates the template like resources that are identified by id and name
+func validateTemplate(doc []byte) error {
+	fields := gjson.GetManyBytes(doc, templateFields...)
+	for idx := range templateFields {
+		if !fields[idx].Exists() {
+			return fmt.Errorf("field %s not exist", templateFields[idx])
+		}
+	}
+	return nil
+}
+
+// ServiceTemplateKey service template event watch key
+var ServiceTemplateKey = Key{
+	namespace:  watchCacheNamespace + string(watch.ServiceTemplate),
+	collection: common.BKTableNameServiceTemplate,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  validateTemplate,
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// SetTemplateKey set template event watch key
+var SetTemplateKey = Key{
+	namespace:  watchCacheNamespace + string(watch.SetTemplate),
+	collection: common.BKTableNameSetTemplate,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  validateTemplate,
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// ServiceInstanceKey service instance event watch key
+// NOTE: only the identity fields of service instance is archived when it is deleted, so the delete event detail
+// only contains these fields
+var ServiceInstanceKey = Key{
+	namespace:  watchCacheNamespace + string(watch.ServiceInstance),
+	collection: common.BKTableNameServiceInstance,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		if !gjson.GetBytes(doc, common.BKFieldID).Exists() {
+			return fmt.Errorf("field %s not exist", common.BKFieldID)
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
+// FieldTemplateKey field template event watch key
+var FieldTemplateKey = Key{
+	namespace:  watchCacheNamespace + string(watch.FieldTemplate),
+	collection: common.BKTableNameFieldTemplate,
+	ttlSeconds: 6 * 60 * 60,
+	validator:  validateTemplate,
+	instName: func(doc []byte) string {
+		return gjson.GetBytes(doc, common.BKFieldName).String()
+	},
+	instID: func(doc []byte) int64 {
+		return gjson.GetBytes(doc, common.BKFieldID).Int()
+	},
+}
+
 // Key TODO
 type Key struct {
 	namespace string
@@ -46,6 +46,9 @@ type MixEventFlowOptions struct {
 	CcDB         dal.DB
 	EventLockTTL time.Duration
 	EventLockKey string
+	// AfterInsertEvents is an optional function that is called with the rearranged events after their chain nodes are
+	// inserted, it is used to persist the states that the mix events are generated from.
+	AfterInsertEvents func(events []*types.Event, rid string) error
 }
 
 // MixEventFlow mix event flow
@@ -267,6 +270,13 @@ func (f *MixEventFlow) handleEvents(events []*types.Event, lastTokenData mapstr.
 		return retry, err
 	}
 
+	if f.AfterInsertEvents != nil {
+		if err := f.AfterInsertEvents(events, rid); err != nil {
+			blog.Errorf("handle %s events after insertion failed, err: %v, rid: %s", f.MixKey.Namespace(), err, rid)
+			return true, err
+		}
+	}
+
 	blog.Infof("insert %s event for %s success, oids: %v, rid: %s", f.MixKey.Namespace(), f.Key.Collection(), oids, rid)
 	return false, nil
 
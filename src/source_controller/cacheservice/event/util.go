@@ -44,6 +44,16 @@ var resourceKeyMap = map[watch.CursorType]Key{
 	watch.KubeWorkload:            KubeWorkloadKey,
 	watch.KubePod:                 KubePodKey,
 	watch.Project:                 ProjectKey,
+	watch.DynamicGroupMembership:  DynamicGroupMembershipKey,
+	watch.Model:                   ModelKey,
+	watch.ModelAttribute:          ModelAttributeKey,
+	watch.ModelAttributeGroup:     ModelAttributeGroupKey,
+	watch.ModelUnique:             ModelUniqueKey,
+	watch.ServiceTemplate:         ServiceTemplateKey,
+	watch.SetTemplate:             SetTemplateKey,
+	watch.ServiceInstance:         ServiceInstanceKey,
+	watch.FieldTemplate:           FieldTemplateKey,
+	watch.DynamicGroup:            DynamicGroupKey,
 }
 
 // GetResourceKeyWithCursorType get resource key
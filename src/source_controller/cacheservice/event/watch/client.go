@@ -113,6 +113,10 @@ func (c *Client) getEventDetail(kit *rest.Kit, node *watch.ChainNode, fields []s
 		}
 		return getFirstEventDetail(details)
 
+	case event.DynamicGroupMembershipKey.Collection():
+		detail := event.GenDynamicGroupMembershipDetail(node)
+		return &detail, true, nil
+
 	default:
 		detail, err := c.getEventDetailFromRedis(kit, node, fields, key)
 		if err == nil {
@@ -203,6 +203,11 @@ func (c *Client) getEventDetailsWithNodes(kit *rest.Kit, opts *watch.WatchEventO
 		return c.getBizSetRelationEventDetailWithNodes(kit, hitNodes)
 	}
 
+	if opts.Resource == watch.DynamicGroupMembership {
+		// generate from chain nodes directly.
+		return getDynamicGroupMembershipEventDetailWithNodes(hitNodes), nil
+	}
+
 	details, errNodes, errCursorIndexMap, err := c.searchEventDetailsFromRedis(kit, hitNodes, key)
 	if err != nil {
 		return nil, err
@@ -403,6 +408,21 @@ func (c *Client) getBizSetRelationEventDetailWithNodes(kit *rest.Kit, hitNodes [
 	return resp, nil
 }
 
+// getDynamicGroupMembershipEventDetailWithNodes get dynamic group membership event detail by chain nodes
+func getDynamicGroupMembershipEventDetailWithNodes(hitNodes []*watch.ChainNode) []*watch.WatchEventDetail {
+	resp := make([]*watch.WatchEventDetail, len(hitNodes))
+	for idx, node := range hitNodes {
+		resp[idx] = &watch.WatchEventDetail{
+			Cursor:    node.Cursor,
+			Resource:  watch.DynamicGroupMembership,
+			EventType: node.EventType,
+			Detail:    watch.JsonString(event.GenDynamicGroupMembershipDetail(node)),
+			ChainNode: node,
+		}
+	}
+	return resp
+}
+
 // getBizSetRelationEventDetailFromMongo get biz set relation event details by biz set ids from mongo
 func (c *Client) getBizSetRelationEventDetailFromMongo(kit *rest.Kit, bizSetIDs []int64) (map[int64]string, error) {
 	if len(bizSetIDs) == 0 {
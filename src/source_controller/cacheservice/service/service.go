@@ -33,6 +33,7 @@ import (
 	"configcenter/src/source_controller/cacheservice/cache"
 	cacheop "configcenter/src/source_controller/cacheservice/cache"
 	"configcenter/src/source_controller/cacheservice/event/bsrelation"
+	"configcenter/src/source_controller/cacheservice/event/dynamicgroup"
 	"configcenter/src/source_controller/cacheservice/event/flow"
 	"configcenter/src/source_controller/cacheservice/event/identifier"
 	"configcenter/src/source_controller/coreservice/core"
@@ -147,6 +148,11 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 		return err
 	}
 
+	if err := dynamicgroup.NewDynamicGroupMembership(watcher, watchDB, ccDB); err != nil {
+		blog.Errorf("new dynamic group membership event failed, err: %v", err)
+		return err
+	}
+
 	return nil
 }
 
@@ -14,6 +14,8 @@
 package options
 
 import (
+	"fmt"
+
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -31,6 +33,10 @@ type ServerOption struct {
 type Config struct {
 	Mongo mongo.Config
 	Redis redis.Config
+	// AuditLog is the audit log retention, archival and export config
+	AuditLog AuditLogConfig
+	// FullTextSearchIndex is whether the fulltext search uses the mongodb backend that needs the text indexes
+	FullTextSearchIndex bool
 }
 
 // NewServerOption create a ServerOption object
@@ -47,3 +53,233 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	s.ServConf.AddFlags(fs, "127.0.0.1:60001")
 	fs.BoolVar(&s.DisableInsert, "disable-insertion", false, "disable mongodb insert operation for specific types")
 }
+
+// AuditLogConfig is the config of the audit log retention, archival and export
+type AuditLogConfig struct {
+	// Retention is the retention policies of the audit logs, the expired audit logs are archived or deleted
+	Retention AuditRetentionConfig `mapstructure:"retention"`
+	// Export is the sinks that every audit log is exported to after it is written
+	Export []AuditExportConfig `mapstructure:"export"`
+	// Chain is the config of linking the audit logs into the tamper-evident hash chains
+	Chain AuditChainConfig `mapstructure:"chain"`
+}
+
+// Validate AuditLogConfig and set the default values
+func (c *AuditLogConfig) Validate() error {
+	if err := c.Retention.Validate(); err != nil {
+		return err
+	}
+
+	exportNames := make(map[string]struct{})
+	for idx := range c.Export {
+		if err := c.Export[idx].Validate(); err != nil {
+			return err
+		}
+
+		if _, exists := exportNames[c.Export[idx].Name]; exists {
+			return fmt.Errorf("audit log export name %s is duplicated", c.Export[idx].Name)
+		}
+		exportNames[c.Export[idx].Name] = struct{}{}
+	}
+
+	return c.Chain.Validate()
+}
+
+// AuditRetentionConfig is the config of the scheduled audit log retention job
+type AuditRetentionConfig struct {
+	// Enabled defines if the scheduled audit log retention job is enabled
+	Enabled bool `mapstructure:"enabled"`
+	// IntervalMinutes is the job interval, unit: minute
+	IntervalMinutes int `mapstructure:"intervalMinutes"`
+	// ArchiveDir is the local directory that the expired audit logs are archived into as compressed json lines files,
+	// the expired audit logs are deleted without archiving if it is empty
+	ArchiveDir string `mapstructure:"archiveDir"`
+	// Policies is the retention policies of the audit types
+	Policies []AuditRetentionPolicy `mapstructure:"policies"`
+}
+
+// AuditRetentionDefaultType is the audit type of the retention policy for the audit types without their own policies
+const AuditRetentionDefaultType = "*"
+
+// AuditRetentionPolicy is the retention policy of an audit type, the audit log is expired if it exceeds either the
+// ttl days or the max count, zero value means no limit
+type AuditRetentionPolicy struct {
+	// AuditType is the audit type that the policy applies to, AuditRetentionDefaultType means all other audit types
+	AuditType string `mapstructure:"auditType"`
+	// TTLDays is the number of days that the audit logs are kept
+	TTLDays int `mapstructure:"ttlDays"`
+	// MaxCount is the maximum number of the audit logs that are kept, the oldest ones are expired first
+	MaxCount int64 `mapstructure:"maxCount"`
+}
+
+const (
+	// AuditRetentionIntervalMinutesDefault is the default audit log retention job interval
+	AuditRetentionIntervalMinutesDefault = 60
+	// AuditRetentionIntervalMinutesMin is the minimum audit log retention job interval
+	AuditRetentionIntervalMinutesMin = 10
+)
+
+// Validate AuditRetentionConfig and set the default values
+func (c *AuditRetentionConfig) Validate() error {
+	if c.IntervalMinutes == 0 {
+		c.IntervalMinutes = AuditRetentionIntervalMinutesDefault
+	}
+
+	if c.IntervalMinutes < AuditRetentionIntervalMinutesMin {
+		return fmt.Errorf("audit log retention interval minutes %d is less than %d", c.IntervalMinutes,
+			AuditRetentionIntervalMinutesMin)
+	}
+
+	auditTypes := make(map[string]struct{})
+	for _, policy := range c.Policies {
+		if policy.AuditType == "" {
+			return fmt.Errorf("audit log retention policy audit type is not set")
+		}
+
+		if _, exists := auditTypes[policy.AuditType]; exists {
+			return fmt.Errorf("audit log retention policy of audit type %s is duplicated", policy.AuditType)
+		}
+		auditTypes[policy.AuditType] = struct{}{}
+
+		if policy.TTLDays < 0 || policy.MaxCount < 0 || (policy.TTLDays == 0 && policy.MaxCount == 0) {
+			return fmt.Errorf("audit log retention policy of audit type %s is invalid, ttl days: %d, max count: %d",
+				policy.AuditType, policy.TTLDays, policy.MaxCount)
+		}
+	}
+	return nil
+}
+
+// AuditExportFormat is the format of the exported audit logs
+type AuditExportFormat string
+
+const (
+	// AuditExportFormatSyslog exports the audit logs as RFC5424 syslog messages with the json audit log as the message
+	AuditExportFormatSyslog AuditExportFormat = "syslog"
+	// AuditExportFormatJSON exports the audit logs as newline delimited json
+	AuditExportFormatJSON AuditExportFormat = "json"
+)
+
+// AuditExportNetwork is the network that the audit logs are exported by
+type AuditExportNetwork string
+
+const (
+	// AuditExportNetworkTCP exports the audit logs to a tcp address
+	AuditExportNetworkTCP AuditExportNetwork = "tcp"
+	// AuditExportNetworkFile exports the audit logs by appending them to a local file
+	AuditExportNetworkFile AuditExportNetwork = "file"
+)
+
+// AuditExportConfig is the config of an audit log export sink
+type AuditExportConfig struct {
+	// Name is the unique name of the sink, the export progress of the sink is saved by its name, defaults to
+	// network:address
+	Name string `mapstructure:"name"`
+	// Format is the format of the exported audit logs
+	Format AuditExportFormat `mapstructure:"format"`
+	// Network is the network that the audit logs are exported by
+	Network AuditExportNetwork `mapstructure:"network"`
+	// Address is the host:port address for tcp network, or the file path for file network
+	Address string `mapstructure:"address"`
+	// SettleSeconds is the delay of exporting the new audit logs, so that the audit logs written in the transactions
+	// are exported after the transactions are committed, it should be longer than the transaction timeout, unit: second
+	SettleSeconds int `mapstructure:"settleSeconds"`
+}
+
+const (
+	// AuditExportIntervalSeconds is the interval of exporting the new audit logs
+	AuditExportIntervalSeconds = 10
+	// AuditExportSettleSecondsDefault is the default audit log export delay, it is longer than the transaction timeout
+	AuditExportSettleSecondsDefault = 180
+)
+
+// Validate AuditExportConfig and set the default values
+func (c *AuditExportConfig) Validate() error {
+	if c.Format != AuditExportFormatSyslog && c.Format != AuditExportFormatJSON {
+		return fmt.Errorf("audit log export format %s is invalid", c.Format)
+	}
+
+	if c.Network != AuditExportNetworkTCP && c.Network != AuditExportNetworkFile {
+		return fmt.Errorf("audit log export network %s is invalid", c.Network)
+	}
+
+	if c.Address == "" {
+		return fmt.Errorf("audit log export address is not set")
+	}
+
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("%s:%s", c.Network, c.Address)
+	}
+
+	if c.SettleSeconds == 0 {
+		c.SettleSeconds = AuditExportSettleSecondsDefault
+	}
+
+	if c.SettleSeconds < 0 {
+		return fmt.Errorf("audit log export settle seconds %d is invalid", c.SettleSeconds)
+	}
+	return nil
+}
+
+// AuditChainConfig is the config of the scheduled job that links the audit logs into the hash chains of their supplier
+// accounts and signs the chain heads as checkpoints
+type AuditChainConfig struct {
+	// Enabled defines if the audit logs are linked into the hash chains
+	Enabled bool `mapstructure:"enabled"`
+	// IntervalSeconds is the interval of linking the new audit logs, unit: second
+	IntervalSeconds int `mapstructure:"intervalSeconds"`
+	// SettleSeconds is the delay of linking the new audit logs, so that the audit logs written in the transactions
+	// are linked after the transactions are finished, unit: second
+	SettleSeconds int `mapstructure:"settleSeconds"`
+	// CheckpointIntervalMinutes is the interval of signing the chain heads as checkpoints, unit: minute
+	CheckpointIntervalMinutes int `mapstructure:"checkpointIntervalMinutes"`
+	// SignKey is the key that the checkpoints are signed with
+	SignKey string `mapstructure:"signKey"`
+}
+
+const (
+	// AuditChainIntervalSecondsDefault is the default audit log linking interval
+	AuditChainIntervalSecondsDefault = 60
+	// AuditChainIntervalSecondsMin is the minimum audit log linking interval
+	AuditChainIntervalSecondsMin = 10
+	// AuditChainSettleSecondsDefault is the default audit log linking delay
+	AuditChainSettleSecondsDefault = 300
+	// AuditChainCheckpointIntervalMinutesDefault is the default checkpoint interval
+	AuditChainCheckpointIntervalMinutesDefault = 60
+)
+
+// Validate AuditChainConfig and set the default values
+func (c *AuditChainConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.IntervalSeconds == 0 {
+		c.IntervalSeconds = AuditChainIntervalSecondsDefault
+	}
+
+	if c.IntervalSeconds < AuditChainIntervalSecondsMin {
+		return fmt.Errorf("audit log chain interval seconds %d is less than %d", c.IntervalSeconds,
+			AuditChainIntervalSecondsMin)
+	}
+
+	if c.SettleSeconds == 0 {
+		c.SettleSeconds = AuditChainSettleSecondsDefault
+	}
+
+	if c.SettleSeconds < 0 {
+		return fmt.Errorf("audit log chain settle seconds %d is invalid", c.SettleSeconds)
+	}
+
+	if c.CheckpointIntervalMinutes == 0 {
+		c.CheckpointIntervalMinutes = AuditChainCheckpointIntervalMinutesDefault
+	}
+
+	if c.CheckpointIntervalMinutes < 0 {
+		return fmt.Errorf("audit log chain checkpoint interval minutes %d is invalid", c.CheckpointIntervalMinutes)
+	}
+
+	if c.SignKey == "" {
+		return fmt.Errorf("audit log chain sign key is not set")
+	}
+	return nil
+}
@@ -27,6 +27,7 @@ import (
 	coresvr "configcenter/src/source_controller/coreservice/service"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
+	"configcenter/src/thirdparty/elasticsearch"
 )
 
 // CoreServer the core server
@@ -41,10 +42,40 @@ func (t *CoreServer) onCoreServiceConfigUpdate(previous, current cc.ProcessConfi
 		t.Config = new(options.Config)
 	}
 
+	t.Config.AuditLog = parseAuditLogConfig()
+	fullTextSearch, _ := cc.String("es.fullTextSearch")
+	fullTextBackend, _ := cc.String("es.backend")
+	t.Config.FullTextSearchIndex = elasticsearch.EsConfig{FullTextSearch: fullTextSearch,
+		Backend: fullTextBackend}.UseMongoDB()
+
 	blog.V(3).Infof("the new cfg:%#v the origin cfg:%#v", t.Config, string(current.ConfigData))
 
 }
 
+const auditLogConfigKey = "coreService.auditLog"
+
+// parseAuditLogConfig parse the audit log retention, archival, export and hash chain config, they are disabled if the config is
+// not set or invalid
+func parseAuditLogConfig() options.AuditLogConfig {
+	conf := options.AuditLogConfig{}
+	if !cc.IsExist(auditLogConfigKey) {
+		return conf
+	}
+
+	if err := cc.UnmarshalKey(auditLogConfigKey, &conf); err != nil {
+		blog.Errorf("parse audit log config failed, err: %v", err)
+		return options.AuditLogConfig{}
+	}
+
+	if err := conf.Validate(); err != nil {
+		blog.Errorf("audit log config is invalid, err: %v", err)
+		return options.AuditLogConfig{}
+	}
+
+	blog.Infof("audit log config: %+v", conf)
+	return conf
+}
+
 // Run main function
 func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOption) error {
 	svrInfo, err := types.NewServerInfo(op.ServConf)
@@ -100,6 +131,11 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	if err != nil {
 		return err
 	}
+
+	go coreService.RunAuditLogRetention(ctx)
+	go coreService.RunAuditLogChain(ctx)
+	go coreService.RunAuditLogExport(ctx)
+
 	select {
 	case <-ctx.Done():
 	}
@@ -0,0 +1,309 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditchain"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/app/options"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// auditArchiveBatchSize is the maximum number of the expired audit logs that are archived into one archive file
+const auditArchiveBatchSize = 10000
+
+// ArchiveExpiredAuditLog moves the audit logs that are expired by the retention policies into the compressed archive
+// files, and records the index of the archive files. the expired audit logs are deleted directly if the archive
+// directory is not set.
+func (m *auditManager) ArchiveExpiredAuditLog(kit *rest.Kit) error {
+	policyTypes := make([]string, 0)
+	for _, policy := range m.retention.Policies {
+		if policy.AuditType != options.AuditRetentionDefaultType {
+			policyTypes = append(policyTypes, policy.AuditType)
+		}
+	}
+
+	for _, policy := range m.retention.Policies {
+		cond, err := m.getExpiredAuditLogCond(kit, policy, policyTypes)
+		if err != nil {
+			return err
+		}
+
+		if len(cond) == 0 {
+			continue
+		}
+
+		if err := m.archiveAuditLog(kit, cond); err != nil {
+			blog.Errorf("archive expired audit logs failed, err: %v, policy: %+v, rid: %s", err, policy, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// getExpiredAuditLogCond get the condition of the audit logs that are expired by the retention policy, returns nil if
+// no audit log is expired
+func (m *auditManager) getExpiredAuditLogCond(kit *rest.Kit, policy options.AuditRetentionPolicy,
+	policyTypes []string) (mapstr.MapStr, error) {
+
+	typeCond := mapstr.MapStr{common.BKAuditTypeField: policy.AuditType}
+	if policy.AuditType == options.AuditRetentionDefaultType {
+		typeCond = mapstr.MapStr{common.BKAuditTypeField: mapstr.MapStr{common.BKDBNIN: policyTypes}}
+	}
+
+	expiredCond := make([]mapstr.MapStr, 0)
+	if policy.TTLDays > 0 {
+		expireTime := time.Now().AddDate(0, 0, -policy.TTLDays)
+		expiredCond = append(expiredCond, mapstr.MapStr{
+			common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: expireTime},
+		})
+	}
+
+	if policy.MaxCount > 0 {
+		// the audit logs that are older than the newest max count ones are expired
+		logs := make([]metadata.AuditLog, 0)
+		err := mongodb.Client().Table(common.BKTableNameAuditLog).Find(typeCond).Sort("-"+common.BKFieldID).
+			Start(uint64(policy.MaxCount-1)).Limit(1).Fields(common.BKFieldID).All(kit.Ctx, &logs)
+		if err != nil {
+			blog.Errorf("get the oldest retained audit log failed, err: %v, cond: %+v, rid: %s", err, typeCond,
+				kit.Rid)
+			return nil, err
+		}
+
+		if len(logs) > 0 {
+			expiredCond = append(expiredCond, mapstr.MapStr{
+				common.BKFieldID: mapstr.MapStr{common.BKDBLT: logs[0].ID},
+			})
+		}
+	}
+
+	if len(expiredCond) == 0 {
+		return nil, nil
+	}
+
+	typeCond[common.BKDBOR] = expiredCond
+	return typeCond, nil
+}
+
+// archiveAuditLog archives and deletes the audit logs matching the condition in batches, the oldest ones first
+func (m *auditManager) archiveAuditLog(kit *rest.Kit, cond mapstr.MapStr) error {
+	for {
+		logs := make([]metadata.AuditLog, 0)
+		err := mongodb.Client().Table(common.BKTableNameAuditLog).Find(cond).Sort(common.BKFieldID).
+			Limit(auditArchiveBatchSize).All(kit.Ctx, &logs)
+		if err != nil {
+			blog.Errorf("get expired audit logs failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return err
+		}
+
+		if len(logs) == 0 {
+			return nil
+		}
+
+		if m.retention.ArchiveDir != "" {
+			if err := m.saveAuditLogArchive(kit, logs); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]int64, len(logs))
+		for idx, log := range logs {
+			ids[idx] = log.ID
+		}
+
+		delCond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: ids}}
+		if err := mongodb.Client().Table(common.BKTableNameAuditLog).Delete(kit.Ctx, delCond); err != nil {
+			blog.Errorf("delete expired audit logs failed, err: %v, ids: %v, rid: %s", err, ids, kit.Rid)
+			return err
+		}
+
+		blog.Infof("%d expired audit logs are archived, id range: [%d, %d], rid: %s", len(logs), ids[0],
+			ids[len(ids)-1], kit.Rid)
+
+		if len(logs) < auditArchiveBatchSize {
+			return nil
+		}
+	}
+}
+
+// saveAuditLogArchive writes the audit logs sorted by id into an archive file and records its index
+func (m *auditManager) saveAuditLogArchive(kit *rest.Kit, logs []metadata.AuditLog) error {
+	first, last := logs[0], logs[len(logs)-1]
+	filePath := filepath.Join(m.retention.ArchiveDir, fmt.Sprintf("audit_%d_%d.jsonl.gz", first.ID, last.ID))
+
+	size, err := writeAuditLogArchiveFile(filePath, logs)
+	if err != nil {
+		blog.Errorf("write audit log archive file %s failed, err: %v, rid: %s", filePath, err, kit.Rid)
+		return err
+	}
+
+	archive := metadata.AuditLogArchive{
+		FilePath:   filePath,
+		Host:       m.hostname,
+		AuditTypes: make([]metadata.AuditType, 0),
+		StartID:    first.ID,
+		EndID:      last.ID,
+		StartTime:  first.OperationTime,
+		EndTime:    first.OperationTime,
+		Count:      int64(len(logs)),
+		Size:       size,
+		CreateTime: metadata.Now(),
+	}
+
+	auditTypeMap := make(map[metadata.AuditType]struct{})
+	for _, log := range logs {
+		if _, exists := auditTypeMap[log.AuditType]; !exists {
+			auditTypeMap[log.AuditType] = struct{}{}
+			archive.AuditTypes = append(archive.AuditTypes, log.AuditType)
+		}
+
+		if log.OperationTime.Before(archive.StartTime.Time) {
+			archive.StartTime = log.OperationTime
+		}
+		if log.OperationTime.After(archive.EndTime.Time) {
+			archive.EndTime = log.OperationTime
+		}
+	}
+
+	// record the signed archived hash chain ranges, so that the hash chain verification skips the archived audit logs
+	archive.ChainRanges = auditchain.GenArchivedRanges(m.chain.SignKey, logs)
+
+	id, err := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameAuditLogArchive)
+	if err != nil {
+		blog.Errorf("get next audit log archive id failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	archive.ID = int64(id)
+
+	if err := mongodb.Client().Table(common.BKTableNameAuditLogArchive).Insert(kit.Ctx, archive); err != nil {
+		blog.Errorf("save audit log archive %+v failed, err: %v, rid: %s", archive, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// writeAuditLogArchiveFile writes the audit logs into the file as gzip compressed json lines, the file is written to a
+// temporary file first so that an incomplete archive file is never left, returns the size of the file
+func writeAuditLogArchiveFile(filePath string, logs []metadata.AuditLog) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return 0, err
+	}
+
+	tmpPath := filePath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpPath)
+
+	writer := gzip.NewWriter(file)
+	for _, log := range logs {
+		line, err := json.Marshal(log)
+		if err != nil {
+			file.Close()
+			return 0, err
+		}
+
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			file.Close()
+			return 0, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// SearchAuditLogArchive search the index of the audit log archive files
+func (m *auditManager) SearchAuditLogArchive(kit *rest.Kit, opt *metadata.SearchAuditLogArchiveOption) (
+	*metadata.SearchAuditLogArchiveResult, errors.CCErrorCoder) {
+
+	cond := mapstr.MapStr{}
+	if opt.AuditType != "" {
+		cond["audit_types"] = opt.AuditType
+	}
+
+	if opt.AuditID > 0 {
+		cond["start_id"] = mapstr.MapStr{common.BKDBLTE: opt.AuditID}
+		cond["end_id"] = mapstr.MapStr{common.BKDBGTE: opt.AuditID}
+	}
+
+	start, end, err := opt.Time.ParseTime()
+	if err != nil {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "time")
+	}
+	// the archive files whose operation time range overlaps the searched time range are matched
+	if !start.IsZero() {
+		cond["end_time"] = mapstr.MapStr{common.BKDBGTE: start}
+	}
+	if !end.IsZero() {
+		cond["start_time"] = mapstr.MapStr{common.BKDBLTE: end}
+	}
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameAuditLogArchive).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count audit log archives failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.SearchAuditLogArchiveResult{Count: int64(count)}, nil
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = "-" + common.BKFieldID
+	}
+
+	archives := make([]metadata.AuditLogArchive, 0)
+	err = mongodb.Client().Table(common.BKTableNameAuditLogArchive).Find(cond).Sort(sort).
+		Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).All(kit.Ctx, &archives)
+	if err != nil {
+		blog.Errorf("search audit log archives failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.SearchAuditLogArchiveResult{Info: archives}, nil
+}
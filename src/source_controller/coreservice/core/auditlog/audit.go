@@ -13,6 +13,7 @@
 package auditlog
 
 import (
+	"os"
 	"strings"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/app/options"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/driver/mongodb"
 
@@ -31,11 +33,30 @@ import (
 var _ core.AuditOperation = (*auditManager)(nil)
 
 type auditManager struct {
+	retention options.AuditRetentionConfig
+	chain     options.AuditChainConfig
+	exporters []*auditExporter
+	hostname  string
 }
 
 // New create a new instance manager instance
-func New() core.AuditOperation {
-	return &auditManager{}
+func New(conf options.AuditLogConfig) core.AuditOperation {
+	hostname, err := os.Hostname()
+	if err != nil {
+		blog.Errorf("get hostname failed, err: %v", err)
+	}
+
+	manager := &auditManager{
+		retention: conf.Retention,
+		chain:     conf.Chain,
+		exporters: make([]*auditExporter, 0),
+		hostname:  hostname,
+	}
+
+	for _, exportConf := range conf.Export {
+		manager.exporters = append(manager.exporters, newAuditExporter(exportConf, hostname, mongodb.Client()))
+	}
+	return manager
 }
 
 // CreateAuditLog TODO
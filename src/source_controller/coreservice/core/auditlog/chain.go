@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"time"
+
+	"configcenter/src/common/auditchain"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// SealAuditLogChain links the audit logs written before the settle time into the hash chains of their supplier accounts
+func (m *auditManager) SealAuditLogChain(kit *rest.Kit) error {
+	settleTime := time.Now().Add(-time.Duration(m.chain.SettleSeconds) * time.Second)
+	count, err := auditchain.Seal(kit.Ctx, mongodb.Client(), settleTime)
+	if err != nil {
+		blog.Errorf("link audit logs into hash chain failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	if count > 0 {
+		blog.Infof("%d audit logs are linked into hash chain, rid: %s", count, kit.Rid)
+	}
+	return nil
+}
+
+// CheckpointAuditLogChain signs the heads of the audit log hash chains as checkpoints
+func (m *auditManager) CheckpointAuditLogChain(kit *rest.Kit) error {
+	if err := auditchain.Checkpoint(kit.Ctx, mongodb.Client(), m.chain.SignKey); err != nil {
+		blog.Errorf("sign audit log hash chain checkpoints failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/app/options"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// auditExportTimeout is the timeout of connecting and writing to the tcp sink
+	auditExportTimeout = 10 * time.Second
+	// auditExportBatchSize is the number of the audit logs that are exported in a batch
+	auditExportBatchSize = 500
+)
+
+// auditExporter exports the audit logs to a sink by tailing the audit log table in the order of their ids. the id of
+// the last exported audit log is saved after the audit logs are written to the sink, so that every audit log is
+// exported at least once even if the sink is unavailable or the process restarts. only the audit logs written before
+// the settle time are exported, so that the audit logs of the aborted transactions are never exported.
+type auditExporter struct {
+	conf     options.AuditExportConfig
+	hostname string
+	db       dal.DB
+	writer   io.WriteCloser
+}
+
+// auditExportProgress is the export progress of an audit log export sink
+type auditExportProgress struct {
+	Name     string    `bson:"name"`
+	LastID   int64     `bson:"last_id"`
+	LastTime time.Time `bson:"last_time"`
+}
+
+func newAuditExporter(conf options.AuditExportConfig, hostname string, db dal.DB) *auditExporter {
+	return &auditExporter{
+		conf:     conf,
+		hostname: hostname,
+		db:       db,
+	}
+}
+
+// ExportAuditLog exports the new audit logs to all the export sinks
+func (m *auditManager) ExportAuditLog(kit *rest.Kit) error {
+	var firstErr error
+	for _, exporter := range m.exporters {
+		count, err := exporter.exportOnce(kit.Ctx)
+		if count > 0 {
+			blog.V(4).Infof("%d audit logs are exported to %s, rid: %s", count, exporter.conf.Name, kit.Rid)
+		}
+
+		if err != nil {
+			blog.Errorf("export audit logs to %s failed, err: %v, rid: %s", exporter.conf.Name, err, kit.Rid)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// exportOnce exports the settled audit logs after the last exported audit log, returns the number of exported logs
+func (e *auditExporter) exportOnce(ctx context.Context) (int, error) {
+	settleTime := time.Now().Add(-time.Duration(e.conf.SettleSeconds) * time.Second)
+	lastID, err := e.getLastExportedID(ctx, settleTime)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for {
+		cond := mapstr.MapStr{
+			common.BKFieldID:            mapstr.MapStr{common.BKDBGT: lastID},
+			common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: settleTime},
+		}
+		logs := make([]metadata.AuditLog, 0)
+		err := e.db.Table(common.BKTableNameAuditLog).Find(cond).Sort(common.BKFieldID).Limit(auditExportBatchSize).
+			All(ctx, &logs)
+		if err != nil {
+			blog.Errorf("get audit logs to export failed, err: %v, cond: %+v", err, cond)
+			return count, err
+		}
+
+		if len(logs) == 0 {
+			return count, nil
+		}
+
+		for _, log := range logs {
+			data, err := formatAuditLog(log, e.conf, e.hostname)
+			if err != nil {
+				blog.Errorf("format audit log %d for export failed, err: %v", log.ID, err)
+				return count, err
+			}
+
+			if err = e.write(data); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		// the audit logs are exported again from the last saved progress if the progress is failed to save
+		lastID = logs[len(logs)-1].ID
+		if err = e.saveLastExportedID(ctx, lastID); err != nil {
+			return count, err
+		}
+
+		if len(logs) < auditExportBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// getLastExportedID get the id of the last exported audit log of the sink, a new sink starts exporting after the
+// latest settled audit log
+func (e *auditExporter) getLastExportedID(ctx context.Context, settleTime time.Time) (int64, error) {
+	cond := mapstr.MapStr{"name": e.conf.Name}
+	progress := make([]auditExportProgress, 0)
+	err := e.db.Table(common.BKTableNameAuditLogExportProgress).Find(cond).All(ctx, &progress)
+	if err != nil {
+		blog.Errorf("get audit log export progress failed, err: %v, cond: %+v", err, cond)
+		return 0, err
+	}
+
+	if len(progress) > 0 {
+		return progress[0].LastID, nil
+	}
+
+	latestCond := mapstr.MapStr{common.BKOperationTimeField: mapstr.MapStr{common.BKDBLT: settleTime}}
+	latest := make([]metadata.AuditLog, 0)
+	err = e.db.Table(common.BKTableNameAuditLog).Find(latestCond).Fields(common.BKFieldID).
+		Sort("-"+common.BKFieldID).Limit(1).All(ctx, &latest)
+	if err != nil {
+		blog.Errorf("get latest settled audit log failed, err: %v, cond: %+v", err, latestCond)
+		return 0, err
+	}
+
+	var lastID int64
+	if len(latest) > 0 {
+		lastID = latest[0].ID
+	}
+
+	if err = e.saveLastExportedID(ctx, lastID); err != nil {
+		return 0, err
+	}
+	return lastID, nil
+}
+
+func (e *auditExporter) saveLastExportedID(ctx context.Context, lastID int64) error {
+	cond := mapstr.MapStr{"name": e.conf.Name}
+	progress := &auditExportProgress{
+		Name:     e.conf.Name,
+		LastID:   lastID,
+		LastTime: time.Now(),
+	}
+
+	if err := e.db.Table(common.BKTableNameAuditLogExportProgress).Upsert(ctx, cond, progress); err != nil {
+		blog.Errorf("save audit log export progress failed, err: %v, progress: %+v", err, progress)
+		return err
+	}
+	return nil
+}
+
+// write writes the data to the sink, the sink is reopened at the next write if the write failed
+func (e *auditExporter) write(data []byte) error {
+	if e.writer == nil {
+		writer, err := e.open()
+		if err != nil {
+			blog.Errorf("open audit log export %s sink %s failed, err: %v", e.conf.Network, e.conf.Address, err)
+			return err
+		}
+		e.writer = writer
+	}
+
+	if conn, ok := e.writer.(net.Conn); ok {
+		if err := conn.SetWriteDeadline(time.Now().Add(auditExportTimeout)); err != nil {
+			blog.Errorf("set audit log export sink %s write deadline failed, err: %v", e.conf.Address, err)
+		}
+	}
+
+	if _, err := e.writer.Write(data); err != nil {
+		blog.Errorf("write audit log to %s sink %s failed, err: %v", e.conf.Network, e.conf.Address, err)
+		e.writer.Close()
+		e.writer = nil
+		return err
+	}
+	return nil
+}
+
+func (e *auditExporter) open() (io.WriteCloser, error) {
+	switch e.conf.Network {
+	case options.AuditExportNetworkTCP:
+		return net.DialTimeout("tcp", e.conf.Address, auditExportTimeout)
+	case options.AuditExportNetworkFile:
+		if err := os.MkdirAll(filepath.Dir(e.conf.Address), 0755); err != nil {
+			return nil, err
+		}
+		return os.OpenFile(e.conf.Address, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	default:
+		return nil, fmt.Errorf("audit log export network %s is invalid", e.conf.Network)
+	}
+}
+
+const (
+	// syslogFacilityLogAudit is the syslog "log audit" facility
+	syslogFacilityLogAudit = 13
+	// syslogSeverityInfo is the syslog "informational" severity
+	syslogSeverityInfo = 6
+	// syslogAppName is the APP-NAME of the exported syslog messages
+	syslogAppName = "bk_cmdb"
+	// syslogTimeFormat is the RFC5424 TIMESTAMP format, which is RFC3339 with at most 6 fractional digits
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// formatAuditLog formats the audit log to the exported data. for syslog format, the audit log is exported as a
+// RFC5424 message with the json audit log as MSG, which is framed by octet counting (RFC6587) over tcp, and by newline
+// in file. for json format, the audit log is exported as a json line.
+func formatAuditLog(log metadata.AuditLog, conf options.AuditExportConfig, hostname string) ([]byte, error) {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Format != options.AuditExportFormatSyslog {
+		return append(data, '\n'), nil
+	}
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s", syslogFacilityLogAudit*8+syslogSeverityInfo,
+		log.OperationTime.Format(syslogTimeFormat), syslogHeaderValue(hostname, 255), syslogAppName, os.Getpid(),
+		syslogHeaderValue(string(log.AuditType), 32), data)
+
+	if conf.Network == options.AuditExportNetworkTCP {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg)), nil
+	}
+	return []byte(msg + "\n"), nil
+}
+
+// syslogHeaderValue converts the value to a valid syslog header field, which only contains printable US-ASCII
+// characters except space and is not longer than the max length, the empty value is converted to NILVALUE
+func syslogHeaderValue(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/app/options"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuditLog(id int64) metadata.AuditLog {
+	return metadata.AuditLog{
+		ID:              id,
+		AuditType:       metadata.HostType,
+		User:            "admin",
+		ResourceType:    metadata.HostRes,
+		Action:          metadata.AuditUpdate,
+		OperationDetail: &metadata.BasicOpDetail{Details: &metadata.BasicContent{}},
+		OperationTime:   metadata.Time{Time: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		ResourceID:      id,
+	}
+}
+
+func TestFormatAuditLog(t *testing.T) {
+	log := newTestAuditLog(1)
+	data, err := json.Marshal(log)
+	require.NoError(t, err)
+
+	jsonConf := options.AuditExportConfig{Format: options.AuditExportFormatJSON, Network: options.AuditExportNetworkTCP}
+	line, err := formatAuditLog(log, jsonConf, "host")
+	require.NoError(t, err)
+	require.Equal(t, string(data)+"\n", string(line))
+
+	syslogHeader := regexp.MustCompile(`^<110>1 2026-10-18T12:00:00\.000000Z host bk_cmdb \d+ host - `)
+
+	fileConf := options.AuditExportConfig{Format: options.AuditExportFormatSyslog,
+		Network: options.AuditExportNetworkFile}
+	msg, err := formatAuditLog(log, fileConf, "host")
+	require.NoError(t, err)
+	require.Regexp(t, syslogHeader, string(msg))
+	require.True(t, strings.HasSuffix(string(msg), string(data)+"\n"))
+
+	// syslog messages over tcp are framed by octet counting
+	tcpConf := options.AuditExportConfig{Format: options.AuditExportFormatSyslog, Network: options.AuditExportNetworkTCP}
+	frame, err := formatAuditLog(log, tcpConf, "my host")
+	require.NoError(t, err)
+	parts := strings.SplitN(string(frame), " ", 2)
+	require.Len(t, parts, 2)
+	require.Equal(t, fmt.Sprint(len(parts[1])), parts[0])
+	require.Contains(t, parts[1], " my_host bk_cmdb ")
+	require.True(t, strings.HasSuffix(parts[1], string(data)))
+}
+
+func TestSyslogHeaderValue(t *testing.T) {
+	require.Equal(t, "-", syslogHeaderValue("", 32))
+	require.Equal(t, "a_b_c", syslogHeaderValue("a b\tc", 32))
+	require.Equal(t, "abc", syslogHeaderValue("abcdef", 3))
+}
+
+func TestAuditExporterFile(t *testing.T) {
+	db := memory.NewDB()
+	ctx := context.Background()
+	now := time.Now()
+	insertLogs := func(opTime time.Time, ids ...int64) {
+		logs := make([]metadata.AuditLog, len(ids))
+		for idx, id := range ids {
+			logs[idx] = newTestAuditLog(id)
+			logs[idx].OperationTime = metadata.Time{Time: opTime}
+		}
+		require.NoError(t, db.Table(common.BKTableNameAuditLog).Insert(ctx, logs))
+	}
+	readIDs := func(filePath string) []int64 {
+		data, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		ids := make([]int64, 0)
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			log := new(metadata.AuditLog)
+			require.NoError(t, json.Unmarshal([]byte(line), log))
+			ids = append(ids, log.ID)
+		}
+		return ids
+	}
+
+	// a new sink starts exporting after the latest settled audit log
+	insertLogs(now.Add(-time.Hour), 1, 2)
+	filePath := filepath.Join(t.TempDir(), "export", "audit.log")
+	conf := options.AuditExportConfig{Name: "file", Format: options.AuditExportFormatJSON,
+		Network: options.AuditExportNetworkFile, Address: filePath, SettleSeconds: 60}
+	exporter := newAuditExporter(conf, "host", db)
+	count, err := exporter.exportOnce(ctx)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// the audit logs that are not settled are exported in the later rounds
+	insertLogs(now.Add(-time.Minute*2), 4, 3)
+	insertLogs(now, 5)
+	count, err = exporter.exportOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []int64{3, 4}, readIDs(filePath))
+
+	// the export progress is saved, the restarted exporter does not export the exported audit logs again
+	require.NoError(t, db.Table(common.BKTableNameAuditLog).Update(ctx, mapstr.MapStr{common.BKFieldID: 5},
+		mapstr.MapStr{common.BKOperationTimeField: now.Add(-time.Minute * 2)}))
+	exporter = newAuditExporter(conf, "host", db)
+	count, err = exporter.exportOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, []int64{3, 4, 5}, readIDs(filePath))
+
+	// the audit logs are not exported if the sink is unavailable, they are exported after the sink is available
+	insertLogs(now.Add(-time.Minute*2), 6)
+	invalidConf := conf
+	invalidConf.Address = filepath.Join(filePath, "invalid")
+	count, err = newAuditExporter(invalidConf, "host", db).exportOnce(ctx)
+	require.Error(t, err)
+	require.Zero(t, count)
+
+	count, err = exporter.exportOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, []int64{3, 4, 5, 6}, readIDs(filePath))
+
+	progress := make([]auditExportProgress, 0)
+	require.NoError(t, db.Table(common.BKTableNameAuditLogExportProgress).Find(nil).All(ctx, &progress))
+	require.Len(t, progress, 1)
+	require.Equal(t, "file", progress[0].Name)
+	require.Equal(t, int64(6), progress[0].LastID)
+}
+
+func TestWriteAuditLogArchiveFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "archive", "audit_1_3.jsonl.gz")
+	logs := []metadata.AuditLog{newTestAuditLog(1), newTestAuditLog(2), newTestAuditLog(3)}
+
+	size, err := writeAuditLogArchiveFile(filePath, logs)
+	require.NoError(t, err)
+	require.Greater(t, size, int64(0))
+
+	_, err = os.Stat(filePath + ".tmp")
+	require.True(t, os.IsNotExist(err))
+
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(reader)
+	ids := make([]int64, 0)
+	for scanner.Scan() {
+		log := new(metadata.AuditLog)
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), log))
+		ids = append(ids, log.ID)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []int64{1, 2, 3}, ids)
+}
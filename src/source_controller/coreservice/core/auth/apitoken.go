@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const (
+	apiTokenUserField       = "user"
+	apiTokenHashField       = "token_hash"
+	apiTokenLastUsedField   = "last_used_time"
+	apiTokenExpireTimeField = "expire_time"
+)
+
+// CreateAPIToken create personal api token for the current user, token name is unique for each user
+func (a *authOperation) CreateAPIToken(kit *rest.Kit, token *metadata.APIToken) (int64, errors.CCErrorCoder) {
+	cond := util.SetModOwner(mapstr.MapStr{apiTokenUserField: kit.User}, kit.SupplierAccount)
+	tokens := make([]metadata.APIToken, 0)
+	err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(cond).Fields(common.BKFieldName).All(kit.Ctx, &tokens)
+	if err != nil {
+		blog.Errorf("list api tokens of user %s failed, err: %v, rid: %s", kit.User, err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(tokens) >= metadata.APITokenUserLimit {
+		blog.Errorf("user %s already has %d api tokens, rid: %s", kit.User, len(tokens), kit.Rid)
+		return 0, kit.CCError.CCErrorf(common.CCErrCommXXExceedLimit, "api token", metadata.APITokenUserLimit)
+	}
+
+	for _, exist := range tokens {
+		if exist.Name == token.Name {
+			blog.Errorf("api token name %s of user %s is duplicated, rid: %s", token.Name, kit.User, kit.Rid)
+			return 0, kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, common.BKFieldName)
+		}
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAPIToken)
+	if err != nil {
+		blog.Errorf("generate api token id failed, err: %v, rid: %s", err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	token.ID = int64(id)
+	token.User = kit.User
+	token.OwnerID = kit.SupplierAccount
+	token.LastUsedTime = nil
+	token.CreateTime = &now
+
+	if err = a.dbProxy.Table(common.BKTableNameAPIToken).Insert(kit.Ctx, token); err != nil {
+		blog.Errorf("create api token failed, err: %v, name: %s, rid: %s", err, token.Name, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return token.ID, nil
+}
+
+// DeleteAPIToken delete personal api token, users can only delete their own tokens
+func (a *authOperation) DeleteAPIToken(kit *rest.Kit, id int64) errors.CCErrorCoder {
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id, apiTokenUserField: kit.User}, kit.SupplierAccount)
+	cnt, err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count api token %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt == 0 {
+		blog.Errorf("api token %d of user %s is not exist, rid: %s", id, kit.User, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+
+	if err = a.dbProxy.Table(common.BKTableNameAPIToken).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete api token %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// ListAPITokens list the personal api tokens of the current user, the token hashes are not returned
+func (a *authOperation) ListAPITokens(kit *rest.Kit, opt *metadata.ListAPITokenOption) (*metadata.ListAPITokenResult,
+	errors.CCErrorCoder) {
+
+	// the api tokens belong to the user of the supplier account, so the tokens of the same user name in the default
+	// supplier account are not returned
+	cond := util.SetModOwner(mapstr.MapStr{apiTokenUserField: kit.User}, kit.SupplierAccount)
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+
+	result := &metadata.ListAPITokenResult{Info: make([]metadata.APIToken, 0)}
+	count, err := a.listByCond(kit, common.BKTableNameAPIToken, cond, opt.Page, &result.Info)
+	if err != nil {
+		return nil, err
+	}
+	result.Count = count
+
+	for idx := range result.Info {
+		result.Info[idx].TokenHash = ""
+	}
+	return result, nil
+}
+
+// FindAPITokenByHash find the api token that is not expired by the hash of the token, the token is used for
+// authentication, so the supplier account and the user of the token are not known yet and are not checked
+func (a *authOperation) FindAPITokenByHash(kit *rest.Kit, hash string) (*metadata.APIToken, errors.CCErrorCoder) {
+	cond := mapstr.MapStr{
+		apiTokenHashField:       hash,
+		apiTokenExpireTimeField: mapstr.MapStr{common.BKDBGT: metadata.Now()},
+	}
+
+	tokens := make([]metadata.APIToken, 0)
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(cond).All(kit.Ctx, &tokens); err != nil {
+		blog.Errorf("find api token by hash failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(tokens) == 0 {
+		return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	return &tokens[0], nil
+}
+
+// UpdateAPITokenLastUsedTime update the last used time of the api token of the current user
+func (a *authOperation) UpdateAPITokenLastUsedTime(kit *rest.Kit, id int64) errors.CCErrorCoder {
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id, apiTokenUserField: kit.User}, kit.SupplierAccount)
+	data := mapstr.MapStr{apiTokenLastUsedField: metadata.Now()}
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Update(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("update api token %d last used time failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAPITokens(t *testing.T) {
+	db := memory.NewDB()
+	tokens := []metadata.APIToken{
+		{ID: 1, Name: "default", User: "alice", TokenHash: "hash1", OwnerID: common.BKDefaultOwnerID},
+		{ID: 2, Name: "tenant", User: "alice", TokenHash: "hash2", OwnerID: "1"},
+		{ID: 3, Name: "other", User: "bob", TokenHash: "hash3", OwnerID: "1"},
+	}
+	require.NoError(t, db.Table(common.BKTableNameAPIToken).Insert(context.Background(), tokens))
+
+	op := &authOperation{dbProxy: db}
+	kit := &rest.Kit{Ctx: context.Background(), Rid: "rid", User: "alice", SupplierAccount: "1"}
+	opt := &metadata.ListAPITokenOption{Page: metadata.BasePage{Limit: common.BKMaxPageSize}}
+
+	// the tokens of the same user name in the default supplier account are not listed
+	result, err := op.ListAPITokens(kit, opt)
+	require.NoError(t, err)
+	require.Len(t, result.Info, 1)
+	require.EqualValues(t, 2, result.Info[0].ID)
+	require.Empty(t, result.Info[0].TokenHash)
+
+	opt.IDs = []int64{1}
+	result, err = op.ListAPITokens(kit, opt)
+	require.NoError(t, err)
+	require.Empty(t, result.Info)
+
+	kit.SupplierAccount = common.BKDefaultOwnerID
+	opt.IDs = nil
+	result, err = op.ListAPITokens(kit, opt)
+	require.NoError(t, err)
+	require.Len(t, result.Info, 1)
+	require.EqualValues(t, 1, result.Info[0].ID)
+}
@@ -0,0 +1,434 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+const (
+	rbacRoleIDField = "role_id"
+	rbacUsersField  = "users"
+	rbacGroupsField = "groups"
+)
+
+// CreateRBACRole create rbac role, role name is unique
+func (a *authOperation) CreateRBACRole(kit *rest.Kit, role *metadata.RBACRole) (int64, errors.CCErrorCoder) {
+	if err := a.checkRBACNameUnique(kit, common.BKTableNameRBACRole, role.Name, 0); err != nil {
+		return 0, err
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameRBACRole)
+	if err != nil {
+		blog.Errorf("generate rbac role id failed, err: %v, rid: %s", err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	role.ID = int64(id)
+	role.OwnerID = kit.SupplierAccount
+	role.Creator = kit.User
+	role.Modifier = kit.User
+	role.CreateTime = &now
+	role.LastTime = &now
+
+	if err = a.dbProxy.Table(common.BKTableNameRBACRole).Insert(kit.Ctx, role); err != nil {
+		blog.Errorf("create rbac role failed, err: %v, role: %+v, rid: %s", err, role, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return role.ID, nil
+}
+
+// UpdateRBACRole update the name, description and permissions of the rbac role
+func (a *authOperation) UpdateRBACRole(kit *rest.Kit, id int64, role *metadata.RBACRole) errors.CCErrorCoder {
+	if err := a.checkRBACExists(kit, common.BKTableNameRBACRole, id); err != nil {
+		return err
+	}
+
+	if err := a.checkRBACNameUnique(kit, common.BKTableNameRBACRole, role.Name, id); err != nil {
+		return err
+	}
+
+	data := mapstr.MapStr{
+		common.BKFieldName:        role.Name,
+		common.BKDescriptionField: role.Description,
+		"permissions":             role.Permissions,
+	}
+	return a.updateRBAC(kit, common.BKTableNameRBACRole, id, data)
+}
+
+// DeleteRBACRole delete rbac role, role that is bound to users or groups can not be deleted
+func (a *authOperation) DeleteRBACRole(kit *rest.Kit, id int64) errors.CCErrorCoder {
+	cond := util.SetQueryOwner(mapstr.MapStr{rbacRoleIDField: id}, kit.SupplierAccount)
+	cnt, err := a.dbProxy.Table(common.BKTableNameRBACRoleBinding).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count rbac role bindings failed, err: %v, role id: %d, rid: %s", err, id, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt > 0 {
+		blog.Errorf("rbac role %d is bound by %d bindings, can not be deleted, rid: %s", id, cnt, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommRemoveReferencedRecordForbidden)
+	}
+
+	return a.deleteRBAC(kit, common.BKTableNameRBACRole, id)
+}
+
+// ListRBACRoles list rbac roles
+func (a *authOperation) ListRBACRoles(kit *rest.Kit, opt *metadata.ListRBACRoleOption) (*metadata.ListRBACRoleResult,
+	errors.CCErrorCoder) {
+
+	cond := mapstr.MapStr{}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if len(opt.Names) > 0 {
+		cond[common.BKFieldName] = mapstr.MapStr{common.BKDBIN: opt.Names}
+	}
+
+	result := &metadata.ListRBACRoleResult{Info: make([]metadata.RBACRole, 0)}
+	count, err := a.listRBAC(kit, common.BKTableNameRBACRole, cond, opt.Page, &result.Info)
+	if err != nil {
+		return nil, err
+	}
+	result.Count = count
+	return result, nil
+}
+
+// CreateRBACRoleBinding create rbac role binding
+func (a *authOperation) CreateRBACRoleBinding(kit *rest.Kit, binding *metadata.RBACRoleBinding) (int64,
+	errors.CCErrorCoder) {
+
+	if err := a.checkRBACRoleBinding(kit, binding); err != nil {
+		return 0, err
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameRBACRoleBinding)
+	if err != nil {
+		blog.Errorf("generate rbac role binding id failed, err: %v, rid: %s", err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	binding.ID = int64(id)
+	binding.OwnerID = kit.SupplierAccount
+	binding.Creator = kit.User
+	binding.Modifier = kit.User
+	binding.CreateTime = &now
+	binding.LastTime = &now
+
+	if err = a.dbProxy.Table(common.BKTableNameRBACRoleBinding).Insert(kit.Ctx, binding); err != nil {
+		blog.Errorf("create rbac role binding failed, err: %v, binding: %+v, rid: %s", err, binding, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return binding.ID, nil
+}
+
+// UpdateRBACRoleBinding update the role, subjects and scope of the rbac role binding
+func (a *authOperation) UpdateRBACRoleBinding(kit *rest.Kit, id int64,
+	binding *metadata.RBACRoleBinding) errors.CCErrorCoder {
+
+	if err := a.checkRBACExists(kit, common.BKTableNameRBACRoleBinding, id); err != nil {
+		return err
+	}
+
+	if err := a.checkRBACRoleBinding(kit, binding); err != nil {
+		return err
+	}
+
+	data := mapstr.MapStr{
+		rbacRoleIDField: binding.RoleID,
+		rbacUsersField:  binding.Users,
+		rbacGroupsField: binding.Groups,
+		"scope":         binding.Scope,
+	}
+	return a.updateRBAC(kit, common.BKTableNameRBACRoleBinding, id, data)
+}
+
+// checkRBACRoleBinding check if the role and the groups of the rbac role binding exist
+func (a *authOperation) checkRBACRoleBinding(kit *rest.Kit, binding *metadata.RBACRoleBinding) errors.CCErrorCoder {
+	if err := a.checkRBACExists(kit, common.BKTableNameRBACRole, binding.RoleID); err != nil {
+		return err
+	}
+
+	if binding.Users == nil {
+		binding.Users = make([]string, 0)
+	}
+	if binding.Groups == nil {
+		binding.Groups = make([]string, 0)
+	}
+	binding.Users = util.StrArrayUnique(binding.Users)
+	binding.Groups = util.StrArrayUnique(binding.Groups)
+
+	if len(binding.Groups) == 0 {
+		return nil
+	}
+
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldName: mapstr.MapStr{common.BKDBIN: binding.Groups}},
+		kit.SupplierAccount)
+	cnt, err := a.dbProxy.Table(common.BKTableNameRBACUserGroup).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count rbac user groups failed, err: %v, groups: %v, rid: %s", err, binding.Groups, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if int(cnt) != len(binding.Groups) {
+		blog.Errorf("some of the rbac user groups %v are not exist, rid: %s", binding.Groups, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, rbacGroupsField)
+	}
+
+	return nil
+}
+
+// DeleteRBACRoleBinding delete rbac role binding
+func (a *authOperation) DeleteRBACRoleBinding(kit *rest.Kit, id int64) errors.CCErrorCoder {
+	return a.deleteRBAC(kit, common.BKTableNameRBACRoleBinding, id)
+}
+
+// ListRBACRoleBindings list rbac role bindings
+func (a *authOperation) ListRBACRoleBindings(kit *rest.Kit, opt *metadata.ListRBACRoleBindingOption) (
+	*metadata.ListRBACRoleBindingResult, errors.CCErrorCoder) {
+
+	cond := mapstr.MapStr{}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if len(opt.RoleIDs) > 0 {
+		cond[rbacRoleIDField] = mapstr.MapStr{common.BKDBIN: opt.RoleIDs}
+	}
+
+	subjectCond := make([]mapstr.MapStr, 0)
+	if len(opt.Users) > 0 {
+		subjectCond = append(subjectCond, mapstr.MapStr{rbacUsersField: mapstr.MapStr{common.BKDBIN: opt.Users}})
+	}
+	if len(opt.Groups) > 0 {
+		subjectCond = append(subjectCond, mapstr.MapStr{rbacGroupsField: mapstr.MapStr{common.BKDBIN: opt.Groups}})
+	}
+	if len(subjectCond) > 0 {
+		cond[common.BKDBOR] = subjectCond
+	}
+
+	result := &metadata.ListRBACRoleBindingResult{Info: make([]metadata.RBACRoleBinding, 0)}
+	count, err := a.listRBAC(kit, common.BKTableNameRBACRoleBinding, cond, opt.Page, &result.Info)
+	if err != nil {
+		return nil, err
+	}
+	result.Count = count
+	return result, nil
+}
+
+// CreateRBACUserGroup create rbac user group, group name is unique
+func (a *authOperation) CreateRBACUserGroup(kit *rest.Kit, group *metadata.RBACUserGroup) (int64,
+	errors.CCErrorCoder) {
+
+	if err := a.checkRBACNameUnique(kit, common.BKTableNameRBACUserGroup, group.Name, 0); err != nil {
+		return 0, err
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameRBACUserGroup)
+	if err != nil {
+		blog.Errorf("generate rbac user group id failed, err: %v, rid: %s", err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := metadata.Now()
+	group.ID = int64(id)
+	group.OwnerID = kit.SupplierAccount
+	group.Creator = kit.User
+	group.Modifier = kit.User
+	group.CreateTime = &now
+	group.LastTime = &now
+	if group.Members == nil {
+		group.Members = make([]string, 0)
+	}
+	group.Members = util.StrArrayUnique(group.Members)
+
+	if err = a.dbProxy.Table(common.BKTableNameRBACUserGroup).Insert(kit.Ctx, group); err != nil {
+		blog.Errorf("create rbac user group failed, err: %v, group: %+v, rid: %s", err, group, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return group.ID, nil
+}
+
+// UpdateRBACUserGroup update the description and members of the rbac user group, the name of the group is referred
+// by the role bindings, so it can not be changed
+func (a *authOperation) UpdateRBACUserGroup(kit *rest.Kit, id int64,
+	group *metadata.RBACUserGroup) errors.CCErrorCoder {
+
+	if err := a.checkRBACExists(kit, common.BKTableNameRBACUserGroup, id); err != nil {
+		return err
+	}
+
+	if group.Members == nil {
+		group.Members = make([]string, 0)
+	}
+
+	data := mapstr.MapStr{
+		common.BKDescriptionField: group.Description,
+		"members":                 util.StrArrayUnique(group.Members),
+	}
+	return a.updateRBAC(kit, common.BKTableNameRBACUserGroup, id, data)
+}
+
+// DeleteRBACUserGroup delete rbac user group, group that is bound to roles can not be deleted
+func (a *authOperation) DeleteRBACUserGroup(kit *rest.Kit, id int64) errors.CCErrorCoder {
+	groups := make([]metadata.RBACUserGroup, 0)
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: id}, kit.SupplierAccount)
+	err := a.dbProxy.Table(common.BKTableNameRBACUserGroup).Find(cond).Fields(common.BKFieldName).All(kit.Ctx, &groups)
+	if err != nil {
+		blog.Errorf("get rbac user group %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(groups) == 0 {
+		return kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+
+	bindingCond := util.SetQueryOwner(mapstr.MapStr{rbacGroupsField: groups[0].Name}, kit.SupplierAccount)
+	cnt, err := a.dbProxy.Table(common.BKTableNameRBACRoleBinding).Find(bindingCond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count rbac role bindings failed, err: %v, group: %s, rid: %s", err, groups[0].Name, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt > 0 {
+		blog.Errorf("rbac user group %d is bound by %d bindings, can not be deleted, rid: %s", id, cnt, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommRemoveReferencedRecordForbidden)
+	}
+
+	return a.deleteRBAC(kit, common.BKTableNameRBACUserGroup, id)
+}
+
+// ListRBACUserGroups list rbac user groups
+func (a *authOperation) ListRBACUserGroups(kit *rest.Kit, opt *metadata.ListRBACUserGroupOption) (
+	*metadata.ListRBACUserGroupResult, errors.CCErrorCoder) {
+
+	cond := mapstr.MapStr{}
+	if len(opt.IDs) > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBIN: opt.IDs}
+	}
+	if len(opt.Names) > 0 {
+		cond[common.BKFieldName] = mapstr.MapStr{common.BKDBIN: opt.Names}
+	}
+	if len(opt.Members) > 0 {
+		cond["members"] = mapstr.MapStr{common.BKDBIN: opt.Members}
+	}
+
+	result := &metadata.ListRBACUserGroupResult{Info: make([]metadata.RBACUserGroup, 0)}
+	count, err := a.listRBAC(kit, common.BKTableNameRBACUserGroup, cond, opt.Page, &result.Info)
+	if err != nil {
+		return nil, err
+	}
+	result.Count = count
+	return result, nil
+}
+
+func (a *authOperation) checkRBACExists(kit *rest.Kit, table string, id int64) errors.CCErrorCoder {
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: id}, kit.SupplierAccount)
+	cnt, err := a.dbProxy.Table(table).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count %s by id %d failed, err: %v, rid: %s", table, id, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt == 0 {
+		blog.Errorf("%s with id %d is not exist, rid: %s", table, id, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	return nil
+}
+
+// checkRBACNameUnique check if the name is used by other records except the one with the id
+func (a *authOperation) checkRBACNameUnique(kit *rest.Kit, table, name string, id int64) errors.CCErrorCoder {
+	cond := mapstr.MapStr{common.BKFieldName: name}
+	if id > 0 {
+		cond[common.BKFieldID] = mapstr.MapStr{common.BKDBNE: id}
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	cnt, err := a.dbProxy.Table(table).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count %s by name %s failed, err: %v, rid: %s", table, name, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt > 0 {
+		blog.Errorf("%s name %s is duplicated, rid: %s", table, name, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, common.BKFieldName)
+	}
+	return nil
+}
+
+func (a *authOperation) updateRBAC(kit *rest.Kit, table string, id int64, data mapstr.MapStr) errors.CCErrorCoder {
+	data[common.ModifierField] = kit.User
+	data[common.LastTimeField] = metadata.Now()
+
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, kit.SupplierAccount)
+	if err := a.dbProxy.Table(table).Update(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("update %s failed, err: %v, id: %d, data: %+v, rid: %s", table, err, id, data, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+func (a *authOperation) deleteRBAC(kit *rest.Kit, table string, id int64) errors.CCErrorCoder {
+	cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, kit.SupplierAccount)
+	if err := a.dbProxy.Table(table).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete %s failed, err: %v, id: %d, rid: %s", table, err, id, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// listRBAC list the rbac records into result, only count is returned if page enables count
+func (a *authOperation) listRBAC(kit *rest.Kit, table string, cond mapstr.MapStr, page metadata.BasePage,
+	result interface{}) (uint64, errors.CCErrorCoder) {
+
+	return a.listByCond(kit, table, util.SetQueryOwner(cond, kit.SupplierAccount), page, result)
+}
+
+// listByCond list the data of the table by the condition as it is, the supplier account condition is not set
+func (a *authOperation) listByCond(kit *rest.Kit, table string, cond mapstr.MapStr, page metadata.BasePage,
+	result interface{}) (uint64, errors.CCErrorCoder) {
+
+	if page.EnableCount {
+		count, err := a.dbProxy.Table(table).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count %s failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
+			return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return count, nil
+	}
+
+	sort := page.Sort
+	if sort == "" {
+		sort = common.BKFieldID
+	}
+
+	err := a.dbProxy.Table(table).Find(cond).Sort(sort).Start(uint64(page.Start)).Limit(uint64(page.Limit)).
+		All(kit.Ctx, result)
+	if err != nil {
+		blog.Errorf("list %s failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return 0, nil
+}
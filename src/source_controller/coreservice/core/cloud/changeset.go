@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloud
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateSyncChangeSet 创建云同步变更集，同一任务之前待审批的变更集会被标记为已替代，保证任务最多只有一个待审批的变更集
+func (c *cloudOperation) CreateSyncChangeSet(kit *rest.Kit, changeSet *metadata.CloudSyncChangeSet) (
+	*metadata.CloudSyncChangeSet, errors.CCErrorCoder) {
+
+	if changeSet.TaskID <= 0 {
+		blog.Errorf("CreateSyncChangeSet failed, task id is invalid, taskID: %d, rid: %s", changeSet.TaskID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudSyncTaskID)
+	}
+
+	if err := c.supersedePendingChangeSet(kit, changeSet.TaskID); err != nil {
+		return nil, err
+	}
+
+	id, err := c.dbProxy.NextSequence(kit.Ctx, common.BKTableNameCloudSyncChangeSet)
+	if err != nil {
+		blog.Errorf("CreateSyncChangeSet failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	changeSet.ChangeSetID = int64(id)
+	changeSet.Status = metadata.ChangeSetPending
+	changeSet.OwnerID = kit.SupplierAccount
+	changeSet.CreateTime = now
+	changeSet.LastTime = now
+
+	err = c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Insert(kit.Ctx, changeSet)
+	if err != nil {
+		blog.Errorf("CreateSyncChangeSet failed, db insert failed, taskID: %d, err: %v, rid: %s", changeSet.TaskID,
+			err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	return changeSet, nil
+}
+
+// SearchSyncChangeSet 查询云同步变更集
+func (c *cloudOperation) SearchSyncChangeSet(kit *rest.Kit, option *metadata.SearchSyncChangeSetOption) (
+	*metadata.MultipleSyncChangeSet, errors.CCErrorCoder) {
+
+	cond := mapstr.MapStr{common.BKCloudSyncTaskID: option.TaskID}
+	if option.ChangeSetID > 0 {
+		cond.Set(common.BKCloudChangeSetID, option.ChangeSetID)
+	}
+	if option.Status != "" {
+		cond.Set(common.BKStatus, option.Status)
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	count, err := c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("SearchSyncChangeSet failed, db count failed, cond: %v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := option.Page.Sort
+	if sort == "" {
+		sort = "-" + common.BKCloudChangeSetID
+	}
+
+	results := make([]metadata.CloudSyncChangeSet, 0)
+	err = c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Find(cond).Fields(option.Fields...).
+		Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).Sort(sort).All(kit.Ctx, &results)
+	if err != nil {
+		blog.Errorf("SearchSyncChangeSet failed, db find failed, cond: %v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleSyncChangeSet{Count: int64(count), Info: results}, nil
+}
+
+// UpdateSyncChangeSet 更新云同步变更集，只允许更新状态和操作人
+func (c *cloudOperation) UpdateSyncChangeSet(kit *rest.Kit, changeSetID int64, option mapstr.MapStr) errors.CCErrorCoder {
+	data := mapstr.MapStr{common.LastTimeField: time.Now()}
+	if option.Exists(common.BKStatus) {
+		status, err := option.String(common.BKStatus)
+		if err != nil || (status != metadata.ChangeSetApplied && status != metadata.ChangeSetSuperseded) {
+			blog.Errorf("UpdateSyncChangeSet failed, status %v is invalid, rid: %s", option[common.BKStatus], kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKStatus)
+		}
+		data.Set(common.BKStatus, status)
+	}
+	if option.Exists(common.BKOperatorField) {
+		data.Set(common.BKOperatorField, option[common.BKOperatorField])
+	}
+
+	// 只有待审批的变更集可以被更新
+	filter := mapstr.MapStr{
+		common.BKCloudChangeSetID: changeSetID,
+		common.BKStatus:           metadata.ChangeSetPending,
+	}
+	filter = util.SetModOwner(filter, kit.SupplierAccount)
+	count, err := c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("UpdateSyncChangeSet failed, db count failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		blog.Errorf("UpdateSyncChangeSet failed, change set %d is not pending, rid: %s", changeSetID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCloudSyncChangeSetNotPending, changeSetID)
+	}
+
+	if err := c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Update(kit.Ctx, filter, data); err != nil {
+		blog.Errorf("UpdateSyncChangeSet failed, db update failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// supersedePendingChangeSet 将任务下待审批的变更集标记为已替代
+func (c *cloudOperation) supersedePendingChangeSet(kit *rest.Kit, taskID int64) errors.CCErrorCoder {
+	filter := mapstr.MapStr{
+		common.BKCloudSyncTaskID: taskID,
+		common.BKStatus:          metadata.ChangeSetPending,
+	}
+	filter = util.SetModOwner(filter, kit.SupplierAccount)
+	data := mapstr.MapStr{
+		common.BKStatus:      metadata.ChangeSetSuperseded,
+		common.LastTimeField: time.Now(),
+	}
+	if err := c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Update(kit.Ctx, filter, data); err != nil {
+		blog.Errorf("supersede pending change set failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
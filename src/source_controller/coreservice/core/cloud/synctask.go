@@ -128,6 +128,11 @@ func (c *cloudOperation) DeleteSyncTask(kit *rest.Kit, taskID int64) errors.CCEr
 		blog.Errorf("DeleteSyncTask failed, mongodb operate failed, table: %s, filter: %+v, err: %+v, rid: %s", common.BKTableNameCloudAccount, cond, err, kit.Rid)
 		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
 	}
+	// 删除任务的变更集
+	if err := c.dbProxy.Table(common.BKTableNameCloudSyncChangeSet).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("DeleteSyncTask failed, delete change set failed, filter: %+v, err: %+v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
 
 	return nil
 }
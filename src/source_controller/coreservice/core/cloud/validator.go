@@ -75,6 +75,33 @@ func (c *cloudOperation) validUpdateAccount(kit *rest.Kit, accountID int64, opti
 		}
 	}
 
+	// http inventory endpoint and field mapping check
+	if option.Exists(common.BKCloudEndpoint) {
+		endpoint, err := option.String(common.BKCloudEndpoint)
+		if err != nil {
+			blog.ErrorJSON("[validUpdateAccount] invalid endpoint, option: %s, rid: %s", option, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCloudValidAccountParamFail, common.BKCloudEndpoint)
+		}
+		if rawErr := metadata.ValidateInventoryEndpoint(endpoint); rawErr.ErrCode != 0 {
+			return rawErr.ToCCError(kit.CCError)
+		}
+	}
+
+	if option.Exists(common.BKCloudFieldMapping) {
+		fieldMapping := make(map[string]string)
+		mappingJs, err := json.Marshal(option[common.BKCloudFieldMapping])
+		if err == nil {
+			err = json.Unmarshal(mappingJs, &fieldMapping)
+		}
+		if err != nil {
+			blog.ErrorJSON("[validUpdateAccount] invalid field mapping, option: %s, rid: %s", option, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCloudValidAccountParamFail, common.BKCloudFieldMapping)
+		}
+		if rawErr := metadata.ValidateInventoryFieldMapping(fieldMapping); rawErr.ErrCode != 0 {
+			return rawErr.ToCCError(kit.CCError)
+		}
+	}
+
 	// account name unique check
 	if option.Exists(common.BKCloudAccountName) {
 		cloudAccountName, err := option.String(common.BKCloudAccountName)
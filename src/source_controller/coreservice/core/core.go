@@ -126,6 +126,12 @@ type InstanceOperation interface {
 	DeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount,
 		error)
+	ListRecycleBin(kit *rest.Kit, objID string, opt *metadata.ListRecycleBinOption) (*metadata.ListRecycleBinResult,
+		error)
+	PreviewRestoreRecycleBin(kit *rest.Kit, objID string, opt *metadata.RestoreRecycleBinOption) (
+		*metadata.RestoreRecycleBinResult, error)
+	RestoreRecycleBin(kit *rest.Kit, objID string, opt *metadata.RestoreRecycleBinOption) (
+		*metadata.RestoreRecycleBinResult, error)
 }
 
 // KubeOperation crud operations on kube data.
@@ -236,6 +242,12 @@ type AssociationOperation interface {
 type AuditOperation interface {
 	CreateAuditLog(kit *rest.Kit, logs ...metadata.AuditLog) error
 	SearchAuditLog(kit *rest.Kit, param metadata.QueryCondition) ([]metadata.AuditLog, uint64, error)
+	ArchiveExpiredAuditLog(kit *rest.Kit) error
+	SealAuditLogChain(kit *rest.Kit) error
+	CheckpointAuditLogChain(kit *rest.Kit) error
+	ExportAuditLog(kit *rest.Kit) error
+	SearchAuditLogArchive(kit *rest.Kit, opt *metadata.SearchAuditLogArchiveOption) (
+		*metadata.SearchAuditLogArchiveResult, errors.CCErrorCoder)
 }
 
 // StatisticOperation TODO
@@ -404,6 +416,11 @@ type HostApplyRuleOperation interface {
 		metadata.MultipleHostApplyResult, errors.CCErrorCoder)
 	SearchRuleRelatedServiceTemplates(kit *rest.Kit, option metadata.RuleRelatedServiceTemplateOption) (
 		[]metadata.SrvTemplate, errors.CCErrorCoder)
+	SaveHostApplyDrift(kit *rest.Kit, bizID int64, runID string, drifts []metadata.HostApplyDrift) errors.CCErrorCoder
+	FinishHostApplyDriftRun(kit *rest.Kit, bizID int64,
+		option *metadata.FinishHostApplyDriftRunOption) errors.CCErrorCoder
+	ListHostApplyDrift(kit *rest.Kit, bizID int64, option *metadata.ListHostApplyDriftOption) (
+		*metadata.MultipleHostApplyDriftResult, errors.CCErrorCoder)
 }
 
 // CloudOperation TODO
@@ -425,6 +442,11 @@ type CloudOperation interface {
 	SearchSyncHistory(kit *rest.Kit, option *metadata.SearchSyncHistoryOption) (*metadata.MultipleSyncHistory,
 		errors.CCErrorCoder)
 	DeleteDestroyedHostRelated(kit *rest.Kit, option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder
+	CreateSyncChangeSet(kit *rest.Kit, changeSet *metadata.CloudSyncChangeSet) (*metadata.CloudSyncChangeSet,
+		errors.CCErrorCoder)
+	SearchSyncChangeSet(kit *rest.Kit, option *metadata.SearchSyncChangeSetOption) (*metadata.MultipleSyncChangeSet,
+		errors.CCErrorCoder)
+	UpdateSyncChangeSet(kit *rest.Kit, changeSetID int64, option mapstr.MapStr) errors.CCErrorCoder
 }
 
 // SystemOperation TODO
@@ -439,6 +461,29 @@ type SystemOperation interface {
 type AuthOperation interface {
 	SearchAuthResource(kit *rest.Kit, param metadata.PullResourceParam) (int64, []map[string]interface{},
 		errors.CCErrorCoder)
+
+	CreateRBACRole(kit *rest.Kit, role *metadata.RBACRole) (int64, errors.CCErrorCoder)
+	UpdateRBACRole(kit *rest.Kit, id int64, role *metadata.RBACRole) errors.CCErrorCoder
+	DeleteRBACRole(kit *rest.Kit, id int64) errors.CCErrorCoder
+	ListRBACRoles(kit *rest.Kit, opt *metadata.ListRBACRoleOption) (*metadata.ListRBACRoleResult,
+		errors.CCErrorCoder)
+	CreateRBACRoleBinding(kit *rest.Kit, binding *metadata.RBACRoleBinding) (int64, errors.CCErrorCoder)
+	UpdateRBACRoleBinding(kit *rest.Kit, id int64, binding *metadata.RBACRoleBinding) errors.CCErrorCoder
+	DeleteRBACRoleBinding(kit *rest.Kit, id int64) errors.CCErrorCoder
+	ListRBACRoleBindings(kit *rest.Kit, opt *metadata.ListRBACRoleBindingOption) (
+		*metadata.ListRBACRoleBindingResult, errors.CCErrorCoder)
+	CreateRBACUserGroup(kit *rest.Kit, group *metadata.RBACUserGroup) (int64, errors.CCErrorCoder)
+	UpdateRBACUserGroup(kit *rest.Kit, id int64, group *metadata.RBACUserGroup) errors.CCErrorCoder
+	DeleteRBACUserGroup(kit *rest.Kit, id int64) errors.CCErrorCoder
+	ListRBACUserGroups(kit *rest.Kit, opt *metadata.ListRBACUserGroupOption) (*metadata.ListRBACUserGroupResult,
+		errors.CCErrorCoder)
+
+	CreateAPIToken(kit *rest.Kit, token *metadata.APIToken) (int64, errors.CCErrorCoder)
+	DeleteAPIToken(kit *rest.Kit, id int64) errors.CCErrorCoder
+	ListAPITokens(kit *rest.Kit, opt *metadata.ListAPITokenOption) (*metadata.ListAPITokenResult,
+		errors.CCErrorCoder)
+	FindAPITokenByHash(kit *rest.Kit, hash string) (*metadata.APIToken, errors.CCErrorCoder)
+	UpdateAPITokenLastUsedTime(kit *rest.Kit, id int64) errors.CCErrorCoder
 }
 
 // CommonOperation TODO
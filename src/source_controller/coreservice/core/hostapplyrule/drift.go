@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// SaveHostApplyDrift save a batch of the drifts detected by the drift check run, they are not reported until the run
+// is finished successfully
+func (p *hostApplyRule) SaveHostApplyDrift(kit *rest.Kit, bizID int64, runID string,
+	drifts []metadata.HostApplyDrift) errors.CCErrorCoder {
+
+	if len(drifts) == 0 {
+		return nil
+	}
+
+	for index := range drifts {
+		drifts[index].BizID = bizID
+		drifts[index].RunID = runID
+		drifts[index].SupplierAccount = kit.SupplierAccount
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyDrift).Insert(kit.Ctx, drifts); err != nil {
+		blog.Errorf("insert host apply drifts failed, biz: %d, run: %s, count: %d, err: %v, rid: %s", bizID, runID,
+			len(drifts), err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return nil
+}
+
+// FinishHostApplyDriftRun finish the drift check run. if the run succeeded, it becomes the active run whose drifts
+// are reported, and the drifts of the previous runs are removed. otherwise the error is recorded, the drifts of the
+// run are removed and the drifts of the active run are still reported.
+func (p *hostApplyRule) FinishHostApplyDriftRun(kit *rest.Kit, bizID int64,
+	option *metadata.FinishHostApplyDriftRunOption) errors.CCErrorCoder {
+
+	runFilter := map[string]interface{}{
+		common.BKAppIDField:      bizID,
+		common.BkSupplierAccount: kit.SupplierAccount,
+	}
+
+	now := time.Now()
+	runData := map[string]interface{}{
+		"last_run_time": now,
+		"last_error":    option.Error,
+	}
+	if len(option.Error) == 0 {
+		runData["run_id"] = option.RunID
+		runData["finish_time"] = now
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyDriftRun).Upsert(kit.Ctx, runFilter,
+		runData); err != nil {
+		blog.Errorf("save host apply drift run failed, biz: %d, data: %v, err: %v, rid: %s", bizID, runData, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	// the drifts of the inactive runs are not reported, so the run is finished even if they are not removed, they
+	// will be removed when the next run is finished
+	driftFilter := map[string]interface{}{
+		common.BKAppIDField:      bizID,
+		common.BkSupplierAccount: kit.SupplierAccount,
+		"run_id":                 map[string]interface{}{common.BKDBNE: option.RunID},
+	}
+	if len(option.Error) != 0 {
+		driftFilter["run_id"] = option.RunID
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyDrift).Delete(kit.Ctx, driftFilter); err != nil {
+		blog.Errorf("delete inactive host apply drifts failed, filter: %v, err: %v, rid: %s", driftFilter, err,
+			kit.Rid)
+	}
+	return nil
+}
+
+// getHostApplyDriftRun get the drift check run status of the business, returns nil if the check has never run
+func (p *hostApplyRule) getHostApplyDriftRun(kit *rest.Kit, bizID int64) (*metadata.HostApplyDriftRun,
+	errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		common.BKAppIDField:      bizID,
+		common.BkSupplierAccount: kit.SupplierAccount,
+	}
+
+	runs := make([]metadata.HostApplyDriftRun, 0)
+	if err := mongodb.Client().Table(common.BKTableNameHostApplyDriftRun).Find(filter).All(kit.Ctx,
+		&runs); err != nil {
+		blog.Errorf("get host apply drift run failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
+
+// ListHostApplyDrift list the host apply drift report of the business
+func (p *hostApplyRule) ListHostApplyDrift(kit *rest.Kit, bizID int64, option *metadata.ListHostApplyDriftOption) (
+	*metadata.MultipleHostApplyDriftResult, errors.CCErrorCoder) {
+
+	run, ccErr := p.getHostApplyDriftRun(kit, bizID)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	// only the drifts of the active run are reported, the drifts saved before the runs are recorded have no run id
+	filter := map[string]interface{}{
+		common.BKAppIDField:      bizID,
+		common.BkSupplierAccount: kit.SupplierAccount,
+		"run_id":                 map[string]interface{}{common.BKDBExists: false},
+	}
+	if run != nil && len(run.RunID) != 0 {
+		filter["run_id"] = run.RunID
+	}
+	if len(option.HostIDs) != 0 {
+		filter[common.BKHostIDField] = map[string]interface{}{common.BKDBIN: option.HostIDs}
+	}
+	if len(option.ModuleIDs) != 0 {
+		filter["bk_module_ids"] = map[string]interface{}{common.BKDBIN: option.ModuleIDs}
+	}
+	if len(option.AttributeIDs) != 0 {
+		filter[common.BKAttributeIDField] = map[string]interface{}{common.BKDBIN: option.AttributeIDs}
+	}
+	if option.Conflict != nil {
+		filter["conflict"] = *option.Conflict
+	}
+	if option.Remediated != nil {
+		filter["remediated"] = *option.Remediated
+	}
+
+	if option.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameHostApplyDrift).Find(filter).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count host apply drifts failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return &metadata.MultipleHostApplyDriftResult{Count: int64(count), Run: run}, nil
+	}
+
+	sort := option.Page.Sort
+	if len(sort) == 0 {
+		sort = common.BKHostIDField
+	}
+
+	drifts := make([]metadata.HostApplyDrift, 0)
+	err := mongodb.Client().Table(common.BKTableNameHostApplyDrift).Find(filter).Start(uint64(option.Page.Start)).
+		Limit(uint64(option.Page.Limit)).Sort(sort).All(kit.Ctx, &drifts)
+	if err != nil {
+		blog.Errorf("list host apply drifts failed, filter: %v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleHostApplyDriftResult{Info: drifts, Run: run}, nil
+}
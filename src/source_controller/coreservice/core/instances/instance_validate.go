@@ -207,6 +207,11 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
 	}
 
+	if err := m.convertNetworkToStandardFormat(instanceData, valid.propertySlice); err != nil {
+		blog.Errorf("convert ip or cidr value to standard format failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
 	switch objID {
 	case common.BKInnerObjIDModule:
 		// module instance's name must coincide with template
@@ -372,6 +377,11 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, u
 		return err
 	}
 
+	if err := m.convertNetworkToStandardFormat(updateData, valid.propertySlice); err != nil {
+		blog.Errorf("convert ip or cidr value to standard format failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
 	skip, err := hooks.IsSkipValidateHook(kit, objID, instanceData)
 	if err != nil {
 		blog.Errorf("check is skip validate %s hook failed, err: %v, rid: %s", objID, err, kit.Rid)
@@ -554,6 +564,41 @@ func (m *instanceManager) changeStringToTime(valData mapstr.MapStr, properties [
 	return nil
 }
 
+// convertNetworkToStandardFormat convert the ip and cidr field values to the standard format,
+// so that they can be queried by the network filter operators like ip_in_cidr and cidr_contains
+func (m *instanceManager) convertNetworkToStandardFormat(valData mapstr.MapStr,
+	properties []metadata.Attribute) error {
+
+	for _, field := range properties {
+		var convert func(string) (string, error)
+		switch field.PropertyType {
+		case common.FieldTypeIP:
+			convert = common.ConvertIPToStandardFormat
+		case common.FieldTypeCIDR:
+			convert = common.ConvertCIDRToStandardFormat
+		default:
+			continue
+		}
+
+		val, ok := valData[field.PropertyID]
+		if !ok || val == nil || val == "" {
+			continue
+		}
+
+		valStr, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("%s value %v is not string type", field.PropertyID, val)
+		}
+
+		value, err := convert(valStr)
+		if err != nil {
+			return err
+		}
+		valData[field.PropertyID] = value
+	}
+	return nil
+}
+
 // getValidatorsFromInstances get validators from instances, returns the mapping of instance index to its validator
 func (m *instanceManager) getValidatorsFromInstances(kit *rest.Kit, objID string, instanceData []mapstr.MapStr,
 	validTye string) ([]*validator, error) {
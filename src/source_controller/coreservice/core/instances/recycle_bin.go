@@ -0,0 +1,797 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/mongodb/instancemapping"
+)
+
+// instArchive is the archived deleted instance in the del-archive collection
+type instArchive struct {
+	Oid      string        `bson:"oid"`
+	Time     time.Time     `bson:"time"`
+	Operator string        `bson:"operator"`
+	Detail   mapstr.MapStr `bson:"detail"`
+}
+
+// instAsstArchive is the archived deleted instance association in the del-archive collection
+type instAsstArchive struct {
+	Oid    string            `bson:"oid"`
+	Detail metadata.InstAsst `bson:"detail"`
+}
+
+// hostRelArchive is the archived deleted host module relation in the del-archive collection
+type hostRelArchive struct {
+	Oid    string              `bson:"oid"`
+	Detail metadata.ModuleHost `bson:"detail"`
+}
+
+// validateRecycleBinObject only the deleted hosts and common model instances can be restored, the mainline
+// instances can not be restored because their topology may have been changed
+func (m *instanceManager) validateRecycleBinObject(kit *rest.Kit, objID string) error {
+	if objID == common.BKInnerObjIDHost {
+		return nil
+	}
+
+	if !metadata.IsCommon(objID) {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+	}
+
+	isMainline, err := m.isMainlineObject(kit, objID)
+	if err != nil {
+		return err
+	}
+
+	if isMainline {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKObjIDField)
+	}
+	return nil
+}
+
+// ListRecycleBin list the archived deleted instances of the model
+func (m *instanceManager) ListRecycleBin(kit *rest.Kit, objID string, opt *metadata.ListRecycleBinOption) (
+	*metadata.ListRecycleBinResult, error) {
+
+	if err := m.validateRecycleBinObject(kit, objID); err != nil {
+		return nil, err
+	}
+
+	cond := mapstr.MapStr{"coll": common.GetInstTableName(objID, kit.SupplierAccount)}
+	if opt.Operator != "" {
+		cond["operator"] = opt.Operator
+	}
+
+	if len(opt.InstIDs) > 0 {
+		cond["detail."+metadata.GetInstIDFieldByObjID(objID)] = mapstr.MapStr{common.BKDBIN: opt.InstIDs}
+	}
+
+	start, end, err := opt.Time.ParseTime()
+	if err != nil {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "time")
+	}
+	timeCond := mapstr.MapStr{}
+	if !start.IsZero() {
+		timeCond[common.BKDBGTE] = start
+	}
+	if !end.IsZero() {
+		timeCond[common.BKDBLTE] = end
+	}
+	if len(timeCond) > 0 {
+		cond["time"] = timeCond
+	}
+
+	if opt.BizID > 0 {
+		if objID == common.BKInnerObjIDHost {
+			// deleted hosts are filtered by the business of their archived host module relations
+			relCond := mapstr.MapStr{
+				"coll":                          common.BKTableNameModuleHostConfig,
+				"detail." + common.BKAppIDField: opt.BizID,
+			}
+			hostIDs, err := mongodb.Client().Table(common.BKTableNameDelArchive).Distinct(kit.Ctx,
+				"detail."+common.BKHostIDField, relCond)
+			if err != nil {
+				blog.Errorf("get archived host ids failed, err: %v, cond: %+v, rid: %s", err, relCond, kit.Rid)
+				return nil, err
+			}
+			cond["detail."+common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: hostIDs}
+		} else {
+			cond["detail."+common.BKAppIDField] = opt.BizID
+		}
+	}
+
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(common.BKTableNameDelArchive).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count recycle bin records failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return nil, err
+		}
+		return &metadata.ListRecycleBinResult{Count: count}, nil
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = "-time"
+	}
+
+	archives := make([]instArchive, 0)
+	err = mongodb.Client().Table(common.BKTableNameDelArchive).Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(kit.Ctx, &archives)
+	if err != nil {
+		blog.Errorf("list recycle bin records failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	records, err := m.convertRecycleBinRecords(kit, objID, archives)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.ListRecycleBinResult{Info: records}, nil
+}
+
+func (m *instanceManager) convertRecycleBinRecords(kit *rest.Kit, objID string, archives []instArchive) (
+	[]metadata.RecycleBinRecord, error) {
+
+	idField := metadata.GetInstIDFieldByObjID(objID)
+	records := make([]metadata.RecycleBinRecord, len(archives))
+	hostIDs := make([]int64, 0)
+	for i, archive := range archives {
+		instID, err := util.GetInt64ByInterface(archive.Detail[idField])
+		if err != nil {
+			blog.Errorf("parse archived %s id failed, err: %v, data: %+v, rid: %s", objID, err, archive, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParseDBFailed, idField)
+		}
+
+		records[i] = metadata.RecycleBinRecord{
+			ID:       archive.Oid,
+			ObjID:    objID,
+			InstID:   instID,
+			Operator: archive.Operator,
+			Time:     archive.Time,
+			Detail:   archive.Detail,
+		}
+
+		if objID == common.BKInnerObjIDHost {
+			hostIDs = append(hostIDs, instID)
+			continue
+		}
+
+		if bizID, err := util.GetInt64ByInterface(archive.Detail[common.BKAppIDField]); err == nil {
+			records[i].BizID = bizID
+		}
+	}
+
+	if len(hostIDs) == 0 {
+		return records, nil
+	}
+
+	// fill the business of the deleted hosts by their archived host module relations
+	relArchives, err := getHostRelArchives(kit, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	hostBizMap := make(map[int64]int64)
+	for _, rel := range relArchives {
+		if _, exists := hostBizMap[rel.Detail.HostID]; !exists {
+			hostBizMap[rel.Detail.HostID] = rel.Detail.AppID
+		}
+	}
+
+	for i := range records {
+		records[i].BizID = hostBizMap[records[i].InstID]
+	}
+	return records, nil
+}
+
+func getHostRelArchives(kit *rest.Kit, hostIDs []int64) ([]hostRelArchive, error) {
+	cond := mapstr.MapStr{
+		"coll":                           common.BKTableNameModuleHostConfig,
+		"detail." + common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs},
+	}
+
+	relArchives := make([]hostRelArchive, 0)
+	err := mongodb.Client().Table(common.BKTableNameDelArchive).Find(cond).Sort("-time").All(kit.Ctx, &relArchives)
+	if err != nil {
+		blog.Errorf("get archived host relations failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+	return relArchives, nil
+}
+
+// restoreContext is the context of restoring the archived deleted instances
+type restoreContext struct {
+	objID     string
+	table     string
+	idField   string
+	instOids  []string
+	asstOids  []string
+	relOids   []string
+	result    *metadata.RestoreRecycleBinResult
+	instIDMap map[int64]struct{}
+}
+
+// PreviewRestoreRecycleBin preview the result of restoring the archived deleted instances
+func (m *instanceManager) PreviewRestoreRecycleBin(kit *rest.Kit, objID string,
+	opt *metadata.RestoreRecycleBinOption) (*metadata.RestoreRecycleBinResult, error) {
+
+	ctx, err := m.prepareRestore(kit, objID, opt)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.result, nil
+}
+
+// RestoreRecycleBin restore the archived deleted instances with their instance associations and host module
+// relations that still resolve, the instances are restored only when there is no conflict
+func (m *instanceManager) RestoreRecycleBin(kit *rest.Kit, objID string, opt *metadata.RestoreRecycleBinOption) (
+	*metadata.RestoreRecycleBinResult, error) {
+
+	ctx, err := m.prepareRestore(kit, objID, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ctx.result.Conflicts) > 0 {
+		conflict := ctx.result.Conflicts[0]
+		blog.Errorf("restore %s instances conflicts: %+v, rid: %s", objID, ctx.result.Conflicts, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDuplicateItem,
+			fmt.Sprintf("%s %d(%s)", objID, conflict.InstID, conflict.Reason))
+	}
+
+	if err = m.saveRestoreData(kit, ctx); err != nil {
+		return nil, err
+	}
+
+	// remove the restored records from the recycle bin
+	for coll, oids := range map[string][]string{
+		ctx.table: ctx.instOids,
+		common.GetObjectInstAsstTableName(objID, kit.SupplierAccount): ctx.asstOids,
+		common.BKTableNameModuleHostConfig:                            ctx.relOids,
+	} {
+		if len(oids) == 0 {
+			continue
+		}
+
+		cond := mapstr.MapStr{"coll": coll, "oid": mapstr.MapStr{common.BKDBIN: oids}}
+		if err = mongodb.Client().Table(common.BKTableNameDelArchive).Delete(kit.Ctx, cond); err != nil {
+			blog.Errorf("delete restored archives failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return nil, err
+		}
+	}
+
+	return ctx.result, nil
+}
+
+func (m *instanceManager) prepareRestore(kit *rest.Kit, objID string, opt *metadata.RestoreRecycleBinOption) (
+	*restoreContext, error) {
+
+	if err := m.validateRecycleBinObject(kit, objID); err != nil {
+		return nil, err
+	}
+
+	ctx := &restoreContext{
+		objID:   objID,
+		table:   common.GetInstTableName(objID, kit.SupplierAccount),
+		idField: metadata.GetInstIDFieldByObjID(objID),
+		result: &metadata.RestoreRecycleBinResult{
+			Instances:     make([]mapstr.MapStr, 0),
+			Associations:  make([]metadata.InstAsst, 0),
+			HostRelations: make([]metadata.ModuleHost, 0),
+			Conflicts:     make([]metadata.RestoreConflict, 0),
+		},
+		instIDMap: make(map[int64]struct{}),
+	}
+
+	oids := util.StrArrayUnique(opt.IDs)
+	cond := mapstr.MapStr{"coll": ctx.table, "oid": mapstr.MapStr{common.BKDBIN: oids}}
+	archives := make([]instArchive, 0)
+	if err := mongodb.Client().Table(common.BKTableNameDelArchive).Find(cond).All(kit.Ctx, &archives); err != nil {
+		blog.Errorf("get archived %s instances failed, err: %v, cond: %+v, rid: %s", objID, err, cond, kit.Rid)
+		return nil, err
+	}
+
+	if len(archives) != len(oids) {
+		blog.Errorf("some archived %s instances are not found, ids: %+v, rid: %s", objID, oids, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommNotFound, "ids")
+	}
+
+	instIDs := make([]int64, 0)
+	instOidMap := make(map[int64]string)
+	for _, archive := range archives {
+		instID, err := util.GetInt64ByInterface(archive.Detail[ctx.idField])
+		if err != nil {
+			blog.Errorf("parse archived %s id failed, err: %v, data: %+v, rid: %s", objID, err, archive, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParseDBFailed, ctx.idField)
+		}
+
+		ctx.instOids = append(ctx.instOids, archive.Oid)
+		if _, exists := instOidMap[instID]; exists {
+			ctx.addConflict(archive.Oid, instID, metadata.RestoreConflictDuplicateID, 0)
+			continue
+		}
+
+		instOidMap[instID] = archive.Oid
+		instIDs = append(instIDs, instID)
+		ctx.instIDMap[instID] = struct{}{}
+		ctx.result.Instances = append(ctx.result.Instances, archive.Detail)
+	}
+
+	if err := m.checkRestoreIDConflict(kit, ctx, instIDs, instOidMap); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkRestoreUniqueConflict(kit, ctx, instOidMap); err != nil {
+		return nil, err
+	}
+
+	if err := m.getRestoreAssociations(kit, ctx, instIDs); err != nil {
+		return nil, err
+	}
+
+	if objID == common.BKInnerObjIDHost {
+		if err := m.getRestoreHostRelations(kit, ctx, instIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+func (c *restoreContext) addConflict(oid string, instID int64, reason metadata.RestoreConflictReason,
+	uniqueID uint64) {
+
+	c.result.Conflicts = append(c.result.Conflicts, metadata.RestoreConflict{
+		ID:       oid,
+		InstID:   instID,
+		Reason:   reason,
+		UniqueID: uniqueID,
+	})
+}
+
+// checkRestoreIDConflict check if the ids of the restored instances are used by existing instances
+func (m *instanceManager) checkRestoreIDConflict(kit *rest.Kit, ctx *restoreContext, instIDs []int64,
+	instOidMap map[int64]string) error {
+
+	cond := mapstr.MapStr{ctx.idField: mapstr.MapStr{common.BKDBIN: instIDs}}
+	existIDs, err := mongodb.Client().Table(ctx.table).Distinct(kit.Ctx, ctx.idField, cond)
+	if err != nil {
+		blog.Errorf("get exist %s ids failed, err: %v, cond: %+v, rid: %s", ctx.objID, err, cond, kit.Rid)
+		return err
+	}
+
+	for _, rawID := range existIDs {
+		id, err := util.GetInt64ByInterface(rawID)
+		if err != nil {
+			blog.Errorf("parse %s id %v failed, err: %v, rid: %s", ctx.objID, rawID, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParseDBFailed, ctx.idField)
+		}
+		ctx.addConflict(instOidMap[id], id, metadata.RestoreConflictIDExists, 0)
+	}
+	return nil
+}
+
+// checkRestoreUniqueConflict check if the restored instances violate the unique rules of the model, both with the
+// existing instances and among the restored instances
+func (m *instanceManager) checkRestoreUniqueConflict(kit *rest.Kit, ctx *restoreContext,
+	instOidMap map[int64]string) error {
+
+	uniques, err := m.dependent.SearchUnique(kit, ctx.objID)
+	if err != nil {
+		return err
+	}
+
+	if len(uniques) == 0 {
+		return nil
+	}
+
+	attrIDs := make([]uint64, 0)
+	for _, unique := range uniques {
+		for _, key := range unique.Keys {
+			attrIDs = append(attrIDs, key.ID)
+		}
+	}
+
+	attrCond := mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: attrIDs}}
+	attrs := make([]metadata.Attribute, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjAttDes).Find(attrCond).Fields(common.BKFieldID,
+		common.BKPropertyIDField).All(kit.Ctx, &attrs)
+	if err != nil {
+		blog.Errorf("get unique attributes failed, err: %v, cond: %+v, rid: %s", err, attrCond, kit.Rid)
+		return err
+	}
+
+	attrMap := make(map[uint64]string)
+	for _, attr := range attrs {
+		attrMap[uint64(attr.ID)] = attr.PropertyID
+	}
+
+	for _, unique := range uniques {
+		valueMap := make(map[string]int64)
+		for _, inst := range ctx.result.Instances {
+			instID, _ := util.GetInt64ByInterface(inst[ctx.idField])
+			cond, key, ok := genRestoreUniqueCond(ctx.objID, unique, attrMap, inst)
+			if !ok {
+				continue
+			}
+
+			// the restored instances conflict with each other
+			if _, exists := valueMap[key]; exists {
+				ctx.addConflict(instOidMap[instID], instID, metadata.RestoreConflictUnique, unique.ID)
+				continue
+			}
+			valueMap[key] = instID
+
+			cnt, err := mongodb.Client().Table(ctx.table).Find(cond).Count(kit.Ctx)
+			if err != nil {
+				blog.Errorf("count %s by unique cond failed, err: %v, cond: %+v, rid: %s", ctx.objID, err, cond,
+					kit.Rid)
+				return err
+			}
+
+			if cnt > 0 {
+				ctx.addConflict(instOidMap[instID], instID, metadata.RestoreConflictUnique, unique.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// genRestoreUniqueCond generate the condition to find the instances that has the same unique values with the
+// restored instance, and the key of the unique values. returns false if the instance lacks some of the unique fields
+func genRestoreUniqueCond(objID string, unique metadata.ObjectUnique, attrMap map[uint64]string,
+	inst mapstr.MapStr) (mapstr.MapStr, string, bool) {
+
+	cond := mapstr.MapStr{}
+	values := make([]string, 0)
+	for _, key := range unique.Keys {
+		field, exists := attrMap[key.ID]
+		if !exists {
+			return nil, "", false
+		}
+
+		val, exists := inst[field]
+		if !exists || val == nil {
+			return nil, "", false
+		}
+
+		// the host special fields are stored as array, they are unique if any of the elements are the same
+		if arr, ok := val.([]interface{}); ok && objID == common.BKInnerObjIDHost && hostSpecialFieldMap[field] {
+			cond[field] = mapstr.MapStr{common.BKDBIN: arr}
+		} else {
+			cond[field] = val
+		}
+		values = append(values, fmt.Sprint(val))
+	}
+
+	return cond, strings.Join(values, "|"), true
+}
+
+// getRestoreAssociations get the archived instance associations of the restored instances whose model association
+// and the instances on the other side still exist
+func (m *instanceManager) getRestoreAssociations(kit *rest.Kit, ctx *restoreContext, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	asstTable := common.GetObjectInstAsstTableName(ctx.objID, kit.SupplierAccount)
+	cond := mapstr.MapStr{
+		"coll": asstTable,
+		common.BKDBOR: []mapstr.MapStr{
+			{
+				"detail." + common.BKObjIDField:  ctx.objID,
+				"detail." + common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+			},
+			{
+				"detail." + common.BKAsstObjIDField:  ctx.objID,
+				"detail." + common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+			},
+		},
+	}
+
+	archives := make([]instAsstArchive, 0)
+	if err := mongodb.Client().Table(common.BKTableNameDelArchive).Find(cond).All(kit.Ctx, &archives); err != nil {
+		blog.Errorf("get archived instance associations failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return err
+	}
+
+	if len(archives) == 0 {
+		return nil
+	}
+
+	asstIDs := make([]int64, 0)
+	objAsstIDs := make([]string, 0)
+	// otherInstMap is the object id to the ids of the instances on the other side of the associations
+	otherInstMap := make(map[string][]int64)
+	for _, archive := range archives {
+		asst := archive.Detail
+		asstIDs = append(asstIDs, asst.ID)
+		objAsstIDs = append(objAsstIDs, asst.ObjectAsstID)
+		otherInstMap[asst.ObjectID] = append(otherInstMap[asst.ObjectID], asst.InstID)
+		otherInstMap[asst.AsstObjectID] = append(otherInstMap[asst.AsstObjectID], asst.AsstInstID)
+	}
+
+	existAsstIDs, err := mongodb.Client().Table(asstTable).Distinct(kit.Ctx, common.BKFieldID,
+		mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: asstIDs}})
+	if err != nil {
+		blog.Errorf("get exist instance association ids failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	existAsstIDMap := make(map[int64]struct{})
+	for _, rawID := range existAsstIDs {
+		id, err := util.GetInt64ByInterface(rawID)
+		if err != nil {
+			return kit.CCError.CCErrorf(common.CCErrCommParseDBFailed, common.BKFieldID)
+		}
+		existAsstIDMap[id] = struct{}{}
+	}
+
+	objAsstCond := mapstr.MapStr{
+		common.AssociationObjAsstIDField: mapstr.MapStr{common.BKDBIN: util.StrArrayUnique(objAsstIDs)},
+	}
+	existObjAsstIDs, err := mongodb.Client().Table(common.BKTableNameObjAsst).Distinct(kit.Ctx,
+		common.AssociationObjAsstIDField, objAsstCond)
+	if err != nil {
+		blog.Errorf("get exist model associations failed, err: %v, cond: %+v, rid: %s", err, objAsstCond, kit.Rid)
+		return err
+	}
+	existObjAsstIDMap := make(map[string]struct{})
+	for _, id := range existObjAsstIDs {
+		existObjAsstIDMap[util.GetStrByInterface(id)] = struct{}{}
+	}
+
+	existInstMap, err := getExistInstances(kit, otherInstMap)
+	if err != nil {
+		return err
+	}
+
+	isInstExists := func(objID string, instID int64) bool {
+		if _, exists := existInstMap[objID][instID]; exists {
+			return true
+		}
+		_, exists := ctx.instIDMap[instID]
+		return objID == ctx.objID && exists
+	}
+
+	handledAsstIDs := make(map[int64]struct{})
+	for _, archive := range archives {
+		asst := archive.Detail
+		if _, exists := handledAsstIDs[asst.ID]; exists {
+			continue
+		}
+		handledAsstIDs[asst.ID] = struct{}{}
+
+		if _, exists := existAsstIDMap[asst.ID]; exists {
+			continue
+		}
+
+		if _, exists := existObjAsstIDMap[asst.ObjectAsstID]; !exists {
+			continue
+		}
+
+		if !isInstExists(asst.ObjectID, asst.InstID) || !isInstExists(asst.AsstObjectID, asst.AsstInstID) {
+			continue
+		}
+
+		ctx.asstOids = append(ctx.asstOids, archive.Oid)
+		ctx.result.Associations = append(ctx.result.Associations, asst)
+	}
+
+	return nil
+}
+
+// getExistInstances returns the object id to the existing instance ids map
+func getExistInstances(kit *rest.Kit, objInstMap map[string][]int64) (map[string]map[int64]struct{}, error) {
+	existInstMap := make(map[string]map[int64]struct{})
+	for objID, instIDs := range objInstMap {
+		idField := metadata.GetInstIDFieldByObjID(objID)
+		cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(instIDs)}}
+		table := common.GetInstTableName(objID, kit.SupplierAccount)
+		existIDs, err := mongodb.Client().Table(table).Distinct(kit.Ctx, idField, cond)
+		if err != nil {
+			blog.Errorf("get exist %s ids failed, err: %v, cond: %+v, rid: %s", objID, err, cond, kit.Rid)
+			return nil, err
+		}
+
+		existInstMap[objID] = make(map[int64]struct{})
+		for _, rawID := range existIDs {
+			id, err := util.GetInt64ByInterface(rawID)
+			if err != nil {
+				return nil, kit.CCError.CCErrorf(common.CCErrCommParseDBFailed, idField)
+			}
+			existInstMap[objID][id] = struct{}{}
+		}
+	}
+	return existInstMap, nil
+}
+
+// getRestoreHostRelations get the archived host module relations of the restored hosts whose modules still exist,
+// the hosts that have no such relation are restored to the idle module of the resource pool
+func (m *instanceManager) getRestoreHostRelations(kit *rest.Kit, ctx *restoreContext, hostIDs []int64) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	relArchives, err := getHostRelArchives(kit, hostIDs)
+	if err != nil {
+		return err
+	}
+
+	moduleIDs := make([]int64, 0)
+	for _, rel := range relArchives {
+		moduleIDs = append(moduleIDs, rel.Detail.ModuleID)
+	}
+
+	modules := make([]metadata.ModuleInst, 0)
+	if len(moduleIDs) > 0 {
+		moduleCond := mapstr.MapStr{common.BKModuleIDField: mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(moduleIDs)}}
+		err = mongodb.Client().Table(common.BKTableNameBaseModule).Find(moduleCond).Fields(common.BKModuleIDField,
+			common.BKSetIDField, common.BKAppIDField).All(kit.Ctx, &modules)
+		if err != nil {
+			blog.Errorf("get modules failed, err: %v, cond: %+v, rid: %s", err, moduleCond, kit.Rid)
+			return err
+		}
+	}
+
+	moduleMap := make(map[int64]metadata.ModuleInst)
+	for _, module := range modules {
+		moduleMap[module.ModuleID] = module
+	}
+
+	// a host can only belong to one business, the relations are sorted by time desc, so the business of the latest
+	// relation is used if the host has relations in multiple businesses
+	hostBizMap := make(map[int64]int64)
+	relKeyMap := make(map[string]struct{})
+	for _, archive := range relArchives {
+		rel := archive.Detail
+		module, exists := moduleMap[rel.ModuleID]
+		if !exists || module.SetID != rel.SetID || module.BizID != rel.AppID {
+			continue
+		}
+
+		if bizID, exists := hostBizMap[rel.HostID]; exists && bizID != rel.AppID {
+			continue
+		}
+
+		key := fmt.Sprintf("%d:%d", rel.HostID, rel.ModuleID)
+		if _, exists := relKeyMap[key]; exists {
+			continue
+		}
+		relKeyMap[key] = struct{}{}
+
+		hostBizMap[rel.HostID] = rel.AppID
+		rel.OwnerID = kit.SupplierAccount
+		ctx.relOids = append(ctx.relOids, archive.Oid)
+		ctx.result.HostRelations = append(ctx.result.HostRelations, rel)
+	}
+
+	if len(hostBizMap) == len(hostIDs) {
+		return nil
+	}
+
+	idleModule, err := getResourcePoolIdleModule(kit)
+	if err != nil {
+		return err
+	}
+
+	for _, hostID := range hostIDs {
+		if _, exists := hostBizMap[hostID]; exists {
+			continue
+		}
+
+		ctx.result.HostRelations = append(ctx.result.HostRelations, metadata.ModuleHost{
+			AppID:    idleModule.BizID,
+			HostID:   hostID,
+			ModuleID: idleModule.ModuleID,
+			SetID:    idleModule.SetID,
+			OwnerID:  kit.SupplierAccount,
+		})
+	}
+	return nil
+}
+
+func getResourcePoolIdleModule(kit *rest.Kit) (*metadata.ModuleInst, error) {
+	bizCond := mapstr.MapStr{common.BKDefaultField: common.DefaultAppFlag}
+	biz := new(metadata.BizInst)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(bizCond).Fields(common.BKAppIDField).One(kit.Ctx,
+		biz)
+	if err != nil {
+		blog.Errorf("get resource pool biz failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	moduleCond := mapstr.MapStr{
+		common.BKAppIDField:   biz.BizID,
+		common.BKDefaultField: common.DefaultResModuleFlag,
+	}
+	module := new(metadata.ModuleInst)
+	err = mongodb.Client().Table(common.BKTableNameBaseModule).Find(moduleCond).Fields(common.BKModuleIDField,
+		common.BKSetIDField, common.BKAppIDField).One(kit.Ctx, module)
+	if err != nil {
+		blog.Errorf("get resource pool idle module failed, err: %v, cond: %+v, rid: %s", err, moduleCond, kit.Rid)
+		return nil, err
+	}
+	return module, nil
+}
+
+// saveRestoreData save the restored instances, instance associations and host module relations
+func (m *instanceManager) saveRestoreData(kit *rest.Kit, ctx *restoreContext) error {
+	if len(ctx.result.Instances) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	mappings := make([]mapstr.MapStr, 0)
+	for _, inst := range ctx.result.Instances {
+		inst[common.LastTimeField] = now
+		inst[common.BKUpdatedAt] = now
+
+		if metadata.IsCommon(ctx.objID) {
+			mappings = append(mappings, mapstr.MapStr{
+				ctx.idField:              inst[ctx.idField],
+				common.BKObjIDField:      ctx.objID,
+				common.BkSupplierAccount: kit.SupplierAccount,
+			})
+		}
+	}
+
+	if len(mappings) > 0 {
+		if err := instancemapping.Create(kit.Ctx, mappings); err != nil {
+			blog.Errorf("create restored instance mappings failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+	}
+
+	if err := mongodb.Client().Table(ctx.table).Insert(kit.Ctx, ctx.result.Instances); err != nil {
+		blog.Errorf("restore %s instances failed, err: %v, rid: %s", ctx.objID, err, kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, mongodb.GetDuplicateKey(err))
+		}
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	for _, asst := range ctx.result.Associations {
+		tables := []string{common.GetObjectInstAsstTableName(asst.ObjectID, kit.SupplierAccount)}
+		if asst.ObjectID != asst.AsstObjectID {
+			tables = append(tables, common.GetObjectInstAsstTableName(asst.AsstObjectID, kit.SupplierAccount))
+		}
+
+		for _, table := range tables {
+			if err := mongodb.Client().Table(table).Insert(kit.Ctx, asst); err != nil {
+				blog.Errorf("restore instance association %+v failed, err: %v, rid: %s", asst, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+			}
+		}
+	}
+
+	if len(ctx.result.HostRelations) > 0 {
+		err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Insert(kit.Ctx, ctx.result.HostRelations)
+		if err != nil {
+			blog.Errorf("restore host relations failed, err: %v, rid: %s", err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	return nil
+}
@@ -92,6 +92,14 @@ func FillLostFieldValue(ctx context.Context, valData mapstr.MapStr, properties [
 			}
 		case common.FieldTypeIDRule:
 			idRuleField = &properties[idx]
+		case common.FieldTypeIP:
+			if err := fillLostNetworkFieldValue(valData, field, common.ConvertIPToStandardFormat); err != nil {
+				return err
+			}
+		case common.FieldTypeCIDR:
+			if err := fillLostNetworkFieldValue(valData, field, common.ConvertCIDRToStandardFormat); err != nil {
+				return err
+			}
 		default:
 			valData[field.PropertyID] = nil
 		}
@@ -107,6 +115,31 @@ func FillLostFieldValue(ctx context.Context, valData mapstr.MapStr, properties [
 	return nil
 }
 
+func fillLostNetworkFieldValue(valData mapstr.MapStr, field metadata.Attribute,
+	convert func(string) (string, error)) error {
+
+	valData[field.PropertyID] = ""
+	if field.Default == nil {
+		return nil
+	}
+
+	defaultVal, ok := field.Default.(string)
+	if !ok {
+		return fmt.Errorf("%s default value not string, value: %v", field.PropertyType, field.Default)
+	}
+
+	if len(defaultVal) == 0 {
+		return nil
+	}
+
+	value, err := convert(defaultVal)
+	if err != nil {
+		return err
+	}
+	valData[field.PropertyID] = value
+	return nil
+}
+
 func fillLostStringFieldValue(valData mapstr.MapStr, field metadata.Attribute) error {
 	valData[field.PropertyID] = ""
 	if field.Default == nil {
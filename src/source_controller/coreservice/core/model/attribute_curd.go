@@ -137,7 +137,8 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
 			common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeTimeZone,
-			common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIDRule:
+			common.FieldTypeBool, common.FieldTypeList, common.FieldTypeIDRule, common.FieldTypeIP,
+			common.FieldTypeCIDR:
 			isMultiple := false
 			attribute.IsMultiple = &isMultiple
 		case common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeEnumQuote, common.FieldTypeEnumMulti:
@@ -498,6 +499,8 @@ var validAttrPropertyTypes = map[string]struct{}{
 	common.FieldTypeList:         {},
 	common.FieldTypeEnumQuote:    {},
 	common.FieldTypeIDRule:       {},
+	common.FieldTypeIP:           {},
+	common.FieldTypeCIDR:         {},
 }
 
 func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadata.Attribute,
@@ -656,6 +659,9 @@ func (m *modelAttribute) checkAttributeDefaultValue(kit *rest.Kit, attribute met
 	case common.FieldTypeList:
 		err = attrvalid.ValidFieldTypeList(kit, attribute.Option, attribute.Default)
 
+	case common.FieldTypeIP, common.FieldTypeCIDR:
+		err = attrvalid.ValidFieldTypeNetwork(kit, propertyType, attribute.Default)
+
 	default:
 		if propertyType == common.FieldTypeEnum || propertyType == common.FieldTypeEnumMulti ||
 			propertyType == common.FieldTypeEnumQuote {
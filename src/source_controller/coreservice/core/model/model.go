@@ -41,12 +41,15 @@ type modelManager struct {
 	*modelAttrUnique
 	language  language.CCLanguageIf
 	dependent OperationDependences
+	// fullTextSearchIndex is whether the new object instance tables need the mongodb fulltext search text index
+	fullTextSearchIndex bool
 }
 
 // New create a new model manager instance
-func New(dependent OperationDependences, language language.CCLanguageIf) core.ModelOperation {
+func New(dependent OperationDependences, language language.CCLanguageIf,
+	fullTextSearchIndex bool) core.ModelOperation {
 
-	coreMgr := &modelManager{dependent: dependent, language: language}
+	coreMgr := &modelManager{dependent: dependent, language: language, fullTextSearchIndex: fullTextSearchIndex}
 	coreMgr.modelAttribute = &modelAttribute{model: coreMgr, language: language}
 	coreMgr.modelClassification = &modelClassification{model: coreMgr}
 	coreMgr.modelAttributeGroup = &modelAttributeGroup{model: coreMgr}
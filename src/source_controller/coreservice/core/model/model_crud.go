@@ -489,6 +489,10 @@ func (m *modelManager) createObjectShardingTables(kit *rest.Kit, objID string, i
 	} else {
 		instTableIndexes = append(instTableIndexes, index.InstanceUniqueIndex()...)
 	}
+	// the same as admin server, the text index is created so that the new model can be searched at once
+	if m.fullTextSearchIndex {
+		instTableIndexes = append(instTableIndexes, index.FullTextSearchIndex())
+	}
 
 	// create object instance table.
 	err := m.createShardingTable(kit, instTableName, instTableIndexes)
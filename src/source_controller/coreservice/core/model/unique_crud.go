@@ -466,6 +466,8 @@ func getBasicDataType(propertyType string) (interface{}, error) {
 		return "", nil
 	case common.FieldTypeTimeZone:
 		return "", nil
+	case common.FieldTypeIP, common.FieldTypeCIDR:
+		return "", nil
 	case common.FieldTypeBool:
 		return false, nil
 	case common.FieldTypeFloat:
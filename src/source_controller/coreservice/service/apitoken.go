@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// CreateAPIToken create personal api token, the token is generated by the caller, only its hash is stored
+func (s *coreService) CreateAPIToken(ctx *rest.Contexts) {
+	token := new(metadata.APIToken)
+	if err := ctx.DecodeInto(token); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(token.Name) == 0 || len(token.TokenHash) == 0 || token.ExpireTime == nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "name/token_hash/expire_time"))
+		return
+	}
+
+	if rawErr := token.Scope.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	id, err := s.core.AuthOperation().CreateAPIToken(ctx.Kit, token)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// DeleteAPIToken delete personal api token of the current user
+func (s *coreService) DeleteAPIToken(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteAPIToken(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListAPITokens list personal api tokens of the current user
+func (s *coreService) ListAPITokens(ctx *rest.Contexts) {
+	opt := new(metadata.ListAPITokenOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListAPITokens(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// FindAPITokenByHash find the valid api token by the hash of the token, used by api server to authenticate requests
+func (s *coreService) FindAPITokenByHash(ctx *rest.Contexts) {
+	opt := new(metadata.FindAPITokenOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(opt.TokenHash) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "token_hash"))
+		return
+	}
+
+	token, err := s.core.AuthOperation().FindAPITokenByHash(ctx.Kit, opt.TokenHash)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(token)
+}
+
+// RecordAPITokenUse record that the api token is used by the request, updates the last used time of the token
+// and saves the audit log of the use
+func (s *coreService) RecordAPITokenUse(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	use := new(metadata.APITokenUse)
+	if err := ctx.DecodeInto(use); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	opt := &metadata.ListAPITokenOption{IDs: []int64{id}, Page: metadata.BasePage{Limit: 1}}
+	tokens, err := s.core.AuthOperation().ListAPITokens(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(tokens.Info) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommNotFound))
+		return
+	}
+
+	if err = s.core.AuthOperation().UpdateAPITokenLastUsedTime(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	audit := metadata.AuditLog{
+		AuditType:       metadata.APITokenType,
+		ResourceType:    metadata.APITokenRes,
+		Action:          metadata.AuditUse,
+		ResourceID:      id,
+		ResourceName:    tokens.Info[0].Name,
+		OperationDetail: &metadata.GenericOpDetail{Data: use},
+	}
+	if err := s.core.AuditOperation().CreateAuditLog(ctx.Kit, audit); err != nil {
+		blog.Errorf("save api token %d use audit log failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrAuditSaveLogFailed))
+		return
+	}
+	ctx.RespEntity(nil)
+}
@@ -13,10 +13,15 @@
 package service
 
 import (
+	"context"
+	"time"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	headerutil "configcenter/src/common/http/header/util"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/app/options"
 )
 
 // CreateAuditLog TODO
@@ -59,3 +64,130 @@ func (s *coreService) SearchAuditLog(ctx *rest.Contexts) {
 func (s *coreService) CreateAuditLogDependence(kit *rest.Kit, logs ...metadata.AuditLog) error {
 	return s.core.AuditOperation().CreateAuditLog(kit, logs...)
 }
+
+// SearchAuditLogArchive search the index of the archive files that the expired audit logs are moved into
+func (s *coreService) SearchAuditLogArchive(ctx *rest.Contexts) {
+	opt := new(metadata.SearchAuditLogArchiveOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.AuditOperation().SearchAuditLogArchive(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// RunAuditLogRetention archives the audit logs that are expired by the retention policies periodically, only the
+// master core service runs the job, so that the audit logs are not archived repeatedly.
+func (s *coreService) RunAuditLogRetention(ctx context.Context) {
+	conf := s.cfg.AuditLog.Retention
+	if !conf.Enabled || len(conf.Policies) == 0 {
+		return
+	}
+
+	interval := conf.IntervalMinutes
+	if interval <= 0 {
+		interval = options.AuditRetentionIntervalMinutesDefault
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(interval) * time.Minute):
+		}
+
+		if !s.engine.ServiceManageInterface.IsMaster() {
+			blog.V(4).Infof("it is not master, skip audit log retention")
+			continue
+		}
+
+		header := headerutil.BuildHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID)
+		kit := rest.NewKitFromHeader(header, s.err)
+		kit.Ctx = ctx
+
+		blog.Infof("start archiving expired audit logs, rid: %s", kit.Rid)
+		if err := s.core.AuditOperation().ArchiveExpiredAuditLog(kit); err != nil {
+			blog.Errorf("archive expired audit logs failed, err: %v, rid: %s", err, kit.Rid)
+			continue
+		}
+		blog.Infof("finish archiving expired audit logs, rid: %s", kit.Rid)
+	}
+}
+
+// RunAuditLogChain links the new audit logs into the tamper-evident hash chains and signs the chain heads as
+// checkpoints periodically, only the master core service runs the job, so that the chains are not linked concurrently.
+func (s *coreService) RunAuditLogChain(ctx context.Context) {
+	conf := s.cfg.AuditLog.Chain
+	if !conf.Enabled {
+		return
+	}
+
+	var lastCheckpoint time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(conf.IntervalSeconds) * time.Second):
+		}
+
+		if !s.engine.ServiceManageInterface.IsMaster() {
+			blog.V(4).Infof("it is not master, skip linking audit log hash chain")
+			continue
+		}
+
+		header := headerutil.BuildHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID)
+		kit := rest.NewKitFromHeader(header, s.err)
+		kit.Ctx = ctx
+
+		if err := s.core.AuditOperation().SealAuditLogChain(kit); err != nil {
+			continue
+		}
+
+		if time.Since(lastCheckpoint) < time.Duration(conf.CheckpointIntervalMinutes)*time.Minute {
+			continue
+		}
+
+		if err := s.core.AuditOperation().CheckpointAuditLogChain(kit); err != nil {
+			continue
+		}
+		lastCheckpoint = time.Now()
+	}
+}
+
+// RunAuditLogExport exports the new audit logs to the export sinks periodically, only the master core service runs the
+// job, so that the audit logs are exported from the saved progress in order.
+func (s *coreService) RunAuditLogExport(ctx context.Context) {
+	if len(s.cfg.AuditLog.Export) == 0 {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(options.AuditExportIntervalSeconds * time.Second):
+		}
+
+		if !s.engine.ServiceManageInterface.IsMaster() {
+			blog.V(4).Infof("it is not master, skip exporting audit logs")
+			continue
+		}
+
+		header := headerutil.BuildHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID)
+		kit := rest.NewKitFromHeader(header, s.err)
+		kit.Ctx = ctx
+
+		// the audit logs that are failed to export are exported again in the next round from the saved progress
+		_ = s.core.AuditOperation().ExportAuditLog(kit)
+	}
+}
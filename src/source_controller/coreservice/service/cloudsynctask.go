@@ -142,3 +142,63 @@ func (s *coreService) DeleteDestroyedHostRelated(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(nil)
 }
+
+// CreateSyncChangeSet create cloud sync change set
+func (s *coreService) CreateSyncChangeSet(ctx *rest.Contexts) {
+	changeSet := metadata.CloudSyncChangeSet{}
+	if err := ctx.DecodeInto(&changeSet); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.CloudOperation().CreateSyncChangeSet(ctx.Kit, &changeSet)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+// SearchSyncChangeSet search cloud sync change set
+func (s *coreService) SearchSyncChangeSet(ctx *rest.Contexts) {
+	option := metadata.SearchSyncChangeSetOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.CloudOperation().SearchSyncChangeSet(ctx.Kit, &option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// UpdateSyncChangeSet update cloud sync change set
+func (s *coreService) UpdateSyncChangeSet(ctx *rest.Contexts) {
+	changeSetIDStr := ctx.Request.PathParameter(common.BKCloudChangeSetID)
+	changeSetID, err := strconv.ParseInt(changeSetIDStr, 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudChangeSetID))
+		return
+	}
+
+	option := mapstr.MapStr{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.CloudOperation().UpdateSyncChangeSet(ctx.Kit, changeSetID, option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
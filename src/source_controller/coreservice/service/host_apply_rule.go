@@ -379,3 +379,84 @@ func (s *coreService) SearchRuleRelatedServiceTemplates(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(serviceTemplates)
 }
+
+// SaveHostApplyDrift save a batch of the drifts detected by a host apply drift check run
+func (s *coreService) SaveHostApplyDrift(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := new(metadata.SaveHostApplyDriftOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	err = s.core.HostApplyRuleOperation().SaveHostApplyDrift(ctx.Kit, bizID, option.RunID, option.Drifts)
+	if err != nil {
+		blog.Errorf("save host apply drifts failed, biz: %d, run: %s, count: %d, err: %v, rid: %s", bizID,
+			option.RunID, len(option.Drifts), err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// FinishHostApplyDriftRun finish a host apply drift check run, switch the reported drifts to the run if it succeeded
+func (s *coreService) FinishHostApplyDriftRun(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := new(metadata.FinishHostApplyDriftRunOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := option.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.core.HostApplyRuleOperation().FinishHostApplyDriftRun(ctx.Kit, bizID, option); err != nil {
+		blog.Errorf("finish host apply drift run failed, biz: %d, option: %+v, err: %v, rid: %s", bizID, option,
+			err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListHostApplyDrift list the host apply drift report of the business
+func (s *coreService) ListHostApplyDrift(ctx *rest.Contexts) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	option := new(metadata.ListHostApplyDriftOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.HostApplyRuleOperation().ListHostApplyDrift(ctx.Kit, bizID, option)
+	if err != nil {
+		blog.Errorf("list host apply drifts failed, biz: %d, option: %+v, err: %v, rid: %s", bizID, option, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
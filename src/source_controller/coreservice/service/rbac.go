@@ -0,0 +1,268 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func parseRBACID(ctx *rest.Contexts) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateRBACRole create rbac role
+func (s *coreService) CreateRBACRole(ctx *rest.Contexts) {
+	role := new(metadata.RBACRole)
+	if err := ctx.DecodeInto(role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := role.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	id, err := s.core.AuthOperation().CreateRBACRole(ctx.Kit, role)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// UpdateRBACRole update rbac role
+func (s *coreService) UpdateRBACRole(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	role := new(metadata.RBACRole)
+	if err := ctx.DecodeInto(role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := role.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.core.AuthOperation().UpdateRBACRole(ctx.Kit, id, role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DeleteRBACRole delete rbac role
+func (s *coreService) DeleteRBACRole(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteRBACRole(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListRBACRoles list rbac roles
+func (s *coreService) ListRBACRoles(ctx *rest.Contexts) {
+	opt := new(metadata.ListRBACRoleOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListRBACRoles(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// CreateRBACRoleBinding create rbac role binding
+func (s *coreService) CreateRBACRoleBinding(ctx *rest.Contexts) {
+	binding := new(metadata.RBACRoleBinding)
+	if err := ctx.DecodeInto(binding); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := binding.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	id, err := s.core.AuthOperation().CreateRBACRoleBinding(ctx.Kit, binding)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// UpdateRBACRoleBinding update rbac role binding
+func (s *coreService) UpdateRBACRoleBinding(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	binding := new(metadata.RBACRoleBinding)
+	if err := ctx.DecodeInto(binding); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := binding.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	if err := s.core.AuthOperation().UpdateRBACRoleBinding(ctx.Kit, id, binding); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DeleteRBACRoleBinding delete rbac role binding
+func (s *coreService) DeleteRBACRoleBinding(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteRBACRoleBinding(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListRBACRoleBindings list rbac role bindings
+func (s *coreService) ListRBACRoleBindings(ctx *rest.Contexts) {
+	opt := new(metadata.ListRBACRoleBindingOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListRBACRoleBindings(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// CreateRBACUserGroup create rbac user group
+func (s *coreService) CreateRBACUserGroup(ctx *rest.Contexts) {
+	group := new(metadata.RBACUserGroup)
+	if err := ctx.DecodeInto(group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := group.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	id, err := s.core.AuthOperation().CreateRBACUserGroup(ctx.Kit, group)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.RspID{ID: id})
+}
+
+// UpdateRBACUserGroup update rbac user group
+func (s *coreService) UpdateRBACUserGroup(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	group := new(metadata.RBACUserGroup)
+	if err := ctx.DecodeInto(group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().UpdateRBACUserGroup(ctx.Kit, id, group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DeleteRBACUserGroup delete rbac user group
+func (s *coreService) DeleteRBACUserGroup(ctx *rest.Contexts) {
+	id, ok := parseRBACID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteRBACUserGroup(ctx.Kit, id); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListRBACUserGroups list rbac user groups
+func (s *coreService) ListRBACUserGroups(ctx *rest.Contexts) {
+	opt := new(metadata.ListRBACUserGroupOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListRBACUserGroups(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -14,6 +14,7 @@
 package service
 
 import (
+	"context"
 	"net/http"
 
 	"configcenter/src/common"
@@ -52,6 +53,9 @@ import (
 type CoreServiceInterface interface {
 	WebService() *restful.Container
 	SetConfig(cfg options.Config, engine *backbone.Engine, err errors.CCErrorIf, language language.CCLanguageIf) error
+	RunAuditLogRetention(ctx context.Context)
+	RunAuditLogChain(ctx context.Context)
+	RunAuditLogExport(ctx context.Context)
 }
 
 // New create topo service instance
@@ -108,14 +112,14 @@ func (s *coreService) SetConfig(cfg options.Config, engine *backbone.Engine, err
 	instance := instances.New(s, lang, engine.CoreAPI)
 	hostApplyRuleCore := hostapplyrule.New(instance, engine.CoreAPI)
 	s.core = core.New(
-		model.New(s, lang),
+		model.New(s, lang, cfg.FullTextSearchIndex),
 		instance,
 		kube.New(),
 		association.New(s),
 		datasynchronize.New(s),
 		mainline.New(lang),
 		host.New(s, hostApplyRuleCore, engine.CoreAPI.CacheService().Cache().Host()),
-		auditlog.New(),
+		auditlog.New(cfg.AuditLog),
 		process.New(s),
 		label.New(),
 		settemplate.New(),
@@ -45,6 +45,13 @@ func (s *coreService) initHostApplyRule(web *restful.WebService) {
 		Handler: s.UpdateHostByHostApplyRule})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/service_templates/host_apply_rule_related",
 		Handler: s.SearchRuleRelatedServiceTemplates})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/host_apply_drift/bk_biz_id/{bk_biz_id}",
+		Handler: s.SaveHostApplyDrift})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut,
+		Path:    "/update/host_apply_drift_run/bk_biz_id/{bk_biz_id}",
+		Handler: s.FinishHostApplyDriftRun})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_apply_drift/bk_biz_id/{bk_biz_id}",
+		Handler: s.ListHostApplyDrift})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -168,6 +168,12 @@ func (s *coreService) initModelInstances(web *restful.WebService) {
 		Handler: s.CascadeDeleteModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/get/instance/object/mapping",
 		Handler: s.GetInstanceObjectMapping})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/recycle_bin/object/{bk_obj_id}",
+		Handler: s.ListRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/recycle_bin/object/{bk_obj_id}/restore_preview",
+		Handler: s.PreviewRestoreRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/recycle_bin/object/{bk_obj_id}/restore",
+		Handler: s.RestoreRecycleBin})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -347,6 +353,8 @@ func (s *coreService) audit(web *restful.WebService) {
 		Handler: s.CreateAuditLog})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/auditlog",
 		Handler: s.SearchAuditLog})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auditlog/archive",
+		Handler: s.SearchAuditLogArchive})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -481,6 +489,12 @@ func (s *coreService) initCloudSync(web *restful.WebService) {
 		Handler: s.SearchSyncHistory})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/sync/destroyed_host_related",
 		Handler: s.DeleteDestroyedHostRelated})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/cloud/sync/change_set",
+		Handler: s.CreateSyncChangeSet})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/change_set",
+		Handler: s.SearchSyncChangeSet})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/sync/change_set/{bk_change_set_id}",
+		Handler: s.UpdateSyncChangeSet})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -494,6 +508,35 @@ func (s *coreService) initAuth(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/search/auth/resource",
 		Handler: s.SearchAuthResource})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/rbac/role", Handler: s.CreateRBACRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/rbac/role/{id}", Handler: s.UpdateRBACRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/rbac/role/{id}", Handler: s.DeleteRBACRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/rbac/role", Handler: s.ListRBACRoles})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/rbac/role_binding",
+		Handler: s.CreateRBACRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/rbac/role_binding/{id}",
+		Handler: s.UpdateRBACRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/rbac/role_binding/{id}",
+		Handler: s.DeleteRBACRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/rbac/role_binding",
+		Handler: s.ListRBACRoleBindings})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/rbac/user_group",
+		Handler: s.CreateRBACUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/rbac/user_group/{id}",
+		Handler: s.UpdateRBACUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/rbac/user_group/{id}",
+		Handler: s.DeleteRBACUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/rbac/user_group",
+		Handler: s.ListRBACUserGroups})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/api_token", Handler: s.CreateAPIToken})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/api_token/{id}", Handler: s.DeleteAPIToken})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/api_token", Handler: s.ListAPITokens})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/api_token/by_hash",
+		Handler: s.FindAPITokenByHash})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/api_token/{id}/use",
+		Handler: s.RecordAPITokenUse})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -18,10 +18,12 @@
 package options
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
 
+	"configcenter/pkg/filter"
 	"configcenter/pkg/synchronize/types"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -48,8 +50,17 @@ type SyncConfig struct {
 	Role SyncRole `mapstructure:"role"`
 	// SyncIntervalHours is the full sync interval, unit: hour
 	SyncIntervalHours int `mapstructure:"syncIntervalHours"`
-	// TransMediumAddr is the transfer medium addresses
+	// TransMediumType is the transfer medium type, default is http
+	TransMediumType TransMediumType `mapstructure:"transferMediumType"`
+	// TransMediumAddr is the transfer medium addresses, only used by http transfer medium
 	TransMediumAddr []string `mapstructure:"transferMediumAddress"`
+	// Bundle is the bundle file transfer medium config, only used by file transfer medium
+	Bundle *BundleConfig `mapstructure:"bundle"`
+	// Scope is the scope of the synced data, only used by the source environment, all data is synced if not set
+	Scope *SyncScopeConfig `mapstructure:"scope"`
+	// ConflictPolicy is the policy of handling the sync data that conflicts with the local change of the data,
+	// only used by the destination environment, default is srcWins
+	ConflictPolicy ConflictPolicy `mapstructure:"conflictPolicy"`
 }
 
 // Validate SyncConfig
@@ -68,17 +79,200 @@ func (s *SyncConfig) Validate() error {
 			return fmt.Errorf("invalid sync interval hours: %d", s.SyncIntervalHours)
 		}
 	case SyncRoleDest:
+		switch s.ConflictPolicy {
+		case "":
+			s.ConflictPolicy = ConflictPolicySrcWins
+		case ConflictPolicySrcWins, ConflictPolicyDestWins, ConflictPolicyQueue:
+		default:
+			return fmt.Errorf("invalid conflict policy: %s", s.ConflictPolicy)
+		}
 	default:
 		return fmt.Errorf("invalid sync role: %s", s.Role)
 	}
 
-	if len(s.TransMediumAddr) == 0 {
-		return fmt.Errorf("transfer medium address is not set")
+	switch s.TransMediumType {
+	case "", TransMediumHTTP:
+		if len(s.TransMediumAddr) == 0 {
+			return fmt.Errorf("transfer medium address is not set")
+		}
+	case TransMediumFile:
+		if s.Bundle == nil {
+			return errors.New("bundle config is not set for file transfer medium")
+		}
+
+		if err := s.Bundle.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid transfer medium type: %s", s.TransMediumType)
+	}
+
+	if s.Scope != nil {
+		if s.Role != SyncRoleSrc {
+			return errors.New("sync scope can only be set for the source environment")
+		}
+
+		if err := s.Scope.Validate(); err != nil {
+			return fmt.Errorf("validate sync scope failed, err: %v", err)
+		}
 	}
 
 	return nil
 }
 
+// ConflictPolicy is the policy of handling the sync data that conflicts with the local change of the destination data
+type ConflictPolicy string
+
+const (
+	// ConflictPolicySrcWins means that the sync data overwrites the local change
+	ConflictPolicySrcWins ConflictPolicy = "srcWins"
+	// ConflictPolicyDestWins means that the local change is kept and the sync data is dropped
+	ConflictPolicyDestWins ConflictPolicy = "destWins"
+	// ConflictPolicyQueue means that the sync data is parked in the conflict queue until it is resolved manually
+	ConflictPolicyQueue ConflictPolicy = "queue"
+)
+
+// TransMediumType is the transfer medium type
+type TransMediumType string
+
+const (
+	// TransMediumHTTP is the transfer medium service that pushes and pulls sync data through http
+	TransMediumHTTP TransMediumType = "http"
+	// TransMediumFile is the bundle files in a directory, which can be carried across isolated networks by
+	// removable media
+	TransMediumFile TransMediumType = "file"
+)
+
+const (
+	defaultBundleMaxRecords           = 1000
+	defaultBundleFlushIntervalSeconds = 60
+)
+
+// BundleConfig is the bundle file transfer medium config
+type BundleConfig struct {
+	// Dir is the directory that the source environment writes bundles into and the destination environment
+	// imports bundles from
+	Dir string `mapstructure:"dir"`
+	// Secret is the secret that is used to sign and verify the bundles, must be the same in all environments
+	Secret string `mapstructure:"secret"`
+	// MaxRecords is the max number of sync data in one bundle, only used by the source environment
+	MaxRecords int `mapstructure:"maxRecords"`
+	// FlushIntervalSeconds is the max seconds that the sync data waits before it is written into a bundle,
+	// only used by the source environment
+	FlushIntervalSeconds int `mapstructure:"flushIntervalSeconds"`
+}
+
+// Validate BundleConfig, and set the default values for the unset options
+func (b *BundleConfig) Validate() error {
+	if b.Dir == "" {
+		return errors.New("bundle dir is not set")
+	}
+
+	if b.Secret == "" {
+		return errors.New("bundle secret is not set")
+	}
+
+	if b.MaxRecords < 0 || b.FlushIntervalSeconds < 0 {
+		return fmt.Errorf("invalid bundle max records %d or flush interval seconds %d", b.MaxRecords,
+			b.FlushIntervalSeconds)
+	}
+
+	if b.MaxRecords == 0 {
+		b.MaxRecords = defaultBundleMaxRecords
+	}
+
+	if b.FlushIntervalSeconds == 0 {
+		b.FlushIntervalSeconds = defaultBundleFlushIntervalSeconds
+	}
+	return nil
+}
+
+// SyncScopeConfig is the scope of the data that the source environment syncs to the destination environment,
+// the data that is out of the scope is not synced, and is removed from the destination environment by full sync
+type SyncScopeConfig struct {
+	// BizIDs are the ids of the businesses whose resources are synced, all businesses are synced if not set
+	BizIDs []int64 `mapstructure:"bizIDs"`
+	// ObjIDs are the ids of the objects whose instances are synced, all objects are synced if not set
+	ObjIDs []string `mapstructure:"objIDs"`
+	// Filters are the filters on the attributes of the synced resources
+	Filters []ScopeFilterConf `mapstructure:"filters"`
+}
+
+// Validate SyncScopeConfig
+func (s *SyncScopeConfig) Validate() error {
+	for _, bizID := range s.BizIDs {
+		if bizID <= 0 {
+			return fmt.Errorf("scope biz id %d is invalid", bizID)
+		}
+	}
+
+	for _, objID := range s.ObjIDs {
+		if objID == "" {
+			return errors.New("scope object id is empty")
+		}
+	}
+
+	for i, conf := range s.Filters {
+		if err := conf.Validate(); err != nil {
+			return fmt.Errorf("validate scope filter(index: %d) failed, err: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ScopeFilterConf is the filter on the attributes of one resource
+type ScopeFilterConf struct {
+	// Resource is the resource type that the filter applies to
+	Resource types.ResType `mapstructure:"resource"`
+	// ObjID is the object id that the filter applies to, only used by object instance, applies to all objects if
+	// not set
+	ObjID string `mapstructure:"objID"`
+	// Filter is the json format filter expression, the data that does not match it is not synced
+	Filter string `mapstructure:"filter"`
+}
+
+// Validate ScopeFilterConf
+func (s *ScopeFilterConf) Validate() error {
+	isValidRes := false
+	for _, resType := range types.ListAllResType() {
+		if s.Resource == resType {
+			isValidRes = true
+			break
+		}
+	}
+	if !isValidRes {
+		return fmt.Errorf("scope filter resource %s is invalid", s.Resource)
+	}
+
+	if s.ObjID != "" && s.Resource != types.ObjectInstance {
+		return fmt.Errorf("scope filter object id can not be set for %s", s.Resource)
+	}
+
+	_, err := s.Expression()
+	return err
+}
+
+// Expression parses the filter expression
+func (s *ScopeFilterConf) Expression() (*filter.Expression, error) {
+	if s.Filter == "" {
+		return nil, fmt.Errorf("%s scope filter is not set", s.Resource)
+	}
+
+	expr := new(filter.Expression)
+	if err := json.Unmarshal([]byte(s.Filter), expr); err != nil {
+		return nil, fmt.Errorf("%s scope filter is invalid, err: %v", s.Resource, err)
+	}
+
+	opt := filter.NewDefaultExprOpt(nil)
+	opt.IgnoreRuleFields = true
+	if err := expr.Validate(opt); err != nil {
+		return nil, fmt.Errorf("%s scope filter is invalid, err: %v", s.Resource, err)
+	}
+
+	return expr, nil
+}
+
 // SyncRole is the transfer service role in cmdb synchronization
 type SyncRole string
 
@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+)
+
+// ListSyncConflicts list sync conflicts
+func (s *Service) ListSyncConflicts(cts *rest.Contexts) {
+	opt := new(types.ListSyncConflictOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	res, err := s.syncer.ListSyncConflicts(cts.Kit, opt)
+	if err != nil {
+		blog.Errorf("list sync conflicts failed, err: %v, opt: %+v, rid: %s", err, opt, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(res)
+}
+
+// ResolveSyncConflicts resolve sync conflicts
+func (s *Service) ResolveSyncConflicts(cts *rest.Contexts) {
+	opt := new(types.ResolveSyncConflictOption)
+	if err := cts.DecodeInto(opt); err != nil {
+		cts.RespAutoError(err)
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		cts.RespAutoError(rawErr.ToCCError(cts.Kit.CCError))
+		return
+	}
+
+	if err := s.syncer.ResolveSyncConflicts(cts.Kit, opt); err != nil {
+		blog.Errorf("resolve sync conflicts failed, err: %v, opt: %+v, rid: %s", err, opt, cts.Kit.Rid)
+		cts.RespAutoError(err)
+		return
+	}
+
+	cts.RespEntity(nil)
+}
@@ -50,7 +50,7 @@ func New(conf *options.Config, engine *backbone.Engine) (*Service, error) {
 	}
 
 	syncer, err := sync.NewSyncer(conf, engine.ServiceManageInterface, loopW, engine.CoreAPI.CacheService(),
-		engine.Metric().Registry())
+		engine.CoreAPI.CoreService(), engine.Metric().Registry())
 	if err != nil {
 		blog.Errorf("new syncer failed, err: %v", err)
 		return nil, err
@@ -95,6 +95,10 @@ func (s *Service) initService(api *restful.WebService) {
 	})
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/sync/cmdb/data", Handler: s.SyncCmdbData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/sync/conflict",
+		Handler: s.ListSyncConflicts})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/sync/conflict/resolve",
+		Handler: s.ResolveSyncConflicts})
 
 	utility.AddToRestfulWebService(api)
 }
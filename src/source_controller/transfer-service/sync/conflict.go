@@ -0,0 +1,178 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package sync
+
+import (
+	"context"
+	"errors"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/sync/logics"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	daltypes "configcenter/src/storage/dal/types"
+	"configcenter/src/storage/driver/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// initConflictTable creates the index of the sync conflict table
+func initConflictTable() {
+	index := daltypes.Index{
+		Name: "idx_status_resType_lastTime",
+		Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: common.BKResourceTypeField, Value: 1},
+			{Key: common.LastTimeField, Value: -1},
+		},
+		Background: true,
+	}
+	if err := mongodb.Client().Table(logics.ConflictTable).CreateIndex(context.Background(), index); err != nil &&
+		!mongodb.Client().IsDuplicatedError(err) {
+		blog.Errorf("create %s index failed, err: %v", logics.ConflictTable, err)
+	}
+}
+
+// ListSyncConflicts list the conflicts between the sync data and the local change of the destination data
+func (s *Syncer) ListSyncConflicts(kit *rest.Kit, opt *types.ListSyncConflictOption) (*types.ListSyncConflictResult,
+	error) {
+
+	if !s.enableSync {
+		return nil, errors.New("sync is disabled")
+	}
+
+	cond := genListConflictCond(opt)
+	if opt.Page.EnableCount {
+		count, err := mongodb.Client().Table(logics.ConflictTable).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+			return nil, err
+		}
+		return &types.ListSyncConflictResult{Count: count}, nil
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = "-" + common.LastTimeField
+	}
+
+	conflicts := make([]types.SyncConflict, 0)
+	err := mongodb.Client().Table(logics.ConflictTable).Find(cond).Fields("_id", common.BKResourceTypeField,
+		"sub_resource", "data_id", "action", "status", "resolution", "src_data", "dest_data", common.CreateTimeField,
+		common.LastTimeField).Sort(sort).Start(uint64(opt.Page.Start)).Limit(uint64(opt.Page.Limit)).
+		All(kit.Ctx, &conflicts)
+	if err != nil {
+		blog.Errorf("list sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return nil, err
+	}
+
+	return &types.ListSyncConflictResult{Info: conflicts}, nil
+}
+
+// genListConflictCond generates the condition to list sync conflicts, sub resource is only used with resource type
+func genListConflictCond(opt *types.ListSyncConflictOption) mapstr.MapStr {
+	cond := mapstr.MapStr{}
+	if opt.ResType != "" {
+		cond[common.BKResourceTypeField] = opt.ResType
+		if opt.SubRes != "" {
+			cond["sub_resource"] = opt.SubRes
+		}
+	}
+	if opt.Status != "" {
+		cond["status"] = opt.Status
+	}
+	return cond
+}
+
+// ResolveSyncConflicts resolve the pending sync conflicts, the sync data is written to the destination if the
+// resolution is src, otherwise the local change is kept
+func (s *Syncer) ResolveSyncConflicts(kit *rest.Kit, opt *types.ResolveSyncConflictOption) error {
+	if !s.enableSync {
+		return errors.New("sync is disabled")
+	}
+
+	cond := mapstr.MapStr{
+		"_id":    mapstr.MapStr{common.BKDBIN: opt.IDs},
+		"status": types.ConflictPending,
+	}
+
+	conflicts := make([]types.SyncConflict, 0)
+	err := mongodb.Client().Table(logics.ConflictTable).Find(cond).Fields("_id", common.BKResourceTypeField,
+		"sub_resource", "data_id", "action").All(kit.Ctx, &conflicts)
+	if err != nil {
+		blog.Errorf("get pending sync conflicts failed, err: %v, cond: %+v, rid: %s", err, cond, kit.Rid)
+		return err
+	}
+
+	// read from primary so that the resolved conflict status can be seen when writing the sync data
+	kt := util.ConvertKit(kit)
+	kt.Ctx = commonutil.SetDBReadPreference(kit.Ctx, common.PrimaryMode)
+	for _, conflict := range conflicts {
+		syncer, exists := s.resSyncerMap[conflict.ResType]
+		if !exists {
+			blog.Errorf("sync conflict %s res type is invalid, rid: %s", conflict.ID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "resource_type")
+		}
+
+		// mark the conflict as resolved first, so that the sync data can be written by the resolution
+		updateCond := mapstr.MapStr{"_id": conflict.ID}
+		updateData := mapstr.MapStr{
+			"status":             types.ConflictResolved,
+			"resolution":         opt.Resolution,
+			common.LastTimeField: metadata.Now(),
+		}
+		if err = mongodb.Client().Table(logics.ConflictTable).Update(kit.Ctx, updateCond, updateData); err != nil {
+			blog.Errorf("resolve sync conflict %s failed, err: %v, rid: %s", conflict.ID, err, kit.Rid)
+			return err
+		}
+
+		if err = writeConflictResolution(kt, syncer.lgc, conflict, opt.Resolution); err != nil {
+			blog.Errorf("write sync conflict %s data failed, err: %v, rid: %s", conflict.ID, err, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeConflictResolution writes the sync data of the conflict to the destination if the resolution is src,
+// the local change is kept otherwise
+func writeConflictResolution(kit *util.Kit, lgc logics.Logics, conflict types.SyncConflict,
+	resolution types.ConflictResolution) error {
+
+	if resolution != types.ResolutionSrc {
+		return nil
+	}
+
+	switch conflict.Action {
+	case types.ConflictActionDelete:
+		return lgc.DeleteData(kit, conflict.SubRes, []int64{conflict.DataID})
+	case types.ConflictActionUpdate:
+		data, err := lgc.ParseConflictData(kit, conflict.ID)
+		if err != nil {
+			return err
+		}
+		return lgc.UpdateData(kit, conflict.SubRes, data)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/transfer-service/sync/logics"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenListConflictCond(t *testing.T) {
+	testCases := []struct {
+		opt  *types.ListSyncConflictOption
+		cond mapstr.MapStr
+	}{{
+		opt:  &types.ListSyncConflictOption{},
+		cond: mapstr.MapStr{},
+	}, {
+		opt: &types.ListSyncConflictOption{ResType: types.ObjectInstance, SubRes: "switch",
+			Status: types.ConflictPending},
+		cond: mapstr.MapStr{common.BKResourceTypeField: types.ObjectInstance, "sub_resource": "switch",
+			"status": types.ConflictPending},
+	}, {
+		opt:  &types.ListSyncConflictOption{SubRes: "switch", Status: types.ConflictResolved},
+		cond: mapstr.MapStr{"status": types.ConflictResolved},
+	}}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.cond, genListConflictCond(testCase.opt))
+	}
+}
+
+// fakeLogics records the sync data written by the conflict resolution
+type fakeLogics struct {
+	logics.Logics
+	parsed  []string
+	updated []any
+	deleted []any
+	err     error
+}
+
+// ParseConflictData returns the conflict id as the parsed data
+func (f *fakeLogics) ParseConflictData(_ *util.Kit, conflictID string) (any, error) {
+	f.parsed = append(f.parsed, conflictID)
+	return conflictID, f.err
+}
+
+// UpdateData records the updated data
+func (f *fakeLogics) UpdateData(_ *util.Kit, _ string, data any) error {
+	f.updated = append(f.updated, data)
+	return nil
+}
+
+// DeleteData records the deleted data
+func (f *fakeLogics) DeleteData(_ *util.Kit, _ string, data any) error {
+	f.deleted = append(f.deleted, data)
+	return nil
+}
+
+func TestWriteConflictResolution(t *testing.T) {
+	kit := &util.Kit{Rid: "rid", Header: make(http.Header), Ctx: context.Background()}
+	updateConflict := types.SyncConflict{ID: "set::1", ResType: types.Set, DataID: 1,
+		Action: types.ConflictActionUpdate}
+	deleteConflict := types.SyncConflict{ID: "set::2", ResType: types.Set, DataID: 2,
+		Action: types.ConflictActionDelete}
+
+	// local change is kept, no sync data is written
+	lgc := new(fakeLogics)
+	require.NoError(t, writeConflictResolution(kit, lgc, updateConflict, types.ResolutionDest))
+	require.NoError(t, writeConflictResolution(kit, lgc, deleteConflict, types.ResolutionDest))
+	require.Empty(t, lgc.parsed)
+	require.Empty(t, lgc.updated)
+	require.Empty(t, lgc.deleted)
+
+	// sync data is written
+	require.NoError(t, writeConflictResolution(kit, lgc, updateConflict, types.ResolutionSrc))
+	require.Equal(t, []string{"set::1"}, lgc.parsed)
+	require.Equal(t, []any{"set::1"}, lgc.updated)
+	require.NoError(t, writeConflictResolution(kit, lgc, deleteConflict, types.ResolutionSrc))
+	require.Equal(t, []any{[]int64{2}}, lgc.deleted)
+
+	// sync data is not updated if it can not be parsed
+	lgc = &fakeLogics{err: errors.New("conflict not found")}
+	require.Equal(t, lgc.err, writeConflictResolution(kit, lgc, updateConflict, types.ResolutionSrc))
+	require.Empty(t, lgc.updated)
+}
+
+func TestSyncConflictsWithSyncDisabled(t *testing.T) {
+	syncer := new(Syncer)
+	_, err := syncer.ListSyncConflicts(nil, &types.ListSyncConflictOption{Page: metadata.BasePage{Limit: 10}})
+	require.Error(t, err)
+	require.Error(t, syncer.ResolveSyncConflicts(nil, &types.ResolveSyncConflictOption{}))
+}
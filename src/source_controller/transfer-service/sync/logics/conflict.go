@@ -0,0 +1,486 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/driver/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// dataVersionTable stores the version of the synced data in the destination environment, it is used to detect
+	// whether the data is changed locally since last sync
+	dataVersionTable = "cc_SyncDataVersion"
+	// ConflictTable stores the conflicts between the sync data and the local change of the destination data
+	ConflictTable = "cc_SyncConflict"
+)
+
+// conflictResInfo is the info of the resource that supports conflict detection
+type conflictResInfo struct {
+	auditType metadata.AuditType
+	auditRes  metadata.ResourceType
+	// objID is the object id of the resource, the sub resource is used as object id if it is empty
+	objID     string
+	nameField string
+}
+
+var conflictResInfoMap = map[types.ResType]conflictResInfo{
+	types.Biz: {metadata.BusinessType, metadata.BusinessRes, common.BKInnerObjIDApp, common.BKAppNameField},
+	types.Set: {metadata.BusinessResourceType, metadata.SetRes, common.BKInnerObjIDSet, common.BKSetNameField},
+	types.Module: {metadata.BusinessResourceType, metadata.ModuleRes, common.BKInnerObjIDModule,
+		common.BKModuleNameField},
+	types.Host:           {metadata.HostType, metadata.HostRes, common.BKInnerObjIDHost, common.BKHostInnerIPField},
+	types.ObjectInstance: {metadata.ModelInstanceType, metadata.ModelInstanceRes, "", common.BKInstNameField},
+	types.Process: {metadata.BusinessResourceType, metadata.ProcessRes, common.BKInnerObjIDProc,
+		common.BKProcessNameField},
+}
+
+// dataVersion is the version of the synced data
+type dataVersion struct {
+	ID string `bson:"_id"`
+	// Fields are the synced fields of the data, the local change of other fields does not cause conflict
+	Fields []string `bson:"fields"`
+	Hash   string   `bson:"hash"`
+}
+
+func genDataKey(resType types.ResType, subRes string, id int64) string {
+	return fmt.Sprintf("%s:%s:%d", resType, subRes, id)
+}
+
+// isConflictEnabled returns if the conflict detection is enabled for the resource
+func (l *resLogicsConfig) isConflictEnabled() bool {
+	if l.conflictPolicy == "" {
+		return false
+	}
+
+	_, exists := conflictResInfoMap[l.resType]
+	return exists
+}
+
+// toRawMap converts data to field to json value map
+func toRawMap(data any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rawMap := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(raw, &rawMap); err != nil {
+		return nil, err
+	}
+	return rawMap, nil
+}
+
+// toStoredRawMap converts the sync data to field to json value map in the form that it is read from db, so that its
+// hash can be compared with the local data. e.g. the host ips are arrays in the sync data, but are read as strings.
+func toStoredRawMap[T any](data T) (map[string]json.RawMessage, error) {
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored T
+	if err = bson.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+	return toRawMap(stored)
+}
+
+// hashFields returns the hash of the specified fields of the data, time fields are skipped because they are
+// maintained by each environment
+func hashFields(rawMap map[string]json.RawMessage, fields []string) string {
+	fieldMap := make(map[string]json.RawMessage)
+	for _, field := range fields {
+		if field == common.CreateTimeField || field == common.LastTimeField {
+			continue
+		}
+
+		if val, exists := rawMap[field]; exists {
+			fieldMap[field] = val
+		}
+	}
+
+	// json map keys are sorted, so the marshalled value is stable
+	raw, _ := json.Marshal(fieldMap)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedFields(rawMap map[string]json.RawMessage) []string {
+	fields := make([]string, 0, len(rawMap))
+	for field := range rawMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// saveDataVersions saves the versions of the written sync data
+func (l *dataWithIDLogics[T]) saveDataVersions(kit *util.Kit, subRes string, dataArr []DataWithID[T]) error {
+	if !l.isConflictEnabled() {
+		return nil
+	}
+
+	for _, info := range dataArr {
+		rawMap, err := toStoredRawMap(info.Data)
+		if err != nil {
+			blog.Errorf("convert %s data(%+v) failed, err: %v, rid: %s", l.resType, info, err, kit.Rid)
+			return err
+		}
+
+		fields := sortedFields(rawMap)
+		cond := mapstr.MapStr{"_id": genDataKey(l.resType, subRes, info.ID)}
+		version := mapstr.MapStr{
+			common.BKResourceTypeField: l.resType,
+			"sub_resource":             subRes,
+			"data_id":                  info.ID,
+			"fields":                   fields,
+			"hash":                     hashFields(rawMap, fields),
+			common.LastTimeField:       time.Now(),
+		}
+
+		if err = mongodb.Client().Table(dataVersionTable).Upsert(kit.Ctx, cond, version); err != nil {
+			blog.Errorf("save %s data version failed, err: %v, cond: %+v, rid: %s", l.resType, err, cond, kit.Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteDataVersions deletes the versions of the deleted sync data
+func (l *dataWithIDLogics[T]) deleteDataVersions(kit *util.Kit, subRes string, ids []int64) error {
+	if !l.isConflictEnabled() || len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = genDataKey(l.resType, subRes, id)
+	}
+
+	cond := mapstr.MapStr{"_id": mapstr.MapStr{common.BKDBIN: keys}}
+	if err := mongodb.Client().Table(dataVersionTable).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete %s data versions failed, err: %v, cond: %+v, rid: %s", l.resType, err, cond, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// filterConflictData detects the conflicts between the sync data and the local change of the destination data,
+// handles the conflicts by the conflict policy, and returns the ids of the data that can be written.
+// srcDataMap is the id to sync data map for update, it is nil for deletion.
+func (l *dataWithIDLogics[T]) filterConflictData(kit *util.Kit, subRes string, ids []int64,
+	srcDataMap map[int64]T) ([]int64, error) {
+
+	if !l.isConflictEnabled() || len(ids) == 0 {
+		return ids, nil
+	}
+
+	localMap, err := l.getLocalData(kit, subRes, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = genDataKey(l.resType, subRes, id)
+	}
+	keyCond := mapstr.MapStr{"_id": mapstr.MapStr{common.BKDBIN: keys}}
+
+	versions := make([]dataVersion, 0)
+	if err = mongodb.Client().Table(dataVersionTable).Find(keyCond).Fields("_id", "fields", "hash").All(kit.Ctx,
+		&versions); err != nil {
+		blog.Errorf("get %s data versions failed, err: %v, cond: %+v, rid: %s", l.resType, err, keyCond, kit.Rid)
+		return nil, err
+	}
+	versionMap := make(map[string]dataVersion)
+	for _, version := range versions {
+		versionMap[version.ID] = version
+	}
+
+	conflicts := make([]types.SyncConflict, 0)
+	err = mongodb.Client().Table(ConflictTable).Find(keyCond).Fields("_id", "status", "resolution", "src_hash",
+		"dest_hash").All(kit.Ctx, &conflicts)
+	if err != nil {
+		blog.Errorf("get %s sync conflicts failed, err: %v, cond: %+v, rid: %s", l.resType, err, keyCond, kit.Rid)
+		return nil, err
+	}
+	conflictMap := make(map[string]types.SyncConflict)
+	for _, conflict := range conflicts {
+		conflictMap[conflict.ID] = conflict
+	}
+
+	allowedIDs := make([]int64, 0)
+	audits := make([]metadata.AuditLog, 0)
+	for _, id := range ids {
+		local, exists := localMap[id]
+		version, hasVersion := versionMap[genDataKey(l.resType, subRes, id)]
+		if !exists || !hasVersion {
+			allowedIDs = append(allowedIDs, id)
+			continue
+		}
+
+		src, isUpdate := srcDataMap[id]
+		conflict, err := l.detectConflict(subRes, id, version, local, src, isUpdate)
+		if err != nil {
+			blog.Errorf("detect %s data %d conflict failed, err: %v, rid: %s", l.resType, id, err, kit.Rid)
+			return nil, err
+		}
+
+		if conflict == nil {
+			allowedIDs = append(allowedIDs, id)
+			continue
+		}
+
+		allowed, err := l.handleConflict(kit, conflict, conflictMap, src, local)
+		if err != nil {
+			return nil, err
+		}
+
+		if allowed {
+			allowedIDs = append(allowedIDs, id)
+			audits = append(audits, l.genConflictAudit(kit, conflict, src, local))
+		}
+	}
+
+	if err = l.saveConflictAudits(kit, audits); err != nil {
+		return nil, err
+	}
+
+	return allowedIDs, nil
+}
+
+// detectConflict detects if the local data has been changed since last sync, returns nil if there is no conflict.
+// isUpdate specifies if the sync data is an update, otherwise it is a deletion.
+func (l *dataWithIDLogics[T]) detectConflict(subRes string, id int64, version dataVersion, local, src T,
+	isUpdate bool) (*types.SyncConflict, error) {
+
+	localRaw, err := toRawMap(local)
+	if err != nil {
+		return nil, fmt.Errorf("convert local data failed, err: %v", err)
+	}
+
+	localHash := hashFields(localRaw, version.Fields)
+	if localHash == version.Hash {
+		return nil, nil
+	}
+
+	// the local data has been changed since last sync
+	conflict := &types.SyncConflict{
+		ID:       genDataKey(l.resType, subRes, id),
+		ResType:  l.resType,
+		SubRes:   subRes,
+		DataID:   id,
+		Action:   types.ConflictActionDelete,
+		DestHash: localHash,
+	}
+
+	if !isUpdate {
+		return conflict, nil
+	}
+
+	srcRaw, err := toStoredRawMap(src)
+	if err != nil {
+		return nil, fmt.Errorf("convert sync data failed, err: %v", err)
+	}
+
+	srcFields := sortedFields(srcRaw)
+	conflict.SrcHash = hashFields(srcRaw, srcFields)
+	if conflict.SrcHash == hashFields(localRaw, srcFields) {
+		// the local data is changed to the same value as the sync data
+		return nil, nil
+	}
+	conflict.Action = types.ConflictActionUpdate
+	return conflict, nil
+}
+
+// getLocalData gets the local data of the destination environment by ids
+func (l *dataWithIDLogics[T]) getLocalData(kit *util.Kit, subRes string, ids []int64) (map[int64]T, error) {
+	cond := mapstr.MapStr{l.idField: mapstr.MapStr{common.BKDBIN: ids}}
+	dataArr := make([]T, 0)
+	if err := mongodb.Client().Table(l.table(subRes)).Find(cond).All(kit.Ctx, &dataArr); err != nil {
+		blog.Errorf("get %s local data failed, err: %v, cond: %+v, rid: %s", l.resType, err, cond, kit.Rid)
+		return nil, err
+	}
+
+	dataMap := make(map[int64]T)
+	for _, data := range dataArr {
+		id, err := l.getID(data, l.idField)
+		if err != nil {
+			blog.Errorf("get %s local data id failed, err: %v, data: %+v, rid: %s", l.resType, err, data, kit.Rid)
+			continue
+		}
+		dataMap[id] = data
+	}
+	return dataMap, nil
+}
+
+// handleConflict handles the conflict by the conflict policy, returns if the sync data can be written
+func (l *dataWithIDLogics[T]) handleConflict(kit *util.Kit, conflict *types.SyncConflict,
+	conflictMap map[string]types.SyncConflict, src, local T) (bool, error) {
+
+	// the same conflict has been handled before, follow its resolution
+	prev, exists := conflictMap[conflict.ID]
+	if exists && prev.SrcHash == conflict.SrcHash && prev.DestHash == conflict.DestHash {
+		return prev.Status == types.ConflictResolved && prev.Resolution == types.ResolutionSrc, nil
+	}
+
+	allowed := l.resolveConflictByPolicy(conflict)
+
+	blog.Warnf("%s data %d is changed locally since last sync, %s it by policy %s, rid: %s", l.resType,
+		conflict.DataID, conflict.Action, l.conflictPolicy, kit.Rid)
+
+	var srcData any
+	if conflict.Action == types.ConflictActionUpdate {
+		srcData = src
+	}
+
+	now := metadata.Now()
+	cond := mapstr.MapStr{"_id": conflict.ID}
+	data := mapstr.MapStr{
+		common.BKResourceTypeField: conflict.ResType,
+		"sub_resource":             conflict.SubRes,
+		"data_id":                  conflict.DataID,
+		"action":                   conflict.Action,
+		"status":                   conflict.Status,
+		"resolution":               conflict.Resolution,
+		"src_data":                 srcData,
+		"dest_data":                local,
+		"src_hash":                 conflict.SrcHash,
+		"dest_hash":                conflict.DestHash,
+		common.CreateTimeField:     now,
+		common.LastTimeField:       now,
+	}
+
+	if err := mongodb.Client().Table(ConflictTable).Upsert(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("save %s sync conflict failed, err: %v, cond: %+v, rid: %s", l.resType, err, cond, kit.Rid)
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+// resolveConflictByPolicy sets the status and resolution of the new conflict by the conflict policy, returns if the
+// sync data can be written
+func (l *resLogicsConfig) resolveConflictByPolicy(conflict *types.SyncConflict) bool {
+	switch l.conflictPolicy {
+	case options.ConflictPolicySrcWins:
+		conflict.Status, conflict.Resolution = types.ConflictResolved, types.ResolutionSrc
+		return true
+	case options.ConflictPolicyDestWins:
+		conflict.Status, conflict.Resolution = types.ConflictResolved, types.ResolutionDest
+	default:
+		conflict.Status = types.ConflictPending
+	}
+	return false
+}
+
+// genConflictAudit generates the audit log of the locally changed data that is overwritten by the sync data
+func (l *dataWithIDLogics[T]) genConflictAudit(kit *util.Kit, conflict *types.SyncConflict, src,
+	local T) metadata.AuditLog {
+
+	resInfo := conflictResInfoMap[l.resType]
+	objID := resInfo.objID
+	if objID == "" {
+		objID = conflict.SubRes
+	}
+
+	localData, err := mapstr.NewFromInterface(local)
+	if err != nil {
+		blog.Errorf("convert %s local data(%+v) failed, err: %v, rid: %s", l.resType, local, err, kit.Rid)
+	}
+
+	audit := metadata.AuditLog{
+		AuditType:       resInfo.auditType,
+		SupplierAccount: commonutil.GetStrByInterface(localData[common.BkSupplierAccount]),
+		User:            common.CCSystemOperatorUserName,
+		ResourceType:    resInfo.auditRes,
+		Action:          metadata.AuditDelete,
+		OperateFrom:     metadata.FromSynchronizer,
+		OperationDetail: &metadata.InstanceOpDetail{
+			BasicOpDetail: metadata.BasicOpDetail{Details: &metadata.BasicContent{PreData: localData}},
+			ModelID:       objID,
+		},
+		OperationTime: metadata.Now(),
+		ResourceID:    conflict.DataID,
+		ResourceName:  commonutil.GetStrByInterface(localData[resInfo.nameField]),
+		RequestID:     kit.Rid,
+	}
+
+	if bizID, err := commonutil.GetInt64ByInterface(localData[common.BKAppIDField]); err == nil {
+		audit.BusinessID = bizID
+	}
+
+	if conflict.Action == types.ConflictActionUpdate {
+		audit.Action = metadata.AuditUpdate
+		srcData, err := mapstr.NewFromInterface(src)
+		if err != nil {
+			blog.Errorf("convert %s sync data(%+v) failed, err: %v, rid: %s", l.resType, src, err, kit.Rid)
+		}
+		audit.OperationDetail.(*metadata.InstanceOpDetail).Details.UpdateFields = srcData
+	}
+
+	return audit
+}
+
+// saveConflictAudits saves the audit logs of the locally changed data that is overwritten by the sync data
+func (l *resLogicsConfig) saveConflictAudits(kit *util.Kit, audits []metadata.AuditLog) error {
+	if len(audits) == 0 {
+		return nil
+	}
+
+	if err := l.auditCli.SaveAuditLog(kit.Ctx, kit.Header, audits...); err != nil {
+		blog.Errorf("save sync conflict audit logs failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// ParseConflictData parses the sync data of the conflict into the actual data type that can be written
+func (l *dataWithIDLogics[T]) ParseConflictData(kit *util.Kit, conflictID string) (any, error) {
+	conflict := new(struct {
+		DataID  int64 `bson:"data_id"`
+		SrcData T     `bson:"src_data"`
+	})
+
+	cond := mapstr.MapStr{"_id": conflictID}
+	if err := mongodb.Client().Table(ConflictTable).Find(cond).Fields("data_id", "src_data").One(kit.Ctx,
+		conflict); err != nil {
+		blog.Errorf("get sync conflict %s data failed, err: %v, rid: %s", conflictID, err, kit.Rid)
+		return nil, err
+	}
+
+	return []DataWithID[T]{{ID: conflict.DataID, Data: conflict.SrcData}}, nil
+}
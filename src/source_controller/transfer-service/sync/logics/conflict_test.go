@@ -0,0 +1,228 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	ccErr "configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/source_controller/transfer-service/sync/util"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// genTestDataVersion generates the version of the written sync data in the same way as saveDataVersions
+func genTestDataVersion[T any](t *testing.T, data T) dataVersion {
+	rawMap, err := toStoredRawMap(data)
+	require.NoError(t, err)
+	fields := sortedFields(rawMap)
+	return dataVersion{Fields: fields, Hash: hashFields(rawMap, fields)}
+}
+
+func TestDetectConflictOfSyncedHost(t *testing.T) {
+	// the host ips are strings in the sync data json, and are converted to arrays when the sync data is parsed
+	raw := json.RawMessage(`{"bk_host_id":1,"bk_host_innerip":"127.0.0.1,127.0.0.2","bk_host_name":"host1",` +
+		`"operator":"admin","bk_cloud_id":0,"bk_supplier_account":"0"}`)
+	arr, err := convertDataArr[metadata.HostMapStr]([]json.RawMessage{raw}, "")
+	require.NoError(t, err)
+	src, err := hostLgc.parseData(arr[0], nil, nil)
+	require.NoError(t, err)
+	version := genTestDataVersion(t, src)
+
+	// the written host is read from db with the ips converted to strings again
+	db := memory.NewDB()
+	ctx := context.Background()
+	require.NoError(t, db.Table(common.BKTableNameBaseHost).Insert(ctx, []metadata.HostMapStr{src}))
+	locals := make([]metadata.HostMapStr, 0)
+	require.NoError(t, db.Table(common.BKTableNameBaseHost).Find(nil).All(ctx, &locals))
+	require.Len(t, locals, 1)
+	require.Equal(t, "127.0.0.1,127.0.0.2", locals[0][common.BKHostInnerIPField])
+
+	// the hash of the sync data json form differs from the local data, it must not be used as the version
+	srcRaw, err := toRawMap(src)
+	require.NoError(t, err)
+	localRaw, err := toRawMap(locals[0])
+	require.NoError(t, err)
+	require.NotEqual(t, hashFields(srcRaw, version.Fields), hashFields(localRaw, version.Fields))
+
+	lgc := newDataWithIDLogics(&resLogicsConfig{resType: types.Host}, hostLgc)
+	conflict, err := lgc.detectConflict("", 1, version, locals[0], src, true)
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+
+	// the host is changed locally since last sync
+	locals[0][common.BKHostNameField] = "changed"
+	conflict, err = lgc.detectConflict("", 1, version, locals[0], src, true)
+	require.NoError(t, err)
+	require.NotNil(t, conflict)
+	require.Equal(t, types.ConflictActionUpdate, conflict.Action)
+	require.Equal(t, genDataKey(types.Host, "", 1), conflict.ID)
+	require.NotEmpty(t, conflict.SrcHash)
+	require.NotEmpty(t, conflict.DestHash)
+}
+
+func TestDetectConflict(t *testing.T) {
+	lgc := newDataWithIDLogics(&resLogicsConfig{resType: types.Set}, setLgc)
+	synced := mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "set", common.BKAppIDField: int64(1),
+		common.LastTimeField: "2024-01-01"}
+	version := genTestDataVersion(t, synced)
+
+	testCases := []struct {
+		name     string
+		local    mapstr.MapStr
+		src      mapstr.MapStr
+		isUpdate bool
+		action   types.ConflictAction
+	}{{
+		name:     "local data is not changed",
+		local:    synced.Clone(),
+		src:      mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "new", common.BKAppIDField: int64(1)},
+		isUpdate: true,
+	}, {
+		name: "only time field of local data is changed",
+		local: mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "set", common.BKAppIDField: int64(1),
+			common.LastTimeField: "2024-02-01"},
+		isUpdate: false,
+	}, {
+		name: "local data is changed to the sync data",
+		local: mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "new", common.BKAppIDField: int64(1),
+			common.LastTimeField: "2024-02-01"},
+		src:      mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "new", common.BKAppIDField: int64(1)},
+		isUpdate: true,
+	}, {
+		name: "local data is changed before update",
+		local: mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "local",
+			common.BKAppIDField: int64(1)},
+		src:      mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "new", common.BKAppIDField: int64(1)},
+		isUpdate: true,
+		action:   types.ConflictActionUpdate,
+	}, {
+		name: "local data is changed before delete",
+		local: mapstr.MapStr{common.BKSetIDField: int64(2), common.BKSetNameField: "local",
+			common.BKAppIDField: int64(1)},
+		isUpdate: false,
+		action:   types.ConflictActionDelete,
+	}}
+
+	for _, testCase := range testCases {
+		conflict, err := lgc.detectConflict("", 2, version, testCase.local, testCase.src, testCase.isUpdate)
+		require.NoError(t, err, testCase.name)
+		if testCase.action == "" {
+			require.Nil(t, conflict, testCase.name)
+			continue
+		}
+
+		require.NotNil(t, conflict, testCase.name)
+		require.Equal(t, testCase.action, conflict.Action, testCase.name)
+		require.Equal(t, int64(2), conflict.DataID, testCase.name)
+		if testCase.isUpdate {
+			require.NotEmpty(t, conflict.SrcHash, testCase.name)
+		} else {
+			require.Empty(t, conflict.SrcHash, testCase.name)
+		}
+	}
+}
+
+func TestResolveConflictByPolicy(t *testing.T) {
+	testCases := []struct {
+		policy     options.ConflictPolicy
+		allowed    bool
+		status     types.ConflictStatus
+		resolution types.ConflictResolution
+	}{
+		{options.ConflictPolicySrcWins, true, types.ConflictResolved, types.ResolutionSrc},
+		{options.ConflictPolicyDestWins, false, types.ConflictResolved, types.ResolutionDest},
+		{options.ConflictPolicyQueue, false, types.ConflictPending, ""},
+	}
+
+	for _, testCase := range testCases {
+		lgc := &resLogicsConfig{resType: types.Set, conflictPolicy: testCase.policy}
+		conflict := &types.SyncConflict{ID: "set::1", Action: types.ConflictActionUpdate}
+		require.Equal(t, testCase.allowed, lgc.resolveConflictByPolicy(conflict), testCase.policy)
+		require.Equal(t, testCase.status, conflict.Status, testCase.policy)
+		require.Equal(t, testCase.resolution, conflict.Resolution, testCase.policy)
+	}
+}
+
+type fakeAuditClient struct {
+	logs []metadata.AuditLog
+	err  ccErr.CCErrorCoder
+}
+
+// SaveAuditLog records the saved audit logs
+func (f *fakeAuditClient) SaveAuditLog(_ context.Context, _ http.Header, logs ...metadata.AuditLog) ccErr.CCErrorCoder {
+	if f.err != nil {
+		return f.err
+	}
+	f.logs = append(f.logs, logs...)
+	return nil
+}
+
+// SearchAuditLog is not used
+func (f *fakeAuditClient) SearchAuditLog(context.Context, http.Header, metadata.QueryCondition) (
+	*metadata.AuditQueryResult, ccErr.CCErrorCoder) {
+	return nil, nil
+}
+
+// SearchAuditLogArchive is not used
+func (f *fakeAuditClient) SearchAuditLogArchive(context.Context, http.Header, *metadata.SearchAuditLogArchiveOption) (
+	*metadata.SearchAuditLogArchiveResult, ccErr.CCErrorCoder) {
+	return nil, nil
+}
+
+func TestSaveConflictAudits(t *testing.T) {
+	auditCli := new(fakeAuditClient)
+	lgc := newDataWithIDLogics(&resLogicsConfig{resType: types.ObjectInstance, auditCli: auditCli}, bizLgc)
+	kit := &util.Kit{Rid: "rid", Header: make(http.Header), Ctx: context.Background()}
+
+	require.NoError(t, lgc.saveConflictAudits(kit, nil))
+	require.Empty(t, auditCli.logs)
+
+	conflict := &types.SyncConflict{ID: "object_instance:switch:3", ResType: types.ObjectInstance, SubRes: "switch",
+		DataID: 3, Action: types.ConflictActionUpdate}
+	local := mapstr.MapStr{common.BKInstIDField: int64(3), common.BKInstNameField: "local", common.BKAppIDField: 5,
+		common.BkSupplierAccount: "0"}
+	src := mapstr.MapStr{common.BKInstIDField: int64(3), common.BKInstNameField: "sync"}
+	audit := lgc.genConflictAudit(kit, conflict, src, local)
+	require.Equal(t, metadata.ModelInstanceType, audit.AuditType)
+	require.Equal(t, metadata.AuditUpdate, audit.Action)
+	require.Equal(t, metadata.FromSynchronizer, audit.OperateFrom)
+	require.Equal(t, int64(3), audit.ResourceID)
+	require.Equal(t, "local", audit.ResourceName)
+	require.Equal(t, int64(5), audit.BusinessID)
+	detail, ok := audit.OperationDetail.(*metadata.InstanceOpDetail)
+	require.True(t, ok)
+	require.Equal(t, "switch", detail.ModelID)
+	require.Equal(t, "sync", detail.Details.UpdateFields[common.BKInstNameField])
+
+	require.NoError(t, lgc.saveConflictAudits(kit, []metadata.AuditLog{audit}))
+	require.Len(t, auditCli.logs, 1)
+
+	auditCli.err = ccErr.New(common.CCErrAuditSaveLogFailed, "save audit log failed")
+	require.Equal(t, auditCli.err, lgc.saveConflictAudits(kit, []metadata.AuditLog{audit}))
+	require.Len(t, auditCli.logs, 1)
+}
@@ -115,6 +115,15 @@ func (l *dataWithIDLogics[T]) convertToDataWithIDArr(isSrc bool, srcEnv, subRes
 
 // ListData list data
 func (l *dataWithIDLogics[T]) ListData(kit *util.Kit, opt *types.ListDataOpt) (*types.ListDataRes, error) {
+	// sub resource that is out of the sync scope has no data, so that its data is removed from the destination
+	if !l.metadata.IsSubResInScope(l.resType, opt.SubRes) {
+		return &types.ListDataRes{
+			IsAll:     true,
+			Data:      make([]T, 0),
+			NextStart: make(map[string]int64),
+		}, nil
+	}
+
 	// generate id condition by start and end options
 	idCond := mapstr.MapStr{common.BKDBGT: 0}
 	if len(opt.Start) > 0 {
@@ -125,7 +134,7 @@ func (l *dataWithIDLogics[T]) ListData(kit *util.Kit, opt *types.ListDataOpt) (*
 		idCond[common.BKDBLTE] = opt.End[l.idField]
 	}
 
-	cond := l.metadata.AddListCond(l.resType, mapstr.MapStr{
+	cond := l.metadata.AddListCond(l.resType, opt.SubRes, mapstr.MapStr{
 		l.idField: idCond,
 	})
 
@@ -162,11 +171,53 @@ func (l *dataWithIDLogics[T]) ListData(kit *util.Kit, opt *types.ListDataOpt) (*
 
 	return &types.ListDataRes{
 		IsAll:     len(dataArr) < common.BKMaxLimitSize,
-		Data:      dataArr,
+		Data:      l.filterHostScope(opt.SubRes, dataArr, l.metadata.IsHostInScope, kit.Rid),
 		NextStart: map[string]int64{l.idField: lastID},
 	}, nil
 }
 
+// filterHostScope filter out the hosts and host associations that are out of the sync scope, the paging is still
+// based on the listed data, so that the next start id and whether all data is listed are not affected
+func (l *dataWithIDLogics[T]) filterHostScope(subRes string, dataArr []T, isHostInScope func(hostID int64) bool,
+	rid string) []T {
+
+	if l.resType != types.Host && l.resType != types.InstAsst {
+		return dataArr
+	}
+
+	res := make([]T, 0, len(dataArr))
+	for _, data := range dataArr {
+		hostIDs := make([]int64, 0)
+		if l.resType == types.Host {
+			hostID, err := l.getID(data, l.idField)
+			if err != nil {
+				blog.Errorf("get host id failed, skip it, err: %v, data: %+v, rid: %s", err, data, rid)
+				continue
+			}
+			hostIDs = append(hostIDs, hostID)
+		} else {
+			idMap, err := l.getRelatedIDs(subRes, data)
+			if err != nil {
+				blog.Errorf("get data(%+v) related ids failed, skip it, err: %v, rid: %s", data, err, rid)
+				continue
+			}
+			hostIDs = idMap[types.Host]
+		}
+
+		inScope := true
+		for _, hostID := range hostIDs {
+			if !isHostInScope(hostID) {
+				inScope = false
+				break
+			}
+		}
+		if inScope {
+			res = append(res, data)
+		}
+	}
+	return res
+}
+
 // CompareData compare src data with dest data, returns diff data and remaining src data
 func (l *dataWithIDLogics[T]) CompareData(kit *util.Kit, subRes string, srcInfo *types.FullSyncTransData,
 	destInfo *types.ListDataRes) (*types.CompDataRes, error) {
@@ -297,7 +348,7 @@ func (l *dataWithIDLogics[T]) InsertData(kit *util.Kit, subRes string, data any)
 		blog.Errorf("insert %s data(%+v) failed, err: %v, rid: %s", table, insertData, err, kit.Rid)
 		return err
 	}
-	return nil
+	return l.saveDataVersions(kit, subRes, dataArr)
 }
 
 // UpdateData update data
@@ -311,6 +362,11 @@ func (l *dataWithIDLogics[T]) UpdateData(kit *util.Kit, subRes string, data any)
 		return nil
 	}
 
+	dataArr, err := l.filterConflictUpdateData(kit, subRes, dataArr)
+	if err != nil {
+		return err
+	}
+
 	table := l.table(subRes)
 	for _, info := range dataArr {
 		cond := mapstr.MapStr{l.idField: info.ID}
@@ -320,7 +376,41 @@ func (l *dataWithIDLogics[T]) UpdateData(kit *util.Kit, subRes string, data any)
 			return err
 		}
 	}
-	return nil
+	return l.saveDataVersions(kit, subRes, dataArr)
+}
+
+// filterConflictUpdateData filters out the update data that conflicts with the local change and can not be written
+func (l *dataWithIDLogics[T]) filterConflictUpdateData(kit *util.Kit, subRes string,
+	dataArr []DataWithID[T]) ([]DataWithID[T], error) {
+
+	if !l.isConflictEnabled() {
+		return dataArr, nil
+	}
+
+	ids := make([]int64, len(dataArr))
+	srcDataMap := make(map[int64]T)
+	for i, info := range dataArr {
+		ids[i] = info.ID
+		srcDataMap[info.ID] = info.Data
+	}
+
+	allowedIDs, err := l.filterConflictData(kit, subRes, ids, srcDataMap)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedIDMap := make(map[int64]struct{})
+	for _, id := range allowedIDs {
+		allowedIDMap[id] = struct{}{}
+	}
+
+	allowedData := make([]DataWithID[T], 0)
+	for _, info := range dataArr {
+		if _, exists := allowedIDMap[info.ID]; exists {
+			allowedData = append(allowedData, info)
+		}
+	}
+	return allowedData, nil
 }
 
 // DeleteData delete data
@@ -338,10 +428,20 @@ func (l *dataWithIDLogics[T]) DeleteData(kit *util.Kit, subRes string, data any)
 		return fmt.Errorf("data type %T is invalid", data)
 	}
 
+	ids, err := l.filterConflictData(kit, subRes, ids, nil)
+	if err != nil {
+		return err
+	}
+
 	if len(ids) == 0 {
 		return nil
 	}
 
+	return l.deleteDataByIDs(kit, subRes, ids)
+}
+
+// deleteDataByIDs delete data by ids without conflict detection
+func (l *dataWithIDLogics[T]) deleteDataByIDs(kit *util.Kit, subRes string, ids []int64) error {
 	cond := mapstr.MapStr{
 		l.idField: mapstr.MapStr{common.BKDBIN: ids},
 	}
@@ -352,5 +452,5 @@ func (l *dataWithIDLogics[T]) DeleteData(kit *util.Kit, subRes string, data any)
 		blog.Errorf("delete %s data failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
 		return err
 	}
-	return nil
+	return l.deleteDataVersions(kit, subRes, ids)
 }
@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterHostScope(t *testing.T) {
+	inScope := func(hostID int64) bool {
+		return hostID == 1 || hostID == 2
+	}
+
+	hostLogics := newDataWithIDLogics(&resLogicsConfig{resType: types.Host}, hostLgc)
+	hosts := []metadata.HostMapStr{{"bk_host_id": 1}, {"bk_host_id": 3}, {"bk_host_id": 2}, {"bk_host_id": 4}}
+	require.Equal(t, []metadata.HostMapStr{{"bk_host_id": 1}, {"bk_host_id": 2}},
+		hostLogics.filterHostScope("", hosts, inScope, ""))
+
+	asstLogics := newDataWithIDLogics(&resLogicsConfig{resType: types.InstAsst}, instAsstLgc)
+	assts := []metadata.InstAsst{
+		{ID: 1, ObjectID: "host", InstID: 1, AsstObjectID: "switch", AsstInstID: 3},
+		{ID: 2, ObjectID: "switch", InstID: 3, AsstObjectID: "host", AsstInstID: 3},
+		{ID: 3, ObjectID: "switch", InstID: 3, AsstObjectID: "router", AsstInstID: 4},
+		{ID: 4, ObjectID: "host", InstID: 2, AsstObjectID: "host", AsstInstID: 4},
+	}
+	filtered := asstLogics.filterHostScope("switch", assts, inScope, "")
+	require.Len(t, filtered, 2)
+	require.Equal(t, int64(1), filtered[0].ID)
+	require.Equal(t, int64(3), filtered[1].ID)
+
+	// the other resources are not filtered by host scope
+	bizLogics := newDataWithIDLogics(&resLogicsConfig{resType: types.Biz}, hostLgc)
+	require.Equal(t, hosts, bizLogics.filterHostScope("", hosts, inScope, ""))
+}
@@ -91,7 +91,7 @@ func (o *objInstLogics) InsertData(kit *util.Kit, subRes string, data any) error
 		blog.Errorf("create object instance mappings(%+v) failed, err: %v, rid: %s", mappings, err, kit.Rid)
 		return err
 	}
-	return nil
+	return o.saveDataVersions(kit, subRes, dataArr)
 }
 
 // DeleteData delete data
@@ -109,26 +109,21 @@ func (o *objInstLogics) DeleteData(kit *util.Kit, subRes string, data any) error
 		return fmt.Errorf("data type %T is invalid", data)
 	}
 
+	ids, err := o.filterConflictData(kit, subRes, ids, nil)
+	if err != nil {
+		return err
+	}
+
 	if len(ids) == 0 {
 		return nil
 	}
 
-	if err := instancemapping.Delete(kit.Ctx, ids); err != nil {
+	if err = instancemapping.Delete(kit.Ctx, ids); err != nil {
 		blog.Errorf("delete object instance mapping failed, err: %v, inst ids: %+v, rid: %s", err, ids, kit.Rid)
 		return err
 	}
 
-	cond := mapstr.MapStr{
-		common.BKInstIDField: mapstr.MapStr{common.BKDBIN: ids},
-	}
-
-	table := o.table(subRes)
-	err := mongodb.Client().Table(table).Delete(kit.Ctx, cond)
-	if err != nil {
-		blog.Errorf("delete %s data failed, err: %v, cond: %+v, rid: %s", table, err, cond, kit.Rid)
-		return err
-	}
-	return o.dataWithIDLogics.DeleteData(kit, subRes, data)
+	return o.deleteDataByIDs(kit, subRes, ids)
 }
 
 var instAsstLgc = &dataWithIDLgc[metadata.InstAsst]{
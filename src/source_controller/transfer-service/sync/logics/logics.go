@@ -20,6 +20,7 @@ package logics
 
 import (
 	"configcenter/pkg/synchronize/types"
+	"configcenter/src/apimachinery/coreservice/auditlog"
 	"configcenter/src/source_controller/transfer-service/app/options"
 	"configcenter/src/source_controller/transfer-service/sync/metadata"
 	"configcenter/src/source_controller/transfer-service/sync/util"
@@ -36,6 +37,7 @@ type Logics interface {
 	InsertData(kit *util.Kit, subRes string, data any) error
 	UpdateData(kit *util.Kit, subRes string, data any) error
 	DeleteData(kit *util.Kit, subRes string, data any) error
+	ParseConflictData(kit *util.Kit, conflictID string) (any, error)
 }
 
 // New creates a new resource type to resource sync logics map
@@ -62,6 +64,10 @@ type LogicsConfig struct {
 	Metadata      *metadata.Metadata
 	IDRuleMap     map[types.ResType]map[string][]options.IDRuleInfo
 	SrcInnerIDMap map[string]*options.InnerDataIDConf
+	// ConflictPolicy is the policy to handle the conflict between sync data and local change, empty means no detection
+	ConflictPolicy options.ConflictPolicy
+	// AuditCli is used to save the audit logs of the local changes that are overwritten by the sync data
+	AuditCli auditlog.AuditClientInterface
 }
 
 func (c *LogicsConfig) genResLgcConf(resType types.ResType) *resLogicsConfig {
@@ -70,6 +76,9 @@ func (c *LogicsConfig) genResLgcConf(resType types.ResType) *resLogicsConfig {
 		metadata:      c.Metadata,
 		idRuleMap:     c.IDRuleMap,
 		srcInnerIDMap: c.SrcInnerIDMap,
+
+		conflictPolicy: c.ConflictPolicy,
+		auditCli:       c.AuditCli,
 	}
 }
 
@@ -79,6 +88,9 @@ type resLogicsConfig struct {
 	metadata      *metadata.Metadata
 	idRuleMap     map[types.ResType]map[string][]options.IDRuleInfo
 	srcInnerIDMap map[string]*options.InnerDataIDConf
+
+	conflictPolicy options.ConflictPolicy
+	auditCli       auditlog.AuditClientInterface
 }
 
 // ResType get resource type
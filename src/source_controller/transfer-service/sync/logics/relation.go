@@ -141,7 +141,7 @@ func (l *relationLogics[T]) ListData(kit *util.Kit, opt *types.ListDataOpt) (*ty
 	if len(andConds) > 0 {
 		cond[common.BKDBAND] = andConds
 	}
-	cond = l.metadata.AddListCond(l.resType, cond)
+	cond = l.metadata.AddListCond(l.resType, opt.SubRes, cond)
 
 	// list data from db
 	dataArr := make([]T, 0)
@@ -368,3 +368,8 @@ func (l *relationLogics[T]) DeleteData(kit *util.Kit, subRes string, data any) e
 	}
 	return nil
 }
+
+// ParseConflictData relation has no local change conflict, so there is no conflict data to parse
+func (l *relationLogics[T]) ParseConflictData(kit *util.Kit, conflictID string) (any, error) {
+	return nil, fmt.Errorf("%s does not support sync conflict", l.resType)
+}
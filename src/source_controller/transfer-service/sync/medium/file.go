@@ -0,0 +1,260 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+	daltypes "configcenter/src/storage/dal/types"
+	"configcenter/src/storage/driver/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// bundleVersion is the version of the bundle file format
+	bundleVersion = 1
+	// bundleFileSuffix is the suffix of the gzip compressed bundle data file
+	bundleFileSuffix = ".bundle.gz"
+	// manifestFileSuffix is the suffix of the bundle manifest file
+	manifestFileSuffix = ".manifest.json"
+	// stagingDir is the directory that stores the sync data that is not sealed into bundles yet
+	stagingDir = ".staging"
+)
+
+// FileMediumOption is the option of the file transfer medium
+type FileMediumOption struct {
+	// Dir is the directory that stores the bundle files
+	Dir string
+	// Secret is the secret that is used to sign and verify the bundle manifests
+	Secret string
+	// Name is the transfer service name, source environment uses it as the name of the bundles
+	Name string
+	// MaxRecords is the max number of sync data in one bundle
+	MaxRecords int
+	// FlushInterval is the max duration that the sync data waits before it is sealed into a bundle
+	FlushInterval time.Duration
+}
+
+// NewFileMedium new file transfer medium client, it writes the pushed sync data into signed, compressed,
+// sequence-numbered bundle files in a directory, and imports the bundles in order for pulling, so that the sync data
+// can be carried across isolated networks by removable media.
+func NewFileMedium(opt *FileMediumOption) (ClientI, error) {
+	if opt == nil || opt.Dir == "" || opt.Secret == "" || opt.Name == "" {
+		return nil, errors.New("file medium dir, secret and name must be set")
+	}
+
+	if err := os.MkdirAll(filepath.Join(opt.Dir, stagingDir), 0o750); err != nil {
+		blog.Errorf("create bundle staging dir failed, dir: %s, err: %v", opt.Dir, err)
+		return nil, err
+	}
+
+	index := daltypes.Index{
+		Name: "idx_resType_subRes_isIncrement_seq_index",
+		Keys: bson.D{
+			{Key: "res_type", Value: 1},
+			{Key: "sub_res", Value: 1},
+			{Key: "is_increment", Value: 1},
+			{Key: "seq", Value: 1},
+			{Key: "index", Value: 1},
+		},
+		Background: true,
+	}
+	db := mongodb.Client()
+	if err := db.Table(common.BKTableNameSyncBundleData).CreateIndex(context.Background(), index); err != nil &&
+		!db.IsDuplicatedError(err) {
+		blog.Errorf("create %s index failed, err: %v", common.BKTableNameSyncBundleData, err)
+	}
+
+	f := &fileMediumCli{
+		opt: opt,
+		db:  db,
+	}
+
+	if err := f.loadStaging(); err != nil {
+		return nil, err
+	}
+
+	go f.loopSealBundle()
+
+	return f, nil
+}
+
+// fileMediumCli defines the file transfer medium client
+type fileMediumCli struct {
+	opt *FileMediumOption
+	// db stores the bundle progresses and the imported sync data
+	db dal.DB
+
+	// writeLock protects the staging data of the pushed sync data
+	writeLock    sync.Mutex
+	stagingCount int
+	stagingStart time.Time
+
+	// readLock protects the import of the bundles and the pulled data
+	readLock   sync.Mutex
+	lastImport time.Time
+}
+
+// BundleManifest is the manifest of a bundle, it describes and signs the bundle data file
+type BundleManifest struct {
+	Version     int       `json:"version"`
+	Name        string    `json:"name"`
+	Seq         int64     `json:"seq"`
+	DataFile    string    `json:"data_file"`
+	RecordCount int       `json:"record_count"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreateTime  time.Time `json:"create_time"`
+	Signature   string    `json:"signature"`
+}
+
+// sign returns the hex encoded hmac-sha256 signature of the manifest
+func (m *BundleManifest) sign(secret string) string {
+	content := strings.Join([]string{strconv.Itoa(m.Version), m.Name, strconv.FormatInt(m.Seq, 10), m.DataFile,
+		strconv.Itoa(m.RecordCount), strconv.FormatInt(m.Size, 10), m.Checksum}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature of the manifest and the checksum of the bundle data
+func (m *BundleManifest) verify(secret string, data []byte) error {
+	if m.Version != bundleVersion {
+		return fmt.Errorf("bundle version %d is not supported", m.Version)
+	}
+
+	if !hmac.Equal([]byte(m.sign(secret)), []byte(m.Signature)) {
+		return errors.New("bundle manifest signature is invalid")
+	}
+
+	if int64(len(data)) != m.Size {
+		return fmt.Errorf("bundle data size %d is not equal to %d", len(data), m.Size)
+	}
+
+	if checksum(data) != m.Checksum {
+		return errors.New("bundle data checksum is invalid")
+	}
+	return nil
+}
+
+// bundleRecord is one pushed sync data in the bundle
+type bundleRecord struct {
+	ResType     types.ResType   `json:"resource_type"`
+	SubRes      string          `json:"sub_resource"`
+	IsIncrement bool            `json:"is_increment"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// bundleProgress is the bundle progress of one environment
+type bundleProgress struct {
+	ID      string `bson:"_id"`
+	Name    string `bson:"name"`
+	LastSeq int64  `bson:"last_seq"`
+	// MissingSeq is the sequence of the bundle that is not found while the later bundles exist
+	MissingSeq int64 `bson:"missing_seq"`
+	// LastChecksum is the checksum of the staging data that is sealed into the last bundle, it is used to skip the
+	// staging data that is already sealed but failed to be removed
+	LastChecksum string `bson:"last_checksum"`
+	// Error is the error of importing the next bundle
+	Error    string    `bson:"error"`
+	LastTime time.Time `bson:"last_time"`
+}
+
+func srcProgressID(name string) string {
+	return "src:" + name
+}
+
+func destProgressID(name string) string {
+	return "dest:" + name
+}
+
+// getProgress get bundle progress by id, returns an empty progress if it does not exist
+func (f *fileMediumCli) getProgress(ctx context.Context, id string) (*bundleProgress, error) {
+	progress := new(bundleProgress)
+	cond := map[string]interface{}{"_id": id}
+	opts := daltypes.NewFindOpts().SetWithObjectID(true)
+	err := f.db.Table(common.BKTableNameSyncBundleProgress).Find(cond, opts).One(ctx, progress)
+	if err != nil {
+		if f.db.IsNotFoundError(err) {
+			return &bundleProgress{ID: id}, nil
+		}
+		blog.Errorf("get bundle progress %s failed, err: %v", id, err)
+		return nil, err
+	}
+	return progress, nil
+}
+
+// setProgress set bundle progress
+func (f *fileMediumCli) setProgress(ctx context.Context, progress *bundleProgress) error {
+	progress.LastTime = time.Now()
+	filter := map[string]interface{}{"_id": progress.ID}
+	data := map[string]interface{}{
+		"name":          progress.Name,
+		"last_seq":      progress.LastSeq,
+		"missing_seq":   progress.MissingSeq,
+		"last_checksum": progress.LastChecksum,
+		"error":         progress.Error,
+		"last_time":     progress.LastTime,
+	}
+	if err := f.db.Table(common.BKTableNameSyncBundleProgress).Upsert(ctx, filter, data); err != nil {
+		blog.Errorf("set bundle progress failed, progress: %+v, err: %v", *progress, err)
+		return err
+	}
+	return nil
+}
+
+func bundleFileName(name string, seq int64) string {
+	return fmt.Sprintf("%s-%012d%s", name, seq, bundleFileSuffix)
+}
+
+func manifestFileName(name string, seq int64) string {
+	return fmt.Sprintf("%s-%012d%s", name, seq, manifestFileSuffix)
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes the file to a temporary file first and renames it, so that a partially written file is
+// never seen by the reader
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,321 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	daltypes "configcenter/src/storage/dal/types"
+)
+
+// importInterval is the min interval of scanning the bundle directory for new bundles
+const importInterval = 10 * time.Second
+
+// bundleData is the imported sync data that is waiting to be pulled
+type bundleData struct {
+	ID          string        `bson:"_id"`
+	Name        string        `bson:"name"`
+	Seq         int64         `bson:"seq"`
+	Index       int           `bson:"index"`
+	ResType     types.ResType `bson:"res_type"`
+	SubRes      string        `bson:"sub_res"`
+	IsIncrement bool          `bson:"is_increment"`
+	Data        string        `bson:"data"`
+	// Pulled marks the data that is pulled but not acknowledged yet, it is removed when it is acknowledged
+	Pulled bool `bson:"pulled"`
+}
+
+// PullSyncData imports the new bundles in order, then pulls the first sync data of the resource queue,
+// the last pulled data of the queue is removed if it is acknowledged, it is marked in db so that the acknowledgement
+// is not lost when the service restarts
+func (f *fileMediumCli) PullSyncData(ctx context.Context, _ http.Header, opt *types.PullSyncDataOpt) (
+	*types.PullSyncDataRes, error) {
+
+	f.readLock.Lock()
+	defer f.readLock.Unlock()
+
+	if time.Since(f.lastImport) >= importInterval {
+		// import failure does not affect the pulling of the already imported data
+		if err := f.importBundles(ctx); err != nil {
+			blog.Errorf("import sync data bundles failed, err: %v", err)
+		}
+		f.lastImport = time.Now()
+	}
+
+	queue := fmt.Sprintf("%s:%s:%v", opt.ResType, opt.SubRes, opt.IsIncrement)
+	cond := map[string]interface{}{
+		"res_type":     opt.ResType,
+		"sub_res":      opt.SubRes,
+		"is_increment": opt.IsIncrement,
+	}
+
+	if opt.Ack {
+		ackCond := map[string]interface{}{
+			"res_type":     opt.ResType,
+			"sub_res":      opt.SubRes,
+			"is_increment": opt.IsIncrement,
+			"pulled":       true,
+		}
+		if err := f.db.Table(common.BKTableNameSyncBundleData).Delete(ctx, ackCond); err != nil {
+			blog.Errorf("acknowledge %s pulled sync data failed, err: %v", queue, err)
+			return nil, err
+		}
+	}
+
+	total, err := f.db.Table(common.BKTableNameSyncBundleData).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("count %s sync data failed, err: %v", queue, err)
+		return nil, err
+	}
+
+	if total == 0 {
+		return &types.PullSyncDataRes{}, nil
+	}
+
+	data := new(bundleData)
+	opts := daltypes.NewFindOpts().SetWithObjectID(true)
+	err = f.db.Table(common.BKTableNameSyncBundleData).Find(cond, opts).Sort("seq,index").One(ctx, data)
+	if err != nil {
+		blog.Errorf("get %s sync data failed, err: %v", queue, err)
+		return nil, err
+	}
+
+	if !data.Pulled {
+		pulledCond := map[string]interface{}{"_id": data.ID}
+		pulled := map[string]interface{}{"pulled": true}
+		if err = f.db.Table(common.BKTableNameSyncBundleData).Update(ctx, pulledCond, pulled); err != nil {
+			blog.Errorf("mark %s sync data %s as pulled failed, err: %v", queue, data.ID, err)
+			return nil, err
+		}
+	}
+
+	return &types.PullSyncDataRes{Total: int64(total), Info: json.RawMessage(data.Data)}, nil
+}
+
+// importBundles imports the bundles of each source environment in the order of sequence, the importing stops when a
+// bundle is missing or invalid, and the duplicate bundles that are already imported are skipped.
+func (f *fileMediumCli) importBundles(ctx context.Context) error {
+	manifestMap, err := f.listManifests()
+	if err != nil {
+		return err
+	}
+
+	for name, manifests := range manifestMap {
+		progress, err := f.getProgress(ctx, destProgressID(name))
+		if err != nil {
+			return err
+		}
+		progress.Name = name
+
+		importErr := f.importEnvBundles(ctx, progress, manifests)
+
+		progress.Error = ""
+		if importErr != nil {
+			progress.Error = importErr.Error()
+		}
+		if err = f.setProgress(ctx, progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importEnvBundles imports the sorted bundles of one source environment, and updates the progress after each bundle
+func (f *fileMediumCli) importEnvBundles(ctx context.Context, progress *bundleProgress,
+	manifests []*BundleManifest) error {
+
+	progress.MissingSeq = 0
+	for _, manifest := range manifests {
+		if manifest.Seq <= progress.LastSeq {
+			blog.V(4).Infof("skip duplicate bundle %s-%d, last imported seq: %d", manifest.Name, manifest.Seq,
+				progress.LastSeq)
+			continue
+		}
+
+		if manifest.Seq > progress.LastSeq+1 {
+			progress.MissingSeq = progress.LastSeq + 1
+			blog.Errorf("bundle %s-%d is missing, can not import the later bundles until it is provided",
+				manifest.Name, progress.MissingSeq)
+			return fmt.Errorf("bundle %d is missing", progress.MissingSeq)
+		}
+
+		records, err := f.readBundle(manifest)
+		if err != nil {
+			blog.Errorf("read bundle %s failed, err: %v", manifest.DataFile, err)
+			return fmt.Errorf("read bundle %d failed, err: %v", manifest.Seq, err)
+		}
+
+		for idx, record := range records {
+			if err = f.saveBundleData(ctx, manifest, idx, record); err != nil {
+				return fmt.Errorf("import bundle %d failed, err: %v", manifest.Seq, err)
+			}
+		}
+
+		progress.LastSeq = manifest.Seq
+		if err = f.setProgress(ctx, progress); err != nil {
+			return err
+		}
+		blog.Infof("import sync data bundle %s success, records: %d", manifest.DataFile, len(records))
+	}
+
+	return nil
+}
+
+// listManifests returns the bundle manifests in the directory, grouped by the source environment name and sorted by
+// the sequence, duplicate manifests with the same sequence are removed
+func (f *fileMediumCli) listManifests() (map[string][]*BundleManifest, error) {
+	entries, err := os.ReadDir(f.opt.Dir)
+	if err != nil {
+		blog.Errorf("read bundle dir %s failed, err: %v", f.opt.Dir, err)
+		return nil, err
+	}
+
+	manifestMap := make(map[string]map[int64]*BundleManifest)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), manifestFileSuffix) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(f.opt.Dir, entry.Name()))
+		if err != nil {
+			blog.Errorf("read bundle manifest %s failed, err: %v", entry.Name(), err)
+			return nil, err
+		}
+
+		manifest := new(BundleManifest)
+		if err = json.Unmarshal(content, manifest); err != nil {
+			blog.Errorf("unmarshal bundle manifest %s failed, skip it, err: %v", entry.Name(), err)
+			continue
+		}
+
+		if _, exists := manifestMap[manifest.Name]; !exists {
+			manifestMap[manifest.Name] = make(map[int64]*BundleManifest)
+		}
+
+		if _, exists := manifestMap[manifest.Name][manifest.Seq]; exists {
+			blog.Warnf("bundle %s-%d has duplicate manifest %s, skip it", manifest.Name, manifest.Seq, entry.Name())
+			continue
+		}
+		manifestMap[manifest.Name][manifest.Seq] = manifest
+	}
+
+	result := make(map[string][]*BundleManifest)
+	for name, seqMap := range manifestMap {
+		manifests := make([]*BundleManifest, 0, len(seqMap))
+		for _, manifest := range seqMap {
+			manifests = append(manifests, manifest)
+		}
+		sort.Slice(manifests, func(i, j int) bool {
+			return manifests[i].Seq < manifests[j].Seq
+		})
+		result[name] = manifests
+	}
+
+	return result, nil
+}
+
+// readBundle verifies the bundle with its manifest and reads the sync data records in it
+func (f *fileMediumCli) readBundle(manifest *BundleManifest) ([]*bundleRecord, error) {
+	// the data file name is signed, but still make sure that it does not refer to a file outside the directory
+	if filepath.Base(manifest.DataFile) != manifest.DataFile {
+		return nil, fmt.Errorf("bundle data file %s is invalid", manifest.DataFile)
+	}
+
+	compressed, err := os.ReadFile(filepath.Join(f.opt.Dir, manifest.DataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = manifest.verify(f.opt.Secret, compressed); err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	records := make([]*bundleRecord, 0, manifest.RecordCount)
+	reader := bufio.NewReader(zr)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			record := new(bundleRecord)
+			if jsonErr := json.Unmarshal(line, record); jsonErr != nil {
+				return nil, fmt.Errorf("unmarshal bundle record failed, err: %v", jsonErr)
+			}
+			records = append(records, record)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(records) != manifest.RecordCount {
+		return nil, fmt.Errorf("bundle record count %d is not equal to %d", len(records), manifest.RecordCount)
+	}
+
+	return records, nil
+}
+
+// saveBundleData saves one record of the bundle for pulling, it is idempotent so that a bundle that is partially
+// imported before restart can be imported again
+func (f *fileMediumCli) saveBundleData(ctx context.Context, manifest *BundleManifest, index int,
+	record *bundleRecord) error {
+
+	filter := map[string]interface{}{
+		"_id": fmt.Sprintf("%s:%d:%d", manifest.Name, manifest.Seq, index),
+	}
+
+	data := map[string]interface{}{
+		"name":         manifest.Name,
+		"seq":          manifest.Seq,
+		"index":        index,
+		"res_type":     record.ResType,
+		"sub_res":      record.SubRes,
+		"is_increment": record.IsIncrement,
+		"data":         string(record.Data),
+	}
+
+	if err := f.db.Table(common.BKTableNameSyncBundleData).Upsert(ctx, filter, data); err != nil {
+		blog.Errorf("save bundle %s-%d data %d failed, err: %v", manifest.Name, manifest.Seq, index, err)
+		return err
+	}
+	return nil
+}
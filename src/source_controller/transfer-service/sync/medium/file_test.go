@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileMedium(t *testing.T, dir string, db dal.DB) *fileMediumCli {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, stagingDir), 0o750))
+
+	f := &fileMediumCli{
+		opt: &FileMediumOption{
+			Dir:           dir,
+			Secret:        "secret",
+			Name:          "src",
+			MaxRecords:    2,
+			FlushInterval: time.Hour,
+		},
+		db: db,
+	}
+	require.NoError(t, f.loadStaging())
+	return f
+}
+
+func pushTestData(t *testing.T, f *fileMediumCli, ids ...int) {
+	for _, id := range ids {
+		opt := &types.PushSyncDataOpt{ResType: types.Host, IsIncrement: true, Data: map[string]int{"id": id}}
+		require.NoError(t, f.PushSyncData(context.Background(), nil, opt))
+	}
+}
+
+func pullTestData(t *testing.T, f *fileMediumCli, ack bool) (int64, int) {
+	opt := &types.PullSyncDataOpt{ResType: types.Host, IsIncrement: true, Ack: ack}
+	res, err := f.PullSyncData(context.Background(), nil, opt)
+	require.NoError(t, err)
+	if res.Total == 0 {
+		return 0, -1
+	}
+
+	data := make(map[string]int)
+	require.NoError(t, json.Unmarshal(res.Info, &data))
+	return res.Total, data["id"]
+}
+
+func copyBundle(t *testing.T, srcDir, destDir, manifestName string, seq int64) {
+	for _, name := range []string{bundleFileName("src", seq), manifestFileName("src", seq)} {
+		data, err := os.ReadFile(filepath.Join(srcDir, name))
+		require.NoError(t, err)
+		if name == manifestFileName("src", seq) {
+			name = manifestName
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(destDir, name), data, 0o640))
+	}
+}
+
+func TestSealBundle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	f := newTestFileMedium(t, dir, memory.NewDB())
+
+	pushTestData(t, f, 0, 1, 2, 3, 4)
+	progress, err := f.getProgress(ctx, srcProgressID("src"))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), progress.LastSeq)
+	require.Equal(t, 1, f.stagingCount)
+
+	manifests, err := f.listManifests()
+	require.NoError(t, err)
+	require.Len(t, manifests["src"], 2)
+	for idx, manifest := range manifests["src"] {
+		require.Equal(t, int64(idx+1), manifest.Seq)
+		records, err := f.readBundle(manifest)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+	}
+
+	// the staging data is sealed, but the sealing file is failed to be removed before restart
+	staging, err := os.ReadFile(f.stagingPath())
+	require.NoError(t, err)
+	require.NoError(t, f.sealBundle(ctx))
+	require.NoError(t, os.WriteFile(f.sealingPath(), staging, 0o640))
+
+	f = newTestFileMedium(t, dir, f.db)
+	require.Equal(t, 1, f.stagingCount)
+
+	// the sealing data that is already sealed is skipped instead of sealed into a duplicate bundle
+	pushTestData(t, f, 5)
+	progress, err = f.getProgress(ctx, srcProgressID("src"))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), progress.LastSeq)
+	require.Equal(t, 1, f.stagingCount)
+	_, err = os.Stat(f.sealingPath())
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, f.sealBundle(ctx))
+	manifests, err = f.listManifests()
+	require.NoError(t, err)
+	require.Len(t, manifests["src"], 4)
+	records, err := f.readBundle(manifests["src"][3])
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.JSONEq(t, `{"id":5}`, string(records[0].Data))
+}
+
+func TestImportBundles(t *testing.T) {
+	ctx := context.Background()
+	srcDir, destDir := t.TempDir(), t.TempDir()
+	src := newTestFileMedium(t, srcDir, memory.NewDB())
+	pushTestData(t, src, 0, 1, 2, 3, 4, 5, 6, 7)
+
+	dest := newTestFileMedium(t, destDir, memory.NewDB())
+	copyBundle(t, srcDir, destDir, manifestFileName("src", 2), 2)
+	copyBundle(t, srcDir, destDir, manifestFileName("src", 1), 1)
+	copyBundle(t, srcDir, destDir, "src-dup"+manifestFileSuffix, 1)
+	copyBundle(t, srcDir, destDir, manifestFileName("src", 4), 4)
+
+	// the bundles before the missing bundle are imported in order, the later bundles wait for the missing one
+	require.NoError(t, dest.importBundles(ctx))
+	progress, err := dest.getProgress(ctx, destProgressID("src"))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), progress.LastSeq)
+	require.Equal(t, int64(3), progress.MissingSeq)
+	require.NotEmpty(t, progress.Error)
+
+	count, err := dest.db.Table(common.BKTableNameSyncBundleData).Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+
+	total, id := pullTestData(t, dest, false)
+	require.Equal(t, int64(4), total)
+	require.Equal(t, 0, id)
+	total, id = pullTestData(t, dest, true)
+	require.Equal(t, int64(3), total)
+	require.Equal(t, 1, id)
+
+	// the missing bundle is provided, the duplicate bundles that are already imported are skipped
+	copyBundle(t, srcDir, destDir, manifestFileName("src", 3), 3)
+	require.NoError(t, dest.importBundles(ctx))
+	progress, err = dest.getProgress(ctx, destProgressID("src"))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), progress.LastSeq)
+	require.Equal(t, int64(0), progress.MissingSeq)
+	require.Empty(t, progress.Error)
+
+	for expected := 1; expected < 8; expected++ {
+		total, id = pullTestData(t, dest, expected > 1)
+		require.Equal(t, int64(8-expected), total)
+		require.Equal(t, expected, id)
+	}
+
+	total, _ = pullTestData(t, dest, true)
+	require.Equal(t, int64(0), total)
+}
+
+func TestPullSyncDataAckAfterRestart(t *testing.T) {
+	srcDir, destDir := t.TempDir(), t.TempDir()
+	src := newTestFileMedium(t, srcDir, memory.NewDB())
+	pushTestData(t, src, 0, 1)
+	copyBundle(t, srcDir, destDir, manifestFileName("src", 1), 1)
+
+	db := memory.NewDB()
+	dest := newTestFileMedium(t, destDir, db)
+	total, id := pullTestData(t, dest, false)
+	require.Equal(t, int64(2), total)
+	require.Equal(t, 0, id)
+
+	// the acknowledgement of the data that is pulled before restart is not lost
+	dest = newTestFileMedium(t, destDir, db)
+	total, id = pullTestData(t, dest, true)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, 1, id)
+
+	total, _ = pullTestData(t, dest, true)
+	require.Equal(t, int64(0), total)
+}
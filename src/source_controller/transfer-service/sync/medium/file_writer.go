@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package medium
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common/blog"
+)
+
+// PushSyncData appends the sync data to the staging file, the staging data is sealed into a bundle when it reaches
+// the max records or exceeds the flush interval
+func (f *fileMediumCli) PushSyncData(ctx context.Context, _ http.Header, opt *types.PushSyncDataOpt) error {
+	line, err := json.Marshal(opt)
+	if err != nil {
+		blog.Errorf("marshal %s-%s sync data failed, err: %v", opt.ResType, opt.SubRes, err)
+		return err
+	}
+
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	file, err := os.OpenFile(f.stagingPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		blog.Errorf("open bundle staging file failed, err: %v", err)
+		return err
+	}
+	defer file.Close()
+
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		blog.Errorf("write %s-%s sync data to staging file failed, err: %v", opt.ResType, opt.SubRes, err)
+		return err
+	}
+
+	// make sure the pushed data is persisted, since the source will not push it again
+	if err = file.Sync(); err != nil {
+		blog.Errorf("sync bundle staging file failed, err: %v", err)
+		return err
+	}
+
+	if f.stagingCount == 0 {
+		f.stagingStart = time.Now()
+	}
+	f.stagingCount++
+
+	if f.stagingCount < f.opt.MaxRecords {
+		return nil
+	}
+
+	return f.sealBundle(ctx)
+}
+
+func (f *fileMediumCli) stagingPath() string {
+	return filepath.Join(f.opt.Dir, stagingDir, f.opt.Name+".jsonl")
+}
+
+// sealingPath is the path of the staging data that is being sealed, the staging data is moved to it before sealing
+func (f *fileMediumCli) sealingPath() string {
+	return filepath.Join(f.opt.Dir, stagingDir, f.opt.Name+".sealing.jsonl")
+}
+
+// loadStaging loads the staging data that is not sealed before restart
+func (f *fileMediumCli) loadStaging() error {
+	for _, path := range []string{f.sealingPath(), f.stagingPath()} {
+		stat, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			blog.Errorf("stat bundle staging file %s failed, err: %v", path, err)
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			blog.Errorf("read bundle staging file %s failed, err: %v", path, err)
+			return err
+		}
+
+		if f.stagingCount == 0 {
+			f.stagingStart = stat.ModTime()
+		}
+		f.stagingCount += bytes.Count(data, []byte{'\n'})
+	}
+	return nil
+}
+
+// loopSealBundle seals the staging data into a bundle when it exceeds the flush interval
+func (f *fileMediumCli) loopSealBundle() {
+	ticker := time.NewTicker(f.opt.FlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		f.writeLock.Lock()
+		if f.stagingCount > 0 && time.Since(f.stagingStart) >= f.opt.FlushInterval {
+			if err := f.sealBundle(context.Background()); err != nil {
+				blog.Errorf("seal bundle failed, will retry later, err: %v", err)
+			}
+		}
+		f.writeLock.Unlock()
+	}
+}
+
+// sealBundle compresses the staging data into the next sequence-numbered bundle and writes its signed manifest,
+// the manifest is written after the data file so that the reader only sees the completed bundles.
+// the staging data is moved to the sealing file first, so that the data to be sealed does not change when the sealing
+// is retried, and the sealing data that is already sealed into the last bundle is skipped, so that it is not sealed
+// into a duplicate bundle if it failed to be removed.
+// NOTE: must be called with the write lock held
+func (f *fileMediumCli) sealBundle(ctx context.Context) error {
+	if _, err := os.Stat(f.sealingPath()); err != nil {
+		if !os.IsNotExist(err) {
+			blog.Errorf("stat bundle sealing file failed, err: %v", err)
+			return err
+		}
+
+		if err = os.Rename(f.stagingPath(), f.sealingPath()); err != nil {
+			if os.IsNotExist(err) {
+				f.stagingCount = 0
+				return nil
+			}
+			blog.Errorf("move bundle staging file to sealing file failed, err: %v", err)
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(f.sealingPath())
+	if err != nil {
+		blog.Errorf("read bundle sealing file failed, err: %v", err)
+		return err
+	}
+
+	progress, err := f.getProgress(ctx, srcProgressID(f.opt.Name))
+	if err != nil {
+		return err
+	}
+
+	dataChecksum := checksum(data)
+	if progress.LastChecksum == dataChecksum {
+		blog.Warnf("bundle sealing data is already sealed into bundle %d, skip it", progress.LastSeq)
+	} else if err = f.writeBundle(ctx, progress, data, dataChecksum); err != nil {
+		return err
+	}
+
+	if err = os.Remove(f.sealingPath()); err != nil && !os.IsNotExist(err) {
+		blog.Errorf("remove bundle sealing file failed, err: %v", err)
+		return err
+	}
+
+	// the sync data that is pushed after the sealing data is moved still needs to be sealed
+	count := 0
+	staging, err := os.ReadFile(f.stagingPath())
+	if err == nil {
+		count = bytes.Count(staging, []byte{'\n'})
+	} else if !os.IsNotExist(err) {
+		blog.Errorf("read bundle staging file failed, err: %v", err)
+		return err
+	}
+	f.stagingCount = count
+	return nil
+}
+
+// writeBundle writes the sealing data into the next sequence-numbered bundle and saves the progress
+func (f *fileMediumCli) writeBundle(ctx context.Context, progress *bundleProgress, data []byte,
+	dataChecksum string) error {
+
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		blog.Errorf("compress bundle data failed, err: %v", err)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		blog.Errorf("compress bundle data failed, err: %v", err)
+		return err
+	}
+	compressed := buf.Bytes()
+
+	seq := progress.LastSeq + 1
+	manifest := &BundleManifest{
+		Version:     bundleVersion,
+		Name:        f.opt.Name,
+		Seq:         seq,
+		DataFile:    bundleFileName(f.opt.Name, seq),
+		RecordCount: bytes.Count(data, []byte{'\n'}),
+		Size:        int64(len(compressed)),
+		Checksum:    checksum(compressed),
+		CreateTime:  time.Now(),
+	}
+	manifest.Signature = manifest.sign(f.opt.Secret)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		blog.Errorf("marshal bundle manifest failed, manifest: %+v, err: %v", *manifest, err)
+		return err
+	}
+
+	if err = writeFileAtomic(filepath.Join(f.opt.Dir, manifest.DataFile), compressed); err != nil {
+		blog.Errorf("write bundle %s failed, err: %v", manifest.DataFile, err)
+		return err
+	}
+
+	if err = writeFileAtomic(filepath.Join(f.opt.Dir, manifestFileName(f.opt.Name, seq)), manifestData); err != nil {
+		blog.Errorf("write bundle %d manifest failed, err: %v", seq, err)
+		return err
+	}
+
+	// if the progress is failed to save, the sealing data will be sealed into the same bundle again
+	progress.Name = f.opt.Name
+	progress.LastSeq = seq
+	progress.LastChecksum = dataChecksum
+	if err = f.setProgress(ctx, progress); err != nil {
+		return err
+	}
+
+	blog.Infof("seal sync data bundle %s success, records: %d", manifest.DataFile, manifest.RecordCount)
+	return nil
+}
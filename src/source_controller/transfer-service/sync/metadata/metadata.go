@@ -45,6 +45,8 @@ type Metadata struct {
 	InnerIDInfo *options.InnerDataIDConf
 	// blueking is the blueking biz info, is used to skip the resource in blueking biz for source environment
 	blueking *bluekingBizInfo
+	// scope is the sync scope, is used to skip the resource out of the scope for source environment
+	scope *scopeInfo
 }
 
 // BluekingBizID is the blueking biz info, resource in blueking biz should not be synced
@@ -82,7 +84,7 @@ func (m *Metadata) isHostInBluekingBiz(hostID int64) bool {
 }
 
 // NewMetadata new cmdb data syncer's metadata info
-func NewMetadata(role options.SyncRole) (*Metadata, error) {
+func NewMetadata(role options.SyncRole, scope *options.SyncScopeConfig) (*Metadata, error) {
 	meta := &Metadata{
 		role: role,
 		InnerIDInfo: &options.InnerDataIDConf{
@@ -90,7 +92,7 @@ func NewMetadata(role options.SyncRole) (*Metadata, error) {
 		},
 	}
 
-	err := meta.init()
+	err := meta.init(scope)
 	if err != nil {
 		return nil, fmt.Errorf("init metadata failed, err: %v", err)
 	}
@@ -98,7 +100,7 @@ func NewMetadata(role options.SyncRole) (*Metadata, error) {
 	return meta, nil
 }
 
-func (m *Metadata) init() error {
+func (m *Metadata) init(scope *options.SyncScopeConfig) error {
 	ctx := commonutil.SetDBReadPreference(context.Background(), common.SecondaryPreferredMode)
 
 	// get inner data id info
@@ -167,7 +169,7 @@ func (m *Metadata) init() error {
 		m.blueking.hostModuleMap[relation.HostID][relation.ModuleID] = struct{}{}
 	}
 
-	return nil
+	return m.initScope(ctx, scope)
 }
 
 // GetCommonObjIDs get all objIDs and quoted objIDs for object instance resource full sync, do not include inner objects
@@ -211,7 +213,9 @@ var bizRelatedResTypeMap = map[types.ResType]struct{}{types.Biz: {}, types.Objec
 	types.Module: {}, types.HostRelation: {}, types.ServiceInstance: {}, types.Process: {}, types.ProcessRelation: {}}
 
 // AddListCond add list condition for resource full sync list data logics
-func (m *Metadata) AddListCond(resType types.ResType, cond mapstr.MapStr) mapstr.MapStr {
+func (m *Metadata) AddListCond(resType types.ResType, subRes string, cond mapstr.MapStr) mapstr.MapStr {
+	cond = m.addScopeListCond(resType, subRes, cond)
+
 	extraCond := make(mapstr.MapStr)
 	switch resType {
 	case types.Biz, types.ObjectInstance:
@@ -279,8 +283,23 @@ func mergeCond(cond mapstr.MapStr, extraCond mapstr.MapStr) mapstr.MapStr {
 	return mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{cond, extraCond}}
 }
 
-// ParseEventDetail parse event detail for event watch logics, returns if the event needs sync
+// ParseEventDetail parse event detail for event watch logics, returns the events that need sync
 func (m *Metadata) ParseEventDetail(eventType watch.EventType, resType types.ResType, oid string,
+	detail json.RawMessage) []*types.EventInfo {
+
+	event, needSync := m.parseEventDetail(eventType, resType, oid, detail)
+	if !needSync {
+		return nil
+	}
+
+	if event.ResType == types.HostRelation {
+		return m.parseScopeHostRelEvent(event)
+	}
+	return m.parseScopeEvent(event)
+}
+
+// parseEventDetail parse event detail, returns if the event needs sync
+func (m *Metadata) parseEventDetail(eventType watch.EventType, resType types.ResType, oid string,
 	detail json.RawMessage) (*types.EventInfo, bool) {
 
 	event := &types.EventInfo{
@@ -364,29 +383,7 @@ func (m *Metadata) parseHostRelEvent(event *types.EventInfo) (*types.EventInfo,
 		if len(m.blueking.hostModuleMap[hostID]) == 0 {
 			delete(m.blueking.hostModuleMap, hostID)
 			// host is not in blueking biz, change this event to create host event
-			host := new(metadata.HostMapStr)
-			hostCond := mapstr.MapStr{common.BKHostIDField: hostID}
-			err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(hostCond).One(context.Background(), host)
-			if err != nil {
-				if mongodb.Client().IsNotFoundError(err) {
-					return nil, false
-				}
-				blog.Errorf("get not in blueking host by id %d failed, err: %v", hostID, err)
-				return nil, false
-			}
-
-			hostJson, err := json.Marshal(host)
-			if err != nil {
-				blog.Errorf("marshal host(%+v) failed, err: %v", hostID, err)
-				return nil, false
-			}
-			event = &types.EventInfo{
-				EventType: watch.Create,
-				ResType:   types.Host,
-				Oid:       strconv.FormatInt(hostID, 10),
-				Detail:    hostJson,
-			}
-			return event, true
+			return getHostCreateEvent(hostID)
 		}
 		return nil, false
 	}
@@ -0,0 +1,430 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"configcenter/pkg/filter"
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/transfer-service/app/options"
+	"configcenter/src/storage/driver/mongodb"
+
+	"github.com/tidwall/gjson"
+)
+
+// scopeInfo is the scope of the data that the source environment syncs, data out of the scope is not synced
+type scopeInfo struct {
+	bizIDs   []int64
+	bizIDMap map[int64]struct{}
+	objIDMap map[string]struct{}
+	filters  map[types.ResType][]*scopeFilter
+
+	// hostModuleMap is the host id to module ids map of the hosts in the scoped businesses, only used when the
+	// businesses are scoped
+	lock          sync.RWMutex
+	hostModuleMap map[int64]map[int64]struct{}
+}
+
+// scopeFilter is the filter on the attributes of one resource
+type scopeFilter struct {
+	// objID is the object id that the filter applies to, empty means all objects
+	objID string
+	expr  *filter.Expression
+	cond  mapstr.MapStr
+}
+
+// initScope init the sync scope for source environment
+func (m *Metadata) initScope(ctx context.Context, conf *options.SyncScopeConfig) error {
+	if conf == nil {
+		return nil
+	}
+
+	scope := &scopeInfo{
+		bizIDs:   conf.BizIDs,
+		bizIDMap: make(map[int64]struct{}),
+		objIDMap: make(map[string]struct{}),
+		filters:  make(map[types.ResType][]*scopeFilter),
+	}
+
+	for _, bizID := range conf.BizIDs {
+		scope.bizIDMap[bizID] = struct{}{}
+	}
+
+	for _, objID := range conf.ObjIDs {
+		scope.objIDMap[objID] = struct{}{}
+	}
+
+	for _, filterConf := range conf.Filters {
+		expr, err := filterConf.Expression()
+		if err != nil {
+			return err
+		}
+
+		cond, err := expr.ToMgo()
+		if err != nil {
+			blog.Errorf("convert %s scope filter %s to mongo condition failed, err: %v", filterConf.Resource,
+				filterConf.Filter, err)
+			return err
+		}
+
+		scope.filters[filterConf.Resource] = append(scope.filters[filterConf.Resource], &scopeFilter{
+			objID: filterConf.ObjID,
+			expr:  expr,
+			cond:  cond,
+		})
+	}
+
+	m.scope = scope
+	if len(scope.bizIDs) == 0 {
+		return nil
+	}
+
+	// get the hosts in the scoped businesses
+	hostRel := make([]metadata.ModuleHost, 0)
+	hostRelCond := mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: scope.bizIDs}}
+	if err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Find(hostRelCond).
+		Fields(common.BKHostIDField, common.BKModuleIDField).All(ctx, &hostRel); err != nil {
+		blog.Errorf("get scoped host relations by cond(%+v) failed, err: %v", hostRelCond, err)
+		return err
+	}
+
+	scope.hostModuleMap = make(map[int64]map[int64]struct{})
+	for _, relation := range hostRel {
+		_, exists := scope.hostModuleMap[relation.HostID]
+		if !exists {
+			scope.hostModuleMap[relation.HostID] = make(map[int64]struct{})
+		}
+		scope.hostModuleMap[relation.HostID][relation.ModuleID] = struct{}{}
+	}
+
+	return nil
+}
+
+func (m *Metadata) isBizScoped() bool {
+	return m.scope != nil && len(m.scope.bizIDs) > 0
+}
+
+func (m *Metadata) isBizInScope(bizID int64) bool {
+	if !m.isBizScoped() {
+		return true
+	}
+
+	_, exists := m.scope.bizIDMap[bizID]
+	return exists
+}
+
+func (m *Metadata) isObjInScope(objID string) bool {
+	if m.scope == nil || len(m.scope.objIDMap) == 0 || common.IsInnerModel(objID) {
+		return true
+	}
+
+	_, exists := m.scope.objIDMap[objID]
+	return exists
+}
+
+// IsHostInScope returns if the host is in the sync scope. the scoped host ids can be too many to be used in the list
+// condition, so the listed hosts and host associations are filtered by it instead.
+func (m *Metadata) IsHostInScope(hostID int64) bool {
+	if !m.isBizScoped() {
+		return true
+	}
+
+	m.scope.lock.RLock()
+	defer m.scope.lock.RUnlock()
+
+	_, exists := m.scope.hostModuleMap[hostID]
+	return exists
+}
+
+// getScopeFilters get the scope filters of the resource, subRes is the object id for object instance
+func (m *Metadata) getScopeFilters(resType types.ResType, subRes string) []*scopeFilter {
+	if m.scope == nil {
+		return nil
+	}
+
+	filters := make([]*scopeFilter, 0)
+	for _, f := range m.scope.filters[resType] {
+		if f.objID == "" || f.objID == subRes {
+			filters = append(filters, f)
+		}
+	}
+	return filters
+}
+
+// IsSubResInScope returns if the sub resource is in the sync scope, the sub resource that is out of the scope
+// has no data to sync, so that its data is removed from the destination environment by full sync
+func (m *Metadata) IsSubResInScope(resType types.ResType, subRes string) bool {
+	switch resType {
+	case types.ObjectInstance, types.InstAsst:
+		return m.isObjInScope(subRes)
+	case types.QuotedInstance:
+		return m.isObjInScope(metadata.GetModelQuoteSrcObjID(subRes))
+	}
+	return true
+}
+
+// addScopeListCond add the sync scope condition for resource full sync list data logics, hosts and host associations
+// are not scoped by the condition, the listed data needs to be filtered by IsHostInScope
+func (m *Metadata) addScopeListCond(resType types.ResType, subRes string, cond mapstr.MapStr) mapstr.MapStr {
+	if m.scope == nil {
+		return cond
+	}
+
+	extraConds := make([]mapstr.MapStr, 0)
+	if m.isBizScoped() {
+		switch resType {
+		case types.ObjectInstance:
+			// only business instances are scoped by business
+			extraConds = append(extraConds, mapstr.MapStr{
+				common.BKDBOR: []mapstr.MapStr{
+					{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: m.scope.bizIDs}},
+					{common.BKAppIDField: mapstr.MapStr{common.BKDBExists: false}},
+				},
+			})
+		default:
+			_, exists := bizRelatedResTypeMap[resType]
+			if exists {
+				extraConds = append(extraConds, mapstr.MapStr{
+					common.BKAppIDField: mapstr.MapStr{common.BKDBIN: m.scope.bizIDs},
+				})
+			}
+		}
+	}
+
+	if resType == types.InstAsst && len(m.scope.objIDMap) > 0 {
+		// do not sync the associations with the instances of the objects that are out of the scope
+		extraConds = append(extraConds, mapstr.MapStr{
+			common.BKAsstObjIDField: mapstr.MapStr{common.BKDBIN: m.getScopedAsstObjIDs()},
+		})
+	}
+
+	for _, f := range m.getScopeFilters(resType, subRes) {
+		extraConds = append(extraConds, f.cond)
+	}
+
+	for _, extraCond := range extraConds {
+		cond = mergeCond(cond, extraCond)
+	}
+	return cond
+}
+
+// getScopedAsstObjIDs get the object ids whose instances can be associated by the synced associations
+func (m *Metadata) getScopedAsstObjIDs() []string {
+	objIDs := make([]string, 0, len(m.scope.objIDMap))
+	for objID := range m.scope.objIDMap {
+		objIDs = append(objIDs, objID)
+	}
+
+	return append(objIDs, common.BKInnerObjIDBizSet, common.BKInnerObjIDApp, common.BKInnerObjIDProject,
+		common.BKInnerObjIDSet, common.BKInnerObjIDModule, common.BKInnerObjIDProc, common.BKInnerObjIDHost,
+		common.BKInnerObjIDPlat)
+}
+
+// parseScopeEvent parse the event by the sync scope, returns the events that need sync.
+// the updated data that no longer matches the scope filters is changed to delete event, so that it is removed from
+// the destination environment.
+func (m *Metadata) parseScopeEvent(event *types.EventInfo) []*types.EventInfo {
+	if m.scope == nil {
+		return []*types.EventInfo{event}
+	}
+
+	subRes := ""
+	switch event.ResType {
+	case types.ObjectInstance:
+		subRes = gjson.GetBytes(event.Detail, common.BKObjIDField).String()
+		if !m.isObjInScope(subRes) {
+			return nil
+		}
+
+		bizID := gjson.GetBytes(event.Detail, common.BKAppIDField)
+		if bizID.Exists() && !m.isBizInScope(bizID.Int()) {
+			return nil
+		}
+	case types.Host:
+		if !m.IsHostInScope(gjson.GetBytes(event.Detail, common.BKHostIDField).Int()) {
+			return nil
+		}
+	case types.InstAsst:
+		objIDs := []string{gjson.GetBytes(event.Detail, common.BKObjIDField).String(),
+			gjson.GetBytes(event.Detail, common.BKAsstObjIDField).String()}
+		instIDs := []int64{gjson.GetBytes(event.Detail, common.BKInstIDField).Int(),
+			gjson.GetBytes(event.Detail, common.BKAsstInstIDField).Int()}
+
+		for i, objID := range objIDs {
+			if !m.isObjInScope(objID) {
+				return nil
+			}
+			if objID == common.BKInnerObjIDHost && !m.IsHostInScope(instIDs[i]) {
+				return nil
+			}
+		}
+	default:
+		_, exists := bizRelatedResTypeMap[event.ResType]
+		if exists && !m.isBizInScope(gjson.GetBytes(event.Detail, common.BKAppIDField).Int()) {
+			return nil
+		}
+	}
+
+	for _, f := range m.getScopeFilters(event.ResType, subRes) {
+		matched, err := f.expr.Match(filter.JsonString(event.Detail))
+		if err != nil {
+			blog.Errorf("match %s event(%s) with scope filter %s failed, err: %v", event.ResType, event.Oid,
+				f.expr.String(), err)
+			return nil
+		}
+
+		if matched {
+			continue
+		}
+
+		if event.EventType != watch.Update {
+			return nil
+		}
+
+		event.EventType = watch.Delete
+		return []*types.EventInfo{event}
+	}
+
+	return []*types.EventInfo{event}
+}
+
+// parseScopeHostRelEvent parse the host relation event by the sync scope, returns the events that need sync.
+// the host is synced when it is transferred into the scoped businesses, and is removed from the destination
+// environment when it is transferred out of them.
+func (m *Metadata) parseScopeHostRelEvent(event *types.EventInfo) []*types.EventInfo {
+	if !m.isBizScoped() {
+		return m.parseScopeEvent(event)
+	}
+
+	if !m.isBizInScope(gjson.GetBytes(event.Detail, common.BKAppIDField).Int()) {
+		return nil
+	}
+
+	events := m.parseScopeEvent(event)
+
+	hostID := gjson.GetBytes(event.Detail, common.BKHostIDField).Int()
+	moduleID := gjson.GetBytes(event.Detail, common.BKModuleIDField).Int()
+
+	hostEventType, changed := m.updateScopedHost(event.EventType, hostID, moduleID)
+	if !changed {
+		return events
+	}
+
+	if hostEventType == watch.Delete {
+		// host is transferred out of the scoped businesses, change this event to delete host event
+		return append(events, &types.EventInfo{
+			EventType: watch.Delete,
+			ResType:   types.Host,
+			Oid:       strconv.FormatInt(hostID, 10),
+			Detail:    json.RawMessage(fmt.Sprintf(`{"%s":%d}`, common.BKHostIDField, hostID)),
+		})
+	}
+
+	// host is transferred into the scoped businesses, change this event to create host event
+	hostEvent, exists := getHostCreateEvent(hostID)
+	if !exists {
+		return events
+	}
+	return append(events, m.parseScopeEvent(hostEvent)...)
+}
+
+// updateScopedHost update scoped host info by host relation event, returns the changed host event type
+func (m *Metadata) updateScopedHost(eventType watch.EventType, hostID, moduleID int64) (watch.EventType, bool) {
+	m.scope.lock.Lock()
+	defer m.scope.lock.Unlock()
+
+	if eventType != watch.Delete {
+		_, exists := m.scope.hostModuleMap[hostID]
+		if exists {
+			m.scope.hostModuleMap[hostID][moduleID] = struct{}{}
+			return "", false
+		}
+
+		m.scope.hostModuleMap[hostID] = map[int64]struct{}{moduleID: {}}
+		return watch.Create, true
+	}
+
+	_, exists := m.scope.hostModuleMap[hostID]
+	if !exists {
+		return "", false
+	}
+
+	delete(m.scope.hostModuleMap[hostID], moduleID)
+	if len(m.scope.hostModuleMap[hostID]) > 0 {
+		return "", false
+	}
+
+	// host relations are deleted before the new relations are created when host is transferred, check if host
+	// still has relations in the scoped businesses to avoid removing the host that is transferred inside the scope
+	hostRel := make([]metadata.ModuleHost, 0)
+	hostRelCond := mapstr.MapStr{
+		common.BKHostIDField: hostID,
+		common.BKAppIDField:  mapstr.MapStr{common.BKDBIN: m.scope.bizIDs},
+	}
+	err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Find(hostRelCond).
+		Fields(common.BKModuleIDField).All(context.Background(), &hostRel)
+	if err != nil {
+		blog.Errorf("get scoped host %d relations failed, err: %v", hostID, err)
+	}
+
+	if len(hostRel) > 0 {
+		for _, relation := range hostRel {
+			m.scope.hostModuleMap[hostID][relation.ModuleID] = struct{}{}
+		}
+		return "", false
+	}
+
+	delete(m.scope.hostModuleMap, hostID)
+	return watch.Delete, true
+}
+
+// getHostCreateEvent get host create event by host id, returns false if the host does not exist
+func getHostCreateEvent(hostID int64) (*types.EventInfo, bool) {
+	host := new(metadata.HostMapStr)
+	hostCond := mapstr.MapStr{common.BKHostIDField: hostID}
+	err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(hostCond).One(context.Background(), host)
+	if err != nil {
+		if !mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("get host by id %d failed, err: %v", hostID, err)
+		}
+		return nil, false
+	}
+
+	hostJson, err := json.Marshal(host)
+	if err != nil {
+		blog.Errorf("marshal host(%+v) failed, err: %v", hostID, err)
+		return nil, false
+	}
+
+	return &types.EventInfo{
+		EventType: watch.Create,
+		ResType:   types.Host,
+		Oid:       strconv.FormatInt(hostID, 10),
+		Detail:    hostJson,
+	}, true
+}
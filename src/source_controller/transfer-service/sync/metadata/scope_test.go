@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/pkg/synchronize/types"
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScopeMetadata() *Metadata {
+	return &Metadata{
+		scope: &scopeInfo{
+			bizIDs:   []int64{2},
+			bizIDMap: map[int64]struct{}{2: {}},
+			objIDMap: map[string]struct{}{"switch": {}},
+			filters:  make(map[types.ResType][]*scopeFilter),
+			hostModuleMap: map[int64]map[int64]struct{}{
+				1: {10: {}},
+				2: {10: {}, 11: {}},
+			},
+		},
+	}
+}
+
+func TestAddScopeListCond(t *testing.T) {
+	m := newTestScopeMetadata()
+	idCond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBGT: 0}}
+
+	// hosts are not scoped by the list condition, the listed hosts are filtered by IsHostInScope
+	require.Equal(t, idCond, m.addScopeListCond(types.Host, "", idCond))
+
+	cond := m.addScopeListCond(types.Set, "", mapstr.MapStr{})
+	require.Equal(t, mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: []int64{2}}}, cond)
+
+	// host associations are only scoped by the associated objects
+	cond = m.addScopeListCond(types.InstAsst, "switch", mapstr.MapStr{})
+	require.Len(t, cond, 1)
+	asstObjCond, ok := cond[common.BKAsstObjIDField].(mapstr.MapStr)
+	require.True(t, ok)
+	require.Contains(t, asstObjCond[common.BKDBIN], "switch")
+	require.Contains(t, asstObjCond[common.BKDBIN], common.BKInnerObjIDHost)
+
+	unscoped := new(Metadata)
+	require.Equal(t, idCond, unscoped.addScopeListCond(types.Host, "", idCond))
+}
+
+func TestIsHostInScope(t *testing.T) {
+	m := newTestScopeMetadata()
+	require.True(t, m.IsHostInScope(1))
+	require.True(t, m.IsHostInScope(2))
+	require.False(t, m.IsHostInScope(3))
+
+	require.True(t, new(Metadata).IsHostInScope(3))
+	require.True(t, (&Metadata{scope: &scopeInfo{}}).IsHostInScope(3))
+}
+
+func TestParseScopeEvent(t *testing.T) {
+	m := newTestScopeMetadata()
+
+	hostEvent := &types.EventInfo{EventType: watch.Update, ResType: types.Host,
+		Detail: json.RawMessage(`{"bk_host_id":1}`)}
+	require.Len(t, m.parseScopeEvent(hostEvent), 1)
+
+	hostEvent = &types.EventInfo{EventType: watch.Update, ResType: types.Host,
+		Detail: json.RawMessage(`{"bk_host_id":3}`)}
+	require.Empty(t, m.parseScopeEvent(hostEvent))
+
+	asstEvent := &types.EventInfo{EventType: watch.Create, ResType: types.InstAsst,
+		Detail: json.RawMessage(`{"bk_obj_id":"host","bk_inst_id":2,"bk_asst_obj_id":"switch","bk_asst_inst_id":5}`)}
+	require.Len(t, m.parseScopeEvent(asstEvent), 1)
+
+	asstEvent = &types.EventInfo{EventType: watch.Create, ResType: types.InstAsst,
+		Detail: json.RawMessage(`{"bk_obj_id":"switch","bk_inst_id":5,"bk_asst_obj_id":"host","bk_asst_inst_id":3}`)}
+	require.Empty(t, m.parseScopeEvent(asstEvent))
+
+	setEvent := &types.EventInfo{EventType: watch.Create, ResType: types.Set,
+		Detail: json.RawMessage(`{"bk_biz_id":3,"bk_set_id":1}`)}
+	require.Empty(t, m.parseScopeEvent(setEvent))
+}
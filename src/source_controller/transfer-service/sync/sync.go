@@ -26,6 +26,7 @@ import (
 
 	"configcenter/pkg/synchronize/types"
 	"configcenter/src/apimachinery/cacheservice"
+	"configcenter/src/apimachinery/coreservice"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -51,7 +52,8 @@ type Syncer struct {
 
 // NewSyncer new cmdb data syncer
 func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface, loopW stream.LoopInterface,
-	cacheCli cacheservice.CacheServiceClientInterface, reg prometheus.Registerer) (*Syncer, error) {
+	cacheCli cacheservice.CacheServiceClientInterface, coreCli coreservice.CoreServiceClientInterface,
+	reg prometheus.Registerer) (*Syncer, error) {
 
 	if !conf.Sync.EnableSync {
 		return &Syncer{enableSync: false}, nil
@@ -72,24 +74,31 @@ func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface,
 		return &Syncer{enableSync: false}, nil
 	}
 
-	meta, err := metadata.NewMetadata(conf.Sync.Role)
+	meta, err := metadata.NewMetadata(conf.Sync.Role, conf.Sync.Scope)
 	if err != nil {
 		blog.Errorf("new metadata failed, err: %v", err)
 		return nil, err
 	}
 
-	transMedium, err := medium.NewTransferMedium(conf.Sync.TransMediumAddr, reg)
+	transMedium, err := newTransferMedium(conf.Sync, reg)
 	if err != nil {
-		blog.Errorf("new transfer medium failed, err: %v, addr: %+v", err, conf.Sync.TransMediumAddr)
 		return nil, err
 	}
 
 	idRuleMap, srcInnerIDMap := parseDestExConf(conf)
-	resLgcMap := logics.New(&logics.LogicsConfig{
+	lgcConf := &logics.LogicsConfig{
 		Metadata:      meta,
 		IDRuleMap:     idRuleMap,
 		SrcInnerIDMap: srcInnerIDMap,
-	})
+	}
+
+	// detect the conflicts between the sync data and the local change only in the destination environment
+	if conf.Sync.Role == options.SyncRoleDest {
+		lgcConf.ConflictPolicy = conf.Sync.ConflictPolicy
+		lgcConf.AuditCli = coreCli.Audit()
+		initConflictTable()
+	}
+	resLgcMap := logics.New(lgcConf)
 
 	syncer := &Syncer{
 		enableSync:   true,
@@ -119,6 +128,31 @@ func NewSyncer(conf *options.Config, isMaster discovery.ServiceManageInterface,
 	return syncer, nil
 }
 
+// newTransferMedium new transfer medium client by the transfer medium type
+func newTransferMedium(conf *options.SyncConfig, reg prometheus.Registerer) (medium.ClientI, error) {
+	if conf.TransMediumType != options.TransMediumFile {
+		transMedium, err := medium.NewTransferMedium(conf.TransMediumAddr, reg)
+		if err != nil {
+			blog.Errorf("new transfer medium failed, err: %v, addr: %+v", err, conf.TransMediumAddr)
+			return nil, err
+		}
+		return transMedium, nil
+	}
+
+	transMedium, err := medium.NewFileMedium(&medium.FileMediumOption{
+		Dir:           conf.Bundle.Dir,
+		Secret:        conf.Bundle.Secret,
+		Name:          conf.Name,
+		MaxRecords:    conf.Bundle.MaxRecords,
+		FlushInterval: time.Duration(conf.Bundle.FlushIntervalSeconds) * time.Second,
+	})
+	if err != nil {
+		blog.Errorf("new file transfer medium failed, err: %v, dir: %s", err, conf.Bundle.Dir)
+		return nil, err
+	}
+	return transMedium, nil
+}
+
 func parseDestExConf(conf *options.Config) (map[types.ResType]map[string][]options.IDRuleInfo,
 	map[string]*options.InnerDataIDConf) {
 
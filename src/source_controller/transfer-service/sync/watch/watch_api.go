@@ -159,11 +159,8 @@ func (w *Watcher) doWatchAPI(kit *util.Kit, resType types.ResType, opt *watch.Wa
 			continue
 		}
 
-		eventInfo, needSync := w.metadata.ParseEventDetail(event.EventType, resType, oid, json.RawMessage(detail))
-		if !needSync {
-			continue
-		}
-		eventInfos = append(eventInfos, eventInfo)
+		eventInfos = append(eventInfos, w.metadata.ParseEventDetail(event.EventType, resType, oid,
+			json.RawMessage(detail))...)
 	}
 
 	// push incremental sync data to transfer medium
@@ -141,11 +141,7 @@ func (w *Watcher) handleDBEvents(resType synctypes.ResType, coll string, es []*t
 			e.DocBytes = delDetail
 		}
 
-		eventInfo, needSync := w.metadata.ParseEventDetail(eventType, resType, e.Oid, e.DocBytes)
-		if !needSync {
-			continue
-		}
-		eventInfos = append(eventInfos, eventInfo)
+		eventInfos = append(eventInfos, w.metadata.ParseEventDetail(eventType, resType, e.Oid, e.DocBytes)...)
 	}
 
 	// push incremental sync data to transfer medium
@@ -0,0 +1,471 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// runPipeline runs the aggregation pipeline on the documents, the supported stages are $match, $project,
+// $sort, $skip, $limit, $unwind, $group, $replaceRoot and $count.
+func runPipeline(docs []document, pipeline interface{}) ([]document, error) {
+	stages, err := parsePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage specification object must contain exactly one field")
+		}
+
+		name, spec := stage[0].Key, stage[0].Value
+		switch name {
+		case "$match":
+			docs, err = aggregateMatch(docs, spec)
+		case "$project":
+			docs, err = aggregateProject(docs, spec)
+		case "$sort":
+			docs, err = aggregateSort(docs, spec)
+		case "$skip":
+			skip := int(toFloat(spec))
+			if skip >= len(docs) {
+				docs = make([]document, 0)
+			} else {
+				docs = docs[skip:]
+			}
+		case "$limit":
+			limit := int(toFloat(spec))
+			if limit < len(docs) {
+				docs = docs[:limit]
+			}
+		case "$unwind":
+			docs, err = aggregateUnwind(docs, spec)
+		case "$group":
+			docs, err = aggregateGroup(docs, spec)
+		case "$replaceRoot":
+			docs, err = aggregateReplaceRoot(docs, spec)
+		case "$count":
+			field, ok := spec.(string)
+			if !ok || field == "" {
+				return nil, fmt.Errorf("the count field must be a non-empty string")
+			}
+			if len(docs) == 0 {
+				docs = make([]document, 0)
+			} else {
+				docs = []document{{field: int32(len(docs))}}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported pipeline stage %s", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// parsePipeline parses the pipeline into ordered stages, since the order of $sort keys matters.
+func parsePipeline(pipeline interface{}) ([]bson.D, error) {
+	raw, err := toOrderedDocument(bson.M{"pipeline": pipeline})
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := raw[0].Value.(bson.A)
+	if !ok {
+		return nil, fmt.Errorf("pipeline %v is not an array", pipeline)
+	}
+
+	stages := make([]bson.D, len(arr))
+	for idx, elem := range arr {
+		stage, ok := elem.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("pipeline stage %v is not a document", elem)
+		}
+		stages[idx] = stage
+	}
+	return stages, nil
+}
+
+func aggregateMatch(docs []document, spec interface{}) ([]document, error) {
+	filter, ok := normalize(spec).(document)
+	if !ok {
+		return nil, fmt.Errorf("the match filter must be an expression in an object")
+	}
+
+	result := make([]document, 0)
+	for _, doc := range docs {
+		matched, err := matchDocument(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+func aggregateProject(docs []document, spec interface{}) ([]document, error) {
+	project, ok := normalize(spec).(document)
+	if !ok {
+		return nil, fmt.Errorf("$project specification must be an object")
+	}
+
+	include := make([]string, 0)
+	exclude := make([]string, 0)
+	computed := make(document)
+	withID := true
+	for field, val := range project {
+		switch {
+		case field == "_id" && !isTrue(val):
+			withID = false
+		case typeOrder(val) == orderNumber || typeOrder(val) == orderBool:
+			if isTrue(val) {
+				include = append(include, field)
+			} else {
+				exclude = append(exclude, field)
+			}
+		default:
+			computed[field] = val
+		}
+	}
+
+	result := make([]document, len(docs))
+	for idx, doc := range docs {
+		var projected document
+		if len(include) > 0 || len(computed) > 0 {
+			projected = projectFields(doc, include, withID)
+		} else {
+			projected = deepCopy(doc).(document)
+			for _, field := range exclude {
+				unsetField(projected, field)
+			}
+			if !withID {
+				delete(projected, "_id")
+			}
+		}
+
+		for field, expr := range computed {
+			val, err := evalExpression(doc, expr)
+			if err != nil {
+				return nil, err
+			}
+			if err := setField(projected, field, val); err != nil {
+				return nil, err
+			}
+		}
+		result[idx] = projected
+	}
+	return result, nil
+}
+
+func aggregateSort(docs []document, spec interface{}) ([]document, error) {
+	keys, ok := spec.(bson.D)
+	if !ok || len(keys) == 0 {
+		return nil, fmt.Errorf("$sort key specification must be an object")
+	}
+
+	sorts := make([]sortKey, len(keys))
+	for idx, key := range keys {
+		sorts[idx] = sortKey{field: key.Key, desc: toFloat(key.Value) < 0}
+	}
+
+	result := append(make([]document, 0, len(docs)), docs...)
+	sortDocuments(result, sorts)
+	return result, nil
+}
+
+func aggregateUnwind(docs []document, spec interface{}) ([]document, error) {
+	path := ""
+	preserve := false
+	switch s := normalize(spec).(type) {
+	case string:
+		path = s
+	case document:
+		path, _ = s["path"].(string)
+		preserve = isTrue(s["preserveNullAndEmptyArrays"])
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("$unwind path must be prefixed by a '$'")
+	}
+	path = strings.TrimPrefix(path, "$")
+
+	result := make([]document, 0, len(docs))
+	for _, doc := range docs {
+		val, exists := getField(doc, path)
+		arr, isArr := val.([]interface{})
+		if !exists || val == nil || (isArr && len(arr) == 0) {
+			if preserve {
+				result = append(result, doc)
+			}
+			continue
+		}
+		if !isArr {
+			result = append(result, doc)
+			continue
+		}
+		for _, elem := range arr {
+			unwound := deepCopy(doc).(document)
+			if err := setField(unwound, path, deepCopy(elem)); err != nil {
+				return nil, err
+			}
+			result = append(result, unwound)
+		}
+	}
+	return result, nil
+}
+
+type group struct {
+	id     interface{}
+	docs   []document
+	result document
+}
+
+func aggregateGroup(docs []document, spec interface{}) ([]document, error) {
+	groupSpec, ok := normalize(spec).(document)
+	if !ok {
+		return nil, fmt.Errorf("a group's fields must be specified in an object")
+	}
+	idExpr, exists := groupSpec["_id"]
+	if !exists {
+		return nil, fmt.Errorf("a group specification must include an _id")
+	}
+
+	groups := make([]*group, 0)
+	groupMap := make(map[string]*group)
+	for _, doc := range docs {
+		id, err := evalExpression(doc, idExpr)
+		if err != nil {
+			return nil, err
+		}
+		key := valueKey(id)
+		g, exists := groupMap[key]
+		if !exists {
+			g = &group{id: id}
+			groupMap[key] = g
+			groups = append(groups, g)
+		}
+		g.docs = append(g.docs, doc)
+	}
+
+	result := make([]document, len(groups))
+	for idx, g := range groups {
+		out := document{"_id": g.id}
+		for field, acc := range groupSpec {
+			if field == "_id" {
+				continue
+			}
+			val, err := accumulate(g.docs, acc)
+			if err != nil {
+				return nil, err
+			}
+			out[field] = val
+		}
+		result[idx] = out
+	}
+	return result, nil
+}
+
+func accumulate(docs []document, spec interface{}) (interface{}, error) {
+	acc, ok := spec.(document)
+	if !ok || len(acc) != 1 {
+		return nil, fmt.Errorf("the group accumulator must be an object with one field")
+	}
+
+	for op, expr := range acc {
+		switch op {
+		case "$sum":
+			var sum float64
+			allInt := true
+			for _, doc := range docs {
+				val, err := evalExpression(doc, expr)
+				if err != nil {
+					return nil, err
+				}
+				if typeOrder(val) != orderNumber {
+					continue
+				}
+				switch val.(type) {
+				case int32, int64:
+				default:
+					allInt = false
+				}
+				sum += toFloat(val)
+			}
+			if allInt {
+				return int64(sum), nil
+			}
+			return sum, nil
+		case "$first", "$last":
+			if len(docs) == 0 {
+				return nil, nil
+			}
+			doc := docs[0]
+			if op == "$last" {
+				doc = docs[len(docs)-1]
+			}
+			return evalExpression(doc, expr)
+		case "$min", "$max":
+			var result interface{}
+			for idx, doc := range docs {
+				val, err := evalExpression(doc, expr)
+				if err != nil {
+					return nil, err
+				}
+				if idx == 0 {
+					result = val
+					continue
+				}
+				c := compareValues(val, result)
+				if (op == "$min" && c < 0) || (op == "$max" && c > 0) {
+					result = val
+				}
+			}
+			return result, nil
+		case "$push", "$addToSet":
+			values := make([]interface{}, 0)
+			for _, doc := range docs {
+				val, err := evalExpression(doc, expr)
+				if err != nil {
+					return nil, err
+				}
+				if op == "$addToSet" {
+					exists := false
+					for _, old := range values {
+						if equalValues(old, val) {
+							exists = true
+							break
+						}
+					}
+					if exists {
+						continue
+					}
+				}
+				values = append(values, val)
+			}
+			return values, nil
+		default:
+			return nil, fmt.Errorf("unsupported group accumulator %s", op)
+		}
+	}
+	return nil, nil
+}
+
+// aggregateReplaceRoot replaces each document with the document evaluated from the newRoot expression.
+func aggregateReplaceRoot(docs []document, spec interface{}) ([]document, error) {
+	replace, ok := normalize(spec).(document)
+	if !ok {
+		return nil, fmt.Errorf("$replaceRoot specification must be an object")
+	}
+
+	result := make([]document, len(docs))
+	for idx, doc := range docs {
+		val, err := evalExpression(doc, replace["newRoot"])
+		if err != nil {
+			return nil, err
+		}
+		root, ok := val.(document)
+		if !ok {
+			return nil, fmt.Errorf("'newRoot' expression must evaluate to an object, but got %v", val)
+		}
+		result[idx] = root
+	}
+	return result, nil
+}
+
+// evalExpression evaluates the simple aggregation expressions, which are field paths like "$bk_biz_id",
+// the "$$ROOT" variable, documents of expressions and literals.
+func evalExpression(doc document, expr interface{}) (interface{}, error) {
+	switch e := expr.(type) {
+	case string:
+		if e == "$$ROOT" {
+			return deepCopy(doc), nil
+		}
+		if !strings.HasPrefix(e, "$") {
+			return e, nil
+		}
+		val, _ := getField(doc, strings.TrimPrefix(e, "$"))
+		return deepCopy(val), nil
+	case document:
+		out := make(document, len(e))
+		for key, sub := range e {
+			if strings.HasPrefix(key, "$") {
+				return nil, fmt.Errorf("unsupported expression operator %s", key)
+			}
+			val, err := evalExpression(doc, sub)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = val
+		}
+		return out, nil
+	default:
+		return e, nil
+	}
+}
+
+// sortKey is one sort field with its direction.
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// sortDocuments sorts the documents stably with the sort keys.
+func sortDocuments(docs []document, keys []sortKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			vi, _ := getField(docs[i], key.field)
+			vj, _ := getField(docs[j], key.field)
+			c := compareValues(vi, vj)
+			if c == 0 {
+				continue
+			}
+			if key.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// projectFields returns a new document only with the included fields, dot separated embedded fields
+// are supported.
+func projectFields(doc document, fields []string, withID bool) document {
+	projected := make(document)
+	for _, field := range fields {
+		val, exists := getField(doc, field)
+		if !exists {
+			continue
+		}
+		_ = setField(projected, field, deepCopy(val))
+	}
+	if withID {
+		if id, exists := doc["_id"]; exists {
+			projected["_id"] = id
+		}
+	}
+	return projected
+}
@@ -0,0 +1,501 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Collection implement types.Table interface
+type Collection struct {
+	collName string
+	db       *DB
+}
+
+var _ types.Table = new(Collection)
+
+// Find 查询多个并反序列化到 Result
+func (c *Collection) Find(filter types.Filter, opts ...*types.FindOpts) types.Find {
+	find := &Find{
+		Collection: c,
+		filter:     filter,
+		projection: make([]string, 0),
+	}
+	find.Option(opts...)
+	return find
+}
+
+// findDocs returns the documents matching the filter, the caller must hold the lock.
+// the returned documents are the stored ones, do not change them.
+func (c *Collection) findDocs(filter types.Filter) ([]document, []int, error) {
+	m, err := newMatcher(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := c.db.getTable(c.collName, false)
+	if t == nil {
+		return make([]document, 0), make([]int, 0), nil
+	}
+
+	docs := make([]document, 0)
+	positions := make([]int, 0)
+	for idx, doc := range t.docs {
+		matched, err := m.match(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if matched {
+			docs = append(docs, doc)
+			positions = append(positions, idx)
+		}
+	}
+	return docs, positions, nil
+}
+
+// AggregateOne 聚合查询
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(pipeline)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return types.ErrDocumentNotFound
+	}
+	return decodeDocument(docs[0], result)
+}
+
+// AggregateAll aggregate all operation
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{},
+	opts ...*types.AggregateOpts) error {
+
+	docs, err := c.aggregate(pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+func (c *Collection) aggregate(pipeline interface{}) ([]document, error) {
+	c.db.lock.RLock()
+	defer c.db.lock.RUnlock()
+
+	t := c.db.getTable(c.collName, false)
+	if t == nil {
+		return make([]document, 0), nil
+	}
+
+	docs := make([]document, len(t.docs))
+	for idx, doc := range t.docs {
+		docs[idx] = deepCopy(doc).(document)
+	}
+	return runPipeline(docs, pipeline)
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+	rows := util.ConvertToInterfaceSlice(docs)
+	if len(rows) == 0 {
+		return errors.New("must provide at least one element to insert")
+	}
+
+	inserts := make([]document, len(rows))
+	for idx, row := range rows {
+		doc, err := toDocument(row)
+		if err != nil {
+			return fmt.Errorf("parse insert document failed, err: %v", err)
+		}
+		if _, exists := doc["_id"]; !exists {
+			doc["_id"] = primitive.NewObjectID()
+		}
+		inserts[idx] = doc
+	}
+
+	c.db.lock.Lock()
+	defer c.db.lock.Unlock()
+
+	if err := c.db.trySnapshot(ctx); err != nil {
+		return err
+	}
+
+	t := c.db.getTable(c.collName, true)
+	all := append(append(make([]document, 0, len(t.docs)+len(inserts)), t.docs...), inserts...)
+	if err := c.checkUnique(t, all); err != nil {
+		return err
+	}
+	t.docs = all
+	return nil
+}
+
+// checkUnique checks the unique indexes and the _id uniqueness of the documents.
+func (c *Collection) checkUnique(t *table, docs []document) error {
+	ids := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		key := valueKey(doc["_id"])
+		if _, exists := ids[key]; exists {
+			return fmt.Errorf("E11000 duplicate key error collection: %s index: %s dup key: %s", c.collName,
+				idIndexName, key)
+		}
+		ids[key] = struct{}{}
+	}
+	return checkUnique(c.collName, t.indexes, docs)
+}
+
+// update applies the update operations on the documents that match the filter, returns the matched and
+// modified count. if upsert is true and no document matches the filter, a new document is inserted.
+func (c *Collection) update(ctx context.Context, filter types.Filter, ops []*updateOperation, upsert,
+	multi bool) (uint64, uint64, error) {
+
+	c.db.lock.Lock()
+	defer c.db.lock.Unlock()
+
+	matched, positions, err := c.findDocs(filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !multi && len(matched) > 1 {
+		matched, positions = matched[:1], positions[:1]
+	}
+
+	if err := c.db.trySnapshot(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	t := c.db.getTable(c.collName, true)
+	all := append(make([]document, 0, len(t.docs)+1), t.docs...)
+
+	if len(matched) == 0 {
+		if !upsert {
+			return 0, 0, nil
+		}
+
+		m, err := newMatcher(filter)
+		if err != nil {
+			return 0, 0, err
+		}
+		doc := upsertSeed(m.filter)
+		for _, op := range ops {
+			if err := op.apply(doc); err != nil {
+				return 0, 0, err
+			}
+		}
+		if _, exists := doc["_id"]; !exists {
+			doc["_id"] = primitive.NewObjectID()
+		}
+		all = append(all, doc)
+		if err := c.checkUnique(t, all); err != nil {
+			return 0, 0, err
+		}
+		t.docs = all
+		return 0, 1, nil
+	}
+
+	m, err := newMatcher(filter)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var modified uint64
+	for idx, doc := range matched {
+		updated := deepCopy(doc).(document)
+		for _, op := range ops {
+			if op.op == "setOnInsert" {
+				continue
+			}
+			resolved, err := op.resolvePositional(updated, m.filter)
+			if err != nil {
+				return 0, 0, err
+			}
+			if err := resolved.apply(updated); err != nil {
+				return 0, 0, err
+			}
+		}
+		if !equalValues(doc, updated) {
+			modified++
+		}
+		all[positions[idx]] = updated
+	}
+
+	if err := c.checkUnique(t, all); err != nil {
+		return 0, 0, err
+	}
+	t.docs = all
+	return uint64(len(matched)), modified, nil
+}
+
+// Update 更新数据
+func (c *Collection) Update(ctx context.Context, filter types.Filter, doc interface{}) error {
+	_, err := c.UpdateMany(ctx, filter, doc)
+	return err
+}
+
+// Upsert 数据存在更新数据，否则新加数据。
+func (c *Collection) Upsert(ctx context.Context, filter types.Filter, doc interface{}) error {
+	op, err := parseUpdate("set", doc)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.update(ctx, filter, []*updateOperation{op}, true, false)
+	return err
+}
+
+// UpdateMultiModel 根据不同的操作符去更新数据
+func (c *Collection) UpdateMultiModel(ctx context.Context, filter types.Filter, updateModel ...types.ModeUpdate) error {
+	ops := make([]*updateOperation, 0, len(updateModel))
+	exists := make(map[string]struct{})
+	for _, item := range updateModel {
+		if _, ok := exists[item.Op]; ok {
+			return errors.New(item.Op + " appear multiple times")
+		}
+		exists[item.Op] = struct{}{}
+
+		op, err := parseUpdate(item.Op, item.Doc)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, op)
+	}
+
+	_, _, err := c.update(ctx, filter, ops, false, true)
+	return err
+}
+
+// Delete 删除数据
+func (c *Collection) Delete(ctx context.Context, filter types.Filter) error {
+	_, err := c.DeleteMany(ctx, filter)
+	return err
+}
+
+// CreateIndex 创建索引
+func (c *Collection) CreateIndex(ctx context.Context, index types.Index) error {
+	return c.BatchCreateIndexes(ctx, []types.Index{index})
+}
+
+// BatchCreateIndexes 批量创建索引
+func (c *Collection) BatchCreateIndexes(ctx context.Context, indexes []types.Index) error {
+	c.db.lock.Lock()
+	defer c.db.lock.Unlock()
+
+	t := c.db.getTable(c.collName, true)
+	all := append(make([]*index, 0, len(t.indexes)+len(indexes)), t.indexes...)
+	for _, idx := range indexes {
+		created, err := newIndex(idx)
+		if err != nil {
+			return err
+		}
+
+		// same as mongodb implementation, ignore the index that has the same keys with an existing one
+		exists := false
+		for _, old := range all {
+			if old.sameKeys(created) {
+				exists = true
+				break
+			}
+			if old.Name == created.Name {
+				return fmt.Errorf("index with name: %s already exists with different options", created.Name)
+			}
+		}
+		if exists {
+			continue
+		}
+		all = append(all, created)
+	}
+
+	if err := checkUnique(c.collName, all, t.docs); err != nil {
+		return err
+	}
+	t.indexes = all
+	return nil
+}
+
+// DropIndex remove index by name
+func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
+	c.db.lock.Lock()
+	defer c.db.lock.Unlock()
+
+	t := c.db.getTable(c.collName, false)
+	if t == nil {
+		return nil
+	}
+
+	for idx, old := range t.indexes {
+		if old.Name == indexName {
+			t.indexes = append(t.indexes[:idx:idx], t.indexes[idx+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// Indexes get all indexes for the collection
+func (c *Collection) Indexes(ctx context.Context) ([]types.Index, error) {
+	c.db.lock.RLock()
+	defer c.db.lock.RUnlock()
+
+	t := c.db.getTable(c.collName, false)
+	if t == nil {
+		return nil, nil
+	}
+
+	indexes := make([]types.Index, 0, len(t.indexes)+1)
+	indexes = append(indexes, types.Index{Keys: []primitive.E{{Key: "_id", Value: int32(1)}}, Name: idIndexName})
+	for _, idx := range t.indexes {
+		indexes = append(indexes, idx.Index)
+	}
+	return indexes, nil
+}
+
+// AddColumn add a new column for the collection
+func (c *Collection) AddColumn(ctx context.Context, column string, value interface{}) error {
+	filter := map[string]interface{}{column: map[string]interface{}{"$exists": false}}
+	_, err := c.UpdateMany(ctx, filter, map[string]interface{}{column: value})
+	return err
+}
+
+// RenameColumn rename a column for the collection
+func (c *Collection) RenameColumn(ctx context.Context, filter types.Filter, oldName, newColumn string) error {
+	op, err := parseUpdate("rename", map[string]interface{}{oldName: newColumn})
+	if err != nil {
+		return err
+	}
+	_, _, err = c.update(ctx, filter, []*updateOperation{op}, false, true)
+	return err
+}
+
+// DropColumn remove a column by the name
+func (c *Collection) DropColumn(ctx context.Context, field string) error {
+	return c.DropColumns(ctx, nil, []string{field})
+}
+
+// DropColumns remove many columns by the name
+func (c *Collection) DropColumns(ctx context.Context, filter types.Filter, fields []string) error {
+	unsetFields := make(map[string]interface{})
+	for _, field := range fields {
+		unsetFields[field] = ""
+	}
+
+	op, err := parseUpdate("unset", unsetFields)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.update(ctx, filter, []*updateOperation{op}, false, true)
+	return err
+}
+
+// DropDocsColumn remove a column by the name for doc use filter
+func (c *Collection) DropDocsColumn(ctx context.Context, field string, filter types.Filter) error {
+	return c.DropColumns(ctx, filter, []string{field})
+}
+
+// Distinct Finds the distinct values for a specified field across a single collection
+func (c *Collection) Distinct(ctx context.Context, field string, filter types.Filter) ([]interface{}, error) {
+	c.db.lock.RLock()
+	defer c.db.lock.RUnlock()
+
+	docs, _, err := c.findDocs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0)
+	exists := make(map[string]struct{})
+	for _, doc := range docs {
+		values, _ := lookup(doc, field)
+		for _, val := range values {
+			elems := []interface{}{val}
+			if arr, ok := val.([]interface{}); ok {
+				elems = arr
+			}
+			for _, elem := range elems {
+				key := valueKey(elem)
+				if _, ok := exists[key]; ok {
+					continue
+				}
+				exists[key] = struct{}{}
+				results = append(results, deepCopy(elem))
+			}
+		}
+	}
+
+	distinct := make([]interface{}, len(results))
+	for idx, val := range results {
+		decoded, err := decodeValue(val)
+		if err != nil {
+			return nil, err
+		}
+		distinct[idx] = decoded
+	}
+	return distinct, nil
+}
+
+// decodeValue decodes a normalized value with the default bson registry, the same as the mongodb client does.
+func decodeValue(val interface{}) (interface{}, error) {
+	result := struct {
+		V interface{} `bson:"v"`
+	}{}
+	if err := decodeDocument(document{"v": val}, &result); err != nil {
+		return nil, err
+	}
+	return result.V, nil
+}
+
+// DeleteMany delete document, return number of documents that were deleted.
+func (c *Collection) DeleteMany(ctx context.Context, filter types.Filter) (uint64, error) {
+	c.db.lock.Lock()
+	defer c.db.lock.Unlock()
+
+	docs, positions, err := c.findDocs(filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	if err := c.db.trySnapshot(ctx); err != nil {
+		return 0, err
+	}
+
+	c.db.archiveDeletedDocs(c.collName, util.ExtractRequestUserFromContext(ctx), docs)
+
+	t := c.db.getTable(c.collName, true)
+	deleted := make(map[int]struct{}, len(positions))
+	for _, pos := range positions {
+		deleted[pos] = struct{}{}
+	}
+	remain := make([]document, 0, len(t.docs)-len(positions))
+	for idx, doc := range t.docs {
+		if _, exists := deleted[idx]; !exists {
+			remain = append(remain, doc)
+		}
+	}
+	t.docs = remain
+	return uint64(len(docs)), nil
+}
+
+// UpdateMany update document, return number of documents that were modified.
+func (c *Collection) UpdateMany(ctx context.Context, filter types.Filter, doc interface{}) (uint64, error) {
+	op, err := parseUpdate("set", doc)
+	if err != nil {
+		return 0, err
+	}
+	_, modified, err := c.update(ctx, filter, []*updateOperation{op}, false, true)
+	return modified, err
+}
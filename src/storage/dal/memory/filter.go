@@ -0,0 +1,418 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matcher evaluates the mongodb style filter against the normalized documents.
+type matcher struct {
+	filter document
+}
+
+// newMatcher parses the filter into a matcher, nil filter matches all documents.
+func newMatcher(filter types.Filter) (*matcher, error) {
+	doc, err := toDocument(filter)
+	if err != nil {
+		return nil, fmt.Errorf("parse filter %v failed, err: %v", filter, err)
+	}
+	return &matcher{filter: doc}, nil
+}
+
+// match checks if the document matches the filter.
+func (m *matcher) match(doc document) (bool, error) {
+	return matchDocument(doc, m.filter)
+}
+
+func matchDocument(doc document, filter document) (bool, error) {
+	for key, cond := range filter {
+		matched, err := matchField(doc, key, cond)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchField(doc document, key string, cond interface{}) (bool, error) {
+	switch key {
+	case "$and":
+		subs, err := subFilters(key, cond)
+		if err != nil {
+			return false, err
+		}
+		for _, sub := range subs {
+			matched, err := matchDocument(doc, sub)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	case "$or":
+		subs, err := subFilters(key, cond)
+		if err != nil {
+			return false, err
+		}
+		for _, sub := range subs {
+			matched, err := matchDocument(doc, sub)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$nor":
+		subs, err := subFilters(key, cond)
+		if err != nil {
+			return false, err
+		}
+		for _, sub := range subs {
+			matched, err := matchDocument(doc, sub)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$comment":
+		return true, nil
+	}
+
+	if strings.HasPrefix(key, "$") {
+		return false, fmt.Errorf("unsupported top level operator %s", key)
+	}
+
+	values, exists := lookup(doc, key)
+	return matchCondition(values, exists, cond)
+}
+
+func subFilters(op string, cond interface{}) ([]document, error) {
+	arr, ok := cond.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("%s must be a nonempty array", op)
+	}
+
+	subs := make([]document, len(arr))
+	for idx, elem := range arr {
+		sub, ok := elem.(document)
+		if !ok {
+			return nil, fmt.Errorf("%s entries must be documents", op)
+		}
+		subs[idx] = sub
+	}
+	return subs, nil
+}
+
+// isOperatorDocument checks if the condition is an operator expression like {"$in": [...]}.
+func isOperatorDocument(cond interface{}) bool {
+	doc, ok := cond.(document)
+	if !ok || len(doc) == 0 {
+		return false
+	}
+	for key := range doc {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCondition checks if the field values match the condition.
+func matchCondition(values []interface{}, exists bool, cond interface{}) (bool, error) {
+	if !isOperatorDocument(cond) {
+		if regex, ok := cond.(primitive.Regex); ok {
+			return matchRegex(values, regex.Pattern, regex.Options)
+		}
+		return matchEqual(values, exists, cond), nil
+	}
+
+	ops := cond.(document)
+	for op, operand := range ops {
+		var matched bool
+		var err error
+		switch op {
+		case "$eq":
+			matched = matchEqual(values, exists, operand)
+		case "$ne":
+			matched = !matchEqual(values, exists, operand)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = matchCompare(values, op, operand)
+		case "$in":
+			matched, err = matchIn(values, exists, operand)
+		case "$nin":
+			matched, err = matchIn(values, exists, operand)
+			matched = !matched
+		case "$exists":
+			matched = exists == isTrue(operand)
+		case "$regex":
+			options, _ := ops["$options"].(string)
+			switch pattern := operand.(type) {
+			case string:
+				matched, err = matchRegex(values, pattern, options)
+			case primitive.Regex:
+				if options == "" {
+					options = pattern.Options
+				}
+				matched, err = matchRegex(values, pattern.Pattern, options)
+			default:
+				err = fmt.Errorf("$regex has to be a string")
+			}
+		case "$options":
+			// handled with $regex
+			matched = true
+		case "$not":
+			matched, err = matchCondition(values, exists, operand)
+			matched = !matched
+		case "$size":
+			matched = matchSize(values, operand)
+		case "$all":
+			matched, err = matchAll(values, operand)
+		case "$elemMatch":
+			matched, err = matchElem(values, operand)
+		case "$type":
+			matched = matchType(values, operand)
+		default:
+			err = fmt.Errorf("unsupported operator %s", op)
+		}
+
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// candidates returns the values used to compare with the operand, arrays are compared with both
+// the whole array and each of its elements.
+func candidates(values []interface{}) []interface{} {
+	all := make([]interface{}, 0, len(values))
+	for _, val := range values {
+		all = append(all, val)
+		if arr, ok := val.([]interface{}); ok {
+			all = append(all, arr...)
+		}
+	}
+	return all
+}
+
+func matchEqual(values []interface{}, exists bool, operand interface{}) bool {
+	if operand == nil && (!exists || len(values) == 0) {
+		return true
+	}
+	for _, val := range candidates(values) {
+		if equalValues(val, operand) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(values []interface{}, op string, operand interface{}) bool {
+	for _, val := range candidates(values) {
+		// mongodb only compares the values with the same type
+		if typeOrder(val) != typeOrder(operand) {
+			continue
+		}
+		c := compareValues(val, operand)
+		switch op {
+		case "$gt":
+			if c > 0 {
+				return true
+			}
+		case "$gte":
+			if c >= 0 {
+				return true
+			}
+		case "$lt":
+			if c < 0 {
+				return true
+			}
+		case "$lte":
+			if c <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchIn(values []interface{}, exists bool, operand interface{}) (bool, error) {
+	arr, ok := operand.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("$in needs an array")
+	}
+
+	for _, elem := range arr {
+		if regex, ok := elem.(primitive.Regex); ok {
+			matched, err := matchRegex(values, regex.Pattern, regex.Options)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+			continue
+		}
+		if matchEqual(values, exists, elem) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchRegex(values []interface{}, pattern, options string) (bool, error) {
+	flags := ""
+	for _, opt := range options {
+		switch opt {
+		case 'i', 'm', 's':
+			flags += string(opt)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("invalid regex %s, err: %v", pattern, err)
+	}
+
+	for _, val := range candidates(values) {
+		if str, ok := val.(string); ok && re.MatchString(str) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchSize(values []interface{}, operand interface{}) bool {
+	size := toFloat(operand)
+	for _, val := range values {
+		if arr, ok := val.([]interface{}); ok && float64(len(arr)) == size {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAll(values []interface{}, operand interface{}) (bool, error) {
+	arr, ok := operand.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("$all needs an array")
+	}
+	if len(arr) == 0 {
+		return false, nil
+	}
+	for _, elem := range arr {
+		if !matchEqual(values, true, elem) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchElem(values []interface{}, operand interface{}) (bool, error) {
+	cond, ok := operand.(document)
+	if !ok {
+		return false, fmt.Errorf("$elemMatch needs an object")
+	}
+
+	for _, val := range values {
+		arr, ok := val.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, elem := range arr {
+			var matched bool
+			var err error
+			if isOperatorDocument(cond) {
+				matched, err = matchCondition([]interface{}{elem}, true, cond)
+			} else if sub, ok := elem.(document); ok {
+				matched, err = matchDocument(sub, cond)
+			}
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+var typeAliases = map[string]int{
+	"double":    orderNumber,
+	"int":       orderNumber,
+	"long":      orderNumber,
+	"decimal":   orderNumber,
+	"number":    orderNumber,
+	"string":    orderString,
+	"object":    orderDocument,
+	"array":     orderArray,
+	"binData":   orderBinary,
+	"objectId":  orderObjectID,
+	"bool":      orderBool,
+	"date":      orderDate,
+	"null":      orderNull,
+	"timestamp": orderTimestamp,
+	"regex":     orderRegex,
+}
+
+func matchType(values []interface{}, operand interface{}) bool {
+	alias, ok := operand.(string)
+	if !ok {
+		return false
+	}
+	order, ok := typeAliases[alias]
+	if !ok {
+		return false
+	}
+	for _, val := range candidates(values) {
+		if typeOrder(val) == order {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(val interface{}) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case nil:
+		return false
+	default:
+		if typeOrder(v) == orderNumber {
+			return toFloat(v) != 0
+		}
+		return true
+	}
+}
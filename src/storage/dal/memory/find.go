@@ -0,0 +1,193 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"configcenter/src/storage/dal/types"
+)
+
+// Find define a find operation
+type Find struct {
+	*Collection
+
+	projection []string
+	filter     types.Filter
+	start      uint64
+	limit      uint64
+	sort       []sortKey
+
+	option types.FindOpts
+}
+
+var _ types.Find = new(Find)
+
+// Fields 查询字段
+func (f *Find) Fields(fields ...string) types.Find {
+	for _, field := range fields {
+		if len(field) <= 0 {
+			continue
+		}
+		f.projection = append(f.projection, field)
+	}
+	return f
+}
+
+// Sort 查询排序, the same format as the mongodb implementation, e.g. "host_id, -host_name" or
+// "host_id:1, host_name:-1"
+func (f *Find) Sort(sort string) types.Find {
+	if sort == "" {
+		return f
+	}
+
+	f.sort = make([]sortKey, 0)
+	for _, sortItem := range strings.Split(sort, ",") {
+		sortItemArr := strings.Split(strings.TrimSpace(sortItem), ":")
+		sortKeyName := strings.TrimLeft(sortItemArr[0], "+-")
+		if len(sortItemArr) == 2 {
+			desc := strings.TrimSpace(sortItemArr[1]) == "-1"
+			f.sort = append(f.sort, sortKey{field: sortKeyName, desc: desc})
+			continue
+		}
+		f.sort = append(f.sort, sortKey{field: sortKeyName, desc: strings.HasPrefix(sortItemArr[0], "-")})
+	}
+	return f
+}
+
+// Start 查询上标
+func (f *Find) Start(start uint64) types.Find {
+	f.start = start
+	return f
+}
+
+// Limit 查询限制
+func (f *Find) Limit(limit uint64) types.Find {
+	f.limit = limit
+	return f
+}
+
+// Option set find options
+func (f *Find) Option(opts ...*types.FindOpts) {
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.WithObjectID != nil {
+			f.option.WithObjectID = opt.WithObjectID
+		}
+		if opt.WithCount != nil {
+			f.option.WithCount = opt.WithCount
+		}
+	}
+}
+
+// find returns the matched documents after sorting, paging and projection, and the total matched count.
+func (f *Find) find() ([]document, int, error) {
+	f.db.lock.RLock()
+	defer f.db.lock.RUnlock()
+
+	docs, _, err := f.findDocs(f.filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(docs)
+
+	sorted := append(make([]document, 0, len(docs)), docs...)
+	sortDocuments(sorted, f.sort)
+
+	if f.start > 0 {
+		if f.start >= uint64(len(sorted)) {
+			sorted = make([]document, 0)
+		} else {
+			sorted = sorted[f.start:]
+		}
+	}
+	if f.limit > 0 && f.limit < uint64(len(sorted)) {
+		sorted = sorted[:f.limit]
+	}
+
+	withID := f.option.WithObjectID != nil && *f.option.WithObjectID
+	results := make([]document, len(sorted))
+	for idx, doc := range sorted {
+		if len(f.projection) == 0 {
+			result := deepCopy(doc).(document)
+			if !withID {
+				delete(result, "_id")
+			}
+			results[idx] = result
+			continue
+		}
+		results[idx] = projectFields(doc, f.projection, withID || f.hasIDProjection())
+	}
+	return results, total, nil
+}
+
+func (f *Find) hasIDProjection() bool {
+	for _, field := range f.projection {
+		if field == "_id" {
+			return true
+		}
+	}
+	return false
+}
+
+// All 查询多个
+func (f *Find) All(ctx context.Context, result interface{}) error {
+	docs, _, err := f.find()
+	if err != nil {
+		return err
+	}
+	return decodeDocuments(docs, result)
+}
+
+// One 查询一个
+func (f *Find) One(ctx context.Context, result interface{}) error {
+	docs, _, err := f.find()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return types.ErrDocumentNotFound
+	}
+	return decodeDocument(docs[0], result)
+}
+
+// Count 统计数量(非事务)
+func (f *Find) Count(ctx context.Context) (uint64, error) {
+	f.db.lock.RLock()
+	defer f.db.lock.RUnlock()
+
+	docs, _, err := f.findDocs(f.filter)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(docs)), nil
+}
+
+// List 查询多个数据， 当分页中start值为零的时候返回满足条件总行数
+func (f *Find) List(ctx context.Context, result interface{}) (int64, error) {
+	docs, total, err := f.find()
+	if err != nil {
+		return 0, err
+	}
+	if err := decodeDocuments(docs, result); err != nil {
+		return 0, err
+	}
+
+	if f.start == 0 || (f.option.WithCount != nil && *f.option.WithCount) {
+		return int64(total), nil
+	}
+	return 0, nil
+}
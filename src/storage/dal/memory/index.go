@@ -0,0 +1,143 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// idIndexName is the name of the default index on _id field.
+const idIndexName = "_id_"
+
+// index is a collection index, only unique indexes are enforced.
+type index struct {
+	types.Index
+	partial *matcher
+}
+
+func newIndex(idx types.Index) (*index, error) {
+	if len(idx.Keys) == 0 {
+		return nil, fmt.Errorf("index keys can not be empty")
+	}
+
+	keys := make(bson.D, len(idx.Keys))
+	names := make([]string, len(idx.Keys))
+	for i, key := range idx.Keys {
+		val, err := util.GetInt32ByInterface(key.Value)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = bson.E{Key: key.Key, Value: val}
+		names[i] = fmt.Sprintf("%s_%d", key.Key, val)
+	}
+	idx.Keys = keys
+
+	if idx.Name == "" {
+		idx.Name = strings.Join(names, "_")
+	}
+
+	result := &index{Index: idx}
+	if len(idx.PartialFilterExpression) > 0 {
+		partial, err := newMatcher(idx.PartialFilterExpression)
+		if err != nil {
+			return nil, err
+		}
+		result.partial = partial
+	}
+	return result, nil
+}
+
+// sameKeys checks if the two indexes are on the same keys.
+func (i *index) sameKeys(other *index) bool {
+	if len(i.Keys) != len(other.Keys) {
+		return false
+	}
+	for idx := range i.Keys {
+		if i.Keys[idx].Key != other.Keys[idx].Key || i.Keys[idx].Value != other.Keys[idx].Value {
+			return false
+		}
+	}
+	return true
+}
+
+// indexKeys returns all the index keys of the document, array field generates one key for each element
+// like the mongodb multikey index. returns nil if the document is not covered by the partial index.
+func (i *index) indexKeys(doc document) ([]string, error) {
+	if i.partial != nil {
+		matched, err := i.partial.match(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return nil, nil
+		}
+	}
+
+	keys := []string{""}
+	for _, key := range i.Keys {
+		values, exists := lookup(doc, key.Key)
+		if !exists || len(values) == 0 {
+			values = []interface{}{nil}
+		}
+
+		elems := make([]interface{}, 0)
+		for _, val := range values {
+			if arr, ok := val.([]interface{}); ok && len(arr) > 0 {
+				elems = append(elems, arr...)
+				continue
+			}
+			elems = append(elems, val)
+		}
+
+		next := make([]string, 0, len(keys)*len(elems))
+		for _, prefix := range keys {
+			for _, elem := range elems {
+				next = append(next, prefix+"|"+valueKey(elem))
+			}
+		}
+		keys = next
+	}
+
+	return util.StrArrayUnique(keys), nil
+}
+
+// checkUnique checks that the documents do not violate the unique indexes.
+func checkUnique(collName string, indexes []*index, docs []document) error {
+	for _, idx := range indexes {
+		if !idx.Unique {
+			continue
+		}
+
+		exists := make(map[string]struct{}, len(docs))
+		for _, doc := range docs {
+			keys, err := idx.indexKeys(doc)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if _, dup := exists[key]; dup {
+					return fmt.Errorf("E11000 duplicate key error collection: %s index: %s dup key: %s",
+						collName, idx.Name, key)
+				}
+				exists[key] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package memory is an in-memory implementation of dal.DB, it evaluates the mongodb style filters and
+// update operators used by cmdb, so that the business logic can be tested without a mongodb server.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	utiltable "configcenter/src/common/util/table"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	// register the same bson decoders as the mongodb client, so that the decoded results are the same
+	_ "configcenter/src/storage/dal/mongo/local"
+)
+
+// DB is an in-memory dal.DB implementation.
+// transactions are implemented by snapshots: the first write operation in a transaction takes a snapshot of
+// all the collections, abort restores the snapshot and commit drops it. transactions are not isolated.
+type DB struct {
+	lock      sync.RWMutex
+	tables    map[string]*table
+	sequences map[string]uint64
+	snapshots map[string]map[string]*table
+}
+
+var _ dal.DB = new(DB)
+
+// table is the data of a collection.
+type table struct {
+	docs    []document
+	indexes []*index
+}
+
+func newTable() *table {
+	return &table{
+		docs:    make([]document, 0),
+		indexes: make([]*index, 0),
+	}
+}
+
+func (t *table) clone() *table {
+	docs := make([]document, len(t.docs))
+	for idx, doc := range t.docs {
+		docs[idx] = deepCopy(doc).(document)
+	}
+	return &table{
+		docs:    docs,
+		indexes: append(make([]*index, 0, len(t.indexes)), t.indexes...),
+	}
+}
+
+// NewDB returns a new empty in-memory db
+func NewDB() *DB {
+	return &DB{
+		tables:    make(map[string]*table),
+		sequences: make(map[string]uint64),
+		snapshots: make(map[string]map[string]*table),
+	}
+}
+
+// Table collection operation
+func (d *DB) Table(collName string) types.Table {
+	return &Collection{collName: collName, db: d}
+}
+
+// getTable returns the collection data, the collection is created if not exists and create is true.
+// the caller must hold the lock.
+func (d *DB) getTable(collName string, create bool) *table {
+	t, exists := d.tables[collName]
+	if !exists && create {
+		t = newTable()
+		d.tables[collName] = t
+	}
+	return t
+}
+
+func (d *DB) redirectTable(tableName string) string {
+	if common.IsObjectInstShardingTable(tableName) {
+		tableName = common.BKTableNameBaseInst
+	} else if common.IsObjectInstAsstShardingTable(tableName) {
+		tableName = common.BKTableNameInstAsst
+	}
+	return tableName
+}
+
+// NextSequence 获取新序列号(非事务)
+func (d *DB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	sequences, err := d.NextSequences(ctx, sequenceName, 1)
+	if err != nil {
+		return 0, err
+	}
+	return sequences[0], nil
+}
+
+// NextSequences 批量获取新序列号(非事务)
+func (d *DB) NextSequences(ctx context.Context, sequenceName string, num int) ([]uint64, error) {
+	if num == 0 {
+		return make([]uint64, 0), nil
+	}
+	sequenceName = d.redirectTable(sequenceName)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	sequences := make([]uint64, num)
+	for i := 0; i < num; i++ {
+		d.sequences[sequenceName]++
+		sequences[i] = d.sequences[sequenceName]
+	}
+	return sequences, nil
+}
+
+// Ping 健康检查
+func (d *DB) Ping() error {
+	return nil
+}
+
+// HasTable 判断是否存在集合
+func (d *DB) HasTable(ctx context.Context, name string) (bool, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	_, exists := d.tables[name]
+	return exists, nil
+}
+
+// ListTables 获取所有的表名
+func (d *DB) ListTables(ctx context.Context) ([]string, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	names := make([]string, 0, len(d.tables))
+	for name := range d.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DropTable 移除集合
+func (d *DB) DropTable(ctx context.Context, name string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if err := d.trySnapshot(ctx); err != nil {
+		return err
+	}
+	delete(d.tables, name)
+	return nil
+}
+
+// CreateTable 创建集合
+func (d *DB) CreateTable(ctx context.Context, name string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, exists := d.tables[name]; exists {
+		return fmt.Errorf("collection %s already exists", name)
+	}
+	if err := d.trySnapshot(ctx); err != nil {
+		return err
+	}
+	d.tables[name] = newTable()
+	return nil
+}
+
+// RenameTable 更新集合名称
+func (d *DB) RenameTable(ctx context.Context, prevName, currName string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	t, exists := d.tables[prevName]
+	if !exists {
+		return fmt.Errorf("source collection %s does not exist", prevName)
+	}
+	if _, exists := d.tables[currName]; exists {
+		return fmt.Errorf("target collection %s already exists", currName)
+	}
+	if err := d.trySnapshot(ctx); err != nil {
+		return err
+	}
+	d.tables[currName] = t
+	delete(d.tables, prevName)
+	return nil
+}
+
+// IsDuplicatedError check duplicated error
+func (d *DB) IsDuplicatedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == types.ErrDuplicated {
+		return true
+	}
+	return strings.Contains(err.Error(), "E11000 duplicate") ||
+		strings.Contains(err.Error(), "already exists with a different name")
+}
+
+// IsNotFoundError check the not found error
+func (d *DB) IsNotFoundError(err error) bool {
+	return err == types.ErrDocumentNotFound
+}
+
+// Close do nothing for in-memory db
+func (d *DB) Close() error {
+	return nil
+}
+
+// txnSessionID returns the transaction session id in the context, returns empty string if not in transaction.
+func txnSessionID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(common.TransactionIdHeader).(string)
+	return id
+}
+
+// trySnapshot takes a snapshot of all the collections if the context is in a transaction that has no
+// snapshot yet. the caller must hold the write lock.
+func (d *DB) trySnapshot(ctx context.Context) error {
+	sessionID := txnSessionID(ctx)
+	if sessionID == "" {
+		return nil
+	}
+	if _, exists := d.snapshots[sessionID]; exists {
+		return nil
+	}
+
+	snapshot := make(map[string]*table, len(d.tables))
+	for name, t := range d.tables {
+		snapshot[name] = t.clone()
+	}
+	d.snapshots[sessionID] = snapshot
+	return nil
+}
+
+// CommitTransaction 提交事务
+func (d *DB) CommitTransaction(ctx context.Context, cap *metadata.TxnCapable) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.snapshots, cap.SessionID)
+	return nil
+}
+
+// AbortTransaction 取消事务, the collections are restored to the state before the transaction's first write
+func (d *DB) AbortTransaction(ctx context.Context, cap *metadata.TxnCapable) (bool, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	snapshot, exists := d.snapshots[cap.SessionID]
+	if !exists {
+		return false, nil
+	}
+	d.tables = snapshot
+	delete(d.snapshots, cap.SessionID)
+	return false, nil
+}
+
+// InitTxnManager do nothing for in-memory db, transactions do not need redis
+func (d *DB) InitTxnManager(r redis.Client) error {
+	return nil
+}
+
+// archiveDeletedDocs archives the deleted docs like the mongodb implementation. the caller must hold the lock.
+func (d *DB) archiveDeletedDocs(collName, operator string, docs []document) {
+	delArchiveTable, exists := utiltable.GetDelArchiveTable(collName)
+	if !exists || len(docs) == 0 {
+		return
+	}
+
+	fields := utiltable.GetDelArchiveFields(collName)
+	archive := d.getTable(delArchiveTable, true)
+	for _, doc := range docs {
+		detail := deepCopy(doc).(document)
+		if len(fields) > 0 {
+			detail = projectFields(doc, fields, true)
+		}
+		oid := fmt.Sprint(detail["_id"])
+		if id, ok := detail["_id"].(primitive.ObjectID); ok {
+			oid = id.Hex()
+		}
+		delete(detail, "_id")
+
+		archive.docs = append(archive.docs, document{
+			"_id":      primitive.NewObjectID(),
+			"oid":      oid,
+			"coll":     collName,
+			"time":     primitive.NewDateTimeFromTime(time.Now()),
+			"operator": operator,
+			"detail":   detail,
+		})
+	}
+}
@@ -0,0 +1,276 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testHost struct {
+	ID     int64    `bson:"bk_host_id"`
+	Name   string   `bson:"name"`
+	BizID  int64    `bson:"bk_biz_id"`
+	Tags   []string `bson:"tags"`
+	Detail struct {
+		OS string `bson:"os"`
+	} `bson:"detail"`
+}
+
+func prepareHosts(t *testing.T) *DB {
+	db := NewDB()
+	docs := []mapstr.MapStr{
+		{"bk_host_id": int64(1), "name": "host-a", "bk_biz_id": int64(1), "tags": []string{"x", "y"},
+			"detail": mapstr.MapStr{"os": "linux"}},
+		{"bk_host_id": int64(2), "name": "host-b", "bk_biz_id": int64(1), "tags": []string{"y"},
+			"detail": mapstr.MapStr{"os": "windows"}},
+		{"bk_host_id": int64(3), "name": "Host-C", "bk_biz_id": int64(2), "detail": mapstr.MapStr{"os": "linux"}},
+	}
+	require.NoError(t, db.Table("hosts").Insert(context.Background(), docs))
+	return db
+}
+
+func TestFindFilters(t *testing.T) {
+	db := prepareHosts(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		filter map[string]interface{}
+		ids    []int64
+	}{
+		{filter: nil, ids: []int64{1, 2, 3}},
+		{filter: map[string]interface{}{"bk_biz_id": 1}, ids: []int64{1, 2}},
+		{filter: map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBIN: []int64{1, 3}}},
+			ids: []int64{1, 3}},
+		{filter: map[string]interface{}{"bk_host_id": map[string]interface{}{common.BKDBNIN: []int64{1, 3}}},
+			ids: []int64{2}},
+		{filter: map[string]interface{}{"detail.os": "linux"}, ids: []int64{1, 3}},
+		{filter: map[string]interface{}{"tags": "y"}, ids: []int64{1, 2}},
+		{filter: map[string]interface{}{"tags": map[string]interface{}{common.BKDBExists: false}}, ids: []int64{3}},
+		{filter: map[string]interface{}{"name": map[string]interface{}{common.BKDBLIKE: "^host",
+			common.BKDBOPTIONS: "i"}}, ids: []int64{1, 2, 3}},
+		{filter: map[string]interface{}{"name": map[string]interface{}{common.BKDBLIKE: "^host"}},
+			ids: []int64{1, 2}},
+		{filter: map[string]interface{}{common.BKDBOR: []map[string]interface{}{{"bk_host_id": 1}, {"bk_biz_id": 2}}},
+			ids: []int64{1, 3}},
+		{filter: map[string]interface{}{common.BKDBAND: []map[string]interface{}{
+			{"bk_host_id": map[string]interface{}{common.BKDBGT: 1}}, {"bk_host_id": map[string]interface{}{
+				common.BKDBLTE: 3}}, {"bk_biz_id": map[string]interface{}{common.BKDBNE: 2}}}}, ids: []int64{2}},
+	}
+
+	for _, c := range cases {
+		hosts := make([]testHost, 0)
+		err := db.Table("hosts").Find(c.filter).Sort("bk_host_id").All(ctx, &hosts)
+		require.NoError(t, err)
+
+		ids := make([]int64, 0)
+		for _, host := range hosts {
+			ids = append(ids, host.ID)
+		}
+		require.Equal(t, c.ids, ids, "filter: %v", c.filter)
+	}
+}
+
+func TestFindOptions(t *testing.T) {
+	db := prepareHosts(t)
+	ctx := context.Background()
+
+	hosts := make([]map[string]interface{}, 0)
+	cnt, err := db.Table("hosts").Find(nil).Fields("bk_host_id", "detail.os").Sort("bk_host_id:-1").Start(0).
+		Limit(2).List(ctx, &hosts)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, cnt)
+	require.Len(t, hosts, 2)
+	require.EqualValues(t, 3, hosts[0]["bk_host_id"])
+	require.Equal(t, map[string]interface{}{"os": "linux"}, hosts[0]["detail"])
+	require.NotContains(t, hosts[0], "name")
+	require.NotContains(t, hosts[0], "_id")
+
+	host := testHost{}
+	err = db.Table("hosts").Find(map[string]interface{}{"bk_host_id": 2}).One(ctx, &host)
+	require.NoError(t, err)
+	require.Equal(t, "host-b", host.Name)
+	require.Equal(t, "windows", host.Detail.OS)
+
+	err = db.Table("hosts").Find(map[string]interface{}{"bk_host_id": 4}).One(ctx, &host)
+	require.True(t, db.IsNotFoundError(err))
+
+	count, err := db.Table("hosts").Find(map[string]interface{}{"bk_biz_id": 1}).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+
+	distinct, err := db.Table("hosts").Distinct(ctx, "tags", nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []interface{}{"x", "y"}, distinct)
+}
+
+func TestUpdate(t *testing.T) {
+	db := prepareHosts(t)
+	ctx := context.Background()
+	table := db.Table("hosts")
+
+	cnt, err := table.UpdateMany(ctx, map[string]interface{}{"bk_biz_id": 1}, map[string]interface{}{"detail.os": "aix"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, cnt)
+
+	err = table.UpdateMultiModel(ctx, map[string]interface{}{"bk_host_id": 1},
+		types.ModeUpdate{Op: types.UpdateOpAddToSet, Doc: map[string]interface{}{"tags": "z"}},
+		types.ModeUpdate{Op: "inc", Doc: map[string]interface{}{"bk_biz_id": 10}})
+	require.NoError(t, err)
+
+	err = table.UpdateMultiModel(ctx, map[string]interface{}{"bk_host_id": 1},
+		types.ModeUpdate{Op: types.UpdateOpPull, Doc: map[string]interface{}{"tags": "x"}})
+	require.NoError(t, err)
+
+	err = table.UpdateMultiModel(ctx, map[string]interface{}{"bk_host_id": 1},
+		types.ModeUpdate{Op: "push", Doc: map[string]interface{}{"tags": map[string]interface{}{
+			"$each": []string{"u", "v"}, "$slice": -3}}})
+	require.NoError(t, err)
+
+	host := testHost{}
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 1}).One(ctx, &host))
+	require.Equal(t, []string{"z", "u", "v"}, host.Tags)
+	require.EqualValues(t, 11, host.BizID)
+	require.Equal(t, "aix", host.Detail.OS)
+
+	err = table.Upsert(ctx, map[string]interface{}{"bk_host_id": 4}, map[string]interface{}{"name": "host-d"})
+	require.NoError(t, err)
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 4}).One(ctx, &host))
+	require.Equal(t, "host-d", host.Name)
+
+	// the positional operator updates the first matched array element
+	require.NoError(t, table.Update(ctx, map[string]interface{}{"bk_host_id": 1, "tags": "u"},
+		map[string]interface{}{"tags.$": "w"}))
+	require.NoError(t, table.Find(map[string]interface{}{"bk_host_id": 1}).One(ctx, &host))
+	require.Equal(t, []string{"z", "w", "v"}, host.Tags)
+
+	require.NoError(t, table.DropColumn(ctx, "tags"))
+	count, err := table.Find(map[string]interface{}{"tags": map[string]interface{}{common.BKDBExists: true}}).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, count)
+}
+
+func TestUniqueIndex(t *testing.T) {
+	db := prepareHosts(t)
+	ctx := context.Background()
+	table := db.Table("hosts")
+
+	err := table.CreateIndex(ctx, types.Index{Keys: bson.D{{Key: "name", Value: 1}}, Name: "name", Unique: true})
+	require.NoError(t, err)
+
+	err = table.Insert(ctx, map[string]interface{}{"bk_host_id": 4, "name": "host-a"})
+	require.True(t, db.IsDuplicatedError(err))
+
+	err = table.Update(ctx, map[string]interface{}{"bk_host_id": 2}, map[string]interface{}{"name": "host-a"})
+	require.True(t, db.IsDuplicatedError(err))
+
+	// partial index only applies to the documents that match the partial filter
+	err = table.CreateIndex(ctx, types.Index{Keys: bson.D{{Key: "ip", Value: 1}}, Name: "ip", Unique: true,
+		PartialFilterExpression: map[string]interface{}{"ip": map[string]interface{}{common.BKDBType: "string"}}})
+	require.NoError(t, err)
+	require.NoError(t, table.Insert(ctx, map[string]interface{}{"bk_host_id": 5, "name": "host-e"}))
+	require.NoError(t, table.Insert(ctx, map[string]interface{}{"bk_host_id": 6, "name": "host-f"}))
+
+	indexes, err := table.Indexes(ctx)
+	require.NoError(t, err)
+	require.Len(t, indexes, 3)
+
+	require.NoError(t, table.DropIndex(ctx, "name"))
+	require.NoError(t, table.Insert(ctx, map[string]interface{}{"bk_host_id": 7, "name": "host-a"}))
+}
+
+func TestSequenceAndTransaction(t *testing.T) {
+	db := prepareHosts(t)
+	ctx := context.Background()
+
+	id, err := db.NextSequence(ctx, "hosts")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, id)
+	ids, err := db.NextSequences(ctx, "hosts", 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 3, 4}, ids)
+
+	txn := &metadata.TxnCapable{SessionID: "session"}
+	txnCtx := context.WithValue(ctx, common.TransactionIdHeader, txn.SessionID)
+	require.NoError(t, db.Table("hosts").Delete(txnCtx, map[string]interface{}{"bk_biz_id": 1}))
+	_, err = db.AbortTransaction(txnCtx, txn)
+	require.NoError(t, err)
+
+	count, err := db.Table("hosts").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, count)
+
+	require.NoError(t, db.Table("hosts").Delete(txnCtx, map[string]interface{}{"bk_biz_id": 1}))
+	require.NoError(t, db.CommitTransaction(txnCtx, txn))
+	count, err = db.Table("hosts").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+}
+
+func TestDeleteArchive(t *testing.T) {
+	db := NewDB()
+	ctx := context.Background()
+
+	table := db.Table(common.BKTableNameBaseSet)
+	require.NoError(t, table.Insert(ctx, map[string]interface{}{common.BKSetIDField: 1, common.BKSetNameField: "a"}))
+	require.NoError(t, table.Delete(ctx, map[string]interface{}{common.BKSetIDField: 1}))
+
+	archives := make([]metadata.DeleteArchive, 0)
+	err := db.Table(common.BKTableNameDelArchive).Find(nil).All(ctx, &archives)
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	require.Equal(t, common.BKTableNameBaseSet, archives[0].Coll)
+}
+
+func TestAggregate(t *testing.T) {
+	db := prepareHosts(t)
+	ctx := context.Background()
+
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: map[string]interface{}{"detail.os": "linux"}},
+		{common.BKDBGroup: map[string]interface{}{"_id": "$bk_biz_id", "count": map[string]interface{}{
+			common.BKDBSum: 1}}},
+		{common.BKDBSort: bson.D{{Key: "_id", Value: -1}}},
+	}
+
+	result := make([]struct {
+		ID    int64 `bson:"_id"`
+		Count int64 `bson:"count"`
+	}, 0)
+	require.NoError(t, db.Table("hosts").AggregateAll(ctx, pipeline, &result))
+	require.Len(t, result, 2)
+	require.EqualValues(t, 2, result[0].ID)
+	require.EqualValues(t, 1, result[0].Count)
+
+	// get the first host of each biz by the host id
+	pipeline = []map[string]interface{}{
+		{common.BKDBSort: map[string]interface{}{"bk_host_id": 1}},
+		{common.BKDBGroup: map[string]interface{}{"_id": "$bk_biz_id", "doc": map[string]interface{}{
+			"$first": "$$ROOT"}}},
+		{common.BKDBReplaceRoot: map[string]interface{}{"newRoot": "$doc"}},
+		{common.BKDBSort: map[string]interface{}{"bk_host_id": 1}},
+	}
+	hosts := make([]testHost, 0)
+	require.NoError(t, db.Table("hosts").AggregateAll(ctx, pipeline, &hosts))
+	require.Len(t, hosts, 2)
+	require.Equal(t, "host-a", hosts[0].Name)
+	require.Equal(t, "Host-C", hosts[1].Name)
+}
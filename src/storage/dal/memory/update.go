@@ -0,0 +1,351 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// updateOperation is a parsed mongodb update operator with its fields, like {"$set": {"a": 1}}.
+type updateOperation struct {
+	op     string
+	fields document
+}
+
+// parseUpdate parses the update operators, the op name is without the "$" prefix.
+func parseUpdate(op string, doc interface{}) (*updateOperation, error) {
+	fields, err := toDocument(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s update data failed, err: %v", op, err)
+	}
+	return &updateOperation{op: strings.TrimPrefix(op, "$"), fields: fields}, nil
+}
+
+// resolvePositional replaces the positional operator "$" in the update fields with the index of the first array
+// element that matches the filter, like {"detail.$.status": 1} with filter {"detail.sub_task_id": "a"}.
+func (u *updateOperation) resolvePositional(doc document, filter document) (*updateOperation, error) {
+	resolved := &updateOperation{op: u.op, fields: make(document, len(u.fields))}
+	for field, value := range u.fields {
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			if part != "$" {
+				continue
+			}
+			idx, err := positionalIndex(doc, strings.Join(parts[:i], "."), filter)
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = strconv.Itoa(idx)
+			break
+		}
+		resolved.fields[strings.Join(parts, ".")] = value
+	}
+	return resolved, nil
+}
+
+// positionalIndex returns the index of the first element in the array field that matches the filter conditions
+// on the array field.
+func positionalIndex(doc document, arrayField string, filter document) (int, error) {
+	val, _ := getField(doc, arrayField)
+	arr, ok := val.([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("the positional operator did not find the array field %s", arrayField)
+	}
+
+	for idx, elem := range arr {
+		matched, err := matchArrayElement(elem, arrayField, filter)
+		if err != nil {
+			return 0, err
+		}
+		if matched {
+			return idx, nil
+		}
+	}
+	return 0, fmt.Errorf("the positional operator did not find the match needed from the query")
+}
+
+// matchArrayElement checks if the array element matches all the filter conditions on the array field.
+func matchArrayElement(elem interface{}, arrayField string, filter document) (bool, error) {
+	matched := false
+	for key, cond := range filter {
+		var ok bool
+		var err error
+		switch {
+		case key == arrayField:
+			ok, err = matchCondition([]interface{}{elem}, true, cond)
+		case strings.HasPrefix(key, arrayField+"."):
+			sub, isDoc := elem.(document)
+			if isDoc {
+				ok, err = matchField(sub, strings.TrimPrefix(key, arrayField+"."), cond)
+			}
+		default:
+			continue
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+		matched = true
+	}
+	return matched, nil
+}
+
+// apply applies the update operation to the document.
+func (u *updateOperation) apply(doc document) error {
+	for field, value := range u.fields {
+		if field == "_id" && u.op != "setOnInsert" {
+			if old, exists := doc["_id"]; exists && !equalValues(old, value) {
+				return fmt.Errorf("performing an update on the path '_id' would modify the immutable field '_id'")
+			}
+		}
+
+		var err error
+		switch u.op {
+		case "set", "setOnInsert":
+			err = setField(doc, field, deepCopy(value))
+		case "unset":
+			unsetField(doc, field)
+		case "inc":
+			err = incField(doc, field, value)
+		case "rename":
+			newField, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("$rename target of %s must be a string", field)
+			}
+			if old, exists := getField(doc, field); exists {
+				unsetField(doc, field)
+				err = setField(doc, newField, old)
+			}
+		case "addToSet":
+			err = addToSetField(doc, field, value)
+		case "push":
+			err = pushField(doc, field, value)
+		case "pull":
+			err = pullField(doc, field, value)
+		case "pullAll":
+			err = pullAllField(doc, field, value)
+		case "currentDate":
+			err = setField(doc, field, primitive.NewDateTimeFromTime(time.Now()))
+		default:
+			return fmt.Errorf("unsupported update operator $%s", u.op)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func incField(doc document, field string, value interface{}) error {
+	if typeOrder(value) != orderNumber {
+		return fmt.Errorf("cannot increment with non-numeric argument: {%s: %v}", field, value)
+	}
+
+	old, exists := getField(doc, field)
+	if !exists || old == nil {
+		return setField(doc, field, value)
+	}
+	if typeOrder(old) != orderNumber {
+		return fmt.Errorf("cannot apply $inc to a value of non-numeric type, field: %s", field)
+	}
+
+	switch o := old.(type) {
+	case int32:
+		if v, ok := value.(int32); ok {
+			return setField(doc, field, o+v)
+		}
+		if v, ok := value.(int64); ok {
+			return setField(doc, field, int64(o)+v)
+		}
+	case int64:
+		switch v := value.(type) {
+		case int32:
+			return setField(doc, field, o+int64(v))
+		case int64:
+			return setField(doc, field, o+v)
+		}
+	}
+	return setField(doc, field, toFloat(old)+toFloat(value))
+}
+
+// arrayField returns the array value of the field, missing field is treated as an empty array.
+func arrayField(doc document, field, op string) ([]interface{}, error) {
+	old, exists := getField(doc, field)
+	if !exists || old == nil {
+		return make([]interface{}, 0), nil
+	}
+	arr, ok := old.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot apply $%s to non-array field %s", op, field)
+	}
+	return arr, nil
+}
+
+// eachValues returns the values to add, {"$each": [...]} modifier adds all the elements.
+func eachValues(value interface{}) []interface{} {
+	if mod, ok := value.(document); ok {
+		if each, ok := mod["$each"].([]interface{}); ok {
+			return each
+		}
+	}
+	return []interface{}{value}
+}
+
+func addToSetField(doc document, field string, value interface{}) error {
+	arr, err := arrayField(doc, field, "addToSet")
+	if err != nil {
+		return err
+	}
+
+	for _, elem := range eachValues(value) {
+		exists := false
+		for _, old := range arr {
+			if equalValues(old, elem) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			arr = append(arr, deepCopy(elem))
+		}
+	}
+	return setField(doc, field, arr)
+}
+
+func pushField(doc document, field string, value interface{}) error {
+	arr, err := arrayField(doc, field, "push")
+	if err != nil {
+		return err
+	}
+
+	for _, elem := range eachValues(value) {
+		arr = append(arr, deepCopy(elem))
+	}
+
+	// $slice keeps the first n elements if n is positive, or the last -n elements if n is negative
+	if mod, ok := value.(document); ok {
+		if slice, exists := mod["$slice"]; exists {
+			n := int(toFloat(slice))
+			switch {
+			case n >= 0 && n < len(arr):
+				arr = arr[:n]
+			case n < 0 && -n < len(arr):
+				arr = arr[len(arr)+n:]
+			}
+		}
+	}
+	return setField(doc, field, arr)
+}
+
+func pullField(doc document, field string, value interface{}) error {
+	old, exists := getField(doc, field)
+	if !exists {
+		return nil
+	}
+	arr, ok := old.([]interface{})
+	if !ok {
+		return fmt.Errorf("cannot apply $pull to a non-array value, field: %s", field)
+	}
+
+	remain := make([]interface{}, 0, len(arr))
+	for _, elem := range arr {
+		var matched bool
+		var err error
+		switch cond := value.(type) {
+		case document:
+			if isOperatorDocument(cond) {
+				matched, err = matchCondition([]interface{}{elem}, true, cond)
+			} else if sub, ok := elem.(document); ok {
+				matched, err = matchDocument(sub, cond)
+			}
+		default:
+			matched = equalValues(elem, cond)
+		}
+		if err != nil {
+			return err
+		}
+		if !matched {
+			remain = append(remain, elem)
+		}
+	}
+	return setField(doc, field, remain)
+}
+
+func pullAllField(doc document, field string, value interface{}) error {
+	values, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("$pullAll requires an array argument, field: %s", field)
+	}
+
+	old, exists := getField(doc, field)
+	if !exists {
+		return nil
+	}
+	arr, ok := old.([]interface{})
+	if !ok {
+		return fmt.Errorf("cannot apply $pullAll to a non-array value, field: %s", field)
+	}
+
+	remain := make([]interface{}, 0, len(arr))
+	for _, elem := range arr {
+		matched := false
+		for _, val := range values {
+			if equalValues(elem, val) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			remain = append(remain, elem)
+		}
+	}
+	return setField(doc, field, remain)
+}
+
+// upsertSeed returns the document inserted by upsert from the equality conditions of the filter.
+func upsertSeed(filter document) document {
+	seed := make(document)
+	for key, cond := range filter {
+		if strings.HasPrefix(key, "$") {
+			if key == "$and" {
+				subs, err := subFilters(key, cond)
+				if err != nil {
+					continue
+				}
+				for _, sub := range subs {
+					for k, v := range upsertSeed(sub) {
+						_ = setField(seed, k, v)
+					}
+				}
+			}
+			continue
+		}
+
+		if isOperatorDocument(cond) {
+			if eq, exists := cond.(document)["$eq"]; exists {
+				_ = setField(seed, key, deepCopy(eq))
+			}
+			continue
+		}
+		if _, ok := cond.(primitive.Regex); ok {
+			continue
+		}
+		_ = setField(seed, key, deepCopy(cond))
+	}
+	return seed
+}
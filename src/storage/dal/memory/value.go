@@ -0,0 +1,549 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// document is the normalized form of a stored document, embedded documents are also
+// document and arrays are []interface{}, scalar values keep the bson primitive types.
+type document = map[string]interface{}
+
+// rawRegistry is the original bson registry of the mongo driver, it is used to decode
+// the marshaled values into bson primitive types without the cmdb custom decoders.
+var rawRegistry = bson.NewRegistryBuilder().Build()
+
+// toDocument converts a document like value (struct, map, bson.D ...) into the normalized document.
+func toDocument(val interface{}) (document, error) {
+	if val == nil {
+		return make(document), nil
+	}
+
+	raw, err := bson.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	d := bson.D{}
+	if err := bson.UnmarshalWithRegistry(rawRegistry, raw, &d); err != nil {
+		return nil, err
+	}
+
+	doc, _ := normalize(d).(document)
+	return doc, nil
+}
+
+// toOrderedDocument converts a document like value into bson.D, the embedded documents are bson.D too.
+func toOrderedDocument(val interface{}) (bson.D, error) {
+	raw, err := bson.Marshal(bson.M{"v": val})
+	if err != nil {
+		return nil, err
+	}
+
+	d := bson.D{}
+	if err := bson.UnmarshalWithRegistry(rawRegistry, raw, &d); err != nil {
+		return nil, err
+	}
+
+	if len(d) == 0 {
+		return bson.D{}, nil
+	}
+
+	switch v := d[0].Value.(type) {
+	case bson.D:
+		return v, nil
+	case nil:
+		return bson.D{}, nil
+	default:
+		return nil, fmt.Errorf("value %v is not a document", val)
+	}
+}
+
+// toValue converts an arbitrary value into the normalized value.
+func toValue(val interface{}) (interface{}, error) {
+	doc, err := toDocument(bson.M{"v": val})
+	if err != nil {
+		return nil, err
+	}
+	return doc["v"], nil
+}
+
+// normalize converts the bson decoded values into the normalized form.
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case bson.D:
+		doc := make(document, len(v))
+		for _, e := range v {
+			doc[e.Key] = normalize(e.Value)
+		}
+		return doc
+	case bson.M:
+		doc := make(document, len(v))
+		for key, value := range v {
+			doc[key] = normalize(value)
+		}
+		return doc
+	case map[string]interface{}:
+		doc := make(document, len(v))
+		for key, value := range v {
+			doc[key] = normalize(value)
+		}
+		return doc
+	case bson.A:
+		arr := make([]interface{}, len(v))
+		for idx, value := range v {
+			arr[idx] = normalize(value)
+		}
+		return arr
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for idx, value := range v {
+			arr[idx] = normalize(value)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// deepCopy copies a normalized value.
+func deepCopy(val interface{}) interface{} {
+	switch v := val.(type) {
+	case document:
+		doc := make(document, len(v))
+		for key, value := range v {
+			doc[key] = deepCopy(value)
+		}
+		return doc
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for idx, value := range v {
+			arr[idx] = deepCopy(value)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// decodeDocument decodes a normalized document into the result with the default bson registry,
+// so that it behaves the same as the documents read from mongodb.
+func decodeDocument(doc document, result interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, result)
+}
+
+// decodeDocuments decodes the normalized documents into the result slice pointer.
+func decodeDocuments(docs []document, result interface{}) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return errors.New("result argument must be a slice address")
+	}
+
+	elemt := resultv.Elem().Type().Elem()
+	slice := reflect.MakeSlice(resultv.Elem().Type(), 0, len(docs))
+	for _, doc := range docs {
+		elemp := reflect.New(elemt)
+		if err := decodeDocument(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elemp.Elem())
+	}
+
+	resultv.Elem().Set(slice)
+	return nil
+}
+
+// lookup returns the values of the dot separated field path in the document, arrays in the middle of
+// the path are expanded like mongodb does. the returned bool indicates whether the path exists or not.
+func lookup(val interface{}, path string) ([]interface{}, bool) {
+	if path == "" {
+		return []interface{}{val}, true
+	}
+
+	key, rest := path, ""
+	if idx := strings.Index(path, "."); idx >= 0 {
+		key, rest = path[:idx], path[idx+1:]
+	}
+
+	switch v := val.(type) {
+	case document:
+		field, exists := v[key]
+		if !exists {
+			return nil, false
+		}
+		if rest == "" {
+			return []interface{}{field}, true
+		}
+		return lookup(field, rest)
+	case []interface{}:
+		// numeric key refers to the array element
+		if idx, ok := parseIndex(key); ok {
+			if idx >= len(v) {
+				return nil, false
+			}
+			if rest == "" {
+				return []interface{}{v[idx]}, true
+			}
+			return lookup(v[idx], rest)
+		}
+
+		values := make([]interface{}, 0)
+		found := false
+		for _, elem := range v {
+			if _, ok := elem.(document); !ok {
+				continue
+			}
+			sub, exists := lookup(elem, path)
+			if exists {
+				found = true
+				values = append(values, sub...)
+			}
+		}
+		return values, found
+	default:
+		return nil, false
+	}
+}
+
+func parseIndex(key string) (int, bool) {
+	if key == "" {
+		return 0, false
+	}
+	idx := 0
+	for _, c := range key {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		idx = idx*10 + int(c-'0')
+	}
+	return idx, true
+}
+
+// getField returns the value of the dot separated field path without array expansion.
+func getField(doc document, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case document:
+			val, exists := v[key]
+			if !exists {
+				return nil, false
+			}
+			cur = val
+		case []interface{}:
+			idx, ok := parseIndex(key)
+			if !ok || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// setField sets the value of the dot separated field path, the missing embedded documents are created.
+func setField(doc document, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	var cur interface{} = doc
+	for idx, key := range keys {
+		last := idx == len(keys)-1
+		switch v := cur.(type) {
+		case document:
+			if last {
+				v[key] = value
+				return nil
+			}
+			next, exists := v[key]
+			if !exists || next == nil {
+				next = make(document)
+				v[key] = next
+			}
+			cur = next
+		case []interface{}:
+			i, ok := parseIndex(key)
+			if !ok || i >= len(v) {
+				return fmt.Errorf("cannot set field %s, array index %s is invalid", path, key)
+			}
+			if last {
+				v[i] = value
+				return nil
+			}
+			cur = v[i]
+		default:
+			return fmt.Errorf("cannot set field %s, %s is not a document", path, strings.Join(keys[:idx], "."))
+		}
+	}
+	return nil
+}
+
+// unsetField removes the dot separated field path from the document.
+func unsetField(doc document, path string) {
+	keys := strings.Split(path, ".")
+	parent, exists := getField(doc, strings.Join(keys[:len(keys)-1], "."))
+	if len(keys) == 1 {
+		parent, exists = doc, true
+	}
+	if !exists {
+		return
+	}
+	if d, ok := parent.(document); ok {
+		delete(d, keys[len(keys)-1])
+	}
+}
+
+// type order used by mongodb to compare values of different types.
+const (
+	orderNull = iota + 1
+	orderNumber
+	orderString
+	orderDocument
+	orderArray
+	orderBinary
+	orderObjectID
+	orderBool
+	orderDate
+	orderTimestamp
+	orderRegex
+	orderOther
+)
+
+func typeOrder(val interface{}) int {
+	switch val.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return orderNull
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
+		primitive.Decimal128:
+		return orderNumber
+	case string, primitive.Symbol:
+		return orderString
+	case document:
+		return orderDocument
+	case []interface{}:
+		return orderArray
+	case primitive.Binary, []byte:
+		return orderBinary
+	case primitive.ObjectID:
+		return orderObjectID
+	case bool:
+		return orderBool
+	case primitive.DateTime, time.Time:
+		return orderDate
+	case primitive.Timestamp:
+		return orderTimestamp
+	case primitive.Regex:
+		return orderRegex
+	default:
+		return orderOther
+	}
+}
+
+func toFloat(val interface{}) float64 {
+	switch v := val.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	case primitive.Decimal128:
+		f, err := parseDecimal(v)
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+	default:
+		return math.NaN()
+	}
+}
+
+func parseDecimal(d primitive.Decimal128) (float64, error) {
+	var f float64
+	_, err := fmt.Sscan(d.String(), &f)
+	return f, err
+}
+
+func toMillis(val interface{}) int64 {
+	switch v := val.(type) {
+	case primitive.DateTime:
+		return int64(v)
+	case time.Time:
+		return v.UnixNano() / int64(time.Millisecond)
+	default:
+		return 0
+	}
+}
+
+// compareValues compares two normalized values with the mongodb sort order, returns -1, 0 or 1.
+func compareValues(a, b interface{}) int {
+	oa, ob := typeOrder(a), typeOrder(b)
+	if oa != ob {
+		if oa < ob {
+			return -1
+		}
+		return 1
+	}
+
+	switch oa {
+	case orderNull:
+		return 0
+	case orderNumber:
+		fa, fb := toFloat(a), toFloat(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	case orderString:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	case orderDocument:
+		da, db := a.(document), b.(document)
+		ka, kb := sortedKeys(da), sortedKeys(db)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c
+			}
+			if c := compareValues(da[ka[i]], db[kb[i]]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(len(ka), len(kb))
+	case orderArray:
+		aa, ab := a.([]interface{}), b.([]interface{})
+		for i := 0; i < len(aa) && i < len(ab); i++ {
+			if c := compareValues(aa[i], ab[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(len(aa), len(ab))
+	case orderObjectID:
+		ia, ib := a.(primitive.ObjectID), b.(primitive.ObjectID)
+		return bytes.Compare(ia[:], ib[:])
+	case orderBool:
+		ba, bb := a.(bool), b.(bool)
+		if ba == bb {
+			return 0
+		}
+		if !ba {
+			return -1
+		}
+		return 1
+	case orderDate:
+		return compareInt64(toMillis(a), toMillis(b))
+	case orderTimestamp:
+		ta, tb := a.(primitive.Timestamp), b.(primitive.Timestamp)
+		if c := compareInt64(int64(ta.T), int64(tb.T)); c != 0 {
+			return c
+		}
+		return compareInt64(int64(ta.I), int64(tb.I))
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+func compareInt(a, b int) int {
+	return compareInt64(int64(a), int64(b))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func sortedKeys(doc document) []string {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equalValues checks if two normalized values are equal, numbers of different types are equal if
+// their values are equal.
+func equalValues(a, b interface{}) bool {
+	return compareValues(a, b) == 0
+}
+
+// valueKey returns a string representation of the normalized value which is the same for equal values.
+func valueKey(val interface{}) string {
+	switch typeOrder(val) {
+	case orderNull:
+		return "null"
+	case orderNumber:
+		return fmt.Sprintf("n:%v", toFloat(val))
+	case orderString:
+		return "s:" + fmt.Sprint(val)
+	case orderDate:
+		return fmt.Sprintf("d:%d", toMillis(val))
+	case orderDocument:
+		doc := val.(document)
+		parts := make([]string, 0, len(doc))
+		for _, key := range sortedKeys(doc) {
+			parts = append(parts, key+"="+valueKey(doc[key]))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case orderArray:
+		arr := val.([]interface{})
+		parts := make([]string, len(arr))
+		for idx, elem := range arr {
+			parts[idx] = valueKey(elem)
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	default:
+		return fmt.Sprintf("%T:%v", val, val)
+	}
+}
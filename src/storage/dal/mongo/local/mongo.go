@@ -788,10 +788,11 @@ func (c *Collection) tryArchiveDeletedDoc(ctx context.Context, filter types.Filt
 	archives := make([]interface{}, len(docs))
 	for idx, doc := range docs {
 		archives[idx] = metadata.DeleteArchive{
-			Oid:    doc.Lookup("_id").ObjectID().Hex(),
-			Detail: doc.Delete("_id"),
-			Time:   time.Now(),
-			Coll:   c.collName,
+			Oid:      doc.Lookup("_id").ObjectID().Hex(),
+			Detail:   doc.Delete("_id"),
+			Time:     time.Now(),
+			Coll:     c.collName,
+			Operator: util.ExtractRequestUserFromContext(ctx),
 		}
 	}
 
@@ -1008,8 +1009,15 @@ func buildIndex(index types.Index) (mongo.IndexModel, error) {
 		createIndexOpt.SetExpireAfterSeconds(index.ExpireAfterSeconds)
 	}
 
+	if index.DefaultLanguage != "" {
+		createIndexOpt.SetDefaultLanguage(index.DefaultLanguage)
+	}
+
 	keys := index.Keys
 	for idx, key := range keys {
+		if value, ok := key.Value.(string); ok && value == types.TextIndexType {
+			continue
+		}
 		val, err := util.GetInt32ByInterface(key.Value)
 		if err != nil {
 			return mongo.IndexModel{}, err
@@ -188,6 +188,19 @@ func TestIndex(t *testing.T) {
 
 }
 
+func TestBuildTextIndex(t *testing.T) {
+	index := types.Index{
+		Name:            "test_text",
+		Keys:            bson.D{{"$**", types.TextIndexType}, {"a", 1}},
+		DefaultLanguage: "none",
+	}
+
+	model, err := buildIndex(index)
+	require.NoError(t, err)
+	require.Equal(t, bson.D{{"$**", types.TextIndexType}, {"a", int32(1)}}, model.Keys)
+	require.Equal(t, "none", *model.Options.DefaultLanguage)
+}
+
 func TestInsertAndFind(t *testing.T) {
 	ctx := context.Background()
 	tableName := "tmptest_insert_find"
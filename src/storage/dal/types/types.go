@@ -124,6 +124,21 @@ type Index struct {
 	Background              bool                   `json:"background" bson:"background"`
 	ExpireAfterSeconds      int32                  `json:"expire_after_seconds" bson:"expire_after_seconds,omitempty"`
 	PartialFilterExpression map[string]interface{} `json:"partialFilterExpression" bson:"partialFilterExpression"`
+	// DefaultLanguage is the language of the text index that determines the stop words and stemming rules
+	DefaultLanguage string `json:"default_language,omitempty" bson:"default_language,omitempty"`
+}
+
+// TextIndexType is the key value of the text index fields, other index fields use 1 or -1 as the key value
+const TextIndexType = "text"
+
+// IsTextIndex returns if the index is a text index, the keys of a text index in db are {_fts: "text", _ftsx: 1}
+func (i Index) IsTextIndex() bool {
+	for _, key := range i.Keys {
+		if value, ok := key.Value.(string); ok && value == TextIndexType {
+			return true
+		}
+	}
+	return false
 }
 
 // FindOpts TODO
@@ -122,9 +122,18 @@ func (es *EsSrv) Count(ctx context.Context, query elastic.Query, indexes []strin
 	return count, nil
 }
 
+const (
+	// BackendElasticsearch fulltext search with elasticsearch, this is the default backend
+	BackendElasticsearch = "elasticsearch"
+	// BackendMongoDB fulltext search with mongodb queries, used when elasticsearch is not deployed
+	BackendMongoDB = "mongodb"
+)
+
 // EsConfig TODO
 type EsConfig struct {
-	FullTextSearch  string
+	FullTextSearch string
+	// Backend is the fulltext search backend, elasticsearch or mongodb, default is elasticsearch
+	Backend         string
 	EsUrl           string
 	EsUser          string
 	EsPassword      string
@@ -134,17 +143,31 @@ type EsConfig struct {
 // ParseConfigFromKV returns a new config
 func ParseConfigFromKV(prefix string, configMap map[string]string) (EsConfig, error) {
 	fullTextSearch, _ := cc.String(prefix + ".fullTextSearch")
+	backend, _ := cc.String(prefix + ".backend")
 	url, _ := cc.String(prefix + ".url")
 	usr, _ := cc.String(prefix + ".usr")
 	pwd, _ := cc.String(prefix + ".pwd")
 
 	conf := EsConfig{
 		FullTextSearch: fullTextSearch,
+		Backend:        backend,
 		EsUrl:          url,
 		EsUser:         usr,
 		EsPassword:     pwd,
 	}
+	if conf.Backend == "" {
+		conf.Backend = BackendElasticsearch
+	}
+	if conf.Backend != BackendElasticsearch && conf.Backend != BackendMongoDB {
+		return conf, fmt.Errorf("fulltext search backend %s is invalid", conf.Backend)
+	}
+
 	var err error
 	conf.TLSClientConfig, err = cc.NewTLSClientConfigFromConfig(prefix)
 	return conf, err
 }
+
+// UseMongoDB returns if fulltext search is enabled and uses the mongodb backend
+func (c EsConfig) UseMongoDB() bool {
+	return c.FullTextSearch == "on" && c.Backend == BackendMongoDB
+}
@@ -13,6 +13,7 @@
 package hooks
 
 import (
+	"configcenter/src/common"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/storage/dal"
@@ -32,6 +33,28 @@ func ParseVIPFieldsForProcessHook(fields []string, table string) ([]string, []st
 }
 
 // UpdateProcessBindInfoHook if process need to update bind info, only update the specified fields
+// the hook can replace the update data by returning the new update data
 func UpdateProcessBindInfoHook(kit *rest.Kit, objID string, origin mapstr.MapStr, data mapstr.MapStr) error {
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "origin": origin, "data": data}
+	result := new(updateProcessBindInfoResult)
+	handled, err := callHook(kit, UpdateProcessBindInfo, hookData, result)
+	if err != nil {
+		return err
+	}
+
+	if !handled || result.Data == nil {
+		return nil
+	}
+
+	for key := range data {
+		delete(data, key)
+	}
+	for key, val := range result.Data {
+		data[key] = val
+	}
 	return nil
 }
+
+type updateProcessBindInfoResult struct {
+	Data mapstr.MapStr `json:"data"`
+}
@@ -14,17 +14,23 @@ package hooks
 
 import (
 	"configcenter/src/apimachinery"
+	"configcenter/src/common"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 )
 
 // ValidateCreateBusinessHook is to used to validate the to be created business is validate or not.
 func ValidateCreateBusinessHook(kit *rest.Kit, api apimachinery.ClientSetInterface, biz mapstr.MapStr) error {
-
+	if err := validateHook(kit, ValidateCreateBusiness, mapstr.MapStr{"biz": biz}); err != nil {
+		return err
+	}
 	return nil
 }
 
 // ValidateDeleteBusinessHook validates if businesses can be deleted or not
 func ValidateDeleteBusinessHook(kit *rest.Kit, api apimachinery.ClientSetInterface, bizIDs []int64) error {
+	if err := validateHook(kit, ValidateDeleteBusiness, mapstr.MapStr{common.BKAppIDField: bizIDs}); err != nil {
+		return err
+	}
 	return nil
 }
@@ -14,6 +14,7 @@ package hooks
 
 import (
 	"configcenter/src/apimachinery"
+	"configcenter/src/common"
 	ccErr "configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
@@ -24,11 +25,25 @@ import (
 // IsSkipValidateHook is a hook to check if a insert or update option is need to validate or not.
 // and check the resource's insert/operate data is valid.
 func IsSkipValidateHook(kit *rest.Kit, objID string, data mapstr.MapStr) (bool, error) {
-	return false, nil
+	result := new(skipValidateResult)
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "data": data}
+	if _, err := callHook(kit, IsSkipValidate, hookData, result); err != nil {
+		return false, err
+	}
+	return result.Skip, nil
+}
+
+// skipValidateResult is the response data of the skip validate hooks
+type skipValidateResult struct {
+	Skip bool `json:"skip"`
 }
 
 // ValidUpdateCloudIDHook is a hook to check if an update operation on host cloud ID field is valid or not
 func ValidUpdateCloudIDHook(kit *rest.Kit, objID string, originInst mapstr.MapStr, updateData mapstr.MapStr) error {
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "origin": originInst, "data": updateData}
+	if err := validateHook(kit, ValidUpdateCloudID, hookData); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -36,11 +51,20 @@ func ValidUpdateCloudIDHook(kit *rest.Kit, objID string, originInst mapstr.MapSt
 func ValidateBizBsTopoHook(kit *rest.Kit, objID string, originData mapstr.MapStr, updateData mapstr.MapStr,
 	validType string, clientSet apimachinery.ClientSetInterface) error {
 
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "origin": originData, "data": updateData,
+		"valid_type": validType}
+	if err := validateHook(kit, ValidateBizBsTopo, hookData); err != nil {
+		return err
+	}
 	return nil
 }
 
 // ValidateHostBsInfoHook is a hook to check if host bk_bs_info field is valid or not
 func ValidateHostBsInfoHook(kit *rest.Kit, objID string, data mapstr.MapStr) error {
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "data": data}
+	if err := validateHook(kit, ValidateHostBsInfo, hookData); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -48,7 +72,8 @@ func ValidateHostBsInfoHook(kit *rest.Kit, objID string, data mapstr.MapStr) err
 func ValidHostTransferHook(kit *rest.Kit, db dal.DB, crossBizTransfer bool, srcBizIDs []int64,
 	destBizID int64) ccErr.CCErrorCoder {
 
-	return nil
+	hookData := mapstr.MapStr{"cross_biz": crossBizTransfer, "src_bk_biz_ids": srcBizIDs, "dst_bk_biz_id": destBizID}
+	return validateHook(kit, ValidHostTransfer, hookData)
 }
 
 // ValidBizSetPropertyHook is a hook to check if a specific property id is valid or not
@@ -59,18 +84,27 @@ func ValidBizSetPropertyHook(kit *rest.Kit, fieldInfo *metadata.BizSetScopeParam
 
 // ValidHostCloudIDHook valid host cloud id hook
 func ValidHostCloudIDHook(kit *rest.Kit, cloudID int64) ccErr.CCErrorCoder {
-	return nil
+	return validateHook(kit, ValidHostCloudID, mapstr.MapStr{common.BKCloudIDField: cloudID})
 }
 
 // IsSkipValidateKeyHook is a hook to check if a insert or update option data key's value need to validate or not.
 func IsSkipValidateKeyHook(kit *rest.Kit, objID string, key string, data mapstr.MapStr) (bool, error) {
-	return false, nil
+	result := new(skipValidateResult)
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "key": key, "data": data}
+	if _, err := callHook(kit, IsSkipValidateKey, hookData, result); err != nil {
+		return false, err
+	}
+	return result.Skip, nil
 }
 
 // ValidUpdateHostStatusHook is a hook to check if an update operation on host status field is valid or not
 func ValidUpdateHostStatusHook(kit *rest.Kit, cs apimachinery.ClientSetInterface, objID string,
 	originInst mapstr.MapStr, updateData mapstr.MapStr) error {
 
+	hookData := mapstr.MapStr{common.BKObjIDField: objID, "origin": originInst, "data": updateData}
+	if err := validateHook(kit, ValidUpdateHostStatus, hookData); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -78,25 +112,46 @@ func ValidUpdateHostStatusHook(kit *rest.Kit, cs apimachinery.ClientSetInterface
 func ValidHostApplyStatusHook(kit *rest.Kit, cs apimachinery.ClientSetInterface, attrID string,
 	value interface{}) ccErr.CCErrorCoder {
 
-	return nil
+	hookData := mapstr.MapStr{common.BKPropertyIDField: attrID, "value": value}
+	return validateHook(kit, ValidHostApplyStatus, hookData)
 }
 
 // CanUpdateHostApplyStatusHook is a hook to check if host status can be updated by host apply
 func CanUpdateHostApplyStatusHook(kit *rest.Kit, cs apimachinery.ClientSetInterface, attrID string,
 	originalValue, expectValue interface{}) (bool, ccErr.CCErrorCoder) {
 
-	return true, nil
+	result := &canUpdateHostApplyStatusResult{CanUpdate: true}
+	hookData := mapstr.MapStr{common.BKPropertyIDField: attrID, "original_value": originalValue,
+		"expect_value": expectValue}
+	if _, err := callHook(kit, CanUpdateHostApplyStatus, hookData, result); err != nil {
+		return false, err
+	}
+	return result.CanUpdate, nil
+}
+
+type canUpdateHostApplyStatusResult struct {
+	CanUpdate bool `json:"can_update"`
 }
 
 // HostApplyUpdateInfo defines host apply update info
 type HostApplyUpdateInfo struct {
-	HostIDs    []int64
-	Attributes []metadata.HostAttribute
+	HostIDs    []int64                  `json:"bk_host_ids"`
+	Attributes []metadata.HostAttribute `json:"attributes"`
 }
 
 // GetHostApplyUpdateInfoHook is a hook to get host apply update info
 func GetHostApplyUpdateInfoHook(kit *rest.Kit, cs apimachinery.ClientSetInterface, rules []metadata.HostAttribute,
 	hostIDs []int64, attrMap map[int64]string) ([]HostApplyUpdateInfo, ccErr.CCErrorCoder) {
 
+	result := make([]HostApplyUpdateInfo, 0)
+	hookData := mapstr.MapStr{"rules": rules, "bk_host_ids": hostIDs, "attributes": attrMap}
+	handled, err := callHook(kit, GetHostApplyUpdateInfo, hookData, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if handled && len(result) > 0 {
+		return result, nil
+	}
 	return []HostApplyUpdateInfo{{HostIDs: hostIDs, Attributes: rules}}, nil
 }
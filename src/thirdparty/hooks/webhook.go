@@ -0,0 +1,328 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// HookName is the name of a hook point, it is also the config key of the hook's webhook.
+type HookName string
+
+const (
+	// ValidateCreateBusiness is the hook point of ValidateCreateBusinessHook
+	ValidateCreateBusiness HookName = "validateCreateBusiness"
+	// ValidateDeleteBusiness is the hook point of ValidateDeleteBusinessHook
+	ValidateDeleteBusiness HookName = "validateDeleteBusiness"
+	// IsSkipValidate is the hook point of IsSkipValidateHook
+	IsSkipValidate HookName = "isSkipValidate"
+	// IsSkipValidateKey is the hook point of IsSkipValidateKeyHook
+	IsSkipValidateKey HookName = "isSkipValidateKey"
+	// ValidUpdateCloudID is the hook point of ValidUpdateCloudIDHook
+	ValidUpdateCloudID HookName = "validUpdateCloudID"
+	// ValidateBizBsTopo is the hook point of ValidateBizBsTopoHook
+	ValidateBizBsTopo HookName = "validateBizBsTopo"
+	// ValidateHostBsInfo is the hook point of ValidateHostBsInfoHook
+	ValidateHostBsInfo HookName = "validateHostBsInfo"
+	// ValidHostTransfer is the hook point of ValidHostTransferHook
+	ValidHostTransfer HookName = "validHostTransfer"
+	// ValidHostCloudID is the hook point of ValidHostCloudIDHook
+	ValidHostCloudID HookName = "validHostCloudID"
+	// ValidUpdateHostStatus is the hook point of ValidUpdateHostStatusHook
+	ValidUpdateHostStatus HookName = "validUpdateHostStatus"
+	// ValidHostApplyStatus is the hook point of ValidHostApplyStatusHook
+	ValidHostApplyStatus HookName = "validHostApplyStatus"
+	// CanUpdateHostApplyStatus is the hook point of CanUpdateHostApplyStatusHook
+	CanUpdateHostApplyStatus HookName = "canUpdateHostApplyStatus"
+	// GetHostApplyUpdateInfo is the hook point of GetHostApplyUpdateInfoHook
+	GetHostApplyUpdateInfo HookName = "getHostApplyUpdateInfo"
+	// UpdateProcessBindInfo is the hook point of UpdateProcessBindInfoHook
+	UpdateProcessBindInfo HookName = "updateProcessBindInfo"
+)
+
+// Provider is an out-of-process implementation of the hook points.
+type Provider interface {
+	// Call calls the hook point with the request data, and decodes the response data into result if result is
+	// not nil. returns false if the hook point is not bound to this provider, then the default behavior is used.
+	Call(kit *rest.Kit, hook HookName, data interface{}, result interface{}) (bool, ccErr.CCErrorCoder)
+}
+
+var (
+	providerLock sync.RWMutex
+	provider     Provider = new(webhookProvider)
+)
+
+// SetProvider replaces the hook provider, the default provider calls the webhooks in the common config.
+func SetProvider(p Provider) {
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	provider = p
+}
+
+// callHook calls the hook point with the current provider.
+func callHook(kit *rest.Kit, hook HookName, data interface{}, result interface{}) (bool, ccErr.CCErrorCoder) {
+	providerLock.RLock()
+	p := provider
+	providerLock.RUnlock()
+
+	if p == nil || kit == nil {
+		return false, nil
+	}
+	return p.Call(kit, hook, data, result)
+}
+
+// validateHook calls the validation hook point which has no response data.
+func validateHook(kit *rest.Kit, hook HookName, data interface{}) ccErr.CCErrorCoder {
+	_, err := callHook(kit, hook, data, nil)
+	return err
+}
+
+// FailurePolicy defines how to handle the webhook call failure, e.g. timeout or invalid response.
+type FailurePolicy string
+
+const (
+	// FailOpen ignores the webhook call failure and uses the default behavior of the hook point
+	FailOpen FailurePolicy = "failOpen"
+	// FailClosed rejects the request when the webhook call failed
+	FailClosed FailurePolicy = "failClosed"
+)
+
+// isFailOpen returns if the failure is ignored, the default policy is failOpen, and an unknown policy fails closed
+func (p FailurePolicy) isFailOpen() bool {
+	return p == "" || p == FailOpen
+}
+
+const (
+	webhookConfigPrefix   = "hooks.webhook."
+	defaultWebhookTimeout = 5 * time.Second
+)
+
+// WebhookConfig is the webhook config of a hook point, which is in the common config like this:
+//
+//	hooks:
+//	  webhook:
+//	    validateDeleteBusiness:
+//	      enabled: true
+//	      url: http://127.0.0.1:8080/hooks/validate_delete_business
+//	      timeout: 5
+//	      failurePolicy: failClosed
+//	      token: xxx
+type WebhookConfig struct {
+	// Enabled defines if the webhook of the hook point is enabled
+	Enabled bool `mapstructure:"enabled"`
+	// URL is the webhook address, the hook request is sent by POST method
+	URL string `mapstructure:"url"`
+	// Timeout is the webhook call timeout in seconds, default is 5 seconds
+	Timeout int `mapstructure:"timeout"`
+	// FailurePolicy defines how to handle the webhook call failure, default is failOpen
+	FailurePolicy FailurePolicy `mapstructure:"failurePolicy"`
+	// Token is set in the Authorization header as a bearer token if it is not empty
+	Token string `mapstructure:"token"`
+}
+
+// Validate the webhook config
+func (c *WebhookConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("webhook url is not set")
+	}
+
+	if c.Timeout < 0 {
+		return fmt.Errorf("webhook timeout %d is invalid", c.Timeout)
+	}
+
+	switch c.FailurePolicy {
+	case "", FailOpen, FailClosed:
+	default:
+		return fmt.Errorf("webhook failure policy %s is invalid", c.FailurePolicy)
+	}
+
+	return nil
+}
+
+// WebhookRequest is the request body that is sent to the webhook
+type WebhookRequest struct {
+	Hook            HookName    `json:"hook"`
+	Rid             string      `json:"rid"`
+	User            string      `json:"bk_username"`
+	SupplierAccount string      `json:"bk_supplier_account"`
+	Data            interface{} `json:"data"`
+}
+
+// WebhookResponse is the response body of the webhook, result false means the request is rejected,
+// and the bk_error_msg is returned to the user as the reject reason.
+type WebhookResponse struct {
+	metadata.BaseResp `json:",inline"`
+	Data              json.RawMessage `json:"data"`
+}
+
+// webhookProvider calls the webhooks that are configured in the common config, the config is read for each call
+// so that the config changes take effect without restarting.
+type webhookProvider struct {
+	client http.Client
+}
+
+// getWebhookConfig returns the enabled webhook config of the hook point, returns nil if it is not enabled.
+// if the config is invalid, the failure policy of the config is also returned so that the caller can honor it.
+func getWebhookConfig(hook HookName) (*WebhookConfig, FailurePolicy, error) {
+	key := webhookConfigPrefix + string(hook)
+	if !cc.IsExist(key) {
+		return nil, "", nil
+	}
+
+	conf := new(WebhookConfig)
+	if err := cc.UnmarshalKey(key, conf); err != nil {
+		// the config can not be decoded, try to get the failure policy alone
+		policy, policyErr := cc.String(key + ".failurePolicy")
+		if policyErr != nil {
+			policy = ""
+		}
+		return nil, FailurePolicy(policy), err
+	}
+
+	if !conf.Enabled {
+		return nil, "", nil
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, conf.FailurePolicy, err
+	}
+	return conf, conf.FailurePolicy, nil
+}
+
+// Call calls the webhook of the hook point
+func (w *webhookProvider) Call(kit *rest.Kit, hook HookName, data interface{}, result interface{}) (bool,
+	ccErr.CCErrorCoder) {
+
+	conf, policy, err := getWebhookConfig(hook)
+	if err != nil {
+		blog.Errorf("get hook %s webhook config failed, err: %v, rid: %s", hook, err, kit.Rid)
+		if policy.isFailOpen() {
+			return false, nil
+		}
+		return true, hookError(kit, common.CCErrCommHookCallFailed, hook, err.Error())
+	}
+
+	if conf == nil {
+		return false, nil
+	}
+
+	resp, err := w.do(kit, hook, conf, data)
+	if err != nil {
+		blog.Errorf("call hook %s webhook %s failed, err: %v, rid: %s", hook, conf.URL, err, kit.Rid)
+		if !policy.isFailOpen() {
+			return true, hookError(kit, common.CCErrCommHookCallFailed, hook, err.Error())
+		}
+		// fail open, use the default behavior of the hook point
+		return false, nil
+	}
+
+	if !resp.Result {
+		blog.Errorf("hook %s webhook rejected the request, code: %d, msg: %s, rid: %s", hook, resp.Code, resp.ErrMsg,
+			kit.Rid)
+		return true, hookError(kit, common.CCErrCommHookRejected, hook, resp.ErrMsg)
+	}
+
+	if result == nil || len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return true, nil
+	}
+
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		blog.Errorf("decode hook %s webhook response data %s failed, err: %v, rid: %s", hook, resp.Data, err, kit.Rid)
+		if !policy.isFailOpen() {
+			return true, hookError(kit, common.CCErrCommHookCallFailed, hook, err.Error())
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (w *webhookProvider) do(kit *rest.Kit, hook HookName, conf *WebhookConfig, data interface{}) (
+	*WebhookResponse, error) {
+
+	body, err := json.Marshal(WebhookRequest{
+		Hook:            hook,
+		Rid:             kit.Rid,
+		User:            kit.User,
+		SupplierAccount: kit.SupplierAccount,
+		Data:            data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultWebhookTimeout
+	if conf.Timeout > 0 {
+		timeout = time.Duration(conf.Timeout) * time.Second
+	}
+
+	parent := kit.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(httpheader.BKHTTPCCRequestID, kit.Rid)
+	if conf.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+conf.Token)
+	}
+
+	httpResp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webhook returns http status %d, body: %s", httpResp.StatusCode, respBody)
+	}
+
+	resp := new(WebhookResponse)
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return nil, fmt.Errorf("decode webhook response %s failed, err: %v", respBody, err)
+	}
+	return resp, nil
+}
+
+func hookError(kit *rest.Kit, code int, hook HookName, msg string) ccErr.CCErrorCoder {
+	if kit.CCError == nil {
+		return ccErr.New(code, fmt.Sprintf("hook %s: %s", hook, msg))
+	}
+	return kit.CCError.CCErrorf(code, hook, msg)
+}
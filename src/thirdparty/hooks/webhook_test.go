@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(WebhookRequest)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch req.Hook {
+		case ValidateDeleteBusiness:
+			w.Write([]byte(`{"result": false, "bk_error_code": 1, "bk_error_msg": "biz is protected"}`))
+		case IsSkipValidate:
+			w.Write([]byte(`{"result": true, "data": {"skip": true}}`))
+		case ValidHostTransfer:
+			time.Sleep(2 * time.Second)
+		}
+	}))
+	defer server.Close()
+
+	conf := fmt.Sprintf(`
+hooks:
+  webhook:
+    validateDeleteBusiness:
+      enabled: true
+      url: %[1]s
+      token: token
+    isSkipValidate:
+      enabled: true
+      url: %[1]s
+      token: token
+    validHostTransfer:
+      enabled: true
+      url: %[1]s
+      timeout: 1
+      failurePolicy: failClosed
+      token: token
+    validHostCloudID:
+      enabled: false
+      url: %[1]s
+    validUpdateHostStatus:
+      enabled: true
+      failurePolicy: failOpen
+    validHostApplyStatus:
+      enabled: true
+      timeout: -1
+      failurePolicy: failClosed
+`, server.URL)
+	require.NoError(t, cc.SetCommonFromByte([]byte(conf)))
+
+	kit := &rest.Kit{Rid: "rid", Ctx: context.Background(), User: "admin", SupplierAccount: "0"}
+
+	err := ValidateDeleteBusinessHook(kit, nil, []int64{1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "biz is protected")
+
+	skip, err := IsSkipValidateHook(kit, common.BKInnerObjIDHost, mapstr.MapStr{})
+	require.NoError(t, err)
+	require.True(t, skip)
+
+	ccErr := ValidHostTransferHook(kit, nil, true, []int64{1}, 2)
+	require.NotNil(t, ccErr)
+	require.Equal(t, common.CCErrCommHookCallFailed, ccErr.GetCode())
+
+	require.Nil(t, ValidHostCloudIDHook(kit, 1))
+
+	// invalid config honors the failure policy
+	_, err = callHook(kit, ValidUpdateHostStatus, nil, nil)
+	require.NoError(t, err)
+	handled, ccErr := callHook(kit, ValidHostApplyStatus, nil, nil)
+	require.True(t, handled)
+	require.NotNil(t, ccErr)
+	require.Equal(t, common.CCErrCommHookCallFailed, ccErr.GetCode())
+	require.NoError(t, ValidateCreateBusinessHook(kit, nil, mapstr.MapStr{}))
+}
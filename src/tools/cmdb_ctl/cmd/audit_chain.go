@@ -0,0 +1,117 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditchain"
+	"configcenter/src/common/json"
+	"configcenter/src/common/metadata"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewAuditChainCommand())
+}
+
+type auditChainConf struct {
+	supplierAccount string
+	startSeq        int64
+	signKey         string
+}
+
+// NewAuditChainCommand new audit log hash chain command
+func NewAuditChainCommand() *cobra.Command {
+	conf := new(auditChainConf)
+
+	cmd := &cobra.Command{
+		Use:   "audit-chain",
+		Short: "audit log hash chain operations",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "walk the audit log hash chain and report the first broken link or missing range",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerifyAuditChain(conf)
+		},
+	}
+	conf.addFlags(verifyCmd)
+	cmd.AddCommand(verifyCmd)
+
+	return cmd
+}
+
+func (c *auditChainConf) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&c.supplierAccount, "supplier-account", common.BKDefaultOwnerID,
+		"the supplier account of the audit log hash chain")
+	cmd.Flags().Int64Var(&c.startSeq, "start-seq", 0, "the sequence that the verification starts from")
+	cmd.Flags().StringVar(&c.signKey, "sign-key", os.Getenv("AUDIT_CHAIN_SIGN_KEY"),
+		"the key that the checkpoints are signed with, the signatures are not verified if it is not set, "+
+			"corresponding environment variable is AUDIT_CHAIN_SIGN_KEY")
+}
+
+func runVerifyAuditChain(c *auditChainConf) error {
+	opt := &metadata.VerifyAuditChainOption{
+		SupplierAccount: c.supplierAccount,
+		StartSeq:        c.startSeq,
+	}
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		return fmt.Errorf("verify audit chain option is invalid, args: %v", rawErr.Args)
+	}
+
+	service, err := config.NewMongoService(config.Conf.MongoURI, config.Conf.MongoRsName)
+	if err != nil {
+		return err
+	}
+
+	result, err := auditchain.Verify(context.Background(), service.DbProxy, c.signKey, opt)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+
+	if !result.SignatureVerified {
+		fmt.Print(WithBlueColor("the signatures of the checkpoints and the archived ranges are not verified since " +
+			"the sign key is not set"))
+	}
+
+	if len(result.UnverifiedRanges) > 0 {
+		fmt.Print(WithBlueColor(fmt.Sprintf("%d archived ranges of the hash chain are skipped and not verified, "+
+			"verify them with the archive files", len(result.UnverifiedRanges))))
+	}
+
+	if !result.Intact {
+		fmt.Print(WithRedColor(fmt.Sprintf("audit log hash chain of supplier account %s is not intact",
+			c.supplierAccount)))
+		return fmt.Errorf("audit log hash chain is not intact")
+	}
+
+	fmt.Print(WithGreenColor(fmt.Sprintf("audit log hash chain of supplier account %s is intact, %d audit logs are "+
+		"verified", c.supplierAccount, result.CheckedCount)))
+	return nil
+}
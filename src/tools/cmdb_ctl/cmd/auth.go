@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"configcenter/src/ac"
-	"configcenter/src/ac/iam"
 	"configcenter/src/ac/meta"
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
@@ -122,7 +121,7 @@ func newAuthService(c *authConf) (*authService, error) {
 		return nil, fmt.Errorf("new api machinery failed, err: %v", err)
 	}
 	service := &authService{
-		authorizer: iam.NewAuthorizer(clientSet),
+		authorizer: ac.NewAuthorizer(clientSet),
 	}
 
 	if c.resource != "" {
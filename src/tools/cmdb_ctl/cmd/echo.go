@@ -20,8 +20,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"time"
 
+	"configcenter/src/common/watch"
+
 	"github.com/spf13/cobra"
 )
 
@@ -32,6 +35,7 @@ func init() {
 type echo struct {
 	url        string
 	jsonPretty bool
+	secret     string
 }
 
 // NewEchoCommand TODO
@@ -54,6 +58,8 @@ func NewEchoCommand() *cobra.Command {
 func (c *echo) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&c.url, "url", "", "the url of the echo server, eg: http://127.0.0.1:80/echo")
 	cmd.Flags().BoolVar(&c.jsonPretty, "pretty", false, "json indent the received data if it's json format.")
+	cmd.Flags().StringVar(&c.secret, "secret", "", "the secret of the webhook subscription, if set, the signature "+
+		"of the received watch events is verified and the request is rejected if it's invalid.")
 }
 
 func runEchoServer(c *echo) error {
@@ -76,6 +82,10 @@ func (c *echo) echoServer(w http.ResponseWriter, r *http.Request) {
 	}
 	fmt.Fprintf(os.Stdout, "%c[1;40;31m>> received new data, time: %s %c[0m\n", 0x1B, time.Now().Format(time.RFC3339),
 		0x1B)
+	if c.secret != "" && !c.verifySignature(r, s) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
 	if c.jsonPretty {
 		var prettyJSON bytes.Buffer
 		if err := json.Indent(&prettyJSON, s, "", "    "); err != nil {
@@ -88,3 +98,21 @@ func (c *echo) echoServer(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Fprintf(os.Stdout, "%s\n\n", s)
 }
+
+// verifySignature verify the signature of the watch events delivered by the webhook subscription
+func (c *echo) verifySignature(r *http.Request, body []byte) bool {
+	timestamp, err := strconv.ParseInt(r.Header.Get(watch.TimestampHeader), 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid %s header: %s\n\n", watch.TimestampHeader, r.Header.Get(watch.TimestampHeader))
+		return false
+	}
+
+	if !watch.VerifyPayload(c.secret, timestamp, body, r.Header.Get(watch.SignatureHeader)) {
+		fmt.Fprintf(os.Stderr, "invalid signature of subscription %s, timestamp: %d\n\n",
+			r.Header.Get(watch.SubscriptionHeader), timestamp)
+		return false
+	}
+
+	fmt.Fprintf(os.Stdout, "signature of subscription %s is verified\n", r.Header.Get(watch.SubscriptionHeader))
+	return true
+}
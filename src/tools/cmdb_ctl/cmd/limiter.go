@@ -17,15 +17,21 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/types"
+	"configcenter/src/storage/dal/redis"
 	"configcenter/src/tools/cmdb_ctl/app/config"
 
+	goredis "github.com/go-redis/redis/v7"
 	"github.com/spf13/cobra"
 )
 
@@ -41,8 +47,12 @@ const (
 ./tool_ctl limiter set --rule='{"rulename":"rule1","appcode":"gse","user":"admin","ip":"","method":"POST","url":"^/api/v3/module/search/[^\\s/]+/[0-9]+/[0-9]+/?$","limit":1000,"ttl":60,"denyall":false}'
 # 配置策略，将url直接禁掉
 ./tool_ctl limiter set --rule='{"rulename":"rule1","appcode":"gse","user":"admin","url":"^/api/v3/module/search/[^\\s/]+/[0-9]+/[0-9]+/?$","denyall":true}'
+# 配置策略，使用令牌桶算法按用户分别限流，每60秒补充1000个令牌，最多允许突发2000个请求
+./tool_ctl limiter set --rule='{"rulename":"rule2","appcode":"gse","url":"^/api/v3/module/search/.*$","limit":1000,"ttl":60,"algorithm":"token_bucket","burst":2000,"partitionby":"user"}'
 # 获取某些策略详情
 ./tool_ctl limiter get --rulenames=test1,test2
+# 获取某些策略当前的限流状态，需要配置redis地址
+./tool_ctl limiter state --rulenames=test1,test2 --redis-addr=127.0.0.1:6379
 # 删除某些策略
 ./tool_ctl limiter del --rulenames=test1,test2
 ********************************************************
@@ -63,9 +73,15 @@ const (
 | limit    | int64  | 否   | api请求限制总次数                                            |
 | ttl      | int64  | 否   | 策略存活时间，单位为秒                                       |
 | denyall  | bool   | 否   | 是否直接禁掉请求，默认为false，为true时忽略limit和ttl参数    |
+| algorithm | string | 否  | 限流算法，可选fixed_window(固定窗口，默认)、sliding_window(滑动窗口)、token_bucket(令牌桶) |
+| burst    | int64  | 否   | 令牌桶容量，即允许突发的最大请求数，仅token_bucket算法可配置，默认与limit相同 |
+| partitionby | string | 否 | 按appcode、user或ip对匹配的请求分别限流，默认所有请求共享一个计数 |
+| dryrun   | bool   | 否   | 是否只记录日志和指标而不拦截请求，默认为false                 |
  
 appcode、user、ip、method、url需要至少配置一项  
-denyall配置为false的情况下，limit和ttl配置才能生效
+denyall配置为false的情况下，limit和ttl配置才能生效  
+fixed_window和sliding_window算法在ttl秒内最多允许limit个请求，token_bucket算法每ttl秒补充limit个令牌  
+请求被拦截时返回http状态码429，并通过Retry-After、X-RateLimit-*响应头返回限流状态
 ********************************************************
 		`
 )
@@ -124,6 +140,14 @@ func NewLimiterCommand() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "state",
+		Short: "show the current counter or bucket state of api limiter rules, use with flag --rulenames",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShowRuleStates(conf)
+		},
+	})
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "ls",
 		Short: "list all api limiter rules",
@@ -256,3 +280,157 @@ func runListRules(c *limiterConf) error {
 	}
 	return nil
 }
+
+// limiterState is the current state of a limiter rule's counter or bucket
+type limiterState struct {
+	Key       string                    `json:"key"`
+	Algorithm metadata.LimiterAlgorithm `json:"algorithm"`
+	// Count is the requests in the current window of fixed_window and sliding_window algorithm
+	Count int64 `json:"count,omitempty"`
+	// Tokens is the current tokens in the bucket of token_bucket algorithm
+	Tokens float64 `json:"tokens,omitempty"`
+	// Remaining is the requests that can still be made
+	Remaining int64  `json:"remaining"`
+	TTL       string `json:"ttl"`
+}
+
+func runShowRuleStates(c *limiterConf) error {
+	if c.rulenames == "" {
+		return fmt.Errorf("rulenames must be set")
+	}
+	zk, err := config.NewZkService(config.Conf.ZkAddr)
+	if err != nil {
+		return err
+	}
+
+	// don't need to open too many conns
+	config.Conf.RedisConf.MaxOpenConns = redisDefaultConnNum
+	redisCli, err := redis.NewFromConfig(config.Conf.RedisConf)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, name := range strings.Split(c.rulenames, ",") {
+		path := fmt.Sprintf("%s/%s", types.CC_SERVLIMITER_BASEPATH, name)
+		data, err := zk.ZkCli.Get(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "get rule %s err:%s\n", name, err)
+			continue
+		}
+
+		rule := new(metadata.LimiterRule)
+		if err := json.Unmarshal([]byte(data), rule); err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "unmarshal rule %s err:%s\n", name, err)
+			continue
+		}
+		if rule.DenyAll {
+			_, _ = fmt.Fprintf(os.Stdout, "rule %s denies all requests, no state\n\n", name)
+			continue
+		}
+
+		keys, err := getLimiterRuleKeys(ctx, redisCli, rule)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "get rule %s keys err:%s\n", name, err)
+			continue
+		}
+
+		states := make([]limiterState, 0)
+		for _, key := range keys {
+			state, err := getLimiterState(ctx, redisCli, rule, key)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stdout, "get rule %s key %s state err:%s\n", name, key, err)
+				continue
+			}
+			states = append(states, *state)
+		}
+
+		pretty, err := json.MarshalIndent(states, "", "\t")
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "get rule %s Indent err:%s\n", name, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n%s\n\n", path, pretty)
+	}
+	return nil
+}
+
+// getLimiterRuleKeys get the redis keys of the rule, the partitioned rule has a key for each partition
+func getLimiterRuleKeys(ctx context.Context, redisCli redis.Client, rule *metadata.LimiterRule) ([]string, error) {
+	if rule.PartitionBy == metadata.LimiterPartitionNone {
+		return []string{rule.CacheKey("")}, nil
+	}
+
+	keys := make([]string, 0)
+	cursor := redisDefaultCursor
+	for {
+		result, cur, err := redisCli.Scan(ctx, cursor, rule.CacheKey("*"), redisDefaultCount).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, result...)
+		if cur == 0 {
+			break
+		}
+		cursor = cur
+	}
+	return keys, nil
+}
+
+// getLimiterState get the state of the rule's key according to the rule's algorithm
+func getLimiterState(ctx context.Context, redisCli redis.Client, rule *metadata.LimiterRule, key string) (
+	*limiterState, error) {
+
+	ttl, err := redisCli.TTL(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	state := &limiterState{Key: key, Algorithm: rule.GetAlgorithm(), TTL: ttl.String()}
+	nowMs := time.Now().UnixNano() / int64(time.Millisecond)
+
+	switch rule.GetAlgorithm() {
+	case metadata.FixedWindowLimiter:
+		cnt, err := redisCli.Get(ctx, key).Result()
+		if err != nil && !redis.IsNilErr(err) {
+			return nil, err
+		}
+		state.Count, _ = strconv.ParseInt(cnt, 10, 64)
+		state.Remaining = rule.Limit - state.Count
+
+	case metadata.SlidingWindowLimiter:
+		windowStart := nowMs - rule.TTL*int64(time.Second/time.Millisecond)
+		requests, err := redisCli.ZRangeByScore(ctx, key, &goredis.ZRangeBy{Min: "(" + strconv.FormatInt(windowStart,
+			10), Max: "+inf"}).Result()
+		if err != nil {
+			return nil, err
+		}
+		state.Count = int64(len(requests))
+		state.Remaining = rule.Limit - state.Count
+
+	case metadata.TokenBucketLimiter:
+		bucket, err := redisCli.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		burst := float64(rule.GetBurst())
+		tokens, err := strconv.ParseFloat(bucket["tokens"], 64)
+		if err != nil {
+			tokens = burst
+		}
+		ts, err := strconv.ParseInt(bucket["ts"], 10, 64)
+		if err == nil && nowMs > ts {
+			rate := float64(rule.Limit) / float64(rule.TTL*int64(time.Second/time.Millisecond))
+			tokens = math.Min(burst, tokens+float64(nowMs-ts)*rate)
+		}
+		state.Tokens = tokens
+		state.Remaining = int64(tokens)
+
+	default:
+		return nil, fmt.Errorf("limiter algorithm %s is invalid", rule.Algorithm)
+	}
+
+	if state.Remaining < 0 {
+		state.Remaining = 0
+	}
+	return state, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+func isInvalidCredentials(err error) bool {
+	return ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultInvalidCredentials) ||
+		ldapv3.IsErrorWithCode(err, ldapv3.ErrorEmptyPassword)
+}
+
+func isNoSuchObject(err error) bool {
+	return ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultNoSuchObject)
+}
+
+// conn is an ldap client connection whose operations are limited by the timeout
+type conn struct {
+	*ldapv3.Conn
+	timeout time.Duration
+}
+
+// dial connect to the ldap server, the url scheme is ldap or ldaps, starts tls on ldap connection if startTLS is set
+func dial(rawURL string, startTLS bool, tlsConf *tls.Config, timeout time.Duration) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse ldap url %s failed, err: %v", rawURL, err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "ldap" && scheme != "ldaps" {
+		return nil, fmt.Errorf("unsupported ldap url scheme %s", u.Scheme)
+	}
+
+	tlsConf = withServerName(tlsConf, u.Hostname())
+	c, err := ldapv3.DialURL(rawURL, ldapv3.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldapv3.DialWithTLSConfig(tlsConf))
+	if err != nil {
+		return nil, fmt.Errorf("connect to ldap server %s failed, err: %v", u.Host, err)
+	}
+	c.SetTimeout(timeout)
+
+	if startTLS && scheme == "ldap" {
+		if err := c.StartTLS(tlsConf); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("start tls failed, err: %v", err)
+		}
+	}
+	return &conn{Conn: c, timeout: timeout}, nil
+}
+
+func withServerName(tlsConf *tls.Config, serverName string) *tls.Config {
+	if tlsConf == nil {
+		tlsConf = new(tls.Config)
+	}
+	tlsConf = tlsConf.Clone()
+	if tlsConf.ServerName == "" {
+		tlsConf.ServerName = serverName
+	}
+	return tlsConf
+}
+
+// search the entries matching the filter, sizeLimit 0 means no limit, the entries that are found before the size
+// limit is exceeded are returned
+func (c *conn) search(baseDN string, scope int, filter string, attributes []string, sizeLimit int) (
+	[]*ldapv3.Entry, error) {
+
+	request := ldapv3.NewSearchRequest(baseDN, scope, ldapv3.NeverDerefAliases, sizeLimit,
+		int(c.timeout/time.Second), false, filter, attributes, nil)
+	result, err := c.Search(request)
+	if err != nil {
+		if ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultSizeLimitExceeded) && result != nil {
+			return result.Entries, nil
+		}
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// close unbind and close the connection
+func (c *conn) close() {
+	if err := c.Unbind(); err != nil {
+		_ = c.Close()
+	}
+}
@@ -0,0 +1,660 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ldap login method with the users in the ldap directory, the user name and password submitted to the login
+// page are authenticated by binding to the directory, and the ldap groups are mapped to the supplier accounts
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/ssl"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "ldap login system",
+		Version:    common.BKLDAPLoginPluginVersion,
+		HandleFunc: newUser(loadConfig),
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+const (
+	configKey = "webServer.ldap"
+	// loginExpire is the time that the user needs to log in again after
+	loginExpire = 24 * time.Hour
+	// maxUserListSize limit the number of users returned by the user list
+	maxUserListSize = 1000
+)
+
+// the roles of the user in the supplier account
+const (
+	roleMember int64 = 0
+	roleAdmin  int64 = 1
+)
+
+// the session keys of the logged-in identity
+const (
+	sessionDN          = "ldap_dn"
+	sessionUserName    = "ldap_username"
+	sessionDisplayName = "ldap_display_name"
+	sessionEmail       = "ldap_email"
+	sessionPhone       = "ldap_phone"
+	sessionLoginTime   = "ldap_login_time"
+)
+
+var errUserNotFound = errors.New("ldap user not found")
+
+// Config is the ldap login config
+type Config struct {
+	// URL is the ldap server url, like ldap://ldap.example.com:389 or ldaps://ldap.example.com:636
+	URL string `mapstructure:"url"`
+	// StartTLS upgrade the ldap connection to tls with StartTLS
+	StartTLS bool `mapstructure:"startTLS"`
+	// BindDN and BindPassword is the service account to search the users and groups, search anonymously if not set
+	BindDN       string `mapstructure:"bindDN"`
+	BindPassword string `mapstructure:"bindPassword"`
+	// UserBaseDN is the base dn to search the users in
+	UserBaseDN string `mapstructure:"userBaseDN"`
+	// UserFilter is the filter to search the user, {username} is replaced by the escaped user name
+	UserFilter string           `mapstructure:"userFilter"`
+	Attributes AttributesConfig `mapstructure:"attributes"`
+	// GroupBaseDN is the base dn to search the groups of the user in, the groups are not searched if not set
+	GroupBaseDN string `mapstructure:"groupBaseDN"`
+	// GroupFilter is the filter to search the groups of the user, {dn} and {username} is replaced by the escaped
+	// user dn and user name
+	GroupFilter string `mapstructure:"groupFilter"`
+	// GroupNameAttribute is the attribute of the group name
+	GroupNameAttribute string `mapstructure:"groupNameAttribute"`
+	// GroupMappings maps the ldap groups to the supplier accounts
+	GroupMappings []GroupMapping `mapstructure:"groupMappings"`
+	// DefaultSupplierAccount is the supplier account of the user who is not in any mapped group
+	DefaultSupplierAccount string `mapstructure:"defaultSupplierAccount"`
+	// GroupCacheTTL is the seconds that the group membership is cached for
+	GroupCacheTTL int64 `mapstructure:"groupCacheTTL"`
+	// Timeout is the seconds of the ldap operation timeout
+	Timeout int64 `mapstructure:"timeout"`
+
+	TLS ssl.TLSClientConfig `mapstructure:"-"`
+}
+
+// AttributesConfig is the ldap attributes of the user info
+type AttributesConfig struct {
+	UserName    string `mapstructure:"username"`
+	DisplayName string `mapstructure:"displayName"`
+	Email       string `mapstructure:"email"`
+	Phone       string `mapstructure:"phone"`
+	// MemberOf is the attribute of the user entry that contains the dn of the groups, like memberOf
+	MemberOf string `mapstructure:"memberOf"`
+}
+
+// GroupMapping maps the ldap group to the supplier account
+type GroupMapping struct {
+	// Group is the dn of the group, like cn=ops,ou=groups,dc=example,dc=com
+	Group           string `mapstructure:"group"`
+	SupplierAccount string `mapstructure:"supplierAccount"`
+	// Admin means the members of the group are the admin of the supplier account
+	Admin bool `mapstructure:"admin"`
+}
+
+// Validate validate the config and set the default values
+func (c *Config) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("%s.url is not set", configKey)
+	}
+	if c.UserBaseDN == "" {
+		return fmt.Errorf("%s.userBaseDN is not set", configKey)
+	}
+
+	if c.UserFilter == "" {
+		c.UserFilter = "(uid={username})"
+	}
+	if !strings.Contains(c.UserFilter, "{username}") {
+		return fmt.Errorf("%s.userFilter %s does not contain {username}", configKey, c.UserFilter)
+	}
+	if _, err := ldapv3.CompileFilter(strings.ReplaceAll(c.UserFilter, "{username}", "x")); err != nil {
+		return fmt.Errorf("%s.userFilter is invalid, err: %v", configKey, err)
+	}
+
+	if c.Attributes.UserName == "" {
+		c.Attributes.UserName = "uid"
+	}
+	if c.Attributes.DisplayName == "" {
+		c.Attributes.DisplayName = "cn"
+	}
+	if c.Attributes.Email == "" {
+		c.Attributes.Email = "mail"
+	}
+	if c.Attributes.Phone == "" {
+		c.Attributes.Phone = "telephoneNumber"
+	}
+
+	if c.GroupFilter == "" {
+		c.GroupFilter = "(|(member={dn})(uniqueMember={dn}))"
+	}
+	if c.GroupNameAttribute == "" {
+		c.GroupNameAttribute = "cn"
+	}
+	for _, mapping := range c.GroupMappings {
+		if mapping.Group == "" {
+			return fmt.Errorf("%s.groupMappings has mapping without group", configKey)
+		}
+		// the groups are matched by dn, since the groups with the same name may be in different organizations
+		if _, err := ldapv3.ParseDN(mapping.Group); err != nil || !strings.Contains(mapping.Group, "=") {
+			return fmt.Errorf("%s.groupMappings group %s is not a valid dn", configKey, mapping.Group)
+		}
+	}
+
+	if c.DefaultSupplierAccount == "" {
+		c.DefaultSupplierAccount = common.BKDefaultOwnerID
+	}
+	if c.GroupCacheTTL <= 0 {
+		c.GroupCacheTTL = 300
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10
+	}
+	return nil
+}
+
+func loadConfig() (*Config, error) {
+	conf := new(Config)
+	if err := cc.UnmarshalKey(configKey, conf); err != nil {
+		return nil, fmt.Errorf("parse %s config failed, err: %v", configKey, err)
+	}
+
+	var err error
+	conf.TLS, err = cc.NewTLSClientConfigFromConfig(configKey + ".tls")
+	if err != nil {
+		return nil, fmt.Errorf("parse %s tls config failed, err: %v", configKey, err)
+	}
+
+	return conf, conf.Validate()
+}
+
+type user struct {
+	loadConfig func() (*Config, error)
+
+	lock sync.Mutex
+	// groups caches the groups of the users by the user dn
+	groups map[string]*cachedGroups
+}
+
+func newUser(loadConfig func() (*Config, error)) *user {
+	return &user{loadConfig: loadConfig, groups: make(map[string]*cachedGroups)}
+}
+
+// group is the ldap group that the user is a member of, the group is matched by the dn, the name is only displayed
+type group struct {
+	DN   string
+	Name string
+}
+
+type cachedGroups struct {
+	groups []group
+	expire time.Time
+}
+
+// identity is the user info of the ldap user
+type identity struct {
+	DN          string
+	UserName    string
+	DisplayName string
+	Email       string
+	Phone       string
+}
+
+// AuthenticateUser authenticate the user name and password by binding to the ldap directory as the user
+func (m *user) AuthenticateUser(c *gin.Context, config map[string]string, userName,
+	password string) *ccErr.RawErrorInfo {
+
+	rid := httpheader.GetRid(c.Request.Header)
+	conf, err := m.loadConfig()
+	if err != nil {
+		blog.Errorf("load ldap config failed, err: %v, rid: %s", err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrCommConfMissItem, Args: []interface{}{configKey}}
+	}
+
+	ident, groups, err := m.authenticate(conf, userName, password)
+	if err != nil {
+		if err == errUserNotFound || isInvalidCredentials(err) {
+			blog.Errorf("ldap user %s authenticate failed, err: %v, rid: %s", userName, err, rid)
+			return &ccErr.RawErrorInfo{ErrCode: common.CCErrWebUsernamePasswdWrong}
+		}
+		blog.Errorf("ldap authenticate user %s failed, err: %v, rid: %s", userName, err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrCommInternalServerError, Args: []interface{}{"ldap"}}
+	}
+	m.cacheGroups(conf, ident.DN, groups)
+
+	session := sessions.Default(c)
+	session.Set(sessionDN, ident.DN)
+	session.Set(sessionUserName, ident.UserName)
+	session.Set(sessionDisplayName, ident.DisplayName)
+	session.Set(sessionEmail, ident.Email)
+	session.Set(sessionPhone, ident.Phone)
+	session.Set(sessionLoginTime, time.Now().Unix())
+	if err := session.Save(); err != nil {
+		blog.Errorf("save session failed, err: %v, rid: %s", err, rid)
+		return &ccErr.RawErrorInfo{ErrCode: common.CCErrCommInternalServerError, Args: []interface{}{"session"}}
+	}
+	return nil
+}
+
+// authenticate search the user with the service account, and bind as the user to check the password
+func (m *user) authenticate(conf *Config, userName, password string) (*identity, []group, error) {
+	if userName == "" || password == "" {
+		return nil, nil, errUserNotFound
+	}
+
+	c, err := m.dial(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.close()
+
+	filter := strings.ReplaceAll(conf.UserFilter, "{username}", ldapv3.EscapeFilter(userName))
+	entries, err := c.search(conf.UserBaseDN, ldapv3.ScopeWholeSubtree, filter, userAttributes(conf), 2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("search ldap user %s failed, err: %v", userName, err)
+	}
+	if len(entries) == 0 {
+		return nil, nil, errUserNotFound
+	}
+	if len(entries) > 1 {
+		return nil, nil, fmt.Errorf("multiple ldap users match user name %s", userName)
+	}
+
+	if err := c.Bind(entries[0].DN, password); err != nil {
+		return nil, nil, err
+	}
+
+	// search the groups with the service account, users may not have the permission to search the groups
+	if conf.BindDN != "" {
+		if err := c.Bind(conf.BindDN, conf.BindPassword); err != nil {
+			return nil, nil, fmt.Errorf("bind ldap service account failed, err: %v", err)
+		}
+	}
+
+	ident := newIdentity(conf, entries[0])
+	groups, err := searchGroups(c, conf, entries[0], ident.UserName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ident, groups, nil
+}
+
+// lookupGroups get the groups of the user from the ldap directory, returns errUserNotFound if the user is deleted
+func (m *user) lookupGroups(conf *Config, dn, userName string) ([]group, error) {
+	c, err := m.dial(conf)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	entries, err := c.search(dn, ldapv3.ScopeBaseObject, "(objectClass=*)", userAttributes(conf), 1)
+	if err != nil {
+		if isNoSuchObject(err) {
+			return nil, errUserNotFound
+		}
+		return nil, fmt.Errorf("search ldap user %s failed, err: %v", dn, err)
+	}
+	if len(entries) == 0 || entries[0].GetEqualFoldAttributeValue(conf.Attributes.UserName) != userName {
+		return nil, errUserNotFound
+	}
+
+	return searchGroups(c, conf, entries[0], userName)
+}
+
+// dial connect to the ldap server and bind with the service account
+func (m *user) dial(conf *Config) (*conn, error) {
+	var tlsConf *tls.Config
+	if strings.HasPrefix(strings.ToLower(conf.URL), "ldaps://") || conf.StartTLS {
+		var err error
+		tlsConf, _, err = ssl.NewTLSConfigFromConf(&conf.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("new ldap tls config failed, err: %v", err)
+		}
+		tlsConf.InsecureSkipVerify = conf.TLS.InsecureSkipVerify
+	}
+
+	c, err := dial(conf.URL, conf.StartTLS, tlsConf, time.Duration(conf.Timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.BindDN != "" {
+		if err := c.Bind(conf.BindDN, conf.BindPassword); err != nil {
+			c.close()
+			return nil, fmt.Errorf("bind ldap service account failed, err: %v", err)
+		}
+	}
+	return c, nil
+}
+
+func userAttributes(conf *Config) []string {
+	attributes := []string{conf.Attributes.UserName, conf.Attributes.DisplayName, conf.Attributes.Email,
+		conf.Attributes.Phone}
+	if conf.Attributes.MemberOf != "" {
+		attributes = append(attributes, conf.Attributes.MemberOf)
+	}
+	return attributes
+}
+
+func newIdentity(conf *Config, e *ldapv3.Entry) *identity {
+	ident := &identity{
+		DN:          e.DN,
+		UserName:    e.GetEqualFoldAttributeValue(conf.Attributes.UserName),
+		DisplayName: e.GetEqualFoldAttributeValue(conf.Attributes.DisplayName),
+		Email:       e.GetEqualFoldAttributeValue(conf.Attributes.Email),
+		Phone:       e.GetEqualFoldAttributeValue(conf.Attributes.Phone),
+	}
+	if ident.DisplayName == "" {
+		ident.DisplayName = ident.UserName
+	}
+	return ident
+}
+
+// searchGroups get the groups of the user from the member of attribute and by searching the group base dn
+func searchGroups(c *conn, conf *Config, e *ldapv3.Entry, userName string) ([]group, error) {
+	groups := make([]group, 0)
+	exists := make(map[string]struct{})
+	addGroup := func(g group) {
+		key := strings.ToLower(g.DN)
+		if _, ok := exists[key]; ok {
+			return
+		}
+		exists[key] = struct{}{}
+		groups = append(groups, g)
+	}
+
+	if conf.Attributes.MemberOf != "" {
+		for _, dn := range e.GetEqualFoldAttributeValues(conf.Attributes.MemberOf) {
+			addGroup(group{DN: dn, Name: rdnValue(dn)})
+		}
+	}
+
+	if conf.GroupBaseDN == "" {
+		return groups, nil
+	}
+
+	filter := strings.ReplaceAll(conf.GroupFilter, "{dn}", ldapv3.EscapeFilter(e.DN))
+	filter = strings.ReplaceAll(filter, "{username}", ldapv3.EscapeFilter(userName))
+	entries, err := c.search(conf.GroupBaseDN, ldapv3.ScopeWholeSubtree, filter, []string{conf.GroupNameAttribute},
+		0)
+	if err != nil {
+		return nil, fmt.Errorf("search ldap groups of user %s failed, err: %v", e.DN, err)
+	}
+	for _, groupEntry := range entries {
+		name := groupEntry.GetEqualFoldAttributeValue(conf.GroupNameAttribute)
+		if name == "" {
+			name = rdnValue(groupEntry.DN)
+		}
+		addGroup(group{DN: groupEntry.DN, Name: name})
+	}
+	return groups, nil
+}
+
+// rdnValue get the value of the first relative distinguished name, like ops of cn=ops,ou=groups,dc=example,dc=com
+func rdnValue(dn string) string {
+	rdn := strings.SplitN(dn, ",", 2)[0]
+	if idx := strings.IndexByte(rdn, '='); idx >= 0 {
+		return strings.TrimSpace(rdn[idx+1:])
+	}
+	return rdn
+}
+
+// mapGroups maps the groups to the supplier accounts that the user belongs to, the role of the user is the highest
+// role of the mappings, the user belongs to the default supplier account if the user is not in any mapped group
+func mapGroups(conf *Config, groups []group) []metadata.LoginUserInfoOwnerUinList {
+	suppliers := make([]metadata.LoginUserInfoOwnerUinList, 0)
+	index := make(map[string]int)
+	for _, mapping := range conf.GroupMappings {
+		if !inGroups(groups, mapping.Group) {
+			continue
+		}
+
+		supplier := mapping.SupplierAccount
+		if supplier == "" {
+			supplier = conf.DefaultSupplierAccount
+		}
+		role := roleMember
+		if mapping.Admin {
+			role = roleAdmin
+		}
+
+		if idx, ok := index[supplier]; ok {
+			if role > suppliers[idx].Role {
+				suppliers[idx].Role = role
+			}
+			continue
+		}
+		index[supplier] = len(suppliers)
+		suppliers = append(suppliers, metadata.LoginUserInfoOwnerUinList{OwnerID: supplier, OwnerName: supplier,
+			Role: role})
+	}
+
+	if len(suppliers) == 0 {
+		suppliers = append(suppliers, metadata.LoginUserInfoOwnerUinList{OwnerID: conf.DefaultSupplierAccount,
+			OwnerName: conf.DefaultSupplierAccount, Role: roleMember})
+	}
+	return suppliers
+}
+
+// inGroups check if the group dn is one of the groups, the dn is compared by the attribute types and values, the
+// group name is not used since the groups with the same name may be in different organizations
+func inGroups(groups []group, groupDN string) bool {
+	target, err := ldapv3.ParseDN(groupDN)
+	if err != nil {
+		return false
+	}
+
+	for _, g := range groups {
+		dn, err := ldapv3.ParseDN(g.DN)
+		if err == nil && dn.EqualFold(target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *user) cacheGroups(conf *Config, dn string, groups []group) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	for key, cached := range m.groups {
+		if now.After(cached.expire) {
+			delete(m.groups, key)
+		}
+	}
+	m.groups[strings.ToLower(dn)] = &cachedGroups{
+		groups: groups,
+		expire: now.Add(time.Duration(conf.GroupCacheTTL) * time.Second),
+	}
+}
+
+// getGroups get the groups of the user from cache, the groups are looked up again after the cache expires, and the
+// expired groups are used if the ldap server is unavailable
+func (m *user) getGroups(conf *Config, dn, userName string) ([]group, error) {
+	m.lock.Lock()
+	cached, exists := m.groups[strings.ToLower(dn)]
+	m.lock.Unlock()
+
+	if exists && time.Now().Before(cached.expire) {
+		return cached.groups, nil
+	}
+
+	groups, err := m.lookupGroups(conf, dn, userName)
+	if err != nil {
+		if err != errUserNotFound && exists {
+			blog.Warnf("lookup ldap groups of user %s failed, use the expired groups, err: %v", dn, err)
+			return cached.groups, nil
+		}
+		return nil, err
+	}
+
+	m.cacheGroups(conf, dn, groups)
+	return groups, nil
+}
+
+// LoginUser get the login user from session, and maps the ldap groups of the user to the supplier accounts
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo,
+	bool) {
+
+	rid := httpheader.GetRid(c.Request.Header)
+	session := sessions.Default(c)
+	dn, _ := session.Get(sessionDN).(string)
+	userName, _ := session.Get(sessionUserName).(string)
+	if dn == "" || userName == "" {
+		return nil, false
+	}
+
+	loginTime, _ := session.Get(sessionLoginTime).(int64)
+	if time.Since(time.Unix(loginTime, 0)) > loginExpire {
+		blog.Infof("ldap user %s login expired, rid: %s", userName, rid)
+		clearIdentity(session)
+		return nil, false
+	}
+
+	conf, err := m.loadConfig()
+	if err != nil {
+		blog.Errorf("load ldap config failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+
+	groups, err := m.getGroups(conf, dn, userName)
+	if err != nil {
+		blog.Errorf("get ldap groups of user %s failed, err: %v, rid: %s", userName, err, rid)
+		if err == errUserNotFound {
+			clearIdentity(session)
+		}
+		return nil, false
+	}
+
+	suppliers := mapGroups(conf, groups)
+	current := suppliers[0]
+	cookieOwnerID, err := c.Cookie(common.HTTPCookieSupplierAccount)
+	if err == nil {
+		for _, supplier := range suppliers {
+			if supplier.OwnerID == cookieOwnerID {
+				current = supplier
+			}
+		}
+	}
+	if cookieOwnerID != current.OwnerID {
+		c.SetCookie(common.HTTPCookieSupplierAccount, current.OwnerID, 0, "/", "", false, false)
+	}
+
+	groupNames := make([]string, len(groups))
+	for idx, g := range groups {
+		groupNames[idx] = g.Name
+	}
+
+	displayName, _ := session.Get(sessionDisplayName).(string)
+	email, _ := session.Get(sessionEmail).(string)
+	phone, _ := session.Get(sessionPhone).(string)
+	return &metadata.LoginUserInfo{
+		UserName:      userName,
+		ChName:        displayName,
+		Phone:         phone,
+		Email:         email,
+		Role:          fmt.Sprint(current.Role),
+		BkToken:       "",
+		OnwerUin:      current.OwnerID,
+		OwnerUinArr:   suppliers,
+		IsOwner:       current.Role == roleAdmin,
+		Extra:         map[string]interface{}{"groups": groupNames},
+		Language:      webCommon.GetLanguageByHTTPRequest(c),
+		MultiSupplier: len(suppliers) > 1,
+	}, true
+}
+
+func clearIdentity(session sessions.Session) {
+	for _, key := range []string{sessionDN, sessionUserName, sessionDisplayName, sessionEmail, sessionPhone,
+		sessionLoginTime} {
+		session.Delete(key)
+	}
+	if err := session.Save(); err != nil {
+		blog.Warnf("save session failed, err: %v", err)
+	}
+}
+
+// GetLoginUrl get the url of the login page of web server
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	var siteURL string
+	var err error
+	if common.LogoutHTTPSchemeHTTPS == input.HTTPScheme {
+		siteURL, err = cc.String("webServer.site.httpsDomainUrl")
+	} else {
+		siteURL, err = cc.String("webServer.site.domainUrl")
+	}
+	if err != nil {
+		siteURL = ""
+	}
+	siteURL = strings.TrimRight(siteURL, "/")
+	return fmt.Sprintf("%s/login?c_url=%s%s", siteURL, siteURL, c.Request.URL.String())
+}
+
+// GetUserList get the users in the ldap directory
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo,
+	*ccErr.RawErrorInfo) {
+
+	rid := httpheader.GetRid(c.Request.Header)
+	conf, err := m.loadConfig()
+	if err != nil {
+		blog.Errorf("load ldap config failed, err: %v, rid: %s", err, rid)
+		return nil, &ccErr.RawErrorInfo{ErrCode: common.CCErrCommConfMissItem, Args: []interface{}{configKey}}
+	}
+
+	conn, err := m.dial(conf)
+	if err != nil {
+		blog.Errorf("connect to ldap server failed, err: %v, rid: %s", err, rid)
+		return nil, &ccErr.RawErrorInfo{ErrCode: common.CCErrCommInternalServerError, Args: []interface{}{"ldap"}}
+	}
+	defer conn.close()
+
+	filter := strings.ReplaceAll(conf.UserFilter, "{username}", "*")
+	entries, err := conn.search(conf.UserBaseDN, ldapv3.ScopeWholeSubtree, filter, userAttributes(conf),
+		maxUserListSize)
+	if err != nil {
+		blog.Errorf("search ldap users failed, err: %v, rid: %s", err, rid)
+		return nil, &ccErr.RawErrorInfo{ErrCode: common.CCErrCommInternalServerError, Args: []interface{}{"ldap"}}
+	}
+
+	users := make([]*metadata.LoginSystemUserInfo, 0, len(entries))
+	for _, e := range entries {
+		ident := newIdentity(conf, e)
+		if ident.UserName == "" {
+			continue
+		}
+		users = append(users, &metadata.LoginSystemUserInfo{CnName: ident.DisplayName, EnName: ident.UserName})
+	}
+	return users, nil
+}
@@ -0,0 +1,612 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+const (
+	testBindDN       = "cn=cmdb,ou=services,dc=example,dc=com"
+	testBindPassword = "service"
+	startTLSOID      = "1.3.6.1.4.1.1466.20037"
+)
+
+// testEntry is an entry of the test directory, the attribute names are in lower case
+type testEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// testDirectory is an in-process ldap server that serves the entries in memory
+type testDirectory struct {
+	listener net.Listener
+	tlsConf  *tls.Config
+	// requireTLS rejects the bind requests before StartTLS
+	requireTLS bool
+
+	lock      sync.Mutex
+	entries   []*testEntry
+	passwords map[string]string
+}
+
+func newTestDirectory(t *testing.T) *testDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &testDirectory{
+		listener: listener,
+		tlsConf:  &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}},
+		passwords: map[string]string{
+			testBindDN:                              testBindPassword,
+			"uid=alice,ou=people,dc=example,dc=com": "alice-password",
+			"uid=bob,ou=people,dc=example,dc=com":   "bob-password",
+		},
+	}
+	d.add("uid=alice,ou=people,dc=example,dc=com", map[string][]string{"objectClass": {"person"}, "uid": {"alice"},
+		"cn": {"Alice"}, "mail": {"alice@example.com"}, "telephoneNumber": {"10086"}})
+	d.add("uid=bob,ou=people,dc=example,dc=com", map[string][]string{"objectClass": {"person"}, "uid": {"bob"}})
+	d.add("cn=ops,ou=groups,dc=example,dc=com", map[string][]string{"objectClass": {"groupOfNames"}, "cn": {"ops"},
+		"member": {"uid=alice,ou=people,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"}})
+	d.add("cn=dba-admins,ou=groups,dc=example,dc=com", map[string][]string{"objectClass": {"groupOfNames"},
+		"cn": {"dba-admins"}, "member": {"uid=alice,ou=people,dc=example,dc=com"}})
+
+	go d.serve()
+	return d
+}
+
+func (d *testDirectory) url() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *testDirectory) add(dn string, attributes map[string][]string) {
+	e := &testEntry{dn: dn, attributes: make(map[string][]string)}
+	for name, values := range attributes {
+		e.attributes[strings.ToLower(name)] = values
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.entries = append(d.entries, e)
+}
+
+func (d *testDirectory) remove(dn string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for idx, e := range d.entries {
+		if strings.EqualFold(e.dn, dn) {
+			d.entries = append(d.entries[:idx], d.entries[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (d *testDirectory) serve() {
+	for {
+		c, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(c)
+	}
+}
+
+func (d *testDirectory) handle(c net.Conn) {
+	defer c.Close()
+
+	reader := bufio.NewReader(c)
+	bound, isTLS := false, false
+	for {
+		message, err := ber.ReadPacket(reader)
+		if err != nil || len(message.Children) < 2 {
+			return
+		}
+		msgID, _ := message.Children[0].Value.(int64)
+		op := message.Children[1]
+
+		write := func(ops ...*ber.Packet) {
+			for _, p := range ops {
+				envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, ""))
+				envelope.AppendChild(p)
+				_, _ = c.Write(envelope.Bytes())
+			}
+		}
+
+		if op.ClassType != ber.ClassApplication {
+			return
+		}
+
+		switch op.Tag {
+		case ldapv3.ApplicationUnbindRequest:
+			return
+
+		case ldapv3.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			d.lock.Lock()
+			expected, exists := d.passwords[dn]
+			d.lock.Unlock()
+
+			switch {
+			case d.requireTLS && !isTLS:
+				write(ldapResult(ldapv3.ApplicationBindResponse, ldapv3.LDAPResultConfidentialityRequired,
+					"confidentiality required"))
+			case !exists || expected != password:
+				bound = false
+				write(ldapResult(ldapv3.ApplicationBindResponse, ldapv3.LDAPResultInvalidCredentials,
+					"invalid credentials"))
+			default:
+				bound = true
+				write(ldapResult(ldapv3.ApplicationBindResponse, ldapv3.LDAPResultSuccess, ""))
+			}
+
+		case ldapv3.ApplicationExtendedRequest:
+			if op.Children[0].Data.String() != startTLSOID {
+				write(ldapResult(ldapv3.ApplicationExtendedResponse, ldapv3.LDAPResultProtocolError,
+					"unsupported extended operation"))
+				continue
+			}
+			write(ldapResult(ldapv3.ApplicationExtendedResponse, ldapv3.LDAPResultSuccess, ""))
+			tlsConn := tls.Server(c, d.tlsConf)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			c, reader, isTLS = tlsConn, bufio.NewReader(tlsConn), true
+
+		case ldapv3.ApplicationSearchRequest:
+			if !bound {
+				write(ldapResult(ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultInsufficientAccessRights,
+					"anonymous search is not allowed"))
+				continue
+			}
+			write(d.search(op)...)
+		}
+	}
+}
+
+func (d *testDirectory) search(op *ber.Packet) []*ber.Packet {
+	baseDN := strings.ToLower(op.Children[0].Data.String())
+	scope, _ := op.Children[1].Value.(int64)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	attributes := make([]string, 0)
+	for _, attr := range op.Children[7].Children {
+		attributes = append(attributes, strings.ToLower(attr.Data.String()))
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	responses := make([]*ber.Packet, 0)
+	baseExists := false
+	for _, e := range d.entries {
+		dn := strings.ToLower(e.dn)
+		if dn == baseDN {
+			baseExists = true
+		}
+		if scope == ldapv3.ScopeBaseObject && dn != baseDN ||
+			scope != ldapv3.ScopeBaseObject && !strings.HasSuffix(dn, baseDN) {
+			continue
+		}
+		if !matchFilter(filter, e) {
+			continue
+		}
+
+		if sizeLimit > 0 && int64(len(responses)) >= sizeLimit {
+			return append(responses, ldapResult(ldapv3.ApplicationSearchResultDone,
+				ldapv3.LDAPResultSizeLimitExceeded, ""))
+		}
+
+		attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		for _, name := range attributes {
+			attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+			values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, value := range e.attributes[name] {
+				values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+			}
+			attr.AppendChild(values)
+			attrs.AppendChild(attr)
+		}
+
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapv3.ApplicationSearchResultEntry, nil, "")
+		entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+		entry.AppendChild(attrs)
+		responses = append(responses, entry)
+	}
+
+	if scope == ldapv3.ScopeBaseObject && !baseExists {
+		return []*ber.Packet{ldapResult(ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultNoSuchObject,
+			"no such object")}
+	}
+	return append(responses, ldapResult(ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultSuccess, ""))
+}
+
+// matchFilter supports the and, or, not, equality, substrings and present filters
+func matchFilter(filter *ber.Packet, e *testEntry) bool {
+	switch filter.Tag {
+	case ldapv3.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchFilter(child, e) {
+				return false
+			}
+		}
+		return true
+	case ldapv3.FilterOr:
+		for _, child := range filter.Children {
+			if matchFilter(child, e) {
+				return true
+			}
+		}
+		return false
+	case ldapv3.FilterNot:
+		return !matchFilter(filter.Children[0], e)
+	case ldapv3.FilterEqualityMatch:
+		for _, value := range e.attributes[strings.ToLower(filter.Children[0].Data.String())] {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldapv3.FilterSubstrings:
+		for _, value := range e.attributes[strings.ToLower(filter.Children[0].Data.String())] {
+			if matchSubstrings(strings.ToLower(value), filter.Children[1]) {
+				return true
+			}
+		}
+		return false
+	case ldapv3.FilterPresent:
+		return len(e.attributes[strings.ToLower(filter.Data.String())]) > 0
+	}
+	return false
+}
+
+func matchSubstrings(value string, substrings *ber.Packet) bool {
+	for _, sub := range substrings.Children {
+		part := strings.ToLower(sub.Data.String())
+		switch sub.Tag {
+		case ldapv3.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, part) {
+				return false
+			}
+			value = value[len(part):]
+		case ldapv3.FilterSubstringsAny:
+			idx := strings.Index(value, part)
+			if idx < 0 {
+				return false
+			}
+			value = value[idx+len(part):]
+		case ldapv3.FilterSubstringsFinal:
+			if !strings.HasSuffix(value, part) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func ldapResult(op ber.Tag, code uint16, message string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, ""))
+	return p
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func testConfig(url string) *Config {
+	conf := &Config{
+		URL:          url,
+		BindDN:       testBindDN,
+		BindPassword: testBindPassword,
+		UserBaseDN:   "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(uid={username}))",
+		GroupBaseDN:  "ou=groups,dc=example,dc=com",
+		GroupMappings: []GroupMapping{
+			{Group: "cn=ops,ou=groups,dc=example,dc=com", SupplierAccount: "0"},
+			{Group: "cn=dba-admins,ou=groups,dc=example,dc=com", SupplierAccount: "1", Admin: true},
+		},
+	}
+	if err := conf.Validate(); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+func TestGroupMappings(t *testing.T) {
+	conf := testConfig("ldap://127.0.0.1:389")
+	conf.GroupMappings = []GroupMapping{{Group: "ops", SupplierAccount: "0"}}
+	if err := conf.Validate(); err == nil {
+		t.Errorf("expect group mapping without dn rejected")
+	}
+
+	// the groups with the same name in different organizations are not matched
+	conf.GroupMappings = []GroupMapping{{Group: "cn=ops,ou=groups,dc=example,dc=com", SupplierAccount: "1"}}
+	suppliers := mapGroups(conf, []group{{DN: "cn=ops,ou=partner,dc=example,dc=com", Name: "ops"}})
+	if len(suppliers) != 1 || suppliers[0].OwnerID != conf.DefaultSupplierAccount {
+		t.Errorf("expect group of other organization not mapped, got %+v", suppliers)
+	}
+
+	// the dn is compared by the attribute types and values
+	suppliers = mapGroups(conf, []group{{DN: "CN=ops, OU=Groups,DC=example,DC=com", Name: "ops"}})
+	if len(suppliers) != 1 || suppliers[0].OwnerID != "1" {
+		t.Errorf("expect group mapped by dn, got %+v", suppliers)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	directory := newTestDirectory(t)
+	defer directory.listener.Close()
+
+	conf := testConfig(directory.url())
+	plugin := newUser(func() (*Config, error) { return conf, nil })
+
+	ident, groups, err := plugin.authenticate(conf, "alice", "alice-password")
+	if err != nil {
+		t.Fatalf("authenticate alice failed, err: %v", err)
+	}
+	if ident.DN != "uid=alice,ou=people,dc=example,dc=com" || ident.DisplayName != "Alice" ||
+		ident.Email != "alice@example.com" || ident.Phone != "10086" || len(groups) != 2 {
+		t.Errorf("unexpected identity %+v, groups: %+v", ident, groups)
+	}
+
+	failures := map[string][2]string{
+		"wrong password":   {"alice", "bob-password"},
+		"empty password":   {"alice", ""},
+		"unknown user":     {"carol", "alice-password"},
+		"filter injection": {"*)(uid=*", "alice-password"},
+	}
+	for name, credential := range failures {
+		_, _, err := plugin.authenticate(conf, credential[0], credential[1])
+		if err != errUserNotFound && !isInvalidCredentials(err) {
+			t.Errorf("%s: expect authenticate failed with invalid credentials, err: %v", name, err)
+		}
+	}
+
+	// bob is in the ops group only, and the default display name is the user name
+	ident, groups, err = plugin.authenticate(conf, "bob", "bob-password")
+	if err != nil {
+		t.Fatalf("authenticate bob failed, err: %v", err)
+	}
+	suppliers := mapGroups(conf, groups)
+	if ident.DisplayName != "bob" || len(suppliers) != 1 || suppliers[0].OwnerID != "0" ||
+		suppliers[0].Role != roleMember {
+		t.Errorf("unexpected identity %+v, suppliers: %+v", ident, suppliers)
+	}
+
+	wrongService := *conf
+	wrongService.BindPassword = "wrong"
+	if _, _, err := plugin.authenticate(&wrongService, "alice", "alice-password"); err == nil ||
+		isInvalidCredentials(err) {
+		t.Errorf("expect authenticate with wrong service account failed with system error, err: %v", err)
+	}
+}
+
+func TestStartTLS(t *testing.T) {
+	directory := newTestDirectory(t)
+	directory.requireTLS = true
+	defer directory.listener.Close()
+
+	conf := testConfig(directory.url())
+	plugin := newUser(func() (*Config, error) { return conf, nil })
+	if _, _, err := plugin.authenticate(conf, "alice", "alice-password"); err == nil {
+		t.Errorf("expect bind without tls rejected")
+	}
+
+	conf.StartTLS = true
+	conf.TLS.InsecureSkipVerify = true
+	if _, _, err := plugin.authenticate(conf, "alice", "alice-password"); err != nil {
+		t.Errorf("authenticate with start tls failed, err: %v", err)
+	}
+
+	// the server certificate is verified by default
+	conf.TLS.InsecureSkipVerify = false
+	if _, _, err := plugin.authenticate(conf, "alice", "alice-password"); err == nil {
+		t.Errorf("expect start tls with untrusted certificate failed")
+	}
+}
+
+func TestLoginUser(t *testing.T) {
+	directory := newTestDirectory(t)
+	defer directory.listener.Close()
+
+	conf := testConfig(directory.url())
+	plugin := newUser(func() (*Config, error) { return conf, nil })
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sessions.Sessions("cc3", cookie.NewStore([]byte("test"))))
+	engine.POST("/login", func(c *gin.Context) {
+		if rawErr := plugin.AuthenticateUser(c, nil, c.PostForm("username"), c.PostForm("password")); rawErr != nil {
+			c.JSON(http.StatusUnauthorized, rawErr)
+			return
+		}
+	})
+	engine.GET("/index", func(c *gin.Context) {
+		userInfo, ok := plugin.LoginUser(c, nil, false)
+		if !ok {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.JSON(http.StatusOK, &loginResult{LoginUserInfo: userInfo, IsOwner: userInfo.IsOwner, Role: userInfo.Role})
+	})
+
+	var cookies []*http.Cookie
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		for _, c := range w.Result().Cookies() {
+			if c.Name == "cc3" {
+				cookies = []*http.Cookie{c}
+			}
+		}
+		return w
+	}
+	login := func(userName, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login",
+			strings.NewReader("username="+userName+"&password="+password))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return do(req)
+	}
+	index := func(supplier string) (*loginResult, bool) {
+		req := httptest.NewRequest(http.MethodGet, "/index", nil)
+		if supplier != "" {
+			req.AddCookie(&http.Cookie{Name: common.HTTPCookieSupplierAccount, Value: supplier})
+		}
+
+		w := do(req)
+		if w.Code != http.StatusOK {
+			return nil, false
+		}
+		userInfo := new(loginResult)
+		if err := json.Unmarshal(w.Body.Bytes(), userInfo); err != nil {
+			t.Fatal(err)
+		}
+		return userInfo, true
+	}
+
+	if w := login("alice", "wrong"); w.Code != http.StatusUnauthorized ||
+		!strings.Contains(w.Body.String(), "1111014") {
+		t.Fatalf("expect login with wrong password failed, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := index(""); ok {
+		t.Fatalf("expect not logged in")
+	}
+
+	if w := login("alice", "alice-password"); w.Code != http.StatusOK {
+		t.Fatalf("login failed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	userInfo, ok := index("")
+	if !ok {
+		t.Fatalf("expect logged in")
+	}
+	if userInfo.UserName != "alice" || userInfo.ChName != "Alice" || userInfo.OnwerUin != "0" || userInfo.IsOwner ||
+		!userInfo.MultiSupplier || len(userInfo.OwnerUinArr) != 2 || userInfo.OwnerUinArr[1].Role != roleAdmin {
+		t.Errorf("unexpected user info %+v", userInfo)
+	}
+
+	// switch to the supplier account that alice is the admin of
+	userInfo, ok = index("1")
+	if !ok || userInfo.OnwerUin != "1" || !userInfo.IsOwner || userInfo.Role != "1" {
+		t.Errorf("unexpected user info of supplier account 1: %+v", userInfo)
+	}
+
+	// group membership is cached until it expires
+	directory.remove("cn=dba-admins,ou=groups,dc=example,dc=com")
+	if userInfo, _ := index(""); len(userInfo.OwnerUinArr) != 2 {
+		t.Errorf("expect cached groups used, got %+v", userInfo.OwnerUinArr)
+	}
+	expireGroupCache(plugin)
+	if userInfo, _ := index("1"); len(userInfo.OwnerUinArr) != 1 || userInfo.OnwerUin != "0" {
+		t.Errorf("expect groups refreshed, got %+v", userInfo.OwnerUinArr)
+	}
+
+	// the expired groups are used when the ldap server is unavailable
+	unavailable := *conf
+	unavailable.URL = "ldap://127.0.0.1:1"
+	plugin.loadConfig = func() (*Config, error) { return &unavailable, nil }
+	expireGroupCache(plugin)
+	if _, ok := index(""); !ok {
+		t.Errorf("expect expired groups used when ldap server is unavailable")
+	}
+
+	// the user is logged out after being deleted from the directory
+	plugin.loadConfig = func() (*Config, error) { return conf, nil }
+	directory.remove("uid=alice,ou=people,dc=example,dc=com")
+	expireGroupCache(plugin)
+	if _, ok := index(""); ok {
+		t.Errorf("expect deleted user logged out")
+	}
+}
+
+// loginResult returns the user info with the fields that are not marshaled
+type loginResult struct {
+	*metadata.LoginUserInfo
+	IsOwner bool   `json:"is_owner"`
+	Role    string `json:"role"`
+}
+
+func expireGroupCache(plugin *user) {
+	plugin.lock.Lock()
+	defer plugin.lock.Unlock()
+	for _, cached := range plugin.groups {
+		cached.expire = time.Now().Add(-time.Second)
+	}
+}
+
+func TestGetUserList(t *testing.T) {
+	directory := newTestDirectory(t)
+	defer directory.listener.Close()
+
+	conf := testConfig(directory.url())
+	plugin := newUser(func() (*Config, error) { return conf, nil })
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/user/list", nil)
+	users, rawErr := plugin.GetUserList(ctx, nil)
+	if rawErr != nil {
+		t.Fatalf("get user list failed, err: %+v", rawErr)
+	}
+	if len(users) != 2 || users[0].EnName != "alice" || users[0].CnName != "Alice" || users[1].EnName != "bob" {
+		t.Errorf("unexpected users %+v", users)
+	}
+}
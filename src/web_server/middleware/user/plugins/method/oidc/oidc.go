@@ -0,0 +1,545 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package oidc login method with an OpenID Connect provider, it uses the authorization code flow with PKCE
+package oidc
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	ccErr "configcenter/src/common/errors"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/ssl"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "openid connect login system",
+		Version:    common.BKOIDCLoginPluginVersion,
+		HandleFunc: &user{loadConfig: loadConfig},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+const (
+	configKey = "webServer.oidc"
+	// callbackPath is the path of the web server that the issuer redirects the user to after login
+	callbackPath = "/login/callback"
+)
+
+// the session keys of the login request and the logged-in identity
+const (
+	sessionState       = "oidc_state"
+	sessionNonce       = "oidc_nonce"
+	sessionVerifier    = "oidc_verifier"
+	sessionRedirect    = "oidc_redirect"
+	sessionUserName    = "oidc_username"
+	sessionDisplayName = "oidc_display_name"
+	sessionEmail       = "oidc_email"
+	sessionPhone       = "oidc_phone"
+	sessionSupplier    = "oidc_supplier_account"
+	sessionGroups      = "oidc_groups"
+	sessionIDToken     = "oidc_id_token"
+	sessionRefresh     = "oidc_refresh_token"
+	sessionExpiry      = "oidc_expiry"
+)
+
+// Config is the OpenID Connect login config
+type Config struct {
+	// Issuer is the issuer url, the discovery document is got from its /.well-known/openid-configuration
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"clientId"`
+	ClientSecret string `mapstructure:"clientSecret"`
+	// Scopes are the space separated scopes to request, default is "openid profile email"
+	Scopes string `mapstructure:"scopes"`
+	// RedirectURL is the callback url registered in the issuer, default is the site url with /login/callback path
+	RedirectURL string `mapstructure:"redirectUrl"`
+	// PostLogoutRedirectURL is the url the issuer redirects to after logout, default is the site url
+	PostLogoutRedirectURL string       `mapstructure:"postLogoutRedirectUrl"`
+	Claims                ClaimsConfig `mapstructure:"claims"`
+	// SiteURL is the cmdb site url, which is the default url to redirect to after login
+	SiteURL string              `mapstructure:"-"`
+	TLS     ssl.TLSClientConfig `mapstructure:"-"`
+}
+
+// ClaimsConfig is the names of the id token claims that the user info is mapped from, nested claims are specified
+// by the dot separated path, like "realm_access.roles"
+type ClaimsConfig struct {
+	// UserName default is preferred_username, sub is used if it is not set in the id token
+	UserName string `mapstructure:"username"`
+	// DisplayName default is name
+	DisplayName string `mapstructure:"displayName"`
+	// Email default is email
+	Email string `mapstructure:"email"`
+	// Phone default is phone_number
+	Phone string `mapstructure:"phone"`
+	// SupplierAccount is not mapped by default, the default supplier account is used if it is not set
+	SupplierAccount string `mapstructure:"supplierAccount"`
+	// Groups default is groups
+	Groups string `mapstructure:"groups"`
+}
+
+// Validate the config and set the default values
+func (c *Config) Validate() error {
+	if c.Issuer == "" {
+		return errors.New("oidc issuer is not set")
+	}
+
+	if c.ClientID == "" {
+		return errors.New("oidc client id is not set")
+	}
+
+	c.SiteURL = strings.TrimRight(c.SiteURL, "/")
+	if c.Scopes == "" {
+		c.Scopes = "openid profile email"
+	}
+	if c.RedirectURL == "" {
+		c.RedirectURL = c.SiteURL + callbackPath
+	}
+	if c.PostLogoutRedirectURL == "" {
+		c.PostLogoutRedirectURL = c.SiteURL
+	}
+	if c.Claims.UserName == "" {
+		c.Claims.UserName = "preferred_username"
+	}
+	if c.Claims.DisplayName == "" {
+		c.Claims.DisplayName = "name"
+	}
+	if c.Claims.Email == "" {
+		c.Claims.Email = "email"
+	}
+	if c.Claims.Phone == "" {
+		c.Claims.Phone = "phone_number"
+	}
+	if c.Claims.Groups == "" {
+		c.Claims.Groups = "groups"
+	}
+	return nil
+}
+
+func loadConfig() (*Config, error) {
+	conf := new(Config)
+	if err := cc.UnmarshalKey(configKey, conf); err != nil {
+		return nil, fmt.Errorf("parse %s config failed, err: %v", configKey, err)
+	}
+
+	var err error
+	conf.SiteURL, _ = cc.String("webServer.site.domainUrl")
+	conf.TLS, err = cc.NewTLSClientConfigFromConfig(configKey + ".tls")
+	if err != nil {
+		return nil, fmt.Errorf("parse %s tls config failed, err: %v", configKey, err)
+	}
+
+	return conf, conf.Validate()
+}
+
+type user struct {
+	loadConfig func() (*Config, error)
+
+	lock     sync.Mutex
+	provider *provider
+}
+
+// getProvider get the provider of the current config, the provider is created again if the config is changed
+func (m *user) getProvider() (*provider, error) {
+	conf, err := m.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.provider != nil && m.provider.conf == *conf {
+		return m.provider, nil
+	}
+
+	tlsConf, _, err := ssl.NewTLSConfigFromConf(&conf.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("new oidc tls config failed, err: %v", err)
+	}
+	tlsConf.InsecureSkipVerify = conf.TLS.InsecureSkipVerify
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	m.provider = newProvider(*conf, &http.Client{Transport: transport, Timeout: requestTimeout})
+	return m.provider, nil
+}
+
+// LoginUser user login, the user is logged in if the identity got from the id token is in the session, and the id
+// token is not expired or can be refreshed by the refresh token
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo,
+	bool) {
+
+	rid := httpheader.GetRid(c.Request.Header)
+	session := sessions.Default(c)
+
+	userName, _ := session.Get(sessionUserName).(string)
+	if userName == "" {
+		return nil, false
+	}
+
+	expiry, _ := session.Get(sessionExpiry).(int64)
+	if time.Now().Unix() >= expiry {
+		if err := m.refresh(c, session); err != nil {
+			blog.Errorf("refresh oidc tokens of user %s failed, err: %v, rid: %s", userName, err, rid)
+			clearIdentity(session)
+			if err := session.Save(); err != nil {
+				blog.Warnf("save session failed, err: %v, rid: %s", err, rid)
+			}
+			return nil, false
+		}
+	}
+
+	return sessionUser(c, session), true
+}
+
+// refresh get new tokens by the refresh token in the session, and update the identity in the session
+func (m *user) refresh(c *gin.Context, session sessions.Session) error {
+	refreshToken, _ := session.Get(sessionRefresh).(string)
+	if refreshToken == "" {
+		return errors.New("id token is expired and there is no refresh token")
+	}
+
+	p, err := m.getProvider()
+	if err != nil {
+		return err
+	}
+
+	token, err := p.refresh(c.Request.Context(), refreshToken)
+	if err != nil {
+		return err
+	}
+
+	// the issuer may not return a new id token when refreshing, the expiry is calculated by the access token then
+	if token.IDToken == "" {
+		if token.ExpiresIn <= 0 {
+			return errors.New("refresh response has neither id token nor expiry")
+		}
+		session.Set(sessionExpiry, time.Now().Unix()+token.ExpiresIn)
+		if token.RefreshToken != "" {
+			session.Set(sessionRefresh, token.RefreshToken)
+		}
+		return session.Save()
+	}
+
+	claims, err := p.verifyIDToken(c.Request.Context(), token.IDToken, "")
+	if err != nil {
+		return err
+	}
+
+	ident, err := mapClaims(claims, p.conf.Claims)
+	if err != nil {
+		return err
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	saveIdentity(session, ident, token)
+	return session.Save()
+}
+
+// GetLoginUrl get the login url of the issuer, the state, nonce and PKCE code verifier of the login request are saved
+// in the session to validate the callback request
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	rid := httpheader.GetRid(c.Request.Header)
+
+	p, err := m.getProvider()
+	if err != nil {
+		blog.Errorf("get oidc provider failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+
+	randoms := make([]string, 3)
+	for idx := range randoms {
+		if randoms[idx], err = randomString(); err != nil {
+			blog.Errorf("generate oidc login request state failed, err: %v, rid: %s", err, rid)
+			return ""
+		}
+	}
+
+	state, nonce, verifier := randoms[0], randoms[1], randoms[2]
+	loginURL, err := p.authCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		blog.Errorf("get oidc login url failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+
+	session := sessions.Default(c)
+	session.Set(sessionState, state)
+	session.Set(sessionNonce, nonce)
+	session.Set(sessionVerifier, verifier)
+	session.Set(sessionRedirect, p.conf.SiteURL+c.Request.URL.String())
+	if err := session.Save(); err != nil {
+		blog.Errorf("save oidc login request to session failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+
+	return loginURL
+}
+
+// HandleLoginCallback validate the callback request of the issuer, exchange the authorization code for the tokens,
+// and save the identity got from the id token in the session
+func (m *user) HandleLoginCallback(c *gin.Context, config map[string]string) (string, error) {
+	if errCode := c.Query("error"); errCode != "" {
+		return "", fmt.Errorf("oidc login failed, error: %s, description: %s", errCode,
+			c.Query("error_description"))
+	}
+
+	session := sessions.Default(c)
+	state, _ := session.Get(sessionState).(string)
+	nonce, _ := session.Get(sessionNonce).(string)
+	verifier, _ := session.Get(sessionVerifier).(string)
+	redirectURL, _ := session.Get(sessionRedirect).(string)
+	if state == "" || c.Query("state") != state {
+		return "", errors.New("oidc login state does not match")
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		return "", errors.New("oidc authorization code is not set")
+	}
+
+	// the login request can only be used once
+	session.Delete(sessionState)
+	session.Delete(sessionNonce)
+	session.Delete(sessionVerifier)
+	session.Delete(sessionRedirect)
+
+	p, err := m.getProvider()
+	if err != nil {
+		return "", err
+	}
+
+	token, err := p.exchange(c.Request.Context(), code, verifier)
+	if err != nil {
+		return "", fmt.Errorf("exchange oidc authorization code failed, err: %v", err)
+	}
+
+	if token.IDToken == "" {
+		return "", errors.New("oidc token response has no id token")
+	}
+
+	claims, err := p.verifyIDToken(c.Request.Context(), token.IDToken, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	ident, err := mapClaims(claims, p.conf.Claims)
+	if err != nil {
+		return "", err
+	}
+
+	saveIdentity(session, ident, token)
+	if err := session.Save(); err != nil {
+		return "", fmt.Errorf("save oidc identity to session failed, err: %v", err)
+	}
+
+	if redirectURL == "" {
+		redirectURL = p.conf.SiteURL
+	}
+	return redirectURL, nil
+}
+
+// GetLogoutUrl get the logout url of the issuer so that the user is logged out of the sso system too, the cmdb site
+// url is returned if the issuer does not support it
+func (m *user) GetLogoutUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	rid := httpheader.GetRid(c.Request.Header)
+
+	p, err := m.getProvider()
+	if err != nil {
+		blog.Errorf("get oidc provider failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+
+	idToken, _ := sessions.Default(c).Get(sessionIDToken).(string)
+	logoutURL, err := p.logoutURL(c.Request.Context(), idToken)
+	if err != nil {
+		blog.Errorf("get oidc logout url failed, err: %v, rid: %s", err, rid)
+	}
+
+	if logoutURL == "" {
+		return p.conf.PostLogoutRedirectURL
+	}
+	return logoutURL
+}
+
+// GetUserList get user list, there is no user directory in OpenID Connect, only the current user is returned
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo,
+	*ccErr.RawErrorInfo) {
+
+	users := make([]*metadata.LoginSystemUserInfo, 0)
+	session := sessions.Default(c)
+	if userName, _ := session.Get(sessionUserName).(string); userName != "" {
+		displayName, _ := session.Get(sessionDisplayName).(string)
+		users = append(users, &metadata.LoginSystemUserInfo{CnName: displayName, EnName: userName})
+	}
+	return users, nil
+}
+
+// identity is the user info mapped from the id token claims
+type identity struct {
+	UserName        string
+	DisplayName     string
+	Email           string
+	Phone           string
+	SupplierAccount string
+	Groups          []string
+	Expiry          int64
+}
+
+func mapClaims(claims jwt.MapClaims, conf ClaimsConfig) (*identity, error) {
+	ident := &identity{
+		UserName:        claimString(claims, conf.UserName),
+		DisplayName:     claimString(claims, conf.DisplayName),
+		Email:           claimString(claims, conf.Email),
+		Phone:           claimString(claims, conf.Phone),
+		SupplierAccount: claimString(claims, conf.SupplierAccount),
+		Groups:          claimStrings(claims, conf.Groups),
+	}
+
+	if ident.UserName == "" {
+		ident.UserName = claimString(claims, "sub")
+	}
+	if ident.UserName == "" {
+		return nil, fmt.Errorf("id token has no %s claim", conf.UserName)
+	}
+
+	if ident.DisplayName == "" {
+		ident.DisplayName = ident.UserName
+	}
+	if ident.SupplierAccount == "" {
+		ident.SupplierAccount = common.BKDefaultOwnerID
+	}
+
+	if exp, ok := claims["exp"].(float64); ok {
+		ident.Expiry = int64(exp)
+	}
+	return ident, nil
+}
+
+// claimValue get the value of the claim by the dot separated path
+func claimValue(claims jwt.MapClaims, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+
+	var value interface{} = map[string]interface{}(claims)
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	return value
+}
+
+func claimString(claims jwt.MapClaims, path string) string {
+	switch value := claimValue(claims, path).(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	values := make([]string, 0)
+	switch value := claimValue(claims, path).(type) {
+	case string:
+		if value != "" {
+			values = append(values, value)
+		}
+	case []interface{}:
+		for _, item := range value {
+			if str, ok := item.(string); ok && str != "" {
+				values = append(values, str)
+			}
+		}
+	}
+	return values
+}
+
+func saveIdentity(session sessions.Session, ident *identity, token *tokenResponse) {
+	session.Set(sessionUserName, ident.UserName)
+	session.Set(sessionDisplayName, ident.DisplayName)
+	session.Set(sessionEmail, ident.Email)
+	session.Set(sessionPhone, ident.Phone)
+	session.Set(sessionSupplier, ident.SupplierAccount)
+	session.Set(sessionGroups, strings.Join(ident.Groups, ","))
+	session.Set(sessionIDToken, token.IDToken)
+	session.Set(sessionRefresh, token.RefreshToken)
+	session.Set(sessionExpiry, ident.Expiry)
+}
+
+func clearIdentity(session sessions.Session) {
+	for _, key := range []string{sessionUserName, sessionDisplayName, sessionEmail, sessionPhone, sessionSupplier,
+		sessionGroups, sessionIDToken, sessionRefresh, sessionExpiry} {
+		session.Delete(key)
+	}
+}
+
+func sessionUser(c *gin.Context, session sessions.Session) *metadata.LoginUserInfo {
+	userName, _ := session.Get(sessionUserName).(string)
+	displayName, _ := session.Get(sessionDisplayName).(string)
+	email, _ := session.Get(sessionEmail).(string)
+	phone, _ := session.Get(sessionPhone).(string)
+	supplier, _ := session.Get(sessionSupplier).(string)
+	groupStr, _ := session.Get(sessionGroups).(string)
+
+	groups := make([]string, 0)
+	if groupStr != "" {
+		groups = strings.Split(groupStr, ",")
+	}
+
+	return &metadata.LoginUserInfo{
+		UserName: userName,
+		ChName:   displayName,
+		Phone:    phone,
+		Email:    email,
+		BkToken:  "",
+		OnwerUin: supplier,
+		IsOwner:  false,
+		Extra:    map[string]interface{}{"groups": groups},
+		Language: webCommon.GetLanguageByHTTPRequest(c),
+	}
+}
+
+// randomString generate a random string that is used as the state, nonce and PKCE code verifier
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("generate random string failed, err: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,440 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testClientID     = "cmdb"
+	testClientSecret = "secret"
+)
+
+// mockIssuer is a local OpenID Connect issuer that issues id tokens for the authorization codes it generated
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	lock      sync.Mutex
+	jwksCount int
+	codes     map[string]url.Values
+	refreshs  map[string]jwt.MapClaims
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	issuer := &mockIssuer{
+		codes:    make(map[string]url.Values),
+		refreshs: make(map[string]jwt.MapClaims),
+		claims: jwt.MapClaims{
+			"sub":                "10001",
+			"preferred_username": "alice",
+			"name":               "Alice",
+			"email":              "alice@example.com",
+			"tenant":             map[string]interface{}{"id": float64(0)},
+			"groups":             []interface{}{"ops", "dba"},
+		},
+	}
+	issuer.rotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(discoveryDoc{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JwksURI:               issuer.server.URL + "/jwks",
+			EndSessionEndpoint:    issuer.server.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.lock.Lock()
+		defer issuer.lock.Unlock()
+		issuer.jwksCount++
+		// the key of the type that is not supported is skipped
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jsonWebKey{{
+			Kty: "OKP",
+			Kid: "ed25519",
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+		}, {
+			Kty: "RSA",
+			Kid: issuer.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", issuer.handleToken)
+	issuer.server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (i *mockIssuer) rotateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.key, i.kid = key, fmt.Sprintf("key-%d", time.Now().UnixNano())
+}
+
+// authorize simulate the user logs in at the authorization endpoint, returns the authorization code
+func (i *mockIssuer) authorize(t *testing.T, loginURL string) url.Values {
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := u.Query()
+	if query.Get("client_id") != testClientID || query.Get("code_challenge_method") != "S256" ||
+		query.Get("response_type") != "code" {
+		t.Fatalf("invalid authorization request: %s", loginURL)
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	code, err := randomString()
+	if err != nil {
+		t.Fatalf("generate authorization code failed, err: %v", err)
+	}
+	i.codes[code] = query
+	return url.Values{"code": {code}, "state": {query.Get("state")}}
+}
+
+func (i *mockIssuer) signIDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.kid
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (i *mockIssuer) idTokenClaims(nonce string) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss": i.server.URL,
+		"aud": testClientID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for key, value := range i.claims {
+		claims[key] = value
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return claims
+}
+
+func (i *mockIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	writeErr := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(tokenResponse{Error: code})
+	}
+
+	if id, secret, ok := r.BasicAuth(); !ok || id != testClientID || secret != testClientSecret {
+		writeErr("invalid_client")
+		return
+	}
+
+	var claims jwt.MapClaims
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		request, exists := i.codes[r.PostFormValue("code")]
+		if !exists {
+			writeErr("invalid_grant")
+			return
+		}
+		delete(i.codes, r.PostFormValue("code"))
+
+		if codeChallenge(r.PostFormValue("code_verifier")) != request.Get("code_challenge") ||
+			r.PostFormValue("redirect_uri") != request.Get("redirect_uri") {
+			writeErr("invalid_grant")
+			return
+		}
+		claims = i.idTokenClaims(request.Get("nonce"))
+
+	case "refresh_token":
+		if _, exists := i.refreshs[r.PostFormValue("refresh_token")]; !exists {
+			writeErr("invalid_grant")
+			return
+		}
+		delete(i.refreshs, r.PostFormValue("refresh_token"))
+		claims = i.idTokenClaims("")
+
+	default:
+		writeErr("unsupported_grant_type")
+		return
+	}
+
+	refreshToken, err := randomString()
+	if err != nil {
+		writeErr("server_error")
+		return
+	}
+	accessToken, err := randomString()
+	if err != nil {
+		writeErr("server_error")
+		return
+	}
+
+	i.refreshs[refreshToken] = claims
+	_ = json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		RefreshToken: refreshToken,
+		ExpiresIn:    3600,
+		IDToken:      i.signIDToken(claims),
+	})
+}
+
+func testConfig(issuer string) *Config {
+	conf := &Config{
+		Issuer:       issuer,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		SiteURL:      "http://cmdb.example.com/",
+		Claims:       ClaimsConfig{SupplierAccount: "tenant.id"},
+	}
+	if err := conf.Validate(); err != nil {
+		panic(err)
+	}
+	return conf
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	issuer := newMockIssuer(t)
+	defer issuer.server.Close()
+
+	p := newProvider(*testConfig(issuer.server.URL), issuer.server.Client())
+	ctx := context.Background()
+
+	if _, err := p.verifyIDToken(ctx, issuer.signIDToken(issuer.idTokenClaims("n1")), "n1"); err != nil {
+		t.Errorf("verify valid id token failed, err: %v", err)
+	}
+
+	invalidCases := map[string]func(claims jwt.MapClaims){
+		"wrong audience": func(claims jwt.MapClaims) { claims["aud"] = "other" },
+		"wrong issuer":   func(claims jwt.MapClaims) { claims["iss"] = "http://other" },
+		"wrong nonce":    func(claims jwt.MapClaims) { claims["nonce"] = "n2" },
+		"expired":        func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiry":      func(claims jwt.MapClaims) { delete(claims, "exp") },
+	}
+	for name, modify := range invalidCases {
+		claims := issuer.idTokenClaims("n1")
+		modify(claims)
+		if _, err := p.verifyIDToken(ctx, issuer.signIDToken(claims), "n1"); err == nil {
+			t.Errorf("%s: expect verify id token failed", name)
+		}
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.idTokenClaims(""))
+	forged.Header["kid"] = issuer.kid
+	forgedToken, _ := forged.SignedString(otherKey)
+	if _, err := p.verifyIDToken(ctx, forgedToken, ""); err == nil {
+		t.Errorf("expect verify id token signed by other key failed")
+	}
+
+	// the json web keys are not fetched again for the unknown key id within the refetch interval
+	issuer.rotateKey(t)
+	if _, err := p.verifyIDToken(ctx, issuer.signIDToken(issuer.idTokenClaims("")), ""); err == nil {
+		t.Errorf("expect verify id token signed by unknown key within refetch interval failed")
+	}
+	if issuer.jwksCount != 1 {
+		t.Errorf("expect json web keys fetched once, actual: %d", issuer.jwksCount)
+	}
+
+	// the json web keys are fetched again after the refetch interval, and the rotated key is found
+	p.keysFetchTime = time.Now().Add(-keysRefetchInterval)
+	if _, err := p.verifyIDToken(ctx, issuer.signIDToken(issuer.idTokenClaims("")), ""); err != nil {
+		t.Errorf("verify id token signed by rotated key failed, err: %v", err)
+	}
+	if issuer.jwksCount != 2 {
+		t.Errorf("expect json web keys fetched twice, actual: %d", issuer.jwksCount)
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	issuer := newMockIssuer(t)
+	defer issuer.server.Close()
+
+	conf := testConfig(issuer.server.URL)
+	plugin := &user{loadConfig: func() (*Config, error) {
+		copied := *conf
+		return &copied, nil
+	}}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sessions.Sessions("cc3", cookie.NewStore([]byte("test"))))
+	engine.GET("/index", func(c *gin.Context) {
+		userInfo, ok := plugin.LoginUser(c, nil, false)
+		if !ok {
+			c.String(http.StatusUnauthorized, plugin.GetLoginUrl(c, nil, &metadata.LogoutRequestParams{}))
+			return
+		}
+		c.JSON(http.StatusOK, userInfo)
+	})
+	engine.GET(callbackPath, func(c *gin.Context) {
+		redirectURL, err := plugin.HandleLoginCallback(c, nil)
+		if err != nil {
+			c.String(http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.Redirect(http.StatusFound, redirectURL)
+	})
+	engine.GET("/expire", func(c *gin.Context) {
+		session := sessions.Default(c)
+		session.Set(sessionExpiry, int64(0))
+		_ = session.Save()
+	})
+	engine.GET("/logout", func(c *gin.Context) {
+		c.String(http.StatusOK, plugin.GetLogoutUrl(c, nil, &metadata.LogoutRequestParams{}))
+	})
+
+	var cookies []*http.Cookie
+	do := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if newCookies := w.Result().Cookies(); len(newCookies) > 0 {
+			cookies = newCookies
+		}
+		return w
+	}
+
+	// not logged in, redirect to the issuer
+	w := do("/index")
+	if w.Code != http.StatusUnauthorized || !strings.HasPrefix(w.Body.String(), issuer.server.URL+"/authorize?") {
+		t.Fatalf("expect redirect to issuer, got %d: %s", w.Code, w.Body.String())
+	}
+	callback := issuer.authorize(t, w.Body.String())
+
+	// callback with wrong state is rejected
+	if w := do(callbackPath + "?code=" + callback.Get("code") + "&state=wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect callback with wrong state rejected, got %d", w.Code)
+	}
+
+	w = do("/index")
+	callback = issuer.authorize(t, w.Body.String())
+	w = do(callbackPath + "?" + callback.Encode())
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "http://cmdb.example.com/index" {
+		t.Fatalf("expect redirect to the original page, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// the callback can not be replayed
+	if w := do(callbackPath + "?" + callback.Encode()); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect replayed callback rejected, got %d", w.Code)
+	}
+
+	checkUser := func() {
+		w := do("/index")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expect logged in, got %d: %s", w.Code, w.Body.String())
+		}
+
+		userInfo := new(metadata.LoginUserInfo)
+		if err := json.Unmarshal(w.Body.Bytes(), userInfo); err != nil {
+			t.Fatal(err)
+		}
+		if userInfo.UserName != "alice" || userInfo.ChName != "Alice" || userInfo.Email != "alice@example.com" ||
+			userInfo.OnwerUin != "0" || fmt.Sprint(userInfo.Extra["groups"]) != "[ops dba]" {
+			t.Errorf("unexpected user info: %+v", userInfo)
+		}
+	}
+	checkUser()
+
+	// the tokens are refreshed after the id token expires
+	do("/expire")
+	checkUser()
+
+	w = do("/logout")
+	logoutURL, err := url.Parse(w.Body.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logoutURL.Path != "/logout" || logoutURL.Query().Get("id_token_hint") == "" ||
+		logoutURL.Query().Get("post_logout_redirect_uri") != "http://cmdb.example.com" {
+		t.Errorf("unexpected logout url: %s", w.Body.String())
+	}
+
+	// refresh token can not be used twice, the user needs to log in again
+	issuer.lock.Lock()
+	issuer.refreshs = make(map[string]jwt.MapClaims)
+	issuer.lock.Unlock()
+	do("/expire")
+	if w := do("/index"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expect login again after refresh failed, got %d", w.Code)
+	}
+}
+
+func TestMapClaims(t *testing.T) {
+	claims := jwt.MapClaims{
+		"sub":       "10001",
+		"groups":    "ops",
+		"exp":       float64(1700000000),
+		"ext_attrs": map[string]interface{}{"supplier": float64(1)},
+	}
+
+	ident, err := mapClaims(claims, ClaimsConfig{UserName: "preferred_username", SupplierAccount: "ext_attrs.supplier",
+		Groups: "groups"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ident.UserName != "10001" || ident.DisplayName != "10001" || ident.SupplierAccount != "1" ||
+		len(ident.Groups) != 1 || ident.Groups[0] != "ops" || ident.Expiry != 1700000000 {
+		t.Errorf("unexpected identity: %+v", ident)
+	}
+
+	if _, err := mapClaims(jwt.MapClaims{}, ClaimsConfig{UserName: "preferred_username"}); err == nil {
+		t.Errorf("expect map claims without user name failed")
+	}
+}
@@ -0,0 +1,413 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// metadataTTL is the time that the discovery document and the json web keys of the issuer are cached
+	metadataTTL = 10 * time.Minute
+	// requestTimeout is the timeout of the requests to the issuer
+	requestTimeout = 30 * time.Second
+	// keysRefetchInterval is the minimum interval of fetching the json web keys, so that the id tokens with unknown
+	// key ids can not make the issuer be requested again and again
+	keysRefetchInterval = time.Minute
+)
+
+// signingMethods are the algorithms that are allowed to sign the id token
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// discoveryDoc is the OpenID Connect discovery document of the issuer
+type discoveryDoc struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// jsonWebKey is a public key in the json web key set of the issuer, only rsa and ec keys are supported
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// tokenResponse is the response of the token endpoint
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// provider is the client of the OpenID Connect issuer
+type provider struct {
+	conf   Config
+	client *http.Client
+
+	lock     sync.Mutex
+	doc      *discoveryDoc
+	docTime  time.Time
+	keys     map[string]interface{}
+	keysTime time.Time
+	// keysFetchTime is the time of the last attempt to fetch the json web keys, no matter it succeeded or not
+	keysFetchTime time.Time
+}
+
+func newProvider(conf Config, client *http.Client) *provider {
+	return &provider{conf: conf, client: client}
+}
+
+// discovery get the discovery document of the issuer
+func (p *provider) discovery(ctx context.Context) (*discoveryDoc, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.doc != nil && time.Since(p.docTime) < metadataTTL {
+		return p.doc, nil
+	}
+
+	doc := new(discoveryDoc)
+	wellKnown := strings.TrimSuffix(p.conf.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, doc); err != nil {
+		return nil, fmt.Errorf("get discovery document failed, err: %v", err)
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.conf.Issuer, "/") {
+		return nil, fmt.Errorf("issuer %s in discovery document does not match %s", doc.Issuer, p.conf.Issuer)
+	}
+
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, errors.New("discovery document lacks authorization, token or jwks endpoint")
+	}
+
+	p.doc, p.docTime = doc, time.Now()
+	return doc, nil
+}
+
+// authCodeURL generate the url of the authorization endpoint that the user is redirected to for login
+func (p *provider) authCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	doc, err := p.discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.conf.ClientID},
+		"redirect_uri":          {p.conf.RedirectURL},
+		"scope":                 {p.conf.Scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	return appendQuery(doc.AuthorizationEndpoint, params), nil
+}
+
+// exchange exchange the authorization code for the tokens
+func (p *provider) exchange(ctx context.Context, code, verifier string) (*tokenResponse, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.conf.RedirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+// refresh get new tokens by the refresh token
+func (p *provider) refresh(ctx context.Context, refreshToken string) (*tokenResponse, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (p *provider) token(ctx context.Context, params url.Values) (*tokenResponse, error) {
+	doc, err := p.discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("client_id", p.conf.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint,
+		strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.conf.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.conf.ClientID), url.QueryEscape(p.conf.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	token := new(tokenResponse)
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("decode token response failed, status: %d, err: %v", resp.StatusCode, err)
+	}
+
+	if token.Error != "" {
+		return nil, fmt.Errorf("token endpoint returns error %s: %s", token.Error, token.ErrorDescription)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returns status %d", resp.StatusCode)
+	}
+	return token, nil
+}
+
+// verifyIDToken verify the signature and the claims of the id token, the nonce is not checked if it is empty
+func (p *provider) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	doc, err := p.discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods))
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, doc.JwksURI, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token, err: %v", err)
+	}
+
+	if !claims.VerifyIssuer(doc.Issuer, true) {
+		return nil, fmt.Errorf("id token issuer %v does not match %s", claims["iss"], doc.Issuer)
+	}
+
+	if !claims.VerifyAudience(p.conf.ClientID, true) {
+		return nil, fmt.Errorf("id token audience %v does not contain %s", claims["aud"], p.conf.ClientID)
+	}
+
+	if azp, exists := claims["azp"]; exists && azp != p.conf.ClientID {
+		return nil, fmt.Errorf("id token authorized party %v is not %s", azp, p.conf.ClientID)
+	}
+
+	if _, exists := claims["exp"]; !exists {
+		return nil, errors.New("id token has no expiration time")
+	}
+
+	if nonce != "" && claims["nonce"] != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+
+	return claims, nil
+}
+
+// getKey get the public key to verify the id token, the json web key set is fetched again if the key is not found,
+// because the issuer may have rotated its keys, but it is not fetched more than once in keysRefetchInterval
+func (p *provider) getKey(ctx context.Context, jwksURI, kid string) (interface{}, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key, exists := p.findKey(kid)
+	if exists && time.Since(p.keysTime) < metadataTTL {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchTime) < keysRefetchInterval {
+		if exists {
+			return key, nil
+		}
+		return nil, fmt.Errorf("json web key %s is not found", kid)
+	}
+	p.keysFetchTime = time.Now()
+
+	keySet := new(struct {
+		Keys []jsonWebKey `json:"keys"`
+	})
+	if err := p.getJSON(ctx, jwksURI, keySet); err != nil {
+		return nil, fmt.Errorf("get json web keys failed, err: %v", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		// the issuer may publish keys of the types that are not supported, skip them instead of rejecting all keys
+		key, err := jwk.publicKey()
+		if err != nil {
+			blog.Warnf("skip json web key %s that can not be used, type: %s, curve: %s, err: %v", jwk.Kid, jwk.Kty,
+				jwk.Crv, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys, p.keysTime = keys, time.Now()
+
+	if key, exists := p.findKey(kid); exists {
+		return key, nil
+	}
+	return nil, fmt.Errorf("json web key %s is not found", kid)
+}
+
+// findKey find the key by kid, the only key is used if the id token does not specify the kid
+func (p *provider) findKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, exists := p.keys[kid]
+	return key, exists
+}
+
+// logoutURL generate the url of the end session endpoint of the issuer, returns empty if it is not supported
+func (p *provider) logoutURL(ctx context.Context, idToken string) (string, error) {
+	doc, err := p.discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if doc.EndSessionEndpoint == "" {
+		return "", nil
+	}
+
+	params := url.Values{"client_id": {p.conf.ClientID}}
+	if idToken != "" {
+		params.Set("id_token_hint", idToken)
+	}
+	if p.conf.PostLogoutRedirectURL != "" {
+		params.Set("post_logout_redirect_uri", p.conf.PostLogoutRedirectURL)
+	}
+
+	return appendQuery(doc.EndSessionEndpoint, params), nil
+}
+
+func (p *provider) getJSON(ctx context.Context, rawURL string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s returns status %d", rawURL, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// codeChallenge generate the PKCE code challenge of the code verifier with S256 method
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func appendQuery(rawURL string, params url.Values) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + params.Encode()
+	}
+	return rawURL + "?" + params.Encode()
+}
@@ -14,6 +14,8 @@ package user
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
 
 	"configcenter/src/apimachinery/apiserver"
 	"configcenter/src/common"
@@ -98,7 +100,23 @@ func (m *publicUser) LoginUser(c *gin.Context) bool {
 
 // GetLoginUrl TODO
 func (m *publicUser) GetLoginUrl(c *gin.Context) string {
+	params := getLogoutRequestParams(c)
+	user := plugins.CurrentPlugin(m.config.LoginVersion)
+	return user.GetLoginUrl(c, m.config.ConfigMap, params)
+
+}
 
+// GetLogoutUrl get the url to redirect to after logout
+func (m *publicUser) GetLogoutUrl(c *gin.Context) string {
+	params := getLogoutRequestParams(c)
+	user := plugins.CurrentPlugin(m.config.LoginVersion)
+	if logoutPlugin, ok := user.(metadata.LogoutPluginInterface); ok {
+		return logoutPlugin.GetLogoutUrl(c, m.config.ConfigMap, params)
+	}
+	return user.GetLoginUrl(c, m.config.ConfigMap, params)
+}
+
+func getLogoutRequestParams(c *gin.Context) *metadata.LogoutRequestParams {
 	params := new(metadata.LogoutRequestParams)
 	err := json.NewDecoder(c.Request.Body).Decode(params)
 	if nil != err || (common.LogoutHTTPSchemeHTTP != params.HTTPScheme && common.LogoutHTTPSchemeHTTPS != params.HTTPScheme) {
@@ -107,10 +125,50 @@ func (m *publicUser) GetLoginUrl(c *gin.Context) string {
 			params.HTTPScheme = common.LogoutHTTPSchemeHTTP
 		}
 	}
+	return params
+}
 
+// HandleLoginCallback handle the callback request of the external login system, returns the url to redirect to
+func (m *publicUser) HandleLoginCallback(c *gin.Context) (string, error) {
 	user := plugins.CurrentPlugin(m.config.LoginVersion)
-	return user.GetLoginUrl(c, m.config.ConfigMap, params)
+	callbackPlugin, ok := user.(metadata.LoginCallbackPluginInterface)
+	if !ok {
+		return "", fmt.Errorf("login version %s does not support login callback", m.config.LoginVersion)
+	}
 
+	redirectURL, err := callbackPlugin.HandleLoginCallback(c, m.config.ConfigMap)
+	if err != nil {
+		return "", err
+	}
+
+	if !m.LoginUser(c) {
+		return "", stderrors.New("login user failed after login callback")
+	}
+	return redirectURL, nil
+}
+
+// SupportPasswordLogin check if the login plugin authenticates the user name and password submitted to the login page
+func (m *publicUser) SupportPasswordLogin() bool {
+	_, ok := plugins.CurrentPlugin(m.config.LoginVersion).(metadata.PasswordLoginPluginInterface)
+	return ok
+}
+
+// AuthenticateUser authenticate the user name and password with the login plugin, and log in the user if authenticated
+func (m *publicUser) AuthenticateUser(c *gin.Context, userName, password string) *errors.RawErrorInfo {
+	user := plugins.CurrentPlugin(m.config.LoginVersion)
+	passwordPlugin, ok := user.(metadata.PasswordLoginPluginInterface)
+	if !ok {
+		return &errors.RawErrorInfo{ErrCode: common.CCErrWebUnknownLoginVersion}
+	}
+
+	if rawErr := passwordPlugin.AuthenticateUser(c, m.config.ConfigMap, userName, password); rawErr != nil {
+		return rawErr
+	}
+
+	if !m.LoginUser(c) {
+		return &errors.RawErrorInfo{ErrCode: common.CCErrWebUsernamePasswdWrong}
+	}
+	return nil
 }
 
 // GetUserList TODO
@@ -33,6 +33,14 @@ type LoginInterface interface {
 	GetLoginUrl(c *gin.Context) string
 	// GetUserList 获取不同登录方式下对应的用户列表
 	GetUserList(c *gin.Context) ([]*metadata.LoginSystemUserInfo, *errors.RawErrorInfo)
+	// GetLogoutUrl 获取退出登录后跳转的URL，登录系统支持退出登录时为登录系统的退出登录地址，否则为登录地址
+	GetLogoutUrl(c *gin.Context) string
+	// HandleLoginCallback 处理外部登录系统登录成功后的回调请求，返回登录后跳转的URL
+	HandleLoginCallback(c *gin.Context) (string, error)
+	// SupportPasswordLogin 登录系统是否通过登录插件校验登录页面提交的用户名和密码
+	SupportPasswordLogin() bool
+	// AuthenticateUser 通过登录插件校验用户名和密码，校验通过后登录用户
+	AuthenticateUser(c *gin.Context, userName, password string) *errors.RawErrorInfo
 }
 
 // NewUser return user instance by type
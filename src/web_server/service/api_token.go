@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	webCommon "configcenter/src/web_server/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Service) initAPIToken(ws *gin.Engine) {
+	ws.POST("/create/api_token", s.CreateAPIToken)
+	ws.DELETE("/delete/api_token/:id", s.DeleteAPIToken)
+	ws.POST("/findmany/api_token", s.ListAPITokens)
+}
+
+// CreateAPIToken create personal api token for the login user, the token is only returned once
+func (s *Service) CreateAPIToken(c *gin.Context) {
+	webCommon.SetProxyHeader(c)
+	kit := rest.NewKitFromHeader(c.Request.Header, s.CCErr)
+
+	opt := new(metadata.CreateAPITokenOption)
+	if err := json.NewDecoder(c.Request.Body).Decode(opt); err != nil {
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: common.CCErrCommHTTPReadBodyFailed, ErrMsg: err.Error()})
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: rawErr.ErrCode, ErrMsg: rawErr.ToCCError(kit.CCError).Error()})
+		return
+	}
+
+	result, err := s.ApiCli.CreateAPIToken(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("create api token failed, err: %v, name: %s, rid: %s", err, opt.Name, kit.Rid)
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: err.GetCode(), ErrMsg: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+}
+
+// DeleteAPIToken revoke personal api token of the login user
+func (s *Service) DeleteAPIToken(c *gin.Context) {
+	webCommon.SetProxyHeader(c)
+	kit := rest.NewKitFromHeader(c.Request.Header, s.CCErr)
+
+	id, err := strconv.ParseInt(c.Param(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: common.CCErrCommParamsInvalid,
+			ErrMsg: kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID).Error()})
+		return
+	}
+
+	if err := s.ApiCli.DeleteAPIToken(kit.Ctx, kit.Header, id); err != nil {
+		blog.Errorf("delete api token %d failed, err: %v, rid: %s", id, err, kit.Rid)
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: err.GetCode(), ErrMsg: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(nil))
+}
+
+// ListAPITokens list personal api tokens of the login user
+func (s *Service) ListAPITokens(c *gin.Context) {
+	webCommon.SetProxyHeader(c)
+	kit := rest.NewKitFromHeader(c.Request.Header, s.CCErr)
+
+	opt := new(metadata.ListAPITokenOption)
+	if err := json.NewDecoder(c.Request.Body).Decode(opt); err != nil {
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: common.CCErrCommHTTPReadBodyFailed, ErrMsg: err.Error()})
+		return
+	}
+
+	if rawErr := opt.Validate(); rawErr.ErrCode != 0 {
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: rawErr.ErrCode, ErrMsg: rawErr.ToCCError(kit.CCError).Error()})
+		return
+	}
+
+	result, err := s.ApiCli.ListAPITokens(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("list api tokens failed, err: %v, opt: %+v, rid: %s", err, opt, kit.Rid)
+		c.JSON(http.StatusOK, metadata.BaseResp{Code: err.GetCode(), ErrMsg: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.NewSuccessResp(result))
+}
@@ -73,6 +73,12 @@ const (
 	AsstDataRowIdx = 2
 )
 
+// csv and json lines file const define
+const (
+	// FlatAsstField csv和json lines文件中，存放实例关联关系的列；字段标识都以字母开头，所以不会与之冲突
+	FlatAsstField = "_associations"
+)
+
 // export instance const define
 const (
 	// TopoObjID 导出主机实例时，「业务拓扑」这一字段的objID
@@ -178,3 +178,20 @@ func GetIDProp(colIndex int, objID string, defLang language.DefaultCCLanguageIf)
 
 	return idProperty
 }
+
+// GetFlatHeader get the column keys of csv or json lines file, which are the property ids
+func GetFlatHeader(colProps []ColProp, withAsst bool) []string {
+	header := make([]string, 0, len(colProps)+1)
+	for _, prop := range colProps {
+		if prop.NotExport {
+			continue
+		}
+		header = append(header, prop.ID)
+	}
+
+	if withAsst {
+		header = append(header, FlatAsstField)
+	}
+
+	return header
+}
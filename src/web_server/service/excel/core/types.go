@@ -60,3 +60,11 @@ type SimpleHost struct {
 	Ipv6    string
 	AgentID string
 }
+
+// FlatAsst instance association in csv or json lines file, csv file stores the associations of an instance as json
+type FlatAsst struct {
+	AsstID  string `json:"bk_obj_asst_id"`
+	Op      AsstOp `json:"op,omitempty"`
+	SrcInst string `json:"src"`
+	DstInst string `json:"dst"`
+}
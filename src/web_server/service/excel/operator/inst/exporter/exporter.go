@@ -65,6 +65,10 @@ func ExportParam(param ExportParamI) BuildExporterFunc {
 
 // Export export data to excel
 func (e *Exporter) Export() error {
+	if e.GetFlatFile() != nil {
+		return e.exportFlat()
+	}
+
 	cond, err := e.exportParam.GetPropCond()
 	if err != nil {
 		blog.Errorf("get property condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
@@ -374,6 +378,27 @@ func (e *Exporter) exportAsst(instIDs []int64) error {
 }
 
 func (e *Exporter) getInstAsst(instIDs []int64) ([][]excel.Cell, error) {
+	asstData, err := e.findInstAsstData(instIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构造需要写到excel的关联关系数据
+	result := make([][]excel.Cell, len(asstData))
+	for idx, data := range asstData {
+		row := make([]excel.Cell, core.AsstDstInstColIdx+1)
+		row[core.AsstIDColIdx] = excel.Cell{Value: data.asstID}
+		row[core.AsstSrcInstColIdx] = excel.Cell{Value: data.srcInst}
+		row[core.AsstDstInstColIdx] = excel.Cell{Value: data.destInst}
+
+		result[idx] = row
+	}
+
+	return result, nil
+}
+
+// findInstAsstData 获取实例需要导出的关联关系，源实例和目标实例使用唯一标识表示
+func (e *Exporter) findInstAsstData(instIDs []int64) ([]instAsstData, error) {
 	// 1. 获取需要导出的模型关联关系，以及判断是否有自关联的关联关系
 	asstObjUniqueIDMap := e.exportParam.GetAsstObjUniqueIDMap()
 	asstObjIDMap := make(map[string]struct{})
@@ -429,21 +454,12 @@ func (e *Exporter) getInstAsst(instIDs []int64) ([][]excel.Cell, error) {
 		return nil, err
 	}
 
-	// 4. 构造需要写到excel的关联关系数据
-	result := make([][]excel.Cell, len(asstData))
-	for idx, data := range asstData {
-		row := make([]excel.Cell, core.AsstDstInstColIdx+1)
-		row[core.AsstIDColIdx] = excel.Cell{Value: data.asstID}
-		row[core.AsstSrcInstColIdx] = excel.Cell{Value: data.srcInst}
-		row[core.AsstDstInstColIdx] = excel.Cell{Value: data.destInst}
-
-		result[idx] = row
-	}
-
-	return result, nil
+	return asstData, nil
 }
 
 type instAsstData struct {
+	// instID 关联关系中，当前操作对象的实例id
+	instID   int64
 	asstID   string
 	srcInst  string
 	destInst string
@@ -511,7 +527,8 @@ func (e *Exporter) getInstAsstData(instAsstArr []*metadata.InstAsst) ([]instAsst
 				continue
 			}
 
-			result = append(result, instAsstData{asstID: instAsst.ObjectAsstID, srcInst: srcInst, destInst: dstInst})
+			result = append(result, instAsstData{instID: instAsst.InstID, asstID: instAsst.ObjectAsstID,
+				srcInst: srcInst, destInst: dstInst})
 			continue
 		}
 
@@ -535,7 +552,8 @@ func (e *Exporter) getInstAsstData(instAsstArr []*metadata.InstAsst) ([]instAsst
 			continue
 		}
 
-		result = append(result, instAsstData{asstID: instAsst.ObjectAsstID, srcInst: srcInst, destInst: dstInst})
+		result = append(result, instAsstData{instID: instAsst.AsstInstID, asstID: instAsst.ObjectAsstID,
+			srcInst: srcInst, destInst: dstInst})
 	}
 
 	return result, nil
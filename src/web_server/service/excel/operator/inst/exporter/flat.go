@@ -0,0 +1,249 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package exporter
+
+import (
+	"configcenter/pkg/excel"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+)
+
+// buildFlatHeader create a csv or json lines file with a header. json lines file has no header line, so an example
+// record with empty values of all the columns is written instead
+func (t *TmplOp) buildFlatHeader(colProps []core.ColProp) error {
+	if err := t.openFlatWriter(colProps, true); err != nil {
+		return err
+	}
+
+	if t.GetFlatFile().GetFormat() != excel.JSONL {
+		return nil
+	}
+
+	header := core.GetFlatHeader(colProps, true)
+	example := make(map[string]interface{}, len(header))
+	for _, key := range header {
+		example[key] = ""
+	}
+
+	if err := t.flatWriter.Write(example); err != nil {
+		blog.Errorf("write example record failed, err: %v, rid: %s", err, t.GetKit().Rid)
+		t.closeFlatWriter()
+		return err
+	}
+
+	return nil
+}
+
+func (t *TmplOp) openFlatWriter(colProps []core.ColProp, withAsst bool) error {
+	var err error
+	t.flatWriter, err = t.GetFlatFile().NewWriter(core.GetFlatHeader(colProps, withAsst))
+	if err != nil {
+		blog.Errorf("create flat file writer failed, err: %v, rid: %s", err, t.GetKit().Rid)
+		return err
+	}
+
+	return nil
+}
+
+func (t *TmplOp) closeFlatWriter() error {
+	if t.flatWriter == nil {
+		return nil
+	}
+
+	err := t.flatWriter.Close()
+	t.flatWriter = nil
+	if err != nil {
+		blog.Errorf("close flat file writer failed, err: %v, rid: %s", err, t.GetKit().Rid)
+		return err
+	}
+
+	return nil
+}
+
+// exportFlat export data to csv or json lines file. instances are written page by page with their associations,
+// so that the memory usage does not grow with the number of instances
+func (e *Exporter) exportFlat() error {
+	cond, err := e.exportParam.GetPropCond()
+	if err != nil {
+		blog.Errorf("get property condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+	colProps, err := e.GetClient().GetSortedColProp(e.GetKit(), cond)
+	if err != nil {
+		blog.Errorf("get sorted column property failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	colProps, err = e.addExtraProp(colProps)
+	if err != nil {
+		blog.Errorf("add extra property failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	// 未设置, 不导出关联关系数据
+	withAsst := len(e.exportParam.GetAsstObjUniqueIDMap()) != 0
+	if err := e.openFlatWriter(colProps, withAsst); err != nil {
+		return err
+	}
+
+	// 导出失败时不会再调用Close，需要在这里关闭文件
+	for e.exportParam.HasInstCond() {
+		instCond, err := e.exportParam.GetInstCond()
+		if err != nil {
+			blog.Errorf("get instance condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			e.closeFlatWriter()
+			return err
+		}
+
+		if err := e.exportFlatByCond(instCond, colProps, withAsst); err != nil {
+			blog.Errorf("export instance by condition failed, err: %v, rid: %s", err, e.GetKit().Rid)
+			e.closeFlatWriter()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Exporter) exportFlatByCond(cond interface{}, colProps []core.ColProp, withAsst bool) error {
+	insts, err := e.getInst(cond)
+	if err != nil {
+		blog.Errorf("get instance failed, objID: %s, cond: %v, err: %v, rid: %s", e.GetObjID(), cond, err,
+			e.GetKit().Rid)
+		return err
+	}
+
+	if len(insts) == 0 {
+		return nil
+	}
+
+	insts, _, err = e.enrichInst(insts, colProps)
+	if err != nil {
+		blog.Errorf("enrich instance field failed, err: %v, rid: %s", err, e.GetKit().Rid)
+		return err
+	}
+
+	instIDs := make([]int64, len(insts))
+	instIDKey := metadata.GetInstIDFieldByObjID(e.GetObjID())
+	for idx, inst := range insts {
+		instIDs[idx], err = inst.Int64(instIDKey)
+		if err != nil {
+			blog.Errorf("parse instance(%+v) id(key:%s) failed, err: %v, objID: %s, rid: %s", inst, instIDKey, err,
+				e.GetObjID(), e.GetKit().Rid)
+		}
+	}
+
+	asstMap := make(map[int64][]core.FlatAsst)
+	if withAsst {
+		asstData, err := e.findInstAsstData(instIDs)
+		if err != nil {
+			blog.Errorf("get instance association failed, instIDs: %v, err: %v, rid: %s", instIDs, err,
+				e.GetKit().Rid)
+			return err
+		}
+
+		for _, data := range asstData {
+			asstMap[data.instID] = append(asstMap[data.instID],
+				core.FlatAsst{AsstID: data.asstID, SrcInst: data.srcInst, DstInst: data.destInst})
+		}
+	}
+
+	for idx, inst := range insts {
+		record, err := e.getFlatRecord(inst, colProps)
+		if err != nil {
+			blog.ErrorJSON("convert an instance to flat record failed, inst: %s, property: %s, err: %s, rid: %s",
+				inst, colProps, err, e.GetKit().Rid)
+			return err
+		}
+
+		if assts := asstMap[instIDs[idx]]; len(assts) != 0 {
+			record[core.FlatAsstField] = assts
+		}
+
+		if err := e.flatWriter.Write(record); err != nil {
+			blog.ErrorJSON("write data to flat file failed, record: %s, err: %s, rid: %s", record, err,
+				e.GetKit().Rid)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getFlatRecord 将实例转换为以字段标识为key的记录，字段值的转换与导出excel时一致
+func (e *Exporter) getFlatRecord(inst mapstr.MapStr, colProps []core.ColProp) (map[string]interface{}, error) {
+	record := make(map[string]interface{})
+	for _, property := range colProps {
+		if property.NotExport {
+			continue
+		}
+
+		val, ok := inst[property.ID]
+		if !ok {
+			continue
+		}
+
+		handleFunc := getHandleInstFieldFunc(&property)
+		rows, err := handleFunc(e, &property, val)
+		if err != nil {
+			blog.ErrorJSON("handle instance failed, property: %s, val: %s, err: %s, rid: %s", property, val, err,
+				e.GetKit().Rid)
+			return nil, err
+		}
+
+		if property.PropertyType == common.FieldTypeInnerTable {
+			table, err := getFlatTable(&property, rows)
+			if err != nil {
+				return nil, err
+			}
+			record[property.ID] = table
+			continue
+		}
+
+		if len(rows) == 0 || len(rows[0]) == 0 || rows[0][0].Value == nil {
+			continue
+		}
+		record[property.ID] = rows[0][0].Value
+	}
+
+	return record, nil
+}
+
+// getFlatTable 表格字段的每一行单元格按照表头顺序排列，将其转换为以表头字段标识为key的数组
+func getFlatTable(property *core.ColProp, rows [][]excel.Cell) ([]map[string]interface{}, error) {
+	option, err := metadata.ParseTableAttrOption(property.Option)
+	if err != nil {
+		return nil, err
+	}
+
+	table := make([]map[string]interface{}, len(rows))
+	for rowIdx, row := range rows {
+		table[rowIdx] = make(map[string]interface{})
+		for idx, attr := range option.Header {
+			if idx >= len(row) || row[idx].Value == nil {
+				continue
+			}
+			table[rowIdx][attr.PropertyID] = row[idx].Value
+		}
+	}
+
+	return table, nil
+}
@@ -233,7 +233,8 @@ func getHandleTableFieldFunc() handleInstFieldFunc {
 func getDefaultHandleFieldFunc() handleInstFieldFunc {
 	return func(e *Exporter, property *core.ColProp, val interface{}) ([][]excel.Cell, error) {
 		var styleID int
-		if property.NotEditable {
+		// csv和json lines文件没有单元格样式
+		if property.NotEditable && e.GetFlatFile() == nil {
 			var err error
 			styleID, err = e.styleCreator.getStyle(noEditField)
 			if err != nil {
@@ -31,6 +31,7 @@ import (
 type TmplOp struct {
 	*operator.BaseOp
 	styleCreator *styleCreator
+	flatWriter   *excel.FlatWriter
 }
 
 type BuildTmplOpFunc func(tmpl *TmplOp) error
@@ -72,6 +73,10 @@ func (t *TmplOp) BuildHeader(colProps ...core.ColProp) error {
 		}
 	}
 
+	if t.GetFlatFile() != nil {
+		return t.buildFlatHeader(colProps)
+	}
+
 	if err := t.productSheet(); err != nil {
 		blog.Errorf("product sheet failed, err: %v, rid: %s", err, t.GetKit().Rid)
 		return err
@@ -106,6 +111,10 @@ func (t *TmplOp) productSheet() error {
 
 // Close excel
 func (t *TmplOp) Close() error {
+	if t.GetFlatFile() != nil {
+		return t.closeFlatWriter()
+	}
+
 	if err := t.GetExcel().Flush([]string{t.GetObjID(), core.AsstSheet}); err != nil {
 		blog.Errorf("flush excel failed, sheet %s, err: %v, rid: %s", t.GetObjID(), err, t.GetKit().Rid)
 		return err
@@ -126,6 +135,10 @@ func (t *TmplOp) Close() error {
 
 // Clean delete temporary file
 func (t *TmplOp) Clean() error {
+	if t.GetFlatFile() != nil {
+		return t.GetFlatFile().Clean()
+	}
+
 	return t.GetExcel().Clean()
 }
 
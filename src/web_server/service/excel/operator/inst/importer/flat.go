@@ -0,0 +1,428 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"configcenter/pkg/excel"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	httpheader "configcenter/src/common/http/header"
+	"configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/web_server/service/excel/core"
+)
+
+// handleFlat handle import request of csv or json lines file. the file is read record by record and the instances
+// are imported in batches, so that the memory usage does not grow with the file size
+func (i *Importer) handleFlat() (mapstr.MapStr, error) {
+	// 获取文件中关联的模型以及关联关系的条数等信息
+	if i.param.GetOpType() == getAsstFlag {
+		asstInfo, err := i.getAsstFromFlatFile()
+		if err != nil {
+			blog.Errorf("get association info from flat file failed, err: %v, rid: %s", err, i.GetKit().Rid)
+			return nil, err
+		}
+
+		return i.getAsstStatistics(asstInfo)
+	}
+
+	result, asstInfo, hasErrMsg, err := i.importFlatInst()
+	if err != nil {
+		blog.Errorf("import instance failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+
+	if hasErrMsg || len(i.param.GetAsstObjUniqueIDMap()) == 0 || len(asstInfo.asstInfoMap) == 0 {
+		return result, nil
+	}
+
+	return i.importAsstInfo(asstInfo)
+}
+
+func (i *Importer) importFlatInst() (mapstr.MapStr, *excelAsstInfo, bool, error) {
+	propMap, err := i.getFlatPropertyMap()
+	if err != nil {
+		blog.Errorf("get property failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, false, err
+	}
+
+	reader, err := i.GetFlatFile().NewReader()
+	if err != nil {
+		blog.Errorf("create flat file reader failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, false, err
+	}
+
+	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+	asstInfo := newFlatAsstInfo()
+	var successMsg []int64
+	var errMsg []string
+	insts := make(map[int]map[string]interface{})
+	hasData := false
+
+	for reader.Next() {
+		line := reader.GetLine()
+		inst, assts, err := i.getFlatInst(reader, propMap)
+		if err != nil {
+			blog.Errorf("get instance from flat file failed, line: %d, err: %v, rid: %s", line, err, i.GetKit().Rid)
+			errMsg = append(errMsg, lang.Languagef("import_data_fail", line, err.Error()))
+			continue
+		}
+
+		if len(assts) != 0 {
+			hasData = true
+			addFlatAsst(asstInfo, line, assts, lang)
+		}
+
+		if inst == nil {
+			continue
+		}
+		hasData = true
+		insts[line] = inst
+		if len(insts) < onceImportLimit {
+			continue
+		}
+
+		successRes, errRes, err := i.importInstBatch(insts)
+		if err != nil {
+			reader.Close()
+			return nil, nil, false, err
+		}
+		successMsg = append(successMsg, successRes...)
+		errMsg = append(errMsg, errRes...)
+
+		insts = make(map[int]map[string]interface{})
+	}
+
+	if err := reader.Err(); err != nil {
+		blog.Errorf("read flat file failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		reader.Close()
+		return nil, nil, false, err
+	}
+
+	if err := reader.Close(); err != nil {
+		blog.Errorf("close flat file reader failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, false, err
+	}
+
+	result := mapstr.New()
+	if !hasData && len(errMsg) == 0 {
+		result["error"] = []string{lang.Language("web_excel_not_data")}
+		return result, asstInfo, true, nil
+	}
+
+	if len(insts) != 0 {
+		successRes, errRes, err := i.importInstBatch(insts)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		successMsg = append(successMsg, successRes...)
+		errMsg = append(errMsg, errRes...)
+	}
+
+	result["success"] = successMsg
+	result["error"] = errMsg
+	return result, asstInfo, len(errMsg) > 0, nil
+}
+
+// getFlatPropertyMap 获取模型属性，key为字段标识。文件的每个字段值会单独转换为一行数据进行处理，所以字段所在列都为0，
+// 表格字段的每一行数据按照表头顺序转换为一行数据，子字段所在列为其在表头中的位置
+func (i *Importer) getFlatPropertyMap() (map[string]PropWithTable, error) {
+	cond := mapstr.MapStr{
+		common.BKObjIDField: i.GetObjID(),
+		common.BKAppIDField: i.param.GetBizID(),
+	}
+	colProps, err := i.GetClient().GetObjColProp(i.GetKit(), cond)
+	if err != nil {
+		blog.Errorf("get property failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+	handleType := i.param.GetHandleType()
+	if handleType == core.UpdateHost || handleType == core.AddInst {
+		lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+		colProps = append(colProps, core.GetIDProp(core.PropDefaultColIdx, i.GetObjID(), lang))
+	}
+
+	result := make(map[string]PropWithTable, len(colProps))
+	for _, prop := range colProps {
+		prop.ExcelColIndex = core.PropDefaultColIdx
+		if prop.PropertyType != common.FieldTypeInnerTable {
+			result[prop.ID] = PropWithTable{ColProp: prop}
+			continue
+		}
+
+		option, err := metadata.ParseTableAttrOption(prop.Option)
+		if err != nil {
+			return nil, err
+		}
+
+		subProperties := make(map[int]PropWithTable, len(option.Header))
+		for idx, attr := range option.Header {
+			subProp := core.ColProp{ID: attr.PropertyID, Name: attr.PropertyName, PropertyType: attr.PropertyType,
+				Option: attr.Option, IsRequire: attr.IsRequired, ExcelColIndex: idx, Length: core.PropertyNormalLen}
+			subProperties[idx] = PropWithTable{ColProp: subProp}
+		}
+
+		prop.Length = len(subProperties)
+		result[prop.ID] = PropWithTable{ColProp: prop, subProperties: subProperties}
+	}
+
+	return result, nil
+}
+
+// getFlatInst 获取当前记录的实例数据和关联关系数据，字段值的转换与导入excel时一致
+func (i *Importer) getFlatInst(reader *excel.FlatReader, propMap map[string]PropWithTable) (
+	map[string]interface{}, []core.FlatAsst, error) {
+
+	record, err := reader.CurRecord()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inst := make(map[string]interface{})
+	var assts []core.FlatAsst
+	for key, val := range record {
+		if key == core.FlatAsstField {
+			assts, err = parseFlatAsst(val)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s is invalid, err: %v", key, err)
+			}
+			continue
+		}
+
+		prop, ok := propMap[key]
+		if !ok {
+			continue
+		}
+
+		rows, err := getFlatRows(&prop, val)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s is invalid, err: %v", key, err)
+		}
+
+		if len(rows) == 0 {
+			continue
+		}
+
+		handleFunc := getHandleInstFieldFunc(&prop)
+		value, err := handleFunc(i, &prop, rows)
+		if err != nil {
+			blog.ErrorJSON("handle instance failed, property: %s, data: %s, err: %s, rid: %s", prop, rows, err,
+				i.GetKit().Rid)
+			return nil, nil, fmt.Errorf("%s is invalid, err: %v", key, err)
+		}
+		inst[prop.ID] = value
+	}
+
+	if len(inst) == 0 {
+		return nil, assts, nil
+	}
+
+	return inst, assts, nil
+}
+
+// getFlatRows 将字段值转换为与excel一致的行数据，空值返回nil
+func getFlatRows(prop *PropWithTable, val interface{}) ([][]string, error) {
+	if prop.PropertyType != common.FieldTypeInnerTable {
+		cell, err := getFlatCell(prop.PropertyType, val)
+		if err != nil {
+			return nil, err
+		}
+
+		if cell == "" {
+			return nil, nil
+		}
+
+		return [][]string{{cell}}, nil
+	}
+
+	table, err := parseFlatTable(val)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, len(table))
+	for rowIdx, item := range table {
+		rows[rowIdx] = make([]string, len(prop.subProperties))
+		for subIdx, subProp := range prop.subProperties {
+			cell, err := getFlatCell(subProp.PropertyType, item[subProp.ID])
+			if err != nil {
+				return nil, fmt.Errorf("%s is invalid, err: %v", subProp.ID, err)
+			}
+			rows[rowIdx][subIdx] = cell
+		}
+	}
+
+	return rows, nil
+}
+
+// getFlatCell 将字段值转换为excel单元格的值，json lines文件中的多个值可以使用数组表示
+func getFlatCell(propertyType string, val interface{}) (string, error) {
+	switch value := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, len(value))
+		for idx, item := range value {
+			cell, err := getFlatCell(propertyType, item)
+			if err != nil {
+				return "", err
+			}
+			items[idx] = cell
+		}
+
+		// 与excel一致，枚举多选和枚举引用的多个值使用换行分隔，组织和用户的多个值使用逗号分隔
+		sep := ","
+		if propertyType == common.FieldTypeEnumMulti || propertyType == common.FieldTypeEnumQuote {
+			sep = "\n"
+		}
+		return strings.Join(items, sep), nil
+	default:
+		return "", fmt.Errorf("value type %T is invalid", val)
+	}
+}
+
+// parseFlatTable 解析表格字段值，csv文件中使用json字符串表示
+func parseFlatTable(val interface{}) ([]map[string]interface{}, error) {
+	switch value := val.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
+		}
+
+		table := make([]map[string]interface{}, 0)
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&table); err != nil {
+			return nil, err
+		}
+		return table, nil
+	case []interface{}:
+		table := make([]map[string]interface{}, len(value))
+		for idx, item := range value {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("table row type %T is invalid", item)
+			}
+			table[idx] = row
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("table type %T is invalid", val)
+	}
+}
+
+// parseFlatAsst 解析实例的关联关系，csv文件中使用json字符串表示
+func parseFlatAsst(val interface{}) ([]core.FlatAsst, error) {
+	var data []byte
+	switch value := val.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
+		}
+		data = []byte(value)
+	default:
+		var err error
+		data, err = json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	assts := make([]core.FlatAsst, 0)
+	if err := json.Unmarshal(data, &assts); err != nil {
+		return nil, err
+	}
+
+	return assts, nil
+}
+
+func newFlatAsstInfo() *excelAsstInfo {
+	asstInfo := newExcelAsstInfo()
+	asstInfo.rowMap = make(map[int]int)
+	return asstInfo
+}
+
+// addFlatAsst 添加一行记录中的关联关系数据，每条关联关系数据使用单独的序号，并记录其所在的行号
+func addFlatAsst(asstInfo *excelAsstInfo, line int, assts []core.FlatAsst, lang language.DefaultCCLanguageIf) {
+	for _, asst := range assts {
+		idx := len(asstInfo.rowMap) + 1
+		asstInfo.rowMap[idx] = line
+
+		if !asstInfo.addAsst(idx, asst.AsstID, string(asst.Op), asst.SrcInst, asst.DstInst) {
+			msg := lang.Languagef("web_excel_row_handle_error", core.FlatAsstField, line)
+			asstInfo.errMsg = append(asstInfo.errMsg, metadata.RowMsgData{Row: line, Msg: msg})
+		}
+	}
+}
+
+func (i *Importer) getAsstFromFlatFile() (*excelAsstInfo, error) {
+	reader, err := i.GetFlatFile().NewReader()
+	if err != nil {
+		blog.Errorf("create flat file reader failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+
+	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
+	asstInfo := newFlatAsstInfo()
+	for reader.Next() {
+		record, err := reader.CurRecord()
+		if err != nil {
+			continue
+		}
+
+		assts, err := parseFlatAsst(record[core.FlatAsstField])
+		if err != nil {
+			msg := lang.Languagef("web_excel_row_handle_error", core.FlatAsstField, reader.GetLine())
+			asstInfo.errMsg = append(asstInfo.errMsg, metadata.RowMsgData{Row: reader.GetLine(), Msg: msg})
+			continue
+		}
+
+		addFlatAsst(asstInfo, reader.GetLine(), assts, lang)
+	}
+
+	if err := reader.Err(); err != nil {
+		blog.Errorf("read flat file failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		reader.Close()
+		return nil, err
+	}
+
+	if err := reader.Close(); err != nil {
+		blog.Errorf("close flat file reader failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, err
+	}
+
+	return asstInfo, nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making
+ * 蓝鲸智云 - 配置平台 (BlueKing - Configuration System) available.
+ * Copyright (C) 2017 THL A29 Limited,
+ * a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ * We undertake not to change the open source license (MIT license) applicable
+ * to the current version of the project delivered to anyone in the future.
+ */
+
+package importer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/web_server/service/excel/core"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFlatCell(t *testing.T) {
+	cell, err := getFlatCell(common.FieldTypeInt, json.Number("9007199254740993"))
+	require.NoError(t, err)
+	require.Equal(t, "9007199254740993", cell)
+
+	cell, err = getFlatCell(common.FieldTypeBool, true)
+	require.NoError(t, err)
+	require.Equal(t, "true", cell)
+
+	cell, err = getFlatCell(common.FieldTypeEnumMulti, []interface{}{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, "a\nb", cell)
+
+	cell, err = getFlatCell(common.FieldTypeUser, []interface{}{"admin", "user"})
+	require.NoError(t, err)
+	require.Equal(t, "admin,user", cell)
+
+	_, err = getFlatCell(common.FieldTypeSingleChar, map[string]interface{}{"a": "b"})
+	require.Error(t, err)
+}
+
+func TestGetFlatRows(t *testing.T) {
+	prop := &PropWithTable{ColProp: core.ColProp{ID: "name", PropertyType: common.FieldTypeSingleChar}}
+	rows, err := getFlatRows(prop, "")
+	require.NoError(t, err)
+	require.Nil(t, rows)
+
+	rows, err = getFlatRows(prop, "a")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"a"}}, rows)
+
+	table := &PropWithTable{
+		ColProp: core.ColProp{ID: "table", PropertyType: common.FieldTypeInnerTable},
+		subProperties: map[int]PropWithTable{
+			0: {ColProp: core.ColProp{ID: "key", PropertyType: common.FieldTypeSingleChar, ExcelColIndex: 0}},
+			1: {ColProp: core.ColProp{ID: "num", PropertyType: common.FieldTypeInt, ExcelColIndex: 1}},
+		},
+	}
+	expected := [][]string{{"a", "1"}, {"", "2"}}
+
+	rows, err = getFlatRows(table, `[{"key":"a","num":1},{"num":2}]`)
+	require.NoError(t, err)
+	require.Equal(t, expected, rows)
+
+	rows, err = getFlatRows(table, []interface{}{map[string]interface{}{"key": "a", "num": json.Number("1")},
+		map[string]interface{}{"num": json.Number("2")}})
+	require.NoError(t, err)
+	require.Equal(t, expected, rows)
+
+	_, err = getFlatRows(table, []interface{}{"a"})
+	require.Error(t, err)
+}
+
+func TestParseFlatAsst(t *testing.T) {
+	assts, err := parseFlatAsst("")
+	require.NoError(t, err)
+	require.Empty(t, assts)
+
+	expected := []core.FlatAsst{{AsstID: "host_run_app", Op: core.AsstOpAdd, SrcInst: "ip=127.0.0.1",
+		DstInst: "name=app"}}
+
+	assts, err = parseFlatAsst(`[{"bk_obj_asst_id":"host_run_app","op":"add","src":"ip=127.0.0.1",` +
+		`"dst":"name=app"}]`)
+	require.NoError(t, err)
+	require.Equal(t, expected, assts)
+
+	assts, err = parseFlatAsst([]interface{}{map[string]interface{}{"bk_obj_asst_id": "host_run_app", "op": "add",
+		"src": "ip=127.0.0.1", "dst": "name=app"}})
+	require.NoError(t, err)
+	require.Equal(t, expected, assts)
+
+	_, err = parseFlatAsst("{invalid")
+	require.Error(t, err)
+}
+
+func TestAddFlatAsst(t *testing.T) {
+	asstInfo := newFlatAsstInfo()
+	require.True(t, asstInfo.addAsst(1, "host_run_app", "add", "ip=127.0.0.1", "name=app"))
+	require.False(t, asstInfo.addAsst(2, "host_run_app", "", "ip=127.0.0.1", "name=app"))
+	require.Equal(t, []string{"host_run_app"}, asstInfo.asstIDs)
+	require.EqualValues(t, 1, asstInfo.statisticalMap["host_run_app"].Create)
+	require.Len(t, asstInfo.asstInfoMap, 1)
+}
@@ -69,6 +69,14 @@ func Param(param ImportParamI) BuildImporterFunc {
 
 // Clean close importer file io and remove excel file
 func (i *Importer) Clean() error {
+	if i.GetFlatFile() != nil {
+		if err := i.GetFlatFile().Clean(); err != nil {
+			blog.Errorf("remove flat file failed, err: %v, rid: %s", err, i.GetKit().Rid)
+			return err
+		}
+		return nil
+	}
+
 	if err := i.GetExcel().Close(); err != nil {
 		blog.Errorf("close excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return err
@@ -88,6 +96,10 @@ const (
 
 // Handle handle import request
 func (i *Importer) Handle() (mapstr.MapStr, error) {
+	if i.GetFlatFile() != nil {
+		return i.handleFlat()
+	}
+
 	// 获取association sheet中关联的模型以及关联关系的条数等信息
 	if i.param.GetOpType() == getAsstFlag {
 		result, err := i.getAsstInfo()
@@ -130,6 +142,11 @@ func (i *Importer) getAsstInfo() (mapstr.MapStr, error) {
 		return nil, err
 	}
 
+	return i.getAsstStatistics(asstInfo)
+}
+
+// getAsstStatistics 统计关联的模型以及关联关系的条数等信息
+func (i *Importer) getAsstStatistics(asstInfo *excelAsstInfo) (mapstr.MapStr, error) {
 	if asstInfo == nil || len(asstInfo.asstIDs) == 0 {
 		return mapstr.MapStr{"association": mapstr.New()}, nil
 	}
@@ -174,6 +191,49 @@ type excelAsstInfo struct {
 	asstInfoMap map[int]metadata.ExcelAssociation
 	// errMsg 关联关系sheet不合法的数据信息
 	errMsg []metadata.RowMsgData
+	// rowMap 关联关系数据的序号与其所在文件行号的对应关系，csv和json lines文件的一行可以有多条关联关系数据，所以需要记录
+	rowMap map[int]int
+}
+
+func newExcelAsstInfo() *excelAsstInfo {
+	return &excelAsstInfo{
+		asstIDs:        make([]string, 0),
+		statisticalMap: make(map[string]metadata.ObjectAsstIDStatisticsInfo),
+		asstInfoMap:    make(map[int]metadata.ExcelAssociation),
+		errMsg:         make([]metadata.RowMsgData, 0),
+	}
+}
+
+// addAsst 添加一条关联关系数据并进行统计，数据不完整时返回false
+func (a *excelAsstInfo) addAsst(idx int, asstID, op, srcInst, dstInst string) bool {
+	if asstID == "" || op == "" || srcInst == "" || dstInst == "" {
+		return false
+	}
+
+	statisticalInfo, ok := a.statisticalMap[asstID]
+	if !ok {
+		a.asstIDs = append(a.asstIDs, asstID)
+		statisticalInfo = metadata.ObjectAsstIDStatisticsInfo{}
+	}
+
+	operate := core.GetAsstOpFlag(core.AsstOp(op))
+	switch operate {
+	case metadata.ExcelAssociationOperateDelete:
+		statisticalInfo.Delete += 1
+	case metadata.ExcelAssociationOperateAdd:
+		statisticalInfo.Create += 1
+	}
+
+	a.statisticalMap[asstID] = statisticalInfo
+
+	a.asstInfoMap[idx] = metadata.ExcelAssociation{
+		ObjectAsstID: asstID,
+		Operate:      operate,
+		SrcPrimary:   srcInst,
+		DstPrimary:   dstInst,
+	}
+
+	return true
 }
 
 func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
@@ -184,10 +244,7 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 	}
 	lang := i.GetLang().CreateDefaultCCLanguageIf(httpheader.GetLanguage(i.GetKit().Header))
 
-	statisticalMap := make(map[string]metadata.ObjectAsstIDStatisticsInfo, 0)
-	asstIDs := make([]string, 0)
-	asstInfoMap := make(map[int]metadata.ExcelAssociation)
-	errMsg := make([]metadata.RowMsgData, 0)
+	result := newExcelAsstInfo()
 
 	for reader.Next() {
 		if reader.GetCurIdx() < core.AsstDataRowIdx {
@@ -202,44 +259,15 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 
 		if len(row) < core.AsstDstInstColIdx+1 {
 			msg := lang.Languagef("web_excel_row_handle_error", core.AsstSheet, reader.GetCurIdx()+1)
-			errMsg = append(errMsg, metadata.RowMsgData{Row: reader.GetCurIdx(), Msg: msg})
+			result.errMsg = append(result.errMsg, metadata.RowMsgData{Row: reader.GetCurIdx(), Msg: msg})
 			continue
 		}
 
-		asstID := row[core.AsstIDColIdx]
-		op := row[core.AsstOPColIdx]
-		srcInst := row[core.AsstSrcInstColIdx]
-		dstInst := row[core.AsstDstInstColIdx]
-
 		idx := reader.GetCurIdx() + 1
-
-		if asstID == "" || op == "" || srcInst == "" || dstInst == "" {
+		if !result.addAsst(idx, row[core.AsstIDColIdx], row[core.AsstOPColIdx], row[core.AsstSrcInstColIdx],
+			row[core.AsstDstInstColIdx]) {
 			msg := lang.Languagef("web_excel_row_handle_error", core.AsstSheet, idx)
-			errMsg = append(errMsg, metadata.RowMsgData{Row: idx, Msg: msg})
-			continue
-		}
-
-		statisticalInfo, ok := statisticalMap[asstID]
-		if !ok {
-			asstIDs = append(asstIDs, asstID)
-			statisticalInfo = metadata.ObjectAsstIDStatisticsInfo{}
-		}
-
-		operate := core.GetAsstOpFlag(core.AsstOp(op))
-		switch operate {
-		case metadata.ExcelAssociationOperateDelete:
-			statisticalInfo.Delete += 1
-		case metadata.ExcelAssociationOperateAdd:
-			statisticalInfo.Create += 1
-		}
-
-		statisticalMap[asstID] = statisticalInfo
-
-		asstInfoMap[idx] = metadata.ExcelAssociation{
-			ObjectAsstID: asstID,
-			Operate:      operate,
-			SrcPrimary:   srcInst,
-			DstPrimary:   dstInst,
+			result.errMsg = append(result.errMsg, metadata.RowMsgData{Row: idx, Msg: msg})
 		}
 	}
 
@@ -248,7 +276,6 @@ func (i *Importer) getAsstFromExcel() (*excelAsstInfo, error) {
 		return nil, err
 	}
 
-	result := &excelAsstInfo{asstIDs: asstIDs, statisticalMap: statisticalMap, asstInfoMap: asstInfoMap, errMsg: errMsg}
 	return result, nil
 }
 
@@ -369,24 +396,11 @@ func (i *Importer) importInst() (mapstr.MapStr, bool, error) {
 			continue
 		}
 
-		var errRes []string
-		insts, errRes = i.doSpecialOp(insts)
-		errMsg = append(errMsg, errRes...)
-		if len(insts) == 0 {
-			continue
-		}
-
-		req, err := i.param.BuildParam(insts)
+		successRes, errRes, err := i.importInstBatch(insts)
 		if err != nil {
-			blog.Errorf("get import instances parameter failed, err: %v, rid: %s", err, i.GetKit().Rid)
 			return nil, false, err
 		}
-		importParam := &core.ImportedParam{Language: i.GetLang(), ObjID: i.GetObjID(), Instances: insts,
-			Req: req, HandleType: i.param.GetHandleType()}
-		successRes, errRes := i.GetClient().HandleImportedInst(i.GetKit(), importParam)
-		if len(successRes) != 0 {
-			successMsg = append(successMsg, successRes...)
-		}
+		successMsg = append(successMsg, successRes...)
 		errMsg = append(errMsg, errRes...)
 
 		insts = make(map[int]map[string]interface{})
@@ -402,6 +416,27 @@ func (i *Importer) importInst() (mapstr.MapStr, bool, error) {
 	return result, len(errMsg) > 0, nil
 }
 
+// importInstBatch 导入一批实例，insts的key为实例所在的行号
+func (i *Importer) importInstBatch(insts map[int]map[string]interface{}) ([]int64, []string, error) {
+	var errMsg []string
+	insts, errMsg = i.doSpecialOp(insts)
+	if len(insts) == 0 {
+		return nil, errMsg, nil
+	}
+
+	req, err := i.param.BuildParam(insts)
+	if err != nil {
+		blog.Errorf("get import instances parameter failed, err: %v, rid: %s", err, i.GetKit().Rid)
+		return nil, nil, err
+	}
+	importParam := &core.ImportedParam{Language: i.GetLang(), ObjID: i.GetObjID(), Instances: insts,
+		Req: req, HandleType: i.param.GetHandleType()}
+	successRes, errRes := i.GetClient().HandleImportedInst(i.GetKit(), importParam)
+	errMsg = append(errMsg, errRes...)
+
+	return successRes, errMsg, nil
+}
+
 func (i *Importer) getExcelMsg(reader *excel.Reader) (*ExcelMsg, error) {
 	propertyMap, err := i.getPropertyMap(reader)
 	if err != nil {
@@ -905,6 +940,13 @@ func (i *Importer) importAssociation() (mapstr.MapStr, error) {
 		blog.Errorf("get association info from excel failed, err: %v, rid: %s", err, i.GetKit().Rid)
 		return nil, err
 	}
+
+	return i.importAsstInfo(asstInfo)
+}
+
+// importAsstInfo 导入指定的关联关系数据
+func (i *Importer) importAsstInfo(asstInfo *excelAsstInfo) (mapstr.MapStr, error) {
+	result := mapstr.New()
 	asstObjUniqueIDMap := i.param.GetAsstObjUniqueIDMap()
 
 	if asstInfo == nil || asstObjUniqueIDMap == nil {
@@ -957,6 +999,12 @@ func (i *Importer) importAssociation() (mapstr.MapStr, error) {
 	}
 
 	if len(asstResp.ErrMsgMap) != 0 {
+		// 关联关系数据的序号转换为其所在文件的行号
+		if asstInfo.rowMap != nil {
+			for idx, msg := range asstResp.ErrMsgMap {
+				asstResp.ErrMsgMap[idx].Row = asstInfo.rowMap[msg.Row]
+			}
+		}
 		result["error"] = asstResp.ErrMsgMap
 	}
 
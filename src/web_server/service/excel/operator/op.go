@@ -27,6 +27,7 @@ import (
 // BaseOp excel base operator
 type BaseOp struct {
 	excel    *excel.Excel
+	flatFile *excel.FlatFile
 	client   *core.Client
 	objID    string
 	kit      *rest.Kit
@@ -60,6 +61,19 @@ func FilePath(filePath string) BuildOpFunc {
 	}
 }
 
+// FlatFilePath set operator csv or json lines file path, the operator handles this file instead of excel
+func FlatFilePath(filePath string, format excel.FlatFormat) BuildOpFunc {
+	return func(op *BaseOp) error {
+		var err error
+		op.flatFile, err = excel.NewFlatFile(filePath, format)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
 // Client set client
 func Client(client *core.Client) BuildOpFunc {
 	return func(op *BaseOp) error {
@@ -97,6 +111,11 @@ func (op *BaseOp) GetExcel() *excel.Excel {
 	return op.excel
 }
 
+// GetFlatFile get csv or json lines file, it is nil when the operator handles excel
+func (op *BaseOp) GetFlatFile() *excel.FlatFile {
+	return op.flatFile
+}
+
 // GetClient get client
 func (op *BaseOp) GetClient() *core.Client {
 	return op.client
@@ -26,6 +26,7 @@ import (
 	"strings"
 	"time"
 
+	"configcenter/pkg/excel"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	httpheader "configcenter/src/common/http/header"
@@ -54,6 +55,10 @@ const (
 	FileTypeZip FileType = "zip"
 	// FileTypeYaml 文件格式yaml
 	FileTypeYaml FileType = "yaml"
+	// FileTypeCsv 文件格式csv
+	FileTypeCsv FileType = "csv"
+	// FileTypeJsonl 文件格式json lines
+	FileTypeJsonl FileType = "jsonl"
 )
 
 // ImportType 导入类型
@@ -72,7 +77,7 @@ const (
 
 // ImportTypeMap 导入类型与文件类型对应关系map
 var ImportTypeMap = map[ImportType][]FileType{
-	ImportTypeInst:       {FileTypeXlsx, FileTypeXls},
+	ImportTypeInst:       {FileTypeXlsx, FileTypeXls, FileTypeCsv, FileTypeJsonl},
 	ImportTypeObjectAttr: {FileTypeXlsx, FileTypeXls},
 	ImportTypeObject:     {FileTypeZip},
 	ImportTypeObjectYaml: {FileTypeYaml},
@@ -82,15 +87,21 @@ var ImportTypeMap = map[ImportType][]FileType{
 func (s *service) BuildTemplate(c *gin.Context) {
 	objID := c.Param(common.BKObjIDField)
 	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	fileType, err := getExportFileType(c)
+	if err != nil {
+		blog.Errorf("get template file type failed, err: %v, rid: %s", err, kit.Rid)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommParamsInvalid, formatKey))
+		return
+	}
 
 	// 1. 创建excel模版
 	dir := fmt.Sprintf("%s/template", webCommon.ResourcePath)
 	randNum := rand.Uint32()
-	filePath := fmt.Sprintf("%s/%stemplate-%d-%d.xlsx", dir, objID, time.Now().UnixNano(), randNum)
+	filePath := fmt.Sprintf("%s/%stemplate-%d-%d.%s", dir, objID, time.Now().UnixNano(), randNum, fileType)
 
 	client := &core.Client{ApiClient: s.apiCli}
-	baseOp, err := operator.NewBaseOp(operator.FilePath(filePath), operator.Client(client), operator.ObjID(objID),
-		operator.Kit(kit), operator.Language(s.engine.Language))
+	baseOp, err := operator.NewBaseOp(getFileOpFunc(filePath, fileType), operator.Client(client),
+		operator.ObjID(objID), operator.Kit(kit), operator.Language(s.engine.Language))
 	if err != nil {
 		blog.Errorf("create excel template failed, err: %v, rid: %s", err, kit.Rid)
 		c.JSON(http.StatusOK, metadata.BaseResp{Code: common.CCErrCommExcelTemplateFailed, ErrMsg: err.Error()})
@@ -118,9 +129,9 @@ func (s *service) BuildTemplate(c *gin.Context) {
 
 	// 2. 将excel模版文件返回，并删除临时文件
 	if objID == common.BKInnerObjIDHost {
-		addDownExcelHttpHeader(c, "bk_cmdb_import_host.xlsx")
+		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_import_host.%s", fileType))
 	} else {
-		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_inst_%s.xlsx", objID))
+		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_inst_%s.%s", objID, fileType))
 	}
 
 	c.File(filePath)
@@ -155,6 +166,13 @@ func (s *service) ExportProject(c *gin.Context) {
 
 func (s *service) exportInstFunc(c *gin.Context, objID string) {
 	kit := rest.NewKitFromHeader(c.Request.Header, s.engine.CCErr)
+	fileType, err := getExportFileType(c)
+	if err != nil {
+		blog.Errorf("get export file type failed, err: %v, rid: %s", err, kit.Rid)
+		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommParamsInvalid, formatKey))
+		return
+	}
+
 	input := exporter.GetExportParamInterface(objID)
 
 	if err := c.BindJSON(input); err != nil {
@@ -169,11 +187,11 @@ func (s *service) exportInstFunc(c *gin.Context, objID string) {
 
 	// 1. 初始化导出excel对象
 	dir := fmt.Sprintf("%s/export", webCommon.ResourcePath)
-	filePath := fmt.Sprintf("%s/%s", dir, fmt.Sprintf("%dinst.xlsx", time.Now().UnixNano()))
+	filePath := fmt.Sprintf("%s/%s", dir, fmt.Sprintf("%dinst.%s", time.Now().UnixNano(), fileType))
 
 	client := &core.Client{ApiClient: s.apiCli, GinCtx: c}
-	baseOp, err := operator.NewBaseOp(operator.FilePath(filePath), operator.Client(client), operator.ObjID(objID),
-		operator.Kit(kit), operator.Language(s.engine.Language))
+	baseOp, err := operator.NewBaseOp(getFileOpFunc(filePath, fileType), operator.Client(client),
+		operator.ObjID(objID), operator.Kit(kit), operator.Language(s.engine.Language))
 	if err != nil {
 		blog.Errorf("create excel template failed, err: %v, rid: %s", err, kit.Rid)
 		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrCommExcelTemplateFailed, err.Error()))
@@ -210,13 +228,13 @@ func (s *service) exportInstFunc(c *gin.Context, objID string) {
 	// 3. 将excel文件返回，并删除临时文件
 	switch objID {
 	case common.BKInnerObjIDHost:
-		addDownExcelHttpHeader(c, "bk_cmdb_export_host.xlsx")
+		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_host.%s", fileType))
 	case common.BKInnerObjIDApp:
-		addDownExcelHttpHeader(c, "bk_cmdb_export_biz.xlsx")
+		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_biz.%s", fileType))
 	case common.BKInnerObjIDProject:
-		addDownExcelHttpHeader(c, "bk_cmdb_export_project.xlsx")
+		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_project.%s", fileType))
 	default:
-		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_inst_%s.xlsx", objID))
+		addDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_inst_%s.%s", objID, fileType))
 	}
 
 	c.File(filePath)
@@ -292,7 +310,13 @@ func (s *service) importInstFunc(c *gin.Context, objID string, handleType core.H
 		}
 	}
 
-	filePath := fmt.Sprintf("%s/importinsts-%d-%d.xlsx", dir, time.Now().UnixNano(), rand.Uint32())
+	// xls文件与xlsx文件一样使用excel进行处理
+	fileType := getFileType(file.Filename)
+	if fileType == FileTypeXls {
+		fileType = FileTypeXlsx
+	}
+
+	filePath := fmt.Sprintf("%s/importinsts-%d-%d.%s", dir, time.Now().UnixNano(), rand.Uint32(), fileType)
 	err = c.SaveUploadedFile(file, filePath)
 	if err != nil {
 		c.JSON(http.StatusOK, getErrResp(kit, common.CCErrWebFileSaveFail))
@@ -300,8 +324,8 @@ func (s *service) importInstFunc(c *gin.Context, objID string, handleType core.H
 	}
 
 	client := &core.Client{ApiClient: s.apiCli}
-	baseOp, err := operator.NewBaseOp(operator.FilePath(filePath), operator.Client(client), operator.ObjID(objID),
-		operator.Kit(kit), operator.Language(s.engine.Language))
+	baseOp, err := operator.NewBaseOp(getFileOpFunc(filePath, fileType), operator.Client(client),
+		operator.ObjID(objID), operator.Kit(kit), operator.Language(s.engine.Language))
 	if err != nil {
 		blog.Errorf("create importer failed, err: %v, rid: %s", err, kit.Rid)
 		c.String(http.StatusInternalServerError, fmt.Errorf("create importer failed, err: %+v", err).Error())
@@ -455,9 +479,14 @@ func getErrResp(kit *rest.Kit, code int, params ...string) metadata.BaseResp {
 }
 
 func addDownExcelHttpHeader(c *gin.Context, name string) {
-	if strings.HasSuffix(name, ".xls") {
+	switch {
+	case strings.HasSuffix(name, ".xls"):
 		c.Header("Content-Type", "application/vnd.ms-excel")
-	} else {
+	case strings.HasSuffix(name, ".csv"):
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	case strings.HasSuffix(name, ".jsonl"):
+		c.Header("Content-Type", "application/x-ndjson")
+	default:
 		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 	}
 	c.Header("Accept-Ranges", "bytes")
@@ -474,13 +503,12 @@ func VerifyFileType(importType ImportType, fileName, rid string) error {
 		return errors.NewCCError(common.CCErrInvalidFileTypeFail, "file name is empty")
 	}
 
-	lastIndex := strings.LastIndex(fileName, ".")
-	if lastIndex == -1 {
+	fileType := getFileType(fileName)
+	if fileType == "" {
 		blog.Errorf("verify file type failed, file type is empty, rid: %s", rid)
 		return errors.NewCCError(common.CCErrInvalidFileTypeFail, "file type is empty")
 	}
 
-	fileType := fileName[lastIndex+1:]
 	fileTypeArr, exist := ImportTypeMap[importType]
 	if !exist {
 		blog.Errorf("verify file type failed, unknown import type %s, rid: %s", importType, rid)
@@ -488,10 +516,46 @@ func VerifyFileType(importType ImportType, fileName, rid string) error {
 	}
 
 	for _, ft := range fileTypeArr {
-		if ft == FileType(fileType) {
+		if ft == fileType {
 			return nil
 		}
 	}
 	blog.Errorf("verify file type failed, unknown import type %s, rid: %s", fileType, rid)
 	return errors.NewCCError(common.CCErrInvalidFileTypeFail, "file type is illegality")
 }
+
+// getFileType get file type from file name
+func getFileType(fileName string) FileType {
+	lastIndex := strings.LastIndex(fileName, ".")
+	if lastIndex == -1 {
+		return ""
+	}
+
+	return FileType(fileName[lastIndex+1:])
+}
+
+// formatKey 导出文件格式的请求参数，不设置时导出excel
+const formatKey = "format"
+
+// getExportFileType get the type of the exported file
+func getExportFileType(c *gin.Context) (FileType, error) {
+	fileType := FileType(c.Query(formatKey))
+	switch fileType {
+	case "":
+		return FileTypeXlsx, nil
+	case FileTypeXlsx, FileTypeCsv, FileTypeJsonl:
+		return fileType, nil
+	default:
+		return "", fmt.Errorf("file type %s is invalid", fileType)
+	}
+}
+
+// getFileOpFunc get the operator function which sets the file to be handled, csv and json lines file is handled
+// record by record, and the others are handled as excel
+func getFileOpFunc(filePath string, fileType FileType) operator.BuildOpFunc {
+	if excel.IsFlatFormat(string(fileType)) {
+		return operator.FlatFilePath(filePath, excel.FlatFormat(fileType))
+	}
+
+	return operator.FilePath(filePath)
+}
@@ -13,6 +13,8 @@
 package service
 
 import (
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -29,18 +31,39 @@ import (
 
 // LogOutUser log out user
 func (s *Service) LogOutUser(c *gin.Context) {
-	session := sessions.Default(c)
-	session.Clear()
+	rid := httpheader.GetRid(c.Request.Header)
 	c.Request.URL.Path = ""
 	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
-	loginURL := userManger.GetLoginUrl(c)
+	// get logout url before the session is cleared, the login system may need the login info to log out
+	logoutURL := userManger.GetLogoutUrl(c)
+
+	session := sessions.Default(c)
+	session.Clear()
+	if err := session.Save(); err != nil {
+		blog.Warnf("save session failed, err: %s, rid: %s", err.Error(), rid)
+	}
+
 	ret := metadata.LogoutResult{}
 	ret.BaseResp.Result = true
-	ret.Data.LogoutURL = loginURL
+	ret.Data.LogoutURL = logoutURL
 	c.JSON(200, ret)
 	return
 }
 
+// LoginCallback handle the callback request of the external login system after the user logs in
+func (s *Service) LoginCallback(c *gin.Context) {
+	rid := httpheader.GetRid(c.Request.Header)
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
+	redirectURL, err := userManger.HandleLoginCallback(c)
+	if err != nil {
+		blog.Errorf("handle login callback failed, err: %v, rid: %s", err, rid)
+		c.JSON(http.StatusUnauthorized, gin.H{"status": fmt.Sprintf("login failed, err: %v", err)})
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
 // IsLogin user is login
 func (s *Service) IsLogin(c *gin.Context) {
 	user := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
@@ -78,7 +101,22 @@ func (s *Service) LoginUser(c *gin.Context) {
 		c.HTML(200, "login.html", gin.H{
 			"error": defErr.CCError(common.CCErrWebNeedFillinUsernamePasswd).Error(),
 		})
+		return
 	}
+
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
+	if userManger.SupportPasswordLogin() {
+		if rawErr := userManger.AuthenticateUser(c, userName, password); rawErr != nil {
+			blog.Errorf("authenticate user %s failed, err code: %d, rid: %s", userName, rawErr.ErrCode, rid)
+			c.HTML(200, "login.html", gin.H{
+				"error": rawErr.ToCCError(defErr).Error(),
+			})
+			return
+		}
+		c.Redirect(302, s.loginRedirectURL(c))
+		return
+	}
+
 	userInfo, err := cc.String("webServer.session.userInfo")
 	if err != nil {
 		c.HTML(200, "login.html", gin.H{
@@ -103,15 +141,8 @@ func (s *Service) LoginUser(c *gin.Context) {
 			if err := session.Save(); err != nil {
 				blog.Warnf("save session failed, err: %s, rid: %s", err.Error(), rid)
 			}
-			userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.ApiCli)
 			userManger.LoginUser(c)
-			var redirectURL string
-			if c.Query("c_url") != "" {
-				redirectURL = c.Query("c_url")
-			} else {
-				redirectURL = s.Config.Site.DomainUrl
-			}
-			c.Redirect(302, redirectURL)
+			c.Redirect(302, s.loginRedirectURL(c))
 			return
 		}
 	}
@@ -120,3 +151,11 @@ func (s *Service) LoginUser(c *gin.Context) {
 	})
 	return
 }
+
+// loginRedirectURL get the url to redirect to after the user logs in
+func (s *Service) loginRedirectURL(c *gin.Context) string {
+	if c.Query("c_url") != "" {
+		return c.Query("c_url")
+	}
+	return s.Config.Site.DomainUrl
+}
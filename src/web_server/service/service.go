@@ -101,6 +101,9 @@ func (s *Service) WebService() *gin.Engine {
 	// resource count, only for ui
 	s.initResourceCount(ws)
 
+	// personal api token management, only for ui
+	s.initAPIToken(ws)
+
 	c := &capability.Capability{
 		Ws:        ws,
 		Engine:    s.Engine,
@@ -135,6 +138,7 @@ func (s *Service) initService(ws *gin.Engine) {
 	ws.GET("/login", s.Login)
 	ws.GET("/is_login", s.IsLogin)
 	ws.POST("/login", s.LoginUser)
+	ws.GET("/login/callback", s.LoginCallback)
 	ws.POST("/object/exportmany", s.BatchExportObject)
 	ws.POST("/object/importmany/analysis", s.BatchImportObjectAnalysis)
 	ws.POST("/object/importmany", s.BatchImportObject)